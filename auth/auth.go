@@ -0,0 +1,85 @@
+// Package auth answers the HTTP authentication schemes which take several
+// round trips, such as NTLM and Negotiate (SPNEGO/Kerberos), which are used
+// by intranet servers and corporate proxies. Set the authenticators of a
+// browser with Browser.SetAuthenticators():
+//
+//	bow.SetAuthenticators(auth.NewNTLM(credentials.Map{
+//		"intranet.example.com": {Username: `CORP\jdoe`, Password: "secret"},
+//	}))
+//
+// The browser answers the 401 responses of servers, and the 407 responses of
+// HTTP proxies, which offer the scheme of an authenticator.
+//
+// The package also signs requests, with AWS Signature Version 4 or a HMAC of
+// their content, see RequestSigner.
+package auth
+
+import (
+	"strings"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+// MaxRounds is the number of requests sent to answer the challenges of a
+// single authentication.
+var MaxRounds = 3
+
+// Authenticator answers the challenges of an authentication scheme.
+type Authenticator interface {
+	// Scheme returns the name of the scheme in the WWW-Authenticate and
+	// Proxy-Authenticate headers, eg "NTLM".
+	Scheme() string
+
+	// Start starts an authentication with the host, which is the server or
+	// the proxy asking for it. A nil Handshake means the authenticator has
+	// no credentials for the host, and the response asking for them is
+	// returned as is.
+	Start(host string) (Handshake, error)
+}
+
+// Handshake is an authentication in progress.
+type Handshake interface {
+	// Next returns the token sent in the next request, given the token of
+	// the last challenge, which is nil when the challenge had none.
+	Next(challenge []byte) ([]byte, error)
+}
+
+// TokenFunc returns the Negotiate token for the service principal name of a
+// host, eg "HTTP/intranet.example.com", given the token of the challenge.
+type TokenFunc func(spn string, challenge []byte) ([]byte, error)
+
+// Negotiate is an Authenticator for the Negotiate scheme (RFC 4559), whose
+// tokens are created by a SPNEGO implementation, eg a Kerberos client.
+type Negotiate struct {
+	// Token creates the tokens.
+	Token TokenFunc
+}
+
+// NewNegotiate creates and returns a *Negotiate getting its tokens from fn.
+func NewNegotiate(fn TokenFunc) *Negotiate {
+	return &Negotiate{Token: fn}
+}
+
+// Scheme returns "Negotiate".
+func (n *Negotiate) Scheme() string {
+	return "Negotiate"
+}
+
+// Start starts an authentication with the host.
+func (n *Negotiate) Start(host string) (Handshake, error) {
+	if n.Token == nil {
+		return nil, errors.New("The Negotiate authenticator has no token function.")
+	}
+	return &negotiateHandshake{spn: "HTTP/" + strings.ToLower(host), token: n.Token}, nil
+}
+
+// negotiateHandshake passes the challenges to the token function.
+type negotiateHandshake struct {
+	spn   string
+	token TokenFunc
+}
+
+// Next returns the token for the challenge.
+func (h *negotiateHandshake) Next(challenge []byte) ([]byte, error) {
+	return h.token(h.spn, challenge)
+}
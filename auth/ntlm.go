@@ -0,0 +1,241 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/binary"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/lostinblue/surf/credentials"
+	"github.com/lostinblue/surf/errors"
+)
+
+// NTLM negotiation flags.
+const (
+	ntlmUnicode         = 0x00000001
+	ntlmOEM             = 0x00000002
+	ntlmRequestTarget   = 0x00000004
+	ntlmNTLM            = 0x00000200
+	ntlmAlwaysSign      = 0x00008000
+	ntlmExtendedSession = 0x00080000
+	ntlmTargetInfo      = 0x00800000
+	ntlm128             = 0x20000000
+	ntlm56              = 0x80000000
+)
+
+// ntlmSignature starts every NTLM message.
+var ntlmSignature = []byte("NTLMSSP\x00")
+
+// NTLM is an Authenticator for the NTLM scheme, using NTLMv2 responses.
+//
+// The user name of the credentials may include the domain, as in
+// `CORP\jdoe`. The connection must be kept open between the requests of the
+// handshake, which the browser does unless keep-alives are disabled.
+type NTLM struct {
+	// Credentials looks up the credentials of the hosts.
+	Credentials credentials.Store
+
+	// Domain is the domain of the user names which do not include one.
+	Domain string
+
+	// Workstation is the name of the computer sent to the server, if any.
+	Workstation string
+}
+
+// NewNTLM creates and returns a *NTLM looking up credentials in the store.
+func NewNTLM(store credentials.Store) *NTLM {
+	return &NTLM{Credentials: store}
+}
+
+// Scheme returns "NTLM".
+func (n *NTLM) Scheme() string {
+	return "NTLM"
+}
+
+// Start starts an authentication with the host, or returns a nil Handshake
+// when the store has no credentials for it.
+func (n *NTLM) Start(host string) (Handshake, error) {
+	if n.Credentials == nil {
+		return nil, errors.New("The NTLM authenticator has no credential store.")
+	}
+	c, err := n.Credentials.Lookup(strings.ToLower(host))
+	if err != nil {
+		return nil, err
+	}
+	if c == nil {
+		return nil, nil
+	}
+	h := &ntlmHandshake{user: c.Username, password: c.Password, domain: n.Domain, workstation: n.Workstation}
+	if i := strings.Index(h.user, `\`); i >= 0 {
+		h.domain, h.user = h.user[:i], h.user[i+1:]
+	}
+	return h, nil
+}
+
+// ntlmHandshake sends the negotiate message, then answers the challenge
+// message of the server with an authenticate message.
+type ntlmHandshake struct {
+	user, password      string
+	domain, workstation string
+	step                int
+}
+
+// Next returns the message answering the challenge.
+func (h *ntlmHandshake) Next(challenge []byte) ([]byte, error) {
+	h.step++
+	switch h.step {
+	case 1:
+		return ntlmNegotiate(), nil
+	case 2:
+		if challenge == nil {
+			return nil, errors.New("The server did not send a NTLM challenge.")
+		}
+		return h.authenticate(challenge)
+	}
+	return nil, errors.New("The server rejected the NTLM credentials of '%s'.", h.user)
+}
+
+// ntlmNegotiate returns the negotiate message, without domain and workstation.
+func ntlmNegotiate() []byte {
+	msg := make([]byte, 32)
+	copy(msg, ntlmSignature)
+	binary.LittleEndian.PutUint32(msg[8:], 1)
+	binary.LittleEndian.PutUint32(msg[12:], ntlmUnicode|ntlmOEM|ntlmRequestTarget|ntlmNTLM|
+		ntlmAlwaysSign|ntlmExtendedSession|ntlm128|ntlm56)
+	return msg
+}
+
+// ntlmChallenge is the content of a challenge message.
+type ntlmChallenge struct {
+	flags      uint32
+	challenge  []byte
+	targetInfo []byte
+}
+
+// parseNTLMChallenge reads a challenge message.
+func parseNTLMChallenge(msg []byte) (*ntlmChallenge, error) {
+	if len(msg) < 32 || !bytes.Equal(msg[:8], ntlmSignature) || binary.LittleEndian.Uint32(msg[8:]) != 2 {
+		return nil, errors.New("Invalid NTLM challenge message.")
+	}
+	c := &ntlmChallenge{
+		flags:     binary.LittleEndian.Uint32(msg[20:]),
+		challenge: msg[24:32],
+	}
+	if len(msg) >= 48 && c.flags&ntlmTargetInfo != 0 {
+		size := int(binary.LittleEndian.Uint16(msg[40:]))
+		offset := int(binary.LittleEndian.Uint32(msg[44:]))
+		if offset+size > len(msg) {
+			return nil, errors.New("Invalid NTLM challenge message.")
+		}
+		c.targetInfo = msg[offset : offset+size]
+	}
+	return c, nil
+}
+
+// authenticate returns the authenticate message answering the challenge.
+func (h *ntlmHandshake) authenticate(msg []byte) ([]byte, error) {
+	c, err := parseNTLMChallenge(msg)
+	if err != nil {
+		return nil, err
+	}
+	clientChallenge := make([]byte, 8)
+	if _, err := rand.Read(clientChallenge); err != nil {
+		return nil, err
+	}
+	timestamp := ntlmTimestamp(c.targetInfo)
+	if timestamp == nil {
+		timestamp = fileTime(time.Now())
+	}
+
+	key := ntowfv2(h.user, h.password, h.domain)
+	nt := ntlmv2Response(key, c.challenge, clientChallenge, timestamp, c.targetInfo)
+	lm := lmv2Response(key, c.challenge, clientChallenge)
+
+	encode, flags := func(s string) []byte { return []byte(s) }, c.flags
+	if c.flags&ntlmUnicode != 0 {
+		encode, flags = utf16le, c.flags&^ntlmOEM
+	}
+	fields := [][]byte{lm, nt, encode(h.domain), encode(h.user), encode(h.workstation), nil}
+
+	out := make([]byte, 64)
+	copy(out, ntlmSignature)
+	binary.LittleEndian.PutUint32(out[8:], 3)
+	for i, f := range fields {
+		pos := 12 + i*8
+		binary.LittleEndian.PutUint16(out[pos:], uint16(len(f)))
+		binary.LittleEndian.PutUint16(out[pos+2:], uint16(len(f)))
+		binary.LittleEndian.PutUint32(out[pos+4:], uint32(len(out)))
+		out = append(out, f...)
+	}
+	binary.LittleEndian.PutUint32(out[60:], flags)
+	return out, nil
+}
+
+// ntowfv2 returns the NTLMv2 key of the user.
+func ntowfv2(user, password, domain string) []byte {
+	return hmacMD5(md4(utf16le(password)), utf16le(strings.ToUpper(user)+domain))
+}
+
+// ntlmv2Response returns the NTLMv2 response to the server challenge.
+func ntlmv2Response(key, serverChallenge, clientChallenge, timestamp, targetInfo []byte) []byte {
+	var blob []byte
+	blob = append(blob, 1, 1, 0, 0, 0, 0, 0, 0)
+	blob = append(blob, timestamp...)
+	blob = append(blob, clientChallenge...)
+	blob = append(blob, 0, 0, 0, 0)
+	blob = append(blob, targetInfo...)
+	blob = append(blob, 0, 0, 0, 0)
+	proof := hmacMD5(key, append(append([]byte(nil), serverChallenge...), blob...))
+	return append(proof, blob...)
+}
+
+// lmv2Response returns the LMv2 response to the server challenge.
+func lmv2Response(key, serverChallenge, clientChallenge []byte) []byte {
+	mac := hmacMD5(key, append(append([]byte(nil), serverChallenge...), clientChallenge...))
+	return append(mac, clientChallenge...)
+}
+
+// ntlmTimestamp returns the MsvAvTimestamp of the target information, or nil.
+func ntlmTimestamp(info []byte) []byte {
+	for len(info) >= 4 {
+		id := binary.LittleEndian.Uint16(info)
+		size := int(binary.LittleEndian.Uint16(info[2:]))
+		if id == 0 || len(info) < 4+size {
+			break
+		}
+		if id == 7 && size == 8 {
+			return info[4:12]
+		}
+		info = info[4+size:]
+	}
+	return nil
+}
+
+// fileTime returns the time as a Windows FILETIME, the number of 100
+// nanoseconds intervals since January 1, 1601.
+func fileTime(t time.Time) []byte {
+	ft := make([]byte, 8)
+	binary.LittleEndian.PutUint64(ft, uint64(t.UnixNano()/100+116444736000000000))
+	return ft
+}
+
+// hmacMD5 returns the HMAC-MD5 of the data.
+func hmacMD5(key, data []byte) []byte {
+	mac := hmac.New(md5.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// utf16le encodes the string in UTF-16, little endian.
+func utf16le(s string) []byte {
+	codes := utf16.Encode([]rune(s))
+	b := make([]byte, len(codes)*2)
+	for i, c := range codes {
+		binary.LittleEndian.PutUint16(b[i*2:], c)
+	}
+	return b
+}
@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"testing"
+
+	"github.com/lostinblue/surf/credentials"
+)
+
+func TestMD4(t *testing.T) {
+	// Test vectors of RFC 1320.
+	tests := map[string]string{
+		"":               "31d6cfe0d16ae931b73c59d7e0c089c0",
+		"abc":            "a448017aaf21d8525fc10ae87aa6729d",
+		"message digest": "d9130a8164549fe818874806e1c7014b",
+		"12345678901234567890123456789012345678901234567890123456789012345678901234567890": "e33b4ddc9c38f2199c3e7b164fcc0536",
+	}
+	for in, want := range tests {
+		if got := hex.EncodeToString(md4([]byte(in))); got != want {
+			t.Errorf("Expected MD4 %s for '%s', got %s.", want, in, got)
+		}
+	}
+}
+
+func TestNTLMv2(t *testing.T) {
+	// Test vectors of MS-NLMP, section 4.2.
+	unhex := func(s string) []byte {
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+	if got := hex.EncodeToString(md4(utf16le("Password"))); got != "a4f49c406510bdcab6824ee7c30fd852" {
+		t.Errorf("Unexpected NT hash %s.", got)
+	}
+	key := ntowfv2("User", "Password", "Domain")
+	if got := hex.EncodeToString(key); got != "0c868a403bfd7a93a3001ef22ef02e3f" {
+		t.Errorf("Unexpected NTLMv2 key %s.", got)
+	}
+
+	serverChallenge := unhex("0123456789abcdef")
+	clientChallenge := unhex("aaaaaaaaaaaaaaaa")
+	if got := hex.EncodeToString(lmv2Response(key, serverChallenge, clientChallenge)); got != "86c35097ac9cec102554764a57cccc19aaaaaaaaaaaaaaaa" {
+		t.Errorf("Unexpected LMv2 response %s.", got)
+	}
+	targetInfo := unhex("02000c0044006f006d00610069006e00" + "01000c00530065007200760065007200" + "00000000")
+	nt := ntlmv2Response(key, serverChallenge, clientChallenge, make([]byte, 8), targetInfo)
+	if got := hex.EncodeToString(nt[:16]); got != "68cd0ab851e51c96aabc927bebef6a1c" {
+		t.Errorf("Unexpected NTLMv2 proof %s.", got)
+	}
+}
+
+// challengeMessage returns a challenge message with the target information.
+func challengeMessage(challenge, targetInfo []byte) []byte {
+	msg := make([]byte, 48)
+	copy(msg, ntlmSignature)
+	binary.LittleEndian.PutUint32(msg[8:], 2)
+	binary.LittleEndian.PutUint32(msg[20:], ntlmUnicode|ntlmNTLM|ntlmTargetInfo)
+	copy(msg[24:], challenge)
+	binary.LittleEndian.PutUint16(msg[40:], uint16(len(targetInfo)))
+	binary.LittleEndian.PutUint16(msg[42:], uint16(len(targetInfo)))
+	binary.LittleEndian.PutUint32(msg[44:], 48)
+	return append(msg, targetInfo...)
+}
+
+// messageField returns a field of an authenticate message.
+func messageField(msg []byte, i int) []byte {
+	pos := 12 + i*8
+	size := int(binary.LittleEndian.Uint16(msg[pos:]))
+	offset := int(binary.LittleEndian.Uint32(msg[pos+4:]))
+	return msg[offset : offset+size]
+}
+
+func TestNTLMHandshake(t *testing.T) {
+	n := NewNTLM(credentials.Map{"intranet": {Username: `CORP\jdoe`, Password: "secret"}})
+	if hs, err := n.Start("internet"); hs != nil || err != nil {
+		t.Errorf("Expected no handshake for a host without credentials, got %v (%v).", hs, err)
+	}
+	hs, err := n.Start("INTRANET")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	negotiate, err := hs.Next(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(negotiate, ntlmSignature) || binary.LittleEndian.Uint32(negotiate[8:]) != 1 {
+		t.Fatalf("Expected a negotiate message, got %x.", negotiate)
+	}
+
+	challenge := []byte("8bytes!!")
+	targetInfo := []byte{7, 0, 8, 0, 1, 2, 3, 4, 5, 6, 7, 8, 0, 0, 0, 0}
+	msg, err := hs.Next(challengeMessage(challenge, targetInfo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if binary.LittleEndian.Uint32(msg[8:]) != 3 {
+		t.Fatalf("Expected an authenticate message, got %x.", msg)
+	}
+	if domain, user := messageField(msg, 2), messageField(msg, 3); !bytes.Equal(domain, utf16le("CORP")) || !bytes.Equal(user, utf16le("jdoe")) {
+		t.Errorf("Expected the domain and user of the credentials, got %q %q.", domain, user)
+	}
+	nt := messageField(msg, 1)
+	if !bytes.Equal(nt[24:32], targetInfo[4:12]) {
+		t.Errorf("Expected the timestamp of the server, got %x.", nt[24:32])
+	}
+	proof := hmacMD5(ntowfv2("jdoe", "secret", "CORP"), append(append([]byte(nil), challenge...), nt[16:]...))
+	if !bytes.Equal(proof, nt[:16]) {
+		t.Errorf("Expected the proof %x, got %x.", proof, nt[:16])
+	}
+
+	if _, err := hs.Next(nil); err == nil {
+		t.Error("Expected an error once the credentials are rejected.")
+	}
+	hs, _ = n.Start("intranet")
+	hs.Next(nil)
+	if _, err := hs.Next([]byte("NTLMSSP\x00garbage")); err == nil {
+		t.Error("Expected an error for an invalid challenge.")
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	if _, err := (&Negotiate{}).Start("host"); err == nil {
+		t.Error("Expected an error without a token function.")
+	}
+	var spn string
+	n := NewNegotiate(func(s string, challenge []byte) ([]byte, error) {
+		spn = s
+		return append([]byte("token:"), challenge...), nil
+	})
+	hs, err := n.Start("Intranet.example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token, _ := hs.Next([]byte("x")); string(token) != "token:x" || spn != "HTTP/intranet.example.com" {
+		t.Errorf("Expected the token of the function for the SPN, got '%s' for '%s'.", token, spn)
+	}
+}
@@ -0,0 +1,183 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+// RequestSigner signs requests, eg by adding a header with a signature of the
+// request. Set it with Browser.SignRequestsWith(). The browser calls it right
+// before sending each request, once its headers are final, including the
+// requests of redirects.
+type RequestSigner interface {
+	// Sign signs the request.
+	Sign(req *http.Request) error
+}
+
+// HostSigner is a RequestSigner limited to some hosts. The browser signs the
+// redirects to another host than the one of the first request only with the
+// signers listing the host explicitly, so the credentials of a signer are
+// not used for the hosts a server redirects to.
+type HostSigner interface {
+	RequestSigner
+
+	// SignsHost returns a boolean value indicating whether the host is
+	// listed explicitly by the signer.
+	SignsHost(host string) bool
+}
+
+// SignerFunc is a RequestSigner calling a function.
+type SignerFunc func(req *http.Request) error
+
+// Sign calls the function.
+func (fn SignerFunc) Sign(req *http.Request) error {
+	return fn(req)
+}
+
+// DefaultSignatureHeader is the header of the HMAC signatures.
+var DefaultSignatureHeader = "X-Signature"
+
+// HMAC is a RequestSigner adding the HMAC of the method, the path, the
+// query and the body of the requests to a header, as required by many APIs.
+//
+// The signed message is, by default, the method, the path and query, the
+// timestamp and the body, separated by new lines:
+//
+//	POST
+//	/v1/orders?page=2
+//	1700000000
+//	{"item":"book"}
+type HMAC struct {
+	// Key is the secret key.
+	Key []byte
+
+	// Hash creates the hash of the HMAC. Defaults to sha256.New.
+	Hash func() hash.Hash
+
+	// Header is the header of the signature. Defaults to
+	// DefaultSignatureHeader.
+	Header string
+
+	// Prefix is written before the signature, eg "sha256=".
+	Prefix string
+
+	// Base64 encodes the signature with base64 instead of hexadecimal.
+	Base64 bool
+
+	// TimestampHeader is the header the Unix time of the request is set in,
+	// eg "X-Timestamp". The timestamp is signed with the request when it's
+	// set, and left empty otherwise.
+	TimestampHeader string
+
+	// Message returns the message signed for the request, given its body,
+	// and replaces the default message.
+	Message func(req *http.Request, body []byte) string
+
+	// Hosts are the patterns of the hosts whose requests are signed, in the
+	// syntax of path.Match(). All requests are signed when it's empty, except
+	// the redirects to another host.
+	Hosts []string
+}
+
+// NewHMAC creates and returns a *HMAC signing with the key and SHA-256.
+func NewHMAC(key []byte) *HMAC {
+	return &HMAC{Key: key}
+}
+
+// Sign sets the signature header of the request. The body is read to be
+// signed, and replaced when it can't be read again.
+func (h *HMAC) Sign(req *http.Request) error {
+	if !matchHost(h.Hosts, req.URL.Hostname()) {
+		return nil
+	}
+	if len(h.Key) == 0 {
+		return errors.New("The HMAC signer has no key.")
+	}
+	body, err := RequestBody(req)
+	if err != nil {
+		return err
+	}
+	var timestamp string
+	if h.TimestampHeader != "" {
+		timestamp = strconv.FormatInt(now().Unix(), 10)
+		req.Header.Set(h.TimestampHeader, timestamp)
+	}
+
+	message := strings.Join([]string{req.Method, req.URL.RequestURI(), timestamp, string(body)}, "\n")
+	if h.Message != nil {
+		message = h.Message(req, body)
+	}
+	fn := h.Hash
+	if fn == nil {
+		fn = sha256.New
+	}
+	mac := hmac.New(fn, h.Key)
+	mac.Write([]byte(message))
+	sum := mac.Sum(nil)
+
+	signature := hex.EncodeToString(sum)
+	if h.Base64 {
+		signature = base64.StdEncoding.EncodeToString(sum)
+	}
+	header := h.Header
+	if header == "" {
+		header = DefaultSignatureHeader
+	}
+	req.Header.Set(header, h.Prefix+signature)
+	return nil
+}
+
+// SignsHost returns a boolean value indicating whether the host matches one
+// of the Hosts.
+func (h *HMAC) SignsHost(host string) bool {
+	return len(h.Hosts) > 0 && matchHost(h.Hosts, host)
+}
+
+// RequestBody returns the body of the request, for signers. Bodies which
+// can't be read again are read, and replaced by a copy.
+func RequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return ioutil.ReadAll(body)
+	}
+	data, err := ioutil.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader(data))
+	return data, nil
+}
+
+// matchHost returns a boolean value indicating whether the host matches one
+// of the patterns, or there are none.
+func matchHost(patterns []string, host string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	host = strings.ToLower(host)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToLower(pattern), host); ok {
+			return true
+		}
+	}
+	return false
+}
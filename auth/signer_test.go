@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHMAC(t *testing.T) {
+	defer func() { now = time.Now }()
+	now = func() time.Time { return time.Unix(1700000000, 0) }
+
+	h := NewHMAC([]byte("secret"))
+	h.TimestampHeader = "X-Timestamp"
+	req, _ := http.NewRequest("POST", "https://api.example.com/v1/orders?page=2", strings.NewReader(`{"item":"book"}`))
+	if err := h.Sign(req); err != nil {
+		t.Fatal(err)
+	}
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte("POST\n/v1/orders?page=2\n1700000000\n{\"item\":\"book\"}"))
+	if got, want := req.Header.Get("X-Signature"), hex.EncodeToString(mac.Sum(nil)); got != want {
+		t.Errorf("Expected the signature %s, got %s.", want, got)
+	}
+	if got := req.Header.Get("X-Timestamp"); got != "1700000000" {
+		t.Errorf("Expected the timestamp header, got '%s'.", got)
+	}
+	if body, _ := ioutil.ReadAll(req.Body); string(body) != `{"item":"book"}` {
+		t.Errorf("Expected the body to be left readable, got '%s'.", body)
+	}
+
+	h = &HMAC{
+		Key:     []byte("secret"),
+		Hash:    sha1.New,
+		Header:  "X-Hub-Signature",
+		Prefix:  "sha1=",
+		Base64:  true,
+		Message: func(req *http.Request, body []byte) string { return req.URL.Path },
+		Hosts:   []string{"*.example.com"},
+	}
+	req, _ = http.NewRequest("GET", "https://api.example.com/hook", nil)
+	if err := h.Sign(req); err != nil {
+		t.Fatal(err)
+	}
+	if got := req.Header.Get("X-Hub-Signature"); got != "sha1=YLi0c5CVfbLaCXuTrBN5G8QM+vc=" {
+		t.Errorf("Unexpected custom signature '%s'.", got)
+	}
+	req, _ = http.NewRequest("GET", "https://example.org/hook", nil)
+	if err := h.Sign(req); err != nil || req.Header.Get("X-Hub-Signature") != "" {
+		t.Errorf("Expected the requests to other hosts not to be signed (%v).", err)
+	}
+	if !h.SignsHost("api.example.com") || h.SignsHost("example.org") || NewHMAC([]byte("secret")).SignsHost("example.org") {
+		t.Error("Expected only the listed hosts to be signed explicitly.")
+	}
+
+	if err := (&HMAC{}).Sign(req); err == nil {
+		t.Error("Expected an error without a key.")
+	}
+}
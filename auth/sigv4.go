@@ -0,0 +1,208 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+// now returns the time requests are signed at.
+var now = time.Now
+
+// SigV4 is a RequestSigner signing requests with AWS Signature Version 4, as
+// expected by S3 and the other AWS APIs.
+type SigV4 struct {
+	// AccessKeyID and SecretAccessKey are the credentials of the AWS account.
+	AccessKeyID     string
+	SecretAccessKey string
+
+	// SessionToken is the token of temporary credentials, if any.
+	SessionToken string
+
+	// Region and Service are the region and the name of the service the
+	// requests are sent to, eg "us-east-1" and "s3".
+	Region  string
+	Service string
+
+	// Hosts are the patterns of the hosts whose requests are signed, in the
+	// syntax of path.Match(), eg "*.amazonaws.com". All requests are signed
+	// when it's empty, except the redirects to another host.
+	Hosts []string
+}
+
+// NewSigV4 creates and returns a *SigV4 with the credentials.
+func NewSigV4(accessKeyID, secretAccessKey, region, service string) *SigV4 {
+	return &SigV4{
+		AccessKeyID:     accessKeyID,
+		SecretAccessKey: secretAccessKey,
+		Region:          region,
+		Service:         service,
+	}
+}
+
+// NewSigV4FromEnv creates and returns a *SigV4 for the service, with the
+// credentials and the region of the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY,
+// AWS_SESSION_TOKEN and AWS_REGION (or AWS_DEFAULT_REGION) environment
+// variables.
+func NewSigV4FromEnv(service string) (*SigV4, error) {
+	s := NewSigV4(os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY"), os.Getenv("AWS_REGION"), service)
+	s.SessionToken = os.Getenv("AWS_SESSION_TOKEN")
+	if s.Region == "" {
+		s.Region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if s.AccessKeyID == "" || s.SecretAccessKey == "" {
+		return nil, errors.New("The AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables are not set.")
+	}
+	if s.Region == "" {
+		return nil, errors.New("The AWS_REGION environment variable is not set.")
+	}
+	return s, nil
+}
+
+// Sign adds the X-Amz-Date and Authorization headers to the request, and
+// X-Amz-Content-Sha256 for S3. The body is read to be hashed, and replaced.
+// Requests to the hosts which do not match Hosts are left unchanged.
+func (s *SigV4) Sign(req *http.Request) error {
+	if !matchHost(s.Hosts, req.URL.Hostname()) {
+		return nil
+	}
+	payload, err := payloadHash(req)
+	if err != nil {
+		return err
+	}
+	t := now().UTC()
+	date := t.Format("20060102")
+	req.Header.Set("X-Amz-Date", t.Format("20060102T150405Z"))
+	if s.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.SessionToken)
+	}
+	if s.Service == "s3" {
+		req.Header.Set("X-Amz-Content-Sha256", payload)
+	}
+
+	headers, signed := s.canonicalHeaders(req)
+	canonical := strings.Join([]string{
+		req.Method,
+		s.canonicalPath(req),
+		canonicalQuery(req),
+		headers,
+		signed,
+		payload,
+	}, "\n")
+	scope := strings.Join([]string{date, s.Region, s.Service, "aws4_request"}, "/")
+	toSign := "AWS4-HMAC-SHA256\n" + t.Format("20060102T150405Z") + "\n" + scope + "\n" + sha256Hex([]byte(canonical))
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), date)
+	for _, part := range []string{s.Region, s.Service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, toSign))
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+s.AccessKeyID+"/"+scope+
+		", SignedHeaders="+signed+", Signature="+signature)
+	return nil
+}
+
+// SignsHost returns a boolean value indicating whether the host matches one
+// of the Hosts.
+func (s *SigV4) SignsHost(host string) bool {
+	return len(s.Hosts) > 0 && matchHost(s.Hosts, host)
+}
+
+// canonicalPath returns the escaped path of the request. The path is escaped
+// twice, except for S3.
+func (s *SigV4) canonicalPath(req *http.Request) string {
+	p := req.URL.Path
+	if p == "" {
+		p = "/"
+	}
+	segments := strings.Split(p, "/")
+	for i, seg := range segments {
+		segments[i] = awsEscape(seg)
+		if s.Service != "s3" {
+			segments[i] = awsEscape(segments[i])
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// canonicalQuery returns the query of the request, sorted and escaped.
+func canonicalQuery(req *http.Request) string {
+	var pairs []string
+	for key, values := range req.URL.Query() {
+		for _, v := range values {
+			pairs = append(pairs, awsEscape(key)+"="+awsEscape(v))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// canonicalHeaders returns the signed headers of the request, and their
+// names. The host, the content type and the X-Amz-* headers are signed.
+func (s *SigV4) canonicalHeaders(req *http.Request) (string, string) {
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+	values := map[string]string{"host": host}
+	for name, v := range req.Header {
+		name = strings.ToLower(name)
+		if name == "content-type" || name == "content-md5" || strings.HasPrefix(name, "x-amz-") {
+			values[name] = strings.Join(v, ",")
+		}
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name + ":" + strings.Join(strings.Fields(values[name]), " ") + "\n")
+	}
+	return b.String(), strings.Join(names, ";")
+}
+
+// payloadHash returns the hex SHA-256 of the body of the request.
+func payloadHash(req *http.Request) (string, error) {
+	data, err := RequestBody(req)
+	if err != nil {
+		return "", err
+	}
+	return sha256Hex(data), nil
+}
+
+// awsEscape escapes the string as AWS does, leaving only the unreserved
+// characters of RFC 3986.
+func awsEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-_.~", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			b.WriteString("%" + strings.ToUpper(hex.EncodeToString([]byte{c})))
+		}
+	}
+	return b.String()
+}
+
+// sha256Hex returns the hex SHA-256 of the data.
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of the data.
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSigV4(t *testing.T) {
+	defer func() { now = time.Now }()
+	now = func() time.Time { return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC) }
+
+	// Examples of the AWS Signature Version 4 documentation.
+	s := NewSigV4("AKIDEXAMPLE", "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY", "us-east-1", "service")
+	req, _ := http.NewRequest("GET", "https://example.amazonaws.com/", nil)
+	if err := s.Sign(req); err != nil {
+		t.Fatal(err)
+	}
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Expected the signature of get-vanilla, got '%s'.", got)
+	}
+	if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+		t.Errorf("Unexpected X-Amz-Date '%s'.", got)
+	}
+
+	s.Service = "iam"
+	req, _ = http.NewRequest("GET", "https://iam.amazonaws.com/?Version=2010-05-08&Action=ListUsers", nil)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	if err := s.Sign(req); err != nil {
+		t.Fatal(err)
+	}
+	if got := req.Header.Get("Authorization"); !strings.HasSuffix(got, "SignedHeaders=content-type;host;x-amz-date, Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7") {
+		t.Errorf("Expected the signature of ListUsers, got '%s'.", got)
+	}
+}
+
+func TestSigV4S3(t *testing.T) {
+	s := NewSigV4("AKID", "secret", "eu-west-1", "s3")
+	s.SessionToken = "token"
+	s.Hosts = []string{"*.amazonaws.com"}
+
+	req, _ := http.NewRequest("PUT", "https://bucket.s3.amazonaws.com/a b.txt", strings.NewReader("hello"))
+	req.GetBody = nil
+	if err := s.Sign(req); err != nil {
+		t.Fatal(err)
+	}
+	if got := req.Header.Get("X-Amz-Content-Sha256"); got != "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824" {
+		t.Errorf("Expected the hash of the body, got '%s'.", got)
+	}
+	if !strings.Contains(req.Header.Get("Authorization"), "SignedHeaders=host;x-amz-content-sha256;x-amz-date;x-amz-security-token,") {
+		t.Errorf("Expected the S3 headers to be signed, got '%s'.", req.Header.Get("Authorization"))
+	}
+	if body := make([]byte, 5); req.Body == nil {
+		t.Error("Expected the body to be replaced.")
+	} else if n, _ := req.Body.Read(body); string(body[:n]) != "hello" {
+		t.Errorf("Expected the body to be readable again, got '%s'.", body[:n])
+	}
+	if got := s.canonicalPath(req); got != "/a%20b.txt" {
+		t.Errorf("Expected the S3 path to be escaped once, got '%s'.", got)
+	}
+
+	req, _ = http.NewRequest("GET", "https://example.com/", nil)
+	if err := s.Sign(req); err != nil || req.Header.Get("Authorization") != "" {
+		t.Errorf("Expected the requests to other hosts not to be signed, got '%s' (%v).", req.Header.Get("Authorization"), err)
+	}
+}
+
+func TestNewSigV4FromEnv(t *testing.T) {
+	for _, name := range []string{"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN", "AWS_REGION", "AWS_DEFAULT_REGION"} {
+		t.Setenv(name, "")
+	}
+	if _, err := NewSigV4FromEnv("s3"); err == nil {
+		t.Error("Expected an error without credentials.")
+	}
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_DEFAULT_REGION", "eu-west-3")
+	s, err := NewSigV4FromEnv("s3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.AccessKeyID != "AKID" || s.Region != "eu-west-3" || s.Service != "s3" {
+		t.Errorf("Unexpected signer %+v.", s)
+	}
+}
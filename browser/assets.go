@@ -1,9 +1,13 @@
 package browser
 
 import (
+	"context"
 	"io"
+	"mime"
 	"net/http"
 	"net/url"
+	"strings"
+	"time"
 )
 
 // AssetType describes a type of page asset, such as an image or stylesheet.
@@ -21,6 +25,18 @@ const (
 
 	// ScriptAsset describes a *Script asset.
 	ScriptAsset
+
+	// AlternateAsset describes an *Alternate asset.
+	AlternateAsset
+
+	// StyleResourceAsset describes a *StyleResource asset.
+	StyleResourceAsset
+
+	// FontAsset describes a *Font asset.
+	FontAsset
+
+	// ResourceHintAsset describes a *ResourceHint asset.
+	ResourceHintAsset
 )
 
 // AsyncDownloadResult has the results of an asynchronous download.
@@ -34,6 +50,14 @@ type AsyncDownloadResult struct {
 	// Size is the number of bytes written to the io.Writer.
 	Size int64
 
+	// StatusCode is the status code of the response, or 0 when no response
+	// was received.
+	StatusCode int
+
+	// Duration is the time taken by the download, until it completed, failed
+	// or was canceled.
+	Duration time.Duration
+
 	// Error contains any error that occurred during the download or nil.
 	Error error
 }
@@ -66,10 +90,38 @@ type Asset struct {
 	Type AssetType
 }
 
+// AssetInfo describes a remote asset without downloading its contents.
+type AssetInfo struct {
+	// StatusCode is the response status code.
+	StatusCode int
+
+	// ContentType is the media type of the asset, without parameters.
+	ContentType string
+
+	// ContentLength is the size of the asset in bytes, or -1 when unknown.
+	ContentLength int64
+
+	// Header contains the response headers.
+	Header http.Header
+}
+
+// AssetFilter reports whether an asset described by the given info should be downloaded.
+type AssetFilter func(info *AssetInfo) bool
+
+// Probe issues a HEAD request for the asset URL, and returns the content
+// type and size reported by the server.
+func (at Asset) Probe() (*AssetInfo, error) {
+	return ProbeAsset(at)
+}
+
 // Downloadable represents an asset that may be downloaded.
 type Downloadable interface {
 	Assetable
 
+	// Probe returns the content type and size of the asset without
+	// downloading it.
+	Probe() (*AssetInfo, error)
+
 	// Download writes the contents of the element to the given writer.
 	//
 	// Returns the number of bytes written.
@@ -94,7 +146,7 @@ func (at DownloadableAsset) Download(out io.Writer) (int64, error) {
 
 // DownloadAsync downloads the asset asynchronously.
 func (at DownloadableAsset) DownloadAsync(out io.Writer, ch AsyncDownloadChannel) {
-	DownloadAssetAsync(at, out, ch)
+	DownloadAssetAsync(context.Background(), at, out, ch)
 }
 
 func (self DownloadableAsset) AssetType() AssetType {
@@ -130,6 +182,9 @@ type Image struct {
 
 	// Title is the value of the image title attribute if available.
 	Title string
+
+	// bow is the browser which found the image, used by Decode().
+	bow *Browser
 }
 
 // NewImageAsset creates and returns a new *Image type.
@@ -195,13 +250,53 @@ func NewScriptAsset(url *url.URL, id, typ string) *Script {
 	}
 }
 
+// Alternate stores the properties of an alternate language version of the
+// page, linked with <link rel="alternate" hreflang="...">.
+type Alternate struct {
+	DownloadableAsset
+
+	// HrefLang is the language of the version, eg "en-US", or "x-default"
+	// for the version used when no other language matches.
+	HrefLang string
+
+	// Media is the value of the media attribute, if available.
+	Media string
+}
+
+// NewAlternateAsset creates and returns a new *Alternate type.
+func NewAlternateAsset(url *url.URL, id, hreflang, media string) *Alternate {
+	return &Alternate{
+		DownloadableAsset: DownloadableAsset{
+			Asset: Asset{
+				URL:  url,
+				Type: AlternateAsset,
+				ID:   id,
+			},
+		},
+		HrefLang: hreflang,
+		Media:    media,
+	}
+}
+
 // DownloadAsset copies a remote file to the given writer.
 //# TODO: Should int64 be returned?
 func DownloadAsset(asset DownloadableAsset, out io.Writer) (int64, error) {
+	size, _, err := downloadAsset(context.Background(), asset, out)
+	return size, err
+}
+
+// downloadAsset copies a remote file to the given writer, and returns the
+// number of bytes written and the status code of the response. The download
+// is abandoned when ctx is done.
+func downloadAsset(ctx context.Context, asset DownloadableAsset, out io.Writer) (int64, int, error) {
 	//# TODO: out may be nil, this needs a check
-	resp, err := http.Get(asset.URL.String())
+	req, err := http.NewRequest("GET", asset.URL.String(), nil)
+	if err != nil {
+		return 0, 0, err
+	}
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
 	if err != nil {
-		return 0, err
+		return 0, 0, err
 	}
 	//# TODO: check if Body is nil before closing,
 	// since web requests are not always successful and the nil pointer
@@ -209,22 +304,133 @@ func DownloadAsset(asset DownloadableAsset, out io.Writer) (int64, error) {
 	if resp.Body != nil {
 		defer resp.Body.Close()
 
-		return io.Copy(out, resp.Body)
+		size, err := io.Copy(out, resp.Body)
+		return size, resp.StatusCode, err
 	}
-	return 0, nil
+	return 0, resp.StatusCode, nil
 }
 
 // DownloadAssetAsync downloads an asset asynchronously and notifies the given channel
 // when the download is complete.
-func DownloadAssetAsync(asset DownloadableAsset, out io.Writer, c AsyncDownloadChannel) {
+//
+// The download is abandoned when ctx is done, eg when its deadline passes,
+// or when the returned function is called. The result is sent to the channel
+// in every case, with the error of ctx when the download was abandoned.
+func DownloadAssetAsync(ctx context.Context, asset DownloadableAsset, out io.Writer, c AsyncDownloadChannel) context.CancelFunc {
+	ctx, cancel := context.WithCancel(ctx)
 	go func() {
+		defer cancel()
+		start := time.Now()
 		results := &AsyncDownloadResult{Asset: asset, Writer: out}
-		size, err := DownloadAsset(asset, out)
-		if err != nil {
-			results.Error = err
-		} else {
-			results.Size = size
+		results.Size, results.StatusCode, results.Error = downloadAsset(ctx, asset, out)
+		if results.Error != nil && ctx.Err() != nil {
+			results.Error = ctx.Err()
 		}
+		results.Duration = time.Since(start)
 		c <- results
 	}()
+	return cancel
+}
+
+// ProbeAsset issues a HEAD request for the asset URL, and returns the content
+// type and size reported by the server. The request is sent with the default
+// client; use Browser.ProbeAsset() to send it with the session of a browser.
+func ProbeAsset(asset Asset) (*AssetInfo, error) {
+	resp, err := http.Head(asset.URL.String())
+	if err != nil {
+		return nil, err
+	}
+	if resp.Body != nil {
+		resp.Body.Close()
+	}
+	return newAssetInfo(resp), nil
+}
+
+// ProbeAsset issues a HEAD request for the asset URL with the session of the
+// browser, its cookies, headers, proxy, host rules and budget, and returns
+// the content type and size reported by the server.
+func (bow *Browser) ProbeAsset(asset Asset) (*AssetInfo, error) {
+	req, err := bow.buildRequest("HEAD", asset.URL.String(), bow.URL(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, release, err := bow.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	if resp.Body != nil {
+		resp.Body.Close()
+	}
+	return newAssetInfo(resp), nil
+}
+
+// newAssetInfo returns the *AssetInfo described by the response to a probe.
+func newAssetInfo(resp *http.Response) *AssetInfo {
+	info := &AssetInfo{
+		StatusCode:    resp.StatusCode,
+		ContentLength: resp.ContentLength,
+		Header:        resp.Header,
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		mt, _, err := mime.ParseMediaType(ct)
+		if err != nil {
+			mt = ct
+		}
+		info.ContentType = strings.ToLower(mt)
+	}
+	return info
+}
+
+// Allowed returns a boolean value indicating whether the asset passes every
+// one of the given filters.
+func (info *AssetInfo) Allowed(filters ...AssetFilter) bool {
+	for _, f := range filters {
+		if !f(info) {
+			return false
+		}
+	}
+	return true
+}
+
+// MaxSize returns an AssetFilter which rejects assets larger than the given
+// number of bytes. Assets of unknown size are allowed.
+func MaxSize(n int64) AssetFilter {
+	return func(info *AssetInfo) bool {
+		return info.ContentLength <= n
+	}
+}
+
+// ContentTypes returns an AssetFilter which only allows assets of the given
+// media types. A type may end with a wildcard, eg "image/*".
+func ContentTypes(types ...string) AssetFilter {
+	return func(info *AssetInfo) bool {
+		for _, t := range types {
+			t = strings.ToLower(t)
+			if t == info.ContentType {
+				return true
+			}
+			if strings.HasSuffix(t, "/*") && strings.HasPrefix(info.ContentType, strings.TrimSuffix(t, "*")) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// FilterAssets probes each of the given assets and returns the assets which
+// pass every one of the given filters. Assets which cannot be probed, or which
+// respond with an error status, are left out.
+func FilterAssets(assets []Downloadable, filters ...AssetFilter) []Downloadable {
+	allowed := make([]Downloadable, 0, len(assets))
+	for _, asset := range assets {
+		info, err := asset.Probe()
+		if err != nil || info.StatusCode >= 400 {
+			continue
+		}
+		if info.Allowed(filters...) {
+			allowed = append(allowed, asset)
+		}
+	}
+	return allowed
 }
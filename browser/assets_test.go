@@ -2,9 +2,13 @@ package browser
 
 import (
 	"bytes"
+	"context"
 	"github.com/headzoo/ut"
+	"net/http"
+	"net/http/httptest"
 	"net/url"
 	"testing"
+	"time"
 )
 
 func TestDownload(t *testing.T) {
@@ -31,8 +35,8 @@ func TestDownloadAsync(t *testing.T) {
 	out2 := &bytes.Buffer{}
 
 	queue := 2
-	DownloadAssetAsync(asset1, out1, ch)
-	DownloadAssetAsync(asset2, out2, ch)
+	DownloadAssetAsync(context.Background(), asset1, out1, ch)
+	DownloadAssetAsync(context.Background(), asset2, out2, ch)
 
 	for {
 		select {
@@ -56,3 +60,143 @@ COMPLETE:
 	close(ch)
 	ut.AssertEquals(0, queue)
 }
+
+func TestProbe(t *testing.T) {
+	ut.Run(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ut.AssertEquals("HEAD", r.Method)
+		switch r.URL.Path {
+		case "/small.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Header().Set("Content-Length", "100")
+		case "/large.jpg":
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.Header().Set("Content-Length", "5000")
+		case "/page.html":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Header().Set("Content-Length", "10")
+		}
+	}))
+	defer ts.Close()
+
+	u1, _ := url.Parse(ts.URL + "/small.png")
+	u2, _ := url.Parse(ts.URL + "/large.jpg")
+	u3, _ := url.Parse(ts.URL + "/page.html")
+	small := NewImageAsset(u1, "", "", "")
+	large := NewImageAsset(u2, "", "", "")
+	page := NewImageAsset(u3, "", "", "")
+
+	info, err := small.Probe()
+	ut.AssertNil(err)
+	ut.AssertEquals("image/png", info.ContentType)
+	ut.AssertEquals(int64(100), info.ContentLength)
+
+	info, err = page.Probe()
+	ut.AssertNil(err)
+	ut.AssertEquals("text/html", info.ContentType)
+
+	assets := FilterAssets([]Downloadable{small, large, page}, ContentTypes("image/*"), MaxSize(1000))
+	ut.AssertEquals(1, len(assets))
+	ut.AssertEquals(Downloadable(small), assets[0])
+}
+
+func TestBrowserProbeAsset(t *testing.T) {
+	ut.Run(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ut.AssertEquals("HEAD", r.Method)
+		if c, err := r.Cookie("session"); err != nil || c.Value != "1" || r.Header.Get("User-Agent") != "Prober" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Header().Set("Content-Length", "100")
+	}))
+	defer ts.Close()
+
+	u, _ := url.Parse(ts.URL + "/private.png")
+	bow := newDefaultTestBrowser()
+	bow.SetUserAgent("Prober")
+	bow.SetCookie(u.Host, &http.Cookie{Name: "session", Value: "1"})
+
+	info, err := bow.ProbeAsset(NewImageAsset(u, "", "", "").Asset)
+	ut.AssertNil(err)
+	ut.AssertEquals(http.StatusOK, info.StatusCode)
+	ut.AssertEquals("image/png", info.ContentType)
+	ut.AssertEquals(int64(100), info.ContentLength)
+
+	info, err = ProbeAsset(NewImageAsset(u, "", "", "").Asset)
+	ut.AssertNil(err)
+	ut.AssertEquals(http.StatusForbidden, info.StatusCode)
+}
+
+func TestAlternates(t *testing.T) {
+	ut.Run(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><head>
+			<link rel="alternate" hreflang="en-US" href="/en/">
+			<link rel="alternate" hreflang="fr" href="https://example.fr/" media="only screen">
+			<link rel="Alternate" hreflang="x-default" href="/">
+			<link rel="alternate" type="application/rss+xml" href="/feed">
+		</head></html>`))
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	ut.AssertNil(bow.GET(ts.URL + "/de/"))
+
+	alternates := bow.Alternates()
+	ut.AssertEquals(3, len(alternates))
+	ut.AssertEquals("en-US", alternates[0].HrefLang)
+	ut.AssertEquals(ts.URL+"/en/", alternates[0].URL.String())
+	ut.AssertEquals(AlternateAsset, alternates[0].AssetType())
+	ut.AssertEquals("fr", alternates[1].HrefLang)
+	ut.AssertEquals("https://example.fr/", alternates[1].URL.String())
+	ut.AssertEquals("only screen", alternates[1].Media)
+	ut.AssertEquals("x-default", alternates[2].HrefLang)
+	ut.AssertEquals(ts.URL+"/", alternates[2].URL.String())
+}
+
+func TestDownloadAssetAsyncContext(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.Error(w, "missing", http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("partial"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer ts.Close()
+
+	asset := func(path string) DownloadableAsset {
+		u, _ := url.Parse(ts.URL + path)
+		return NewImageAsset(u, "", "", "").DownloadableAsset
+	}
+	ch := make(AsyncDownloadChannel, 1)
+
+	DownloadAssetAsync(context.Background(), asset("/missing"), &bytes.Buffer{}, ch)
+	res := <-ch
+	if res.Error != nil || res.StatusCode != http.StatusNotFound || res.Size != 8 {
+		t.Errorf("Expected the status and size of the response, got %d %d %v.", res.StatusCode, res.Size, res.Error)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	DownloadAssetAsync(ctx, asset("/hang"), &bytes.Buffer{}, ch)
+	res = <-ch
+	if res.Error != context.DeadlineExceeded || res.StatusCode != http.StatusOK {
+		t.Errorf("Expected the deadline to abandon the download, got %d %v.", res.StatusCode, res.Error)
+	}
+	if res.Duration < 50*time.Millisecond {
+		t.Errorf("Expected the duration of the download, got %v.", res.Duration)
+	}
+
+	stop := DownloadAssetAsync(context.Background(), asset("/hang"), &bytes.Buffer{}, ch)
+	stop()
+	if res = <-ch; res.Error != context.Canceled {
+		t.Errorf("Expected the download to be canceled, got %v.", res.Error)
+	}
+}
@@ -0,0 +1,186 @@
+package browser
+
+import (
+	"encoding/base64"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/lostinblue/surf/auth"
+)
+
+// SetAuthenticators sets the authenticators answering the challenges of the
+// servers answering with 401 Unauthorized, and of the HTTP proxies answering
+// with 407 Proxy Authentication Required, eg auth.NTLM. The first
+// authenticator whose scheme is offered by the response is used. Tabs share
+// the authenticators of their parent.
+//
+// Proxies are only authenticated for the requests they forward themselves,
+// ie plain HTTP requests. HTTPS requests are tunnelled with CONNECT, which
+// the authenticators can't answer.
+func (bow *Browser) SetAuthenticators(a ...auth.Authenticator) {
+	bow.authenticators = append([]auth.Authenticator(nil), a...)
+}
+
+// Authenticators returns the authenticators set with SetAuthenticators().
+func (bow *Browser) Authenticators() []auth.Authenticator {
+	return bow.authenticators
+}
+
+// SignRequestsWith sets the signer of the requests, eg an auth.SigV4 to fetch
+// from S3, or an auth.HMAC. The requests are signed last, once their headers
+// are final, including the requests of redirects. The redirects to another
+// host are only signed by an auth.HostSigner listing the host. Tabs share
+// the signer of their parent. Pass nil to stop signing requests.
+func (bow *Browser) SignRequestsWith(s auth.RequestSigner) {
+	bow.signer = s
+}
+
+// Signer returns the signer set with SignRequestsWith(), or nil.
+func (bow *Browser) Signer() auth.RequestSigner {
+	return bow.signer
+}
+
+// signRequest signs the request with the signer of the browser, if any.
+func (bow *Browser) signRequest(req *http.Request) error {
+	if bow.signer == nil {
+		return nil
+	}
+	return bow.signer.Sign(req)
+}
+
+// signRedirect signs the request of a redirect from the first request. The
+// redirects to another host are only signed when the signer lists the host.
+func (bow *Browser) signRedirect(req, first *http.Request) error {
+	if bow.signer == nil {
+		return nil
+	}
+	if host := req.URL.Hostname(); !strings.EqualFold(host, first.URL.Hostname()) {
+		if s, ok := bow.signer.(auth.HostSigner); !ok || !s.SignsHost(host) {
+			return nil
+		}
+	}
+	return bow.signer.Sign(req)
+}
+
+// answerChallenges sends the request again with the tokens of an
+// authenticator, until the server or the proxy stops asking for them, and
+// returns the last response. The response is returned unchanged when no
+// authenticator answers its challenge.
+func (bow *Browser) answerChallenges(client *http.Client, req *http.Request, resp *http.Response, o *requestOptions) (*http.Response, error) {
+	if len(bow.authenticators) == 0 {
+		return resp, nil
+	}
+	status := resp.StatusCode
+	challengeHeader, authHeader := "WWW-Authenticate", "Authorization"
+	host := req.URL.Hostname()
+	switch status {
+	case http.StatusUnauthorized:
+	case http.StatusProxyAuthRequired:
+		challengeHeader, authHeader = "Proxy-Authenticate", "Proxy-Authorization"
+		host = bow.proxyHost(o, host)
+	default:
+		return resp, nil
+	}
+	if req.Header.Get(authHeader) != "" {
+		return resp, nil
+	}
+	a, challenge := findAuthenticator(bow.authenticators, resp.Header.Values(challengeHeader))
+	if a == nil {
+		return resp, nil
+	}
+	hs, err := a.Start(host)
+	if err != nil {
+		discardBody(resp)
+		return nil, err
+	}
+	if hs == nil {
+		return resp, nil
+	}
+
+	for round := 0; round < auth.MaxRounds; round++ {
+		token, err := hs.Next(challenge)
+		// The body is read so the connection is reused, which NTLM requires.
+		discardBody(resp)
+		if err != nil {
+			return nil, err
+		}
+		retry, err := resendRequest(req)
+		if err != nil {
+			return nil, err
+		}
+		if err := bow.budget.spend(retry.URL); err != nil {
+			return nil, err
+		}
+		retry.Header.Set(authHeader, a.Scheme()+" "+base64.StdEncoding.EncodeToString(token))
+		if err := bow.signRequest(retry); err != nil {
+			return nil, err
+		}
+		if resp, err = client.Do(retry); err != nil {
+			return nil, err
+		}
+		if resp.StatusCode != status {
+			return resp, nil
+		}
+		var ok bool
+		if challenge, ok = schemeToken(resp.Header.Values(challengeHeader), a.Scheme()); !ok || challenge == nil {
+			return resp, nil
+		}
+	}
+	return resp, nil
+}
+
+// proxyHost returns the host of the proxy the request was sent through, or
+// host when it's not known.
+func (bow *Browser) proxyHost(o *requestOptions, host string) string {
+	if o.proxy != "" {
+		if u, err := url.Parse(o.proxy); err == nil && u.Hostname() != "" {
+			return u.Hostname()
+		}
+	}
+	if bow.proxy != nil {
+		return bow.proxy.Hostname()
+	}
+	return host
+}
+
+// findAuthenticator returns the first authenticator whose scheme is offered
+// by the challenges, and the token of the challenge.
+func findAuthenticator(authenticators []auth.Authenticator, challenges []string) (auth.Authenticator, []byte) {
+	for _, a := range authenticators {
+		if token, ok := schemeToken(challenges, a.Scheme()); ok {
+			return a, token
+		}
+	}
+	return nil, nil
+}
+
+// schemeToken returns the decoded token of the challenge of the scheme, and
+// a boolean value indicating whether the scheme is offered at all.
+func schemeToken(challenges []string, scheme string) ([]byte, bool) {
+	for _, c := range challenges {
+		for _, part := range strings.Split(c, ",") {
+			fields := strings.Fields(part)
+			if len(fields) == 0 || !strings.EqualFold(fields[0], scheme) {
+				continue
+			}
+			if len(fields) < 2 {
+				return nil, true
+			}
+			token, err := base64.StdEncoding.DecodeString(fields[1])
+			if err != nil {
+				return nil, true
+			}
+			return token, true
+		}
+	}
+	return nil, false
+}
+
+// discardBody reads and closes the body of the response.
+func discardBody(resp *http.Response) {
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+}
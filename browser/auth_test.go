@@ -0,0 +1,182 @@
+package browser
+
+import (
+	"bytes"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/lostinblue/surf/auth"
+	"github.com/lostinblue/surf/credentials"
+	"github.com/lostinblue/surf/errors"
+)
+
+// ntlmChallenge is a minimal NTLM challenge message.
+var ntlmChallenge = append([]byte("NTLMSSP\x00\x02\x00\x00\x00"), make([]byte, 36)...)
+
+func TestAuthenticators(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		header, status := "WWW-Authenticate", http.StatusUnauthorized
+		if r.URL.Path == "/proxy" {
+			header, status = "Proxy-Authenticate", http.StatusProxyAuthRequired
+		}
+		authz := r.Header.Get("Authorization")
+		if status == http.StatusProxyAuthRequired {
+			authz = r.Header.Get("Proxy-Authorization")
+		}
+		msg, _ := base64.StdEncoding.DecodeString(strings.TrimPrefix(authz, "NTLM "))
+		switch {
+		case r.URL.Path == "/negotiate":
+			if authz != "Negotiate "+base64.StdEncoding.EncodeToString([]byte("kerberos")) {
+				w.Header().Set(header, "Negotiate")
+				w.WriteHeader(status)
+				return
+			}
+		case len(msg) < 12 || !bytes.HasPrefix(msg, []byte("NTLMSSP\x00")):
+			w.Header().Add(header, "Negotiate")
+			w.Header().Add(header, `NTLM, Basic realm="surf"`)
+			w.WriteHeader(status)
+			return
+		case msg[8] == 1:
+			w.Header().Set(header, "NTLM "+base64.StdEncoding.EncodeToString(ntlmChallenge))
+			w.WriteHeader(status)
+			return
+		case msg[8] != 3:
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte("<html><head><title>Welcome</title></head></html>"))
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	bow.SetAuthenticators(auth.NewNTLM(credentials.Map{"127.0.0.1": {Username: `CORP\jdoe`, Password: "secret"}}))
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if bow.StatusCode() != http.StatusOK || bow.Title() != "Welcome" || atomic.LoadInt32(&hits) != 3 {
+		t.Errorf("Expected the NTLM handshake to take 3 requests, got %d '%s' after %d.", bow.StatusCode(), bow.Title(), hits)
+	}
+
+	atomic.StoreInt32(&hits, 0)
+	tab := bow.NewTab()
+	if err := tab.GET(ts.URL + "/proxy"); err != nil {
+		t.Fatal(err)
+	}
+	if tab.StatusCode() != http.StatusOK || atomic.LoadInt32(&hits) != 3 {
+		t.Errorf("Expected the tab to answer the proxy challenge, got %d after %d requests.", tab.StatusCode(), hits)
+	}
+
+	if err := bow.GET(ts.URL + "/negotiate"); err != nil {
+		t.Fatal(err)
+	}
+	if bow.StatusCode() != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without a Negotiate authenticator, got %d.", bow.StatusCode())
+	}
+	bow.SetAuthenticators(auth.NewNegotiate(func(spn string, challenge []byte) ([]byte, error) {
+		return []byte("kerberos"), nil
+	}), bow.Authenticators()[0])
+	if err := bow.GET(ts.URL + "/negotiate"); err != nil {
+		t.Fatal(err)
+	}
+	if bow.StatusCode() != http.StatusOK {
+		t.Errorf("Expected the Negotiate token to be accepted, got %d.", bow.StatusCode())
+	}
+
+	bow.SetAuthenticators(auth.NewNTLM(credentials.Map{}))
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if bow.StatusCode() != http.StatusUnauthorized {
+		t.Errorf("Expected the 401 without NTLM credentials for the host, got %d.", bow.StatusCode())
+	}
+}
+
+func TestSignRequestsWith(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, "/bucket/key", http.StatusFound)
+			return
+		case "/elsewhere":
+			_, port, _ := net.SplitHostPort(r.Host)
+			http.Redirect(w, r, "http://localhost:"+port+"/bucket/key", http.StatusFound)
+			return
+		}
+		authz := r.Header.Get("Authorization")
+		if !strings.HasPrefix(authz, "AWS4-HMAC-SHA256 Credential=AKID/") || r.Header.Get("X-Amz-Content-Sha256") == "" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte("<html><head><title>" + r.URL.Path + "</title></head></html>"))
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	if err := bow.GET(ts.URL + "/bucket/key"); err != nil {
+		t.Fatal(err)
+	}
+	if bow.StatusCode() != http.StatusForbidden {
+		t.Errorf("Expected 403 without a signer, got %d.", bow.StatusCode())
+	}
+
+	bow.SignRequestsWith(auth.NewSigV4("AKID", "secret", "us-east-1", "s3"))
+	if err := bow.GET(ts.URL + "/redirect"); err != nil {
+		t.Fatal(err)
+	}
+	if bow.StatusCode() != http.StatusOK || bow.Title() != "/bucket/key" {
+		t.Errorf("Expected the redirected request to be signed, got %d '%s'.", bow.StatusCode(), bow.Title())
+	}
+	if tab := bow.NewTab(); tab.Signer() != bow.Signer() {
+		t.Error("Expected the tab to share the signer.")
+	}
+
+	if err := bow.GET(ts.URL + "/elsewhere"); err != nil {
+		t.Fatal(err)
+	}
+	if bow.StatusCode() != http.StatusForbidden {
+		t.Errorf("Expected the redirect to another host not to be signed, got %d.", bow.StatusCode())
+	}
+	s := auth.NewSigV4("AKID", "secret", "us-east-1", "s3")
+	s.Hosts = []string{"127.0.0.1", "localhost"}
+	bow.SignRequestsWith(s)
+	if err := bow.GET(ts.URL + "/elsewhere"); err != nil {
+		t.Fatal(err)
+	}
+	if bow.StatusCode() != http.StatusOK || bow.URL().Hostname() != "localhost" {
+		t.Errorf("Expected the redirect to a listed host to be signed, got %d for '%s'.", bow.StatusCode(), bow.URL())
+	}
+}
+
+func TestRequestSigner(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html><head><title>" + r.Header.Get("X-Signed") + "</title></head></html>"))
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	bow.SignRequestsWith(auth.SignerFunc(func(req *http.Request) error {
+		body, err := auth.RequestBody(req)
+		req.Header.Set("X-Signed", req.Header.Get("User-Agent")+" "+req.Header.Get("X-Custom")+" "+string(body))
+		return err
+	}))
+	if err := bow.POST(ts.URL, "text/plain", strings.NewReader("data"), WithHeader("X-Custom", "final")); err != nil {
+		t.Fatal(err)
+	}
+	if want := bow.UserAgent() + " final data"; bow.Title() != want {
+		t.Errorf("Expected the request to be signed with its final headers, got '%s'.", bow.Title())
+	}
+
+	bow.SignRequestsWith(auth.SignerFunc(func(req *http.Request) error {
+		return errors.New("no key")
+	}))
+	if err := bow.GET(ts.URL); err == nil {
+		t.Error("Expected the error of the signer.")
+	}
+}
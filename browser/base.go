@@ -0,0 +1,68 @@
+package browser
+
+import (
+	"bytes"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// baseCandidate matches the bodies which may contain a base element, so
+// other pages don't need to be tokenized to find their base URL.
+var baseCandidate = regexp.MustCompile(`(?i)<base[\s/>]`)
+
+// documentBase returns the URL the relative URLs of the page are resolved
+// against: the base URL recorded on the state when the page was loaded, or
+// the page URL when it has none. Returns nil when no page has been loaded.
+func (bow *Browser) documentBase() *url.URL {
+	if bow.state.Base != nil {
+		return bow.state.Base
+	}
+	return bow.URL()
+}
+
+// setDocumentBase records the base URL of the current page on its state,
+// once its body is known, so documentBase() doesn't look for it each time a
+// URL is resolved.
+func (bow *Browser) setDocumentBase() {
+	bow.state.Base = findBase(bow.URL(), bow.state.Body)
+}
+
+// findBase returns the href of the first <base> element of the body,
+// resolved against the page URL, or nil when the body has none or its href
+// is not a valid URL.
+func findBase(page *url.URL, body []byte) *url.URL {
+	if page == nil || !baseCandidate.Match(body) {
+		return nil
+	}
+	z := html.NewTokenizer(bytes.NewReader(body))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return nil
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			if string(name) != "base" {
+				continue
+			}
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				if string(key) != "href" {
+					continue
+				}
+				u, err := url.Parse(strings.TrimSpace(string(val)))
+				if err != nil {
+					return nil
+				}
+				base := page.ResolveReference(u)
+				if base.Scheme == "data" || base.Scheme == "javascript" {
+					return nil
+				}
+				return base
+			}
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBaseHref(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/pages/index.html":
+			w.Write([]byte(`<html><head><base href="/static/v2/"></head><body>
+				<a href="about.html">About</a> <a href="/root">Root</a> <img src="logo.png">
+				<form action="search"><input name="q"></form><form id="self"></form></body></html>`))
+		case "/pages/plain.html":
+			w.Write([]byte(`<html><body><a href="about.html">About</a></body></html>`))
+		default:
+			w.Write([]byte(`<html><head><title>` + r.URL.Path + `</title></head></html>`))
+		}
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	if err := bow.GET(ts.URL + "/pages/index.html"); err != nil {
+		t.Fatal(err)
+	}
+	if b := bow.State().Base; b == nil || b.String() != ts.URL+"/static/v2/" {
+		t.Errorf("Expected the base URL to be recorded on the state, got %v.", b)
+	}
+	links := bow.Links()
+	if len(links) != 2 || links[0].URL.String() != ts.URL+"/static/v2/about.html" || links[1].URL.String() != ts.URL+"/root" {
+		t.Errorf("Expected the links resolved against the base href, got %v.", links)
+	}
+	if images := bow.Images(); len(images) != 1 || images[0].URL.String() != ts.URL+"/static/v2/logo.png" {
+		t.Errorf("Expected the image resolved against the base href, got %v.", images)
+	}
+	if u, _ := bow.ResolveStringURL("x"); u != ts.URL+"/static/v2/x" {
+		t.Errorf("Expected the URL resolved against the base href, got %s.", u)
+	}
+	form, err := bow.Form("form[action]")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if form.Action() != ts.URL+"/static/v2/search" {
+		t.Errorf("Expected the form action resolved against the base href, got %s.", form.Action())
+	}
+	self, err := bow.Form("#self")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if self.Action() != ts.URL+"/pages/index.html" {
+		t.Errorf("Expected a form without action to submit to the page URL, got %s.", self.Action())
+	}
+	if err := bow.Click("a"); err != nil {
+		t.Fatal(err)
+	}
+	if bow.Title() != "/static/v2/about.html" {
+		t.Errorf("Expected to click through to the base href, got %s.", bow.Title())
+	}
+
+	if err := bow.GET(ts.URL + "/pages/plain.html"); err != nil {
+		t.Fatal(err)
+	}
+	if links := bow.Links(); len(links) != 1 || links[0].URL.String() != ts.URL+"/pages/about.html" {
+		t.Errorf("Expected the link resolved against the page URL, got %v.", links)
+	}
+	if bow.State().Base != nil {
+		t.Errorf("Expected no base URL for a page without a base element, got %v.", bow.State().Base)
+	}
+	bow.SetBody(`<html><head><base href="/other/"></head></html>`)
+	if u, _ := bow.ResolveStringURL("x"); u != ts.URL+"/other/x" {
+		t.Errorf("Expected the base URL of the new body, got %s.", u)
+	}
+	if !bow.Back() || !bow.Back() || bow.URL().Path != "/pages/index.html" {
+		t.Fatalf("Expected to go back to the page with a base element, got %s.", bow.URL())
+	}
+	if u, _ := bow.ResolveStringURL("x"); u != ts.URL+"/static/v2/x" {
+		t.Errorf("Expected the base URL of the previous page, got %s.", u)
+	}
+}
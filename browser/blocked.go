@@ -0,0 +1,160 @@
+package browser
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+// BlockKind is the kind of captcha or anti-bot page blocking the browser.
+type BlockKind string
+
+const (
+	// BlockRecaptcha is a Google reCAPTCHA widget.
+	BlockRecaptcha BlockKind = "recaptcha"
+
+	// BlockHCaptcha is an hCaptcha widget.
+	BlockHCaptcha BlockKind = "hcaptcha"
+
+	// BlockCloudflare is a Cloudflare challenge page, eg "Just a moment...".
+	BlockCloudflare BlockKind = "cloudflare"
+)
+
+// blockCandidate matches the bodies which may contain a captcha or an
+// anti-bot challenge, so other pages don't need to be parsed.
+var blockCandidate = regexp.MustCompile(`(?i)captcha|cf-browser-verification|challenge-platform|cf_chl_`)
+
+// captchaWidgets are the selectors of the captcha widgets, and the name of
+// the form field their token is sent in.
+var captchaWidgets = []struct {
+	kind     BlockKind
+	selector string
+	field    string
+}{
+	{BlockRecaptcha, ".g-recaptcha, script[src*='google.com/recaptcha'], script[src*='recaptcha.net/recaptcha']", "g-recaptcha-response"},
+	{BlockHCaptcha, ".h-captcha, script[src*='hcaptcha.com']", "h-captcha-response"},
+}
+
+// BlockInfo describes a captcha or an anti-bot page.
+type BlockInfo struct {
+	// Kind is the kind of captcha or challenge.
+	Kind BlockKind
+
+	// URL is the address of the page.
+	URL *url.URL
+
+	// StatusCode is the status code of the page.
+	StatusCode int
+
+	// SiteKey is the data-sitekey of the captcha widget, which solving
+	// services require. It's empty for challenge pages.
+	SiteKey string
+
+	// Field is the name of the form field the captcha token is sent in,
+	// eg "g-recaptcha-response".
+	Field string
+}
+
+// CaptchaSolver solves captchas, usually with an external solving service.
+type CaptchaSolver interface {
+	// Solve returns the token answering the captcha.
+	Solve(info *BlockInfo) (string, error)
+}
+
+// CaptchaFunc is a function used as a CaptchaSolver.
+type CaptchaFunc func(info *BlockInfo) (string, error)
+
+// Solve calls the function.
+func (fn CaptchaFunc) Solve(info *BlockInfo) (string, error) {
+	return fn(info)
+}
+
+// Blocked returns a description of the captcha or anti-bot challenge on the
+// current page, or nil when the page is not blocked. Detects reCAPTCHA and
+// hCaptcha widgets, and Cloudflare challenge pages.
+func (bow *Browser) Blocked() *BlockInfo {
+	resp := bow.state.Response
+	if resp == nil {
+		return nil
+	}
+	info := &BlockInfo{URL: bow.URL(), StatusCode: resp.StatusCode}
+	if isCloudflareChallenge(resp.StatusCode, resp.Header.Get("Server"), resp.Header.Get("Cf-Mitigated"), bow.state.Body) {
+		info.Kind = BlockCloudflare
+		return info
+	}
+	if !isContentTypeHtml(resp) || !blockCandidate.Match(bow.state.Body) {
+		return nil
+	}
+	for _, w := range captchaWidgets {
+		if bow.Find(w.selector).Length() > 0 {
+			info.Kind = w.kind
+			info.Field = w.field
+			info.SiteKey = bow.Find("[data-sitekey]").AttrOr("data-sitekey", "")
+			return info
+		}
+	}
+	return nil
+}
+
+// SetCaptchaSolver sets the solver used by SolveCaptcha().
+func (bow *Browser) SetCaptchaSolver(s CaptchaSolver) {
+	bow.captchaSolver = s
+}
+
+// CaptchaSolver returns the solver used by SolveCaptcha().
+func (bow *Browser) CaptchaSolver() CaptchaSolver {
+	return bow.captchaSolver
+}
+
+// SolveCaptcha solves the captcha of the current page with the captcha
+// solver, sets the token in the form containing the widget and submits it.
+// Does nothing when the page is not blocked.
+//
+// Challenge pages, which are not captchas, return an error.
+func (bow *Browser) SolveCaptcha() error {
+	info := bow.Blocked()
+	if info == nil {
+		return nil
+	}
+	if info.Field == "" {
+		return errors.New("Cannot solve the %s page of '%s' with a captcha solver.", info.Kind, info.URL)
+	}
+	if bow.captchaSolver == nil {
+		return errors.New("The page '%s' has a %s captcha, but no captcha solver was set.", info.URL, info.Kind)
+	}
+	var form *Form
+	for _, w := range captchaWidgets {
+		if w.kind == info.Kind {
+			if sel := bow.Find(w.selector).Closest("form"); sel.Length() > 0 {
+				form = NewForm(bow, sel.First())
+			}
+		}
+	}
+	if form == nil {
+		return errors.NewElementNotFound("No form contains the %s widget of '%s'.", info.Kind, info.URL)
+	}
+
+	token, err := bow.captchaSolver.Solve(info)
+	if err != nil {
+		return err
+	}
+	form.fields.Set(info.Field, token)
+	return form.Submit()
+}
+
+// isCloudflareChallenge returns a boolean value indicating whether the
+// response is a Cloudflare challenge page.
+func isCloudflareChallenge(status int, server, mitigated string, body []byte) bool {
+	if strings.EqualFold(mitigated, "challenge") {
+		return true
+	}
+	if status != 403 && status != 503 && status != 429 {
+		return false
+	}
+	if !strings.EqualFold(server, "cloudflare") {
+		return false
+	}
+	return blockCandidate.Match(body) || strings.Contains(string(body), "Just a moment...")
+}
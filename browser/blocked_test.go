@@ -0,0 +1,87 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBlocked(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/recaptcha":
+			w.Write([]byte(`<html><body><form method="post" action="/verify">
+				<div class="g-recaptcha" data-sitekey="site-key"></div>
+				<input type="submit" name="go" value="Go">
+			</form></body></html>`))
+		case "/hcaptcha":
+			w.Write([]byte(`<html><head><script src="https://js.hcaptcha.com/1/api.js"></script></head>
+				<body><div data-sitekey="h-key"></div></body></html>`))
+		case "/cloudflare":
+			w.Header().Set("Server", "cloudflare")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte(`<html><head><title>Just a moment...</title></head><body></body></html>`))
+		case "/verify":
+			r.ParseForm()
+			if r.PostForm.Get("g-recaptcha-response") == "token-site-key" {
+				w.Write([]byte(`<html><body>Welcome</body></html>`))
+				return
+			}
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.Write([]byte(`<html><body>Page about captchas</body></html>`))
+		}
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	if bow.Blocked() != nil {
+		t.Error("Expected no block before loading a page")
+	}
+	tests := map[string]BlockKind{
+		"/":           "",
+		"/recaptcha":  BlockRecaptcha,
+		"/hcaptcha":   BlockHCaptcha,
+		"/cloudflare": BlockCloudflare,
+	}
+	for path, kind := range tests {
+		if err := bow.GET(ts.URL + path); err != nil {
+			t.Fatal(err)
+		}
+		info := bow.Blocked()
+		if kind == "" {
+			if info != nil {
+				t.Errorf("Expected %s not to be blocked, got %+v", path, info)
+			}
+			continue
+		}
+		if info == nil || info.Kind != kind {
+			t.Errorf("Expected %s to be blocked by %s, got %+v", path, kind, info)
+		}
+	}
+	if err := bow.GET(ts.URL + "/cloudflare"); err != nil {
+		t.Fatal(err)
+	}
+	if err := bow.SolveCaptcha(); err == nil {
+		t.Error("Expected an error solving a challenge page")
+	}
+
+	if err := bow.GET(ts.URL + "/recaptcha"); err != nil {
+		t.Fatal(err)
+	}
+	if info := bow.Blocked(); info.SiteKey != "site-key" || info.Field != "g-recaptcha-response" {
+		t.Errorf("Expected the site key and field of the widget, got %+v", info)
+	}
+	if err := bow.SolveCaptcha(); err == nil {
+		t.Error("Expected an error without a captcha solver")
+	}
+	bow.SetCaptchaSolver(CaptchaFunc(func(info *BlockInfo) (string, error) {
+		return "token-" + info.SiteKey, nil
+	}))
+	if err := bow.SolveCaptcha(); err != nil {
+		t.Fatal(err)
+	}
+	if bow.StatusCode() != http.StatusOK || bow.Blocked() != nil {
+		t.Errorf("Expected the captcha to be solved, got the status %d", bow.StatusCode())
+	}
+}
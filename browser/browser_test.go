@@ -1,13 +1,17 @@
 package browser
 
 import (
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/lostinblue/surf/agent"
+	"github.com/lostinblue/surf/errors"
 	"github.com/lostinblue/surf/jar"
 )
 
@@ -15,7 +19,7 @@ func newDefaultTestBrowser() *Browser {
 	bow := &Browser{}
 	bow.SetUserAgent(agent.Create())
 	bow.SetState(&jar.State{})
-	bow.SetCookieJar(jar.NewMemoryCookies())
+	bow.SetCookieJar(jar.NewCookiesJar())
 	bow.SetBookmarksJar(jar.NewMemoryBookmarks())
 	bow.SetHistoryJar(jar.NewMemoryHistory())
 	bow.SetHeadersJar(jar.NewMemoryHeaders())
@@ -195,8 +199,8 @@ func TestTabInheritance(t *testing.T){
 		t.Fatal("Tab did not copy the CookieJar")
 	}
 
-	if bow1.HistoryJar() != bow2.HistoryJar(){
-		t.Fatal("Tab did not copy the HistoryJar")
+	if bow1.HistoryJar() == bow2.HistoryJar(){
+		t.Fatal("Tab shares the HistoryJar of its parent")
 	}
 
 	if len(bow1.headers) != len(bow2.headers){
@@ -207,3 +211,446 @@ func TestTabInheritance(t *testing.T){
 		t.Fatal("Tab did not copy the transport method")
 	}
 }
+
+// Tabs should share the session of their parent but navigate independently.
+func TestTabIsolation(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "1", Path: "/"})
+			io.WriteString(w, "<html><head><title>Login</title></head></html>")
+		case "/private":
+			if _, err := r.Cookie("session"); err != nil {
+				http.Error(w, "Forbidden", 403)
+				return
+			}
+			io.WriteString(w, "<html><head><title>Private</title></head></html>")
+		}
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	if err := bow.GET(ts.URL + "/login"); err != nil {
+		t.Fatal(err)
+	}
+
+	tab := bow.NewTab()
+	if err := tab.GET(ts.URL + "/private"); err != nil {
+		t.Fatal(err)
+	}
+	if tab.StatusCode() != 200 {
+		t.Fatalf("Tab did not share the session cookie, got status %d", tab.StatusCode())
+	}
+	if tab.Title() != "Private" {
+		t.Errorf("Expected tab title 'Private', got '%s'", tab.Title())
+	}
+	if bow.Title() != "Login" {
+		t.Errorf("Tab navigation changed the parent page to '%s'", bow.Title())
+	}
+	if bow.HistoryJar().Len() != 1 {
+		t.Errorf("Tab navigation was recorded in the parent history")
+	}
+
+	bow.AddRequestHeader("Accept-Language", "en")
+	tab = bow.NewTab()
+	tab.AddRequestHeader("Accept-Language", "fr")
+	tab.headers.Add("Accept-Language", "de")
+	if got := bow.headers["Accept-Language"]; len(got) != 1 || got[0] != "en" {
+		t.Errorf("Tab headers changed the parent headers to %v", got)
+	}
+}
+
+func TestCanonicalURL(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/article":
+			io.WriteString(w, `<html><head><link rel="canonical" href="/articles/1?utm_source=feed"></head></html>`)
+		default:
+			io.WriteString(w, `<html><head><title>Plain</title></head></html>`)
+		}
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	if bow.CanonicalURL() != nil {
+		t.Error("Expected nil canonical URL before loading a page")
+	}
+
+	if err := bow.GET(ts.URL + "/article?ref=home#top"); err != nil {
+		t.Fatal(err)
+	}
+	if got := bow.CanonicalURL().String(); got != ts.URL+"/articles/1" {
+		t.Errorf("Expected the canonical link URL, got '%s'", got)
+	}
+
+	if err := bow.GET(ts.URL + "/plain?b=2&a=1&utm_medium=x"); err != nil {
+		t.Fatal(err)
+	}
+	if got := bow.CanonicalURL().String(); got != ts.URL+"/plain?a=1&b=2" {
+		t.Errorf("Expected the normalized page URL, got '%s'", got)
+	}
+}
+
+func TestDocument(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `<html><body><p class="a">A</p><p>B</p></body></html>`)
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	if bow.Document() != nil {
+		t.Error("Expected no document before loading a page")
+	}
+	if err := bow.GET(ts.URL + "/page"); err != nil {
+		t.Fatal(err)
+	}
+	doc := bow.Document()
+	if doc.URL().String() != ts.URL+"/page" {
+		t.Errorf("Expected the URL of the page, got '%s'", doc.URL())
+	}
+	if doc.Find("p").Length() != 2 || doc.Find("p.a").Text() != bow.Find("p.a").Text() {
+		t.Errorf("Expected the document to match the DOM, got '%s'", doc.Find("p").Text())
+	}
+}
+
+func TestHasVisited(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/old" {
+			http.Redirect(w, r, "/new", 301)
+			return
+		}
+		io.WriteString(w, "<html></html>")
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	bow.SetVisitedJar(jar.NewMemoryVisited())
+	if bow.HasVisited(ts.URL + "/old") {
+		t.Error("Expected page to not be visited")
+	}
+
+	if err := bow.GET(ts.URL + "/old?utm_source=x"); err != nil {
+		t.Fatal(err)
+	}
+	if !bow.HasVisited(ts.URL + "/old") {
+		t.Error("Expected requested URL to be visited")
+	}
+	if !bow.HasVisited(ts.URL + "/new#top") {
+		t.Error("Expected redirected URL to be visited")
+	}
+	if !bow.NewTab().HasVisited(ts.URL + "/new") {
+		t.Error("Expected tabs to share the visited jar")
+	}
+}
+
+func TestCookieEditing(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc", Path: "/"})
+		}
+		if c, err := r.Cookie("session"); err == nil {
+			io.WriteString(w, c.Value)
+		}
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	if err := bow.GET(ts.URL + "/login"); err != nil {
+		t.Fatal(err)
+	}
+	host := bow.URL().Host
+	cookies := bow.Cookies(host)
+	if len(cookies) != 1 || cookies[0].Value != "abc" {
+		t.Fatalf("Expected the session cookie, got %v", cookies)
+	}
+
+	bow.SetCookie(host, &http.Cookie{Name: "session", Value: "xyz", Path: "/"})
+	bow.GET(ts.URL + "/")
+	if string(bow.body) != "xyz" {
+		t.Errorf("Expected the edited cookie to be sent, got '%s'", bow.body)
+	}
+
+	if err := bow.DeleteCookie(host, "session"); err != nil {
+		t.Fatal(err)
+	}
+	bow.GET(ts.URL + "/")
+	if string(bow.body) != "" {
+		t.Errorf("Expected the deleted cookie to not be sent, got '%s'", bow.body)
+	}
+
+	bow.SetCookie(host, &http.Cookie{Name: "a", Value: "1"})
+	bow.ClearCookies()
+	if len(bow.Cookies(host)) != 0 {
+		t.Error("Expected no cookies after ClearCookies")
+	}
+}
+
+func TestReloadBody(t *testing.T) {
+	var bodies []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, r.Method+" "+string(b))
+		w.Write([]byte("<html><body>ok</body></html>"))
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	if err := bow.POSTForm(ts.URL, url.Values{"q": {"surf"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := bow.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	// Readers without GetBody support are read in memory.
+	body := io.MultiReader(strings.NewReader("a="), strings.NewReader("b"))
+	if err := bow.POST(ts.URL, "application/x-www-form-urlencoded", body); err != nil {
+		t.Fatal(err)
+	}
+	if err := bow.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"POST q=surf", "POST q=surf", "POST a=b", "POST a=b"}
+	if len(bodies) != len(expected) {
+		t.Fatalf("Expected %d requests, got %v", len(expected), bodies)
+	}
+	for i, b := range expected {
+		if bodies[i] != b {
+			t.Errorf("Expected request %d to be %q, got %q", i, b, bodies[i])
+		}
+	}
+}
+
+func TestPutDelete(t *testing.T) {
+	var requests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		requests = append(requests, r.Method+" "+r.Header.Get("Content-Type")+" "+string(b))
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	if err := bow.PUT(ts.URL, "application/json", strings.NewReader(`{"name":"surf"}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := bow.DELETE(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{`PUT application/json {"name":"surf"}`, "DELETE  "}
+	if len(requests) != 2 || requests[0] != expected[0] || requests[1] != expected[1] {
+		t.Errorf("Expected requests %q, got %q", expected, requests)
+	}
+}
+
+func TestPatchOptions(t *testing.T) {
+	var requests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		requests = append(requests, r.Method+" "+r.Header.Get("Content-Type")+" "+string(b))
+		if r.Method == "OPTIONS" {
+			w.Header().Set("Allow", "GET, PATCH, OPTIONS")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	if err := bow.OPTIONS(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if allow := bow.ResponseHeaders().Get("Allow"); allow != "GET, PATCH, OPTIONS" {
+		t.Errorf("Expected the Allow header, got %q", allow)
+	}
+	if err := bow.PATCH(ts.URL, "application/json", strings.NewReader(`{"name":"surf"}`)); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"OPTIONS  ", `PATCH application/json {"name":"surf"}`}
+	if len(requests) != 2 || requests[0] != expected[0] || requests[1] != expected[1] {
+		t.Errorf("Expected requests %q, got %q", expected, requests)
+	}
+}
+
+func TestRequestDo(t *testing.T) {
+	var requests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		requests = append(requests, r.Method+" "+r.Header.Get("X-Token")+" "+r.Header.Get("X-Extra")+" "+string(b))
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "1"})
+		w.Write([]byte("<html><head><title>" + r.Method + "</title></head></html>"))
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	bow.AddRequestHeader("X-Token", "secret")
+	if err := bow.Request("PROPFIND", ts.URL, strings.NewReader("<propfind/>"), WithHeader("Content-Type", "text/xml")); err != nil {
+		t.Fatal(err)
+	}
+	if bow.Title() != "PROPFIND" {
+		t.Errorf("Expected title %q, got %q", "PROPFIND", bow.Title())
+	}
+
+	req, _ := http.NewRequest("PURGE", ts.URL, io.NopCloser(strings.NewReader("all")))
+	if err := bow.Do(req, WithHeader("X-Extra", "1")); err != nil {
+		t.Fatal(err)
+	}
+	if bow.Title() != "PURGE" || bow.HistoryJar().Len() != 2 {
+		t.Errorf("Expected the response to be loaded, got %q with %d pages in history", bow.Title(), bow.HistoryJar().Len())
+	}
+	if err := bow.Reload(); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []string{"PROPFIND secret  <propfind/>", "PURGE secret 1 all", "PURGE secret 1 all"}
+	if strings.Join(requests, "|") != strings.Join(expected, "|") {
+		t.Errorf("Expected requests %q, got %q", expected, requests)
+	}
+	if len(bow.CookieJar().Cookies(req.URL)) != 1 {
+		t.Error("Expected the cookie to be stored")
+	}
+
+	req.Header["X-Token"][0] = "other"
+	if v := bow.headers.Values("X-Token"); len(v) != 1 || v[0] != "secret" {
+		t.Errorf("Expected the headers of the request not to alias the browser headers, got %q", v)
+	}
+}
+
+func TestLastResponse(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Trailer", "X-Checksum")
+		w.Write([]byte("<html><body><p>Hello</p></body></html>"))
+		w.Header().Set("X-Checksum", "abc")
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	if bow.LastRequest() != nil || bow.LastResponse() != nil {
+		t.Error("Expected no request nor response before loading a page")
+	}
+	if err := bow.POSTForm(ts.URL, url.Values{"q": {"surf"}}); err != nil {
+		t.Fatal(err)
+	}
+	if req := bow.LastRequest(); req == nil || req.Method != "POST" {
+		t.Errorf("Expected the POST request, got %v", req)
+	}
+	bow.SetBody("<html><body>Changed</body></html>")
+
+	for i := 0; i < 2; i++ {
+		resp := bow.LastResponse()
+		b, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b) != "<html><body><p>Hello</p></body></html>" {
+			t.Errorf("Expected the received body, got %q", b)
+		}
+		if resp.Trailer.Get("X-Checksum") != "abc" {
+			t.Errorf("Expected the trailer, got %v", resp.Trailer)
+		}
+	}
+}
+
+func TestJumpTo(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprintf(w, "<html><head><title>%s</title></head></html>", r.URL.Path)
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	for _, p := range []string{"/a", "/b", "/c", "/d"} {
+		if err := bow.GET(ts.URL + p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	found, err := bow.HistoryJar().Find(`/b$`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0] != 1 {
+		t.Fatalf("Expected /b at index 1, got %v", found)
+	}
+	if err := bow.JumpTo(found[0]); err != nil {
+		t.Fatal(err)
+	}
+	if bow.Title() != "/b" {
+		t.Errorf("Expected title /b, got %q", bow.Title())
+	}
+	if requests != 4 {
+		t.Errorf("Expected JumpTo not to send requests, got %d", requests)
+	}
+	if bow.HistoryJar().Len() != 2 {
+		t.Errorf("Expected 2 states left in the history, got %d", bow.HistoryJar().Len())
+	}
+	if err := bow.JumpTo(1); err == nil {
+		t.Error("Expected an error jumping past the start of the history")
+	}
+}
+
+func TestBackStripped(t *testing.T) {
+	requests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprintf(w, "<html><head><title>%s</title></head></html>", r.URL.Path)
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	bow.HistoryJar().(*jar.MemoryHistory).SetMaxFull(1)
+	for _, p := range []string{"/a", "/b", "/c"} {
+		if err := bow.GET(ts.URL + p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := bow.JumpTo(0); err != nil {
+		t.Fatal(err)
+	}
+	if bow.Title() != "/b" || requests != 3 {
+		t.Errorf("Expected the kept page /b without a request, got %q after %d requests", bow.Title(), requests)
+	}
+	if !bow.Back() {
+		t.Fatal("Expected the stripped page to be loaded again")
+	}
+	if bow.Title() != "/a" || bow.Find("title").Length() != 1 || requests != 4 {
+		t.Errorf("Expected the stripped page /a to be requested again, got %q after %d requests", bow.Title(), requests)
+	}
+
+	bow.HistoryJar().Push(&jar.State{Request: httptest.NewRequest("POST", ts.URL+"/form", nil), Stripped: true})
+	bow.GET(ts.URL + "/d")
+	if err := bow.JumpTo(1); err == nil {
+		t.Error("Expected an error going back to a stripped POST")
+	} else if _, ok := err.(errors.PageNotLoaded); !ok {
+		t.Errorf("Expected PageNotLoaded, got %T", err)
+	}
+	if bow.Title() != "" {
+		t.Errorf("Expected an empty page, got %q", bow.Title())
+	}
+}
+
+func TestOpenBookmarkWith(t *testing.T) {
+	var query string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query().Get("q")
+		w.Write([]byte("<html></html>"))
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	bow.SetBookmarksJar(jar.NewMemoryBookmarks())
+	if err := bow.BookmarksJar().Save("search", ts.URL+"/search?q={query}"); err != nil {
+		t.Fatal(err)
+	}
+	if err := bow.OpenBookmarkWith("search", map[string]string{"query": "surf & go"}); err != nil {
+		t.Fatal(err)
+	}
+	if query != "surf & go" {
+		t.Errorf("Expected query %q, got %q", "surf & go", query)
+	}
+	if err := bow.OpenBookmark("search"); err == nil {
+		t.Error("Expected an error opening a templated bookmark without parameters")
+	}
+}
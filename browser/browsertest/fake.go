@@ -0,0 +1,1982 @@
+// Package browsertest provides a fake browser.Browsable for unit tests.
+package browsertest
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/beevik/etree"
+	"github.com/lostinblue/surf/auth"
+	"github.com/lostinblue/surf/browser"
+	"github.com/lostinblue/surf/credentials"
+	"github.com/lostinblue/surf/dom"
+	"github.com/lostinblue/surf/headers"
+	"github.com/lostinblue/surf/jar"
+	"github.com/lostinblue/surf/notify"
+	"github.com/lostinblue/surf/profiles"
+	"github.com/lostinblue/surf/render"
+	"github.com/lostinblue/surf/tor"
+	"github.com/robertkrimen/otto"
+)
+
+// Fake implements browser.Browsable.
+var _ browser.Browsable = (*Fake)(nil)
+
+// Call is a method call recorded by Fake.
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+// Fake is a browser.Browsable whose methods are stubbed with functions.
+//
+// Every method records its call and runs the matching On field when it
+// is set, returning zero values otherwise.
+//
+// Fake is maintained by hand: each method added to browser.Browsable needs
+// an On field and a method here, and the assertion above stops the build
+// until they are added.
+type Fake struct {
+	OnSetUserAgent           func(string)
+	OnUserAgent              func() string
+	OnSetAttribute           func(browser.Attribute, bool)
+	OnSetAttributes          func(browser.AttributeMap)
+	OnAttribute              func(browser.Attribute) bool
+	OnSetProfiles            func(*profiles.Set)
+	OnProfiles               func() *profiles.Set
+	OnSetReferrerPolicy      func(browser.ReferrerPolicy)
+	OnReferrerPolicy         func() browser.ReferrerPolicy
+	OnSetState               func(*jar.State)
+	OnState                  func() *jar.State
+	OnSetBookmarksJar        func(jar.BookmarksJar)
+	OnBookmarksJar           func() jar.BookmarksJar
+	OnSetCookieJar           func(http.CookieJar)
+	OnCookieJar              func() http.CookieJar
+	OnSetHistoryJar          func(jar.History)
+	OnHistoryJar             func() jar.History
+	OnSetVisitedJar          func(jar.Visited)
+	OnVisitedJar             func() jar.Visited
+	OnHasVisited             func(string) bool
+	OnSetHeadersJar          func(http.Header)
+	OnApplyHeaderProfile     func(*headers.Profile)
+	OnSetTimeout             func(time.Duration)
+	OnTimeout                func() time.Duration
+	OnSetTransport           func(http.RoundTripper)
+	OnSetProxy               func(string) error
+	OnSetProxyFunc           func(func(*http.Request) (*url.URL, error)) error
+	OnProxy                  func() string
+	OnUseTor                 func(tor.Config) error
+	OnRenewTorIdentity       func() error
+	OnAddRewriteRule         func(browser.Matcher, browser.RewriteAction)
+	OnClearRewriteRules      func()
+	OnSetDefaultQuery        func(string, string, ...string)
+	OnDelDefaultQuery        func(string)
+	OnAllowHosts             func(...string)
+	OnDenyHosts              func(...string)
+	OnClearHostRules         func()
+	OnAddRequestHeader       func(string, string)
+	OnDelRequestHeader       func(string)
+	OnGET                    func(string, ...browser.RequestOption) error
+	OnHEAD                   func(string, ...browser.RequestOption) error
+	OnPOST                   func(string, string, io.Reader, ...browser.RequestOption) error
+	OnPUT                    func(string, string, io.Reader, ...browser.RequestOption) error
+	OnDELETE                 func(string, ...browser.RequestOption) error
+	OnPATCH                  func(string, string, io.Reader, ...browser.RequestOption) error
+	OnOPTIONS                func(string, ...browser.RequestOption) error
+	OnRequest                func(string, string, io.Reader, ...browser.RequestOption) error
+	OnDo                     func(*http.Request, ...browser.RequestOption) error
+	OnGETForm                func(string, url.Values, ...browser.RequestOption) error
+	OnOpenBookmark           func(string) error
+	OnOpenBookmarkWith       func(string, map[string]string, ...browser.RequestOption) error
+	OnOpenTemplate           func(string, map[string]string, ...browser.RequestOption) error
+	OnLoadFromFile           func(string) error
+	OnLoadFromReader         func(io.Reader, string) error
+	OnSetContent             func(string, string) error
+	OnSetBaseURL             func(string) error
+	OnBaseURL                func() *url.URL
+	OnPOSTForm               func(string, url.Values, ...browser.RequestOption) error
+	OnPOSTMultipart          func(string, url.Values, browser.FileSet, ...browser.RequestOption) error
+	OnBack                   func() bool
+	OnJumpTo                 func(int) error
+	OnResetPage              func()
+	OnReload                 func() error
+	OnReloadIfModified       func() (bool, error)
+	OnPendingRefresh         func() *browser.Refresh
+	OnCancelRefresh          func()
+	OnFollowMetaRefresh      func() error
+	OnAutoFollowRefreshBelow func(time.Duration)
+	OnPollUntil              func(string, time.Duration, time.Duration) error
+	OnPollUntilFunc          func(func(b browser.Browsable) bool, time.Duration, time.Duration) error
+	OnNextPage               func(string) error
+	OnPaginate               func(string) *browser.Paginator
+	OnHeaderLinks            func() []*browser.HeaderLink
+	OnHeaderLink             func(string) *browser.HeaderLink
+	OnFollowLinkHeader       func(string) error
+	OnCursor                 func(...string) string
+	OnFollowCursor           func(string, ...string) error
+	OnBlocked                func() *browser.BlockInfo
+	OnSetCaptchaSolver       func(browser.CaptchaSolver)
+	OnCaptchaSolver          func() browser.CaptchaSolver
+	OnSolveCaptcha           func() error
+	OnSetChallengeResolver   func(browser.ChallengeResolver)
+	OnChallengeResolver      func() browser.ChallengeResolver
+	OnLogin                  func(browser.LoginSpec) error
+	OnBookmark               func(string) error
+	OnClick                  func(string) error
+	OnForm                   func(string) (browser.Submittable, error)
+	OnForms                  func() []browser.Submittable
+	OnLinks                  func() []*browser.Link
+	OnImages                 func() []*browser.Image
+	OnResponsiveImages       func() []*browser.ResponsiveImage
+	OnStylesheets            func() []*browser.Stylesheet
+	OnStyleResources         func() []*browser.StyleResource
+	OnFonts                  func() ([]*browser.Font, error)
+	OnResourceHints          func() []*browser.ResourceHint
+	OnAlternates             func() []*browser.Alternate
+	OnScripts                func() []*browser.Script
+	OnCheckLinks             func(browser.CheckLinksOptions) *browser.LinkReport
+	OnSiteCookies            func() []*http.Cookie
+	OnCookies                func(string) []*http.Cookie
+	OnSetCookie              func(string, *http.Cookie)
+	OnDeleteCookie           func(string, string) error
+	OnClearCookies           func()
+	OnResolveURL             func(*url.URL) *url.URL
+	OnResolveStringURL       func(string) (string, error)
+	OnDownload               func(io.Writer) (int64, error)
+	OnWriteTo                func(io.Writer) (int64, error)
+	OnWriteDOM               func(io.Writer) (int64, error)
+	OnURL                    func() *url.URL
+	OnCanonicalURL           func() *url.URL
+	OnCanonical              func() *url.URL
+	OnStatusCode             func() int
+	OnTitle                  func() string
+	OnMetaTag                func(string) string
+	OnDescription            func() string
+	OnLanguage               func() string
+	OnCharset                func() string
+	OnContentEncoding        func() string
+	OnReport                 func() *browser.Report
+	OnEncodedSize            func() int64
+	OnResponseHeaders        func() http.Header
+	OnLastRequest            func() *http.Request
+	OnLastResponse           func() *http.Response
+	OnRequestHeaders         func() http.Header
+	OnHTML                   func() string
+	OnBody                   func() string
+	OnSanitizedBody          func(browser.Sanitizer) string
+	OnDOM                    func() *goquery.Document
+	OnDocument               func() dom.Document
+	OnSetBody                func(string)
+	OnMutateDom              func(func(doc *goquery.Document)) error
+	OnExpect                 func() *browser.Expectation
+	OnSetSessionGuard        func(*browser.SessionGuard)
+	OnSessionGuard           func() *browser.SessionGuard
+	OnOn                     func(int, browser.StatusHandler)
+	OnFind                   func(string) *goquery.Selection
+	OnFindText               func(string) (string, error)
+	OnAttr                   func(string, string) (string, error)
+	OnExists                 func(string) bool
+	OnCount                  func(string) int
+	OnSetParserLimits        func(browser.ParserLimits)
+	OnParserLimits           func() browser.ParserLimits
+	OnSetDecompressionLimits func(browser.DecompressionLimits)
+	OnDecompressionLimits    func() browser.DecompressionLimits
+	OnSetDialOptions         func(browser.DialOptions) error
+	OnDialOptions            func() browser.DialOptions
+	OnSetConnTimeouts        func(browser.ConnTimeouts) error
+	OnConnTimeouts           func() browser.ConnTimeouts
+	OnSchedule               func(string, time.Time, ...browser.RequestOption) *browser.ScheduledJob
+	OnRunSchedule            func(context.Context) error
+	OnSetNotifier            func(notify.Notifier)
+	OnNotifier               func() notify.Notifier
+	OnNotify                 func(*notify.Event) error
+	OnConnStats              func() []browser.HostConnStats
+	OnResetConnStats         func()
+	OnSetBudget              func(browser.Budget)
+	OnBudget                 func() browser.Budget
+	OnBudgetUsage            func() browser.BudgetUsage
+	OnSetHostBudget          func(string, browser.Budget)
+	OnHostBudgetUsage        func(string) browser.BudgetUsage
+	OnSetCredentialStore     func(credentials.Store)
+	OnCredentialStore        func() credentials.Store
+	OnSetAuthenticators      func(...auth.Authenticator)
+	OnAuthenticators         func() []auth.Authenticator
+	OnSignRequestsWith       func(auth.RequestSigner)
+	OnSigner                 func() auth.RequestSigner
+	OnSetHeadFilter          func(browser.HeadFilter)
+	OnHeadFilter             func() browser.HeadFilter
+	OnLastHead               func() *browser.HeadResult
+	OnSetExpectContinueAbove func(int64)
+	OnExpectContinueAbove    func() int64
+	OnDOMError               func() error
+	OnXML                    func() (*etree.Document, error)
+	OnText                   func() string
+	OnUnmarshal              func(interface{}) error
+	OnExportMarkdown         func(io.Writer) (int64, error)
+	OnExportMHTML            func(io.Writer) error
+	OnSnapshot               func(string) error
+	OnDiffSnapshot           func(string) (*browser.SnapshotDiff, error)
+	OnSetSnapshotsJar        func(jar.SnapshotsJar)
+	OnSnapshotsJar           func() jar.SnapshotsJar
+	OnSetRenderer            func(render.Renderer)
+	OnRenderer               func() render.Renderer
+	OnRender                 func(io.Writer, render.Format) error
+	OnScreenshot             func(io.Writer) error
+	OnNewTab                 func() *browser.Browser
+	OnNewJavaScriptVM        func()
+	OnRunJavaScript          func(string) (otto.Value, error)
+	OnSetLocalStorageJar     func(jar.Storage)
+	OnLocalStorageJar        func() jar.Storage
+	OnSetSessionStorageJar   func(jar.Storage)
+	OnSessionStorageJar      func() jar.Storage
+	OnProbeAsset             func(browser.Asset) (*browser.AssetInfo, error)
+	OnDownloadAssetAsync     func(context.Context, browser.DownloadableAsset, io.Writer, browser.AsyncDownloadChannel) context.CancelFunc
+	OnClose                  func() error
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+// Calls returns the calls made to the fake, in order.
+func (f *Fake) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// CallsTo returns the calls made to the given method, in order.
+func (f *Fake) CallsTo(method string) []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var calls []Call
+	for _, c := range f.calls {
+		if c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+	return calls
+}
+
+// Reset forgets the recorded calls.
+func (f *Fake) Reset() {
+	f.mu.Lock()
+	f.calls = nil
+	f.mu.Unlock()
+}
+
+func (f *Fake) record(method string, args ...interface{}) {
+	f.mu.Lock()
+	f.calls = append(f.calls, Call{Method: method, Args: args})
+	f.mu.Unlock()
+}
+
+// SetUserAgent records the call and runs OnSetUserAgent if set.
+func (f *Fake) SetUserAgent(ua string) {
+	f.record("SetUserAgent", ua)
+	if f.OnSetUserAgent != nil {
+		f.OnSetUserAgent(ua)
+	}
+}
+
+// UserAgent records the call and runs OnUserAgent if set.
+func (f *Fake) UserAgent() string {
+	f.record("UserAgent")
+	if f.OnUserAgent != nil {
+		return f.OnUserAgent()
+	}
+	return ""
+}
+
+// SetAttribute records the call and runs OnSetAttribute if set.
+func (f *Fake) SetAttribute(a browser.Attribute, v bool) {
+	f.record("SetAttribute", a, v)
+	if f.OnSetAttribute != nil {
+		f.OnSetAttribute(a, v)
+	}
+}
+
+// SetAttributes records the call and runs OnSetAttributes if set.
+func (f *Fake) SetAttributes(a browser.AttributeMap) {
+	f.record("SetAttributes", a)
+	if f.OnSetAttributes != nil {
+		f.OnSetAttributes(a)
+	}
+}
+
+// Attribute records the call and runs OnAttribute if set.
+func (f *Fake) Attribute(a browser.Attribute) bool {
+	f.record("Attribute", a)
+	if f.OnAttribute != nil {
+		return f.OnAttribute(a)
+	}
+	return false
+}
+
+// SetProfiles records the call and runs OnSetProfiles if set.
+func (f *Fake) SetProfiles(s *profiles.Set) {
+	f.record("SetProfiles", s)
+	if f.OnSetProfiles != nil {
+		f.OnSetProfiles(s)
+	}
+}
+
+// Profiles records the call and runs OnProfiles if set.
+func (f *Fake) Profiles() *profiles.Set {
+	f.record("Profiles")
+	if f.OnProfiles != nil {
+		return f.OnProfiles()
+	}
+	return nil
+}
+
+// SetReferrerPolicy records the call and runs OnSetReferrerPolicy if set.
+func (f *Fake) SetReferrerPolicy(p browser.ReferrerPolicy) {
+	f.record("SetReferrerPolicy", p)
+	if f.OnSetReferrerPolicy != nil {
+		f.OnSetReferrerPolicy(p)
+	}
+}
+
+// ReferrerPolicy records the call and runs OnReferrerPolicy if set.
+func (f *Fake) ReferrerPolicy() browser.ReferrerPolicy {
+	f.record("ReferrerPolicy")
+	if f.OnReferrerPolicy != nil {
+		return f.OnReferrerPolicy()
+	}
+	return ""
+}
+
+// SetState records the call and runs OnSetState if set.
+func (f *Fake) SetState(sj *jar.State) {
+	f.record("SetState", sj)
+	if f.OnSetState != nil {
+		f.OnSetState(sj)
+	}
+}
+
+// State records the call and runs OnState if set.
+func (f *Fake) State() *jar.State {
+	f.record("State")
+	if f.OnState != nil {
+		return f.OnState()
+	}
+	return nil
+}
+
+// SetBookmarksJar records the call and runs OnSetBookmarksJar if set.
+func (f *Fake) SetBookmarksJar(bj jar.BookmarksJar) {
+	f.record("SetBookmarksJar", bj)
+	if f.OnSetBookmarksJar != nil {
+		f.OnSetBookmarksJar(bj)
+	}
+}
+
+// BookmarksJar records the call and runs OnBookmarksJar if set.
+func (f *Fake) BookmarksJar() jar.BookmarksJar {
+	f.record("BookmarksJar")
+	if f.OnBookmarksJar != nil {
+		return f.OnBookmarksJar()
+	}
+	return nil
+}
+
+// SetCookieJar records the call and runs OnSetCookieJar if set.
+func (f *Fake) SetCookieJar(cj http.CookieJar) {
+	f.record("SetCookieJar", cj)
+	if f.OnSetCookieJar != nil {
+		f.OnSetCookieJar(cj)
+	}
+}
+
+// CookieJar records the call and runs OnCookieJar if set.
+func (f *Fake) CookieJar() http.CookieJar {
+	f.record("CookieJar")
+	if f.OnCookieJar != nil {
+		return f.OnCookieJar()
+	}
+	return nil
+}
+
+// SetHistoryJar records the call and runs OnSetHistoryJar if set.
+func (f *Fake) SetHistoryJar(hj jar.History) {
+	f.record("SetHistoryJar", hj)
+	if f.OnSetHistoryJar != nil {
+		f.OnSetHistoryJar(hj)
+	}
+}
+
+// HistoryJar records the call and runs OnHistoryJar if set.
+func (f *Fake) HistoryJar() jar.History {
+	f.record("HistoryJar")
+	if f.OnHistoryJar != nil {
+		return f.OnHistoryJar()
+	}
+	return nil
+}
+
+// SetVisitedJar records the call and runs OnSetVisitedJar if set.
+func (f *Fake) SetVisitedJar(vj jar.Visited) {
+	f.record("SetVisitedJar", vj)
+	if f.OnSetVisitedJar != nil {
+		f.OnSetVisitedJar(vj)
+	}
+}
+
+// VisitedJar records the call and runs OnVisitedJar if set.
+func (f *Fake) VisitedJar() jar.Visited {
+	f.record("VisitedJar")
+	if f.OnVisitedJar != nil {
+		return f.OnVisitedJar()
+	}
+	return nil
+}
+
+// HasVisited records the call and runs OnHasVisited if set.
+func (f *Fake) HasVisited(u string) bool {
+	f.record("HasVisited", u)
+	if f.OnHasVisited != nil {
+		return f.OnHasVisited(u)
+	}
+	return false
+}
+
+// SetHeadersJar records the call and runs OnSetHeadersJar if set.
+func (f *Fake) SetHeadersJar(h http.Header) {
+	f.record("SetHeadersJar", h)
+	if f.OnSetHeadersJar != nil {
+		f.OnSetHeadersJar(h)
+	}
+}
+
+// ApplyHeaderProfile records the call and runs OnApplyHeaderProfile if set.
+func (f *Fake) ApplyHeaderProfile(p *headers.Profile) {
+	f.record("ApplyHeaderProfile", p)
+	if f.OnApplyHeaderProfile != nil {
+		f.OnApplyHeaderProfile(p)
+	}
+}
+
+// SetTimeout records the call and runs OnSetTimeout if set.
+func (f *Fake) SetTimeout(t time.Duration) {
+	f.record("SetTimeout", t)
+	if f.OnSetTimeout != nil {
+		f.OnSetTimeout(t)
+	}
+}
+
+// Timeout records the call and runs OnTimeout if set.
+func (f *Fake) Timeout() time.Duration {
+	f.record("Timeout")
+	if f.OnTimeout != nil {
+		return f.OnTimeout()
+	}
+	return 0
+}
+
+// SetTransport records the call and runs OnSetTransport if set.
+func (f *Fake) SetTransport(rt http.RoundTripper) {
+	f.record("SetTransport", rt)
+	if f.OnSetTransport != nil {
+		f.OnSetTransport(rt)
+	}
+}
+
+// SetProxy records the call and runs OnSetProxy if set.
+func (f *Fake) SetProxy(u string) error {
+	f.record("SetProxy", u)
+	if f.OnSetProxy != nil {
+		return f.OnSetProxy(u)
+	}
+	return nil
+}
+
+// SetProxyFunc records the call and runs OnSetProxyFunc if set.
+func (f *Fake) SetProxyFunc(fn func(*http.Request) (*url.URL, error)) error {
+	f.record("SetProxyFunc", fn)
+	if f.OnSetProxyFunc != nil {
+		return f.OnSetProxyFunc(fn)
+	}
+	return nil
+}
+
+// Proxy records the call and runs OnProxy if set.
+func (f *Fake) Proxy() string {
+	f.record("Proxy")
+	if f.OnProxy != nil {
+		return f.OnProxy()
+	}
+	return ""
+}
+
+// UseTor records the call and runs OnUseTor if set.
+func (f *Fake) UseTor(cfg tor.Config) error {
+	f.record("UseTor", cfg)
+	if f.OnUseTor != nil {
+		return f.OnUseTor(cfg)
+	}
+	return nil
+}
+
+// RenewTorIdentity records the call and runs OnRenewTorIdentity if set.
+func (f *Fake) RenewTorIdentity() error {
+	f.record("RenewTorIdentity")
+	if f.OnRenewTorIdentity != nil {
+		return f.OnRenewTorIdentity()
+	}
+	return nil
+}
+
+// AddRewriteRule records the call and runs OnAddRewriteRule if set.
+func (f *Fake) AddRewriteRule(m browser.Matcher, a browser.RewriteAction) {
+	f.record("AddRewriteRule", m, a)
+	if f.OnAddRewriteRule != nil {
+		f.OnAddRewriteRule(m, a)
+	}
+}
+
+// ClearRewriteRules records the call and runs OnClearRewriteRules if set.
+func (f *Fake) ClearRewriteRules() {
+	f.record("ClearRewriteRules")
+	if f.OnClearRewriteRules != nil {
+		f.OnClearRewriteRules()
+	}
+}
+
+// SetDefaultQuery records the call and runs OnSetDefaultQuery if set.
+func (f *Fake) SetDefaultQuery(key string, value string, hosts ...string) {
+	f.record("SetDefaultQuery", key, value, hosts)
+	if f.OnSetDefaultQuery != nil {
+		f.OnSetDefaultQuery(key, value, hosts...)
+	}
+}
+
+// DelDefaultQuery records the call and runs OnDelDefaultQuery if set.
+func (f *Fake) DelDefaultQuery(key string) {
+	f.record("DelDefaultQuery", key)
+	if f.OnDelDefaultQuery != nil {
+		f.OnDelDefaultQuery(key)
+	}
+}
+
+// AllowHosts records the call and runs OnAllowHosts if set.
+func (f *Fake) AllowHosts(patterns ...string) {
+	f.record("AllowHosts", patterns)
+	if f.OnAllowHosts != nil {
+		f.OnAllowHosts(patterns...)
+	}
+}
+
+// DenyHosts records the call and runs OnDenyHosts if set.
+func (f *Fake) DenyHosts(patterns ...string) {
+	f.record("DenyHosts", patterns)
+	if f.OnDenyHosts != nil {
+		f.OnDenyHosts(patterns...)
+	}
+}
+
+// ClearHostRules records the call and runs OnClearHostRules if set.
+func (f *Fake) ClearHostRules() {
+	f.record("ClearHostRules")
+	if f.OnClearHostRules != nil {
+		f.OnClearHostRules()
+	}
+}
+
+// AddRequestHeader records the call and runs OnAddRequestHeader if set.
+func (f *Fake) AddRequestHeader(name string, value string) {
+	f.record("AddRequestHeader", name, value)
+	if f.OnAddRequestHeader != nil {
+		f.OnAddRequestHeader(name, value)
+	}
+}
+
+// DelRequestHeader records the call and runs OnDelRequestHeader if set.
+func (f *Fake) DelRequestHeader(name string) {
+	f.record("DelRequestHeader", name)
+	if f.OnDelRequestHeader != nil {
+		f.OnDelRequestHeader(name)
+	}
+}
+
+// GET records the call and runs OnGET if set.
+func (f *Fake) GET(u string, opts ...browser.RequestOption) error {
+	f.record("GET", u, opts)
+	if f.OnGET != nil {
+		return f.OnGET(u, opts...)
+	}
+	return nil
+}
+
+// HEAD records the call and runs OnHEAD if set.
+func (f *Fake) HEAD(u string, opts ...browser.RequestOption) error {
+	f.record("HEAD", u, opts)
+	if f.OnHEAD != nil {
+		return f.OnHEAD(u, opts...)
+	}
+	return nil
+}
+
+// POST records the call and runs OnPOST if set.
+func (f *Fake) POST(u string, contentType string, body io.Reader, opts ...browser.RequestOption) error {
+	f.record("POST", u, contentType, body, opts)
+	if f.OnPOST != nil {
+		return f.OnPOST(u, contentType, body, opts...)
+	}
+	return nil
+}
+
+// PUT records the call and runs OnPUT if set.
+func (f *Fake) PUT(u string, contentType string, body io.Reader, opts ...browser.RequestOption) error {
+	f.record("PUT", u, contentType, body, opts)
+	if f.OnPUT != nil {
+		return f.OnPUT(u, contentType, body, opts...)
+	}
+	return nil
+}
+
+// DELETE records the call and runs OnDELETE if set.
+func (f *Fake) DELETE(u string, opts ...browser.RequestOption) error {
+	f.record("DELETE", u, opts)
+	if f.OnDELETE != nil {
+		return f.OnDELETE(u, opts...)
+	}
+	return nil
+}
+
+// PATCH records the call and runs OnPATCH if set.
+func (f *Fake) PATCH(u string, contentType string, body io.Reader, opts ...browser.RequestOption) error {
+	f.record("PATCH", u, contentType, body, opts)
+	if f.OnPATCH != nil {
+		return f.OnPATCH(u, contentType, body, opts...)
+	}
+	return nil
+}
+
+// OPTIONS records the call and runs OnOPTIONS if set.
+func (f *Fake) OPTIONS(u string, opts ...browser.RequestOption) error {
+	f.record("OPTIONS", u, opts)
+	if f.OnOPTIONS != nil {
+		return f.OnOPTIONS(u, opts...)
+	}
+	return nil
+}
+
+// Request records the call and runs OnRequest if set.
+func (f *Fake) Request(method string, u string, body io.Reader, opts ...browser.RequestOption) error {
+	f.record("Request", method, u, body, opts)
+	if f.OnRequest != nil {
+		return f.OnRequest(method, u, body, opts...)
+	}
+	return nil
+}
+
+// Do records the call and runs OnDo if set.
+func (f *Fake) Do(req *http.Request, opts ...browser.RequestOption) error {
+	f.record("Do", req, opts)
+	if f.OnDo != nil {
+		return f.OnDo(req, opts...)
+	}
+	return nil
+}
+
+// GETForm records the call and runs OnGETForm if set.
+func (f *Fake) GETForm(u string, data url.Values, opts ...browser.RequestOption) error {
+	f.record("GETForm", u, data, opts)
+	if f.OnGETForm != nil {
+		return f.OnGETForm(u, data, opts...)
+	}
+	return nil
+}
+
+// OpenBookmark records the call and runs OnOpenBookmark if set.
+func (f *Fake) OpenBookmark(name string) error {
+	f.record("OpenBookmark", name)
+	if f.OnOpenBookmark != nil {
+		return f.OnOpenBookmark(name)
+	}
+	return nil
+}
+
+// OpenBookmarkWith records the call and runs OnOpenBookmarkWith if set.
+func (f *Fake) OpenBookmarkWith(name string, params map[string]string, opts ...browser.RequestOption) error {
+	f.record("OpenBookmarkWith", name, params, opts)
+	if f.OnOpenBookmarkWith != nil {
+		return f.OnOpenBookmarkWith(name, params, opts...)
+	}
+	return nil
+}
+
+// OpenTemplate records the call and runs OnOpenTemplate if set.
+func (f *Fake) OpenTemplate(tmpl string, params map[string]string, opts ...browser.RequestOption) error {
+	f.record("OpenTemplate", tmpl, params, opts)
+	if f.OnOpenTemplate != nil {
+		return f.OnOpenTemplate(tmpl, params, opts...)
+	}
+	return nil
+}
+
+// LoadFromFile records the call and runs OnLoadFromFile if set.
+func (f *Fake) LoadFromFile(path string) error {
+	f.record("LoadFromFile", path)
+	if f.OnLoadFromFile != nil {
+		return f.OnLoadFromFile(path)
+	}
+	return nil
+}
+
+// LoadFromReader records the call and runs OnLoadFromReader if set.
+func (f *Fake) LoadFromReader(r io.Reader, baseURL string) error {
+	f.record("LoadFromReader", r, baseURL)
+	if f.OnLoadFromReader != nil {
+		return f.OnLoadFromReader(r, baseURL)
+	}
+	return nil
+}
+
+// SetContent records the call and runs OnSetContent if set.
+func (f *Fake) SetContent(html string, baseURL string) error {
+	f.record("SetContent", html, baseURL)
+	if f.OnSetContent != nil {
+		return f.OnSetContent(html, baseURL)
+	}
+	return nil
+}
+
+// SetBaseURL records the call and runs OnSetBaseURL if set.
+func (f *Fake) SetBaseURL(u string) error {
+	f.record("SetBaseURL", u)
+	if f.OnSetBaseURL != nil {
+		return f.OnSetBaseURL(u)
+	}
+	return nil
+}
+
+// BaseURL records the call and runs OnBaseURL if set.
+func (f *Fake) BaseURL() *url.URL {
+	f.record("BaseURL")
+	if f.OnBaseURL != nil {
+		return f.OnBaseURL()
+	}
+	return nil
+}
+
+// POSTForm records the call and runs OnPOSTForm if set.
+func (f *Fake) POSTForm(u string, data url.Values, opts ...browser.RequestOption) error {
+	f.record("POSTForm", u, data, opts)
+	if f.OnPOSTForm != nil {
+		return f.OnPOSTForm(u, data, opts...)
+	}
+	return nil
+}
+
+// POSTMultipart records the call and runs OnPOSTMultipart if set.
+func (f *Fake) POSTMultipart(u string, fields url.Values, files browser.FileSet, opts ...browser.RequestOption) error {
+	f.record("POSTMultipart", u, fields, files, opts)
+	if f.OnPOSTMultipart != nil {
+		return f.OnPOSTMultipart(u, fields, files, opts...)
+	}
+	return nil
+}
+
+// Back records the call and runs OnBack if set.
+func (f *Fake) Back() bool {
+	f.record("Back")
+	if f.OnBack != nil {
+		return f.OnBack()
+	}
+	return false
+}
+
+// JumpTo records the call and runs OnJumpTo if set.
+func (f *Fake) JumpTo(index int) error {
+	f.record("JumpTo", index)
+	if f.OnJumpTo != nil {
+		return f.OnJumpTo(index)
+	}
+	return nil
+}
+
+// ResetPage records the call and runs OnResetPage if set.
+func (f *Fake) ResetPage() {
+	f.record("ResetPage")
+	if f.OnResetPage != nil {
+		f.OnResetPage()
+	}
+}
+
+// Reload records the call and runs OnReload if set.
+func (f *Fake) Reload() error {
+	f.record("Reload")
+	if f.OnReload != nil {
+		return f.OnReload()
+	}
+	return nil
+}
+
+// ReloadIfModified records the call and runs OnReloadIfModified if set.
+func (f *Fake) ReloadIfModified() (bool, error) {
+	f.record("ReloadIfModified")
+	if f.OnReloadIfModified != nil {
+		return f.OnReloadIfModified()
+	}
+	return false, nil
+}
+
+// PendingRefresh records the call and runs OnPendingRefresh if set.
+func (f *Fake) PendingRefresh() *browser.Refresh {
+	f.record("PendingRefresh")
+	if f.OnPendingRefresh != nil {
+		return f.OnPendingRefresh()
+	}
+	return nil
+}
+
+// CancelRefresh records the call and runs OnCancelRefresh if set.
+func (f *Fake) CancelRefresh() {
+	f.record("CancelRefresh")
+	if f.OnCancelRefresh != nil {
+		f.OnCancelRefresh()
+	}
+}
+
+// FollowMetaRefresh records the call and runs OnFollowMetaRefresh if set.
+func (f *Fake) FollowMetaRefresh() error {
+	f.record("FollowMetaRefresh")
+	if f.OnFollowMetaRefresh != nil {
+		return f.OnFollowMetaRefresh()
+	}
+	return nil
+}
+
+// AutoFollowRefreshBelow records the call and runs OnAutoFollowRefreshBelow if set.
+func (f *Fake) AutoFollowRefreshBelow(d time.Duration) {
+	f.record("AutoFollowRefreshBelow", d)
+	if f.OnAutoFollowRefreshBelow != nil {
+		f.OnAutoFollowRefreshBelow(d)
+	}
+}
+
+// PollUntil records the call and runs OnPollUntil if set.
+func (f *Fake) PollUntil(expr string, interval time.Duration, timeout time.Duration) error {
+	f.record("PollUntil", expr, interval, timeout)
+	if f.OnPollUntil != nil {
+		return f.OnPollUntil(expr, interval, timeout)
+	}
+	return nil
+}
+
+// PollUntilFunc records the call and runs OnPollUntilFunc if set.
+func (f *Fake) PollUntilFunc(done func(b browser.Browsable) bool, interval time.Duration, timeout time.Duration) error {
+	f.record("PollUntilFunc", done, interval, timeout)
+	if f.OnPollUntilFunc != nil {
+		return f.OnPollUntilFunc(done, interval, timeout)
+	}
+	return nil
+}
+
+// NextPage records the call and runs OnNextPage if set.
+func (f *Fake) NextPage(selectorOrRel string) error {
+	f.record("NextPage", selectorOrRel)
+	if f.OnNextPage != nil {
+		return f.OnNextPage(selectorOrRel)
+	}
+	return nil
+}
+
+// Paginate records the call and runs OnPaginate if set.
+func (f *Fake) Paginate(selectorOrRel string) *browser.Paginator {
+	f.record("Paginate", selectorOrRel)
+	if f.OnPaginate != nil {
+		return f.OnPaginate(selectorOrRel)
+	}
+	return nil
+}
+
+// HeaderLinks records the call and runs OnHeaderLinks if set.
+func (f *Fake) HeaderLinks() []*browser.HeaderLink {
+	f.record("HeaderLinks")
+	if f.OnHeaderLinks != nil {
+		return f.OnHeaderLinks()
+	}
+	return nil
+}
+
+// HeaderLink records the call and runs OnHeaderLink if set.
+func (f *Fake) HeaderLink(rel string) *browser.HeaderLink {
+	f.record("HeaderLink", rel)
+	if f.OnHeaderLink != nil {
+		return f.OnHeaderLink(rel)
+	}
+	return nil
+}
+
+// FollowLinkHeader records the call and runs OnFollowLinkHeader if set.
+func (f *Fake) FollowLinkHeader(rel string) error {
+	f.record("FollowLinkHeader", rel)
+	if f.OnFollowLinkHeader != nil {
+		return f.OnFollowLinkHeader(rel)
+	}
+	return nil
+}
+
+// Cursor records the call and runs OnCursor if set.
+func (f *Fake) Cursor(keys ...string) string {
+	f.record("Cursor", keys)
+	if f.OnCursor != nil {
+		return f.OnCursor(keys...)
+	}
+	return ""
+}
+
+// FollowCursor records the call and runs OnFollowCursor if set.
+func (f *Fake) FollowCursor(param string, keys ...string) error {
+	f.record("FollowCursor", param, keys)
+	if f.OnFollowCursor != nil {
+		return f.OnFollowCursor(param, keys...)
+	}
+	return nil
+}
+
+// Blocked records the call and runs OnBlocked if set.
+func (f *Fake) Blocked() *browser.BlockInfo {
+	f.record("Blocked")
+	if f.OnBlocked != nil {
+		return f.OnBlocked()
+	}
+	return nil
+}
+
+// SetCaptchaSolver records the call and runs OnSetCaptchaSolver if set.
+func (f *Fake) SetCaptchaSolver(s browser.CaptchaSolver) {
+	f.record("SetCaptchaSolver", s)
+	if f.OnSetCaptchaSolver != nil {
+		f.OnSetCaptchaSolver(s)
+	}
+}
+
+// CaptchaSolver records the call and runs OnCaptchaSolver if set.
+func (f *Fake) CaptchaSolver() browser.CaptchaSolver {
+	f.record("CaptchaSolver")
+	if f.OnCaptchaSolver != nil {
+		return f.OnCaptchaSolver()
+	}
+	return nil
+}
+
+// SolveCaptcha records the call and runs OnSolveCaptcha if set.
+func (f *Fake) SolveCaptcha() error {
+	f.record("SolveCaptcha")
+	if f.OnSolveCaptcha != nil {
+		return f.OnSolveCaptcha()
+	}
+	return nil
+}
+
+// SetChallengeResolver records the call and runs OnSetChallengeResolver if set.
+func (f *Fake) SetChallengeResolver(r browser.ChallengeResolver) {
+	f.record("SetChallengeResolver", r)
+	if f.OnSetChallengeResolver != nil {
+		f.OnSetChallengeResolver(r)
+	}
+}
+
+// ChallengeResolver records the call and runs OnChallengeResolver if set.
+func (f *Fake) ChallengeResolver() browser.ChallengeResolver {
+	f.record("ChallengeResolver")
+	if f.OnChallengeResolver != nil {
+		return f.OnChallengeResolver()
+	}
+	return nil
+}
+
+// Login records the call and runs OnLogin if set.
+func (f *Fake) Login(spec browser.LoginSpec) error {
+	f.record("Login", spec)
+	if f.OnLogin != nil {
+		return f.OnLogin(spec)
+	}
+	return nil
+}
+
+// Bookmark records the call and runs OnBookmark if set.
+func (f *Fake) Bookmark(name string) error {
+	f.record("Bookmark", name)
+	if f.OnBookmark != nil {
+		return f.OnBookmark(name)
+	}
+	return nil
+}
+
+// Click records the call and runs OnClick if set.
+func (f *Fake) Click(expr string) error {
+	f.record("Click", expr)
+	if f.OnClick != nil {
+		return f.OnClick(expr)
+	}
+	return nil
+}
+
+// Form records the call and runs OnForm if set.
+func (f *Fake) Form(expr string) (browser.Submittable, error) {
+	f.record("Form", expr)
+	if f.OnForm != nil {
+		return f.OnForm(expr)
+	}
+	return nil, nil
+}
+
+// Forms records the call and runs OnForms if set.
+func (f *Fake) Forms() []browser.Submittable {
+	f.record("Forms")
+	if f.OnForms != nil {
+		return f.OnForms()
+	}
+	return nil
+}
+
+// Links records the call and runs OnLinks if set.
+func (f *Fake) Links() []*browser.Link {
+	f.record("Links")
+	if f.OnLinks != nil {
+		return f.OnLinks()
+	}
+	return nil
+}
+
+// Images records the call and runs OnImages if set.
+func (f *Fake) Images() []*browser.Image {
+	f.record("Images")
+	if f.OnImages != nil {
+		return f.OnImages()
+	}
+	return nil
+}
+
+// ResponsiveImages records the call and runs OnResponsiveImages if set.
+func (f *Fake) ResponsiveImages() []*browser.ResponsiveImage {
+	f.record("ResponsiveImages")
+	if f.OnResponsiveImages != nil {
+		return f.OnResponsiveImages()
+	}
+	return nil
+}
+
+// Stylesheets records the call and runs OnStylesheets if set.
+func (f *Fake) Stylesheets() []*browser.Stylesheet {
+	f.record("Stylesheets")
+	if f.OnStylesheets != nil {
+		return f.OnStylesheets()
+	}
+	return nil
+}
+
+// StyleResources records the call and runs OnStyleResources if set.
+func (f *Fake) StyleResources() []*browser.StyleResource {
+	f.record("StyleResources")
+	if f.OnStyleResources != nil {
+		return f.OnStyleResources()
+	}
+	return nil
+}
+
+// Fonts records the call and runs OnFonts if set.
+func (f *Fake) Fonts() ([]*browser.Font, error) {
+	f.record("Fonts")
+	if f.OnFonts != nil {
+		return f.OnFonts()
+	}
+	return nil, nil
+}
+
+// ResourceHints records the call and runs OnResourceHints if set.
+func (f *Fake) ResourceHints() []*browser.ResourceHint {
+	f.record("ResourceHints")
+	if f.OnResourceHints != nil {
+		return f.OnResourceHints()
+	}
+	return nil
+}
+
+// Alternates records the call and runs OnAlternates if set.
+func (f *Fake) Alternates() []*browser.Alternate {
+	f.record("Alternates")
+	if f.OnAlternates != nil {
+		return f.OnAlternates()
+	}
+	return nil
+}
+
+// Scripts records the call and runs OnScripts if set.
+func (f *Fake) Scripts() []*browser.Script {
+	f.record("Scripts")
+	if f.OnScripts != nil {
+		return f.OnScripts()
+	}
+	return nil
+}
+
+// CheckLinks records the call and runs OnCheckLinks if set.
+func (f *Fake) CheckLinks(opts browser.CheckLinksOptions) *browser.LinkReport {
+	f.record("CheckLinks", opts)
+	if f.OnCheckLinks != nil {
+		return f.OnCheckLinks(opts)
+	}
+	return nil
+}
+
+// SiteCookies records the call and runs OnSiteCookies if set.
+func (f *Fake) SiteCookies() []*http.Cookie {
+	f.record("SiteCookies")
+	if f.OnSiteCookies != nil {
+		return f.OnSiteCookies()
+	}
+	return nil
+}
+
+// Cookies records the call and runs OnCookies if set.
+func (f *Fake) Cookies(host string) []*http.Cookie {
+	f.record("Cookies", host)
+	if f.OnCookies != nil {
+		return f.OnCookies(host)
+	}
+	return nil
+}
+
+// SetCookie records the call and runs OnSetCookie if set.
+func (f *Fake) SetCookie(host string, cookie *http.Cookie) {
+	f.record("SetCookie", host, cookie)
+	if f.OnSetCookie != nil {
+		f.OnSetCookie(host, cookie)
+	}
+}
+
+// DeleteCookie records the call and runs OnDeleteCookie if set.
+func (f *Fake) DeleteCookie(host string, name string) error {
+	f.record("DeleteCookie", host, name)
+	if f.OnDeleteCookie != nil {
+		return f.OnDeleteCookie(host, name)
+	}
+	return nil
+}
+
+// ClearCookies records the call and runs OnClearCookies if set.
+func (f *Fake) ClearCookies() {
+	f.record("ClearCookies")
+	if f.OnClearCookies != nil {
+		f.OnClearCookies()
+	}
+}
+
+// ResolveURL records the call and runs OnResolveURL if set.
+func (f *Fake) ResolveURL(u *url.URL) *url.URL {
+	f.record("ResolveURL", u)
+	if f.OnResolveURL != nil {
+		return f.OnResolveURL(u)
+	}
+	return nil
+}
+
+// ResolveStringURL records the call and runs OnResolveStringURL if set.
+func (f *Fake) ResolveStringURL(u string) (string, error) {
+	f.record("ResolveStringURL", u)
+	if f.OnResolveStringURL != nil {
+		return f.OnResolveStringURL(u)
+	}
+	return "", nil
+}
+
+// Download records the call and runs OnDownload if set.
+func (f *Fake) Download(o io.Writer) (int64, error) {
+	f.record("Download", o)
+	if f.OnDownload != nil {
+		return f.OnDownload(o)
+	}
+	return 0, nil
+}
+
+// WriteTo records the call and runs OnWriteTo if set.
+func (f *Fake) WriteTo(w io.Writer) (int64, error) {
+	f.record("WriteTo", w)
+	if f.OnWriteTo != nil {
+		return f.OnWriteTo(w)
+	}
+	return 0, nil
+}
+
+// WriteDOM records the call and runs OnWriteDOM if set.
+func (f *Fake) WriteDOM(w io.Writer) (int64, error) {
+	f.record("WriteDOM", w)
+	if f.OnWriteDOM != nil {
+		return f.OnWriteDOM(w)
+	}
+	return 0, nil
+}
+
+// URL records the call and runs OnURL if set.
+func (f *Fake) URL() *url.URL {
+	f.record("URL")
+	if f.OnURL != nil {
+		return f.OnURL()
+	}
+	return nil
+}
+
+// CanonicalURL records the call and runs OnCanonicalURL if set.
+func (f *Fake) CanonicalURL() *url.URL {
+	f.record("CanonicalURL")
+	if f.OnCanonicalURL != nil {
+		return f.OnCanonicalURL()
+	}
+	return nil
+}
+
+// Canonical records the call and runs OnCanonical if set.
+func (f *Fake) Canonical() *url.URL {
+	f.record("Canonical")
+	if f.OnCanonical != nil {
+		return f.OnCanonical()
+	}
+	return nil
+}
+
+// StatusCode records the call and runs OnStatusCode if set.
+func (f *Fake) StatusCode() int {
+	f.record("StatusCode")
+	if f.OnStatusCode != nil {
+		return f.OnStatusCode()
+	}
+	return 0
+}
+
+// Title records the call and runs OnTitle if set.
+func (f *Fake) Title() string {
+	f.record("Title")
+	if f.OnTitle != nil {
+		return f.OnTitle()
+	}
+	return ""
+}
+
+// MetaTag records the call and runs OnMetaTag if set.
+func (f *Fake) MetaTag(name string) string {
+	f.record("MetaTag", name)
+	if f.OnMetaTag != nil {
+		return f.OnMetaTag(name)
+	}
+	return ""
+}
+
+// Description records the call and runs OnDescription if set.
+func (f *Fake) Description() string {
+	f.record("Description")
+	if f.OnDescription != nil {
+		return f.OnDescription()
+	}
+	return ""
+}
+
+// Language records the call and runs OnLanguage if set.
+func (f *Fake) Language() string {
+	f.record("Language")
+	if f.OnLanguage != nil {
+		return f.OnLanguage()
+	}
+	return ""
+}
+
+// Charset records the call and runs OnCharset if set.
+func (f *Fake) Charset() string {
+	f.record("Charset")
+	if f.OnCharset != nil {
+		return f.OnCharset()
+	}
+	return ""
+}
+
+// ContentEncoding records the call and runs OnContentEncoding if set.
+func (f *Fake) ContentEncoding() string {
+	f.record("ContentEncoding")
+	if f.OnContentEncoding != nil {
+		return f.OnContentEncoding()
+	}
+	return ""
+}
+
+// Report records the call and runs OnReport if set.
+func (f *Fake) Report() *browser.Report {
+	f.record("Report")
+	if f.OnReport != nil {
+		return f.OnReport()
+	}
+	return nil
+}
+
+// EncodedSize records the call and runs OnEncodedSize if set.
+func (f *Fake) EncodedSize() int64 {
+	f.record("EncodedSize")
+	if f.OnEncodedSize != nil {
+		return f.OnEncodedSize()
+	}
+	return 0
+}
+
+// ResponseHeaders records the call and runs OnResponseHeaders if set.
+func (f *Fake) ResponseHeaders() http.Header {
+	f.record("ResponseHeaders")
+	if f.OnResponseHeaders != nil {
+		return f.OnResponseHeaders()
+	}
+	return nil
+}
+
+// LastRequest records the call and runs OnLastRequest if set.
+func (f *Fake) LastRequest() *http.Request {
+	f.record("LastRequest")
+	if f.OnLastRequest != nil {
+		return f.OnLastRequest()
+	}
+	return nil
+}
+
+// LastResponse records the call and runs OnLastResponse if set.
+func (f *Fake) LastResponse() *http.Response {
+	f.record("LastResponse")
+	if f.OnLastResponse != nil {
+		return f.OnLastResponse()
+	}
+	return nil
+}
+
+// RequestHeaders records the call and runs OnRequestHeaders if set.
+func (f *Fake) RequestHeaders() http.Header {
+	f.record("RequestHeaders")
+	if f.OnRequestHeaders != nil {
+		return f.OnRequestHeaders()
+	}
+	return nil
+}
+
+// HTML records the call and runs OnHTML if set.
+func (f *Fake) HTML() string {
+	f.record("HTML")
+	if f.OnHTML != nil {
+		return f.OnHTML()
+	}
+	return ""
+}
+
+// Body records the call and runs OnBody if set.
+func (f *Fake) Body() string {
+	f.record("Body")
+	if f.OnBody != nil {
+		return f.OnBody()
+	}
+	return ""
+}
+
+// SanitizedBody records the call and runs OnSanitizedBody if set.
+func (f *Fake) SanitizedBody(policy browser.Sanitizer) string {
+	f.record("SanitizedBody", policy)
+	if f.OnSanitizedBody != nil {
+		return f.OnSanitizedBody(policy)
+	}
+	return ""
+}
+
+// DOM records the call and runs OnDOM if set.
+func (f *Fake) DOM() *goquery.Document {
+	f.record("DOM")
+	if f.OnDOM != nil {
+		return f.OnDOM()
+	}
+	return nil
+}
+
+// Document records the call and runs OnDocument if set.
+func (f *Fake) Document() dom.Document {
+	f.record("Document")
+	if f.OnDocument != nil {
+		return f.OnDocument()
+	}
+	return nil
+}
+
+// SetBody records the call and runs OnSetBody if set.
+func (f *Fake) SetBody(html string) {
+	f.record("SetBody", html)
+	if f.OnSetBody != nil {
+		f.OnSetBody(html)
+	}
+}
+
+// MutateDom records the call and runs OnMutateDom if set.
+func (f *Fake) MutateDom(fn func(doc *goquery.Document)) error {
+	f.record("MutateDom", fn)
+	if f.OnMutateDom != nil {
+		return f.OnMutateDom(fn)
+	}
+	return nil
+}
+
+// Expect records the call and runs OnExpect if set.
+func (f *Fake) Expect() *browser.Expectation {
+	f.record("Expect")
+	if f.OnExpect != nil {
+		return f.OnExpect()
+	}
+	return nil
+}
+
+// SetSessionGuard records the call and runs OnSetSessionGuard if set.
+func (f *Fake) SetSessionGuard(g *browser.SessionGuard) {
+	f.record("SetSessionGuard", g)
+	if f.OnSetSessionGuard != nil {
+		f.OnSetSessionGuard(g)
+	}
+}
+
+// SessionGuard records the call and runs OnSessionGuard if set.
+func (f *Fake) SessionGuard() *browser.SessionGuard {
+	f.record("SessionGuard")
+	if f.OnSessionGuard != nil {
+		return f.OnSessionGuard()
+	}
+	return nil
+}
+
+// On records the call and runs OnOn if set.
+func (f *Fake) On(code int, h browser.StatusHandler) {
+	f.record("On", code, h)
+	if f.OnOn != nil {
+		f.OnOn(code, h)
+	}
+}
+
+// Find records the call and runs OnFind if set.
+func (f *Fake) Find(expr string) *goquery.Selection {
+	f.record("Find", expr)
+	if f.OnFind != nil {
+		return f.OnFind(expr)
+	}
+	return nil
+}
+
+// FindText records the call and runs OnFindText if set.
+func (f *Fake) FindText(expr string) (string, error) {
+	f.record("FindText", expr)
+	if f.OnFindText != nil {
+		return f.OnFindText(expr)
+	}
+	return "", nil
+}
+
+// Attr records the call and runs OnAttr if set.
+func (f *Fake) Attr(expr string, name string) (string, error) {
+	f.record("Attr", expr, name)
+	if f.OnAttr != nil {
+		return f.OnAttr(expr, name)
+	}
+	return "", nil
+}
+
+// Exists records the call and runs OnExists if set.
+func (f *Fake) Exists(expr string) bool {
+	f.record("Exists", expr)
+	if f.OnExists != nil {
+		return f.OnExists(expr)
+	}
+	return false
+}
+
+// Count records the call and runs OnCount if set.
+func (f *Fake) Count(expr string) int {
+	f.record("Count", expr)
+	if f.OnCount != nil {
+		return f.OnCount(expr)
+	}
+	return 0
+}
+
+// SetParserLimits records the call and runs OnSetParserLimits if set.
+func (f *Fake) SetParserLimits(l browser.ParserLimits) {
+	f.record("SetParserLimits", l)
+	if f.OnSetParserLimits != nil {
+		f.OnSetParserLimits(l)
+	}
+}
+
+// ParserLimits records the call and runs OnParserLimits if set.
+func (f *Fake) ParserLimits() browser.ParserLimits {
+	f.record("ParserLimits")
+	if f.OnParserLimits != nil {
+		return f.OnParserLimits()
+	}
+	return browser.ParserLimits{}
+}
+
+// SetDecompressionLimits records the call and runs OnSetDecompressionLimits if set.
+func (f *Fake) SetDecompressionLimits(l browser.DecompressionLimits) {
+	f.record("SetDecompressionLimits", l)
+	if f.OnSetDecompressionLimits != nil {
+		f.OnSetDecompressionLimits(l)
+	}
+}
+
+// DecompressionLimits records the call and runs OnDecompressionLimits if set.
+func (f *Fake) DecompressionLimits() browser.DecompressionLimits {
+	f.record("DecompressionLimits")
+	if f.OnDecompressionLimits != nil {
+		return f.OnDecompressionLimits()
+	}
+	return browser.DecompressionLimits{}
+}
+
+// SetDialOptions records the call and runs OnSetDialOptions if set.
+func (f *Fake) SetDialOptions(o browser.DialOptions) error {
+	f.record("SetDialOptions", o)
+	if f.OnSetDialOptions != nil {
+		return f.OnSetDialOptions(o)
+	}
+	return nil
+}
+
+// DialOptions records the call and runs OnDialOptions if set.
+func (f *Fake) DialOptions() browser.DialOptions {
+	f.record("DialOptions")
+	if f.OnDialOptions != nil {
+		return f.OnDialOptions()
+	}
+	return browser.DialOptions{}
+}
+
+// SetConnTimeouts records the call and runs OnSetConnTimeouts if set.
+func (f *Fake) SetConnTimeouts(ct browser.ConnTimeouts) error {
+	f.record("SetConnTimeouts", ct)
+	if f.OnSetConnTimeouts != nil {
+		return f.OnSetConnTimeouts(ct)
+	}
+	return nil
+}
+
+// ConnTimeouts records the call and runs OnConnTimeouts if set.
+func (f *Fake) ConnTimeouts() browser.ConnTimeouts {
+	f.record("ConnTimeouts")
+	if f.OnConnTimeouts != nil {
+		return f.OnConnTimeouts()
+	}
+	return browser.ConnTimeouts{}
+}
+
+// Schedule records the call and runs OnSchedule if set.
+func (f *Fake) Schedule(u string, at time.Time, opts ...browser.RequestOption) *browser.ScheduledJob {
+	f.record("Schedule", u, at, opts)
+	if f.OnSchedule != nil {
+		return f.OnSchedule(u, at, opts...)
+	}
+	return nil
+}
+
+// RunSchedule records the call and runs OnRunSchedule if set.
+func (f *Fake) RunSchedule(ctx context.Context) error {
+	f.record("RunSchedule", ctx)
+	if f.OnRunSchedule != nil {
+		return f.OnRunSchedule(ctx)
+	}
+	return nil
+}
+
+// SetNotifier records the call and runs OnSetNotifier if set.
+func (f *Fake) SetNotifier(n notify.Notifier) {
+	f.record("SetNotifier", n)
+	if f.OnSetNotifier != nil {
+		f.OnSetNotifier(n)
+	}
+}
+
+// Notifier records the call and runs OnNotifier if set.
+func (f *Fake) Notifier() notify.Notifier {
+	f.record("Notifier")
+	if f.OnNotifier != nil {
+		return f.OnNotifier()
+	}
+	return nil
+}
+
+// Notify records the call and runs OnNotify if set.
+func (f *Fake) Notify(e *notify.Event) error {
+	f.record("Notify", e)
+	if f.OnNotify != nil {
+		return f.OnNotify(e)
+	}
+	return nil
+}
+
+// ConnStats records the call and runs OnConnStats if set.
+func (f *Fake) ConnStats() []browser.HostConnStats {
+	f.record("ConnStats")
+	if f.OnConnStats != nil {
+		return f.OnConnStats()
+	}
+	return nil
+}
+
+// ResetConnStats records the call and runs OnResetConnStats if set.
+func (f *Fake) ResetConnStats() {
+	f.record("ResetConnStats")
+	if f.OnResetConnStats != nil {
+		f.OnResetConnStats()
+	}
+}
+
+// SetBudget records the call and runs OnSetBudget if set.
+func (f *Fake) SetBudget(b browser.Budget) {
+	f.record("SetBudget", b)
+	if f.OnSetBudget != nil {
+		f.OnSetBudget(b)
+	}
+}
+
+// Budget records the call and runs OnBudget if set.
+func (f *Fake) Budget() browser.Budget {
+	f.record("Budget")
+	if f.OnBudget != nil {
+		return f.OnBudget()
+	}
+	return browser.Budget{}
+}
+
+// BudgetUsage records the call and runs OnBudgetUsage if set.
+func (f *Fake) BudgetUsage() browser.BudgetUsage {
+	f.record("BudgetUsage")
+	if f.OnBudgetUsage != nil {
+		return f.OnBudgetUsage()
+	}
+	return browser.BudgetUsage{}
+}
+
+// SetHostBudget records the call and runs OnSetHostBudget if set.
+func (f *Fake) SetHostBudget(host string, b browser.Budget) {
+	f.record("SetHostBudget", host, b)
+	if f.OnSetHostBudget != nil {
+		f.OnSetHostBudget(host, b)
+	}
+}
+
+// HostBudgetUsage records the call and runs OnHostBudgetUsage if set.
+func (f *Fake) HostBudgetUsage(host string) browser.BudgetUsage {
+	f.record("HostBudgetUsage", host)
+	if f.OnHostBudgetUsage != nil {
+		return f.OnHostBudgetUsage(host)
+	}
+	return browser.BudgetUsage{}
+}
+
+// SetCredentialStore records the call and runs OnSetCredentialStore if set.
+func (f *Fake) SetCredentialStore(s credentials.Store) {
+	f.record("SetCredentialStore", s)
+	if f.OnSetCredentialStore != nil {
+		f.OnSetCredentialStore(s)
+	}
+}
+
+// CredentialStore records the call and runs OnCredentialStore if set.
+func (f *Fake) CredentialStore() credentials.Store {
+	f.record("CredentialStore")
+	if f.OnCredentialStore != nil {
+		return f.OnCredentialStore()
+	}
+	return nil
+}
+
+// SetAuthenticators records the call and runs OnSetAuthenticators if set.
+func (f *Fake) SetAuthenticators(a ...auth.Authenticator) {
+	f.record("SetAuthenticators", a)
+	if f.OnSetAuthenticators != nil {
+		f.OnSetAuthenticators(a...)
+	}
+}
+
+// Authenticators records the call and runs OnAuthenticators if set.
+func (f *Fake) Authenticators() []auth.Authenticator {
+	f.record("Authenticators")
+	if f.OnAuthenticators != nil {
+		return f.OnAuthenticators()
+	}
+	return nil
+}
+
+// SignRequestsWith records the call and runs OnSignRequestsWith if set.
+func (f *Fake) SignRequestsWith(s auth.RequestSigner) {
+	f.record("SignRequestsWith", s)
+	if f.OnSignRequestsWith != nil {
+		f.OnSignRequestsWith(s)
+	}
+}
+
+// Signer records the call and runs OnSigner if set.
+func (f *Fake) Signer() auth.RequestSigner {
+	f.record("Signer")
+	if f.OnSigner != nil {
+		return f.OnSigner()
+	}
+	return nil
+}
+
+// SetHeadFilter records the call and runs OnSetHeadFilter if set.
+func (f *Fake) SetHeadFilter(filter browser.HeadFilter) {
+	f.record("SetHeadFilter", filter)
+	if f.OnSetHeadFilter != nil {
+		f.OnSetHeadFilter(filter)
+	}
+}
+
+// HeadFilter records the call and runs OnHeadFilter if set.
+func (f *Fake) HeadFilter() browser.HeadFilter {
+	f.record("HeadFilter")
+	if f.OnHeadFilter != nil {
+		return f.OnHeadFilter()
+	}
+	return browser.HeadFilter{}
+}
+
+// LastHead records the call and runs OnLastHead if set.
+func (f *Fake) LastHead() *browser.HeadResult {
+	f.record("LastHead")
+	if f.OnLastHead != nil {
+		return f.OnLastHead()
+	}
+	return nil
+}
+
+// SetExpectContinueAbove records the call and runs OnSetExpectContinueAbove if set.
+func (f *Fake) SetExpectContinueAbove(n int64) {
+	f.record("SetExpectContinueAbove", n)
+	if f.OnSetExpectContinueAbove != nil {
+		f.OnSetExpectContinueAbove(n)
+	}
+}
+
+// ExpectContinueAbove records the call and runs OnExpectContinueAbove if set.
+func (f *Fake) ExpectContinueAbove() int64 {
+	f.record("ExpectContinueAbove")
+	if f.OnExpectContinueAbove != nil {
+		return f.OnExpectContinueAbove()
+	}
+	return 0
+}
+
+// DOMError records the call and runs OnDOMError if set.
+func (f *Fake) DOMError() error {
+	f.record("DOMError")
+	if f.OnDOMError != nil {
+		return f.OnDOMError()
+	}
+	return nil
+}
+
+// XML records the call and runs OnXML if set.
+func (f *Fake) XML() (*etree.Document, error) {
+	f.record("XML")
+	if f.OnXML != nil {
+		return f.OnXML()
+	}
+	return nil, nil
+}
+
+// Text records the call and runs OnText if set.
+func (f *Fake) Text() string {
+	f.record("Text")
+	if f.OnText != nil {
+		return f.OnText()
+	}
+	return ""
+}
+
+// Unmarshal records the call and runs OnUnmarshal if set.
+func (f *Fake) Unmarshal(v interface{}) error {
+	f.record("Unmarshal", v)
+	if f.OnUnmarshal != nil {
+		return f.OnUnmarshal(v)
+	}
+	return nil
+}
+
+// ExportMarkdown records the call and runs OnExportMarkdown if set.
+func (f *Fake) ExportMarkdown(w io.Writer) (int64, error) {
+	f.record("ExportMarkdown", w)
+	if f.OnExportMarkdown != nil {
+		return f.OnExportMarkdown(w)
+	}
+	return 0, nil
+}
+
+// ExportMHTML records the call and runs OnExportMHTML if set.
+func (f *Fake) ExportMHTML(w io.Writer) error {
+	f.record("ExportMHTML", w)
+	if f.OnExportMHTML != nil {
+		return f.OnExportMHTML(w)
+	}
+	return nil
+}
+
+// Snapshot records the call and runs OnSnapshot if set.
+func (f *Fake) Snapshot(name string) error {
+	f.record("Snapshot", name)
+	if f.OnSnapshot != nil {
+		return f.OnSnapshot(name)
+	}
+	return nil
+}
+
+// DiffSnapshot records the call and runs OnDiffSnapshot if set.
+func (f *Fake) DiffSnapshot(name string) (*browser.SnapshotDiff, error) {
+	f.record("DiffSnapshot", name)
+	if f.OnDiffSnapshot != nil {
+		return f.OnDiffSnapshot(name)
+	}
+	return nil, nil
+}
+
+// SetSnapshotsJar records the call and runs OnSetSnapshotsJar if set.
+func (f *Fake) SetSnapshotsJar(sj jar.SnapshotsJar) {
+	f.record("SetSnapshotsJar", sj)
+	if f.OnSetSnapshotsJar != nil {
+		f.OnSetSnapshotsJar(sj)
+	}
+}
+
+// SnapshotsJar records the call and runs OnSnapshotsJar if set.
+func (f *Fake) SnapshotsJar() jar.SnapshotsJar {
+	f.record("SnapshotsJar")
+	if f.OnSnapshotsJar != nil {
+		return f.OnSnapshotsJar()
+	}
+	return nil
+}
+
+// SetRenderer records the call and runs OnSetRenderer if set.
+func (f *Fake) SetRenderer(r render.Renderer) {
+	f.record("SetRenderer", r)
+	if f.OnSetRenderer != nil {
+		f.OnSetRenderer(r)
+	}
+}
+
+// Renderer records the call and runs OnRenderer if set.
+func (f *Fake) Renderer() render.Renderer {
+	f.record("Renderer")
+	if f.OnRenderer != nil {
+		return f.OnRenderer()
+	}
+	return nil
+}
+
+// Render records the call and runs OnRender if set.
+func (f *Fake) Render(w io.Writer, format render.Format) error {
+	f.record("Render", w, format)
+	if f.OnRender != nil {
+		return f.OnRender(w, format)
+	}
+	return nil
+}
+
+// Screenshot records the call and runs OnScreenshot if set.
+func (f *Fake) Screenshot(w io.Writer) error {
+	f.record("Screenshot", w)
+	if f.OnScreenshot != nil {
+		return f.OnScreenshot(w)
+	}
+	return nil
+}
+
+// NewTab records the call and runs OnNewTab if set.
+func (f *Fake) NewTab() *browser.Browser {
+	f.record("NewTab")
+	if f.OnNewTab != nil {
+		return f.OnNewTab()
+	}
+	return nil
+}
+
+// NewJavaScriptVM records the call and runs OnNewJavaScriptVM if set.
+func (f *Fake) NewJavaScriptVM() {
+	f.record("NewJavaScriptVM")
+	if f.OnNewJavaScriptVM != nil {
+		f.OnNewJavaScriptVM()
+	}
+}
+
+// RunJavaScript records the call and runs OnRunJavaScript if set.
+func (f *Fake) RunJavaScript(src string) (otto.Value, error) {
+	f.record("RunJavaScript", src)
+	if f.OnRunJavaScript != nil {
+		return f.OnRunJavaScript(src)
+	}
+	return otto.Value{}, nil
+}
+
+// SetLocalStorageJar records the call and runs OnSetLocalStorageJar if set.
+func (f *Fake) SetLocalStorageJar(sj jar.Storage) {
+	f.record("SetLocalStorageJar", sj)
+	if f.OnSetLocalStorageJar != nil {
+		f.OnSetLocalStorageJar(sj)
+	}
+}
+
+// LocalStorageJar records the call and runs OnLocalStorageJar if set.
+func (f *Fake) LocalStorageJar() jar.Storage {
+	f.record("LocalStorageJar")
+	if f.OnLocalStorageJar != nil {
+		return f.OnLocalStorageJar()
+	}
+	return nil
+}
+
+// SetSessionStorageJar records the call and runs OnSetSessionStorageJar if set.
+func (f *Fake) SetSessionStorageJar(sj jar.Storage) {
+	f.record("SetSessionStorageJar", sj)
+	if f.OnSetSessionStorageJar != nil {
+		f.OnSetSessionStorageJar(sj)
+	}
+}
+
+// SessionStorageJar records the call and runs OnSessionStorageJar if set.
+func (f *Fake) SessionStorageJar() jar.Storage {
+	f.record("SessionStorageJar")
+	if f.OnSessionStorageJar != nil {
+		return f.OnSessionStorageJar()
+	}
+	return nil
+}
+
+// ProbeAsset records the call and runs OnProbeAsset if set.
+func (f *Fake) ProbeAsset(asset browser.Asset) (*browser.AssetInfo, error) {
+	f.record("ProbeAsset", asset)
+	if f.OnProbeAsset != nil {
+		return f.OnProbeAsset(asset)
+	}
+	return nil, nil
+}
+
+// DownloadAssetAsync records the call and runs OnDownloadAssetAsync if set.
+func (f *Fake) DownloadAssetAsync(ctx context.Context, asset browser.DownloadableAsset, out io.Writer, ch browser.AsyncDownloadChannel) context.CancelFunc {
+	f.record("DownloadAssetAsync", ctx, asset, out, ch)
+	if f.OnDownloadAssetAsync != nil {
+		return f.OnDownloadAssetAsync(ctx, asset, out, ch)
+	}
+	return nil
+}
+
+// Close records the call and runs OnClose if set.
+func (f *Fake) Close() error {
+	f.record("Close")
+	if f.OnClose != nil {
+		return f.OnClose()
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package browsertest
+
+import (
+	"testing"
+
+	"github.com/lostinblue/surf/browser"
+)
+
+func scrapeTitle(bow browser.Browsable, u string) (string, error) {
+	if err := bow.GET(u); err != nil {
+		return "", err
+	}
+	return bow.Title(), nil
+}
+
+func TestFake(t *testing.T) {
+	f := &Fake{
+		OnTitle: func() string { return "Surf" },
+	}
+	title, err := scrapeTitle(f, "http://example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if title != "Surf" {
+		t.Errorf("Expected title %q, got %q", "Surf", title)
+	}
+
+	calls := f.Calls()
+	if len(calls) != 2 {
+		t.Fatalf("Expected 2 calls, got %d", len(calls))
+	}
+	if calls[0].Method != "GET" || calls[0].Args[0] != "http://example.com" {
+		t.Errorf("Unexpected first call %v", calls[0])
+	}
+	if len(f.CallsTo("Title")) != 1 {
+		t.Errorf("Expected 1 call to Title, got %d", len(f.CallsTo("Title")))
+	}
+
+	f.Reset()
+	if f.URL() != nil || f.StatusCode() != 0 || f.Find("a") != nil {
+		t.Error("Expected zero values from unset methods")
+	}
+	if len(f.Calls()) != 3 {
+		t.Errorf("Expected 3 calls after Reset, got %d", len(f.Calls()))
+	}
+}
@@ -0,0 +1,274 @@
+package browser
+
+import (
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+// Budget limits the requests sent by a browser and its tabs, eg to keep an
+// automated job from running away. Zero fields are not limited.
+type Budget struct {
+	// MaxRequests is the maximum number of requests, including redirects.
+	MaxRequests int
+
+	// MaxBytes is the maximum number of response body bytes read.
+	MaxBytes int64
+
+	// MaxDuration is the maximum time since the first request.
+	MaxDuration time.Duration
+}
+
+// BudgetUsage is the part of a budget which has been used.
+type BudgetUsage struct {
+	Requests int
+	Bytes    int64
+	Elapsed  time.Duration
+}
+
+// SetBudget sets the budget of the browser, shared by its tabs, and resets
+// its usage. Once a limit is reached, requests fail with an
+// errors.BudgetExceeded error, and so do responses whose body goes past
+// MaxBytes or MaxDuration while it's read.
+func (bow *Browser) SetBudget(b Budget) {
+	bow.requestBudget().set("", b)
+}
+
+// Budget returns the budget set with SetBudget().
+func (bow *Browser) Budget() Budget {
+	return bow.requestBudget().limits("")
+}
+
+// BudgetUsage returns the part of the browser budget used since it was set.
+func (bow *Browser) BudgetUsage() BudgetUsage {
+	return bow.requestBudget().used("")
+}
+
+// SetHostBudget sets the budget of the requests sent to the given host by
+// the browser and its tabs, and resets its usage. A zero Budget removes the
+// budget of the host.
+func (bow *Browser) SetHostBudget(host string, b Budget) {
+	bow.requestBudget().set(strings.ToLower(host), b)
+}
+
+// HostBudgetUsage returns the part of the host budget used since it was set.
+func (bow *Browser) HostBudgetUsage(host string) BudgetUsage {
+	return bow.requestBudget().used(strings.ToLower(host))
+}
+
+// requestBudget returns the budget of the browser, creating it on the first call.
+func (bow *Browser) requestBudget() *budget {
+	bow.budgetOnce.Do(func() {
+		if bow.budget == nil {
+			bow.budget = &budget{hosts: make(map[string]*budgetUsage)}
+		}
+	})
+	return bow.budget
+}
+
+// budget tracks the usage of the browser budget and of the host budgets.
+type budget struct {
+	mu      sync.Mutex
+	browser budgetUsage
+	hosts   map[string]*budgetUsage
+}
+
+// budgetUsage is a budget and its usage.
+type budgetUsage struct {
+	limits   Budget
+	requests int
+	bytes    int64
+	start    time.Time
+}
+
+// set sets the budget of the host, or of the browser when host is empty.
+func (b *budget) set(host string, limits Budget) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if host == "" {
+		b.browser = budgetUsage{limits: limits}
+		return
+	}
+	if limits == (Budget{}) {
+		delete(b.hosts, host)
+		return
+	}
+	b.hosts[host] = &budgetUsage{limits: limits}
+}
+
+// limits returns the budget of the host, or of the browser when host is empty.
+func (b *budget) limits(host string) Budget {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if u := b.usage(host); u != nil {
+		return u.limits
+	}
+	return Budget{}
+}
+
+// used returns the usage of the host budget, or of the browser budget when
+// host is empty.
+func (b *budget) used(host string) BudgetUsage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	u := b.usage(host)
+	if u == nil {
+		return BudgetUsage{}
+	}
+	var elapsed time.Duration
+	if !u.start.IsZero() {
+		elapsed = time.Since(u.start)
+	}
+	return BudgetUsage{Requests: u.requests, Bytes: u.bytes, Elapsed: elapsed}
+}
+
+// usage returns the usage of the host, or of the browser when host is
+// empty. The lock must be held.
+func (b *budget) usage(host string) *budgetUsage {
+	if host == "" {
+		return &b.browser
+	}
+	return b.hosts[host]
+}
+
+// spend counts a request to the URL, or returns an error when a budget
+// leaves no room for it.
+func (b *budget) spend(u *url.URL) error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	host := strings.ToLower(u.Hostname())
+	now := time.Now()
+	usages := []*budgetUsage{&b.browser}
+	if hu := b.hosts[host]; hu != nil {
+		usages = append(usages, hu)
+	}
+	for i, bu := range usages {
+		if err := bu.check(budgetHost(i, host), now, true); err != nil {
+			return err
+		}
+	}
+	for _, bu := range usages {
+		bu.requests++
+		if bu.start.IsZero() {
+			bu.start = now
+		}
+	}
+	return nil
+}
+
+// read counts the body bytes read from the host, and returns an error once
+// a budget is spent.
+func (b *budget) read(host string, n int) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	usages := []*budgetUsage{&b.browser}
+	if hu := b.hosts[host]; hu != nil {
+		usages = append(usages, hu)
+	}
+	now := time.Now()
+	for _, bu := range usages {
+		bu.bytes += int64(n)
+	}
+	for i, bu := range usages {
+		if err := bu.check(budgetHost(i, host), now, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deadline returns the time the duration budgets of the host end, if any.
+func (b *budget) deadline(host string) (time.Time, bool) {
+	if b == nil {
+		return time.Time{}, false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var deadline time.Time
+	for _, bu := range []*budgetUsage{&b.browser, b.hosts[host]} {
+		if bu == nil || bu.limits.MaxDuration <= 0 || bu.start.IsZero() {
+			continue
+		}
+		if d := bu.start.Add(bu.limits.MaxDuration); deadline.IsZero() || d.Before(deadline) {
+			deadline = d
+		}
+	}
+	return deadline, !deadline.IsZero()
+}
+
+// expired returns the error of a spent duration budget of the host, if any.
+func (b *budget) expired(host string) error {
+	if b == nil {
+		return nil
+	}
+	if deadline, ok := b.deadline(host); ok && !time.Now().Before(deadline) {
+		return b.read(host, 0)
+	}
+	return nil
+}
+
+// body wraps the response body read from the host, so its bytes are counted.
+func (b *budget) body(host string, rc io.ReadCloser) io.ReadCloser {
+	if b == nil || rc == nil {
+		return rc
+	}
+	return &budgetReader{ReadCloser: rc, b: b, host: host}
+}
+
+// check returns an error when the budget is spent. Requests are refused
+// once MaxRequests are sent, while bytes are refused past MaxBytes.
+func (bu *budgetUsage) check(host string, now time.Time, request bool) error {
+	l := bu.limits
+	of := ""
+	if host != "" {
+		of = " for " + host
+	}
+	switch {
+	case request && l.MaxRequests > 0 && bu.requests >= l.MaxRequests:
+		return errors.NewBudgetExceeded("requests", host, "The budget of %d requests%s is spent.", l.MaxRequests, of)
+	case l.MaxBytes > 0 && (bu.bytes > l.MaxBytes || (request && bu.bytes >= l.MaxBytes)):
+		return errors.NewBudgetExceeded("bytes", host, "The budget of %d bytes%s is spent.", l.MaxBytes, of)
+	case l.MaxDuration > 0 && !bu.start.IsZero() && now.Sub(bu.start) >= l.MaxDuration:
+		return errors.NewBudgetExceeded("duration", host, "The budget of %s%s is spent.", l.MaxDuration, of)
+	}
+	return nil
+}
+
+// budgetHost returns the host of the i-th usage checked by spend and read,
+// which is empty for the browser budget.
+func budgetHost(i int, host string) string {
+	if i == 0 {
+		return ""
+	}
+	return host
+}
+
+// budgetReader counts the bytes of a response body.
+type budgetReader struct {
+	io.ReadCloser
+	b    *budget
+	host string
+}
+
+// Read reads from the body, and returns an error once a budget is spent.
+func (r *budgetReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		if berr := r.b.read(r.host, n); berr != nil {
+			return n, berr
+		}
+	}
+	if err != nil && err != io.EOF {
+		if berr := r.b.expired(r.host); berr != nil {
+			return n, berr
+		}
+	}
+	return n, err
+}
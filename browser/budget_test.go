@@ -0,0 +1,129 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+func newBudgetServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, "/page", http.StatusFound)
+		case "/big":
+			w.Write([]byte(strings.Repeat("a", 10000)))
+		case "/slow":
+			time.Sleep(300 * time.Millisecond)
+			w.Write([]byte("slow"))
+		default:
+			w.Write([]byte("<html><head><title>Page</title></head></html>"))
+		}
+	}))
+}
+
+// assertBudgetExceeded checks the error is a BudgetExceeded error for the limit.
+func assertBudgetExceeded(t *testing.T, err error, limit, host string) {
+	t.Helper()
+	be, ok := err.(errors.BudgetExceeded)
+	if !ok {
+		t.Fatalf("Expected a BudgetExceeded error, got %v.", err)
+	}
+	if be.Limit != limit || be.Host != host {
+		t.Errorf("Expected the %s budget of '%s' to be spent, got %s of '%s'.", limit, host, be.Limit, be.Host)
+	}
+}
+
+func TestBudgetRequests(t *testing.T) {
+	ts := newBudgetServer()
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	bow.SetBudget(Budget{MaxRequests: 3})
+	for i := 0; i < 2; i++ {
+		if err := bow.GET(ts.URL + "/page"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The redirect is the fourth request.
+	assertBudgetExceeded(t, bow.GET(ts.URL+"/redirect"), "requests", "")
+	if u := bow.BudgetUsage(); u.Requests != 3 || u.Elapsed <= 0 {
+		t.Errorf("Expected 3 requests to be used, got %+v.", u)
+	}
+	assertBudgetExceeded(t, bow.NewTab().GET(ts.URL+"/page"), "requests", "")
+
+	bow.SetBudget(Budget{})
+	if err := bow.GET(ts.URL + "/redirect"); err != nil {
+		t.Errorf("Expected the budget to be removed, got %v.", err)
+	}
+	if u := bow.BudgetUsage(); u.Requests != 2 {
+		t.Errorf("Expected the usage to be reset, got %+v.", u)
+	}
+}
+
+func TestBudgetBytes(t *testing.T) {
+	ts := newBudgetServer()
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	bow.SetBudget(Budget{MaxBytes: 15000})
+	if err := bow.GET(ts.URL + "/big"); err != nil {
+		t.Fatal(err)
+	}
+	assertBudgetExceeded(t, bow.GET(ts.URL+"/big"), "bytes", "")
+	if bow.BudgetUsage().Bytes <= 15000 {
+		t.Errorf("Expected the bytes read to pass the budget, got %+v.", bow.BudgetUsage())
+	}
+	assertBudgetExceeded(t, bow.GET(ts.URL+"/page"), "bytes", "")
+	if bow.BudgetUsage().Requests != 2 {
+		t.Errorf("Expected the last request not to be sent, got %+v.", bow.BudgetUsage())
+	}
+}
+
+func TestBudgetDuration(t *testing.T) {
+	ts := newBudgetServer()
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	bow.SetBudget(Budget{MaxDuration: 100 * time.Millisecond})
+	if err := bow.GET(ts.URL + "/page"); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	assertBudgetExceeded(t, bow.GET(ts.URL+"/slow"), "duration", "")
+	if d := time.Since(start); d >= 300*time.Millisecond {
+		t.Errorf("Expected the request to be aborted at the end of the budget, took %s.", d)
+	}
+	assertBudgetExceeded(t, bow.GET(ts.URL+"/page"), "duration", "")
+}
+
+func TestHostBudget(t *testing.T) {
+	ts := newBudgetServer()
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	bow.SetHostBudget("127.0.0.1", Budget{MaxRequests: 1})
+	if err := bow.GET(ts.URL + "/page"); err != nil {
+		t.Fatal(err)
+	}
+	err := bow.GET(ts.URL + "/page")
+	assertBudgetExceeded(t, err, "requests", "127.0.0.1")
+	if !strings.Contains(err.Error(), "for 127.0.0.1") {
+		t.Errorf("Expected the host in the error, got %q.", err)
+	}
+	if u := bow.HostBudgetUsage("127.0.0.1"); u.Requests != 1 {
+		t.Errorf("Expected 1 request to the host, got %+v.", u)
+	}
+	if bow.BudgetUsage().Requests != 1 {
+		t.Errorf("Expected 1 request in the browser usage, got %+v.", bow.BudgetUsage())
+	}
+
+	bow.SetHostBudget("127.0.0.1", Budget{})
+	if err := bow.GET(ts.URL + "/page"); err != nil {
+		t.Errorf("Expected the host budget to be removed, got %v.", err)
+	}
+}
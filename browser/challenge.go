@@ -0,0 +1,139 @@
+package browser
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+// ChallengeResolver resolves anti-bot challenge pages, eg by adding the
+// clearance cookies of a real browser, or by fetching the page with an
+// alternate backend such as a headless browser.
+type ChallengeResolver interface {
+	// Resolve returns the response replacing the challenge page returned
+	// for the request, or an error when the challenge cannot be resolved.
+	Resolve(bow *Browser, req *http.Request, info *BlockInfo) (*http.Response, error)
+}
+
+// ChallengeResolverFunc is a function used as a ChallengeResolver.
+type ChallengeResolverFunc func(bow *Browser, req *http.Request, info *BlockInfo) (*http.Response, error)
+
+// Resolve calls the function.
+func (fn ChallengeResolverFunc) Resolve(bow *Browser, req *http.Request, info *BlockInfo) (*http.Response, error) {
+	return fn(bow, req, info)
+}
+
+// Doer sends HTTP requests, eg an *http.Client.
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// CookieResolver returns a resolver which adds the cookies returned by the
+// given function to the cookie jar, eg a cf_clearance cookie obtained with
+// a real browser, and sends the request again with the browser, so its
+// rewrite rules, host rules, profiles and signer apply to the retry.
+//
+// Challenges usually check the user agent too, so the browser should use the
+// user agent of the browser the cookies were obtained with.
+func CookieResolver(cookies func(info *BlockInfo) ([]*http.Cookie, error)) ChallengeResolver {
+	return ChallengeResolverFunc(func(bow *Browser, req *http.Request, info *BlockInfo) (*http.Response, error) {
+		c, err := cookies(info)
+		if err != nil {
+			return nil, err
+		}
+		cj := bow.CookieJar()
+		if cj == nil {
+			return nil, errors.New("The browser has no cookie jar to add the cookies to.")
+		}
+		cj.SetCookies(req.URL, c)
+		retry, err := resendRequest(req)
+		if err != nil {
+			return nil, err
+		}
+		resp, release, err := bow.do(retry)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+		return resp, nil
+	})
+}
+
+// releaseBody is a response body which releases the request once closed.
+type releaseBody struct {
+	io.ReadCloser
+	release context.CancelFunc
+}
+
+// Close closes the body and releases the request.
+func (b *releaseBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
+
+// BackendResolver returns a resolver which sends the request again with the
+// given backend, eg a client for a headless browser service.
+func BackendResolver(backend Doer) ChallengeResolver {
+	return ChallengeResolverFunc(func(bow *Browser, req *http.Request, info *BlockInfo) (*http.Response, error) {
+		retry, err := resendRequest(req)
+		if err != nil {
+			return nil, err
+		}
+		return backend.Do(retry)
+	})
+}
+
+// SetChallengeResolver sets the resolver of the anti-bot challenge pages
+// detected when the ChallengeDetection attribute is set.
+func (bow *Browser) SetChallengeResolver(r ChallengeResolver) {
+	bow.challengeResolver = r
+}
+
+// ChallengeResolver returns the resolver of the anti-bot challenge pages.
+func (bow *Browser) ChallengeResolver() ChallengeResolver {
+	return bow.challengeResolver
+}
+
+// challenge returns the challenge of the current page: a Cloudflare
+// challenge, or a captcha served with a 403 or 503 status.
+func (bow *Browser) challenge() *BlockInfo {
+	info := bow.Blocked()
+	if info == nil {
+		return nil
+	}
+	if info.Kind == BlockCloudflare || info.StatusCode == http.StatusForbidden || info.StatusCode == http.StatusServiceUnavailable {
+		return info
+	}
+	return nil
+}
+
+// resolveChallenge returns a ChallengeError when the current page is a
+// challenge, after trying the challenge resolver. The challenge page is
+// left loaded, so it can be inspected.
+func (bow *Browser) resolveChallenge(req *http.Request) error {
+	if !bow.attributes[ChallengeDetection] {
+		return nil
+	}
+	info := bow.challenge()
+	if info == nil {
+		return nil
+	}
+	if bow.challengeResolver != nil {
+		resp, err := bow.challengeResolver.Resolve(bow, req, info)
+		if err != nil {
+			return errors.NewChallengeError(string(info.Kind), info.StatusCode, "Cannot resolve the %s challenge of '%s': %s", info.Kind, info.URL, err)
+		}
+		if resp != nil {
+			if err := bow.loadResponse(req, resp, false); err != nil {
+				return err
+			}
+			if info = bow.challenge(); info == nil {
+				return nil
+			}
+		}
+	}
+	return errors.NewChallengeError(string(info.Kind), info.StatusCode, "The page '%s' returned a %s challenge with the status %d.", info.URL, info.Kind, info.StatusCode)
+}
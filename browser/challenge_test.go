@@ -0,0 +1,98 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lostinblue/surf/errors"
+	"github.com/lostinblue/surf/jar"
+)
+
+func TestChallengeError(t *testing.T) {
+	challenge := func(w http.ResponseWriter) {
+		w.Header().Set("Server", "cloudflare")
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`<html><head><title>Just a moment...</title></head>
+			<body><div id="challenge-platform"></div></body></html>`))
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c, err := r.Cookie("cf_clearance"); err != nil || c.Value != "ok" {
+			challenge(w)
+			return
+		}
+		w.Write([]byte(`<html><body><h1>Content</h1></body></html>`))
+	}))
+	defer ts.Close()
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><body><h1>From the backend</h1></body></html>`))
+	}))
+	defer backend.Close()
+
+	bow := newDefaultTestBrowser()
+	bow.SetCookieJar(jar.NewCookiesJar())
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatalf("Expected no error without challenge detection, got %v", err)
+	}
+
+	bow.SetAttribute(ChallengeDetection, true)
+	err := bow.GET(ts.URL)
+	ce, ok := err.(errors.ChallengeError)
+	if !ok {
+		t.Fatalf("Expected a ChallengeError, got %v", err)
+	}
+	if ce.Kind != string(BlockCloudflare) || ce.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected a cloudflare challenge with the status 403, got %+v", ce)
+	}
+	if bow.Title() != "Just a moment..." {
+		t.Errorf("Expected the challenge page to be loaded, got %q", bow.Title())
+	}
+
+	bow.SetChallengeResolver(CookieResolver(func(info *BlockInfo) ([]*http.Cookie, error) {
+		return []*http.Cookie{{Name: "cf_clearance", Value: "ok", Path: "/"}}, nil
+	}))
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if h := bow.Find("h1").Text(); h != "Content" {
+		t.Errorf("Expected the page after resolving the challenge, got %q", h)
+	}
+	if n := bow.HistoryJar().Len(); n != 3 {
+		t.Errorf("Expected the challenge page not to be added to the history, got %d entries", n)
+	}
+
+	bow.CookieJar().SetCookies(bow.URL(), []*http.Cookie{{Name: "cf_clearance", Value: "expired", Path: "/"}})
+	bow.SetChallengeResolver(BackendResolver(doerFunc(func(req *http.Request) (*http.Response, error) {
+		return http.Get(backend.URL)
+	})))
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if h := bow.Find("h1").Text(); h != "From the backend" {
+		t.Errorf("Expected the page of the backend, got %q", h)
+	}
+
+	bow.SetChallengeResolver(BackendResolver(doerFunc(func(req *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		challenge(rec)
+		return rec.Result(), nil
+	})))
+	if _, ok := bow.GET(ts.URL).(errors.ChallengeError); !ok {
+		t.Error("Expected a ChallengeError when the resolver returns a challenge")
+	}
+
+	bow.SetCookieJar(nil)
+	bow.SetChallengeResolver(CookieResolver(func(info *BlockInfo) ([]*http.Cookie, error) {
+		return []*http.Cookie{{Name: "cf_clearance", Value: "ok", Path: "/"}}, nil
+	}))
+	if _, ok := bow.GET(ts.URL).(errors.ChallengeError); !ok {
+		t.Error("Expected a ChallengeError when the browser has no cookie jar")
+	}
+}
+
+// doerFunc is a function used as a Doer.
+type doerFunc func(req *http.Request) (*http.Response, error)
+
+func (fn doerFunc) Do(req *http.Request) (*http.Response, error) {
+	return fn(req)
+}
@@ -0,0 +1,221 @@
+package browser
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/lostinblue/surf/errors"
+	"github.com/lostinblue/surf/jar"
+)
+
+// Close stops the browser and its tabs, which share its connections:
+//
+//   - the requests in flight are canceled, including the downloads started
+//     with DownloadAssetAsync(), and Close waits for the downloads to end,
+//     but not for their results to be received from the channel;
+//   - the pending refresh of the page is discarded;
+//   - the idle connections of the transport of the browser are closed;
+//   - the jars saved to disk, which implement jar.Flusher, are flushed.
+//
+// The requests sent once the browser is closed fail with an errors.Closed
+// error. Returns the first error flushing the jars.
+//
+// Closing a tab stops the tab and its own tabs only: the browser it was
+// opened from keeps working, and so do the connections they share.
+func (bow *Browser) Close() error {
+	l := bow.requestLifecycle()
+	l.close()
+	bow.CancelRefresh()
+	if l.parent == nil && bow.client != nil && bow.client.Transport != nil {
+		bow.client.CloseIdleConnections()
+	}
+
+	var first error
+	jars := []interface{}{
+		bow.CookieJar(), bow.bookmarks, bow.history, bow.visited,
+		bow.localStorage, bow.sessionStorage, bow.snapshots,
+	}
+	for _, j := range jars {
+		if f, ok := j.(jar.Flusher); ok {
+			if err := f.Flush(); err != nil && first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
+
+// DownloadAssetAsync downloads the asset with the session of the browser,
+// and sends the result to the channel once the download is complete. The
+// download is abandoned when ctx is done, when the returned function is
+// called, or when the browser is closed. The compressed bodies are decoded
+// within the decompression limits, as the pages are. See DownloadAssetAsync().
+func (bow *Browser) DownloadAssetAsync(ctx context.Context, asset DownloadableAsset, out io.Writer, ch AsyncDownloadChannel) context.CancelFunc {
+	if bow.client == nil {
+		bow.client = bow.buildClient()
+	}
+	if bow.connStats == nil {
+		bow.connStats = newConnStats()
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	req, err := bow.buildRequest("GET", asset.URL.String(), bow.URL(), nil)
+	l := bow.requestLifecycle()
+	if err == nil && !l.add() {
+		err = errors.NewClosed("Cannot download '%s'.", asset.URL)
+	}
+	if err != nil {
+		go func() {
+			ch <- &AsyncDownloadResult{Asset: asset, Writer: out, Error: err}
+		}()
+		return cancel
+	}
+
+	go func() {
+		start := time.Now()
+		results := &AsyncDownloadResult{Asset: asset, Writer: out}
+		resp, release, err := bow.do(req.WithContext(ctx))
+		if err == nil {
+			results.StatusCode = resp.StatusCode
+			var body io.Reader
+			if body, err = bow.decodeBody(resp); err == nil {
+				results.Size, err = io.Copy(out, body)
+			}
+			resp.Body.Close()
+			release()
+		}
+		if err != nil {
+			if l.isClosed() {
+				err = errors.NewClosed("The download of '%s' was canceled.", asset.URL)
+			} else if ctx.Err() != nil {
+				err = ctx.Err()
+			}
+		}
+		results.Error = err
+		results.Duration = time.Since(start)
+		cancel()
+		// The download ends before its result is sent, so Close() doesn't
+		// wait for the results nobody reads.
+		l.done()
+		ch <- results
+	}()
+	return cancel
+}
+
+// requestLifecycle returns the lifecycle of the browser, creating it once.
+func (bow *Browser) requestLifecycle() *lifecycle {
+	bow.lifecycleOnce.Do(func() {
+		if bow.lifecycle == nil {
+			bow.lifecycle = newLifecycle()
+		}
+	})
+	return bow.lifecycle
+}
+
+// lifecycle tracks the requests in flight and the asynchronous downloads of
+// a browser, so Close() cancels them. The requests and downloads of a tab
+// are also recorded by the lifecycles of the browsers it was opened from, so
+// closing them closes the tab, while closing the tab leaves them open.
+type lifecycle struct {
+	mu        sync.Mutex
+	closed    bool
+	next      int
+	cancels   map[int]context.CancelFunc
+	downloads sync.WaitGroup
+
+	// parent is the lifecycle of the browser the tab was opened from, or
+	// nil.
+	parent *lifecycle
+}
+
+// newLifecycle creates and returns a *lifecycle.
+func newLifecycle() *lifecycle {
+	return &lifecycle{cancels: make(map[int]context.CancelFunc)}
+}
+
+// child returns the lifecycle of a tab opened from the browser.
+func (l *lifecycle) child() *lifecycle {
+	c := newLifecycle()
+	c.parent = l
+	return c
+}
+
+// start returns a context derived from ctx which is canceled when the
+// browser or one of the browsers it was opened from is closed, and the
+// function releasing it. Returns an errors.Closed error when one of them is
+// closed.
+func (l *lifecycle) start(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	release := func() {}
+	if l.parent != nil {
+		var err error
+		if ctx, release, err = l.parent.start(ctx); err != nil {
+			return nil, nil, err
+		}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		release()
+		return nil, nil, errors.NewClosed("The request was not sent.")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	id := l.next
+	l.next++
+	l.cancels[id] = cancel
+	return ctx, func() {
+		cancel()
+		l.mu.Lock()
+		delete(l.cancels, id)
+		l.mu.Unlock()
+		release()
+	}, nil
+}
+
+// add records an asynchronous download, and returns false when the browser
+// or one of the browsers it was opened from is closed. done must be called
+// once the download ends.
+func (l *lifecycle) add() bool {
+	if l.parent != nil && !l.parent.add() {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		if l.parent != nil {
+			l.parent.done()
+		}
+		return false
+	}
+	l.downloads.Add(1)
+	return true
+}
+
+// done records the end of an asynchronous download.
+func (l *lifecycle) done() {
+	l.downloads.Done()
+	if l.parent != nil {
+		l.parent.done()
+	}
+}
+
+// isClosed returns whether the browser or one of the browsers it was opened
+// from is closed.
+func (l *lifecycle) isClosed() bool {
+	l.mu.Lock()
+	closed := l.closed
+	l.mu.Unlock()
+	return closed || l.parent != nil && l.parent.isClosed()
+}
+
+// close cancels the requests in flight, and waits for the downloads to end.
+func (l *lifecycle) close() {
+	l.mu.Lock()
+	l.closed = true
+	for id, cancel := range l.cancels {
+		cancel()
+		delete(l.cancels, id)
+	}
+	l.mu.Unlock()
+	l.downloads.Wait()
+}
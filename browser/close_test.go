@@ -0,0 +1,133 @@
+package browser
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lostinblue/surf/errors"
+	"github.com/lostinblue/surf/jar"
+)
+
+func TestClose(t *testing.T) {
+	started := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			w.Write([]byte("partial"))
+			w.(http.Flusher).Flush()
+			close(started)
+			<-r.Context().Done()
+			return
+		}
+		w.Header().Set("Refresh", "600; url=/later")
+		w.Write([]byte("<html><body>page</body></html>"))
+	}))
+	defer ts.Close()
+
+	file := filepath.Join(t.TempDir(), "storage.json")
+	storage, err := jar.NewFileStorage(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bow := newDefaultTestBrowser()
+	bow.SetLocalStorageJar(storage)
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if bow.PendingRefresh() == nil {
+		t.Fatal("Expected a pending refresh.")
+	}
+	tab := bow.NewTab()
+	grandchild := tab.NewTab()
+
+	closed := bow.NewTab()
+	if err := closed.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := closed.GET(ts.URL); err == nil {
+		t.Error("Expected an error opening a page in a closed tab.")
+	}
+	if err := bow.GET(ts.URL); err != nil {
+		t.Errorf("Expected closing a tab to leave its parent open, got %v.", err)
+	}
+	if err := tab.GET(ts.URL); err != nil {
+		t.Errorf("Expected closing a tab to leave the other tabs open, got %v.", err)
+	}
+
+	u, _ := url.Parse(ts.URL + "/slow")
+	ch := make(AsyncDownloadChannel, 1)
+	var out bytes.Buffer
+	bow.DownloadAssetAsync(context.Background(), NewImageAsset(u, "", "", "").DownloadableAsset, &out, ch)
+	select {
+	case <-started:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the download to start.")
+	}
+
+	if err := bow.Close(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case res := <-ch:
+		if _, ok := res.Error.(errors.Closed); !ok {
+			t.Errorf("Expected a Closed error for the download, got %v.", res.Error)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected the download to be canceled.")
+	}
+	if bow.PendingRefresh() != nil {
+		t.Error("Expected the pending refresh to be discarded.")
+	}
+	if _, err := os.Stat(file); err != nil {
+		t.Errorf("Expected the storage jar to be flushed, got %v.", err)
+	}
+	if err := bow.GET(ts.URL); err == nil {
+		t.Error("Expected an error opening a page once closed.")
+	} else if _, ok := err.(errors.Closed); !ok {
+		t.Errorf("Expected a Closed error, got %v.", err)
+	}
+	if err := tab.GET(ts.URL); err == nil {
+		t.Error("Expected the tabs to be closed with the browser.")
+	}
+	if err := grandchild.GET(ts.URL); err == nil {
+		t.Error("Expected the tabs of the tabs to be closed with the browser.")
+	}
+}
+
+func TestCloseUnreadDownloads(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("asset"))
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	ch := make(AsyncDownloadChannel, 1)
+	for i := 0; i < 3; i++ {
+		u, _ := url.Parse(ts.URL + "/asset")
+		bow.DownloadAssetAsync(context.Background(), NewImageAsset(u, "", "", "").DownloadableAsset, &bytes.Buffer{}, ch)
+	}
+
+	closed := make(chan error, 1)
+	go func() { closed <- bow.Close() }()
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected Close not to wait for the results to be read.")
+	}
+	for i := 0; i < 3; i++ {
+		select {
+		case <-ch:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Expected the result of every download.")
+		}
+	}
+}
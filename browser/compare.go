@@ -0,0 +1,215 @@
+package browser
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/lostinblue/surf/jar"
+	"golang.org/x/net/html"
+)
+
+// NodeChange is an element added to or removed from a page.
+type NodeChange struct {
+	// Path locates the element, eg "html > body > ul.results > li:nth-child(2)".
+	Path string
+
+	// Node is the opening tag of the element, with sorted attributes.
+	Node string
+}
+
+// TextChange is a text which changed in an element. Old is empty for added
+// texts, and New for removed ones.
+type TextChange struct {
+	// Path locates the element holding the text.
+	Path string
+
+	Old string
+	New string
+}
+
+// ChangeReport holds the structural changes between two fetches of a page,
+// see Compare().
+type ChangeReport struct {
+	// Added and Removed are the elements added to and removed from the page.
+	Added   []NodeChange
+	Removed []NodeChange
+
+	// Text contains the texts which changed.
+	Text []TextChange
+}
+
+// Changed returns a boolean value indicating whether the page changed.
+func (r *ChangeReport) Changed() bool {
+	return len(r.Added) > 0 || len(r.Removed) > 0 || len(r.Text) > 0
+}
+
+// String returns the changes, one per line, prefixed by "+" for added
+// elements, "-" for removed ones and "~" for changed texts.
+func (r *ChangeReport) String() string {
+	buff := &bytes.Buffer{}
+	for _, c := range r.Removed {
+		buff.WriteString("- " + c.Path + " " + c.Node + "\n")
+	}
+	for _, c := range r.Added {
+		buff.WriteString("+ " + c.Path + " " + c.Node + "\n")
+	}
+	for _, c := range r.Text {
+		buff.WriteString("~ " + c.Path + ": " + strconv.Quote(c.Old) + " => " + strconv.Quote(c.New) + "\n")
+	}
+	return buff.String()
+}
+
+// compareEntry is an element or text of a page compared by Compare().
+type compareEntry struct {
+	line string
+	path string
+	text string
+}
+
+// Compare returns the changes between the pages of two history states, eg
+// two fetches of the same URL, for bots alerting when a page changes.
+// Scripts, styles and comments are left out, and whitespace is collapsed as
+// with DiffSnapshot(). States without a body or DOM compare as empty pages,
+// as do the bodies exceeding DefaultParserLimits.
+func Compare(a, b *jar.State) *ChangeReport {
+	ea, eb := compareEntries(a), compareEntries(b)
+	la, lb := make([]string, len(ea)), make([]string, len(eb))
+	for i, e := range ea {
+		la[i] = e.line
+	}
+	for i, e := range eb {
+		lb[i] = e.line
+	}
+
+	r := &ChangeReport{}
+	var removedText, addedText []compareEntry
+	for _, d := range diffIndexes(la, lb) {
+		if d.op == DiffAdded {
+			e := eb[d.index]
+			if e.text != "" {
+				addedText = append(addedText, e)
+			} else {
+				r.Added = append(r.Added, NodeChange{Path: e.path, Node: strings.TrimSpace(e.line)})
+			}
+			continue
+		}
+		e := ea[d.index]
+		if e.text != "" {
+			removedText = append(removedText, e)
+		} else {
+			r.Removed = append(r.Removed, NodeChange{Path: e.path, Node: strings.TrimSpace(e.line)})
+		}
+	}
+
+	// Texts removed and added in the same element are reported as changed.
+	paired := make([]bool, len(addedText))
+	for _, old := range removedText {
+		c := TextChange{Path: old.path, Old: old.text}
+		for i, e := range addedText {
+			if !paired[i] && e.path == old.path {
+				c.New = e.text
+				paired[i] = true
+				break
+			}
+		}
+		r.Text = append(r.Text, c)
+	}
+	for i, e := range addedText {
+		if !paired[i] {
+			r.Text = append(r.Text, TextChange{Path: e.path, New: e.text})
+		}
+	}
+	return r
+}
+
+// compareEntries returns the elements and texts of the page of the state,
+// in document order.
+func compareEntries(s *jar.State) []compareEntry {
+	if s == nil {
+		return nil
+	}
+	dom := s.Dom
+	if dom == nil {
+		if len(s.Body) == 0 {
+			return nil
+		}
+		var err error
+		if dom, err = parseDocument(s.Body, DefaultParserLimits); err != nil {
+			return nil
+		}
+	}
+
+	var entries []compareEntry
+	var walk func(n *html.Node, path string, depth int)
+	walk = func(n *html.Node, path string, depth int) {
+		indent := strings.Repeat("  ", depth)
+		switch n.Type {
+		case html.TextNode:
+			if t := strings.Join(strings.Fields(n.Data), " "); t != "" {
+				entries = append(entries, compareEntry{line: indent + t, path: path, text: t})
+			}
+			return
+		case html.ElementNode:
+			if hiddenElements[n.Data] {
+				return
+			}
+			if path != "" {
+				path += " > "
+			}
+			path += nodePath(n)
+			entries = append(entries, compareEntry{line: indent + snapshotTag(n), path: path})
+			depth++
+		case html.DocumentNode:
+		default:
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, path, depth)
+		}
+	}
+	for _, n := range dom.Nodes {
+		walk(n, "", 0)
+	}
+	return entries
+}
+
+// nodePath returns the selector of the element among its siblings, eg
+// "div#main", "ul.results" or "li:nth-child(2)".
+func nodePath(n *html.Node) string {
+	sel := n.Data
+	for _, a := range n.Attr {
+		if a.Key == "id" && a.Val != "" {
+			return sel + "#" + a.Val
+		}
+	}
+	for _, a := range n.Attr {
+		if a.Key == "class" {
+			if classes := strings.Fields(a.Val); len(classes) > 0 {
+				sel += "." + classes[0]
+			}
+		}
+	}
+
+	if n.Parent == nil {
+		return sel
+	}
+	// Elements sharing their tag with a sibling are told apart by position.
+	pos, index, same := 0, 0, 0
+	for c := n.Parent.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+		pos++
+		if c == n {
+			index = pos
+		}
+		if c.Data == n.Data {
+			same++
+		}
+	}
+	if same > 1 {
+		sel += ":nth-child(" + strconv.Itoa(index) + ")"
+	}
+	return sel
+}
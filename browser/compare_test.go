@@ -0,0 +1,62 @@
+package browser
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lostinblue/surf/jar"
+)
+
+func TestCompare(t *testing.T) {
+	a := &jar.State{Body: []byte(`<html><body>
+		<h1>Prices</h1>
+		<ul class="results"><li>one: 10</li><li>two: 20</li></ul>
+		<p id="old">Sale</p>
+		<script>var t = 1;</script>
+	</body></html>`)}
+	b := &jar.State{Body: []byte(`<html><body>
+		<h1>Prices</h1>
+		<ul class="results"><li>one: 12</li><li>two: 20</li><li>three: 30</li></ul>
+		<script>var t = 2;</script>
+	</body></html>`)}
+
+	r := Compare(a, b)
+	if !r.Changed() {
+		t.Fatal("Expected the page to change")
+	}
+	if len(r.Removed) != 1 || r.Removed[0].Path != "html > body > p#old" {
+		t.Errorf("Expected p#old to be removed, got %+v", r.Removed)
+	}
+	if len(r.Added) != 1 || r.Added[0].Path != "html > body > ul.results > li:nth-child(3)" || r.Added[0].Node != "<li>" {
+		t.Errorf("Expected the third li to be added, got %+v", r.Added)
+	}
+	expected := []TextChange{
+		{Path: "html > body > ul.results > li:nth-child(1)", Old: "one: 10", New: "one: 12"},
+		{Path: "html > body > p#old", Old: "Sale"},
+		{Path: "html > body > ul.results > li:nth-child(3)", New: "three: 30"},
+	}
+	if len(r.Text) != len(expected) {
+		t.Fatalf("Expected %d text changes, got %+v", len(expected), r.Text)
+	}
+	for i, c := range expected {
+		if r.Text[i] != c {
+			t.Errorf("Expected text change %+v, got %+v", c, r.Text[i])
+		}
+	}
+	if !strings.Contains(r.String(), `~ html > body > ul.results > li:nth-child(1): "one: 10" => "one: 12"`) {
+		t.Errorf("Unexpected report:\n%s", r)
+	}
+
+	if Compare(a, a).Changed() {
+		t.Error("Expected no changes comparing a page with itself")
+	}
+	if r := Compare(nil, a); len(r.Added) == 0 || len(r.Removed) != 0 {
+		t.Errorf("Expected every element to be added to an empty page, got %+v", r)
+	}
+
+	defer func(l ParserLimits) { DefaultParserLimits = l }(DefaultParserLimits)
+	DefaultParserLimits = ParserLimits{MaxNodes: 3}
+	if r := Compare(nil, a); r.Changed() {
+		t.Errorf("Expected a page over the parser limits to compare as empty, got %+v", r)
+	}
+}
@@ -0,0 +1,79 @@
+package browser
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/lostinblue/surf/errors"
+	"github.com/lostinblue/surf/notify"
+)
+
+// ReloadIfModified reloads the current page with the If-None-Match and
+// If-Modified-Since headers set from the ETag and Last-Modified headers of
+// the last response, and returns whether the page changed.
+//
+// The current page is kept when the server answers 304 Not Modified. When
+// the server ignores the headers, the page changed if the new body differs
+// from the previous one.
+func (bow *Browser) ReloadIfModified() (bool, error) {
+	if bow.state.Request == nil {
+		return false, errors.NewPageNotLoaded("Cannot reload, the previous request failed.")
+	}
+	prev := bow.body
+	req, err := bow.conditionalRequest()
+	if err != nil {
+		return false, err
+	}
+	if err := bow.httpRequest(req); err != nil {
+		return false, err
+	}
+	if optionsFromRequest(req).notModified {
+		return false, nil
+	}
+	if bytes.Equal(prev, bow.body) {
+		return false, nil
+	}
+	bow.notify(notify.PageChanged, "", "The page changed since it was last loaded.", nil)
+	return true, nil
+}
+
+// conditionalRequest returns a copy of the current page request with the
+// validators of the current page response.
+func (bow *Browser) conditionalRequest() (*http.Request, error) {
+	req, err := resendRequest(bow.state.Request)
+	if err != nil {
+		return nil, err
+	}
+	o := *optionsFromRequest(req)
+	o.conditional = true
+	req = req.WithContext(context.WithValue(req.Context(), requestOptionsKey{}, &o))
+	req.Header.Del("If-None-Match")
+	req.Header.Del("If-Modified-Since")
+	if resp := bow.state.Response; resp != nil {
+		if etag := resp.Header.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if lm := resp.Header.Get("Last-Modified"); lm != "" {
+			req.Header.Set("If-Modified-Since", lm)
+		}
+	}
+	return req, nil
+}
+
+// keepNotModified discards a 304 Not Modified response to a conditional
+// request, keeping the current page. Returns whether the response was
+// discarded.
+func (bow *Browser) keepNotModified(resp *http.Response, o *requestOptions) bool {
+	if !o.conditional || resp.StatusCode != http.StatusNotModified {
+		return false
+	}
+	if resp.Body != nil {
+		io.Copy(ioutil.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	o.notModified = true
+	return true
+}
@@ -0,0 +1,75 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestReloadIfModified(t *testing.T) {
+	version := "1"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		etag := `"v` + version + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Write([]byte(`<html><head><title>Version ` + version + `</title></head></html>`))
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	if _, err := bow.ReloadIfModified(); err == nil {
+		t.Error("Expected an error when no page has been loaded")
+	}
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	changed, err := bow.ReloadIfModified()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed {
+		t.Error("Expected the page not to have changed")
+	}
+	if bow.StatusCode() != http.StatusOK || bow.Title() != "Version 1" {
+		t.Errorf("Expected the page to be kept, got %d %q", bow.StatusCode(), bow.Title())
+	}
+
+	version = "2"
+	changed, err = bow.ReloadIfModified()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !changed {
+		t.Error("Expected the page to have changed")
+	}
+	if bow.Title() != "Version 2" {
+		t.Errorf("Expected title %q, got %q", "Version 2", bow.Title())
+	}
+}
+
+func TestReloadIfModifiedWithoutValidators(t *testing.T) {
+	body := "one"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") != "" || r.Header.Get("If-Modified-Since") != "" {
+			t.Error("Expected no conditional headers")
+		}
+		w.Write([]byte(body))
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := bow.ReloadIfModified(); err != nil || changed {
+		t.Errorf("Expected an unchanged page, got %v %v", changed, err)
+	}
+	body = "two"
+	if changed, err := bow.ReloadIfModified(); err != nil || !changed {
+		t.Errorf("Expected a changed page, got %v %v", changed, err)
+	}
+}
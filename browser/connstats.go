@@ -0,0 +1,184 @@
+package browser
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sort"
+	"sync"
+	"time"
+)
+
+// HostConnStats describes the connections used by the requests sent to a
+// host, to diagnose the throughput of crawls. Hosts include the port, as
+// connections are pooled by host and port.
+type HostConnStats struct {
+	// Host is the host and port the connections were made to.
+	Host string
+
+	// Reused is the number of requests sent on a connection kept alive by
+	// a previous request.
+	Reused int
+
+	// New is the number of requests which needed a new connection.
+	New int
+
+	// DNSLookups is the number of DNS lookups, and DNSTime their total time.
+	DNSLookups int
+	DNSTime    time.Duration
+
+	// DialTime is the total time spent connecting to the host.
+	DialTime time.Duration
+
+	// TLSTime is the total time spent in TLS handshakes.
+	TLSTime time.Duration
+}
+
+// ReuseRatio returns the share of the requests sent on a reused connection.
+func (s HostConnStats) ReuseRatio() float64 {
+	if s.Reused+s.New == 0 {
+		return 0
+	}
+	return float64(s.Reused) / float64(s.Reused+s.New)
+}
+
+// AvgDNSTime returns the average time of the DNS lookups.
+func (s HostConnStats) AvgDNSTime() time.Duration {
+	if s.DNSLookups == 0 {
+		return 0
+	}
+	return s.DNSTime / time.Duration(s.DNSLookups)
+}
+
+// ConnStats returns the connection statistics of every host the browser
+// sent requests to, sorted by host. Tabs share the statistics of their
+// parent, as they share its connections. Transports which don't support
+// httptrace, such as OrderedTransport, are not counted.
+func (bow *Browser) ConnStats() []HostConnStats {
+	if bow.connStats == nil {
+		return nil
+	}
+	return bow.connStats.list()
+}
+
+// ResetConnStats clears the connection statistics.
+func (bow *Browser) ResetConnStats() {
+	if bow.connStats != nil {
+		bow.connStats.reset()
+	}
+}
+
+// connStats collects the statistics of the connections used by a browser.
+type connStats struct {
+	mu    sync.Mutex
+	hosts map[string]*HostConnStats
+}
+
+// newConnStats creates and returns an empty *connStats.
+func newConnStats() *connStats {
+	return &connStats{hosts: make(map[string]*HostConnStats)}
+}
+
+// update calls fn with the statistics of the given host.
+func (c *connStats) update(host string, fn func(s *HostConnStats)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	s, ok := c.hosts[host]
+	if !ok {
+		s = &HostConnStats{Host: host}
+		c.hosts[host] = s
+	}
+	fn(s)
+}
+
+// list returns a copy of the statistics, sorted by host.
+func (c *connStats) list() []HostConnStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := make([]HostConnStats, 0, len(c.hosts))
+	for _, s := range c.hosts {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Host < stats[j].Host
+	})
+	return stats
+}
+
+// reset clears the statistics.
+func (c *connStats) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.hosts = make(map[string]*HostConnStats)
+}
+
+// trace returns the request with a client trace recording its connections.
+// The host is taken from each connection request, so redirects to other
+// hosts are counted for the right host.
+func (c *connStats) trace(req *http.Request) *http.Request {
+	var mu sync.Mutex
+	var host string
+	var dnsStart, dialStart, tlsStart time.Time
+	current := func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		return host
+	}
+	t := &httptrace.ClientTrace{
+		GetConn: func(hostPort string) {
+			mu.Lock()
+			host = hostPort
+			mu.Unlock()
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			c.update(current(), func(s *HostConnStats) {
+				if info.Reused {
+					s.Reused++
+				} else {
+					s.New++
+				}
+			})
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			mu.Lock()
+			dnsStart = time.Now()
+			mu.Unlock()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			mu.Lock()
+			d := time.Since(dnsStart)
+			mu.Unlock()
+			c.update(current(), func(s *HostConnStats) {
+				s.DNSLookups++
+				s.DNSTime += d
+			})
+		},
+		ConnectStart: func(_, _ string) {
+			mu.Lock()
+			dialStart = time.Now()
+			mu.Unlock()
+		},
+		ConnectDone: func(_, _ string, _ error) {
+			mu.Lock()
+			d := time.Since(dialStart)
+			mu.Unlock()
+			c.update(current(), func(s *HostConnStats) {
+				s.DialTime += d
+			})
+		},
+		TLSHandshakeStart: func() {
+			mu.Lock()
+			tlsStart = time.Now()
+			mu.Unlock()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			mu.Lock()
+			d := time.Since(tlsStart)
+			mu.Unlock()
+			c.update(current(), func(s *HostConnStats) {
+				s.TLSTime += d
+			})
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), t))
+}
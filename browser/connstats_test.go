@@ -0,0 +1,53 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestConnStats(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html><head><title>Stats</title></head></html>"))
+	}))
+	defer ts.Close()
+	tsURL, _ := url.Parse(ts.URL)
+
+	bow := newDefaultTestBrowser()
+	if len(bow.ConnStats()) != 0 {
+		t.Errorf("Expected no statistics before a request, got %v.", bow.ConnStats())
+	}
+	for i := 0; i < 3; i++ {
+		if err := bow.GET(ts.URL); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tab := bow.NewTab()
+	if err := tab.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	stats := bow.ConnStats()
+	if len(stats) != 1 {
+		t.Fatalf("Expected the statistics of one host, got %v.", stats)
+	}
+	s := stats[0]
+	if s.Host != tsURL.Host {
+		t.Errorf("Expected host '%s', got '%s'.", tsURL.Host, s.Host)
+	}
+	if s.New != 1 || s.Reused != 3 {
+		t.Errorf("Expected 1 new and 3 reused connections, got %d and %d.", s.New, s.Reused)
+	}
+	if s.ReuseRatio() != 0.75 {
+		t.Errorf("Expected a reuse ratio of 0.75, got %g.", s.ReuseRatio())
+	}
+	if s.DialTime <= 0 {
+		t.Errorf("Expected the dial time to be recorded, got %s.", s.DialTime)
+	}
+
+	bow.ResetConnStats()
+	if len(tab.ConnStats()) != 0 {
+		t.Errorf("Expected the statistics to be cleared, got %v.", tab.ConnStats())
+	}
+}
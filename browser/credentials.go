@@ -0,0 +1,68 @@
+package browser
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/lostinblue/surf/credentials"
+)
+
+// SetCredentialStore sets the store looking up the credentials of the hosts
+// which answer with 401 Unauthorized, eg a credentials.Netrc. The request is
+// sent again once with the credentials, using Basic authentication. Tabs
+// share the store of their parent.
+func (bow *Browser) SetCredentialStore(s credentials.Store) {
+	bow.credentials = s
+}
+
+// CredentialStore returns the store set with SetCredentialStore(), or nil.
+func (bow *Browser) CredentialStore() credentials.Store {
+	return bow.credentials
+}
+
+// authenticate returns a copy of the request with the credentials of the
+// host, when the response asks for Basic authentication and the credential
+// store knows the host. Returns nil otherwise.
+//
+// Requests which already have credentials, and requests redirected to
+// another host, are not sent again.
+func (bow *Browser) authenticate(req *http.Request, resp *http.Response) (*http.Request, error) {
+	if bow.credentials == nil || resp.StatusCode != http.StatusUnauthorized || req.Header.Get("Authorization") != "" {
+		return nil, nil
+	}
+	host := strings.ToLower(req.URL.Hostname())
+	if resp.Request != nil && strings.ToLower(resp.Request.URL.Hostname()) != host {
+		return nil, nil
+	}
+	if !basicChallenge(resp.Header) {
+		return nil, nil
+	}
+	c, err := credentials.LookupURL(bow.credentials, req.URL)
+	if err != nil || c == nil {
+		return nil, err
+	}
+	retry, err := resendRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	retry.SetBasicAuth(c.Username, c.Password)
+	return retry, nil
+}
+
+// basicChallenge returns a boolean value indicating whether the response
+// headers accept Basic authentication. Responses without a challenge are
+// assumed to.
+func basicChallenge(h http.Header) bool {
+	challenges := h.Values("WWW-Authenticate")
+	if len(challenges) == 0 {
+		return true
+	}
+	for _, c := range challenges {
+		for _, part := range strings.Split(c, ",") {
+			if fields := strings.Fields(part); len(fields) > 0 && strings.EqualFold(fields[0], "basic") {
+				return true
+			}
+		}
+	}
+	return false
+}
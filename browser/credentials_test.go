@@ -0,0 +1,70 @@
+package browser
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/lostinblue/surf/credentials"
+)
+
+func TestCredentialStore(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		if r.URL.Path == "/digest" {
+			w.Header().Set("WWW-Authenticate", `Digest realm="surf"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "user" || pass != "pass" {
+			w.Header().Set("WWW-Authenticate", `Basic realm="surf", charset="UTF-8"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Write([]byte("<html><head><title>Hello " + user + " " + string(body) + "</title></head></html>"))
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if bow.StatusCode() != http.StatusUnauthorized {
+		t.Errorf("Expected 401 without credentials, got %d.", bow.StatusCode())
+	}
+
+	bow.SetCredentialStore(credentials.Map{"127.0.0.1": {Username: "user", Password: "pass"}})
+	if err := bow.POSTForm(ts.URL, url.Values{"q": {"form"}}); err != nil {
+		t.Fatal(err)
+	}
+	if bow.StatusCode() != http.StatusOK || bow.Title() != "Hello user q=form" {
+		t.Errorf("Expected the request to be sent again with the credentials, got %d '%s'.", bow.StatusCode(), bow.Title())
+	}
+	tab := bow.NewTab()
+	if err := tab.GET(ts.URL); err != nil || tab.StatusCode() != http.StatusOK {
+		t.Errorf("Expected the tab to share the credentials, got %d (%v).", tab.StatusCode(), err)
+	}
+
+	atomic.StoreInt32(&hits, 0)
+	bow.SetCredentialStore(credentials.Map{"127.0.0.1": {Username: "user", Password: "wrong"}})
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if bow.StatusCode() != http.StatusUnauthorized || atomic.LoadInt32(&hits) != 2 {
+		t.Errorf("Expected wrong credentials to be sent once, got %d after %d requests.", bow.StatusCode(), hits)
+	}
+
+	atomic.StoreInt32(&hits, 0)
+	if err := bow.GET(ts.URL + "/digest"); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt32(&hits) != 1 {
+		t.Errorf("Expected no Basic credentials for a Digest challenge, got %d requests.", hits)
+	}
+}
@@ -0,0 +1,299 @@
+package browser
+
+import (
+	"bytes"
+	"mime"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// CSSReference is a URL referenced by a stylesheet, with url() or @import.
+type CSSReference struct {
+	// URL is the referenced URL, unescaped, as written in the stylesheet.
+	URL string
+
+	// Import is true for the stylesheets imported with @import.
+	Import bool
+
+	// Start and End are the offsets of the reference in the stylesheet, eg
+	// of `url("a.png")`, or of `"a.css"` in `@import "a.css"`.
+	Start, End int
+}
+
+// StyleResource is an asset referenced by a stylesheet, such as a background
+// image, a font or an imported stylesheet.
+type StyleResource struct {
+	DownloadableAsset
+
+	// Import is true for the stylesheets imported with @import.
+	Import bool
+}
+
+// NewStyleResourceAsset creates and returns a new *StyleResource type.
+func NewStyleResourceAsset(u *url.URL, imported bool) *StyleResource {
+	return &StyleResource{
+		DownloadableAsset: DownloadableAsset{
+			Asset: Asset{
+				URL:  u,
+				Type: StyleResourceAsset,
+			},
+		},
+		Import: imported,
+	}
+}
+
+// ParseCSS returns the URLs referenced by the stylesheet, with url() and
+// @import, in their order. The comments and strings of the stylesheet are
+// skipped, and url() references to fragments, eg "#filter", are ignored.
+func ParseCSS(css []byte) []CSSReference {
+	var refs []CSSReference
+	for i := 0; i < len(css); {
+		switch c := css[i]; {
+		case c == '/' && i+1 < len(css) && css[i+1] == '*':
+			i = skipCSSComment(css, i)
+		case c == '"' || c == '\'':
+			_, i = readCSSString(css, i)
+		case c == '\\':
+			i += 2
+		case c == '@' && hasCSSPrefix(css[i:], "@import") && !isCSSNameByte(css, i+7):
+			j := skipCSSSpace(css, i+7)
+			if j < len(css) && (css[j] == '"' || css[j] == '\'') {
+				s, end := readCSSString(css, j)
+				refs = append(refs, CSSReference{URL: s, Import: true, Start: j, End: end})
+				i = end
+			} else if ref, ok := readCSSURL(css, j); ok {
+				ref.Import = true
+				refs = append(refs, ref)
+				i = ref.End
+			} else {
+				i = j
+			}
+		case (c == 'u' || c == 'U') && (i == 0 || !isCSSNameByte(css, i-1)):
+			if ref, ok := readCSSURL(css, i); ok {
+				if ref.URL != "" && !strings.HasPrefix(ref.URL, "#") {
+					refs = append(refs, ref)
+				}
+				i = ref.End
+			} else {
+				i++
+			}
+		default:
+			i++
+		}
+	}
+	return refs
+}
+
+// RewriteCSS returns the stylesheet with its references replaced by the
+// URLs returned by fn, written as url(). The references for which fn
+// returns an empty string are kept.
+func RewriteCSS(css []byte, fn func(ref CSSReference) string) []byte {
+	var buf bytes.Buffer
+	last := 0
+	for _, ref := range ParseCSS(css) {
+		u := fn(ref)
+		if u == "" {
+			continue
+		}
+		buf.Write(css[last:ref.Start])
+		buf.WriteString(cssURL(u))
+		last = ref.End
+	}
+	if last == 0 {
+		return css
+	}
+	buf.Write(css[last:])
+	return buf.Bytes()
+}
+
+// CSSResources returns the assets referenced by the stylesheet, resolved
+// against its base URL. Only the http and https URLs are returned, once.
+func CSSResources(base *url.URL, css []byte) []*StyleResource {
+	var resources []*StyleResource
+	seen := make(map[string]bool)
+	for _, ref := range ParseCSS(css) {
+		u, err := url.Parse(strings.TrimSpace(ref.URL))
+		if err != nil {
+			continue
+		}
+		if base != nil {
+			u = base.ResolveReference(u)
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || seen[u.String()] {
+			continue
+		}
+		seen[u.String()] = true
+		resources = append(resources, NewStyleResourceAsset(u, ref.Import))
+	}
+	return resources
+}
+
+// StyleResources returns the assets referenced by the <style> elements and
+// the style attributes of the page, or by the page itself when it's a
+// stylesheet. Use CSSResources() for the linked stylesheets, once
+// downloaded.
+func (bow *Browser) StyleResources() []*StyleResource {
+	mt, _, _ := mime.ParseMediaType(bow.ResponseHeaders().Get("Content-Type"))
+	if mt == "text/css" {
+		return CSSResources(bow.URL(), bow.body)
+	}
+	base := bow.documentBase()
+	var css bytes.Buffer
+	bow.Find("style").Each(func(_ int, s *goquery.Selection) {
+		css.WriteString(s.Text())
+		css.WriteString("\n")
+	})
+	bow.Find("[style]").Each(func(_ int, s *goquery.Selection) {
+		css.WriteString(s.AttrOr("style", ""))
+		css.WriteString("\n")
+	})
+	return CSSResources(base, css.Bytes())
+}
+
+// readCSSURL reads the url() token at i, and returns its reference and
+// whether there is one.
+func readCSSURL(css []byte, i int) (CSSReference, bool) {
+	if !hasCSSPrefix(css[i:], "url(") {
+		return CSSReference{}, false
+	}
+	j := skipCSSSpace(css, i+4)
+	var u string
+	if j < len(css) && (css[j] == '"' || css[j] == '\'') {
+		u, j = readCSSString(css, j)
+	} else {
+		var b strings.Builder
+		for j < len(css) && css[j] != ')' && !isCSSSpace(css[j]) {
+			if css[j] == '\\' {
+				r, end := readCSSEscape(css, j)
+				b.WriteString(r)
+				j = end
+				continue
+			}
+			b.WriteByte(css[j])
+			j++
+		}
+		u = b.String()
+	}
+	j = skipCSSSpace(css, j)
+	if j >= len(css) || css[j] != ')' {
+		return CSSReference{}, false
+	}
+	return CSSReference{URL: u, Start: i, End: j + 1}, true
+}
+
+// readCSSString reads the string at i, and returns its unescaped value and
+// the offset following it.
+func readCSSString(css []byte, i int) (string, int) {
+	quote := css[i]
+	var b strings.Builder
+	for j := i + 1; j < len(css); {
+		switch css[j] {
+		case quote:
+			return b.String(), j + 1
+		case '\n':
+			// An unterminated string ends at the end of the line.
+			return b.String(), j
+		case '\\':
+			if j+1 < len(css) && css[j+1] == '\n' {
+				j += 2
+				continue
+			}
+			r, end := readCSSEscape(css, j)
+			b.WriteString(r)
+			j = end
+		default:
+			b.WriteByte(css[j])
+			j++
+		}
+	}
+	return b.String(), len(css)
+}
+
+// readCSSEscape reads the escape sequence at i, eg `\"` or `\26 `, and
+// returns its value and the offset following it.
+func readCSSEscape(css []byte, i int) (string, int) {
+	j := i + 1
+	for j < len(css) && j < i+7 && isHexByte(css[j]) {
+		j++
+	}
+	if j == i+1 {
+		if j >= len(css) {
+			return "", j
+		}
+		_, size := utf8.DecodeRune(css[j:])
+		return string(css[j : j+size]), j + size
+	}
+	n, _ := strconv.ParseUint(string(css[i+1:j]), 16, 32)
+	r := rune(n)
+	if r == 0 || !utf8.ValidRune(r) {
+		r = utf8.RuneError
+	}
+	if j < len(css) && isCSSSpace(css[j]) {
+		j++
+	}
+	return string(r), j
+}
+
+// skipCSSComment returns the offset following the comment at i.
+func skipCSSComment(css []byte, i int) int {
+	end := bytes.Index(css[i+2:], []byte("*/"))
+	if end < 0 {
+		return len(css)
+	}
+	return i + 2 + end + 2
+}
+
+// skipCSSSpace returns the offset of the first byte from i which is not a
+// space or in a comment.
+func skipCSSSpace(css []byte, i int) int {
+	for i < len(css) {
+		if isCSSSpace(css[i]) {
+			i++
+		} else if css[i] == '/' && i+1 < len(css) && css[i+1] == '*' {
+			i = skipCSSComment(css, i)
+		} else {
+			break
+		}
+	}
+	return i
+}
+
+// cssURL returns the url() token of the URL, quoted when it has characters
+// which cannot appear in an unquoted url().
+func cssURL(u string) string {
+	if !strings.ContainsAny(u, "\"'()\\ \t\n\r\f") {
+		return "url(" + u + ")"
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\a `)
+	return `url("` + r.Replace(u) + `")`
+}
+
+// hasCSSPrefix returns whether b starts with the prefix, ignoring case.
+func hasCSSPrefix(b []byte, prefix string) bool {
+	return len(b) >= len(prefix) && strings.EqualFold(string(b[:len(prefix)]), prefix)
+}
+
+// isCSSNameByte returns whether the byte at i may be part of a name, eg
+// the "back" of "background".
+func isCSSNameByte(css []byte, i int) bool {
+	if i < 0 || i >= len(css) {
+		return false
+	}
+	c := css[i]
+	return c == '-' || c == '_' || c >= 0x80 || (c >= '0' && c <= '9') ||
+		(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// isCSSSpace returns whether the byte is a CSS whitespace.
+func isCSSSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
+
+// isHexByte returns whether the byte is a hexadecimal digit.
+func isHexByte(c byte) bool {
+	return (c >= '0' && c <= '9') || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
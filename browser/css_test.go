@@ -0,0 +1,106 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestParseCSS(t *testing.T) {
+	css := `@import "base.css";
+@IMPORT url( 'print.css' ) print;
+/* url(commented.png) */
+body { background: URL(img/bg.png) no-repeat; content: "url(string.png)"; }
+.icon { background-image: url("a b\".png"), url(c\(1\).png); filter: url(#blur); }
+@font-face { src: url(fonts/f.woff2) format("woff2"), url('fonts/f.woff'); }
+.empty { background: url(); }
+.bad { background: url(unterminated.png`
+	expected := []CSSReference{
+		{URL: "base.css", Import: true},
+		{URL: "print.css", Import: true},
+		{URL: "img/bg.png"},
+		{URL: `a b".png`},
+		{URL: "c(1).png"},
+		{URL: "fonts/f.woff2"},
+		{URL: "fonts/f.woff"},
+	}
+	refs := ParseCSS([]byte(css))
+	if len(refs) != len(expected) {
+		t.Fatalf("Expected %d references, got %+v.", len(expected), refs)
+	}
+	for i, ref := range refs {
+		if ref.URL != expected[i].URL || ref.Import != expected[i].Import {
+			t.Errorf("Expected the reference %+v, got %+v.", expected[i], ref)
+		}
+	}
+	if s := css[refs[0].Start:refs[0].End]; s != `"base.css"` {
+		t.Errorf(`Expected the offsets of "base.css", got %q.`, s)
+	}
+	if s := css[refs[2].Start:refs[2].End]; s != `URL(img/bg.png)` {
+		t.Errorf(`Expected the offsets of URL(img/bg.png), got %q.`, s)
+	}
+}
+
+func TestRewriteCSS(t *testing.T) {
+	css := `@import "base.css"; a { background: url( 'bg.png' ); } b { background: url(keep.png) }`
+	out := RewriteCSS([]byte(css), func(ref CSSReference) string {
+		switch ref.URL {
+		case "base.css":
+			return "../base.css"
+		case "bg.png":
+			return "img/my bg.png"
+		}
+		return ""
+	})
+	expected := `@import url(../base.css); a { background: url("img/my bg.png"); } b { background: url(keep.png) }`
+	if string(out) != expected {
+		t.Errorf("Expected %s, got %s.", expected, out)
+	}
+}
+
+func TestCSSResources(t *testing.T) {
+	base, _ := url.Parse("http://example.com/css/site.css")
+	css := `@import "theme.css"; a { background: url(/img/a.png) } b { background: url(../img/a.png) }
+		c { background: url(data:image/png;base64,AAAA) }`
+	resources := CSSResources(base, []byte(css))
+	if len(resources) != 2 {
+		t.Fatalf("Expected 2 resources, got %d.", len(resources))
+	}
+	if r := resources[0]; r.URL.String() != "http://example.com/css/theme.css" || !r.Import || r.Type != StyleResourceAsset {
+		t.Errorf("Unexpected resource %+v.", r)
+	}
+	if r := resources[1]; r.URL.String() != "http://example.com/img/a.png" || r.Import {
+		t.Errorf("Unexpected resource %+v.", r)
+	}
+}
+
+func TestStyleResources(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Write([]byte(`<html><head><style>body { background: url(/bg.png) }</style></head>
+				<body><div style="background-image: url('hero.jpg')"></div></body></html>`))
+		case "/site.css":
+			w.Header().Set("Content-Type", "text/css")
+			w.Write([]byte(`@import url(theme.css);`))
+		}
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	resources := bow.StyleResources()
+	if len(resources) != 2 || resources[0].URL.Path != "/bg.png" || resources[1].URL.Path != "/hero.jpg" {
+		t.Errorf("Expected the resources of the styles of the page, got %v.", resources)
+	}
+	if err := bow.GET(ts.URL + "/site.css"); err != nil {
+		t.Fatal(err)
+	}
+	resources = bow.StyleResources()
+	if len(resources) != 1 || resources[0].URL.Path != "/theme.css" || !resources[0].Import {
+		t.Errorf("Expected the import of the stylesheet, got %v.", resources)
+	}
+}
@@ -0,0 +1,148 @@
+package browser
+
+import (
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/lostinblue/surf/errors"
+)
+
+// DefaultDecompressionLimits is the global value for the decompression
+// limits, which does not limit the bodies.
+var DefaultDecompressionLimits = DecompressionLimits{}
+
+// minRatioCheck is the decoded size below which MaxRatio is not checked, so
+// small and very repetitive pages are not rejected.
+const minRatioCheck = 64 * 1024
+
+// DecompressionLimits restricts the size of compressed response bodies once
+// decoded, to protect crawlers from decompression bombs.
+type DecompressionLimits struct {
+	// MaxSize is the maximum size of a decoded body in bytes, or 0 for no
+	// limit. It also applies to the bodies decoded by the transport.
+	MaxSize int64
+
+	// MaxRatio is the maximum ratio between the decoded and compressed sizes
+	// of a body, or 0 for no limit. It is checked once more than 64KB have
+	// been decoded.
+	MaxRatio float64
+}
+
+// SetDecompressionLimits sets the limits applied when decoding response bodies.
+func (bow *Browser) SetDecompressionLimits(l DecompressionLimits) {
+	bow.decompressionLimits = l
+}
+
+// DecompressionLimits returns the limits applied when decoding response bodies.
+func (bow *Browser) DecompressionLimits() DecompressionLimits {
+	return bow.decompressionLimits
+}
+
+// ContentEncoding returns the encoding the page was received with, eg
+// "gzip", or an empty string when it was not compressed. See the
+// Compression attribute.
+func (bow *Browser) ContentEncoding() string {
+	return bow.state.ContentEncoding
+}
+
+// EncodedSize returns the size of the body of the page as received, before
+// it was decoded, or -1 when it's unknown. Compared to the size of the body,
+// it tells the bandwidth saved by compression.
+//
+// The size of the bodies decoded by the transport, which asks for gzip
+// bodies when the requests have no Accept-Encoding header, is unknown. Set
+// the header, eg with AddRequestHeader("Accept-Encoding", "gzip, deflate, br"),
+// for the browser to decode the bodies itself and count their size.
+func (bow *Browser) EncodedSize() int64 {
+	return bow.state.EncodedSize
+}
+
+// compresses returns whether the browser advertises compression, which it
+// does unless the Compression attribute is set to false.
+func (bow *Browser) compresses() bool {
+	v, ok := bow.attributes[Compression]
+	return v || !ok
+}
+
+// acceptEncoding asks for an uncompressed response when compression is
+// disabled and the request has no Accept-Encoding header, which stops the
+// transport from asking for gzip.
+func (bow *Browser) acceptEncoding(req *http.Request) {
+	if !bow.compresses() && req.Header.Get("Accept-Encoding") == "" {
+		req.Header.Set("Accept-Encoding", "identity")
+	}
+}
+
+// decodeBody returns a reader of the decoded body of the response, which
+// fails with a DecompressionLimit error when the limits are exceeded. Every
+// body the browser reads, pages as well as assets and downloads, is read
+// through it.
+func (bow *Browser) decodeBody(resp *http.Response) (io.Reader, error) {
+	l := bow.decompressionLimits
+	compressed := &countingReader{r: resp.Body}
+	var reader io.Reader
+	switch strings.ToLower(resp.Header.Get("Content-Encoding")) {
+	case "gzip":
+		zr, err := gzip.NewReader(compressed)
+		if err != nil {
+			return nil, err
+		}
+		reader = zr
+	case "deflate":
+		reader = flate.NewReader(compressed)
+	case "br":
+		reader = brotli.NewReader(compressed)
+	default:
+		if !resp.Uncompressed || l.MaxSize <= 0 {
+			return resp.Body, nil
+		}
+		// The compressed size of the bodies decoded by the transport is unknown.
+		l.MaxRatio = 0
+		reader = resp.Body
+	}
+	if l.MaxSize <= 0 && l.MaxRatio <= 0 {
+		return reader, nil
+	}
+	return &limitedDecoder{r: reader, compressed: compressed, limits: l, url: resp.Request.URL.String()}, nil
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+// Read implements io.Reader.
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// limitedDecoder reads a decoded body, and fails once it exceeds the limits.
+type limitedDecoder struct {
+	r          io.Reader
+	compressed *countingReader
+	limits     DecompressionLimits
+	url        string
+	n          int64
+}
+
+// Read implements io.Reader.
+func (d *limitedDecoder) Read(p []byte) (int, error) {
+	n, err := d.r.Read(p)
+	d.n += int64(n)
+	if d.limits.MaxSize > 0 && d.n > d.limits.MaxSize {
+		return n, errors.NewDecompressionLimit("The body of '%s' exceeds %d bytes once decoded.", d.url, d.limits.MaxSize)
+	}
+	if d.limits.MaxRatio > 0 && d.n > minRatioCheck && d.compressed.n > 0 {
+		if ratio := float64(d.n) / float64(d.compressed.n); ratio > d.limits.MaxRatio {
+			return n, errors.NewDecompressionLimit("The body of '%s' exceeds the compression ratio of %g.", d.url, d.limits.MaxRatio)
+		}
+	}
+	return n, err
+}
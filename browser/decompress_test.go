@@ -0,0 +1,172 @@
+package browser
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/lostinblue/surf/errors"
+)
+
+func TestDecompressionLimits(t *testing.T) {
+	page := "<html><body>" + strings.Repeat("<p>bomb</p>", 100000) + "</body></html>"
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte(page))
+	zw.Close()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			w.Write([]byte(page))
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(gz.Bytes())
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	bow.AddRequestHeader("Accept-Encoding", "gzip")
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(bow.Body(), "<p>bomb</p>") {
+		t.Errorf("Expected the decoded page without limits, got %d bytes.", len(bow.Body()))
+	}
+
+	tests := []struct {
+		name        string
+		transparent bool
+		limits      DecompressionLimits
+		fails       bool
+	}{
+		{"size", false, DecompressionLimits{MaxSize: 100000}, true},
+		{"ratio", false, DecompressionLimits{MaxRatio: 10}, true},
+		{"within", false, DecompressionLimits{MaxSize: int64(len(page)), MaxRatio: 10000}, false},
+		{"transparent size", true, DecompressionLimits{MaxSize: 100000}, true},
+		{"transparent ratio", true, DecompressionLimits{MaxRatio: 10}, false},
+	}
+	for _, test := range tests {
+		if test.transparent {
+			// The transport asks for and decodes gzip bodies itself.
+			bow.DelRequestHeader("Accept-Encoding")
+		}
+		bow.SetDecompressionLimits(test.limits)
+		err := bow.GET(ts.URL)
+		if test.fails {
+			if _, ok := err.(errors.DecompressionLimit); !ok {
+				t.Errorf("%s: Expected a DecompressionLimit error, got %v.", test.name, err)
+			}
+		} else if err != nil {
+			t.Errorf("%s: Expected no error, got %v.", test.name, err)
+		}
+	}
+	if bow.DecompressionLimits().MaxRatio != 10 {
+		t.Errorf("Expected the limits to be returned, got %v.", bow.DecompressionLimits())
+	}
+}
+
+func TestCompression(t *testing.T) {
+	page := "<html><body>" + strings.Repeat("<p>compressed</p>", 1000) + "</body></html>"
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte(page))
+	zw.Close()
+
+	accepted := make(chan string, 1)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accepted <- r.Header.Get("Accept-Encoding")
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			w.Write([]byte(page))
+			return
+		}
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write(gz.Bytes())
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	tests := []struct {
+		name        string
+		compression bool
+		header      string
+		accepted    string
+		encoding    string
+		size        int64
+	}{
+		{"transport", true, "", "gzip", "gzip", -1},
+		{"header", true, "gzip, deflate", "gzip, deflate", "gzip", int64(gz.Len())},
+		{"disabled", false, "", "identity", "", int64(len(page))},
+		{"disabled with header", false, "gzip", "gzip", "gzip", int64(gz.Len())},
+	}
+	for _, test := range tests {
+		bow.SetAttribute(Compression, test.compression)
+		bow.DelRequestHeader("Accept-Encoding")
+		if test.header != "" {
+			bow.AddRequestHeader("Accept-Encoding", test.header)
+		}
+		if err := bow.GET(ts.URL); err != nil {
+			t.Fatal(err)
+		}
+		if a := <-accepted; a != test.accepted {
+			t.Errorf("%s: Expected Accept-Encoding %q, got %q.", test.name, test.accepted, a)
+		}
+		if bow.ContentEncoding() != test.encoding || bow.EncodedSize() != test.size {
+			t.Errorf("%s: Expected the encoding %q and size %d, got %q and %d.",
+				test.name, test.encoding, test.size, bow.ContentEncoding(), bow.EncodedSize())
+		}
+		if !strings.HasSuffix(bow.Body(), "<p>compressed</p>") {
+			t.Errorf("%s: Expected the decoded page, got %d bytes.", test.name, len(bow.Body()))
+		}
+	}
+}
+
+func TestDecompressionBrotli(t *testing.T) {
+	content := strings.Repeat("<p>bomb</p>", 100000)
+	page := "<html><body>" + content + "</body></html>"
+	var br bytes.Buffer
+	bw := brotli.NewWriter(&br)
+	bw.Write([]byte(page))
+	bw.Close()
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "br")
+		w.Write(br.Bytes())
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	bow.AddRequestHeader("Accept-Encoding", "br")
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if string(bow.body) != page || bow.Body() != content || bow.ContentEncoding() != "br" {
+		t.Errorf("Expected the decoded page, got %d bytes encoded with %q.", len(bow.Body()), bow.ContentEncoding())
+	}
+
+	// The limits apply to the assets and downloads too.
+	bow.SetDecompressionLimits(DecompressionLimits{MaxSize: 100000})
+	if err := bow.GET(ts.URL); err == nil {
+		t.Error("Expected the page to exceed the limits.")
+	}
+	if _, _, err := bow.fetchAsset(ts.URL + "/style.css"); err == nil {
+		t.Error("Expected the asset to exceed the limits.")
+	} else if _, ok := err.(errors.DecompressionLimit); !ok {
+		t.Errorf("Expected a DecompressionLimit error, got %v.", err)
+	}
+	u, _ := url.Parse(ts.URL + "/file")
+	ch := make(AsyncDownloadChannel, 1)
+	bow.DownloadAssetAsync(context.Background(), NewImageAsset(u, "", "", "").DownloadableAsset, ioutil.Discard, ch)
+	if res := <-ch; res.Error == nil {
+		t.Error("Expected the download to exceed the limits.")
+	} else if _, ok := res.Error.(errors.DecompressionLimit); !ok {
+		t.Errorf("Expected a DecompressionLimit error, got %v.", res.Error)
+	}
+}
@@ -0,0 +1,284 @@
+package browser
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+// DialOptions controls how the browser opens connections, eg on hosts with
+// several addresses or network interfaces.
+type DialOptions struct {
+	// IPVersion restricts the connections to IPv4 when 4, or IPv6 when 6.
+	// Both are used when 0.
+	IPVersion int
+
+	// LocalAddr is the local IP address the connections are made from, or
+	// an empty string to let the system choose.
+	LocalAddr string
+
+	// Interface is the name of the network interface the connections are
+	// made from, eg "eth1". Its first address of the chosen IP version is
+	// used. LocalAddr takes precedence when both are set.
+	Interface string
+}
+
+// ConnTimeouts limits the steps of opening a connection and waiting for a
+// response, unlike SetTimeout() which limits whole requests. Zero values
+// keep the defaults of the transport.
+type ConnTimeouts struct {
+	// Dial is the maximum time to connect to a host. Defaults to 30 seconds.
+	Dial time.Duration
+
+	// FallbackDelay is how long to wait for an IPv6 connection before also
+	// trying IPv4, known as Happy Eyeballs. Defaults to 300ms, and a negative
+	// value disables the fallback.
+	FallbackDelay time.Duration
+
+	// TLSHandshake is the maximum time of the TLS handshake.
+	TLSHandshake time.Duration
+
+	// ResponseHeader is the maximum time to wait for the response headers
+	// once the request is written.
+	ResponseHeader time.Duration
+}
+
+// apply sets the timeouts of the transport.
+func (ct ConnTimeouts) apply(t *http.Transport) {
+	if ct.TLSHandshake > 0 {
+		t.TLSHandshakeTimeout = ct.TLSHandshake
+	}
+	if ct.ResponseHeader > 0 {
+		t.ResponseHeaderTimeout = ct.ResponseHeader
+	}
+}
+
+// SetDialOptions sets how the browser opens connections. The options apply
+// to the connections made directly and to the connections made to proxies,
+// including the proxies set later with SetProxy() or WithProxy().
+//
+// Returns an error when the options are invalid, or the transport set with
+// SetTransport() is not an *http.Transport or an *OrderedTransport.
+func (bow *Browser) SetDialOptions(o DialOptions) error {
+	d, err := newDialer(o, bow.connTimeouts)
+	if err != nil {
+		return err
+	}
+	if err = bow.configureTransport(d, bow.connTimeouts); err != nil {
+		return err
+	}
+	bow.dialOptions = o
+	return nil
+}
+
+// DialOptions returns the options set with SetDialOptions().
+func (bow *Browser) DialOptions() DialOptions {
+	return bow.dialOptions
+}
+
+// SetConnTimeouts sets the timeouts of the connections. Like the dial
+// options, they apply to the connections made to proxies.
+//
+// Returns an error when the transport set with SetTransport() is not an
+// *http.Transport or an *OrderedTransport.
+func (bow *Browser) SetConnTimeouts(ct ConnTimeouts) error {
+	d, err := newDialer(bow.dialOptions, ct)
+	if err != nil {
+		return err
+	}
+	if err = bow.configureTransport(d, ct); err != nil {
+		return err
+	}
+	bow.connTimeouts = ct
+	return nil
+}
+
+// ConnTimeouts returns the timeouts set with SetConnTimeouts().
+func (bow *Browser) ConnTimeouts() ConnTimeouts {
+	return bow.connTimeouts
+}
+
+// configureTransport replaces the transport of the browser with a copy
+// which opens its connections with d and the given timeouts.
+func (bow *Browser) configureTransport(d *dialer, ct ConnTimeouts) error {
+	if bow.client == nil {
+		bow.client = bow.buildClient()
+	}
+	var rt http.RoundTripper
+	switch t := bow.client.Transport.(type) {
+	case nil:
+		c := defaultTransport()
+		c.DialContext = d.DialContext
+		ct.apply(c)
+		rt = c
+	case *http.Transport:
+		if bow.proxy != nil {
+			c, err := newProxyTransport(bow.proxy, d, ct)
+			if err != nil {
+				return err
+			}
+			rt = c
+			break
+		}
+		c := t.Clone()
+		c.DialContext = d.DialContext
+		ct.apply(c)
+		rt = c
+	case *OrderedTransport:
+		c := *t
+		c.DialContext = d.DialContext
+		if ct.TLSHandshake > 0 {
+			c.TLSHandshakeTimeout = ct.TLSHandshake
+		}
+		if ct.ResponseHeader > 0 {
+			c.ResponseHeaderTimeout = ct.ResponseHeader
+		}
+		rt = &c
+	default:
+		return errors.New("Cannot configure the connections, the transport %T is not supported.", t)
+	}
+	bow.client.Transport = rt
+	bow.dialer = d
+	return nil
+}
+
+// defaultTransport returns a copy of http.DefaultTransport, or a transport
+// with its default settings when it has been replaced by another
+// RoundTripper.
+func defaultTransport() *http.Transport {
+	if t, ok := http.DefaultTransport.(*http.Transport); ok {
+		return t.Clone()
+	}
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+}
+
+// dialer opens the connections of the browser with the dial options.
+type dialer struct {
+	net.Dialer
+
+	// network replaces the tcp network, eg "tcp4" for IPv4 only.
+	network string
+}
+
+// newDialer creates and returns a *dialer for the given options and timeouts.
+func newDialer(o DialOptions, ct ConnTimeouts) (*dialer, error) {
+	d := &dialer{
+		Dialer: net.Dialer{
+			Timeout:       30 * time.Second,
+			KeepAlive:     30 * time.Second,
+			FallbackDelay: ct.FallbackDelay,
+		},
+	}
+	if ct.Dial > 0 {
+		d.Timeout = ct.Dial
+	}
+	switch o.IPVersion {
+	case 0:
+	case 4, 6:
+		d.network = "tcp" + strconv.Itoa(o.IPVersion)
+	default:
+		return nil, errors.New("Invalid IP version %d, expected 4 or 6.", o.IPVersion)
+	}
+
+	var ip net.IP
+	if o.LocalAddr != "" {
+		ip = net.ParseIP(o.LocalAddr)
+		if ip == nil {
+			return nil, errors.New("Invalid local address '%s'.", o.LocalAddr)
+		}
+		if !ipVersionMatches(ip, o.IPVersion) {
+			return nil, errors.New("The local address '%s' is not an IPv%d address.", o.LocalAddr, o.IPVersion)
+		}
+	} else if o.Interface != "" {
+		var err error
+		if ip, err = interfaceIP(o.Interface, o.IPVersion); err != nil {
+			return nil, err
+		}
+	}
+	if ip != nil {
+		d.LocalAddr = &net.TCPAddr{IP: ip}
+		if d.network == "" {
+			// The local address only works with remote addresses of its version.
+			d.network = "tcp6"
+			if ip.To4() != nil {
+				d.network = "tcp4"
+			}
+		}
+	}
+	return d, nil
+}
+
+// Dial connects to the address on the named network.
+func (d *dialer) Dial(network, addr string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, addr)
+}
+
+// DialContext connects to the address on the named network using the context.
+func (d *dialer) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	if d.network != "" && strings.HasPrefix(network, "tcp") {
+		network = d.network
+	}
+	return d.Dialer.DialContext(ctx, network, addr)
+}
+
+// ipNetwork returns the network used to resolve host names.
+func (d *dialer) ipNetwork() string {
+	if d == nil || d.network == "" {
+		return "ip"
+	}
+	return "ip" + strings.TrimPrefix(d.network, "tcp")
+}
+
+// interfaceIP returns the first address of the named interface with the
+// given IP version, preferring IPv4 when version is 0. IPv6 link-local
+// addresses are skipped.
+func interfaceIP(name string, version int) (net.IP, error) {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, err
+	}
+	var found net.IP
+	for _, a := range addrs {
+		ipNet, ok := a.(*net.IPNet)
+		if !ok || ipNet.IP.IsLinkLocalUnicast() || !ipVersionMatches(ipNet.IP, version) {
+			continue
+		}
+		if ipNet.IP.To4() != nil {
+			return ipNet.IP, nil
+		}
+		if found == nil {
+			found = ipNet.IP
+		}
+	}
+	if found == nil {
+		return nil, errors.New("The interface '%s' has no usable address.", name)
+	}
+	return found, nil
+}
+
+// ipVersionMatches reports whether ip has the given version, 0 matching both.
+func ipVersionMatches(ip net.IP, version int) bool {
+	switch version {
+	case 4:
+		return ip.To4() != nil
+	case 6:
+		return ip.To4() == nil
+	}
+	return true
+}
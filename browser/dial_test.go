@@ -0,0 +1,172 @@
+package browser
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSetDialOptions(t *testing.T) {
+	remote := ""
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		remote, _, _ = net.SplitHostPort(r.RemoteAddr)
+		io.WriteString(w, "direct")
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	invalid := []DialOptions{
+		{IPVersion: 5},
+		{LocalAddr: "not an ip"},
+		{IPVersion: 6, LocalAddr: "127.0.0.1"},
+		{Interface: "surf-missing0"},
+	}
+	for _, o := range invalid {
+		if err := bow.SetDialOptions(o); err == nil {
+			t.Errorf("Expected an error for the options %+v.", o)
+		}
+	}
+
+	if err := bow.SetDialOptions(DialOptions{IPVersion: 4, LocalAddr: "127.0.0.1"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if remote != "127.0.0.1" {
+		t.Errorf("Expected the connection from 127.0.0.1, got '%s'.", remote)
+	}
+	if bow.DialOptions().LocalAddr != "127.0.0.1" {
+		t.Errorf("Expected the options to be returned, got %+v.", bow.DialOptions())
+	}
+	if tab := bow.NewTab(); tab.GET(ts.URL) != nil || tab.DialOptions() != bow.DialOptions() {
+		t.Errorf("Expected the tab to use the options, got %+v.", tab.DialOptions())
+	}
+
+	// The test server only listens on IPv4.
+	if err := bow.SetDialOptions(DialOptions{IPVersion: 6}); err != nil {
+		t.Fatal(err)
+	}
+	if err := bow.GET(ts.URL); err == nil {
+		t.Error("Expected an error connecting to an IPv4 server over IPv6.")
+	}
+
+	// The options apply to the connections made to proxies.
+	ps := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "proxied")
+	}))
+	defer ps.Close()
+	if err := bow.SetProxy(ps.URL); err != nil {
+		t.Fatal(err)
+	}
+	if err := bow.GET(ts.URL); err == nil {
+		t.Error("Expected an error connecting to an IPv4 proxy over IPv6.")
+	}
+	if err := bow.SetDialOptions(DialOptions{IPVersion: 4}); err != nil {
+		t.Fatal(err)
+	}
+	if err := bow.GET(ts.URL); err != nil || string(bow.body) != "proxied" {
+		t.Errorf("Expected the request to be sent through the proxy, got '%s' (%v).", bow.body, err)
+	}
+}
+
+func TestDialOptionsInterface(t *testing.T) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := ""
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 {
+			name = iface.Name
+		}
+	}
+	if name == "" {
+		t.Skip("No loopback interface.")
+	}
+	ip, err := interfaceIP(name, 4)
+	if err != nil {
+		t.Skip(err)
+	}
+	if !ip.IsLoopback() {
+		t.Errorf("Expected a loopback address, got %s.", ip)
+	}
+	bow := newDefaultTestBrowser()
+	if err = bow.SetDialOptions(DialOptions{Interface: name}); err != nil {
+		t.Fatal(err)
+	}
+	if bow.dialer.network != "tcp4" {
+		t.Errorf("Expected IPv4 connections from the interface address, got '%s'.", bow.dialer.network)
+	}
+}
+
+func TestSetConnTimeouts(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+		io.WriteString(w, "slow")
+	}))
+	defer ts.Close()
+
+	// The listener accepts connections but never answers the TLS handshake.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+	silent := "https://" + ln.Addr().String()
+
+	ct := ConnTimeouts{Dial: 5 * time.Second, TLSHandshake: 50 * time.Millisecond, ResponseHeader: 50 * time.Millisecond}
+	transports := []struct {
+		name string
+		rt   http.RoundTripper
+	}{
+		{"default", nil},
+		{"ordered", &OrderedTransport{ForceHTTP1: true}},
+	}
+	for _, test := range transports {
+		bow := newDefaultTestBrowser()
+		bow.SetTransport(test.rt)
+		if err = bow.SetConnTimeouts(ct); err != nil {
+			t.Fatal(err)
+		}
+		if bow.ConnTimeouts() != ct || bow.dialer.Timeout != ct.Dial {
+			t.Errorf("%s: Expected the timeouts to be set, got %+v.", test.name, bow.ConnTimeouts())
+		}
+		start := time.Now()
+		if err = bow.GET(ts.URL); err == nil {
+			t.Errorf("%s: Expected a response header timeout.", test.name)
+		}
+		if err = bow.GET(silent); err == nil {
+			t.Errorf("%s: Expected a TLS handshake timeout.", test.name)
+		}
+		if d := time.Since(start); d > time.Second {
+			t.Errorf("%s: Expected the requests to time out early, took %s.", test.name, d)
+		}
+
+		// The timeouts are kept when the dial options change.
+		if err = bow.SetDialOptions(DialOptions{IPVersion: 4}); err != nil {
+			t.Fatal(err)
+		}
+		if err = bow.GET(ts.URL); err == nil {
+			t.Errorf("%s: Expected the timeouts to be kept with the dial options.", test.name)
+		}
+	}
+
+	bow := newDefaultTestBrowser()
+	bow.SetTransport(&orderTransport{})
+	if err = bow.SetConnTimeouts(ct); err == nil {
+		t.Error("Expected an error for an unsupported transport.")
+	}
+}
@@ -0,0 +1,139 @@
+package browser
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+// Expectation checks the current page of a browser, eg in health checks and
+// monitoring scripts. Checks are chained, and the failures are returned by
+// Err() as errors.ExpectationFailed values:
+//
+//	err := bow.Expect().Status(200).ContentType("text/html").SelectorExists(".results").Err()
+type Expectation struct {
+	bow  *Browser
+	errs []error
+}
+
+// Expect returns an Expectation checking the current page.
+func (bow *Browser) Expect() *Expectation {
+	e := &Expectation{bow: bow}
+	if bow.state == nil || bow.state.Response == nil {
+		e.errs = append(e.errs, errors.NewPageNotLoaded("No page was loaded."))
+	}
+	return e
+}
+
+// loaded returns a boolean value indicating whether a page was loaded.
+func (e *Expectation) loaded() bool {
+	return e.bow.state != nil && e.bow.state.Response != nil
+}
+
+// fail records a failed check.
+func (e *Expectation) fail(check, expected, actual string) *Expectation {
+	e.errs = append(e.errs, errors.NewExpectationFailed(check, expected, actual))
+	return e
+}
+
+// Status checks the status code of the page is one of the given codes.
+func (e *Expectation) Status(codes ...int) *Expectation {
+	if !e.loaded() {
+		return e
+	}
+	status := e.bow.StatusCode()
+	expected := make([]string, len(codes))
+	for i, code := range codes {
+		if code == status {
+			return e
+		}
+		expected[i] = strconv.Itoa(code)
+	}
+	return e.fail("Status", strings.Join(expected, " or "), strconv.Itoa(status))
+}
+
+// ContentType checks the media type of the page, ignoring its parameters,
+// eg "text/html" matches "text/html; charset=utf-8".
+func (e *Expectation) ContentType(mediaType string) *Expectation {
+	if !e.loaded() {
+		return e
+	}
+	actual := e.bow.ResponseHeaders().Get("Content-Type")
+	mt, _, err := mime.ParseMediaType(actual)
+	if err != nil || !strings.EqualFold(mt, mediaType) {
+		return e.fail("ContentType", mediaType, fmt.Sprintf("%q", actual))
+	}
+	return e
+}
+
+// Header checks the page has the header with the given value. An empty value
+// only checks the header is set.
+func (e *Expectation) Header(name, value string) *Expectation {
+	if !e.loaded() {
+		return e
+	}
+	values, ok := e.bow.ResponseHeaders()[http.CanonicalHeaderKey(name)]
+	if !ok {
+		return e.fail("Header", name, "no header")
+	}
+	if value == "" {
+		return e
+	}
+	for _, v := range values {
+		if v == value {
+			return e
+		}
+	}
+	return e.fail("Header", fmt.Sprintf("%s: %s", name, value), fmt.Sprintf("%q", strings.Join(values, ", ")))
+}
+
+// SelectorExists checks the page has at least one element matching expr.
+func (e *Expectation) SelectorExists(expr string) *Expectation {
+	if !e.loaded() {
+		return e
+	}
+	if e.bow.Find(expr).Length() == 0 {
+		return e.fail("SelectorExists", expr, "no match")
+	}
+	return e
+}
+
+// SelectorMissing checks the page has no element matching expr, eg an error
+// message or a login form.
+func (e *Expectation) SelectorMissing(expr string) *Expectation {
+	if !e.loaded() {
+		return e
+	}
+	if n := e.bow.Find(expr).Length(); n > 0 {
+		return e.fail("SelectorMissing", "no "+expr, fmt.Sprintf("%d matches", n))
+	}
+	return e
+}
+
+// BodyContains checks the raw body of the page contains s.
+func (e *Expectation) BodyContains(s string) *Expectation {
+	if !e.loaded() {
+		return e
+	}
+	if !strings.Contains(string(e.bow.body), s) {
+		return e.fail("BodyContains", fmt.Sprintf("%q", s), "no match")
+	}
+	return e
+}
+
+// Err returns the first failure, or nil when every check passed.
+func (e *Expectation) Err() error {
+	if len(e.errs) == 0 {
+		return nil
+	}
+	return e.errs[0]
+}
+
+// Errors returns every failure, in the order of the checks.
+func (e *Expectation) Errors() []error {
+	return e.errs
+}
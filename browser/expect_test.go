@@ -0,0 +1,50 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+func TestExpect(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("X-Cache", "HIT")
+		w.Write([]byte(`<html><body><ul class="results"><li>one</li></ul></body></html>`))
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	if _, ok := bow.Expect().Status(200).Err().(errors.PageNotLoaded); !ok {
+		t.Error("Expected a PageNotLoaded error before loading a page")
+	}
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	err := bow.Expect().
+		Status(200).
+		ContentType("text/html").
+		Header("X-Cache", "HIT").
+		SelectorExists(".results li").
+		SelectorMissing(".error").
+		BodyContains("one").
+		Err()
+	if err != nil {
+		t.Errorf("Expected the checks to pass, got %s", err)
+	}
+
+	e := bow.Expect().Status(201, 204).ContentType("application/json").SelectorExists(".missing")
+	if len(e.Errors()) != 3 {
+		t.Fatalf("Expected 3 failures, got %v", e.Errors())
+	}
+	failed, ok := e.Err().(errors.ExpectationFailed)
+	if !ok {
+		t.Fatalf("Expected an ExpectationFailed error, got %T", e.Err())
+	}
+	if failed.Check != "Status" || failed.Expected != "201 or 204" || failed.Actual != "200" {
+		t.Errorf("Unexpected failure %+v", failed)
+	}
+}
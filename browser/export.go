@@ -0,0 +1,330 @@
+package browser
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// hiddenElements are the elements whose content is never displayed, and is
+// left out of snapshots and comparisons. The head is not hidden, so the
+// changes of the title are reported; see visibleText for the displayed text.
+var hiddenElements = map[string]bool{
+	"script":   true,
+	"style":    true,
+	"noscript": true,
+	"template": true,
+}
+
+// blockElements are the elements which start a new line of text.
+var blockElements = map[string]bool{
+	"address": true, "article": true, "aside": true, "blockquote": true,
+	"br": true, "dd": true, "div": true, "dl": true, "dt": true,
+	"fieldset": true, "figcaption": true, "figure": true, "footer": true,
+	"form": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true,
+	"h6": true, "header": true, "hr": true, "li": true, "main": true,
+	"nav": true, "ol": true, "p": true, "pre": true, "section": true,
+	"table": true, "td": true, "th": true, "tr": true,
+	"ul": true,
+}
+
+// markdownEscaper escapes the characters which have a meaning in Markdown text.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`)
+
+// blankLines matches the runs of blank lines collapsed by ExportMarkdown.
+var blankLines = regexp.MustCompile(`\n\s*\n\s*\n+`)
+
+// Text returns the visible text of the current page. Scripts and styles are
+// left out, whitespace is collapsed and the content of block elements, such
+// as paragraphs and list items, is separated by new lines.
+func (bow *Browser) Text() string {
+	if bow.dom() == nil {
+		return ""
+	}
+	return strings.Join(visibleText(bow.dom().Nodes), "\n")
+}
+
+// ExportMarkdown converts the body of the current page to Markdown and writes
+// it to w. Links and images are written with absolute URLs, and scripts,
+// styles and forms controls are left out.
+func (bow *Browser) ExportMarkdown(w io.Writer) (int64, error) {
+	if bow.dom() == nil {
+		return 0, nil
+	}
+	buff := &bytes.Buffer{}
+	for _, n := range bow.dom().Nodes {
+		buff.WriteString(bow.markdown(n, false))
+	}
+	md := tidyMarkdown(blankLines.ReplaceAllString(buff.String(), "\n\n"))
+	if md == "" {
+		return 0, nil
+	}
+	n, err := io.WriteString(w, md+"\n")
+	return int64(n), err
+}
+
+// visibleText returns the visible text of the given nodes, one block per line.
+func visibleText(nodes []*html.Node) []string {
+	var lines []string
+	var text bytes.Buffer
+	flush := func() {
+		if line := strings.Join(strings.Fields(text.String()), " "); line != "" {
+			lines = append(lines, line)
+		}
+		text.Reset()
+	}
+
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			text.WriteString(n.Data)
+			return
+		case html.ElementNode:
+			if hiddenElements[n.Data] || n.Data == "head" {
+				return
+			}
+		case html.DocumentNode:
+		default:
+			return
+		}
+		block := n.Type == html.ElementNode && blockElements[n.Data]
+		if block {
+			flush()
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+		if block {
+			flush()
+		}
+	}
+	for _, n := range nodes {
+		walk(n)
+	}
+	flush()
+	return lines
+}
+
+// markdown returns the Markdown for the given node. Text is written as is
+// when pre is true.
+func (bow *Browser) markdown(n *html.Node, pre bool) string {
+	switch n.Type {
+	case html.TextNode:
+		if pre {
+			return n.Data
+		}
+		return markdownEscaper.Replace(collapseSpace(n.Data))
+	case html.DocumentNode:
+		return bow.markdownChildren(n, pre)
+	case html.ElementNode:
+	default:
+		return ""
+	}
+
+	switch n.Data {
+	case "head", "script", "style", "noscript", "template", "input", "select", "textarea", "button":
+		return ""
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		level, _ := strconv.Atoi(n.Data[1:])
+		return markdownBlock(strings.Repeat("#", level) + " " + strings.TrimSpace(bow.markdownChildren(n, pre)))
+	case "br":
+		return "  \n"
+	case "hr":
+		return markdownBlock("---")
+	case "strong", "b":
+		return markdownWrap("**", bow.markdownChildren(n, pre))
+	case "em", "i":
+		return markdownWrap("_", bow.markdownChildren(n, pre))
+	case "code":
+		if pre {
+			return bow.markdownChildren(n, pre)
+		}
+		return markdownWrap("`", nodeText(n))
+	case "pre":
+		return markdownBlock("```\n" + strings.Trim(nodeText(n), "\n") + "\n```")
+	case "a":
+		text := strings.TrimSpace(bow.markdownChildren(n, pre))
+		href, ok := bow.resolveNodeURL(n, "href")
+		if !ok || text == "" {
+			return text
+		}
+		return "[" + text + "](" + href + ")"
+	case "img":
+		src, ok := bow.resolveNodeURL(n, "src")
+		if !ok {
+			return ""
+		}
+		return "![" + markdownEscaper.Replace(nodeAttr(n, "alt")) + "](" + src + ")"
+	case "ul", "ol":
+		var items []string
+		i := 1
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode || c.Data != "li" {
+				continue
+			}
+			marker := "- "
+			if n.Data == "ol" {
+				marker = strconv.Itoa(i) + ". "
+				i++
+			}
+			item := strings.TrimSpace(blankLines.ReplaceAllString(bow.markdownChildren(c, pre), "\n"))
+			item = strings.Replace(item, "\n\n", "\n", -1)
+			items = append(items, marker+strings.Replace(item, "\n", "\n"+strings.Repeat(" ", len(marker)), -1))
+		}
+		return markdownBlock(strings.Join(items, "\n"))
+	case "blockquote":
+		content := strings.TrimSpace(blankLines.ReplaceAllString(bow.markdownChildren(n, pre), "\n\n"))
+		return markdownBlock("> " + strings.Replace(content, "\n", "\n> ", -1))
+	case "table":
+		return markdownBlock(bow.markdownTable(n))
+	}
+
+	content := bow.markdownChildren(n, pre)
+	if blockElements[n.Data] {
+		return markdownBlock(strings.TrimSpace(content))
+	}
+	return content
+}
+
+// markdownChildren returns the Markdown for the children of the given node.
+func (bow *Browser) markdownChildren(n *html.Node, pre bool) string {
+	buff := &bytes.Buffer{}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		buff.WriteString(bow.markdown(c, pre))
+	}
+	return buff.String()
+}
+
+// markdownTable returns the rows of the given table as a Markdown table.
+// The first row is used as the header.
+func (bow *Browser) markdownTable(n *html.Node) string {
+	var rows []string
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			if c.Data != "tr" {
+				walk(c)
+				continue
+			}
+			var cells []string
+			for td := c.FirstChild; td != nil; td = td.NextSibling {
+				if td.Type == html.ElementNode && (td.Data == "td" || td.Data == "th") {
+					cell := collapseSpace(bow.markdownChildren(td, false))
+					cells = append(cells, strings.Replace(strings.TrimSpace(cell), "|", `\|`, -1))
+				}
+			}
+			rows = append(rows, "| "+strings.Join(cells, " | ")+" |")
+			if len(rows) == 1 {
+				rows = append(rows, strings.Repeat("| --- ", len(cells))+"|")
+			}
+		}
+	}
+	walk(n)
+	return strings.Join(rows, "\n")
+}
+
+// resolveNodeURL returns the absolute URL found in the given attribute.
+func (bow *Browser) resolveNodeURL(n *html.Node, attr string) (string, bool) {
+	v := strings.TrimSpace(nodeAttr(n, attr))
+	if v == "" || strings.HasPrefix(v, "javascript:") {
+		return "", false
+	}
+	u, err := bow.ResolveStringURL(v)
+	if err != nil {
+		return "", false
+	}
+	return u, true
+}
+
+// tidyMarkdown trims the whitespace left around paragraphs by the text
+// between block elements. Code blocks are left untouched.
+func tidyMarkdown(md string) string {
+	lines := strings.Split(md, "\n")
+	fenced := false
+	for i, line := range lines {
+		if strings.HasPrefix(line, "```") {
+			fenced = !fenced
+			continue
+		}
+		if fenced {
+			continue
+		}
+		if i == 0 || strings.TrimSpace(lines[i-1]) == "" {
+			line = strings.TrimLeft(line, " ")
+		}
+		if i == len(lines)-1 || strings.TrimSpace(lines[i+1]) == "" {
+			line = strings.TrimRight(line, " ")
+		}
+		lines[i] = line
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// markdownBlock separates the given block from its siblings with blank lines.
+func markdownBlock(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "\n\n" + s + "\n\n"
+}
+
+// markdownWrap surrounds the given text with the marker, keeping the
+// surrounding whitespace outside of the marker.
+func markdownWrap(marker, s string) string {
+	t := strings.TrimSpace(s)
+	if t == "" {
+		return s
+	}
+	i := strings.Index(s, t)
+	return s[:i] + marker + t + marker + s[i+len(t):]
+}
+
+// collapseSpace replaces each run of whitespace with a single space.
+func collapseSpace(s string) string {
+	var buff bytes.Buffer
+	space := false
+	for _, r := range s {
+		switch r {
+		case ' ', '\t', '\n', '\r', '\f':
+			if !space {
+				buff.WriteByte(' ')
+			}
+			space = true
+		default:
+			buff.WriteRune(r)
+			space = false
+		}
+	}
+	return buff.String()
+}
+
+// nodeText returns the text content of the given node.
+func nodeText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	buff := &bytes.Buffer{}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		buff.WriteString(nodeText(c))
+	}
+	return buff.String()
+}
+
+// nodeAttr returns the value of the given attribute of the node.
+func nodeAttr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
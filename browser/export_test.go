@@ -0,0 +1,85 @@
+package browser
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const exportPage = `<html><head><title>Export</title><style>p { color: red; }</style></head>
+<body>
+	<h1>Hello,   world</h1>
+	<p>Some <b>bold</b> and <em>emphasized</em> text with a <a href="/about">link</a>.</p>
+	<script>document.write("hidden");</script>
+	<ul>
+		<li>First</li>
+		<li>Second <code>x_y</code></li>
+	</ul>
+	<ol><li>One</li><li>Two</li></ol>
+	<pre><code>go test ./...
+go vet ./...</code></pre>
+	<blockquote><p>Quoted</p></blockquote>
+	<img src="/logo.png" alt="Logo">
+	<table>
+		<tr><th>Name</th><th>Age</th></tr>
+		<tr><td>Joe</td><td>32</td></tr>
+	</table>
+</body></html>`
+
+func newExportServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(exportPage))
+	}))
+}
+
+func TestText(t *testing.T) {
+	ts := newExportServer()
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	if bow.Text() != "" {
+		t.Error("Expected no text before a page is loaded")
+	}
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	expected := "Hello, world\n" +
+		"Some bold and emphasized text with a link.\n" +
+		"First\nSecond x_y\nOne\nTwo\n" +
+		"go test ./... go vet ./...\n" +
+		"Quoted\n" +
+		"Name\nAge\nJoe\n32"
+	if text := bow.Text(); text != expected {
+		t.Errorf("Expected text:\n%s\ngot:\n%s", expected, text)
+	}
+}
+
+func TestExportMarkdown(t *testing.T) {
+	ts := newExportServer()
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	buff := &bytes.Buffer{}
+	n, err := bow.ExportMarkdown(buff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "# Hello, world\n\n" +
+		"Some **bold** and _emphasized_ text with a [link](" + ts.URL + "/about).\n\n" +
+		"- First\n- Second `x_y`\n\n" +
+		"1. One\n2. Two\n\n" +
+		"```\ngo test ./...\ngo vet ./...\n```\n\n" +
+		"> Quoted\n\n" +
+		"![Logo](" + ts.URL + "/logo.png)\n\n" +
+		"| Name | Age |\n| --- | --- |\n| Joe | 32 |\n"
+	if buff.String() != expected {
+		t.Errorf("Expected markdown:\n%s\ngot:\n%s", expected, buff.String())
+	}
+	if n != int64(len(expected)) {
+		t.Errorf("Expected %d bytes written, got %d", len(expected), n)
+	}
+}
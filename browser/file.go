@@ -0,0 +1,300 @@
+package browser
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// File represents a input type file, that includes the fileName and a io.reader
+type File struct {
+	fileName    string
+	data        io.Reader
+	path        string
+	contentType string
+
+	// start is the offset data is rewound to when the file is sent again.
+	start int64
+}
+
+// NewFile returns a file with the given name and content.
+func NewFile(fileName string, data io.Reader) *File {
+	return &File{fileName: fileName, data: data}
+}
+
+// FileFromPath returns the file at the given path, named after its base
+// name and typed after its extension. The file is opened when it's sent.
+func FileFromPath(path string) (*File, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	return &File{
+		fileName:    filepath.Base(path),
+		path:        path,
+		contentType: mime.TypeByExtension(filepath.Ext(path)),
+	}, nil
+}
+
+// WithContentType sets the Content-Type of the file part, which is
+// application/octet-stream by default, and returns the file.
+func (f *File) WithContentType(contentType string) *File {
+	f.contentType = contentType
+	return f
+}
+
+// FileName returns the name the file is sent with.
+func (f *File) FileName() string {
+	return f.fileName
+}
+
+// ContentType returns the Content-Type the file is sent with.
+func (f *File) ContentType() string {
+	if f.contentType == "" {
+		return "application/octet-stream"
+	}
+	return f.contentType
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// writePart writes the file as the part of the given field.
+func (f *File) writePart(w *multipart.Writer, field string) error {
+	pw, err := f.createPart(w, field)
+	if err != nil {
+		return err
+	}
+	data := f.data
+	if f.path != "" {
+		fl, err := os.Open(f.path)
+		if err != nil {
+			return err
+		}
+		defer fl.Close()
+		data = fl
+	}
+	if data != nil {
+		_, err = io.Copy(pw, data)
+	}
+	return err
+}
+
+// createPart writes the headers of the file part of the given field.
+func (f *File) createPart(w *multipart.Writer, field string) (io.Writer, error) {
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`,
+		quoteEscaper.Replace(field), quoteEscaper.Replace(f.fileName)))
+	h.Set("Content-Type", f.ContentType())
+	return w.CreatePart(h)
+}
+
+// size returns the size of the file content, and false when it's unknown
+// before reading it.
+func (f *File) size() (int64, bool) {
+	if f.path != "" {
+		fi, err := os.Stat(f.path)
+		if err != nil {
+			return 0, false
+		}
+		return fi.Size(), true
+	}
+	switch d := f.data.(type) {
+	case nil:
+		return 0, true
+	case interface{ Len() int }:
+		return int64(d.Len()), true
+	}
+	return 0, false
+}
+
+// FileSet represents the files used to post multipart, by field name. A
+// field may have several files, eg for multiple file inputs.
+type FileSet map[string][]*File
+
+// Get returns the first file of the field, or nil.
+func (fs FileSet) Get(name string) *File {
+	if files := fs[name]; len(files) > 0 {
+		return files[0]
+	}
+	return nil
+}
+
+// Set sets the files of the field, replacing the existing ones.
+func (fs FileSet) Set(name string, files ...*File) {
+	fs[name] = files
+}
+
+// Add adds a file to the field.
+func (fs FileSet) Add(name string, file *File) {
+	fs[name] = append(fs[name], file)
+}
+
+// Del deletes the files of the field.
+func (fs FileSet) Del(name string) {
+	delete(fs, name)
+}
+
+// replayable returns a copy of the files which may be sent again, eg after
+// a redirect or to reload the page, and false when a file is read from a
+// reader which can't be rewound.
+func (fs FileSet) replayable() (FileSet, bool) {
+	c := make(FileSet, len(fs))
+	for name, files := range fs {
+		for _, f := range files {
+			f2 := *f
+			switch d := f.data.(type) {
+			case nil:
+			case *bytes.Buffer:
+				f2.data = bytes.NewReader(d.Bytes())
+			case io.Seeker:
+				start, err := d.Seek(0, io.SeekCurrent)
+				if err != nil {
+					return nil, false
+				}
+				f2.start = start
+			default:
+				return nil, false
+			}
+			c[name] = append(c[name], &f2)
+		}
+	}
+	return c, true
+}
+
+// rewind seeks the readers of the files back to where they started.
+func (fs FileSet) rewind() error {
+	for _, files := range fs {
+		for _, f := range files {
+			if sk, ok := f.data.(io.Seeker); ok && f.path == "" {
+				if _, err := sk.Seek(f.start, io.SeekStart); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// multipartBody streams a multipart/form-data body, so files are sent as
+// they're read instead of being read in memory first.
+type multipartBody struct {
+	*io.PipeReader
+
+	// contentType is the Content-Type of the body, with its boundary.
+	contentType string
+
+	// boundary separates the parts of the body.
+	boundary string
+
+	// size is the length of the body, or -1 when a file size is unknown.
+	size int64
+
+	// done is closed once the files are no longer read.
+	done chan struct{}
+}
+
+// newMultipartBody starts writing the fields and files to the returned
+// body, with the given boundary or a random one when empty. The body must
+// be closed, which stops the writing when the body was not fully read.
+func newMultipartBody(fields url.Values, files FileSet, boundary string) *multipartBody {
+	pr, pw := io.Pipe()
+	w := multipart.NewWriter(pw)
+	if boundary != "" {
+		w.SetBoundary(boundary)
+	}
+	b := &multipartBody{
+		PipeReader:  pr,
+		contentType: w.FormDataContentType(),
+		boundary:    w.Boundary(),
+		size:        multipartSize(w.Boundary(), fields, files),
+		done:        make(chan struct{}),
+	}
+	go func() {
+		defer close(b.done)
+		pw.CloseWithError(writeMultipart(w, fields, files))
+	}()
+	return b
+}
+
+// Close stops the writing of the body, and waits for the files to be no
+// longer read, so they may be rewound.
+func (b *multipartBody) Close() error {
+	err := b.PipeReader.Close()
+	<-b.done
+	return err
+}
+
+// replayer returns a function building the body again with the same
+// boundary, for http.Request.GetBody. The files must be replayable.
+func (b *multipartBody) replayer(fields url.Values, files FileSet) func() (io.ReadCloser, error) {
+	last := b
+	return func() (io.ReadCloser, error) {
+		last.Close()
+		if err := files.rewind(); err != nil {
+			return nil, err
+		}
+		last = newMultipartBody(fields, files, b.boundary)
+		return last, nil
+	}
+}
+
+// writeMultipart writes the fields and files to w.
+func writeMultipart(w *multipart.Writer, fields url.Values, files FileSet) error {
+	for k, vs := range fields {
+		for _, v := range vs {
+			if err := w.WriteField(k, v); err != nil {
+				return err
+			}
+		}
+	}
+	for k, fs := range files {
+		for _, file := range fs {
+			if err := file.writePart(w, k); err != nil {
+				return err
+			}
+		}
+	}
+	return w.Close()
+}
+
+// multipartSize returns the length of the multipart body with the given
+// boundary, or -1 when a file size is unknown.
+func multipartSize(boundary string, fields url.Values, files FileSet) int64 {
+	var cw countWriter
+	w := multipart.NewWriter(&cw)
+	w.SetBoundary(boundary)
+	for k, vs := range fields {
+		for _, v := range vs {
+			w.WriteField(k, v)
+		}
+	}
+	var size int64
+	for k, fs := range files {
+		for _, file := range fs {
+			n, ok := file.size()
+			if !ok {
+				return -1
+			}
+			size += n
+			file.createPart(w, k)
+		}
+	}
+	w.Close()
+	return size + cw.n
+}
+
+// countWriter counts the bytes written to it.
+type countWriter struct {
+	n int64
+}
+
+func (cw *countWriter) Write(p []byte) (int, error) {
+	cw.n += int64(len(p))
+	return len(p), nil
+}
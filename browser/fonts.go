@@ -0,0 +1,263 @@
+package browser
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Font stores the properties of a web font.
+type Font struct {
+	DownloadableAsset
+
+	// Family is the font-family of the @font-face rule declaring the font,
+	// or empty for a preloaded font which is not declared.
+	Family string
+
+	// Format is the format of the font, eg "woff2", from the format() hint
+	// of the @font-face rule or the type of the preload link, or empty.
+	Format string
+}
+
+// NewFontAsset creates and returns a new *Font type.
+func NewFontAsset(u *url.URL, family, format string) *Font {
+	return &Font{
+		DownloadableAsset: DownloadableAsset{
+			Asset: Asset{
+				URL:  u,
+				Type: FontAsset,
+			},
+		},
+		Family: family,
+		Format: format,
+	}
+}
+
+// Fonts returns the fonts preloaded with <link rel="preload" as="font">,
+// and declared by the @font-face rules of the <style> elements, the linked
+// stylesheets and the stylesheets they import. The stylesheets are
+// downloaded with the session of the browser.
+//
+// The fonts found are returned with the first error downloading a
+// stylesheet, if any.
+func (bow *Browser) Fonts() ([]*Font, error) {
+	var fonts []*Font
+	index := make(map[string]*Font)
+	add := func(f *Font) {
+		if prev := index[f.URL.String()]; prev != nil {
+			if prev.Family == "" {
+				prev.Family = f.Family
+			}
+			if prev.Format == "" {
+				prev.Format = f.Format
+			}
+			return
+		}
+		index[f.URL.String()] = f
+		fonts = append(fonts, f)
+	}
+
+	bow.Find("link[rel~='preload' i][as='font' i][href]").Each(func(_ int, s *goquery.Selection) {
+		href, err := bow.attrToResolvedURL("href", s)
+		if err == nil {
+			typ := strings.TrimSpace(s.AttrOr("type", ""))
+			add(NewFontAsset(href, "", strings.TrimPrefix(strings.ToLower(typ), "font/")))
+		}
+	})
+	base := bow.documentBase()
+	bow.Find("style").Each(func(_ int, s *goquery.Selection) {
+		for _, f := range cssFonts(base, []byte(s.Text())) {
+			add(f)
+		}
+	})
+
+	// The linked stylesheets and their imports, once.
+	var first error
+	var queue []*url.URL
+	for _, s := range bow.Stylesheets() {
+		queue = append(queue, s.URL)
+	}
+	seen := make(map[string]bool)
+	for len(queue) > 0 {
+		u := queue[0]
+		queue = queue[1:]
+		if seen[u.String()] || (u.Scheme != "http" && u.Scheme != "https") {
+			continue
+		}
+		seen[u.String()] = true
+		css, _, err := bow.fetchAsset(u.String())
+		if err != nil {
+			if first == nil {
+				first = err
+			}
+			continue
+		}
+		for _, f := range cssFonts(u, css) {
+			add(f)
+		}
+		for _, r := range CSSResources(u, css) {
+			if r.Import {
+				queue = append(queue, r.URL)
+			}
+		}
+	}
+	return fonts, first
+}
+
+// cssFonts returns the fonts of the @font-face rules of the stylesheet,
+// resolved against its URL.
+func cssFonts(base *url.URL, css []byte) []*Font {
+	var fonts []*Font
+	for _, block := range fontFaceBlocks(css) {
+		var family, src string
+		for _, decl := range splitCSS(block, ';') {
+			i := strings.IndexByte(decl, ':')
+			if i < 0 {
+				continue
+			}
+			switch strings.ToLower(strings.TrimSpace(decl[:i])) {
+			case "font-family":
+				family = cssFamily(strings.TrimSpace(decl[i+1:]))
+			case "src":
+				src = decl[i+1:]
+			}
+		}
+		for _, item := range splitCSS(src, ',') {
+			refs := ParseCSS([]byte(item))
+			if len(refs) == 0 {
+				continue
+			}
+			u, err := url.Parse(strings.TrimSpace(refs[0].URL))
+			if err != nil {
+				continue
+			}
+			if base != nil {
+				u = base.ResolveReference(u)
+			}
+			if u.Scheme != "http" && u.Scheme != "https" {
+				continue
+			}
+			fonts = append(fonts, NewFontAsset(u, family, cssFormat([]byte(item), refs[0].End)))
+		}
+	}
+	return fonts
+}
+
+// fontFaceBlocks returns the contents of the @font-face rules of the
+// stylesheet, between their braces.
+func fontFaceBlocks(css []byte) []string {
+	var blocks []string
+	for i := 0; i < len(css); {
+		switch c := css[i]; {
+		case c == '/' && i+1 < len(css) && css[i+1] == '*':
+			i = skipCSSComment(css, i)
+		case c == '"' || c == '\'':
+			_, i = readCSSString(css, i)
+		case c == '\\':
+			i += 2
+		case c == '@' && hasCSSPrefix(css[i:], "@font-face") && !isCSSNameByte(css, i+10):
+			j := skipCSSSpace(css, i+10)
+			if j >= len(css) || css[j] != '{' {
+				i = j
+				continue
+			}
+			end := j + 1
+			for depth := 1; end < len(css) && depth > 0; {
+				switch css[end] {
+				case '{':
+					depth++
+					end++
+				case '}':
+					depth--
+					end++
+				case '"', '\'':
+					_, end = readCSSString(css, end)
+				case '/':
+					if end+1 < len(css) && css[end+1] == '*' {
+						end = skipCSSComment(css, end)
+					} else {
+						end++
+					}
+				default:
+					end++
+				}
+			}
+			blocks = append(blocks, strings.TrimSuffix(string(css[j+1:end]), "}"))
+			i = end
+		default:
+			i++
+		}
+	}
+	return blocks
+}
+
+// splitCSS splits the declarations, or values, of a block on the separator,
+// outside strings, comments and parentheses, eg of url().
+func splitCSS(s string, sep byte) []string {
+	var parts []string
+	b := []byte(s)
+	depth, last := 0, 0
+	for i := 0; i < len(b); {
+		switch c := b[i]; {
+		case c == '/' && i+1 < len(b) && b[i+1] == '*':
+			i = skipCSSComment(b, i)
+		case c == '"' || c == '\'':
+			_, i = readCSSString(b, i)
+		case c == '\\':
+			i += 2
+		case c == '(':
+			depth++
+			i++
+		case c == ')':
+			if depth > 0 {
+				depth--
+			}
+			i++
+		case c == sep && depth == 0:
+			parts = append(parts, s[last:i])
+			i++
+			last = i
+		default:
+			i++
+		}
+	}
+	if last < len(s) {
+		parts = append(parts, s[last:])
+	}
+	return parts
+}
+
+// cssFamily returns the first family of a font-family value, unquoted.
+func cssFamily(v string) string {
+	if v == "" {
+		return ""
+	}
+	if v[0] == '"' || v[0] == '\'' {
+		s, _ := readCSSString([]byte(v), 0)
+		return s
+	}
+	if i := strings.IndexByte(v, ','); i >= 0 {
+		v = v[:i]
+	}
+	return strings.Join(strings.Fields(v), " ")
+}
+
+// cssFormat returns the format() hint following the url() ending at i, eg
+// "woff2", or an empty string.
+func cssFormat(item []byte, i int) string {
+	j := skipCSSSpace(item, i)
+	if !hasCSSPrefix(item[j:], "format(") {
+		return ""
+	}
+	j = skipCSSSpace(item, j+7)
+	if j < len(item) && (item[j] == '"' || item[j] == '\'') {
+		s, _ := readCSSString(item, j)
+		return strings.ToLower(s)
+	}
+	end := j
+	for end < len(item) && item[end] != ')' {
+		end++
+	}
+	return strings.ToLower(strings.TrimSpace(string(item[j:end])))
+}
@@ -0,0 +1,85 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestCSSFonts(t *testing.T) {
+	css := `/* @font-face { src: url(commented.woff) } */
+@font-face {
+	font-family: "Open Sans", sans-serif;
+	src: local("Open Sans"), url(data:font/woff2;base64,AAAA) format("woff2"),
+		url('/fonts/open.woff2') format("woff2"), url(/fonts/open.woff) FORMAT(woff);
+}
+@FONT-FACE { font-family: Fira   Code; src: url(fira.ttf) }
+body { background: url(bg.png) }`
+	base, _ := url.Parse("http://example.com/css/")
+	fonts := cssFonts(base, []byte(css))
+	expected := []struct{ url, family, format string }{
+		{"http://example.com/fonts/open.woff2", "Open Sans", "woff2"},
+		{"http://example.com/fonts/open.woff", "Open Sans", "woff"},
+		{"http://example.com/css/fira.ttf", "Fira Code", ""},
+	}
+	if len(fonts) != len(expected) {
+		t.Fatalf("Expected %d fonts, got %v.", len(expected), fonts)
+	}
+	for i, f := range fonts {
+		if f.URL.String() != expected[i].url || f.Family != expected[i].family || f.Format != expected[i].format || f.Type != FontAsset {
+			t.Errorf("Expected the font %+v, got %s %q %q.", expected[i], f.URL, f.Family, f.Format)
+		}
+	}
+}
+
+func TestFonts(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "1"})
+			w.Write([]byte(`<html><head>
+				<link rel="preload" href="/fonts/title.woff2" as="font" type="font/woff2" crossorigin>
+				<link rel="preload" href="/hero.jpg" as="image">
+				<link rel="stylesheet" href="/css/site.css">
+				<link rel="stylesheet" href="/css/missing.css">
+				<style>@font-face { font-family: Body; src: url(/fonts/body.woff) format("woff") }</style>
+			</head><body></body></html>`))
+		case "/css/site.css":
+			if c, err := r.Cookie("session"); err != nil || c.Value != "1" {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+			w.Header().Set("Content-Type", "text/css")
+			w.Write([]byte(`@import "theme.css"; @font-face { font-family: Title; src: url(../fonts/title.woff2) }`))
+		case "/css/theme.css":
+			w.Header().Set("Content-Type", "text/css")
+			w.Write([]byte(`@import "site.css"; @font-face { font-family: Theme; src: url(theme.otf) format("opentype") }`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	fonts, err := bow.Fonts()
+	if err == nil {
+		t.Error("Expected an error for the missing stylesheet.")
+	}
+	expected := []struct{ path, family, format string }{
+		{"/fonts/title.woff2", "Title", "woff2"},
+		{"/fonts/body.woff", "Body", "woff"},
+		{"/css/theme.otf", "Theme", "opentype"},
+	}
+	if len(fonts) != len(expected) {
+		t.Fatalf("Expected %d fonts, got %v.", len(expected), fonts)
+	}
+	for i, f := range fonts {
+		if f.URL.Path != expected[i].path || f.Family != expected[i].family || f.Format != expected[i].format {
+			t.Errorf("Expected the font %+v, got %s %q %q.", expected[i], f.URL, f.Family, f.Format)
+		}
+	}
+}
@@ -22,6 +22,10 @@ type Submittable interface {
 	Input(name, value string) error
 	Set(name, value string) error
 
+	// FillStruct sets the form fields from the fields of a struct tagged with
+	// the form field names, eg `surf:"email"`.
+	FillStruct(v interface{}) error
+
 	// Remove will remove the input completely from the form.
 	Remove(name string)
 
@@ -63,6 +67,21 @@ type Submittable interface {
 	// matches.  If name is not found, error is returned.
 	SelectLabels(name string) ([]string, error)
 
+	// AddOption adds an option to a select form element, eg an option added
+	// by a script.
+	AddOption(name, value, label string) error
+
+	// SelectValue sets the current value of a select form element, adding
+	// the values which are not options when override is true.
+	SelectValue(name string, override bool, values ...string) error
+
+	// Datalist returns the suggestions of the datalist of an input.
+	Datalist(name string) ([]string, error)
+
+	// Type sets the value of a text field one character at a time, firing
+	// the keyboard events when the FormEvents attribute is set.
+	Type(name, text string) error
+
 	// File sets the value for an form input type file,
 	// it returns an ElementNotFound error if the field does not exists
 	File(name string, fileName string, data io.Reader) error
@@ -71,13 +90,23 @@ type Submittable interface {
 	// It will add the field to the form if necessary
 	SetFile(name string, fileName string, data io.Reader)
 
+	// AddFile adds a file to a form input type file, eg to upload several
+	// files with a multiple file input.
+	AddFile(name string, file *File)
+
 	Buttons() url.Values
 
 	Button(name string) bool
 
-	Click(button string) error
-	ClickByValue(name, value string) error
-	Submit() error
+	Click(button string, opts ...RequestOption) error
+	ClickByValue(name, value string, opts ...RequestOption) error
+	Submit(opts ...RequestOption) error
+
+	// SubmitAndValidate submits the form, and returns a ValidationError
+	// with the messages of the elements matching errorSelector in the
+	// returned page.
+	SubmitAndValidate(errorSelector string, opts ...RequestOption) error
+
 	Dom() *goquery.Selection
 }
 
@@ -92,6 +121,10 @@ type Form struct {
 	checkboxs url.Values
 	selects   selects
 	files     FileSet
+
+	// events fires the events of the fields, when the FormEvents
+	// attribute is set. It's shared by the forms of the page.
+	events *formEvents
 }
 
 // NewForm creates and returns a *Form type.
@@ -136,6 +169,7 @@ func (f *Form) SetAction(actionURL string) {
 func (f *Form) Input(name, value string) error {
 	if _, ok := f.fields[name]; ok {
 		f.fields.Set(name, value)
+		f.fire(name, "input", "change")
 		return nil
 	}
 	return errors.NewElementNotFound("No input found with name '%s'.", name)
@@ -146,7 +180,7 @@ func (f *Form) Input(name, value string) error {
 func (f *Form) File(name string, fileName string, data io.Reader) error {
 
 	if _, ok := f.files[name]; ok {
-		f.files[name] = &File{fileName: fileName, data: data}
+		f.files.Set(name, NewFile(fileName, data))
 		return nil
 	}
 	return errors.NewElementNotFound("No input type 'file' found with name '%s'.", name)
@@ -155,7 +189,20 @@ func (f *Form) File(name string, fileName string, data io.Reader) error {
 // SetFile sets the value for a form input type file.
 // It will add the field to the form if necessary
 func (f *Form) SetFile(name string, fileName string, data io.Reader) {
-	f.files[name] = &File{fileName: fileName, data: data}
+	f.files.Set(name, NewFile(fileName, data))
+}
+
+// AddFile adds a file to a form input type file, eg to upload several
+// files with a multiple file input. It will add the field to the form if
+// necessary, and replaces the empty value of the field.
+func (f *Form) AddFile(name string, file *File) {
+	var files []*File
+	for _, fl := range f.files[name] {
+		if fl.fileName != "" || fl.data != nil || fl.path != "" {
+			files = append(files, fl)
+		}
+	}
+	f.files[name] = append(files, file)
 }
 
 // Set will set the value of a form field if it exists,
@@ -163,6 +210,7 @@ func (f *Form) SetFile(name string, fileName string, data io.Reader) {
 func (f *Form) Set(name, value string) error {
 	if _, ok := f.fields[name]; !ok {
 		f.fields.Add(name, value)
+		f.fire(name, "input", "change")
 		return nil
 	}
 	return f.Input(name, value)
@@ -171,7 +219,9 @@ func (f *Form) Set(name, value string) error {
 // Check sets the checkbox value to its active state.
 func (f *Form) Check(name string) error {
 	if _, ok := f.checkboxs[name]; ok {
+		prev := f.fields[name]
 		f.fields.Set(name, f.checkboxs.Get(name))
+		f.click(name, prev)
 		return nil
 	}
 	return errors.NewElementNotFound("No checkbox found with name '%s'.", name)
@@ -180,7 +230,9 @@ func (f *Form) Check(name string) error {
 // UnCheck sets the checkbox value to inactive state.
 func (f *Form) UnCheck(name string) error {
 	if _, ok := f.checkboxs[name]; ok {
+		prev := f.fields[name]
 		f.fields.Del(name)
+		f.click(name, prev)
 		return nil
 	}
 	return errors.NewElementNotFound("No checkbox found with name '%s'.", name)
@@ -254,6 +306,7 @@ func (f *Form) SelectByOptionLabel(name string, optionLabel ...string) error {
 		}
 		f.fields.Add(name, s.labels.Get(l))
 	}
+	f.fire(name, "input", "change")
 	return nil
 }
 
@@ -274,6 +327,7 @@ func (f *Form) SelectByOptionValue(name string, optionValue ...string) error {
 		}
 		f.fields.Add(name, v)
 	}
+	f.fire(name, "input", "change")
 	return nil
 }
 
@@ -300,6 +354,69 @@ func (f *Form) SelectLabels(name string) ([]string, error) {
 	return labels, nil
 }
 
+// AddOption adds an option to a select form element, as the scripts of
+// enhanced selects (eg Select2) do with the options they load, so it may be
+// selected by value or label.
+func (f *Form) AddOption(name, value, label string) error {
+	s, ok := f.selects[name]
+	if !ok {
+		return errors.NewElementNotFound("No select element found with name '%s'.", name)
+	}
+	if _, ok := s.values[value]; !ok {
+		s.values.Add(value, label)
+		s.labels.Add(label, value)
+	}
+	return nil
+}
+
+// SelectValue sets the current value of a select form element. Values which
+// are not options of the element are refused, unless override is true, in
+// which case they are added as options labelled with their value.
+func (f *Form) SelectValue(name string, override bool, values ...string) error {
+	s, ok := f.selects[name]
+	if !ok {
+		return errors.NewElementNotFound("No select element found with name '%s'.", name)
+	}
+	if override {
+		for _, v := range values {
+			if _, ok := s.values[v]; !ok {
+				f.AddOption(name, v, v)
+			}
+		}
+	}
+	return f.SelectByOptionValue(name, values...)
+}
+
+// Datalist returns the suggestions of the datalist of the input whose name
+// matches, which are the values of its options, or their text when they have
+// no value. Returns an error when the input has no datalist.
+func (f *Form) Datalist(name string) ([]string, error) {
+	input := f.selection.Find("input[list]").FilterFunction(func(_ int, s *goquery.Selection) bool {
+		return s.AttrOr("name", "") == name
+	}).First()
+	if input.Length() == 0 {
+		return nil, errors.NewElementNotFound("No input with a datalist found with name '%s'.", name)
+	}
+	// The datalist may be outside of the form.
+	root := f.selection.Parents().Last()
+	if root.Length() == 0 {
+		root = f.selection
+	}
+	list := findByID(root, input.AttrOr("list", ""))
+	if !list.Is("datalist") {
+		return nil, errors.NewElementNotFound("No datalist found with id '%s'.", input.AttrOr("list", ""))
+	}
+	var values []string
+	list.Find("option").Each(func(_ int, opt *goquery.Selection) {
+		v, ok := opt.Attr("value")
+		if !ok {
+			v = strings.TrimSpace(opt.Text())
+		}
+		values = append(values, v)
+	})
+	return values, nil
+}
+
 func (f *Form) Buttons() url.Values {
 	return f.buttons
 }
@@ -318,26 +435,26 @@ func (f *Form) Button(name string) bool {
 // Submit submits the form.
 // Clicks the first button in the form, or submits the form without using
 // any button when the form does not contain any buttons.
-func (f *Form) Submit() error {
+func (f *Form) Submit(opts ...RequestOption) error {
 	if len(f.buttons) > 0 {
 		for name := range f.buttons {
-			return f.Click(name)
+			return f.Click(name, opts...)
 		}
 	}
-	return f.send("", "")
+	return f.send("", "", opts...)
 }
 
 // Click submits the form by clicking the button with the given name.
-func (f *Form) Click(button string) error {
+func (f *Form) Click(button string, opts ...RequestOption) error {
 	if _, ok := f.buttons[button]; !ok {
 		return errors.NewInvalidFormValue(
 			"Form does not contain a button with the name '%s'.", button)
 	}
-	return f.send(button, f.buttons[button][0])
+	return f.send(button, f.buttons[button][0], opts...)
 }
 
 // Click submits the form by clicking the button with the given name and value.
-func (f *Form) ClickByValue(name, value string) error {
+func (f *Form) ClickByValue(name, value string, opts ...RequestOption) error {
 	if _, ok := f.buttons[name]; !ok {
 		return errors.NewInvalidFormValue(
 			"Form does not contain a button with the name '%s'.", name)
@@ -353,7 +470,7 @@ func (f *Form) ClickByValue(name, value string) error {
 		return errors.NewInvalidFormValue(
 			"Form does not contain a button with the name '%s' and value '%s'.", name, value)
 	}
-	return f.send(name, value)
+	return f.send(name, value, opts...)
 }
 
 // Dom returns the inner *goquery.Selection.
@@ -362,13 +479,20 @@ func (f *Form) Dom() *goquery.Selection {
 }
 
 // send submits the form.
-func (f *Form) send(buttonName, buttonValue string) error {
+func (f *Form) send(buttonName, buttonValue string, opts ...RequestOption) error {
+	// The submit listeners may still set fields, eg a signature, or prevent
+	// the submission.
+	if !f.fire("", "submit") {
+		return errors.NewBlocked("The scripts of the page prevented the submission of the form.")
+	}
 	method, ok := f.selection.Attr("method")
 	if !ok {
 		method = "GET"
 	}
+	// Forms without an action are submitted to the page URL, regardless of
+	// the base URL.
 	action, ok := f.selection.Attr("action")
-	if !ok {
+	if !ok || strings.TrimSpace(action) == "" {
 		action = f.bow.URL().String()
 	}
 	aurl, err := url.Parse(action)
@@ -386,13 +510,13 @@ func (f *Form) send(buttonName, buttonValue string) error {
 	}
 
 	if strings.ToUpper(method) == "GET" {
-		return f.bow.GETForm(aurl.String(), values)
+		return f.bow.GETForm(aurl.String(), values, opts...)
 	}
 	enctype, _ := f.selection.Attr("enctype")
 	if enctype == "multipart/form-data" {
-		return f.bow.POSTMultipart(aurl.String(), values, f.files)
+		return f.bow.POSTMultipart(aurl.String(), values, f.files, opts...)
 	}
-	return f.bow.POSTForm(aurl.String(), values)
+	return f.bow.POSTForm(aurl.String(), values, opts...)
 }
 
 // serializeForm converts the form fields into a url.Values type.
@@ -422,7 +546,7 @@ func serializeForm(sel *goquery.Selection) (url.Values, url.Values, url.Values,
 					checkboxs.Add(name, val)
 				}
 			} else if t == "file" {
-				files[name] = &File{}
+				files.Add(name, &File{})
 			} else {
 				fields.Add(name, val)
 			}
@@ -482,7 +606,7 @@ func formAttributes(bow Browsable, s *goquery.Selection) (string, string) {
 		method = "GET"
 	}
 	action, ok := s.Attr("action")
-	if !ok {
+	if !ok || strings.TrimSpace(action) == "" {
 		action = bow.URL().String()
 	}
 	aurl, err := url.Parse(action)
@@ -14,6 +14,9 @@ import (
 	"strings"
 
 	"io/ioutil"
+	"path/filepath"
+
+	"time"
 
 	surferrors "github.com/lostinblue/surf/errors"
 	"github.com/lostinblue/surf/jar"
@@ -399,14 +402,54 @@ func TestSubmitMultipart(t *testing.T) {
 	ut.AssertNil(err)
 
 	f.Input("comment", "my profile picture")
-	imgData, err := base64.StdEncoding.DecodeString(image)
+	imgData, err := base64.StdEncoding.DecodeString(testImage)
 	err = f.File("image", "profile.png", bytes.NewBuffer(imgData))
 	ut.AssertNil(err)
 	err = f.Submit()
 	ut.AssertNil(err)
 	ut.AssertContains("comment=my+profile+picture", bow.Body())
 	ut.AssertContains("image=profile.png", bow.Body())
-	ut.AssertContains(fmt.Sprintf("profile.png=%s", url.QueryEscape(image)), bow.Body())
+	ut.AssertContains(fmt.Sprintf("profile.png=%s", url.QueryEscape(testImage)), bow.Body())
+}
+
+func TestSubmitMultipleFiles(t *testing.T) {
+	ut.Run(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			fmt.Fprint(w, `<html><body><form method="post" action="/" enctype="multipart/form-data">
+				<input type="file" name="photos" multiple />
+				<input type="file" name="notes" />
+			</form></body></html>`)
+			return
+		}
+		r.ParseMultipartForm(1024 * 1024)
+		for _, name := range []string{"photos", "notes"} {
+			for _, fh := range r.MultipartForm.File[name] {
+				fmt.Fprintf(w, "%s=%s;%s ", name, fh.Filename, fh.Header.Get("Content-Type"))
+			}
+		}
+	}))
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	ut.AssertNil(ioutil.WriteFile(path, []byte("notes"), 0644))
+
+	bow := newBrowser()
+	ut.AssertNil(bow.GET(ts.URL))
+	f, err := bow.Form("form")
+	ut.AssertNil(err)
+
+	f.AddFile("photos", NewFile("a.png", strings.NewReader("a")).WithContentType("image/png"))
+	f.AddFile("photos", NewFile("b.jpg", strings.NewReader("b")).WithContentType("image/jpeg"))
+	notes, err := FileFromPath(path)
+	ut.AssertNil(err)
+	f.AddFile("notes", notes)
+	ut.AssertNil(f.Submit())
+
+	ut.AssertContains("photos=a.png;image/png photos=b.jpg;image/jpeg notes=notes.txt;text/plain", bow.Body())
+
+	_, err = FileFromPath(filepath.Join(t.TempDir(), "missing.txt"))
+	ut.AssertNotNil(err)
 }
 
 func setupTestServer(html string, t *testing.T) *httptest.Server {
@@ -446,4 +489,128 @@ func setupTestServer(html string, t *testing.T) *httptest.Server {
 	return ts
 }
 
-var image = `iVBORw0KGgoAAAANSUhEUgAAACAAAAAgCAYAAABzenr0AAACjUlEQVRYR+2Wy6oiMRCG4x0V76CIim504fu/gk/hQlS8LhQVERR15stQPZl0a3IOwtlMgXSnU/nrq0p12thoNHqqH7T4D8bWof8DeFXg+fzTJnKldK57c/7dNjsBYrGY4hcFkUwmVSqV0vMmFL7y7F1w5pIuh8fjoeLxv5y5XE61Wi1VKpUUANj9fleHw0Etl0t1Op0CSR8QJ4BkD0i321XtdltXw8wQkGq1qmq1moaYTqeuvIJ5J4B4djodHVy2xI4gQM1mUwPOZjNdOVcvOHuAQJQdAF9ji4rFojM4el4ACPo2lWwZa3zMCUAJy+Wyj5b2wZ/SFwqFf5r3lYATIJ1OB93+SsR8LhUAIpPJOJc4AUTB1Ux2pFfNavs5AW63m+IVlMxsAXssryjXy+ViT4fGTgAC73Y7vdCnEfEBmIPJp2pOAMTW67UW456rCJtj7mUM7Gq1CmUb9cAJQEYcr4vFQldAgnA1zazOdrtV+/3eq2JOAIKQ8Xw+11lJL3A1g8rebzYbNZlMAtiorM1nTgBzH8nseDxGvt9SKbZLIF3BmY/Z/wklEzMwZwFHcb1ejwxuBmJrAKVi1+s1mDIrZ/o7P0b5fF71+339PfAxAjUaDX0SjsdjdT6f3y4LbQEC0mAEHw6HOrhZkbeKvyfxZQ1r0cDQNHtGNEIAOCYSCX38DgYD/Y8Hi1osIvZVfFmLBlpo2m8O60IAsrjX66lsNqsDmz87mD22/dFAC4tKIhKAstFwnzK00PQCYP/kb9enAN5phirAJ5TvfxTtd4HQQjPq8xwCqFQqQfCvdP4rONEAAm3bQgC8v5/MXgKiibZtIQCaxaS2F3x1LMmgKWeCqREC4Nj9ROltUDTRtu0X2hs2IkarWoAAAAAASUVORK5CYII=`
+var testImage = `iVBORw0KGgoAAAANSUhEUgAAACAAAAAgCAYAAABzenr0AAACjUlEQVRYR+2Wy6oiMRCG4x0V76CIim504fu/gk/hQlS8LhQVERR15stQPZl0a3IOwtlMgXSnU/nrq0p12thoNHqqH7T4D8bWof8DeFXg+fzTJnKldK57c/7dNjsBYrGY4hcFkUwmVSqV0vMmFL7y7F1w5pIuh8fjoeLxv5y5XE61Wi1VKpUUANj9fleHw0Etl0t1Op0CSR8QJ4BkD0i321XtdltXw8wQkGq1qmq1moaYTqeuvIJ5J4B4djodHVy2xI4gQM1mUwPOZjNdOVcvOHuAQJQdAF9ji4rFojM4el4ACPo2lWwZa3zMCUAJy+Wyj5b2wZ/SFwqFf5r3lYATIJ1OB93+SsR8LhUAIpPJOJc4AUTB1Ux2pFfNavs5AW63m+IVlMxsAXssryjXy+ViT4fGTgAC73Y7vdCnEfEBmIPJp2pOAMTW67UW456rCJtj7mUM7Gq1CmUb9cAJQEYcr4vFQldAgnA1zazOdrtV+/3eq2JOAIKQ8Xw+11lJL3A1g8rebzYbNZlMAtiorM1nTgBzH8nseDxGvt9SKbZLIF3BmY/Z/wklEzMwZwFHcb1ejwxuBmJrAKVi1+s1mDIrZ/o7P0b5fF71+339PfAxAjUaDX0SjsdjdT6f3y4LbQEC0mAEHw6HOrhZkbeKvyfxZQ1r0cDQNHtGNEIAOCYSCX38DgYD/Y8Hi1osIvZVfFmLBlpo2m8O60IAsrjX66lsNqsDmz87mD22/dFAC4tKIhKAstFwnzK00PQCYP/kb9enAN5phirAJ5TvfxTtd4HQQjPq8xwCqFQqQfCvdP4rONEAAm3bQgC8v5/MXgKiibZtIQCaxaS2F3x1LMmgKWeCqREC4Nj9ROltUDTRtu0X2hs2IkarWoAAAAAASUVORK5CYII=`
+
+func TestFormFillStruct(t *testing.T) {
+	ts := setupTestServer(`
+<!doctype html>
+<html>
+<body>
+	<form method="post" name="signup">
+		<input type="text" name="email" value="" />
+		<input type="text" name="age" value="" />
+		<input type="text" name="birthday" value="" />
+		<input type="text" name="score" value="" />
+		<input type="radio" name="plan" value="free" />
+		<input type="radio" name="plan" value="pro" />
+		<input type="checkbox" name="terms" value="yes" />
+		<input type="checkbox" name="news" value="yes" checked="checked" />
+		<select name="colors" multiple>
+			<option value="red">Red</option>
+			<option value="green">Green</option>
+			<option value="blue">Blue</option>
+		</select>
+		<input type="submit" name="submit" value="go" />
+	</form>
+</body>
+</html>`, t)
+	defer ts.Close()
+
+	type Account struct {
+		Plan string `surf:"plan"`
+	}
+	type Signup struct {
+		Account
+		Email    string    `surf:"email"`
+		Age      int       `surf:"age,omitempty"`
+		Birthday time.Time `surf:"birthday" layout:"01/02/2006"`
+		Score    *float64  `surf:"score"`
+		Terms    bool      `surf:"terms"`
+		News     bool      `surf:"news"`
+		Colors   []string  `surf:"colors"`
+		Ignored  string
+	}
+
+	bow := newBrowser()
+	ut.AssertNil(bow.GET(ts.URL))
+	f, err := bow.Form("[name='signup']")
+	ut.AssertNil(err)
+
+	score := 9.5
+	err = f.FillStruct(&Signup{
+		Account:  Account{Plan: "pro"},
+		Email:    "joe@example.com",
+		Birthday: time.Date(1990, 4, 1, 0, 0, 0, 0, time.UTC),
+		Score:    &score,
+		Terms:    true,
+		Colors:   []string{"red", "blue"},
+	})
+	ut.AssertNil(err)
+	ut.AssertNil(f.Click("submit"))
+	ut.AssertEquals("age=&birthday=04%2F01%2F1990&colors=red&colors=blue&email=joe%40example.com&plan=pro&score=9.5&submit=go&terms=yes", string(bow.body))
+
+	_, ok := f.FillStruct(struct {
+		Missing string `surf:"missing"`
+	}{"x"}).(surferrors.ElementNotFound)
+	ut.AssertTrue(ok)
+	_, ok = f.FillStruct(struct {
+		Colors []string `surf:"colors"`
+	}{[]string{"purple"}}).(surferrors.ElementNotFound)
+	ut.AssertTrue(ok)
+	_, ok = f.FillStruct("email").(surferrors.InvalidFormValue)
+	ut.AssertTrue(ok)
+}
+
+func TestFormDynamicOptions(t *testing.T) {
+	ts := setupTestServer(`
+<!doctype html>
+<html>
+<body>
+	<form method="post" name="order">
+		<select name="city" class="select2">
+			<option value="">Choose...</option>
+		</select>
+		<select name="tags" multiple></select>
+		<input type="text" name="browser" list="browsers" />
+		<input type="text" name="plain" />
+		<input type="submit" name="submit" value="go" />
+	</form>
+	<datalist id="browsers">
+		<option value="Firefox">
+		<option>Chrome</option>
+	</datalist>
+</body>
+</html>`, t)
+	defer ts.Close()
+
+	bow := newBrowser()
+	ut.AssertNil(bow.GET(ts.URL))
+	f, err := bow.Form("[name='order']")
+	ut.AssertNil(err)
+
+	_, ok := f.SelectValue("city", false, "paris").(surferrors.ElementNotFound)
+	ut.AssertTrue(ok)
+	ut.AssertNil(f.AddOption("city", "lyon", "Lyon"))
+	ut.AssertNil(f.SelectByOptionLabel("city", "Lyon"))
+	ut.AssertNil(f.SelectValue("tags", true, "a", "b"))
+	ut.AssertNil(f.Submit())
+	ut.AssertEquals("browser=&city=lyon&plain=&submit=go&tags=a&tags=b", string(bow.body))
+
+	ut.AssertNil(f.SelectValue("city", true, "paris"))
+	labels, err := f.SelectLabels("city")
+	ut.AssertNil(err)
+	ut.AssertEquals("paris", labels[0])
+	_, ok = f.AddOption("missing", "x", "X").(surferrors.ElementNotFound)
+	ut.AssertTrue(ok)
+
+	ut.AssertNil(bow.GET(ts.URL))
+	f, _ = bow.Form("[name='order']")
+	values, err := f.Datalist("browser")
+	ut.AssertNil(err)
+	ut.AssertEquals(2, len(values))
+	ut.AssertEquals("Firefox", values[0])
+	ut.AssertEquals("Chrome", values[1])
+	_, err = f.Datalist("plain")
+	_, ok = err.(surferrors.ElementNotFound)
+	ut.AssertTrue(ok)
+}
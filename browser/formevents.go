@@ -0,0 +1,478 @@
+package browser
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/lostinblue/surf/errors"
+	"github.com/robertkrimen/otto"
+	"golang.org/x/net/html"
+)
+
+// FormScriptTimeout is the time the scripts of a form may run, when the
+// FormEvents attribute is set, before they are stopped.
+var FormScriptTimeout = time.Second
+
+// formFields selects the elements of a form which are visible to its scripts.
+const formFields = "input[name], select[name], textarea[name], button[name]"
+
+// formShim is the minimal DOM of the form scripts: the forms of the page,
+// their fields and a document to find them. The value and checked properties
+// of the fields read and write the values of their form.
+const formShim = `
+var window = this, self = this;
+var console = {log: function() {}, info: function() {}, warn: function() {}, error: function() {}};
+var __surf = {
+	elements: [],
+	form: null,
+	listenable: function(obj) {
+		obj._listeners = {};
+		obj.addEventListener = function(type, fn) {
+			(this._listeners[type] = this._listeners[type] || []).push(fn);
+		};
+		obj.removeEventListener = function(type, fn) {
+			var l = this._listeners[type] || [];
+			for (var i = 0; i < l.length; i++) {
+				if (l[i] === fn) {
+					l.splice(i, 1);
+					break;
+				}
+			}
+		};
+		obj.dispatchEvent = function(ev) {
+			ev.target = ev.target || this;
+			for (var node = this; node && !ev._stopped; node = ev.bubbles ? node.parentNode : null) {
+				ev.currentTarget = node;
+				var h = node["on" + ev.type];
+				if (typeof h === "function" && h.call(node, ev) === false) {
+					ev.preventDefault();
+				}
+				var l = ((node._listeners || {})[ev.type] || []).slice();
+				for (var i = 0; i < l.length; i++) {
+					if (typeof l[i] === "function") {
+						l[i].call(node, ev);
+					} else if (l[i] && l[i].handleEvent) {
+						l[i].handleEvent(ev);
+					}
+				}
+			}
+			return !ev.defaultPrevented;
+		};
+		return obj;
+	},
+	event: function(type, key) {
+		return {
+			type: type, key: key, bubbles: type !== "focus" && type !== "blur", defaultPrevented: false,
+			preventDefault: function() { this.defaultPrevented = true; },
+			stopPropagation: function() { this._stopped = true; }
+		};
+	},
+	add: function(tag, json) {
+		var attrs = JSON.parse(json);
+		var el = __surf.listenable({
+			tagName: tag.toUpperCase(), nodeName: tag.toUpperCase(),
+			name: attrs.name || "", id: attrs.id || "",
+			type: (attrs.type || (tag === "select" ? "select-one" : tag === "textarea" ? "textarea" : "text")).toLowerCase(),
+			getAttribute: function(a) { return attrs.hasOwnProperty(a) ? attrs[a] : null; },
+			setAttribute: function(a, v) { attrs[a] = String(v); },
+			hasAttribute: function(a) { return attrs.hasOwnProperty(a); },
+			querySelector: function(sel) { return document.querySelector(sel); },
+			querySelectorAll: function(sel) { return document.querySelectorAll(sel); }
+		});
+		var name = el.name;
+		if (tag === "form") {
+			el.elements = [];
+			el.submit = function() {};
+			el.parentNode = document;
+			el._index = document.forms.length;
+			document.forms.push(el);
+			__surf.form = el;
+		} else {
+			var form = __surf.form;
+			el.form = form;
+			el.parentNode = form;
+			form.elements.push(el);
+			if (name && !(name in form)) {
+				form[name] = el;
+				form.elements[name] = el;
+			}
+			if (el.type === "checkbox" || el.type === "radio") {
+				var value = attrs.value || "";
+				el.value = value;
+				Object.defineProperty(el, "checked", {
+					get: function() { return __surf.has(form._index, name, value); },
+					set: function(on) { __surf.toggle(form._index, name, value, !!on, el.type === "radio"); }
+				});
+			} else {
+				Object.defineProperty(el, "value", {
+					get: function() { return __surf.get(form._index, name); },
+					set: function(v) { __surf.set(form._index, name, String(v)); }
+				});
+			}
+		}
+		for (var a in attrs) {
+			if (a.indexOf("on") === 0) {
+				try {
+					el[a] = new Function("event", attrs[a]);
+				} catch (e) {}
+			}
+		}
+		__surf.elements.push(el);
+	},
+	fire: function(i, type, key) {
+		return __surf.elements[i].dispatchEvent(__surf.event(type, key));
+	},
+	found: function(indexes) {
+		var out = [];
+		for (var i = 0; i < indexes.length; i++) {
+			out.push(__surf.elements[indexes[i]]);
+		}
+		return out;
+	}
+};
+var document = __surf.listenable({
+	forms: [],
+	getElementById: function(id) {
+		for (var i = 0; i < __surf.elements.length; i++) {
+			if (__surf.elements[i].id === id) {
+				return __surf.elements[i];
+			}
+		}
+		return null;
+	},
+	getElementsByName: function(name) {
+		var out = [];
+		for (var i = 0; i < __surf.elements.length; i++) {
+			if (__surf.elements[i].name === name) {
+				out.push(__surf.elements[i]);
+			}
+		}
+		return out;
+	},
+	querySelector: function(sel) {
+		return __surf.found(__surf.query(String(sel)))[0] || null;
+	},
+	querySelectorAll: function(sel) {
+		return __surf.found(__surf.query(String(sel)));
+	}
+});
+__surf.listenable(window);
+`
+
+// formEvents holds the scripted DOM of the forms of a page, when the
+// FormEvents attribute is set. The inline scripts of the page run once, in
+// the JavaScript VM of the browser, and the page keeps a Form for each of
+// its forms, so the values set by the scripts are kept.
+type formEvents struct {
+	bow *Browser
+	doc *goquery.Document
+
+	// nodes are the forms and their fields, in the order of the elements
+	// of the shim.
+	nodes []*html.Node
+
+	// forms are the forms of the page, in the order of document.forms.
+	forms []*Form
+}
+
+// pageFormEvents returns the scripted forms of the current page, creating them
+// and running the inline scripts of the page the first time they are needed.
+func (bow *Browser) pageFormEvents() *formEvents {
+	doc := bow.dom()
+	if bow.formEvents == nil || bow.formEvents.doc != doc {
+		bow.formEvents = newFormEvents(bow, doc)
+	}
+	return bow.formEvents
+}
+
+// newFormEvents creates the scripting context of the forms of the page, and
+// runs its inline scripts. The errors of the scripts are ignored, as browsers
+// do, since most page scripts expect more than the form shim.
+func newFormEvents(bow *Browser, doc *goquery.Document) *formEvents {
+	e := &formEvents{bow: bow, doc: doc}
+	doc.Find("form").Each(func(_ int, s *goquery.Selection) {
+		f := NewForm(bow, s)
+		f.events = e
+		e.forms = append(e.forms, f)
+		e.nodes = append(e.nodes, s.Nodes...)
+		s.Find(formFields).Each(func(_ int, s *goquery.Selection) {
+			e.nodes = append(e.nodes, s.Nodes...)
+		})
+	})
+
+	err := e.run(func() error {
+		if _, err := bow.RunJavaScript(formShim); err != nil {
+			return err
+		}
+		surf, err := bow.javaScriptVM.Get("__surf")
+		if err != nil {
+			return err
+		}
+		e.bind(surf.Object())
+		for _, n := range e.nodes {
+			attrs := make(map[string]string, len(n.Attr))
+			for _, a := range n.Attr {
+				attrs[a.Key] = a.Val
+			}
+			data, _ := json.Marshal(attrs)
+			if _, err := surf.Object().Call("add", n.Data, string(data)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		e.nodes = nil
+		return e
+	}
+
+	doc.Find("script").Each(func(_ int, s *goquery.Selection) {
+		if _, ok := s.Attr("src"); ok {
+			return
+		}
+		if t := strings.ToLower(s.AttrOr("type", "")); t != "" && !strings.Contains(t, "javascript") && t != "module" {
+			return
+		}
+		src := s.Text()
+		e.run(func() error {
+			_, err := bow.javaScriptVM.Run(src)
+			return err
+		})
+	})
+	e.run(func() error {
+		_, err := bow.javaScriptVM.Run(`document.dispatchEvent(__surf.event("DOMContentLoaded"));` +
+			`window.dispatchEvent(__surf.event("load"));`)
+		return err
+	})
+	return e
+}
+
+// form returns the Form of the page for the first element of the selection,
+// or nil when it's not a form of the page.
+func (e *formEvents) form(s *goquery.Selection) *Form {
+	if s.Length() == 0 {
+		return nil
+	}
+	for _, f := range e.forms {
+		if f.selection.Get(0) == s.Get(0) {
+			return f
+		}
+	}
+	return nil
+}
+
+// bind sets the functions of the shim reading and writing the form values.
+// Their first argument is the index of the form.
+func (e *formEvents) bind(surf *otto.Object) {
+	fields := func(c otto.FunctionCall) url.Values {
+		i, _ := c.Argument(0).ToInteger()
+		if i < 0 || i >= int64(len(e.forms)) {
+			return url.Values{}
+		}
+		return e.forms[i].fields
+	}
+	surf.Set("get", func(c otto.FunctionCall) otto.Value {
+		v, _ := c.Otto.ToValue(fields(c).Get(c.Argument(1).String()))
+		return v
+	})
+	surf.Set("set", func(c otto.FunctionCall) otto.Value {
+		fields(c).Set(c.Argument(1).String(), c.Argument(2).String())
+		return otto.UndefinedValue()
+	})
+	surf.Set("has", func(c otto.FunctionCall) otto.Value {
+		name, value := c.Argument(1).String(), c.Argument(2).String()
+		for _, v := range fields(c)[name] {
+			if v == value {
+				return otto.TrueValue()
+			}
+		}
+		return otto.FalseValue()
+	})
+	surf.Set("toggle", func(c otto.FunctionCall) otto.Value {
+		f := fields(c)
+		name, value := c.Argument(1).String(), c.Argument(2).String()
+		on, _ := c.Argument(3).ToBoolean()
+		radio, _ := c.Argument(4).ToBoolean()
+		var kept []string
+		if !radio {
+			for _, v := range f[name] {
+				if v != value {
+					kept = append(kept, v)
+				}
+			}
+		}
+		if on {
+			kept = append(kept, value)
+		}
+		if len(kept) == 0 {
+			f.Del(name)
+		} else {
+			f[name] = kept
+		}
+		return otto.UndefinedValue()
+	})
+	surf.Set("query", func(c otto.FunctionCall) otto.Value {
+		indexes := []int{}
+		e.doc.Find(c.Argument(0).String()).Each(func(_ int, s *goquery.Selection) {
+			for i, n := range e.nodes {
+				if n == s.Nodes[0] {
+					indexes = append(indexes, i)
+				}
+			}
+		})
+		v, _ := c.Otto.ToValue(indexes)
+		return v
+	})
+}
+
+// fire dispatches the events to the first field of the form with the name,
+// or to the form when name is empty. The key is set on the events, eg for
+// "keydown". Returns false when a listener prevented the default action.
+// The events of the forms of the pages the browser left are not fired.
+func (e *formEvents) fire(f *Form, name, key string, types ...string) bool {
+	if e.bow.formEvents != e || f.selection.Length() == 0 {
+		return true
+	}
+	i := -1
+	for j, n := range e.nodes {
+		if n == f.selection.Get(0) {
+			i = j
+			break
+		}
+	}
+	if i < 0 {
+		return true
+	}
+	if name != "" {
+		field := -1
+		for j := i + 1; j < len(e.nodes) && e.nodes[j].Data != "form"; j++ {
+			if nodeAttr(e.nodes[j], "name") == name {
+				field = j
+				break
+			}
+		}
+		if field < 0 {
+			return true
+		}
+		i = field
+	}
+	allowed := true
+	for _, t := range types {
+		e.run(func() error {
+			surf, err := e.bow.javaScriptVM.Get("__surf")
+			if err != nil || !surf.IsObject() {
+				return err
+			}
+			v, err := surf.Object().Call("fire", i, t, key)
+			if err != nil {
+				return err
+			}
+			if ok, _ := v.ToBoolean(); !ok {
+				allowed = false
+			}
+			return nil
+		})
+	}
+	return allowed
+}
+
+// run calls fn, stopping the VM after FormScriptTimeout.
+func (e *formEvents) run(fn func() error) (err error) {
+	if e.bow.javaScriptVM == nil {
+		e.bow.NewJavaScriptVM()
+	}
+	vm := e.bow.javaScriptVM
+	interrupt := make(chan func(), 1)
+	vm.Interrupt = interrupt
+	timer := time.AfterFunc(FormScriptTimeout, func() {
+		interrupt <- func() {
+			panic(errFormScriptTimeout)
+		}
+	})
+	defer func() {
+		timer.Stop()
+		vm.Interrupt = nil
+		if r := recover(); r != nil {
+			if r != errFormScriptTimeout {
+				panic(r)
+			}
+			err = errFormScriptTimeout
+		}
+	}()
+	return fn()
+}
+
+// errFormScriptTimeout stops the form scripts which run for too long.
+var errFormScriptTimeout = formScriptTimeout{}
+
+type formScriptTimeout struct{}
+
+func (formScriptTimeout) Error() string {
+	return "The form script did not complete in time."
+}
+
+// newForm returns the form of the selection. When the FormEvents attribute
+// is set, it's the scripted form of the page, whose values are kept.
+func (bow *Browser) newForm(s *goquery.Selection) *Form {
+	if bow.attributes[FormEvents] {
+		if f := bow.pageFormEvents().form(s); f != nil {
+			return f
+		}
+	}
+	return NewForm(bow, s)
+}
+
+// fire dispatches the events to the field with the name, when the form
+// events are enabled, and returns false when a listener prevented the
+// default action. See formEvents.fire().
+func (f *Form) fire(name string, types ...string) bool {
+	return f.fireKey(name, "", types...)
+}
+
+// fireKey dispatches the keyboard events of the key to the field with the
+// name, when the form events are enabled. See formEvents.fire().
+func (f *Form) fireKey(name, key string, types ...string) bool {
+	if f.events == nil {
+		return true
+	}
+	return f.events.fire(f, name, key, types...)
+}
+
+// click fires the click of the checkbox whose values were prev, and restores
+// them when a listener prevents the default action, as browsers do.
+// Otherwise the input and change events follow.
+func (f *Form) click(name string, prev []string) {
+	if f.fire(name, "click") {
+		f.fire(name, "input", "change")
+		return
+	}
+	if len(prev) == 0 {
+		f.fields.Del(name)
+	} else {
+		f.fields[name] = prev
+	}
+}
+
+// Type sets the value of a text field as if it was typed, one character at
+// a time. When the FormEvents attribute is set, the keydown, keypress, input
+// and keyup events are fired for each character, and change at the end. The
+// characters whose keydown or keypress is prevented are not typed.
+func (f *Form) Type(name, text string) error {
+	if _, ok := f.fields[name]; !ok {
+		return errors.NewElementNotFound("No input found with name '%s'.", name)
+	}
+	f.fields.Set(name, "")
+	for _, r := range text {
+		key := string(r)
+		if f.fireKey(name, key, "keydown") && f.fireKey(name, key, "keypress") {
+			f.fields.Set(name, f.fields.Get(name)+key)
+			f.fireKey(name, key, "input")
+		}
+		f.fireKey(name, key, "keyup")
+	}
+	f.fire(name, "change")
+	return nil
+}
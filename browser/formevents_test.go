@@ -0,0 +1,156 @@
+package browser
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lostinblue/surf/errors"
+	"github.com/lostinblue/surf/jar"
+)
+
+const formEventsPage = `<!doctype html>
+<html>
+<body>
+	<form method="post" name="pay">
+		<input type="text" name="amount" value="" oninput="document.getElementById('sig').value = 'sig-' + this.value">
+		<input type="text" name="email" value="">
+		<input type="hidden" id="sig" name="sig" value="">
+		<input type="hidden" name="keys" value="">
+		<input type="checkbox" name="terms" value="yes">
+		<input type="hidden" name="agreed" value="no">
+		<select name="plan"><option value="a">A</option><option value="b">B</option></select>
+		<input type="hidden" name="chosen" value="">
+		<input type="hidden" name="stamp" value="">
+		<input type="submit" name="go" value="Go">
+	</form>
+	<script>
+	document.addEventListener("DOMContentLoaded", function() {
+		var f = document.forms[0];
+		f.email.addEventListener("keydown", function(e) { f.keys.value += e.key; });
+		document.querySelector("input[name=terms]").addEventListener("change", function() {
+			f.agreed.value = this.checked ? "yes" : "no";
+		});
+		f.addEventListener("change", function(e) {
+			if (e.target.name === "plan") { f.chosen.value = e.target.value; }
+		});
+		f.addEventListener("submit", function() { f.stamp.value = "signed:" + f.sig.value; });
+	});
+	notDefined();
+	</script>
+	<script type="text/template">not javascript</script>
+</body>
+</html>`
+
+func TestFormEvents(t *testing.T) {
+	ts := setupTestServer(formEventsPage, t)
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	f, _ := bow.Form("form")
+	f.Input("amount", "10")
+	if err := f.Submit(); err != nil {
+		t.Fatal(err)
+	}
+	if want := "agreed=no&amount=10&chosen=&email=&go=Go&keys=&sig=&stamp="; string(bow.body) != want {
+		t.Errorf("Expected no events without the FormEvents attribute, got '%s'.", bow.body)
+	}
+
+	bow.SetAttribute(FormEvents, true)
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	f, _ = bow.Form("form")
+	f.Input("amount", "42")
+	if err := f.Type("email", "ab"); err != nil {
+		t.Fatal(err)
+	}
+	f.Check("terms")
+	f.SelectByOptionValue("plan", "b")
+	if err := f.Submit(); err != nil {
+		t.Fatal(err)
+	}
+	if want := "agreed=yes&amount=42&chosen=b&email=ab&go=Go&keys=ab&plan=b&sig=sig-42&stamp=signed%3Asig-42&terms=yes"; string(bow.body) != want {
+		t.Errorf("Expected the scripts to update the fields, got '%s'.", bow.body)
+	}
+	if err := f.Type("missing", "x"); err == nil {
+		t.Error("Expected an error for a missing field.")
+	}
+}
+
+func TestFormEventsTimeout(t *testing.T) {
+	defer func(d time.Duration) { FormScriptTimeout = d }(FormScriptTimeout)
+	FormScriptTimeout = 50 * time.Millisecond
+	ts := setupTestServer(`<html><body><form method="post"><input name="a" oninput="while (true) {}"></form>
+<script>while (true) {}</script></body></html>`, t)
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	bow.SetAttribute(FormEvents, true)
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	f, err := bow.Form("form")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Input("a", "x"); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Submit(); err != nil || string(bow.body) != "a=x" {
+		t.Errorf("Expected the scripts to be stopped, got '%s' (%v).", bow.body, err)
+	}
+}
+
+func TestFormEventsPage(t *testing.T) {
+	ts := setupTestServer(`<html><body>
+	<form method="post" id="login"><input name="user" value=""><input type="checkbox" name="remember" value="on"
+		onclick="return false"><input type="hidden" name="token" value=""></form>
+	<form method="post" id="search" onsubmit="event.preventDefault()"><input name="q" value=""></form>
+	<script>
+	window.runs = (window.runs || 0) + 1;
+	localStorage.setItem("seen", "yes");
+	document.forms[0].token.value = "t" + window.runs;
+	</script></body></html>`, t)
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	bow.SetAttribute(FormEvents, true)
+	storage := jar.NewMemoryStorage()
+	bow.SetLocalStorageJar(storage)
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	login, _ := bow.Form("#login")
+	login.Input("user", "jdoe")
+	if again, _ := bow.Form("#login"); again != login || len(bow.Forms()) == 0 {
+		t.Error("Expected the page to keep its forms.")
+	}
+	if v, err := bow.RunJavaScript("runs"); err != nil || v.String() != "1" {
+		t.Errorf("Expected the scripts to run once in the page VM, got %v (%v).", v, err)
+	}
+	if v, ok := storage.GetItem(ts.URL, "seen"); !ok || v != "yes" {
+		t.Error("Expected the scripts to use the storage of the page.")
+	}
+
+	if err := login.Check("remember"); err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := login.IsChecked("remember"); ok {
+		t.Error("Expected the prevented click not to check the box.")
+	}
+	search, _ := bow.Form("#search")
+	if err := search.Submit(); err == nil {
+		t.Error("Expected the prevented submission to be blocked.")
+	} else if _, ok := err.(errors.Blocked); !ok {
+		t.Errorf("Expected a Blocked error, got %T.", err)
+	}
+	if err := login.Submit(); err != nil {
+		t.Fatal(err)
+	}
+	if want := "token=t1&user=jdoe"; string(bow.body) != want {
+		t.Errorf("Expected the values of the page form, got '%s'.", bow.body)
+	}
+}
@@ -0,0 +1,166 @@
+package browser
+
+import (
+	"encoding"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+// DefaultTimeLayout is the layout of time.Time values filled by
+// Form.FillStruct() when the field has no layout tag. It's the format of the
+// HTML date input.
+var DefaultTimeLayout = "2006-01-02"
+
+// FillStruct sets the form fields from the fields of the given struct, or
+// pointer to struct, which have a surf tag with the name of a form field:
+//
+//	type Signup struct {
+//		Email    string    `surf:"email"`
+//		Age      int       `surf:"age,omitempty"`
+//		Terms    bool      `surf:"terms"`
+//		Birthday time.Time `surf:"birthday" layout:"01/02/2006"`
+//		Colors   []string  `surf:"colors"`
+//	}
+//
+// Bool fields check or uncheck checkboxes, fields of select elements choose
+// the options by value, and slices select multiple options. Numbers are
+// formatted with strconv, time.Time values with the layout tag or
+// DefaultTimeLayout, and types implementing encoding.TextMarshaler with
+// MarshalText. Nil pointers, and zero values tagged omitempty, are skipped.
+// The fields of embedded structs are filled too.
+//
+// Returns an ElementNotFound error when the form has no field with a tagged name.
+func (f *Form) FillStruct(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return errors.NewInvalidFormValue("Cannot fill the form from a nil pointer.")
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return errors.NewInvalidFormValue("Cannot fill the form from a %s, a struct is required.", rv.Kind())
+	}
+	return f.fillStruct(rv)
+}
+
+// fillStruct sets the form fields from the tagged fields of the struct.
+func (f *Form) fillStruct(rv reflect.Value) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		fv := rv.Field(i)
+		tag, ok := sf.Tag.Lookup("surf")
+		if !ok {
+			if sf.Anonymous {
+				for fv.Kind() == reflect.Ptr && !fv.IsNil() {
+					fv = fv.Elem()
+				}
+				if fv.Kind() == reflect.Struct {
+					if err := f.fillStruct(fv); err != nil {
+						return err
+					}
+				}
+			}
+			continue
+		}
+		if sf.PkgPath != "" {
+			continue
+		}
+
+		parts := strings.Split(tag, ",")
+		name := parts[0]
+		if name == "-" || name == "" {
+			continue
+		}
+		omitempty := len(parts) > 1 && parts[1] == "omitempty"
+		for fv.Kind() == reflect.Ptr {
+			if fv.IsNil() {
+				break
+			}
+			fv = fv.Elem()
+		}
+		if fv.Kind() == reflect.Ptr || (omitempty && fv.IsZero()) {
+			continue
+		}
+
+		if err := f.fillField(name, fv, sf.Tag.Get("layout")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fillField sets the form field with the given name from the struct field.
+func (f *Form) fillField(name string, fv reflect.Value, layout string) error {
+	if fv.Kind() == reflect.Bool {
+		if _, ok := f.checkboxs[name]; ok {
+			if fv.Bool() {
+				return f.Check(name)
+			}
+			return f.UnCheck(name)
+		}
+	}
+
+	var values []string
+	if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() != reflect.Uint8 {
+		for i := 0; i < fv.Len(); i++ {
+			s, err := formatFormValue(name, fv.Index(i), layout)
+			if err != nil {
+				return err
+			}
+			values = append(values, s)
+		}
+	} else {
+		s, err := formatFormValue(name, fv, layout)
+		if err != nil {
+			return err
+		}
+		values = []string{s}
+	}
+
+	if _, ok := f.selects[name]; ok {
+		return f.SelectByOptionValue(name, values...)
+	}
+	if _, ok := f.fields[name]; ok || f.selection.Find(`[name="`+name+`"]`).Length() > 0 {
+		f.fields[name] = values
+		return nil
+	}
+	return errors.NewElementNotFound("No input found with name '%s'.", name)
+}
+
+// formatFormValue returns the form value of the struct field.
+func formatFormValue(name string, fv reflect.Value, layout string) (string, error) {
+	if t, ok := fv.Interface().(time.Time); ok {
+		if layout == "" {
+			layout = DefaultTimeLayout
+		}
+		return t.Format(layout), nil
+	}
+	if m, ok := fv.Interface().(encoding.TextMarshaler); ok {
+		b, err := m.MarshalText()
+		return string(b), err
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		return fv.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(fv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(fv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(fv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(fv.Float(), 'f', -1, fv.Type().Bits()), nil
+	case reflect.Slice:
+		if fv.Type().Elem().Kind() == reflect.Uint8 {
+			return string(fv.Bytes()), nil
+		}
+	}
+	return "", errors.NewInvalidFormValue("Cannot fill the field '%s' from a %s.", name, fv.Type())
+}
@@ -0,0 +1,122 @@
+package browser
+
+import (
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+// DefaultHeadFilter is the global value for the head filter, which loads
+// the HTML pages of any size.
+var DefaultHeadFilter = HeadFilter{
+	ContentTypes: []string{"text/html", "application/xhtml+xml"},
+}
+
+// HeadFilter decides which pages are loaded when the HeadFirst attribute
+// is set, from the response to the HEAD request.
+type HeadFilter struct {
+	// ContentTypes are the media types of the pages to load, eg "text/html",
+	// or empty to load any page. Pages without a Content-Type are loaded.
+	ContentTypes []string
+
+	// MaxContentLength is the size above which pages are skipped, or 0 for
+	// no limit. Pages without a Content-Length are loaded.
+	MaxContentLength int64
+}
+
+// HeadResult is the response to a HEAD request sent because of the
+// HeadFirst attribute.
+type HeadResult struct {
+	// URL is the URL of the response, after following redirects.
+	URL *url.URL
+
+	// StatusCode is the status code of the response.
+	StatusCode int
+
+	// Header contains the response headers.
+	Header http.Header
+
+	// ContentType is the media type of the page, without parameters.
+	ContentType string
+
+	// ContentLength is the size of the page, or -1 when unknown.
+	ContentLength int64
+
+	// Skipped is true when the page was not loaded.
+	Skipped bool
+}
+
+// SetHeadFilter sets the filter deciding which pages are loaded when the
+// HeadFirst attribute is set.
+func (bow *Browser) SetHeadFilter(f HeadFilter) {
+	bow.headFilter = f
+}
+
+// HeadFilter returns the filter set with SetHeadFilter.
+func (bow *Browser) HeadFilter() HeadFilter {
+	return bow.headFilter
+}
+
+// LastHead returns the result of the last HEAD request sent because of the
+// HeadFirst attribute, or nil when none was sent.
+func (bow *Browser) LastHead() *HeadResult {
+	return bow.lastHead
+}
+
+// headFirst sends a HEAD request for the page about to be loaded, and
+// returns a Skipped error when the response doesn't pass the head filter.
+// Failed HEAD requests don't prevent loading the page, since some servers
+// don't implement the method or drop the connection: the errors sending the
+// request are returned by the GET request when they aren't specific to HEAD.
+func (bow *Browser) headFirst(u *url.URL, ref *url.URL, opts []RequestOption) error {
+	bow.lastHead = nil
+	req, err := bow.buildRequest("HEAD", u.String(), ref, nil, opts...)
+	if err != nil {
+		return err
+	}
+	resp, cancel, err := bow.do(req)
+	if err != nil {
+		return nil
+	}
+	defer cancel()
+	resp.Body.Close()
+
+	head := &HeadResult{
+		URL:           resp.Request.URL,
+		StatusCode:    resp.StatusCode,
+		Header:        resp.Header,
+		ContentLength: resp.ContentLength,
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "" {
+		head.ContentType, _, _ = mime.ParseMediaType(ct)
+	}
+	bow.lastHead = head
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil
+	}
+	if !bow.headFilter.accepts(head) {
+		head.Skipped = true
+		return errors.NewSkipped(head.ContentType, head.ContentLength,
+			"Page '%s' is %s of %d bytes.", u.String(), head.ContentType, head.ContentLength)
+	}
+	return nil
+}
+
+// accepts returns whether the page of the HEAD result should be loaded.
+func (f HeadFilter) accepts(head *HeadResult) bool {
+	if f.MaxContentLength > 0 && head.ContentLength > f.MaxContentLength {
+		return false
+	}
+	if len(f.ContentTypes) == 0 || head.ContentType == "" {
+		return true
+	}
+	for _, ct := range f.ContentTypes {
+		if strings.EqualFold(ct, head.ContentType) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+func TestHeadFirst(t *testing.T) {
+	var gets []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			gets = append(gets, r.URL.Path)
+		}
+		switch r.URL.Path {
+		case "/file.zip":
+			w.Header().Set("Content-Type", "application/zip")
+			w.Write([]byte("PK"))
+		case "/big":
+			body := "<html><body>" + strings.Repeat("x", 2048) + "</body></html>"
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+			w.Write([]byte(body))
+		case "/drop":
+			if r.Method == "HEAD" {
+				conn, _, _ := w.(http.Hijacker).Hijack()
+				conn.Close()
+				return
+			}
+			w.Write([]byte("<html><head><title>Dropped HEAD</title></head></html>"))
+		case "/nohead":
+			if r.Method == "HEAD" {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.Write([]byte("<html><head><title>No HEAD</title></head></html>"))
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte("<html><head><title>Page</title></head></html>"))
+		}
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	bow.SetAttribute(HeadFirst, true)
+	bow.SetHeadFilter(DefaultHeadFilter)
+
+	if err := bow.GET(ts.URL + "/page"); err != nil {
+		t.Fatal(err)
+	}
+	if bow.Title() != "Page" {
+		t.Errorf("Expected title %q, got %q", "Page", bow.Title())
+	}
+	if head := bow.LastHead(); head == nil || head.ContentType != "text/html" || head.Skipped {
+		t.Errorf("Unexpected HEAD result %+v", head)
+	}
+
+	err := bow.GET(ts.URL + "/file.zip")
+	if e, ok := err.(errors.Skipped); !ok || e.ContentType != "application/zip" {
+		t.Errorf("Expected a Skipped error, got %v", err)
+	}
+	if head := bow.LastHead(); head == nil || !head.Skipped {
+		t.Errorf("Expected a skipped HEAD result, got %+v", head)
+	}
+	if bow.Title() != "Page" {
+		t.Errorf("Expected the page to be kept, got %q", bow.Title())
+	}
+
+	if err := bow.GET(ts.URL + "/big"); err != nil {
+		t.Fatal(err)
+	}
+	bow.SetHeadFilter(HeadFilter{MaxContentLength: 1024})
+	if _, ok := bow.GET(ts.URL + "/big").(errors.Skipped); !ok {
+		t.Error("Expected a Skipped error for an oversized page")
+	}
+
+	if err := bow.GET(ts.URL + "/nohead"); err != nil {
+		t.Fatal(err)
+	}
+	if bow.Title() != "No HEAD" {
+		t.Errorf("Expected title %q, got %q", "No HEAD", bow.Title())
+	}
+
+	if err := bow.GET(ts.URL + "/drop"); err != nil {
+		t.Fatalf("Expected a failed HEAD not to block the page, got %v", err)
+	}
+	if bow.Title() != "Dropped HEAD" || bow.LastHead() != nil {
+		t.Errorf("Expected the page without a HEAD result, got %q", bow.Title())
+	}
+
+	expected := []string{"/page", "/big", "/nohead", "/drop"}
+	if strings.Join(gets, " ") != strings.Join(expected, " ") {
+		t.Errorf("Expected GET requests %v, got %v", expected, gets)
+	}
+}
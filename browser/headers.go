@@ -0,0 +1,54 @@
+package browser
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+
+	"github.com/lostinblue/surf/headers"
+)
+
+// headerOrderKey is the context key of the header order of a request.
+type headerOrderKey struct{}
+
+// ApplyHeaderProfile sets the headers of the profile on the headers jar, and
+// records their order for the requests the browser sends, see HeaderOrder().
+// The headers set by the profile applied before are removed, unless they were
+// changed since, and a nil profile only removes them and clears the order.
+func (bow *Browser) ApplyHeaderProfile(p *headers.Profile) {
+	for name, values := range bow.profileHeaders {
+		if reflect.DeepEqual(bow.headers[name], values) {
+			delete(bow.headers, name)
+		}
+	}
+	bow.profileHeaders = nil
+	if p == nil {
+		bow.headerOrder = nil
+		return
+	}
+	bow.profileHeaders = make(http.Header)
+	for name, values := range p.Header() {
+		bow.headers[name] = values
+		bow.profileHeaders[name] = append([]string(nil), values...)
+	}
+	bow.headerOrder = p.Order()
+}
+
+// HeaderOrder returns the order the headers of the request should be sent
+// in, set with Browser.ApplyHeaderProfile(), or nil.
+//
+// The net/http transport always sends headers sorted by name, so the order is
+// only used by custom transports set with Browser.SetTransport(), eg to mimic
+// the header order of a browser.
+func HeaderOrder(req *http.Request) []string {
+	order, _ := req.Context().Value(headerOrderKey{}).([]string)
+	return order
+}
+
+// withHeaderOrder returns the request with the browser header order, if any.
+func (bow *Browser) withHeaderOrder(req *http.Request) *http.Request {
+	if len(bow.headerOrder) == 0 {
+		return req
+	}
+	return req.WithContext(context.WithValue(req.Context(), headerOrderKey{}, bow.headerOrder))
+}
@@ -0,0 +1,67 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/lostinblue/surf/headers"
+)
+
+// orderTransport records the header order of the requests it sends.
+type orderTransport struct {
+	order []string
+}
+
+func (t *orderTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.order = HeaderOrder(req)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestApplyHeaderProfile(t *testing.T) {
+	var accept string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept = r.Header.Get("Accept")
+		w.Write([]byte("<html></html>"))
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	rt := &orderTransport{}
+	bow.SetTransport(rt)
+	bow.ApplyHeaderProfile(headers.JSONAPI())
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if accept != "application/json" {
+		t.Errorf("Expected Accept application/json, got %q", accept)
+	}
+	expected := []string{"Accept", "User-Agent", "Cache-Control"}
+	if !reflect.DeepEqual(rt.order, expected) {
+		t.Errorf("Expected header order %v, got %v", expected, rt.order)
+	}
+
+	bow.ApplyHeaderProfile(nil)
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if rt.order != nil {
+		t.Errorf("Expected no header order, got %v", rt.order)
+	}
+	if accept == "application/json" {
+		t.Errorf("Expected the Accept header of the profile to be removed, got %q", accept)
+	}
+
+	// Switching profiles drops the headers missing from the new one, and
+	// keeps the headers changed since.
+	bow.ApplyHeaderProfile(headers.New("a", "X-A", "1", "X-Kept", "1"))
+	bow.AddRequestHeader("X-Kept", "2")
+	bow.ApplyHeaderProfile(headers.New("b", "X-B", "1"))
+	if bow.headers.Get("X-A") != "" || bow.headers.Get("X-B") != "1" {
+		t.Errorf("Expected only the headers of the new profile, got %v", bow.headers)
+	}
+	if got := bow.headers["X-Kept"]; !reflect.DeepEqual(got, []string{"2"}) {
+		t.Errorf("Expected the changed header to be kept, got %v", got)
+	}
+}
@@ -0,0 +1,128 @@
+package browser
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ResourceHintRels are the rel values of the resource hints, see
+// ResourceHints().
+var ResourceHintRels = []string{
+	"preload", "modulepreload", "prefetch", "prerender", "dns-prefetch", "preconnect",
+}
+
+// ResourceHint stores the properties of a resource hint: a resource the
+// page will need, or an origin it will connect to.
+type ResourceHint struct {
+	DownloadableAsset
+
+	// Rel is the hint, one of ResourceHintRels, eg "preload".
+	Rel string
+
+	// As is the destination of a preloaded resource, eg "font" or "script".
+	As string
+
+	// MimeType is the value of the type attribute, eg "font/woff2".
+	MimeType string
+
+	// CrossOrigin is the value of the crossorigin attribute, eg "anonymous",
+	// or empty when not specified.
+	CrossOrigin string
+
+	// Media is the value of the media attribute, if available.
+	Media string
+
+	// Header is true for the hints sent in the Link headers of the page.
+	Header bool
+}
+
+// NewResourceHintAsset creates and returns a new *ResourceHint type.
+func NewResourceHintAsset(u *url.URL, id, rel, as string) *ResourceHint {
+	return &ResourceHint{
+		DownloadableAsset: DownloadableAsset{
+			Asset: Asset{
+				URL:  u,
+				Type: ResourceHintAsset,
+				ID:   id,
+			},
+		},
+		Rel: rel,
+		As:  as,
+	}
+}
+
+// Origin returns the origin of the hint URL, eg "https://cdn.example.com",
+// which is what the dns-prefetch and preconnect hints are for.
+func (h *ResourceHint) Origin() string {
+	return (&url.URL{Scheme: h.URL.Scheme, Host: h.URL.Host}).String()
+}
+
+// ResourceHints returns the resource hints of the page, from its Link
+// headers and <link> elements, with the rel values in ResourceHintRels. A
+// link with several of them, eg rel="preconnect dns-prefetch", returns a
+// hint for each. The hints of the headers are first, as browsers receive
+// them first.
+func (bow *Browser) ResourceHints() []*ResourceHint {
+	hints := make([]*ResourceHint, 0, InitialAssetsSliceSize)
+	for _, l := range bow.HeaderLinks() {
+		if !isResourceHint(l.Rel) {
+			continue
+		}
+		h := NewResourceHintAsset(l.URL, "", l.Rel, strings.ToLower(l.Params["as"]))
+		h.MimeType = l.Params["type"]
+		co, ok := l.Params["crossorigin"]
+		h.CrossOrigin = crossOrigin(co, ok)
+		h.Media = l.Params["media"]
+		h.Header = true
+		hints = append(hints, h)
+	}
+
+	bow.Find("link[rel][href]").Each(func(_ int, s *goquery.Selection) {
+		href, err := bow.attrToResolvedURL("href", s)
+		if err != nil {
+			return
+		}
+		for _, rel := range strings.Fields(strings.ToLower(s.AttrOr("rel", ""))) {
+			if !isResourceHint(rel) {
+				continue
+			}
+			h := NewResourceHintAsset(
+				href,
+				bow.attrOrDefault("id", "", s),
+				rel,
+				strings.ToLower(strings.TrimSpace(bow.attrOrDefault("as", "", s))),
+			)
+			h.MimeType = strings.TrimSpace(bow.attrOrDefault("type", "", s))
+			co, ok := s.Attr("crossorigin")
+			h.CrossOrigin = crossOrigin(co, ok)
+			h.Media = bow.attrOrDefault("media", "", s)
+			hints = append(hints, h)
+		}
+	})
+
+	return hints
+}
+
+// isResourceHint returns whether the rel value is a resource hint.
+func isResourceHint(rel string) bool {
+	for _, r := range ResourceHintRels {
+		if rel == r {
+			return true
+		}
+	}
+	return false
+}
+
+// crossOrigin returns the CORS mode of a crossorigin attribute: a missing
+// attribute is empty, and an empty or invalid one is "anonymous".
+func crossOrigin(value string, ok bool) string {
+	if !ok {
+		return ""
+	}
+	if v := strings.ToLower(strings.TrimSpace(value)); v == "use-credentials" {
+		return v
+	}
+	return "anonymous"
+}
@@ -0,0 +1,56 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestResourceHints(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Link", `</app.css>; rel=preload; as=style, <https://cdn.example.com>; rel=preconnect; crossorigin`)
+		w.Header().Add("Link", `</page/2>; rel=next`)
+		w.Write([]byte(`<html><head>
+			<link rel="preload" href="/fonts/a.woff2" as="Font" type="font/woff2" crossorigin>
+			<link rel="modulepreload" href="/app.js">
+			<link rel="PREFETCH" href="next.html" id="next">
+			<link rel="preconnect dns-prefetch" href="https://img.example.com/x" crossorigin="use-credentials">
+			<link rel="stylesheet" href="/app.css">
+			<link rel="preload" as="image">
+		</head><body></body></html>`))
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	hints := bow.ResourceHints()
+	expected := []struct {
+		url, rel, as, crossOrigin string
+		header                    bool
+	}{
+		{ts.URL + "/app.css", "preload", "style", "", true},
+		{"https://cdn.example.com", "preconnect", "", "anonymous", true},
+		{ts.URL + "/fonts/a.woff2", "preload", "font", "anonymous", false},
+		{ts.URL + "/app.js", "modulepreload", "", "", false},
+		{ts.URL + "/next.html", "prefetch", "", "", false},
+		{"https://img.example.com/x", "preconnect", "", "use-credentials", false},
+		{"https://img.example.com/x", "dns-prefetch", "", "use-credentials", false},
+	}
+	if len(hints) != len(expected) {
+		t.Fatalf("Expected %d hints, got %d.", len(expected), len(hints))
+	}
+	for i, h := range hints {
+		e := expected[i]
+		if h.URL.String() != e.url || h.Rel != e.rel || h.As != e.as || h.CrossOrigin != e.crossOrigin || h.Header != e.header {
+			t.Errorf("Expected the hint %+v, got %s %s %q %q %v.", e, h.URL, h.Rel, h.As, h.CrossOrigin, h.Header)
+		}
+	}
+	if hints[2].MimeType != "font/woff2" || hints[4].ID != "next" || hints[4].Type != ResourceHintAsset {
+		t.Errorf("Unexpected attributes of the hints %+v, %+v.", hints[2], hints[4])
+	}
+	if o := hints[5].Origin(); o != "https://img.example.com" {
+		t.Errorf("Expected the origin https://img.example.com, got %s.", o)
+	}
+}
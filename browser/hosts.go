@@ -0,0 +1,65 @@
+package browser
+
+import (
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+// AllowHosts restricts the browser to the hosts matching one of the given
+// patterns. Patterns use path.Match syntax, eg "*.example.com", which does
+// not match "example.com" itself. Calling it again adds more patterns.
+//
+// Every request, including redirects, meta refreshes and clicked links, to
+// any other host fails with a HostNotAllowed error.
+func (bow *Browser) AllowHosts(patterns ...string) {
+	bow.allowHosts = append(bow.allowHosts, lowerAll(patterns)...)
+}
+
+// DenyHosts prevents the browser from requesting the hosts matching one of
+// the given patterns, even when they are also allowed by AllowHosts(). See
+// AllowHosts() for the pattern syntax.
+func (bow *Browser) DenyHosts(patterns ...string) {
+	bow.denyHosts = append(bow.denyHosts, lowerAll(patterns)...)
+}
+
+// ClearHostRules removes the patterns added with AllowHosts() and DenyHosts().
+func (bow *Browser) ClearHostRules() {
+	bow.allowHosts = nil
+	bow.denyHosts = nil
+}
+
+// checkHost returns a HostNotAllowed error when the host of the given URL
+// is denied, or not allowed.
+func (bow *Browser) checkHost(u *url.URL) error {
+	host := strings.ToLower(u.Hostname())
+	if matchHost(bow.denyHosts, host) {
+		return errors.NewHostNotAllowed("Request to '%s' denied, the host is denied.", u.String())
+	}
+	if len(bow.allowHosts) > 0 && !matchHost(bow.allowHosts, host) {
+		return errors.NewHostNotAllowed("Request to '%s' denied, the host is not allowed.", u.String())
+	}
+	return nil
+}
+
+// matchHost returns a boolean value indicating whether the host matches one
+// of the patterns.
+func matchHost(patterns []string, host string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, host); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// lowerAll returns a copy of the strings in lower case.
+func lowerAll(s []string) []string {
+	l := make([]string, len(s))
+	for i, v := range s {
+		l[i] = strings.ToLower(v)
+	}
+	return l
+}
@@ -0,0 +1,48 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+func TestHostRules(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, strings.Replace("http://"+r.Host, "127.0.0.1", "localhost", 1)+"/", http.StatusFound)
+		default:
+			w.Write([]byte(`<html><body><a href="` + strings.Replace("http://"+r.Host, "127.0.0.1", "localhost", 1) + `/">away</a></body></html>`))
+		}
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	bow.AllowHosts("127.0.0.*")
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := bow.Click("a").(errors.HostNotAllowed); !ok {
+		t.Error("Expected clicking a link to another host to fail")
+	}
+	if _, ok := bow.GET(ts.URL + "/redirect").(errors.HostNotAllowed); !ok {
+		t.Error("Expected a redirect to another host to fail")
+	}
+
+	bow.ClearHostRules()
+	bow.DenyHosts("LOCALHOST")
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := bow.GET(strings.Replace(ts.URL, "127.0.0.1", "localhost", 1)).(errors.HostNotAllowed); !ok {
+		t.Error("Expected a request to a denied host to fail")
+	}
+
+	bow.ClearHostRules()
+	if err := bow.GET(ts.URL + "/redirect"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,111 @@
+package browser
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	_ "image/gif"  // GIF images are decoded by Image.Decode().
+	_ "image/jpeg" // JPEG images are decoded by Image.Decode().
+	_ "image/png"  // PNG images are decoded by Image.Decode().
+	"net/http"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+// MaxImagePixels is the largest number of pixels of the images decoded by
+// Image.Decode(), so small files declaring huge images don't exhaust the
+// memory.
+var MaxImagePixels = 40 * 1000 * 1000
+
+// Decode downloads the image and decodes it. The images returned by
+// Browser.Images() are downloaded with the session of the browser, ie its
+// cookies, headers and proxy, without changing its page.
+//
+// The GIF, JPEG and PNG formats are supported. Other formats are supported
+// by registering their decoder with image.RegisterFormat(), eg by importing
+// golang.org/x/image/webp. Returns an errors.DecompressionLimit error for
+// the images larger than MaxImagePixels.
+func (img *Image) Decode() (image.Image, error) {
+	var data []byte
+	var err error
+	if img.bow != nil {
+		data, _, err = img.bow.fetchAsset(img.URL.String())
+	} else {
+		var buf bytes.Buffer
+		var status int
+		_, status, err = downloadAsset(context.Background(), img.DownloadableAsset, &buf)
+		if err == nil && status != http.StatusOK {
+			err = errors.NewPageNotFound("Cannot download '%s', the server returned %d.", img.URL, status)
+		}
+		data = buf.Bytes()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > int64(MaxImagePixels) {
+		return nil, errors.NewDecompressionLimit(
+			"The image '%s' is %dx%d pixels, more than MaxImagePixels.", img.URL, cfg.Width, cfg.Height)
+	}
+	m, _, err := image.Decode(bytes.NewReader(data))
+	return m, err
+}
+
+// Thumbnail downloads and decodes the image, see Decode(), and returns it
+// scaled down to fit in w by h pixels, keeping its aspect ratio. A zero w or
+// h is not limited. Images which already fit are returned unchanged.
+func (img *Image) Thumbnail(w, h int) (image.Image, error) {
+	m, err := img.Decode()
+	if err != nil {
+		return nil, err
+	}
+	return thumbnail(m, w, h), nil
+}
+
+// thumbnail returns the image scaled down to fit in w by h pixels. Each
+// pixel of the thumbnail is the average of the pixels it covers.
+func thumbnail(m image.Image, w, h int) image.Image {
+	b := m.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	if sw == 0 || sh == 0 || (w <= 0 || sw <= w) && (h <= 0 || sh <= h) {
+		return m
+	}
+	tw, th := sw, sh
+	if w > 0 && tw > w {
+		tw, th = w, sh*w/sw
+	}
+	if h > 0 && th > h {
+		tw, th = sw*h/sh, h
+	}
+	if tw < 1 {
+		tw = 1
+	}
+	if th < 1 {
+		th = 1
+	}
+
+	out := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		y0, y1 := b.Min.Y+y*sh/th, b.Min.Y+(y+1)*sh/th
+		for x := 0; x < tw; x++ {
+			x0, x1 := b.Min.X+x*sw/tw, b.Min.X+(x+1)*sw/tw
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := m.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			out.Set(x, y, color.RGBA64{
+				R: uint16(r / n), G: uint16(g / n), B: uint16(bl / n), A: uint16(a / n),
+			})
+		}
+	}
+	return out
+}
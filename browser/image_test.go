@@ -0,0 +1,108 @@
+package browser
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+func TestImageDecode(t *testing.T) {
+	photo := image.NewRGBA(image.Rect(0, 0, 40, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 40; x++ {
+			photo.Set(x, y, color.RGBA{R: 200, A: 255})
+		}
+	}
+	var data bytes.Buffer
+	if err := png.Encode(&data, photo); err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "1"})
+			w.Write([]byte(`<html><body><img src="/photo.png"><img src="/missing.png"></body></html>`))
+		case "/photo.png":
+			if c, err := r.Cookie("session"); err != nil || c.Value != "1" {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+			w.Header().Set("Content-Type", "image/png")
+			w.Write(data.Bytes())
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	images := bow.Images()
+	m, err := images[0].Decode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := m.Bounds(); b.Dx() != 40 || b.Dy() != 20 {
+		t.Errorf("Expected a 40x20 image, got %v.", b)
+	}
+	if bow.URL().String() != ts.URL {
+		t.Errorf("Expected the page of the browser not to change, got %s.", bow.URL())
+	}
+
+	thumb, err := images[0].Thumbnail(10, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := thumb.Bounds(); b.Dx() != 10 || b.Dy() != 5 {
+		t.Errorf("Expected a 10x5 thumbnail, got %v.", b)
+	}
+	if r, g, _, a := thumb.At(3, 3).RGBA(); r>>8 != 200 || g != 0 || a>>8 != 255 {
+		t.Errorf("Expected the color of the image, got %v.", thumb.At(3, 3))
+	}
+
+	if _, err := images[1].Decode(); err == nil {
+		t.Error("Expected an error for a missing image.")
+	}
+	if _, err := NewImageAsset(images[0].URL, "", "", "").Decode(); err == nil {
+		t.Error("Expected the images created without a browser to be downloaded without its session.")
+	}
+
+	max := MaxImagePixels
+	MaxImagePixels = 100
+	defer func() { MaxImagePixels = max }()
+	if _, err := images[0].Decode(); err == nil {
+		t.Error("Expected an error for an image larger than MaxImagePixels.")
+	} else if _, ok := err.(errors.DecompressionLimit); !ok {
+		t.Errorf("Expected a DecompressionLimit error, got %v.", err)
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	small := image.NewRGBA(image.Rect(0, 0, 8, 6))
+	if thumbnail(small, 10, 10) != image.Image(small) {
+		t.Error("Expected the images which fit not to be scaled.")
+	}
+	tests := []struct {
+		w, h   int
+		tw, th int
+	}{
+		{4, 0, 4, 3},
+		{0, 3, 4, 3},
+		{2, 6, 2, 1},
+		{1, 1, 1, 1},
+	}
+	for _, tt := range tests {
+		b := thumbnail(small, tt.w, tt.h).Bounds()
+		if b.Dx() != tt.tw || b.Dy() != tt.th {
+			t.Errorf("Expected a %dx%d thumbnail in %dx%d, got %v.", tt.tw, tt.th, tt.w, tt.h, b)
+		}
+	}
+}
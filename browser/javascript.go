@@ -4,6 +4,7 @@ import (
 	"bytes"
 
 	"github.com/PuerkitoBio/goquery"
+	"github.com/lostinblue/surf/jar"
 	"github.com/robertkrimen/otto"
 	//"github.com/robertkrimen/otto/parser" - Checkout the parser module, may be useful for intergration into surf
 )
@@ -76,3 +77,80 @@ func (p *Parser) get(el, key string) EachFunc {
 		}
 	}
 }
+
+// RunJavaScript runs the given script in the browser JavaScript VM.
+//
+// The localStorage and sessionStorage objects are available to the script,
+// and read and write the items of the current page origin in the local and
+// session storage jars.
+func (bow *Browser) RunJavaScript(src string) (otto.Value, error) {
+	if bow.javaScriptVM == nil {
+		bow.NewJavaScriptVM()
+	}
+	origin := ""
+	if u := bow.URL(); u != nil {
+		origin = u.Scheme + "://" + u.Host
+	}
+	if bow.localStorage != nil {
+		if err := bindStorage(bow.javaScriptVM, "localStorage", bow.localStorage, origin); err != nil {
+			return otto.UndefinedValue(), err
+		}
+	}
+	if bow.sessionStorage != nil {
+		if err := bindStorage(bow.javaScriptVM, "sessionStorage", bow.sessionStorage, origin); err != nil {
+			return otto.UndefinedValue(), err
+		}
+	}
+	return bow.javaScriptVM.Run(src)
+}
+
+// bindStorage sets a global object in the VM implementing the Web Storage
+// API on top of the given storage jar.
+func bindStorage(vm *otto.Otto, name string, s jar.Storage, origin string) error {
+	obj, err := vm.Object("({})")
+	if err != nil {
+		return err
+	}
+
+	obj.Set("getItem", func(c otto.FunctionCall) otto.Value {
+		v, ok := s.GetItem(origin, c.Argument(0).String())
+		if !ok {
+			return otto.NullValue()
+		}
+		val, _ := c.Otto.ToValue(v)
+		return val
+	})
+	obj.Set("setItem", func(c otto.FunctionCall) otto.Value {
+		s.SetItem(origin, c.Argument(0).String(), c.Argument(1).String())
+		return otto.UndefinedValue()
+	})
+	obj.Set("removeItem", func(c otto.FunctionCall) otto.Value {
+		s.RemoveItem(origin, c.Argument(0).String())
+		return otto.UndefinedValue()
+	})
+	obj.Set("clear", func(c otto.FunctionCall) otto.Value {
+		s.Clear(origin)
+		return otto.UndefinedValue()
+	})
+	obj.Set("key", func(c otto.FunctionCall) otto.Value {
+		i, err := c.Argument(0).ToInteger()
+		keys := s.Keys(origin)
+		if err != nil || i < 0 || i >= int64(len(keys)) {
+			return otto.NullValue()
+		}
+		val, _ := c.Otto.ToValue(keys[i])
+		return val
+	})
+	obj.Set("_length", func(c otto.FunctionCall) otto.Value {
+		val, _ := c.Otto.ToValue(len(s.Keys(origin)))
+		return val
+	})
+
+	if err = vm.Set(name, obj); err != nil {
+		return err
+	}
+	_, err = vm.Run(`Object.defineProperty(` + name + `, "length", {
+		get: function() { return this._length(); }
+	});`)
+	return err
+}
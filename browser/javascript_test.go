@@ -0,0 +1,52 @@
+package browser
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lostinblue/surf/jar"
+)
+
+func TestRunJavaScriptStorage(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "<html></html>")
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	local := jar.NewMemoryStorage()
+	bow.SetLocalStorageJar(local)
+	bow.SetSessionStorageJar(jar.NewMemoryStorage())
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := bow.RunJavaScript(`
+		localStorage.setItem("token", "abc");
+		localStorage.setItem("user", "joe");
+		localStorage.removeItem("user");
+		sessionStorage.setItem("step", 2);
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := local.GetItem(ts.URL, "token"); v != "abc" {
+		t.Errorf("Expected the token to be saved for origin %s, got '%s'", ts.URL, v)
+	}
+
+	v, err := bow.RunJavaScript(`localStorage.getItem("token") + localStorage.length + localStorage.key(0) + sessionStorage.getItem("step") + localStorage.getItem("user")`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.String() != "abc1token2null" {
+		t.Errorf("Unexpected script result '%s'", v.String())
+	}
+
+	tab := bow.NewTab()
+	v, _ = tab.RunJavaScript(`localStorage.getItem("token") + sessionStorage.length`)
+	if v.String() != "abc0" {
+		t.Errorf("Expected tabs to share localStorage but not sessionStorage, got '%s'", v.String())
+	}
+}
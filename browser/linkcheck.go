@@ -0,0 +1,185 @@
+package browser
+
+import (
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultCheckConcurrency is the number of links checked at the same time
+// when CheckLinksOptions.Concurrency is 0.
+var DefaultCheckConcurrency = 8
+
+// CheckLinksOptions configures Browser.CheckLinks().
+type CheckLinksOptions struct {
+	// Concurrency is the number of links checked at the same time.
+	// Defaults to DefaultCheckConcurrency.
+	Concurrency int
+
+	// Assets also checks the images, stylesheets and scripts of the page.
+	Assets bool
+
+	// SameHost skips the links to other hosts than the host of the page.
+	SameHost bool
+
+	// Timeout is the time limit for checking each link, or 0 for no limit.
+	Timeout time.Duration
+}
+
+// LinkStatus is the result of checking a link.
+type LinkStatus struct {
+	// URL is the checked URL, without its fragment.
+	URL *url.URL
+
+	// StatusCode is the status code of the response, or 0 when the request failed.
+	StatusCode int
+
+	// Location is the URL the link redirected to, or nil when the link did
+	// not redirect.
+	Location *url.URL
+
+	// Latency is the time taken to check the link, including redirects.
+	Latency time.Duration
+
+	// Err is the error returned by the request, if any.
+	Err error
+}
+
+// Broken returns a boolean value indicating whether the request failed or
+// the response status is an error.
+func (s *LinkStatus) Broken() bool {
+	return s.Err != nil || s.StatusCode >= 400
+}
+
+// LinkReport holds the results of Browser.CheckLinks().
+type LinkReport struct {
+	// Links contains the status of every checked link, sorted by URL.
+	Links []*LinkStatus
+}
+
+// Broken returns the links which are broken.
+func (r *LinkReport) Broken() []*LinkStatus {
+	var broken []*LinkStatus
+	for _, s := range r.Links {
+		if s.Broken() {
+			broken = append(broken, s)
+		}
+	}
+	return broken
+}
+
+// CheckLinks checks every link of the current page concurrently, and returns
+// a report of their status. Each link is requested with the HEAD method, and
+// with the GET method when the server does not support HEAD. Links which are
+// not http or https, eg "mailto:", are skipped.
+//
+// The links are requested with the cookies and headers of the browser, and
+// count against its budget, but the page and history of the browser are not
+// changed.
+func (bow *Browser) CheckLinks(opts CheckLinksOptions) *LinkReport {
+	var urls []*url.URL
+	for _, l := range bow.Links() {
+		urls = append(urls, l.URL)
+	}
+	if opts.Assets {
+		for _, a := range bow.Images() {
+			urls = append(urls, a.URL)
+		}
+		for _, a := range bow.Stylesheets() {
+			urls = append(urls, a.URL)
+		}
+		for _, a := range bow.Scripts() {
+			urls = append(urls, a.URL)
+		}
+	}
+
+	page := bow.URL()
+	seen := make(map[string]bool)
+	jobs := make(chan *LinkStatus)
+	report := &LinkReport{}
+	for _, u := range urls {
+		c := *u
+		c.Fragment = ""
+		if (c.Scheme != "http" && c.Scheme != "https") || seen[c.String()] {
+			continue
+		}
+		if opts.SameHost && page != nil && c.Host != page.Host {
+			continue
+		}
+		seen[c.String()] = true
+		report.Links = append(report.Links, &LinkStatus{URL: &c})
+	}
+	sort.Slice(report.Links, func(i, j int) bool {
+		return report.Links[i].URL.String() < report.Links[j].URL.String()
+	})
+
+	// The workers share the client, which is created before they start.
+	if bow.client == nil {
+		bow.client = bow.buildClient()
+	}
+	if bow.connStats == nil {
+		bow.connStats = newConnStats()
+	}
+	workers := opts.Concurrency
+	if workers < 1 {
+		workers = DefaultCheckConcurrency
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for s := range jobs {
+				bow.checkLink(s, page, opts.Timeout)
+			}
+		}()
+	}
+	for _, s := range report.Links {
+		jobs <- s
+	}
+	close(jobs)
+	wg.Wait()
+
+	return report
+}
+
+// checkLink requests the URL of the given status and records the result.
+func (bow *Browser) checkLink(s *LinkStatus, ref *url.URL, timeout time.Duration) {
+	start := time.Now()
+	resp, err := bow.checkRequest("HEAD", s.URL, ref, timeout)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		resp, err = bow.checkRequest("GET", s.URL, ref, timeout)
+	}
+	s.Latency = time.Since(start)
+	if err != nil {
+		s.Err = err
+		return
+	}
+	s.StatusCode = resp.StatusCode
+	if final := resp.Request.URL; final.String() != s.URL.String() {
+		s.Location = final
+	}
+}
+
+// checkRequest sends a request for the given URL with do, so the rewrite
+// rules, host rules, budget, profiles and signer of the browser apply, and
+// closes the body.
+func (bow *Browser) checkRequest(method string, u, ref *url.URL, timeout time.Duration) (*http.Response, error) {
+	var opts []RequestOption
+	if timeout > 0 {
+		opts = append(opts, WithTimeout(timeout))
+	}
+	req, err := bow.buildRequest(method, u.String(), ref, nil, opts...)
+	if err != nil {
+		return nil, err
+	}
+	resp, cancel, err := bow.do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+	resp.Body.Close()
+	return resp, nil
+}
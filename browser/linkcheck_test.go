@@ -0,0 +1,83 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+func TestCheckLinks(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Write([]byte(`<html><head><link rel="stylesheet" href="/missing.css"></head><body>
+				<a href="/ok">ok</a>
+				<a href="/ok#section">ok again</a>
+				<a href="/missing">missing</a>
+				<a href="/moved">moved</a>
+				<a href="/nohead">no head</a>
+				<a href="mailto:joe@example.com">mail</a>
+				<a href="http://localhost:1/">down</a>
+				<img src="/ok">
+			</body></html>`))
+		case "/ok":
+		case "/moved":
+			http.Redirect(w, r, "/ok", http.StatusMovedPermanently)
+		case "/nohead":
+			if r.Method == "HEAD" {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	report := bow.CheckLinks(CheckLinksOptions{Timeout: 5 * time.Second})
+	if len(report.Links) != 5 {
+		t.Fatalf("Expected 5 links, got %d", len(report.Links))
+	}
+	status := make(map[string]*LinkStatus)
+	for _, s := range report.Links {
+		status[s.URL.String()] = s
+	}
+	if s := status[ts.URL+"/ok"]; s == nil || s.StatusCode != 200 || s.Broken() {
+		t.Errorf("Expected /ok to be fine, got %+v", s)
+	}
+	if s := status[ts.URL+"/moved"]; s == nil || s.Location == nil || s.Location.Path != "/ok" {
+		t.Errorf("Expected /moved to redirect to /ok, got %+v", s)
+	}
+	if s := status[ts.URL+"/nohead"]; s == nil || s.StatusCode != 200 {
+		t.Errorf("Expected /nohead to be checked with GET, got %+v", s)
+	}
+	broken := report.Broken()
+	if len(broken) != 2 || broken[0].URL.Path != "/missing" || broken[1].Err == nil {
+		t.Errorf("Expected /missing and the down host to be broken, got %+v", broken)
+	}
+
+	report = bow.CheckLinks(CheckLinksOptions{Assets: true, SameHost: true})
+	if len(report.Links) != 5 || len(report.Broken()) != 2 {
+		t.Errorf("Expected the stylesheet to be checked and the other host skipped, got %+v", report.Links)
+	}
+
+	// The checks count against the budget of the browser.
+	bow.SetBudget(Budget{MaxRequests: 1})
+	report = bow.CheckLinks(CheckLinksOptions{SameHost: true})
+	spent := 0
+	for _, l := range report.Links {
+		if _, ok := l.Err.(errors.BudgetExceeded); ok {
+			spent++
+		}
+	}
+	if spent < len(report.Links)-1 {
+		t.Errorf("Expected the checks after the first to exceed the budget, got %+v", report.Links)
+	}
+}
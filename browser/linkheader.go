@@ -0,0 +1,187 @@
+package browser
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+// DefaultCursorKeys are the JSON keys searched by FollowCursor when no keys are given.
+var DefaultCursorKeys = []string{
+	"next_cursor",
+	"nextCursor",
+	"next_page_token",
+	"nextPageToken",
+	"cursor",
+	"meta.next_cursor",
+	"pagination.next_cursor",
+}
+
+// HeaderLink is a link read from a Link response header.
+type HeaderLink struct {
+	// URL is the absolute URL of the link.
+	URL *url.URL
+
+	// Rel is the value of the rel parameter, eg "next".
+	Rel string
+
+	// Params contains every parameter of the link, including rel.
+	Params map[string]string
+}
+
+// ParseLinkHeader parses the values of Link headers, as defined by RFC 8288.
+// Relative URLs are resolved against base, which may be nil.
+func ParseLinkHeader(values []string, base *url.URL) []*HeaderLink {
+	var links []*HeaderLink
+	for _, value := range values {
+		for _, part := range splitLinkHeader(value) {
+			part = strings.TrimSpace(part)
+			if !strings.HasPrefix(part, "<") {
+				continue
+			}
+			end := strings.Index(part, ">")
+			if end < 0 {
+				continue
+			}
+			u, err := url.Parse(part[1:end])
+			if err != nil {
+				continue
+			}
+			if base != nil {
+				u = base.ResolveReference(u)
+			}
+
+			params := make(map[string]string)
+			for _, p := range strings.Split(part[end+1:], ";") {
+				kv := strings.SplitN(strings.TrimSpace(p), "=", 2)
+				if kv[0] == "" {
+					continue
+				}
+				v := ""
+				if len(kv) == 2 {
+					v = strings.Trim(strings.TrimSpace(kv[1]), `"`)
+				}
+				params[strings.ToLower(kv[0])] = v
+			}
+			for _, rel := range strings.Fields(params["rel"]) {
+				links = append(links, &HeaderLink{URL: u, Rel: strings.ToLower(rel), Params: params})
+			}
+		}
+	}
+	return links
+}
+
+// splitLinkHeader splits a Link header value on the commas between links,
+// ignoring commas inside the URLs and quoted parameters.
+func splitLinkHeader(value string) []string {
+	var parts []string
+	inURL, inQuote, start := false, false, 0
+	for i, c := range value {
+		switch {
+		case c == '<' && !inQuote:
+			inURL = true
+		case c == '>' && !inQuote:
+			inURL = false
+		case c == '"' && !inURL:
+			inQuote = !inQuote
+		case c == ',' && !inURL && !inQuote:
+			parts = append(parts, value[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, value[start:])
+}
+
+// HeaderLinks returns the links sent in the Link headers of the current page.
+func (bow *Browser) HeaderLinks() []*HeaderLink {
+	if bow.state.Response == nil {
+		return nil
+	}
+	return ParseLinkHeader(bow.state.Response.Header["Link"], bow.URL())
+}
+
+// HeaderLink returns the first link with the given rel value sent in the
+// Link headers of the current page, or nil when there is none.
+func (bow *Browser) HeaderLink(rel string) *HeaderLink {
+	rel = strings.ToLower(rel)
+	for _, link := range bow.HeaderLinks() {
+		if link.Rel == rel {
+			return link
+		}
+	}
+	return nil
+}
+
+// FollowLinkHeader requests the URL of the Link header with the given rel
+// value, eg "next", which is how many APIs paginate their listings.
+//
+// Returns a LinkNotFound error when the current page has no such link.
+func (bow *Browser) FollowLinkHeader(rel string) error {
+	link := bow.HeaderLink(rel)
+	if link == nil {
+		return errors.NewLinkNotFound("No Link header with rel '%s' found.", rel)
+	}
+	return bow.httpGET(link.URL, bow.URL())
+}
+
+// Cursor returns the pagination cursor found in the JSON body of the current
+// page, by trying each of the given keys in order. Nested keys are separated
+// by dots, eg "meta.next_cursor". DefaultCursorKeys are used when no keys are given.
+//
+// Returns an empty string when no cursor is found, which usually means the
+// last page has been reached.
+func (bow *Browser) Cursor(keys ...string) string {
+	if len(keys) == 0 {
+		keys = DefaultCursorKeys
+	}
+	// Numbers are decoded as json.Number, so large numeric cursors keep
+	// their precision.
+	var doc interface{}
+	dec := json.NewDecoder(bytes.NewReader(bow.body))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return ""
+	}
+	for _, key := range keys {
+		v := doc
+		for _, k := range strings.Split(key, ".") {
+			m, ok := v.(map[string]interface{})
+			if !ok {
+				v = nil
+				break
+			}
+			v = m[k]
+		}
+		switch c := v.(type) {
+		case string:
+			if c != "" {
+				return c
+			}
+		case json.Number:
+			return c.String()
+		}
+	}
+	return ""
+}
+
+// FollowCursor replays the current request with the query parameter param
+// set to the cursor found in the JSON body of the current page. See Cursor()
+// for the meaning of keys. The other query string values are sent as they
+// were, in the same order.
+//
+// Returns a LinkNotFound error when the body has no cursor.
+func (bow *Browser) FollowCursor(param string, keys ...string) error {
+	if bow.URL() == nil {
+		return errors.NewPageNotLoaded("Cannot follow cursor, no page has been loaded.")
+	}
+	cursor := bow.Cursor(keys...)
+	if cursor == "" {
+		return errors.NewLinkNotFound("No cursor found in the page body.")
+	}
+	u := *bow.URL()
+	setQuery(&u, param, cursor)
+	return bow.httpGET(&u, bow.URL())
+}
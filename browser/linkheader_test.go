@@ -0,0 +1,104 @@
+package browser
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+func TestParseLinkHeader(t *testing.T) {
+	base, _ := url.Parse("http://example.com/api/items")
+	links := ParseLinkHeader([]string{
+		`<https://example.com/api/items?page=2>; rel="next", </api/items?page=9>; rel="last"; title="a, b"`,
+		`</api/items?page=1>; rel="first prev"`,
+	}, base)
+
+	if len(links) != 4 {
+		t.Fatalf("Expected 4 links, got %d", len(links))
+	}
+	expected := []struct{ rel, u string }{
+		{"next", "https://example.com/api/items?page=2"},
+		{"last", "http://example.com/api/items?page=9"},
+		{"first", "http://example.com/api/items?page=1"},
+		{"prev", "http://example.com/api/items?page=1"},
+	}
+	for i, e := range expected {
+		if links[i].Rel != e.rel || links[i].URL.String() != e.u {
+			t.Errorf("Expected link %s %s, got %s %s", e.rel, e.u, links[i].Rel, links[i].URL)
+		}
+	}
+	if links[1].Params["title"] != "a, b" {
+		t.Errorf("Expected quoted title parameter, got '%s'", links[1].Params["title"])
+	}
+}
+
+func TestFollowLinkHeaderAndCursor(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/linked":
+			page := r.URL.Query().Get("page")
+			if page == "" {
+				w.Header().Set("Link", `</linked?page=2>; rel="next"`)
+				page = "1"
+			}
+			fmt.Fprintf(w, `{"page": %s}`, page)
+		case "/cursor":
+			switch r.URL.Query().Get("after") {
+			case "":
+				fmt.Fprint(w, `{"items": [1, 2], "meta": {"next_cursor": "abc"}}`)
+			case "abc":
+				fmt.Fprint(w, `{"items": [3], "meta": {"next_cursor": "d&f"}}`)
+			default:
+				fmt.Fprint(w, `{"items": [3], "meta": {"next_cursor": null}}`)
+			}
+		case "/numeric":
+			fmt.Fprint(w, `{"next": 9007199254740993}`)
+		}
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	if err := bow.GET(ts.URL + "/linked"); err != nil {
+		t.Fatal(err)
+	}
+	if err := bow.FollowLinkHeader("next"); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(bow.body); got != `{"page": 2}` {
+		t.Errorf("Expected the second page, got '%s'", got)
+	}
+	if _, ok := bow.FollowLinkHeader("next").(errors.LinkNotFound); !ok {
+		t.Error("Expected LinkNotFound on the last page")
+	}
+
+	if err := bow.GET(ts.URL + "/cursor?limit=2&sig=a%2Cb"); err != nil {
+		t.Fatal(err)
+	}
+	if err := bow.FollowCursor("after"); err != nil {
+		t.Fatal(err)
+	}
+	if got := bow.URL().RawQuery; got != "limit=2&sig=a%2Cb&after=abc" {
+		t.Errorf("Expected the cursor after the original query, got '%s'", got)
+	}
+	if err := bow.FollowCursor("after"); err != nil {
+		t.Fatal(err)
+	}
+	if got := bow.URL().RawQuery; got != "limit=2&sig=a%2Cb&after=d%26f" {
+		t.Errorf("Expected the cursor to be replaced in place, got '%s'", got)
+	}
+	if _, ok := bow.FollowCursor("after").(errors.LinkNotFound); !ok {
+		t.Error("Expected LinkNotFound when there is no cursor")
+	}
+
+	if err := bow.GET(ts.URL + "/numeric"); err != nil {
+		t.Fatal(err)
+	}
+	if got := bow.Cursor("next"); got != "9007199254740993" {
+		t.Errorf("Expected the numeric cursor to keep its precision, got '%s'", got)
+	}
+}
@@ -0,0 +1,219 @@
+package browser
+
+import (
+	"github.com/lostinblue/surf/credentials"
+	"github.com/lostinblue/surf/errors"
+)
+
+// The steps reported by the LoginFailed errors returned by Browser.Login().
+const (
+	// LoginFetch is the request for the login page.
+	LoginFetch = "fetch"
+
+	// LoginForm is finding the login form in the page.
+	LoginForm = "form"
+
+	// LoginFill is setting the credentials in the form fields.
+	LoginFill = "fill"
+
+	// LoginSubmit is the submission of the form.
+	LoginSubmit = "submit"
+
+	// LoginSolve is solving a challenge page, eg a two-factor code form.
+	LoginSolve = "challenge"
+
+	// LoginVerify is checking the page returned by the submission.
+	LoginVerify = "verify"
+)
+
+// MaxLoginChallenges is the number of challenge pages solved by a single
+// call to Browser.Login(), so a solver returning wrong codes doesn't loop.
+var MaxLoginChallenges = 3
+
+// LoginChallenge is an intermediate page shown during a login, eg a form
+// asking for a two-factor code.
+type LoginChallenge struct {
+	// Browser holds the challenge page.
+	Browser *Browser
+
+	// Form is the challenge form, which is submitted after setting the code.
+	Form Submittable
+
+	// Field is the name of the field the code is set in.
+	Field string
+
+	// Attempt is the number of the challenge, starting at 1.
+	Attempt int
+}
+
+// ChallengeSolver solves the challenges shown during logins.
+type ChallengeSolver interface {
+	// Solve returns the code answering the challenge.
+	Solve(c *LoginChallenge) (string, error)
+}
+
+// ChallengeFunc is a function used as a ChallengeSolver, eg to prompt the
+// user for the code sent by SMS.
+type ChallengeFunc func(c *LoginChallenge) (string, error)
+
+// Solve calls the function.
+func (fn ChallengeFunc) Solve(c *LoginChallenge) (string, error) {
+	return fn(c)
+}
+
+// LoginSpec describes a login form for Browser.Login().
+type LoginSpec struct {
+	// URL is the address of the login page. The current page is used when
+	// it's empty.
+	URL string
+
+	// FormSelector matches the login form. Defaults to the form containing
+	// the password field.
+	FormSelector string
+
+	// UserField and PassField are the names of the user name and password fields.
+	UserField string
+	PassField string
+
+	// Username and Password are the credentials set in the fields. When
+	// both are empty, the credentials of the login page host are looked up
+	// in Credentials, or in the credential store of the browser.
+	Username string
+	Password string
+
+	// Credentials looks up the credentials when none are given, eg a
+	// credentials.Keyring.
+	Credentials credentials.Store
+
+	// SubmitSelector matches the button clicked in the form. The form is
+	// submitted with its first button when it's empty.
+	SubmitSelector string
+
+	// ChallengeSelector matches the form of an intermediate page shown
+	// after submitting the credentials, eg a two-factor code form. The
+	// challenge is solved by the Solver, and the code is set in the
+	// ChallengeField before submitting the form.
+	ChallengeSelector string
+	ChallengeField    string
+	Solver            ChallengeSolver
+
+	// SuccessSelector matches an element which is only found on the page
+	// returned by a successful login, eg "a.logout". When it's empty, the
+	// login succeeds unless the response status is an error.
+	SuccessSelector string
+}
+
+// Login fetches the login page, fills the form with the credentials, submits
+// it, solves the challenge pages and verifies the result. Returns a
+// LoginFailed error whose Step field is the step which failed, eg
+// LoginVerify when the credentials were rejected.
+func (bow *Browser) Login(spec LoginSpec) error {
+	if spec.URL != "" {
+		if err := bow.GET(spec.URL); err != nil {
+			return errors.NewLoginFailed(LoginFetch, "Cannot fetch '%s': %s", spec.URL, err)
+		}
+	} else if bow.state.Request == nil {
+		return errors.NewLoginFailed(LoginFetch, "No login URL given and no page has been loaded.")
+	}
+	if code := bow.StatusCode(); code >= 400 {
+		return errors.NewLoginFailed(LoginFetch, "The login page returned the status %d.", code)
+	}
+
+	expr := spec.FormSelector
+	if expr == "" {
+		expr = "form:has([name='" + spec.PassField + "'])"
+	}
+	f, err := bow.Form(expr)
+	if err != nil {
+		return errors.NewLoginFailed(LoginForm, "%s", err)
+	}
+
+	if spec.Username == "" && spec.Password == "" {
+		if spec.Username, spec.Password, err = bow.loginCredentials(spec.Credentials); err != nil {
+			return errors.NewLoginFailed(LoginFill, "%s", err)
+		}
+	}
+	if err := f.Input(spec.UserField, spec.Username); err != nil {
+		return errors.NewLoginFailed(LoginFill, "%s", err)
+	}
+	if err := f.Input(spec.PassField, spec.Password); err != nil {
+		return errors.NewLoginFailed(LoginFill, "%s", err)
+	}
+
+	if spec.SubmitSelector == "" {
+		err = f.Submit()
+	} else {
+		button := f.Dom().Find(spec.SubmitSelector).First()
+		name, ok := button.Attr("name")
+		if button.Length() == 0 || !ok {
+			return errors.NewLoginFailed(LoginSubmit, "No named button matching '%s' in the form.", spec.SubmitSelector)
+		}
+		err = f.ClickByValue(name, button.AttrOr("value", ""))
+	}
+	if err != nil {
+		return errors.NewLoginFailed(LoginSubmit, "%s", err)
+	}
+
+	if err := bow.solveLoginChallenges(spec); err != nil {
+		return err
+	}
+
+	if code := bow.StatusCode(); code >= 400 {
+		return errors.NewLoginFailed(LoginVerify, "The form submission returned the status %d.", code)
+	}
+	if spec.SuccessSelector != "" && bow.Find(spec.SuccessSelector).Length() == 0 {
+		return errors.NewLoginFailed(LoginVerify, "No element matching '%s' after submitting the form.", spec.SuccessSelector)
+	}
+	return nil
+}
+
+// loginCredentials looks up the credentials of the current page host in
+// the store, or in the credential store of the browser when it's nil.
+func (bow *Browser) loginCredentials(store credentials.Store) (string, string, error) {
+	if store == nil {
+		store = bow.credentials
+	}
+	host := bow.URL().Hostname()
+	if store == nil {
+		return "", "", errors.New("No credentials given and no credential store set.")
+	}
+	c, err := credentials.LookupURL(store, bow.URL())
+	if err != nil {
+		return "", "", err
+	}
+	if c == nil {
+		return "", "", errors.New("No credentials found for '%s'.", host)
+	}
+	return c.Username, c.Password, nil
+}
+
+// solveLoginChallenges submits the codes returned by the solver while the
+// page has a challenge form.
+func (bow *Browser) solveLoginChallenges(spec LoginSpec) error {
+	if spec.ChallengeSelector == "" {
+		return nil
+	}
+	for attempt := 1; bow.Find(spec.ChallengeSelector).Length() > 0; attempt++ {
+		if attempt > MaxLoginChallenges {
+			return errors.NewLoginFailed(LoginSolve, "The challenge was still shown after %d attempts.", MaxLoginChallenges)
+		}
+		if spec.Solver == nil {
+			return errors.NewLoginFailed(LoginSolve, "A challenge was shown but no solver was given.")
+		}
+		f, err := bow.Form(spec.ChallengeSelector)
+		if err != nil {
+			return errors.NewLoginFailed(LoginSolve, "%s", err)
+		}
+		code, err := spec.Solver.Solve(&LoginChallenge{Browser: bow, Form: f, Field: spec.ChallengeField, Attempt: attempt})
+		if err != nil {
+			return errors.NewLoginFailed(LoginSolve, "%s", err)
+		}
+		if err := f.Input(spec.ChallengeField, code); err != nil {
+			return errors.NewLoginFailed(LoginSolve, "%s", err)
+		}
+		if err := f.Submit(); err != nil {
+			return errors.NewLoginFailed(LoginSolve, "%s", err)
+		}
+	}
+	return nil
+}
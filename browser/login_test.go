@@ -0,0 +1,152 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lostinblue/surf/credentials"
+	"github.com/lostinblue/surf/errors"
+)
+
+func TestLogin(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			w.Write([]byte(`<html><body>
+				<form id="search" action="/search"><input name="q"></form>
+				<form method="post" action="/session">
+					<input name="user"><input type="password" name="pass">
+					<button type="submit" name="action" value="cancel">Cancel</button>
+					<button type="submit" name="action" value="login">Log in</button>
+				</form>
+			</body></html>`))
+		case "/session":
+			r.ParseForm()
+			if r.PostForm.Get("action") != "login" {
+				http.Error(w, "Cancelled", http.StatusBadRequest)
+				return
+			}
+			if r.PostForm.Get("user") == "admin" && r.PostForm.Get("pass") == "secret" {
+				w.Write([]byte(`<html><body><a class="logout" href="/logout">Log out</a></body></html>`))
+				return
+			}
+			if r.PostForm.Get("user") == "2fa" && r.PostForm.Get("pass") == "secret" {
+				w.Write([]byte(`<html><body><form id="otp" method="post" action="/otp">
+					<input name="code"><input type="submit" name="verify" value="Verify">
+				</form></body></html>`))
+				return
+			}
+			w.Write([]byte(`<html><body><p class="error">Invalid credentials</p></body></html>`))
+		case "/otp":
+			r.ParseForm()
+			if r.PostForm.Get("code") == "287082" {
+				w.Write([]byte(`<html><body><a class="logout" href="/logout">Log out</a></body></html>`))
+				return
+			}
+			w.Write([]byte(`<html><body><form id="otp" method="post" action="/otp">
+				<input name="code"><input type="submit" name="verify" value="Verify">
+			</form></body></html>`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	spec := LoginSpec{
+		URL:             ts.URL + "/login",
+		UserField:       "user",
+		PassField:       "pass",
+		Username:        "admin",
+		Password:        "secret",
+		SubmitSelector:  "button[value='login']",
+		SuccessSelector: "a.logout",
+	}
+	bow := newDefaultTestBrowser()
+	if err := bow.Login(spec); err != nil {
+		t.Fatal(err)
+	}
+
+	step := func(spec LoginSpec) string {
+		err := bow.Login(spec)
+		if lf, ok := err.(errors.LoginFailed); ok {
+			return lf.Step
+		}
+		t.Errorf("Expected a LoginFailed error, got %v", err)
+		return ""
+	}
+
+	bad := spec
+	bad.Password = "wrong"
+	if s := step(bad); s != LoginVerify {
+		t.Errorf("Expected the %s step to fail, got %q", LoginVerify, s)
+	}
+	bad = spec
+	bad.SubmitSelector = "button[value='cancel']"
+	if s := step(bad); s != LoginVerify {
+		t.Errorf("Expected the %s step to fail on an error status, got %q", LoginVerify, s)
+	}
+	bad = spec
+	bad.URL = ts.URL + "/missing"
+	if s := step(bad); s != LoginFetch {
+		t.Errorf("Expected the %s step to fail, got %q", LoginFetch, s)
+	}
+	bad = spec
+	bad.PassField = "password"
+	if s := step(bad); s != LoginForm {
+		t.Errorf("Expected the %s step to fail, got %q", LoginForm, s)
+	}
+	bad = spec
+	bad.UserField = "email"
+	if s := step(bad); s != LoginFill {
+		t.Errorf("Expected the %s step to fail, got %q", LoginFill, s)
+	}
+	bad = spec
+	bad.SubmitSelector = "input[type=submit]"
+	if s := step(bad); s != LoginSubmit {
+		t.Errorf("Expected the %s step to fail, got %q", LoginSubmit, s)
+	}
+
+	bad = spec
+	bad.Username = "2fa"
+	bad.ChallengeSelector = "form#otp"
+	bad.ChallengeField = "code"
+	if s := step(bad); s != LoginSolve {
+		t.Errorf("Expected the %s step to fail without a solver, got %q", LoginSolve, s)
+	}
+	attempts := 0
+	bad.Solver = ChallengeFunc(func(c *LoginChallenge) (string, error) {
+		attempts = c.Attempt
+		return "000000", nil
+	})
+	if s := step(bad); s != LoginSolve || attempts != MaxLoginChallenges {
+		t.Errorf("Expected the %s step to fail after %d attempts, got %q after %d", LoginSolve, MaxLoginChallenges, s, attempts)
+	}
+
+	stored := spec
+	stored.Username, stored.Password = "", ""
+	if s := step(stored); s != LoginFill {
+		t.Errorf("Expected the %s step to fail without credentials, got %q", LoginFill, s)
+	}
+	stored.Credentials = credentials.Map{"127.0.0.1": {Username: "admin", Password: "secret"}}
+	if err := bow.Login(stored); err != nil {
+		t.Errorf("Expected the credentials of the store to be used, got %v", err)
+	}
+	stored.Credentials = nil
+	bow.SetCredentialStore(credentials.Map{"example.com": {Username: "admin", Password: "secret"}})
+	if s := step(stored); s != LoginFill {
+		t.Errorf("Expected the %s step to fail for an unknown host, got %q", LoginFill, s)
+	}
+	bow.SetCredentialStore(nil)
+
+	totp := NewTOTP("GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ")
+	totp.Now = func() time.Time { return time.Unix(59, 0) }
+	spec.Username = "2fa"
+	spec.ChallengeSelector = "form#otp"
+	spec.ChallengeField = "code"
+	spec.Solver = totp
+	if err := bow.Login(spec); err != nil {
+		t.Fatal(err)
+	}
+}
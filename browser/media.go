@@ -0,0 +1,154 @@
+package browser
+
+import (
+	"strconv"
+	"strings"
+)
+
+// MatchMedia returns whether the media query, eg "(min-width: 800px)" or
+// "screen and (orientation: landscape)", matches a screen with the viewport
+// and the pixel density. The width, height, orientation, resolution and
+// device pixel ratio features are evaluated, with the min-, max- and range
+// syntaxes; queries with other features do not match, like invalid queries.
+// A query of several comma separated queries matches when one of them does.
+func MatchMedia(query string, width, height int, density float64) bool {
+	m := mediaScreen{width: float64(width), height: float64(height), density: density}
+	if m.density <= 0 {
+		m.density = 1
+	}
+	for _, q := range strings.Split(strings.ToLower(query), ",") {
+		if m.match(strings.TrimSpace(q)) {
+			return true
+		}
+	}
+	return false
+}
+
+// mediaScreen is the screen media queries are evaluated for.
+type mediaScreen struct {
+	width, height, density float64
+}
+
+// match returns whether a single media query matches.
+func (m mediaScreen) match(q string) bool {
+	if q == "" {
+		return false
+	}
+	not := false
+	if strings.HasPrefix(q, "not ") {
+		not, q = true, strings.TrimSpace(q[4:])
+	} else if strings.HasPrefix(q, "only ") {
+		q = strings.TrimSpace(q[5:])
+	}
+	ok := true
+	for i, part := range strings.Split(q, " and ") {
+		part = strings.TrimSpace(part)
+		if i == 0 && !strings.HasPrefix(part, "(") {
+			ok = ok && (part == "all" || part == "screen")
+			continue
+		}
+		if !strings.HasPrefix(part, "(") || !strings.HasSuffix(part, ")") {
+			return false
+		}
+		matched, valid := m.feature(strings.TrimSpace(part[1 : len(part)-1]))
+		if !valid {
+			return false
+		}
+		ok = ok && matched
+	}
+	return ok != not
+}
+
+// feature evaluates a media feature, eg "min-width: 800px" or
+// "width >= 800px", and returns whether it matches and is valid.
+func (m mediaScreen) feature(f string) (bool, bool) {
+	if i := strings.Index(f, ":"); i >= 0 {
+		name, value := strings.TrimSpace(f[:i]), strings.TrimSpace(f[i+1:])
+		prefix := ""
+		if strings.HasPrefix(name, "min-") || strings.HasPrefix(name, "max-") {
+			prefix, name = name[:4], name[4:]
+		}
+		if name == "-webkit-device-pixel-ratio" {
+			name = "device-pixel-ratio"
+		} else if strings.HasPrefix(name, "-webkit-") && strings.HasSuffix(name, "device-pixel-ratio") {
+			// -webkit-min-device-pixel-ratio and -webkit-max-device-pixel-ratio.
+			prefix, name = name[8:12], "device-pixel-ratio"
+		}
+		if name == "orientation" {
+			landscape := m.width > m.height
+			return (value == "landscape") == landscape, value == "landscape" || value == "portrait"
+		}
+		actual, ok := m.value(name)
+		expected, valid := mediaValue(name, value)
+		if !ok || !valid {
+			return false, false
+		}
+		switch prefix {
+		case "min-":
+			return actual >= expected, true
+		case "max-":
+			return actual <= expected, true
+		}
+		return actual == expected, true
+	}
+	for _, op := range []string{">=", "<=", ">", "<", "="} {
+		if i := strings.Index(f, op); i >= 0 {
+			name, value := strings.TrimSpace(f[:i]), strings.TrimSpace(f[i+len(op):])
+			actual, ok := m.value(name)
+			expected, valid := mediaValue(name, value)
+			if !ok || !valid {
+				return false, false
+			}
+			switch op {
+			case ">=":
+				return actual >= expected, true
+			case "<=":
+				return actual <= expected, true
+			case ">":
+				return actual > expected, true
+			case "<":
+				return actual < expected, true
+			}
+			return actual == expected, true
+		}
+	}
+	return false, false
+}
+
+// value returns the value of the feature for the screen.
+func (m mediaScreen) value(name string) (float64, bool) {
+	switch name {
+	case "width":
+		return m.width, true
+	case "height":
+		return m.height, true
+	case "resolution", "device-pixel-ratio":
+		return m.density, true
+	}
+	return 0, false
+}
+
+// mediaValue parses the value of a feature, in pixels for the lengths and
+// in dppx for the resolutions.
+func mediaValue(name, value string) (float64, bool) {
+	units := map[string]float64{"px": 1, "em": 16, "rem": 16}
+	switch name {
+	case "resolution":
+		units = map[string]float64{"dppx": 1, "x": 1, "dpi": 1.0 / 96, "dpcm": 2.54 / 96}
+	case "device-pixel-ratio":
+		units = map[string]float64{"": 1}
+	}
+	for unit, scale := range units {
+		if unit != "" && !strings.HasSuffix(value, unit) {
+			continue
+		}
+		v, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(value, unit)), 64)
+		if err == nil {
+			return v * scale, true
+		}
+	}
+	if v, err := strconv.ParseFloat(value, 64); err == nil && v == 0 {
+		return 0, true
+	}
+	return 0, false
+}
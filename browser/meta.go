@@ -0,0 +1,99 @@
+package browser
+
+import (
+	"mime"
+	"net/url"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// MetaTag returns the content of the meta tag with the given name, eg
+// "description" or "robots", or with the given property, eg "og:title".
+// Returns an empty string when the page has no such tag.
+func (bow *Browser) MetaTag(name string) string {
+	doc := bow.dom()
+	if doc == nil {
+		return ""
+	}
+	sel := doc.Find("head meta").FilterFunction(func(_ int, s *goquery.Selection) bool {
+		n, _ := s.Attr("name")
+		p, _ := s.Attr("property")
+		return strings.EqualFold(n, name) || strings.EqualFold(p, name)
+	})
+	content, _ := sel.First().Attr("content")
+	return strings.TrimSpace(content)
+}
+
+// Description returns the description of the page, from the description
+// meta tag.
+func (bow *Browser) Description() string {
+	return bow.MetaTag("description")
+}
+
+// Canonical returns the URL of the <link rel="canonical"> element of the
+// page, resolved against the page URL, or nil when the page has none. See
+// CanonicalURL for the normalized URL of any page.
+func (bow *Browser) Canonical() *url.URL {
+	doc := bow.dom()
+	if doc == nil || bow.URL() == nil {
+		return nil
+	}
+	sel := doc.Find("link[rel~='canonical' i]").First()
+	if sel.Length() == 0 {
+		return nil
+	}
+	u, err := bow.attrToResolvedURL("href", sel)
+	if err != nil {
+		return nil
+	}
+	return u
+}
+
+// Language returns the language of the page, from the lang attribute of
+// the html element, the Content-Language meta tag or the Content-Language
+// header, eg "en-US". Returns an empty string when it's not declared.
+func (bow *Browser) Language() string {
+	if doc := bow.dom(); doc != nil {
+		if lang, ok := doc.Find("html").Attr("lang"); ok && strings.TrimSpace(lang) != "" {
+			return strings.TrimSpace(lang)
+		}
+		if lang, ok := doc.Find("meta[http-equiv='content-language' i]").Attr("content"); ok && strings.TrimSpace(lang) != "" {
+			return strings.TrimSpace(lang)
+		}
+	}
+	if bow.state.Response != nil {
+		return strings.TrimSpace(bow.state.Response.Header.Get("Content-Language"))
+	}
+	return ""
+}
+
+// Charset returns the charset of the page, from the Content-Type header of
+// the response or from the meta tags of the page, eg "utf-8". Returns an
+// empty string when it's not declared.
+func (bow *Browser) Charset() string {
+	if bow.state.Response != nil {
+		_, params, err := mime.ParseMediaType(bow.state.Response.Header.Get("Content-Type"))
+		if err == nil && params["charset"] != "" {
+			return params["charset"]
+		}
+	}
+	return metaCharset(bow.dom())
+}
+
+// metaCharset returns the charset declared by the meta tags of the
+// document, or an empty string.
+func metaCharset(doc *goquery.Document) string {
+	if doc == nil {
+		return ""
+	}
+	if cs, ok := doc.Find("meta[charset]").Attr("charset"); ok {
+		return strings.TrimSpace(cs)
+	}
+	if ct, ok := doc.Find("meta[http-equiv='content-type' i]").Attr("content"); ok {
+		if _, params, err := mime.ParseMediaType(ct); err == nil {
+			return params["charset"]
+		}
+	}
+	return ""
+}
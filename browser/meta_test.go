@@ -0,0 +1,66 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMetaAccessors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/article":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<html lang="en-GB"><head>
+				<meta charset="ISO-8859-1">
+				<meta name="Description" content=" An article. ">
+				<meta property="og:title" content="Article">
+				<link rel="canonical" href="/articles/1?ref=x">
+			</head><body><meta name="robots" content="noindex"></body></html>`))
+		default:
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Header().Set("Content-Language", "fr")
+			w.Write([]byte(`<html><head><title>Plain</title></head></html>`))
+		}
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	if bow.Canonical() != nil || bow.Description() != "" || bow.Charset() != "" {
+		t.Error("Expected empty values before loading a page")
+	}
+	if err := bow.GET(ts.URL + "/article"); err != nil {
+		t.Fatal(err)
+	}
+	if d := bow.Description(); d != "An article." {
+		t.Errorf("Expected description %q, got %q", "An article.", d)
+	}
+	if title := bow.MetaTag("og:title"); title != "Article" {
+		t.Errorf("Expected og:title %q, got %q", "Article", title)
+	}
+	if robots := bow.MetaTag("robots"); robots != "" {
+		t.Errorf("Expected the meta tags of the body to be ignored, got %q", robots)
+	}
+	if c := bow.Canonical(); c == nil || c.String() != ts.URL+"/articles/1?ref=x" {
+		t.Errorf("Expected canonical %q, got %v", ts.URL+"/articles/1?ref=x", c)
+	}
+	if l := bow.Language(); l != "en-GB" {
+		t.Errorf("Expected language %q, got %q", "en-GB", l)
+	}
+	if cs := bow.Charset(); cs != "ISO-8859-1" {
+		t.Errorf("Expected charset %q, got %q", "ISO-8859-1", cs)
+	}
+
+	if err := bow.GET(ts.URL + "/plain"); err != nil {
+		t.Fatal(err)
+	}
+	if bow.Canonical() != nil {
+		t.Errorf("Expected no canonical URL, got %v", bow.Canonical())
+	}
+	if l := bow.Language(); l != "fr" {
+		t.Errorf("Expected language %q, got %q", "fr", l)
+	}
+	if cs := bow.Charset(); cs != "utf-8" {
+		t.Errorf("Expected charset %q, got %q", "utf-8", cs)
+	}
+}
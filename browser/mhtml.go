@@ -0,0 +1,155 @@
+package browser
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"path"
+	"time"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+// mhtmlLineLength is the length of the base64 encoded lines of an archive.
+const mhtmlLineLength = 76
+
+// ExportMHTML writes the current page and its images, stylesheets and scripts
+// to w as a single MHTML archive. The assets are downloaded with the browser
+// cookies and rules, without changing the current page, and those which
+// cannot be downloaded are left out of the archive.
+func (bow *Browser) ExportMHTML(w io.Writer) error {
+	if bow.state == nil || bow.state.Request == nil || bow.state.Response == nil {
+		return errors.NewPageNotLoaded("Cannot export the page, no page has been loaded.")
+	}
+	mw := multipart.NewWriter(w)
+	header := fmt.Sprintf("From: <Saved by surf>\r\n"+
+		"Snapshot-Content-Location: %s\r\n"+
+		"Subject: %s\r\n"+
+		"Date: %s\r\n"+
+		"MIME-Version: 1.0\r\n"+
+		"Content-Type: multipart/related; type=\"text/html\"; boundary=\"%s\"\r\n\r\n",
+		bow.URL(), mime.QEncoding.Encode("utf-8", bow.Title()),
+		time.Now().Format(time.RFC1123Z), mw.Boundary())
+	if _, err := io.WriteString(w, header); err != nil {
+		return err
+	}
+
+	contentType := bow.ResponseHeaders().Get("Content-Type")
+	if contentType == "" {
+		contentType = "text/html"
+	}
+	part, err := mw.CreatePart(mhtmlPartHeader(contentType, "quoted-printable", bow.URL().String()))
+	if err != nil {
+		return err
+	}
+	qw := quotedprintable.NewWriter(part)
+	if _, err = qw.Write(bow.body); err != nil {
+		return err
+	}
+	if err = qw.Close(); err != nil {
+		return err
+	}
+
+	for _, u := range bow.mhtmlAssets() {
+		data, contentType, err := bow.fetchAsset(u)
+		if err != nil {
+			continue
+		}
+		part, err := mw.CreatePart(mhtmlPartHeader(contentType, "base64", u))
+		if err != nil {
+			return err
+		}
+		if err = writeBase64Lines(part, data); err != nil {
+			return err
+		}
+	}
+	return mw.Close()
+}
+
+// mhtmlAssets returns the URLs of the assets of the page, without duplicates.
+func (bow *Browser) mhtmlAssets() []string {
+	var urls []string
+	seen := map[string]bool{bow.URL().String(): true}
+	add := func(u *url.URL) {
+		if !seen[u.String()] {
+			seen[u.String()] = true
+			urls = append(urls, u.String())
+		}
+	}
+	for _, a := range bow.Images() {
+		add(a.URL)
+	}
+	for _, a := range bow.Stylesheets() {
+		add(a.URL)
+	}
+	for _, a := range bow.Scripts() {
+		add(a.URL)
+	}
+	return urls
+}
+
+// fetchAsset downloads an asset of the page and returns its content and type.
+func (bow *Browser) fetchAsset(u string) ([]byte, string, error) {
+	req, err := bow.buildRequest("GET", u, bow.URL(), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	resp, cancel, err := bow.do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer cancel()
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", errors.NewPageNotFound("Cannot download '%s', the server returned %d.", u, resp.StatusCode)
+	}
+	body, err := bow.decodeBody(resp)
+	if err != nil {
+		return nil, "", err
+	}
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, "", err
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = mime.TypeByExtension(path.Ext(req.URL.Path))
+	}
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	return data, contentType, nil
+}
+
+// mhtmlPartHeader returns the header of an archive part.
+func mhtmlPartHeader(contentType, encoding, location string) textproto.MIMEHeader {
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Type", contentType)
+	h.Set("Content-Transfer-Encoding", encoding)
+	h.Set("Content-Location", location)
+	return h
+}
+
+// writeBase64Lines writes data to w in base64, split in lines of
+// mhtmlLineLength characters.
+func writeBase64Lines(w io.Writer, data []byte) error {
+	enc := base64.StdEncoding.EncodeToString(data)
+	for len(enc) > 0 {
+		n := mhtmlLineLength
+		if n > len(enc) {
+			n = len(enc)
+		}
+		if _, err := io.WriteString(w, enc[:n]+"\r\n"); err != nil {
+			return err
+		}
+		enc = enc[n:]
+	}
+	return nil
+}
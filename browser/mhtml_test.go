@@ -0,0 +1,97 @@
+package browser
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+)
+
+func TestExportMHTML(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte(`<html><head><title>Archive</title>
+				<link rel="stylesheet" href="/site.css"></head><body>
+				<img src="/logo.png"><img src="/logo.png"><img src="/missing.png">
+			</body></html>`))
+		case "/site.css":
+			w.Header().Set("Content-Type", "text/css")
+			w.Write([]byte("body { color: red; }"))
+		case "/logo.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte("\x89PNG fake image"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	buff := &bytes.Buffer{}
+	if err := bow.ExportMHTML(buff); err == nil {
+		t.Error("Expected an error before loading a page.")
+	}
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if err := bow.ExportMHTML(buff); err != nil {
+		t.Fatal(err)
+	}
+
+	tp := textproto.NewReader(bufio.NewReader(buff))
+	header, err := tp.ReadMIMEHeader()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if header.Get("Snapshot-Content-Location") != ts.URL {
+		t.Errorf("Expected the page location, got '%s'.", header.Get("Snapshot-Content-Location"))
+	}
+	if header.Get("Subject") != "Archive" {
+		t.Errorf("Expected the page title, got '%s'.", header.Get("Subject"))
+	}
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/related" {
+		t.Fatalf("Expected a multipart/related archive, got '%s'.", header.Get("Content-Type"))
+	}
+
+	parts := map[string]string{}
+	var locations []string
+	mr := multipart.NewReader(tp.R, params["boundary"])
+	for {
+		part, err := mr.NextPart()
+		if err != nil {
+			break
+		}
+		data, err := ioutil.ReadAll(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		location := part.Header.Get("Content-Location")
+		locations = append(locations, location)
+		parts[location] = part.Header.Get("Content-Type")
+		if location == ts.URL {
+			html, err := ioutil.ReadAll(quotedprintable.NewReader(bytes.NewReader(data)))
+			if err != nil || !strings.Contains(string(html), "<title>Archive</title>") {
+				t.Errorf("Expected the page body, got '%s'.", html)
+			}
+		}
+	}
+	if len(locations) != 3 || locations[0] != ts.URL {
+		t.Fatalf("Expected the page, the image and the stylesheet, got %v.", locations)
+	}
+	if parts[ts.URL+"/logo.png"] != "image/png" {
+		t.Errorf("Expected the image part, got '%s'.", parts[ts.URL+"/logo.png"])
+	}
+	if parts[ts.URL+"/site.css"] != "text/css" {
+		t.Errorf("Expected the stylesheet part, got '%s'.", parts[ts.URL+"/site.css"])
+	}
+}
@@ -0,0 +1,44 @@
+package browser
+
+import (
+	"bytes"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/lostinblue/surf/errors"
+	"golang.org/x/net/html"
+)
+
+// SetBody replaces the HTML of the current page, which is parsed again on
+// the next DOM access. The change is visible to Body(), DOM() and Download(),
+// and is kept with the page in the history.
+func (bow *Browser) SetBody(html string) {
+	bow.body = []byte(html)
+	bow.state.Body = bow.body
+	bow.state.Dom = nil
+	bow.setDocumentBase()
+	bow.domErr = nil
+}
+
+// MutateDom calls fn with the DOM of the current page, then serializes the
+// modified document so the change is visible to Body(), DOM() and Download(),
+// eg to remove the scripts of a page before saving it.
+func (bow *Browser) MutateDom(fn func(doc *goquery.Document)) error {
+	if !bow.parsesDOM() {
+		return errors.New("Cannot mutate the DOM, the ParseDOM attribute is false.")
+	}
+	doc := bow.dom()
+	if doc == nil {
+		return errors.NewPageNotLoaded("Cannot mutate the DOM, no page has been loaded.")
+	}
+	fn(doc)
+	var buf bytes.Buffer
+	for _, n := range doc.Nodes {
+		if err := html.Render(&buf, n); err != nil {
+			return err
+		}
+	}
+	bow.body = buf.Bytes()
+	bow.state.Body = bow.body
+	bow.setDocumentBase()
+	return nil
+}
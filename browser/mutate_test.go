@@ -0,0 +1,53 @@
+package browser
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestMutateDom(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><head><title>Page</title></head><body><p>Hello</p><script>track()</script></body></html>`))
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	if err := bow.MutateDom(func(doc *goquery.Document) {}); err == nil {
+		t.Error("Expected an error when no page has been loaded")
+	}
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	err := bow.MutateDom(func(doc *goquery.Document) {
+		doc.Find("script").Remove()
+		doc.Find("p").SetText("Bye")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body := bow.Body(); body != "<p>Bye</p>" {
+		t.Errorf("Expected body %q, got %q", "<p>Bye</p>", body)
+	}
+	var buf bytes.Buffer
+	if _, err := bow.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(buf.String(), "track()") || !strings.Contains(buf.String(), "<title>Page</title>") {
+		t.Errorf("Unexpected download %q", buf.String())
+	}
+
+	bow.SetBody(`<html><head><title>Template</title></head><body></body></html>`)
+	if bow.Title() != "Template" {
+		t.Errorf("Expected title %q, got %q", "Template", bow.Title())
+	}
+	buf.Reset()
+	bow.WriteTo(&buf)
+	if !strings.Contains(buf.String(), "Template") {
+		t.Errorf("Unexpected download %q", buf.String())
+	}
+}
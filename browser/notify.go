@@ -0,0 +1,44 @@
+package browser
+
+import (
+	"time"
+
+	"github.com/lostinblue/surf/notify"
+)
+
+// SetNotifier sets the notifier which receives the events of the browser:
+// notify.PageChanged when ReloadIfModified() or DiffSnapshot() report a
+// change, and notify.SessionExpired when the session guard finds an expired
+// session. Tabs share the notifier of their parent.
+func (bow *Browser) SetNotifier(n notify.Notifier) {
+	bow.notifier = n
+}
+
+// Notifier returns the notifier set with SetNotifier(), or nil.
+func (bow *Browser) Notifier() notify.Notifier {
+	return bow.notifier
+}
+
+// Notify sends the event to the notifier of the browser, eg for the events
+// of a program using the browser. The time of the event is set when it's
+// zero, and its URL is set to the current page when it's empty.
+//
+// Returns nil when no notifier has been set.
+func (bow *Browser) Notify(e *notify.Event) error {
+	if bow.notifier == nil {
+		return nil
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if e.URL == "" && bow.state != nil && bow.state.Response != nil {
+		e.URL = bow.URL().String()
+	}
+	return bow.notifier.Notify(e)
+}
+
+// notify sends an event of the browser itself. The error of the notifier is
+// ignored, as it's not the error of the method which fired the event.
+func (bow *Browser) notify(kind notify.Kind, u, msg string, data map[string]string) {
+	bow.Notify(&notify.Event{Kind: kind, URL: u, Message: msg, Data: data})
+}
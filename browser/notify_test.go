@@ -0,0 +1,64 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lostinblue/surf/jar"
+	"github.com/lostinblue/surf/notify"
+)
+
+func TestNotifier(t *testing.T) {
+	version := "1"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html><body><p>version " + version + "</p></body></html>"))
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	bow.SetSnapshotsJar(jar.NewMemorySnapshots())
+	if err := bow.Notify(&notify.Event{Kind: notify.PageChanged}); err != nil {
+		t.Errorf("Expected no error without a notifier, got %v.", err)
+	}
+	var events []*notify.Event
+	bow.SetNotifier(notify.Func(func(e *notify.Event) error {
+		events = append(events, e)
+		return nil
+	}))
+
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if err := bow.Snapshot("home"); err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := bow.ReloadIfModified(); err != nil || changed {
+		t.Fatalf("Expected the page unchanged, got %v (%v).", changed, err)
+	}
+	if len(events) != 0 {
+		t.Errorf("Expected no events for an unchanged page, got %d.", len(events))
+	}
+
+	version = "2"
+	if changed, err := bow.ReloadIfModified(); err != nil || !changed {
+		t.Fatalf("Expected the page changed, got %v (%v).", changed, err)
+	}
+	if _, err := bow.DiffSnapshot("home"); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, got %d.", len(events))
+	}
+	for _, e := range events {
+		if e.Kind != notify.PageChanged || e.URL != bow.URL().String() || e.Time.IsZero() {
+			t.Errorf("Expected a page changed event for the page, got %+v.", e)
+		}
+	}
+	if events[1].Data["snapshot"] != "home" || events[1].Data["diff"] == "" {
+		t.Errorf("Expected the snapshot diff, got %v.", events[1].Data)
+	}
+	if tab := bow.NewTab(); tab.Notifier() == nil {
+		t.Error("Expected the tab to share the notifier.")
+	}
+}
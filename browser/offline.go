@@ -0,0 +1,118 @@
+package browser
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"regexp"
+
+	"github.com/lostinblue/surf/errors"
+	"github.com/lostinblue/surf/jar"
+)
+
+// savedFrom matches the "saved from url" comment written by browsers at the
+// top of saved pages.
+var savedFrom = regexp.MustCompile(`<!--\s*saved from url=\(\d+\)(\S+?)\s*-->`)
+
+// savedFromLimit is the number of bytes searched for the savedFrom comment.
+const savedFromLimit = 4096
+
+// LoadFromFile makes the saved HTML page at the given path the current page,
+// so it can be searched and its forms and links used without a network
+// connection. Relative URLs are resolved against the URL in the "saved from
+// url" comment of the page when there is one, or the file URL otherwise.
+func (bow *Browser) LoadFromFile(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	head := data
+	if len(head) > savedFromLimit {
+		head = head[:savedFromLimit]
+	}
+	if m := savedFrom.FindSubmatch(head); m != nil {
+		return bow.LoadFromReader(bytes.NewReader(data), string(m[1]))
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	u := &url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}
+	return bow.LoadFromReader(bytes.NewReader(data), u.String())
+}
+
+// LoadFromReader makes the HTML read from r the current page, as if it was
+// loaded from baseURL. Links and form actions are resolved against baseURL,
+// so a saved page given its live URL submits its forms to the live host. The
+// previous page is added to the history.
+func (bow *Browser) LoadFromReader(r io.Reader, baseURL string) error {
+	u, err := parseBaseURL(baseURL)
+	if err != nil {
+		return err
+	}
+	req, resp := syntheticResponse(u, ioutil.NopCloser(r))
+	bow.preSend()
+	if err = bow.loadResponse(req, resp, true); err != nil {
+		return err
+	}
+	return bow.postSend()
+}
+
+// SetContent makes the given HTML the current page without sending a
+// request, so the page methods, such as Find, Links and Form, may be used
+// to parse any HTML. Relative URLs are resolved against baseURL. The
+// history and visited pages are not changed.
+func (bow *Browser) SetContent(html, baseURL string) error {
+	u, err := parseBaseURL(baseURL)
+	if err != nil {
+		return err
+	}
+	bow.preSend()
+	bow.body = []byte(html)
+	req, resp := syntheticResponse(u, &storedBody{Reader: bytes.NewReader(bow.body), data: bow.body})
+	bow.state = jar.NewHistoryState(req, resp, nil)
+	bow.state.Body = bow.body
+	bow.setDocumentBase()
+	bow.domErr = nil
+	return nil
+}
+
+// parseBaseURL parses the base URL of a page which is not loaded from the network.
+func parseBaseURL(baseURL string) (*url.URL, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if !u.IsAbs() {
+		return nil, errors.New("Cannot load the page, the base URL '%s' is not absolute.", baseURL)
+	}
+	return u, nil
+}
+
+// syntheticResponse returns a successful request and response for the page
+// at u with the given body.
+func syntheticResponse(u *url.URL, body io.ReadCloser) (*http.Request, *http.Response) {
+	req := &http.Request{
+		Method:     "GET",
+		URL:        u,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{},
+		Host:       u.Host,
+	}
+	resp := &http.Response{
+		Status:     "200 OK",
+		StatusCode: http.StatusOK,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{"Content-Type": {"text/html"}},
+		Body:       body,
+		Request:    req,
+	}
+	return req, resp
+}
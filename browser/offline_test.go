@@ -0,0 +1,141 @@
+package browser
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadFromReader(t *testing.T) {
+	var posted string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" && r.URL.Path == "/search" {
+			r.ParseForm()
+			posted = r.PostForm.Get("q")
+		}
+		w.Write([]byte("<html><head><title>Results</title></head></html>"))
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	page := `<html><head><title>Saved</title></head><body>
+		<a href="/about">About</a>
+		<form method="post" action="/search"><input name="q"></form>
+	</body></html>`
+	if err := bow.LoadFromReader(strings.NewReader(page), "relative/path"); err == nil {
+		t.Error("Expected an error for a relative base URL.")
+	}
+	if err := bow.LoadFromReader(strings.NewReader(page), ts.URL+"/saved"); err != nil {
+		t.Fatal(err)
+	}
+	if bow.Title() != "Saved" {
+		t.Errorf("Expected the saved page, got '%s'.", bow.Title())
+	}
+	if bow.StatusCode() != 200 {
+		t.Errorf("Expected status 200, got %d.", bow.StatusCode())
+	}
+	links := bow.Links()
+	if len(links) != 1 || links[0].URL.String() != ts.URL+"/about" {
+		t.Errorf("Expected the link resolved against the base URL, got %v.", links)
+	}
+
+	f, err := bow.Form("form")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Input("q", "surf")
+	if err = f.Submit(); err != nil {
+		t.Fatal(err)
+	}
+	if posted != "surf" {
+		t.Errorf("Expected the form posted to the live host, got '%s'.", posted)
+	}
+	if bow.Title() != "Results" {
+		t.Errorf("Expected the results page, got '%s'.", bow.Title())
+	}
+	if !bow.Back() || bow.Title() != "Saved" {
+		t.Errorf("Expected the saved page in the history, got '%s'.", bow.Title())
+	}
+}
+
+func TestLoadFromFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "surf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	plain := filepath.Join(dir, "plain.html")
+	ioutil.WriteFile(plain, []byte(`<html><body><a href="next.html">Next</a></body></html>`), 0644)
+	saved := filepath.Join(dir, "saved.html")
+	ioutil.WriteFile(saved, []byte("<!DOCTYPE html>\n<!-- saved from url=(0024)http://example.com/docs/ -->\n"+
+		`<html><body><a href="next.html">Next</a></body></html>`), 0644)
+
+	bow := newDefaultTestBrowser()
+	if err = bow.LoadFromFile(filepath.Join(dir, "missing.html")); err == nil {
+		t.Error("Expected an error for a missing file.")
+	}
+	if err = bow.LoadFromFile(plain); err != nil {
+		t.Fatal(err)
+	}
+	if bow.URL().Scheme != "file" || !strings.HasSuffix(bow.URL().Path, "/plain.html") {
+		t.Errorf("Expected the file URL, got '%s'.", bow.URL())
+	}
+	if links := bow.Links(); len(links) != 1 || !strings.HasSuffix(links[0].URL.Path, "/next.html") {
+		t.Errorf("Expected the link resolved against the file URL, got %v.", links)
+	}
+
+	if err = bow.LoadFromFile(saved); err != nil {
+		t.Fatal(err)
+	}
+	if bow.URL().String() != "http://example.com/docs/" {
+		t.Errorf("Expected the saved from URL, got '%s'.", bow.URL())
+	}
+	if links := bow.Links(); len(links) != 1 || links[0].URL.String() != "http://example.com/docs/next.html" {
+		t.Errorf("Expected the link resolved against the saved from URL, got %v.", links)
+	}
+}
+
+func TestSetContent(t *testing.T) {
+	bow := newDefaultTestBrowser()
+	if err := bow.SetContent("<p>bad</p>", "http://[::1"); err == nil {
+		t.Error("Expected an error for an invalid base URL.")
+	}
+	err := bow.SetContent(`<html><head><link rel="stylesheet" href="/site.css"></head><body>
+		<a href="page.html">Page</a><img src="logo.png">
+		<form action="/login"><input name="user" value="joe"></form>
+	</body></html>`, "http://example.com/docs/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if links := bow.Links(); len(links) != 1 || links[0].URL.String() != "http://example.com/docs/page.html" {
+		t.Errorf("Expected the resolved link, got %v.", links)
+	}
+	if images := bow.Images(); len(images) != 1 || images[0].URL.String() != "http://example.com/docs/logo.png" {
+		t.Errorf("Expected the resolved image, got %v.", images)
+	}
+	if css := bow.Stylesheets(); len(css) != 1 || css[0].URL.String() != "http://example.com/site.css" {
+		t.Errorf("Expected the resolved stylesheet, got %v.", css)
+	}
+	f, err := bow.Form("form")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := f.Value("user"); v != "joe" {
+		t.Errorf("Expected the form value, got '%s'.", v)
+	}
+	if bow.HistoryJar().Len() != 0 {
+		t.Errorf("Expected the history unchanged, got %d pages.", bow.HistoryJar().Len())
+	}
+
+	if err = bow.SetContent(`<a href="page.html">Page</a>`, ""); err == nil {
+		t.Error("Expected an error for an empty base URL.")
+	}
+	if !strings.Contains(bow.Body(), "logo.png") {
+		t.Errorf("Expected the content as the body, got '%s'.", bow.Body())
+	}
+}
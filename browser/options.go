@@ -0,0 +1,126 @@
+package browser
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// RequestOption changes a single request without modifying the browser
+// configuration shared by every other request.
+type RequestOption func(*requestOptions)
+
+// requestOptions holds the settings built from a list of RequestOption values.
+type requestOptions struct {
+	// headers are set on the request, replacing browser headers with the same name.
+	headers http.Header
+
+	// query values are added to the request URL.
+	query url.Values
+
+	// noDefaultQuery skips the browser default query values, and skipQuery
+	// the ones with the given keys.
+	noDefaultQuery bool
+	skipQuery      []string
+
+	// timeout is the time limit for the request, or 0 for no limit.
+	timeout time.Duration
+
+	// noRedirects prevents the request from following Location headers.
+	noRedirects bool
+
+	// proxy is the URL of the proxy the request is sent through.
+	proxy string
+
+	// referrerPolicy overrides the referrer policy of the page.
+	referrerPolicy ReferrerPolicy
+
+	// referrer is the page the request was made from, used to set the
+	// Referer header when following redirects.
+	referrer *url.URL
+
+	// conditional keeps the current page when the server answers 304 Not
+	// Modified, and notModified records that it did.
+	conditional bool
+	notModified bool
+
+	// streaming sends the body as it's read instead of reading it in
+	// memory first, with the given size or -1 when unknown.
+	streaming bool
+	size      int64
+
+	// getBody builds a streamed body again, so the request may be sent
+	// again, or is nil when it can't be.
+	getBody func() (io.ReadCloser, error)
+
+	// progress is called as the body is sent.
+	progress func(sent, total int64)
+}
+
+// requestOptionsKey is the context key under which the request options are stored.
+type requestOptionsKey struct{}
+
+// WithHeader sets a header for this request only. The value replaces any
+// header with the same name set on the browser.
+func WithHeader(name, value string) RequestOption {
+	return func(o *requestOptions) {
+		if o.headers == nil {
+			o.headers = make(http.Header)
+		}
+		o.headers.Add(name, value)
+	}
+}
+
+// WithTimeout sets the time limit for this request only.
+func WithTimeout(t time.Duration) RequestOption {
+	return func(o *requestOptions) {
+		o.timeout = t
+	}
+}
+
+// WithoutRedirects prevents this request from following Location headers,
+// as if the FollowRedirects attribute was false.
+func WithoutRedirects() RequestOption {
+	return func(o *requestOptions) {
+		o.noRedirects = true
+	}
+}
+
+// WithQuery adds a query string value to the URL of this request only.
+func WithQuery(key, value string) RequestOption {
+	return func(o *requestOptions) {
+		if o.query == nil {
+			o.query = make(url.Values)
+		}
+		o.query.Add(key, value)
+	}
+}
+
+// newRequestOptions applies the given options and returns the result.
+func newRequestOptions(opts []RequestOption) *requestOptions {
+	o := &requestOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// apply sets the options on the given request, and stores them in the
+// request context so they are available when following redirects.
+func (o *requestOptions) apply(req *http.Request) *http.Request {
+	for name, values := range o.headers {
+		req.Header[name] = values
+	}
+	appendQuery(req.URL, o.query)
+	return req.WithContext(context.WithValue(req.Context(), requestOptionsKey{}, o))
+}
+
+// optionsFromRequest returns the options the given request was built with.
+func optionsFromRequest(req *http.Request) *requestOptions {
+	if o, ok := req.Context().Value(requestOptionsKey{}).(*requestOptions); ok {
+		return o
+	}
+	return &requestOptions{}
+}
@@ -0,0 +1,54 @@
+package browser
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRequestOptions(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, "/", 302)
+		case "/slow":
+			time.Sleep(200 * time.Millisecond)
+		default:
+			io.WriteString(w, r.Header.Get("X-Test")+"|"+r.URL.RawQuery)
+		}
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	bow.AddRequestHeader("X-Test", "browser")
+
+	if err := bow.GET(ts.URL+"/?a=1", WithHeader("X-Test", "request"), WithQuery("b", "2")); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(bow.body); got != "request|a=1&b=2" {
+		t.Errorf("Expected per-request header and query, got '%s'", got)
+	}
+
+	if err := bow.GET(ts.URL + "/"); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(bow.body); got != "browser|" {
+		t.Errorf("Per-request options leaked into the browser configuration, got '%s'", got)
+	}
+
+	if err := bow.GET(ts.URL+"/redirect", WithoutRedirects()); err == nil {
+		t.Error("Expected redirect to fail with WithoutRedirects")
+	}
+	if !bow.Attribute(FollowRedirects) {
+		t.Error("WithoutRedirects changed the FollowRedirects attribute")
+	}
+	if err := bow.GET(ts.URL + "/redirect"); err != nil {
+		t.Errorf("Expected redirect to be followed, got %s", err)
+	}
+
+	if err := bow.GET(ts.URL+"/slow", WithTimeout(10*time.Millisecond)); err == nil {
+		t.Error("Expected request to time out with WithTimeout")
+	}
+}
@@ -0,0 +1,877 @@
+package browser
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lostinblue/surf/errors"
+	"golang.org/x/net/http/httpguts"
+	"golang.org/x/net/http2/hpack"
+)
+
+// DefaultPseudoHeaderOrder is the order of the HTTP/2 pseudo headers sent by
+// OrderedTransport, which is the order of Chrome.
+var DefaultPseudoHeaderOrder = []string{":method", ":authority", ":scheme", ":path"}
+
+// OrderedTransport is an http.RoundTripper which writes the request headers
+// on the wire in a given order, unlike the net/http transport which sorts
+// them by name. The order is read from the request with HeaderOrder(), as set
+// by Browser.ApplyHeaderProfile(), and falls back to HeaderOrder. Headers
+// missing from the order are sent after the ordered ones, sorted by name.
+//
+// HTTPS requests use HTTP/2 when the server supports it, with the pseudo
+// headers sent in PseudoHeaderOrder. Each request uses its own connection,
+// which is closed when the context of the request is done, and proxies are
+// not supported.
+//
+// Use it with Browser.SetTransport():
+//
+//	bow.SetTransport(&browser.OrderedTransport{})
+//	bow.ApplyHeaderProfile(headers.BrowserLike("en-US"))
+type OrderedTransport struct {
+	// HeaderOrder is the order of the headers of requests which have none.
+	HeaderOrder []string
+
+	// PseudoHeaderOrder is the order of the HTTP/2 pseudo headers. Nil uses
+	// DefaultPseudoHeaderOrder.
+	PseudoHeaderOrder []string
+
+	// TLSClientConfig is the TLS configuration of HTTPS connections.
+	TLSClientConfig *tls.Config
+
+	// DialContext dials the connections. Nil uses a net.Dialer.
+	DialContext func(ctx context.Context, network, addr string) (net.Conn, error)
+
+	// TLSHandshakeTimeout is the maximum time of the TLS handshake, or 0
+	// for no limit other than the request context.
+	TLSHandshakeTimeout time.Duration
+
+	// ResponseHeaderTimeout is the maximum time to wait for the response
+	// headers once the request is written, or 0 for no limit.
+	ResponseHeaderTimeout time.Duration
+
+	// ExpectContinueTimeout is the time to wait for a 100 Continue response
+	// before sending the body of an HTTP/1.1 request with the Expect:
+	// 100-continue header. Zero uses DefaultExpectContinueTimeout, and a
+	// negative value sends the body right away.
+	ExpectContinueTimeout time.Duration
+
+	// ForceHTTP1 disables HTTP/2.
+	ForceHTTP1 bool
+
+	// DisableCompression stops the transport from requesting gzip encoded
+	// responses, and decoding them, when the request has no Accept-Encoding
+	// header.
+	DisableCompression bool
+}
+
+// headerField is a header as written on the wire.
+type headerField struct {
+	name  string
+	value string
+}
+
+// RoundTrip sends the request and returns its response.
+func (t *OrderedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+		return nil, errors.New("Unsupported protocol scheme '%s'.", req.URL.Scheme)
+	}
+	if err := validateHeader(req); err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+	conn, proto, err := t.dial(req)
+	if err != nil {
+		return nil, err
+	}
+	gzipped := !t.DisableCompression && req.Header.Get("Accept-Encoding") == "" &&
+		req.Header.Get("Range") == "" && req.Method != "HEAD"
+
+	ctx := req.Context()
+	stop := watchContext(ctx, conn)
+	var resp *http.Response
+	if proto == "h2" {
+		resp, err = t.roundTripH2(conn, req, gzipped)
+	} else {
+		resp, err = t.roundTripH1(conn, req, gzipped)
+	}
+	if err != nil {
+		stop()
+		conn.Close()
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return nil, err
+	}
+	resp.Body = &ctxBody{ReadCloser: resp.Body, ctx: ctx, stop: stop}
+	if gzipped && strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		resp.Body = &gzipBody{body: resp.Body}
+		resp.Header.Del("Content-Encoding")
+		resp.Header.Del("Content-Length")
+		resp.ContentLength = -1
+		resp.Uncompressed = true
+	}
+	return resp, nil
+}
+
+// watchContext closes the connection once the context is done, so the
+// reads and writes blocked on it return, and returns the function which
+// stops watching.
+func watchContext(ctx context.Context, conn net.Conn) func() {
+	if ctx.Done() == nil {
+		return func() {}
+	}
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// validateHeader returns an error when the host or a header of the request
+// is not valid, as they are written on the wire unchanged, and could
+// otherwise inject headers or requests.
+func validateHeader(req *http.Request) error {
+	if !httpguts.ValidHostHeader(requestHost(req)) {
+		return errors.New("Invalid Host header '%s'.", requestHost(req))
+	}
+	for name, values := range req.Header {
+		if !httpguts.ValidHeaderFieldName(name) {
+			return errors.New("Invalid header name '%s'.", name)
+		}
+		for _, v := range values {
+			if !httpguts.ValidHeaderFieldValue(v) {
+				return errors.New("Invalid value for the header '%s'.", name)
+			}
+		}
+	}
+	return nil
+}
+
+// dial opens the connection of the request, returning the negotiated
+// protocol, "h2" for HTTP/2.
+func (t *OrderedTransport) dial(req *http.Request) (net.Conn, string, error) {
+	ctx := req.Context()
+	host, port := req.URL.Hostname(), req.URL.Port()
+	if port == "" {
+		port = "80"
+		if req.URL.Scheme == "https" {
+			port = "443"
+		}
+	}
+	dial := t.DialContext
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+	conn, err := dial(ctx, "tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return nil, "", err
+	}
+	if req.URL.Scheme != "https" {
+		return conn, "http/1.1", nil
+	}
+
+	cfg := &tls.Config{}
+	if t.TLSClientConfig != nil {
+		cfg = t.TLSClientConfig.Clone()
+	}
+	if cfg.ServerName == "" {
+		cfg.ServerName = host
+	}
+	cfg.NextProtos = []string{"h2", "http/1.1"}
+	if t.ForceHTTP1 {
+		cfg.NextProtos = []string{"http/1.1"}
+	}
+	tc := tls.Client(conn, cfg)
+	if t.TLSHandshakeTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, t.TLSHandshakeTimeout)
+		defer cancel()
+	}
+	if err := tc.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, "", err
+	}
+	return tc, tc.ConnectionState().NegotiatedProtocol, nil
+}
+
+// setHeaderDeadline limits the time to read the response headers from conn
+// to ResponseHeaderTimeout.
+func (t *OrderedTransport) setHeaderDeadline(conn net.Conn) {
+	if t.ResponseHeaderTimeout > 0 {
+		conn.SetReadDeadline(time.Now().Add(t.ResponseHeaderTimeout))
+	}
+}
+
+// orderedFields returns the headers of the request in the order they are
+// written, including the given extra headers, eg Host.
+func (t *OrderedTransport) orderedFields(req *http.Request, extra ...headerField) []headerField {
+	order := HeaderOrder(req)
+	if order == nil {
+		order = t.HeaderOrder
+	}
+	rank := make(map[string]int, len(order))
+	for i, name := range order {
+		rank[http.CanonicalHeaderKey(name)] = i
+	}
+
+	var fields []headerField
+	for _, f := range extra {
+		if f.value != "" {
+			fields = append(fields, f)
+		}
+	}
+	for name, values := range req.Header {
+		if name == "Host" {
+			continue
+		}
+		for _, v := range values {
+			fields = append(fields, headerField{name: name, value: v})
+		}
+	}
+	sort.SliceStable(fields, func(i, j int) bool {
+		ri, iok := rank[http.CanonicalHeaderKey(fields[i].name)]
+		rj, jok := rank[http.CanonicalHeaderKey(fields[j].name)]
+		switch {
+		case iok && jok:
+			return ri < rj
+		case iok != jok:
+			return iok
+		}
+		return fields[i].name < fields[j].name
+	})
+	return fields
+}
+
+// requestHost returns the Host header of the request.
+func requestHost(req *http.Request) string {
+	if req.Host != "" {
+		return req.Host
+	}
+	return req.URL.Host
+}
+
+// roundTripH1 sends the request with HTTP/1.1 on the connection.
+func (t *OrderedTransport) roundTripH1(conn net.Conn, req *http.Request, gzipped bool) (*http.Response, error) {
+	extra := []headerField{{name: "Host", value: requestHost(req)}}
+	chunked := false
+	if req.Body != nil && req.Body != http.NoBody {
+		if req.ContentLength > 0 {
+			extra = append(extra, headerField{name: "Content-Length", value: strconv.FormatInt(req.ContentLength, 10)})
+		} else {
+			chunked = true
+			extra = append(extra, headerField{name: "Transfer-Encoding", value: "chunked"})
+		}
+	} else if req.Method == "POST" || req.Method == "PUT" || req.Method == "PATCH" {
+		extra = append(extra, headerField{name: "Content-Length", value: "0"})
+	}
+	if gzipped {
+		extra = append(extra, headerField{name: "Accept-Encoding", value: "gzip"})
+	}
+
+	w := bufio.NewWriter(conn)
+	fmt.Fprintf(w, "%s %s HTTP/1.1\r\n", req.Method, req.URL.RequestURI())
+	for _, f := range t.orderedFields(req, extra...) {
+		fmt.Fprintf(w, "%s: %s\r\n", f.name, f.value)
+	}
+	w.WriteString("\r\n")
+	br := bufio.NewReader(conn)
+	hasBody := req.Body != nil && req.Body != http.NoBody
+	if hasBody && t.expectContinueTimeout() > 0 && strings.EqualFold(req.Header.Get("Expect"), "100-continue") {
+		if err := w.Flush(); err != nil {
+			return nil, err
+		}
+		resp, err := t.awaitContinue(conn, br, req)
+		if err != nil {
+			return nil, err
+		}
+		if resp != nil {
+			// The server answered without the body, which is not sent.
+			req.Body.Close()
+			resp.Body = &connBody{ReadCloser: resp.Body, conn: conn}
+			return resp, nil
+		}
+	}
+	if hasBody {
+		var bw io.Writer = w
+		var cw *chunkedWriter
+		if chunked {
+			cw = &chunkedWriter{w: w}
+			bw = cw
+		}
+		_, err := io.Copy(bw, req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		if cw != nil {
+			w.WriteString("0\r\n\r\n")
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return nil, err
+	}
+
+	t.setHeaderDeadline(conn)
+	for {
+		resp, err := http.ReadResponse(br, req)
+		if err != nil {
+			return nil, err
+		}
+		// Skip the informational responses, eg 100 Continue.
+		if resp.StatusCode >= 100 && resp.StatusCode < 200 && resp.StatusCode != http.StatusSwitchingProtocols {
+			continue
+		}
+		conn.SetReadDeadline(time.Time{})
+		resp.Body = &connBody{ReadCloser: resp.Body, conn: conn}
+		return resp, nil
+	}
+}
+
+// expectContinueTimeout returns the time to wait for a 100 Continue response.
+func (t *OrderedTransport) expectContinueTimeout() time.Duration {
+	if t.ExpectContinueTimeout == 0 {
+		return DefaultExpectContinueTimeout
+	}
+	return t.ExpectContinueTimeout
+}
+
+// awaitContinue waits for the 100 Continue response to a request with the
+// Expect: 100-continue header. Returns the final response when the server
+// answers without waiting for the body, or nil when the body should be sent.
+func (t *OrderedTransport) awaitContinue(conn net.Conn, br *bufio.Reader, req *http.Request) (*http.Response, error) {
+	conn.SetReadDeadline(time.Now().Add(t.expectContinueTimeout()))
+	defer conn.SetReadDeadline(time.Time{})
+	// Wait for the first byte only, so a timeout never leaves a response
+	// half read.
+	if _, err := br.Peek(1); err != nil {
+		if ne, ok := err.(net.Error); ok && ne.Timeout() {
+			return nil, nil
+		}
+		return nil, err
+	}
+	conn.SetReadDeadline(time.Time{})
+	t.setHeaderDeadline(conn)
+	for {
+		resp, err := http.ReadResponse(br, req)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case resp.StatusCode == http.StatusContinue:
+			return nil, nil
+		case resp.StatusCode >= 100 && resp.StatusCode < 200 && resp.StatusCode != http.StatusSwitchingProtocols:
+			// Skip the other informational responses, eg 103 Early Hints.
+			continue
+		}
+		return resp, nil
+	}
+}
+
+// chunkedWriter writes the chunks of a chunked request body.
+type chunkedWriter struct {
+	w io.Writer
+}
+
+func (cw *chunkedWriter) Write(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	if _, err := fmt.Fprintf(cw.w, "%x\r\n", len(p)); err != nil {
+		return 0, err
+	}
+	n, err := cw.w.Write(p)
+	if err != nil {
+		return n, err
+	}
+	_, err = io.WriteString(cw.w, "\r\n")
+	return n, err
+}
+
+// connBody is a response body which closes its connection when closed.
+type connBody struct {
+	io.ReadCloser
+	conn net.Conn
+}
+
+func (b *connBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.conn.Close()
+	return err
+}
+
+// ctxBody is a response body whose reads fail with the error of the
+// context of the request once it's done, and which stops watching the
+// context when closed.
+type ctxBody struct {
+	io.ReadCloser
+	ctx  context.Context
+	stop func()
+}
+
+func (b *ctxBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err != io.EOF && b.ctx.Err() != nil {
+		err = b.ctx.Err()
+	}
+	return n, err
+}
+
+func (b *ctxBody) Close() error {
+	b.stop()
+	return b.ReadCloser.Close()
+}
+
+// gzipBody decodes a gzip encoded response body on the first read.
+type gzipBody struct {
+	body io.ReadCloser
+	zr   *gzip.Reader
+	err  error
+}
+
+func (b *gzipBody) Read(p []byte) (int, error) {
+	if b.zr == nil && b.err == nil {
+		b.zr, b.err = gzip.NewReader(b.body)
+	}
+	if b.err != nil {
+		return 0, b.err
+	}
+	return b.zr.Read(p)
+}
+
+func (b *gzipBody) Close() error {
+	return b.body.Close()
+}
+
+// HTTP/2 frame types and flags, see RFC 9113 section 6.
+const (
+	h2FrameData         = 0x0
+	h2FrameHeaders      = 0x1
+	h2FrameRSTStream    = 0x3
+	h2FrameSettings     = 0x4
+	h2FramePing         = 0x6
+	h2FrameGoAway       = 0x7
+	h2FrameWindowUpdate = 0x8
+	h2FrameContinuation = 0x9
+
+	h2FlagEndStream  = 0x1
+	h2FlagAck        = 0x1
+	h2FlagEndHeaders = 0x4
+	h2FlagPadded     = 0x8
+	h2FlagPriority   = 0x20
+
+	h2SettingEnablePush        = 0x2
+	h2SettingInitialWindowSize = 0x4
+	h2SettingMaxFrameSize      = 0x5
+
+	h2Preface       = "PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n"
+	h2DefaultWindow = 65535
+	h2DefaultFrame  = 16384
+
+	// h2Stream is the id of the only stream of the connections.
+	h2Stream = 1
+)
+
+// h2Conn is an HTTP/2 connection sending a single request.
+type h2Conn struct {
+	conn net.Conn
+	br   *bufio.Reader
+	dec  *hpack.Decoder
+
+	mu           sync.Mutex
+	sendWindow   int64
+	connWindow   int64
+	maxFrameSize int
+
+	// initialWindow is the initial stream window set by the server, whose
+	// changes apply to the window of the open stream.
+	initialWindow int64
+
+	// headers are the decoded response headers, and block the header block
+	// being read.
+	headers []hpack.HeaderField
+	block   []byte
+
+	resp      *http.Response
+	data      bytes.Buffer
+	endStream bool
+	err       error
+}
+
+// roundTripH2 sends the request with HTTP/2 on the connection.
+func (t *OrderedTransport) roundTripH2(conn net.Conn, req *http.Request, gzipped bool) (*http.Response, error) {
+	c := &h2Conn{
+		conn:          conn,
+		br:            bufio.NewReader(conn),
+		sendWindow:    h2DefaultWindow,
+		connWindow:    h2DefaultWindow,
+		maxFrameSize:  h2DefaultFrame,
+		initialWindow: h2DefaultWindow,
+	}
+	c.dec = hpack.NewDecoder(4096, func(f hpack.HeaderField) {
+		c.headers = append(c.headers, f)
+	})
+
+	settings := make([]byte, 6)
+	binary.BigEndian.PutUint16(settings, h2SettingEnablePush)
+	if _, err := io.WriteString(conn, h2Preface); err != nil {
+		return nil, err
+	}
+	if err := c.writeFrame(h2FrameSettings, 0, 0, settings); err != nil {
+		return nil, err
+	}
+
+	// Encode the header block, pseudo headers first.
+	var block bytes.Buffer
+	enc := hpack.NewEncoder(&block)
+	pseudo := map[string]string{
+		":method":    req.Method,
+		":authority": requestHost(req),
+		":scheme":    req.URL.Scheme,
+		":path":      req.URL.RequestURI(),
+	}
+	order := t.PseudoHeaderOrder
+	if order == nil {
+		order = DefaultPseudoHeaderOrder
+	}
+	for _, name := range order {
+		if v, ok := pseudo[name]; ok {
+			enc.WriteField(hpack.HeaderField{Name: name, Value: v})
+			delete(pseudo, name)
+		}
+	}
+	for _, name := range DefaultPseudoHeaderOrder {
+		if v, ok := pseudo[name]; ok {
+			enc.WriteField(hpack.HeaderField{Name: name, Value: v})
+		}
+	}
+	hasBody := req.Body != nil && req.Body != http.NoBody
+	var extra []headerField
+	if hasBody && req.ContentLength > 0 {
+		extra = append(extra, headerField{name: "Content-Length", value: strconv.FormatInt(req.ContentLength, 10)})
+	}
+	if gzipped {
+		extra = append(extra, headerField{name: "Accept-Encoding", value: "gzip"})
+	}
+	for _, f := range t.orderedFields(req, extra...) {
+		switch http.CanonicalHeaderKey(f.name) {
+		case "Connection", "Keep-Alive", "Proxy-Connection", "Transfer-Encoding", "Upgrade":
+			// Connection-specific headers are not allowed in HTTP/2.
+			continue
+		}
+		enc.WriteField(hpack.HeaderField{Name: strings.ToLower(f.name), Value: f.value})
+	}
+
+	flags := byte(0)
+	if !hasBody {
+		flags |= h2FlagEndStream
+	}
+	if err := c.writeHeaders(block.Bytes(), flags); err != nil {
+		return nil, err
+	}
+	if hasBody {
+		err := c.writeBody(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	t.setHeaderDeadline(conn)
+	for c.resp == nil {
+		if err := c.readFrame(); err != nil {
+			return nil, err
+		}
+	}
+	conn.SetReadDeadline(time.Time{})
+	c.resp.Request = req
+	c.resp.Body = &h2Body{c: c}
+	return c.resp, nil
+}
+
+// writeFrame writes a frame to the connection.
+func (c *h2Conn) writeFrame(typ, flags byte, stream uint32, payload []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	hdr := make([]byte, 9)
+	hdr[0], hdr[1], hdr[2] = byte(len(payload)>>16), byte(len(payload)>>8), byte(len(payload))
+	hdr[3], hdr[4] = typ, flags
+	binary.BigEndian.PutUint32(hdr[5:], stream&0x7fffffff)
+	if _, err := c.conn.Write(append(hdr, payload...)); err != nil {
+		return err
+	}
+	return nil
+}
+
+// writeHeaders writes the header block in a HEADERS frame, followed by
+// CONTINUATION frames when it's larger than the max frame size.
+func (c *h2Conn) writeHeaders(block []byte, flags byte) error {
+	typ := byte(h2FrameHeaders)
+	for {
+		chunk := block
+		if len(chunk) > c.maxFrameSize {
+			chunk = chunk[:c.maxFrameSize]
+		}
+		block = block[len(chunk):]
+		f := flags
+		if len(block) == 0 {
+			f |= h2FlagEndHeaders
+		}
+		if err := c.writeFrame(typ, f, h2Stream, chunk); err != nil {
+			return err
+		}
+		if len(block) == 0 {
+			return nil
+		}
+		typ, flags = h2FrameContinuation, 0
+	}
+}
+
+// writeBody writes the request body in DATA frames, waiting for the flow
+// control windows of the server as needed.
+func (c *h2Conn) writeBody(body io.Reader) error {
+	buf := make([]byte, h2DefaultFrame)
+	for {
+		for c.window() <= 0 {
+			if err := c.readFrame(); err != nil {
+				return err
+			}
+		}
+		n := len(buf)
+		if w := c.window(); int64(n) > w {
+			n = int(w)
+		}
+		n, err := body.Read(buf[:n])
+		if n > 0 {
+			c.mu.Lock()
+			c.sendWindow -= int64(n)
+			c.connWindow -= int64(n)
+			c.mu.Unlock()
+			if werr := c.writeFrame(h2FrameData, 0, h2Stream, buf[:n]); werr != nil {
+				return werr
+			}
+		}
+		if err == io.EOF {
+			return c.writeFrame(h2FrameData, h2FlagEndStream, h2Stream, nil)
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// window returns the number of bytes which may be sent on the stream.
+func (c *h2Conn) window() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.connWindow < c.sendWindow {
+		return c.connWindow
+	}
+	return c.sendWindow
+}
+
+// readFrame reads and handles the next frame of the connection.
+func (c *h2Conn) readFrame() error {
+	hdr := make([]byte, 9)
+	if _, err := io.ReadFull(c.br, hdr); err != nil {
+		return err
+	}
+	length := int(hdr[0])<<16 | int(hdr[1])<<8 | int(hdr[2])
+	typ, flags := hdr[3], hdr[4]
+	stream := binary.BigEndian.Uint32(hdr[5:]) & 0x7fffffff
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.br, payload); err != nil {
+		return err
+	}
+
+	switch typ {
+	case h2FrameSettings:
+		if flags&h2FlagAck != 0 {
+			return nil
+		}
+		for i := 0; i+6 <= len(payload); i += 6 {
+			v := binary.BigEndian.Uint32(payload[i+2:])
+			switch binary.BigEndian.Uint16(payload[i:]) {
+			case h2SettingInitialWindowSize:
+				if v > 1<<31-1 {
+					return errors.New("Invalid HTTP/2 initial window size %d.", v)
+				}
+				// The window of the stream changes by the difference, as
+				// part of it may have been used already.
+				c.mu.Lock()
+				c.sendWindow += int64(v) - c.initialWindow
+				c.initialWindow = int64(v)
+				c.mu.Unlock()
+			case h2SettingMaxFrameSize:
+				c.maxFrameSize = int(v)
+			}
+		}
+		return c.writeFrame(h2FrameSettings, h2FlagAck, 0, nil)
+	case h2FramePing:
+		if flags&h2FlagAck != 0 {
+			return nil
+		}
+		return c.writeFrame(h2FramePing, h2FlagAck, 0, payload)
+	case h2FrameWindowUpdate:
+		if len(payload) < 4 {
+			return errors.New("Invalid HTTP/2 WINDOW_UPDATE frame.")
+		}
+		inc := int64(binary.BigEndian.Uint32(payload) & 0x7fffffff)
+		c.mu.Lock()
+		if stream == 0 {
+			c.connWindow += inc
+		} else {
+			c.sendWindow += inc
+		}
+		c.mu.Unlock()
+		return nil
+	case h2FrameGoAway:
+		if len(payload) >= 8 && binary.BigEndian.Uint32(payload)&0x7fffffff >= h2Stream {
+			return nil
+		}
+		return errors.New("The HTTP/2 connection was closed by the server.")
+	case h2FrameRSTStream:
+		if stream == h2Stream {
+			return errors.New("The HTTP/2 stream was reset by the server.")
+		}
+		return nil
+	}
+	if stream != h2Stream {
+		return nil
+	}
+
+	switch typ {
+	case h2FrameHeaders, h2FrameContinuation:
+		if typ == h2FrameHeaders {
+			payload = h2Unpad(payload, flags)
+			if flags&h2FlagPriority != 0 && len(payload) >= 5 {
+				payload = payload[5:]
+			}
+			if flags&h2FlagEndStream != 0 {
+				c.endStream = true
+			}
+		}
+		c.block = append(c.block, payload...)
+		if flags&h2FlagEndHeaders == 0 {
+			return nil
+		}
+		c.headers = c.headers[:0]
+		_, err := c.dec.Write(c.block)
+		c.block = c.block[:0]
+		if err != nil {
+			return err
+		}
+		if c.resp == nil {
+			return c.setResponse()
+		}
+		// Trailers are ignored.
+		return nil
+	case h2FrameData:
+		data := h2Unpad(payload, flags)
+		c.data.Write(data)
+		if flags&h2FlagEndStream != 0 {
+			c.endStream = true
+		}
+		if length > 0 {
+			// Give the consumed bytes back to the server.
+			inc := make([]byte, 4)
+			binary.BigEndian.PutUint32(inc, uint32(length))
+			if err := c.writeFrame(h2FrameWindowUpdate, 0, 0, inc); err != nil {
+				return err
+			}
+			if !c.endStream {
+				return c.writeFrame(h2FrameWindowUpdate, 0, h2Stream, inc)
+			}
+		}
+	}
+	return nil
+}
+
+// setResponse creates the response from the decoded headers, skipping the
+// informational responses, eg 100 Continue.
+func (c *h2Conn) setResponse() error {
+	resp := &http.Response{
+		Proto:         "HTTP/2.0",
+		ProtoMajor:    2,
+		Header:        make(http.Header),
+		ContentLength: -1,
+	}
+	for _, f := range c.headers {
+		if f.Name == ":status" {
+			code, err := strconv.Atoi(f.Value)
+			if err != nil {
+				return errors.New("Invalid HTTP/2 status '%s'.", f.Value)
+			}
+			resp.StatusCode = code
+			continue
+		}
+		resp.Header.Add(f.Name, f.Value)
+	}
+	if resp.StatusCode >= 100 && resp.StatusCode < 200 {
+		c.endStream = false
+		return nil
+	}
+	resp.Status = fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+	if n, err := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64); err == nil {
+		resp.ContentLength = n
+	}
+	c.resp = resp
+	return nil
+}
+
+// h2Unpad returns the payload of a padded frame without its padding.
+func h2Unpad(payload []byte, flags byte) []byte {
+	if flags&h2FlagPadded == 0 || len(payload) == 0 {
+		return payload
+	}
+	pad := int(payload[0])
+	if pad >= len(payload) {
+		return nil
+	}
+	return payload[1 : len(payload)-pad]
+}
+
+// h2Body is the body of an HTTP/2 response, read from the DATA frames as
+// needed.
+type h2Body struct {
+	c *h2Conn
+}
+
+func (b *h2Body) Read(p []byte) (int, error) {
+	c := b.c
+	for c.data.Len() == 0 {
+		if c.endStream {
+			return 0, io.EOF
+		}
+		if c.err != nil {
+			return 0, c.err
+		}
+		c.err = c.readFrame()
+	}
+	return c.data.Read(p)
+}
+
+func (b *h2Body) Close() error {
+	return b.c.conn.Close()
+}
@@ -0,0 +1,269 @@
+package browser
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lostinblue/surf/headers"
+)
+
+func TestOrderedTransportHTTP1(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	names := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		r.ReadString('\n')
+		var order []string
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil || line == "\r\n" {
+				break
+			}
+			order = append(order, line[:strings.Index(line, ":")])
+		}
+		names <- order
+		io.WriteString(conn, "HTTP/1.1 200 OK\r\nContent-Length: 13\r\n\r\n<html></html>")
+	}()
+
+	bow := newDefaultTestBrowser()
+	bow.SetTransport(&OrderedTransport{DisableCompression: true})
+	bow.ApplyHeaderProfile(headers.New("test",
+		"Host", "",
+		"X-First", "1",
+		"User-Agent", "",
+		"Accept", "text/html",
+	))
+	if err := bow.GET("http://" + ln.Addr().String() + "/"); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"Host", "X-First", "User-Agent", "Accept"}
+	if order := <-names; !reflect.DeepEqual(order, expected) {
+		t.Errorf("Expected header order %v, got %v", expected, order)
+	}
+	if bow.Body() != "" || bow.StatusCode() != 200 {
+		t.Errorf("Expected an empty 200 page, got %d %q", bow.StatusCode(), bow.Body())
+	}
+}
+
+func TestOrderedTransportExpectContinue(t *testing.T) {
+	var bodies []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/reject" {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		b, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+	}))
+	defer ts.Close()
+
+	rt := &OrderedTransport{}
+	for _, path := range []string{"/accept", "/reject"} {
+		req, _ := http.NewRequest("PUT", ts.URL+path, strings.NewReader("payload"))
+		req.Header.Set("Expect", "100-continue")
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if path == "/reject" && resp.StatusCode != http.StatusRequestEntityTooLarge {
+			t.Errorf("Expected the rejection of the server, got %d", resp.StatusCode)
+		}
+	}
+	if len(bodies) != 1 || bodies[0] != "payload" {
+		t.Errorf("Expected the body to be sent once accepted, got %q", bodies)
+	}
+}
+
+func TestOrderedTransportHTTP2(t *testing.T) {
+	large := strings.Repeat("surf", 50000)
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor != 2 {
+			t.Errorf("Expected an HTTP/2 request, got %s", r.Proto)
+		}
+		if r.Header.Get("X-First") != "1" {
+			t.Errorf("Expected the X-First header, got %v", r.Header)
+		}
+		b, _ := io.ReadAll(r.Body)
+		if r.Method == "POST" && string(b) != large {
+			t.Errorf("Expected a %d bytes body, got %d", len(large), len(b))
+		}
+		if r.URL.Path == "/gzip" {
+			w.Header().Set("Content-Encoding", "gzip")
+			zw := gzip.NewWriter(w)
+			zw.Write([]byte("compressed"))
+			zw.Close()
+			return
+		}
+		io.WriteString(w, large)
+	}))
+	ts.EnableHTTP2 = true
+	ts.StartTLS()
+	defer ts.Close()
+
+	rt := &OrderedTransport{
+		TLSClientConfig: ts.Client().Transport.(*http.Transport).TLSClientConfig,
+		HeaderOrder:     []string{"X-First"},
+	}
+	for _, body := range []string{"", large} {
+		method := "GET"
+		if body != "" {
+			method = "POST"
+		}
+		req, _ := http.NewRequest(method, ts.URL+"/", strings.NewReader(body))
+		req.Header.Set("X-First", "1")
+		resp, err := rt.RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.ProtoMajor != 2 || !bytes.Equal(b, []byte(large)) {
+			t.Errorf("Expected a %d bytes HTTP/2 response, got %d bytes with %s", len(large), len(b), resp.Proto)
+		}
+	}
+
+	req, _ := http.NewRequest("GET", ts.URL+"/gzip", nil)
+	req.Header.Set("X-First", "1")
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(b) != "compressed" || !resp.Uncompressed {
+		t.Errorf("Expected the body to be decompressed, got %q", b)
+	}
+}
+
+func TestOrderedTransportContext(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	hang := make(chan struct{})
+	defer close(hang)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				br := bufio.NewReader(conn)
+				req, err := http.ReadRequest(br)
+				if err != nil {
+					return
+				}
+				if req.URL.Path == "/body" {
+					io.WriteString(conn, "HTTP/1.1 200 OK\r\nContent-Length: 100\r\n\r\npartial")
+				}
+				<-hang
+			}()
+		}
+	}()
+
+	tr := &OrderedTransport{}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", "http://"+ln.Addr().String()+"/", nil)
+	start := time.Now()
+	if _, err := tr.RoundTrip(req); err != context.DeadlineExceeded {
+		t.Errorf("Expected the deadline to abandon the request, got %v", err)
+	}
+	if d := time.Since(start); d > 2*time.Second {
+		t.Errorf("Expected the request to end with its context, took %v", d)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	req, _ = http.NewRequestWithContext(ctx, "GET", "http://"+ln.Addr().String()+"/body", nil)
+	resp, err := tr.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	time.AfterFunc(50*time.Millisecond, cancel)
+	if _, err := ioutil.ReadAll(resp.Body); err != context.Canceled {
+		t.Errorf("Expected the body read to be canceled, got %v", err)
+	}
+}
+
+func TestOrderedTransportInvalidHeader(t *testing.T) {
+	rt := &OrderedTransport{}
+	for _, h := range []http.Header{
+		{"X-Bad": {"1\r\nX-Injected: 1"}},
+		{"X-Bad\r\nX-Injected": {"1"}},
+	} {
+		req, _ := http.NewRequest("GET", "http://127.0.0.1:1/", nil)
+		req.Header = h
+		if _, err := rt.RoundTrip(req); err == nil || !strings.Contains(err.Error(), "Invalid") {
+			t.Errorf("Expected an invalid header error for %q, got %v", h, err)
+		}
+	}
+	req, _ := http.NewRequest("GET", "http://127.0.0.1:1/", nil)
+	req.Host = "example.com\r\nX-Injected: 1"
+	if _, err := rt.RoundTrip(req); err == nil || !strings.Contains(err.Error(), "Invalid Host") {
+		t.Errorf("Expected an invalid host error, got %v", err)
+	}
+}
+
+func TestH2InitialWindowSize(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	go io.Copy(io.Discard, server)
+
+	settings := func(size uint32) []byte {
+		return []byte{0, 0, 6, h2FrameSettings, 0, 0, 0, 0, 0,
+			0, h2SettingInitialWindowSize, byte(size >> 24), byte(size >> 16), byte(size >> 8), byte(size)}
+	}
+	var frames bytes.Buffer
+	frames.Write(settings(100000))
+	frames.Write(settings(1000))
+	c := &h2Conn{
+		conn:          client,
+		br:            bufio.NewReader(&frames),
+		sendWindow:    h2DefaultWindow - 60000,
+		connWindow:    1 << 20,
+		initialWindow: h2DefaultWindow,
+	}
+
+	// The window of the stream, of which 60000 bytes were sent, changes by
+	// the difference with the previous initial size.
+	if err := c.readFrame(); err != nil {
+		t.Fatal(err)
+	}
+	if w := c.window(); w != 100000-60000 {
+		t.Errorf("Expected a window of %d, got %d", 100000-60000, w)
+	}
+	if err := c.readFrame(); err != nil {
+		t.Fatal(err)
+	}
+	if w := c.window(); w != 1000-60000 {
+		t.Errorf("Expected a negative window of %d, got %d", 1000-60000, w)
+	}
+}
@@ -0,0 +1,144 @@
+package browser
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/lostinblue/surf/errors"
+)
+
+// nextLinkText matches the text of links commonly used to go to the next page.
+var nextLinkText = regexp.MustCompile(`(?i)^\s*(next( page)?|older|more)?\s*(›|»|>|>>|→)?\s*$`)
+
+// NextPage loads the next page of a paginated listing.
+//
+// The given expression is used as a CSS selector matching the link to the next
+// page. When the selector matches nothing it's used as a rel value instead,
+// eg "next". When the expression is empty, the page is searched for a
+// rel="next" link, and then for a link with a text such as "Next page" or "»".
+//
+// Returns a LinkNotFound error when the page does not have a next link.
+func (bow *Browser) NextPage(selectorOrRel string) error {
+	href, err := bow.nextPageURL(selectorOrRel)
+	if err != nil {
+		return err
+	}
+	return bow.httpGET(href, bow.URL())
+}
+
+// nextPageURL returns the URL of the next page link.
+func (bow *Browser) nextPageURL(expr string) (*url.URL, error) {
+	var sel *goquery.Selection
+	if expr != "" {
+		sel = bow.Find(expr).Filter("[href]")
+		if sel.Length() == 0 {
+			sel = bow.findRel(expr)
+		}
+	} else {
+		if link := bow.HeaderLink("next"); link != nil {
+			return link.URL, nil
+		}
+		sel = bow.findRel("next")
+		if sel.Length() == 0 {
+			sel = bow.Find("a[href]").FilterFunction(func(_ int, s *goquery.Selection) bool {
+				text := strings.TrimSpace(s.Text())
+				return text != "" && nextLinkText.MatchString(text)
+			})
+		}
+	}
+	if sel.Length() == 0 {
+		return nil, errors.NewLinkNotFound("No next page link found on '%s'.", bow.URL())
+	}
+	return bow.attrToResolvedURL("href", sel.First())
+}
+
+// findRel returns the link and anchor elements with the given rel value.
+func (bow *Browser) findRel(rel string) *goquery.Selection {
+	return bow.Find("link[href],a[href]").FilterFunction(func(_ int, s *goquery.Selection) bool {
+		for _, r := range strings.Fields(strings.ToLower(s.AttrOr("rel", ""))) {
+			if r == strings.ToLower(rel) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Paginator iterates over the pages of a paginated listing.
+//
+// Use it the same way as a bufio.Scanner:
+//
+//	p := bow.Paginate("a.next")
+//	for p.Next() {
+//		p.Dom().Find(".result").Each(...)
+//	}
+//	if err := p.Err(); err != nil {
+//		...
+//	}
+type Paginator struct {
+	bow     *Browser
+	expr    string
+	started bool
+	seen    map[string]bool
+	err     error
+}
+
+// Paginate returns a *Paginator which starts at the current page and follows
+// the next page links matched by the given expression. See NextPage() for the
+// meaning of the expression.
+func (bow *Browser) Paginate(selectorOrRel string) *Paginator {
+	return &Paginator{
+		bow:  bow,
+		expr: selectorOrRel,
+		seen: make(map[string]bool),
+	}
+}
+
+// Next advances to the next page, which is the current page on the first call.
+//
+// Returns false when there are no more pages, a page was already visited by
+// the paginator, or an error occurred.
+func (p *Paginator) Next() bool {
+	if p.err != nil {
+		return false
+	}
+	if !p.started {
+		p.started = true
+		if p.bow.URL() == nil {
+			p.err = errors.NewPageNotLoaded("Cannot paginate, no page has been loaded.")
+			return false
+		}
+		p.seen[p.bow.URL().String()] = true
+		return true
+	}
+
+	href, err := p.bow.nextPageURL(p.expr)
+	if err != nil {
+		if _, ok := err.(errors.LinkNotFound); !ok {
+			p.err = err
+		}
+		return false
+	}
+	if p.seen[href.String()] {
+		return false
+	}
+	if err = p.bow.httpGET(href, p.bow.URL()); err != nil {
+		p.err = err
+		return false
+	}
+	p.seen[href.String()] = true
+	p.seen[p.bow.URL().String()] = true
+	return true
+}
+
+// Dom returns the document of the current page.
+func (p *Paginator) Dom() *goquery.Document {
+	return p.bow.DOM()
+}
+
+// Err returns the first error which stopped the paginator, if any.
+func (p *Paginator) Err() error {
+	return p.err
+}
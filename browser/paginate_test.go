@@ -0,0 +1,69 @@
+package browser
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+func TestPaginate(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		if page == 0 {
+			page = 1
+		}
+		next := ""
+		switch r.URL.Path {
+		case "/rel":
+			if page < 3 {
+				next = fmt.Sprintf(`<link rel="next" href="/rel?page=%d">`, page+1)
+			}
+		case "/text":
+			if page < 3 {
+				next = fmt.Sprintf(`<a href="/text?page=%d">Next page »</a>`, page+1)
+			}
+		case "/loop":
+			next = `<a class="next" href="/loop?page=1">Next</a>`
+		}
+		fmt.Fprintf(w, `<html><head>%s</head><body><p class="page">%d</p></body></html>`, next, page)
+	}))
+	defer ts.Close()
+
+	for _, path := range []string{"/rel", "/text"} {
+		bow := newDefaultTestBrowser()
+		if err := bow.GET(ts.URL + path); err != nil {
+			t.Fatal(err)
+		}
+		pages := ""
+		p := bow.Paginate("")
+		for p.Next() {
+			pages += p.Dom().Find(".page").Text()
+		}
+		if p.Err() != nil {
+			t.Errorf("Unexpected pagination error: %s", p.Err())
+		}
+		if pages != "123" {
+			t.Errorf("Expected pages '123' for %s, got '%s'", path, pages)
+		}
+		if _, ok := bow.NextPage("").(errors.LinkNotFound); !ok {
+			t.Errorf("Expected LinkNotFound on the last page of %s", path)
+		}
+	}
+
+	bow := newDefaultTestBrowser()
+	if err := bow.GET(ts.URL + "/loop?page=1"); err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	p := bow.Paginate("a.next")
+	for p.Next() {
+		count++
+	}
+	if count != 1 {
+		t.Errorf("Expected paginator to stop on a loop, got %d pages", count)
+	}
+}
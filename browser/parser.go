@@ -0,0 +1,147 @@
+package browser
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/lostinblue/surf/errors"
+	"golang.org/x/net/html"
+)
+
+// DefaultParserLimits is the global value for the parser limits, which does
+// not limit the documents.
+var DefaultParserLimits = ParserLimits{}
+
+// metaRefreshCandidate matches the bodies which may contain a meta refresh,
+// so other pages don't need to be parsed after each request.
+var metaRefreshCandidate = regexp.MustCompile(`(?i)http-equiv`)
+
+// voidElements are the elements which never have an end tag.
+var voidElements = map[string]bool{
+	"area": true, "base": true, "br": true, "col": true, "embed": true,
+	"hr": true, "img": true, "input": true, "link": true, "meta": true,
+	"param": true, "source": true, "track": true, "wbr": true,
+}
+
+// ParserLimits restricts the size of the documents parsed by the browser, to
+// protect crawlers from huge or maliciously nested pages.
+type ParserLimits struct {
+	// MaxNodes is the maximum number of elements in a document, or 0 for no limit.
+	MaxNodes int
+
+	// MaxDepth is the maximum nesting depth of elements, or 0 for no limit.
+	MaxDepth int
+}
+
+// SetParserLimits sets the limits applied when parsing pages.
+func (bow *Browser) SetParserLimits(l ParserLimits) {
+	bow.parserLimits = l
+}
+
+// ParserLimits returns the limits applied when parsing pages.
+func (bow *Browser) ParserLimits() ParserLimits {
+	return bow.parserLimits
+}
+
+// DOMError returns the error of parsing the current page, eg a ParseLimit
+// error when the page exceeds the parser limits. The page is parsed first
+// when it has not been yet.
+//
+// DOM() returns an empty document for pages which failed to parse.
+func (bow *Browser) DOMError() error {
+	bow.dom()
+	return bow.domErr
+}
+
+// dom returns the document of the current page, parsing the body on the
+// first call. Pages are not parsed after each request, so crawlers which
+// only read headers or status codes don't pay for it.
+//
+// Returns nil when no page has been loaded, and an empty document when the
+// ParseDOM attribute is false.
+func (bow *Browser) dom() *goquery.Document {
+	if bow.state.Dom != nil || bow.state.Body == nil {
+		return bow.state.Dom
+	}
+	if !bow.parsesDOM() {
+		return emptyDocument()
+	}
+	dom, err := parseDocument(bow.state.Body, bow.parserLimits)
+	if err != nil {
+		bow.domErr = err
+		dom = emptyDocument()
+	}
+	bow.state.Dom = dom
+	return dom
+}
+
+// page returns the document of the current page, or an empty document when
+// no page has been loaded, eg after going back to the blank state of a new
+// browser, so the accessors such as Title() and Find() don't panic.
+func (bow *Browser) page() *goquery.Document {
+	if doc := bow.dom(); doc != nil {
+		return doc
+	}
+	return emptyDocument()
+}
+
+// emptyDocument returns a document without nodes.
+func emptyDocument() *goquery.Document {
+	return goquery.NewDocumentFromNode(&html.Node{Type: html.DocumentNode})
+}
+
+// parsesDOM returns whether the pages are parsed, which they are unless the
+// ParseDOM attribute is set to false.
+func (bow *Browser) parsesDOM() bool {
+	v, ok := bow.attributes[ParseDOM]
+	return v || !ok
+}
+
+// parseDocument parses the body after checking it's within the limits.
+func parseDocument(body []byte, l ParserLimits) (*goquery.Document, error) {
+	if l.MaxNodes > 0 || l.MaxDepth > 0 {
+		if err := checkParserLimits(body, l); err != nil {
+			return nil, err
+		}
+	}
+	return goquery.NewDocumentFromReader(bytes.NewReader(body))
+}
+
+// checkParserLimits tokenizes the body, which is much cheaper than building
+// the tree, and returns an error when it exceeds the limits.
+//
+// The depth is counted from the tags found in the body, so it's approximate
+// for malformed pages with missing end tags.
+func checkParserLimits(body []byte, l ParserLimits) error {
+	z := html.NewTokenizer(bytes.NewReader(body))
+	nodes, depth := 0, 0
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if z.Err() == io.EOF {
+				return nil
+			}
+			return z.Err()
+		case html.StartTagToken:
+			nodes++
+			name, _ := z.TagName()
+			if !voidElements[string(name)] {
+				depth++
+			}
+		case html.SelfClosingTagToken:
+			nodes++
+		case html.EndTagToken:
+			if depth > 0 {
+				depth--
+			}
+		}
+		if l.MaxNodes > 0 && nodes > l.MaxNodes {
+			return errors.NewParseLimit("The document has more than %d elements.", l.MaxNodes)
+		}
+		if l.MaxDepth > 0 && depth > l.MaxDepth {
+			return errors.NewParseLimit("The document is nested deeper than %d elements.", l.MaxDepth)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/lostinblue/surf/errors"
+)
+
+func TestLazyParsing(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><head><title>Lazy</title></head><body><p>Hi</p></body></html>"))
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if bow.StatusCode() != 200 {
+		t.Errorf("Expected status 200, got %d", bow.StatusCode())
+	}
+	if bow.state.Dom != nil {
+		t.Error("Expected the page not to be parsed before it's used")
+	}
+	if bow.Title() != "Lazy" {
+		t.Errorf("Expected the title 'Lazy', got '%s'", bow.Title())
+	}
+	if bow.state.Dom == nil || bow.DOMError() != nil {
+		t.Error("Expected the page to be parsed")
+	}
+}
+
+func TestNoPage(t *testing.T) {
+	bow := newDefaultTestBrowser()
+	if bow.Title() != "" || bow.Body() != "" || bow.Find("a").Length() != 0 {
+		t.Error("Expected empty values when no page has been loaded")
+	}
+	if _, ok := bow.Click("a").(errors.PageNotLoaded); !ok {
+		t.Error("Expected Click to return a PageNotLoaded error")
+	}
+	if _, err := bow.Form("form"); err == nil {
+		t.Error("Expected Form to return an error")
+	} else if _, ok := err.(errors.PageNotLoaded); !ok {
+		t.Errorf("Expected Form to return a PageNotLoaded error, got %v", err)
+	}
+}
+
+func TestParserLimits(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/deep":
+			w.Write([]byte("<html><body>" + strings.Repeat("<div>", 50) + "deep" + strings.Repeat("</div>", 50) + "</body></html>"))
+		case "/wide":
+			w.Write([]byte("<html><body>" + strings.Repeat("<p>a</p><br><img src='a.png'>", 100) + "</body></html>"))
+		}
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	bow.SetParserLimits(ParserLimits{MaxNodes: 200, MaxDepth: 20})
+
+	if err := bow.GET(ts.URL + "/deep"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := bow.DOMError().(errors.ParseLimit); !ok {
+		t.Errorf("Expected a ParseLimit error, got %v", bow.DOMError())
+	}
+	if bow.Find("div").Length() != 0 {
+		t.Error("Expected an empty document")
+	}
+
+	if err := bow.GET(ts.URL + "/wide"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := bow.DOMError().(errors.ParseLimit); !ok {
+		t.Errorf("Expected a ParseLimit error, got %v", bow.DOMError())
+	}
+
+	bow.SetParserLimits(ParserLimits{MaxNodes: 400, MaxDepth: 20})
+	if err := bow.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if bow.DOMError() != nil {
+		t.Errorf("Expected no error, got %v", bow.DOMError())
+	}
+	if bow.Find("p").Length() != 100 {
+		t.Errorf("Expected 100 paragraphs, got %d", bow.Find("p").Length())
+	}
+}
+
+func TestParseDOMDisabled(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Version", "1")
+		w.Write([]byte(`<html><head><title>API</title><meta http-equiv="refresh" content="0; url=/next"></head></html>`))
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	bow.SetAttribute(ParseDOM, false)
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if bow.StatusCode() != 200 || bow.ResponseHeaders().Get("X-Version") != "1" {
+		t.Errorf("Expected the status and headers, got %d %v", bow.StatusCode(), bow.ResponseHeaders())
+	}
+	if !strings.Contains(string(bow.body), "<title>API</title>") {
+		t.Errorf("Expected the raw body, got %q", bow.body)
+	}
+	if bow.Title() != "" || bow.state.Dom != nil {
+		t.Error("Expected the page not to be parsed")
+	}
+	if bow.PendingRefresh() != nil {
+		t.Error("Expected no meta refresh without parsing")
+	}
+	if err := bow.MutateDom(func(*goquery.Document) {}); err == nil {
+		t.Error("Expected an error mutating an unparsed page")
+	}
+
+	bow.SetAttribute(ParseDOM, true)
+	if bow.Title() != "API" {
+		t.Errorf("Expected the page to be parsed once enabled, got %q", bow.Title())
+	}
+}
@@ -0,0 +1,54 @@
+package browser
+
+import (
+	"time"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+// PollUntil reloads the current page every interval until an element
+// matches the given expression, eg to wait for a job status page to show
+// the result.
+//
+// Returns a Timeout error when no element matches before the timeout.
+func (bow *Browser) PollUntil(expr string, interval, timeout time.Duration) error {
+	return bow.PollUntilFunc(func(b Browsable) bool {
+		return b.Exists(expr)
+	}, interval, timeout)
+}
+
+// PollUntilFunc reloads the current page every interval until the given
+// predicate returns true. The predicate is called with the current page
+// first, so no request is sent when it's already true.
+//
+// Failed reloads are retried at the next interval. Returns a Timeout error,
+// which includes the last reload error, when the predicate is still false
+// after the timeout.
+func (bow *Browser) PollUntilFunc(done func(b Browsable) bool, interval, timeout time.Duration) error {
+	if bow.state.Request == nil {
+		return errors.NewPageNotLoaded("Cannot poll, no page has been loaded.")
+	}
+	deadline := time.Now().Add(timeout)
+	var lastErr error
+	for {
+		if lastErr == nil && done(bow) {
+			return nil
+		}
+		wait := interval
+		if left := time.Until(deadline); left < wait {
+			wait = left
+		}
+		if wait <= 0 {
+			break
+		}
+		time.Sleep(wait)
+		if time.Now().After(deadline) {
+			break
+		}
+		lastErr = bow.Reload()
+	}
+	if lastErr != nil {
+		return errors.NewTimeout("Condition not met after %s, last error: %s", timeout, lastErr)
+	}
+	return errors.NewTimeout("Condition not met after %s.", timeout)
+}
@@ -0,0 +1,44 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+func TestPollUntil(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) < 3 {
+			w.Write([]byte(`<html><body><p class="status">pending</p></body></html>`))
+			return
+		}
+		w.Write([]byte(`<html><body><p class="status done">done</p></body></html>`))
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	if err := bow.PollUntil(".done", time.Millisecond, time.Second); err == nil {
+		t.Error("Expected an error when no page has been loaded")
+	}
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if err := bow.PollUntil(".done", 5*time.Millisecond, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt32(&hits) != 3 {
+		t.Errorf("Expected 3 requests, got %d", hits)
+	}
+
+	err := bow.PollUntilFunc(func(b Browsable) bool {
+		return b.Find(".status").Text() == "never"
+	}, 5*time.Millisecond, 30*time.Millisecond)
+	if _, ok := err.(errors.Timeout); !ok {
+		t.Errorf("Expected a Timeout error, got %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package browser
+
+import (
+	"net/http"
+
+	"github.com/lostinblue/surf/profiles"
+)
+
+// SetProfiles sets the site profiles applied to the requests, or nil to
+// remove them. The profile of the first matching host patterns applies to
+// each request: its headers, cookies and credentials are also applied to
+// redirects, while its delay and proxy apply to the first request only.
+func (bow *Browser) SetProfiles(s *profiles.Set) {
+	bow.profiles = s
+}
+
+// Profiles returns the site profiles applied to the requests.
+func (bow *Browser) Profiles() *profiles.Set {
+	return bow.profiles
+}
+
+// applyProfile sets the headers, cookies and credentials of the profile
+// matching the request host, and returns the profile, or nil.
+func (bow *Browser) applyProfile(req *http.Request, o *requestOptions) *profiles.Profile {
+	p := bow.profiles.Match(req.URL.Hostname())
+	if p == nil {
+		return nil
+	}
+	for name, value := range p.Headers {
+		if _, ok := o.headers[http.CanonicalHeaderKey(name)]; !ok {
+			req.Header.Set(name, value)
+		}
+	}
+	if p.Username != "" && req.Header.Get("Authorization") == "" {
+		req.SetBasicAuth(p.Username, p.Password)
+	}
+	if cj := bow.CookieJar(); cj != nil && len(p.Cookies) > 0 {
+		have := make(map[string]bool)
+		for _, c := range cj.Cookies(req.URL) {
+			have[c.Name] = true
+		}
+		var add []*http.Cookie
+		for _, c := range p.Cookies {
+			if !have[c.Name] {
+				add = append(add, &http.Cookie{Name: c.Name, Value: c.Value, Path: cookiePath(c)})
+			}
+		}
+		if len(add) > 0 {
+			cj.SetCookies(req.URL, add)
+		}
+	}
+	return p
+}
+
+// redirectProfile applies the profile of the redirect host. The client
+// copies the headers of the previous request, so the headers set by the
+// profile of the previous host are restored to the browser headers first.
+func (bow *Browser) redirectProfile(req, prev *http.Request, o *requestOptions) {
+	if p := bow.profiles.Match(prev.URL.Hostname()); p != nil && p != bow.profiles.Match(req.URL.Hostname()) {
+		for name := range p.Headers {
+			name = http.CanonicalHeaderKey(name)
+			if _, ok := o.headers[name]; ok {
+				continue
+			}
+			if v, ok := bow.headers[name]; ok {
+				req.Header[name] = v
+			} else {
+				req.Header.Del(name)
+			}
+		}
+	}
+	bow.applyProfile(req, o)
+}
+
+// cookiePath returns the path of the profile cookie.
+func cookiePath(c profiles.Cookie) string {
+	if c.Path == "" {
+		return "/"
+	}
+	return c.Path
+}
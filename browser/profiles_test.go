@@ -0,0 +1,70 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lostinblue/surf/jar"
+	"github.com/lostinblue/surf/profiles"
+)
+
+func TestProfiles(t *testing.T) {
+	var req *http.Request
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req = r
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, strings.Replace(r.Host, "127.0.0.1", "http://localhost", 1)+"/", http.StatusFound)
+			return
+		}
+		w.Write([]byte(`<html><body>Page</body></html>`))
+	}))
+	defer ts.Close()
+
+	set, err := profiles.Load(strings.NewReader(`{"profiles": [
+		{"name": "local", "hosts": ["127.0.0.1"], "headers": {"Accept-Language": "fr"},
+		 "cookies": [{"name": "lang", "value": "fr"}], "username": "user", "password": "secret"},
+		{"name": "other", "hosts": ["localhost"], "headers": {"X-Site": "other"}}
+	]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bow := newDefaultTestBrowser()
+	bow.SetCookieJar(jar.NewCookiesJar())
+	bow.SetProfiles(set)
+	if err := bow.GET(ts.URL, WithHeader("Accept-Language", "de")); err != nil {
+		t.Fatal(err)
+	}
+	if v := req.Header.Get("Accept-Language"); v != "de" {
+		t.Errorf("Expected the request header to be kept, got %q", v)
+	}
+	if u, p, ok := req.BasicAuth(); !ok || u != "user" || p != "secret" {
+		t.Errorf("Expected the profile credentials, got %q %q", u, p)
+	}
+	if c, err := req.Cookie("lang"); err != nil || c.Value != "fr" {
+		t.Errorf("Expected the profile cookie, got %v", c)
+	}
+
+	if err := bow.GET(ts.URL + "/redirect"); err != nil {
+		t.Fatal(err)
+	}
+	if req.Host == ts.Listener.Addr().String() {
+		t.Fatal("Expected the request to be redirected to localhost")
+	}
+	if v := req.Header.Get("X-Site"); v != "other" {
+		t.Errorf("Expected the profile of the redirect host, got %q", v)
+	}
+	if v := req.Header.Get("Accept-Language"); v == "fr" {
+		t.Error("Expected the headers of the first profile not to be sent to another host")
+	}
+
+	bow.SetProfiles(nil)
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, ok := req.BasicAuth(); ok {
+		t.Error("Expected no credentials without profiles")
+	}
+}
@@ -0,0 +1,150 @@
+package browser
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// defaultQuery is a query string value added to the requests to the
+// matching hosts.
+type defaultQuery struct {
+	key   string
+	value string
+	hosts []string
+	match Matcher
+}
+
+// SetDefaultQuery sets a query string value added to the URL of every
+// request the browser sends to the given hosts, eg an API key or a locale.
+// Host patterns use path.Match syntax as with MatchHost(), and no hosts
+// matches every host. Setting the same key for the same hosts again replaces
+// the value.
+//
+// Values already in the request URL, or set with the WithQuery() option, are
+// kept, and WithoutDefaultQuery() skips the defaults of a single request.
+func (bow *Browser) SetDefaultQuery(key, value string, hosts ...string) {
+	d := defaultQuery{key: key, value: value, hosts: hosts, match: MatchAll()}
+	if len(hosts) > 0 {
+		d.match = MatchHost(hosts...)
+	}
+	for i, q := range bow.defaultQuery {
+		if q.key == key && equalStrings(q.hosts, hosts) {
+			bow.defaultQuery[i] = d
+			return
+		}
+	}
+	bow.defaultQuery = append(bow.defaultQuery, d)
+}
+
+// DelDefaultQuery deletes the default query string values with the given
+// key, for every host.
+func (bow *Browser) DelDefaultQuery(key string) {
+	kept := bow.defaultQuery[:0]
+	for _, q := range bow.defaultQuery {
+		if q.key != key {
+			kept = append(kept, q)
+		}
+	}
+	bow.defaultQuery = kept
+}
+
+// WithoutDefaultQuery skips the default query string values with the given
+// keys for this request only, or every default when no keys are given.
+func WithoutDefaultQuery(keys ...string) RequestOption {
+	return func(o *requestOptions) {
+		if len(keys) == 0 {
+			o.noDefaultQuery = true
+			return
+		}
+		o.skipQuery = append(o.skipQuery, keys...)
+	}
+}
+
+// addDefaultQuery adds the default query string values matching the request
+// URL, unless the URL or the request options already set them.
+func (bow *Browser) addDefaultQuery(req *http.Request, o *requestOptions) {
+	if len(bow.defaultQuery) == 0 || o.noDefaultQuery {
+		return
+	}
+	q := req.URL.Query()
+	add := make(url.Values)
+	for _, d := range bow.defaultQuery {
+		if _, ok := q[d.key]; ok || !d.match(req.URL) {
+			continue
+		}
+		if _, ok := o.query[d.key]; ok || containsString(o.skipQuery, d.key) {
+			continue
+		}
+		add.Set(d.key, d.value)
+	}
+	appendQuery(req.URL, add)
+}
+
+// appendQuery appends the values to the query string of the URL, keeping the
+// order and encoding of the values already in it, which signed URLs and some
+// servers depend on.
+func appendQuery(u *url.URL, values url.Values) {
+	if len(values) == 0 {
+		return
+	}
+	if u.RawQuery == "" {
+		u.RawQuery = values.Encode()
+		return
+	}
+	u.RawQuery += "&" + values.Encode()
+}
+
+// setQuery sets the query string value of the key in the URL. The first
+// value of the key is replaced in place and the others are removed, while the
+// other values keep their order and encoding, as with appendQuery().
+func setQuery(u *url.URL, key, value string) {
+	pair := url.QueryEscape(key) + "=" + url.QueryEscape(value)
+	pairs := make([]string, 0)
+	set := false
+	for _, p := range strings.Split(u.RawQuery, "&") {
+		if p == "" {
+			continue
+		}
+		k := p
+		if i := strings.IndexByte(p, '='); i >= 0 {
+			k = p[:i]
+		}
+		if k, err := url.QueryUnescape(k); err == nil && k == key {
+			if set {
+				continue
+			}
+			p, set = pair, true
+		}
+		pairs = append(pairs, p)
+	}
+	if !set {
+		pairs = append(pairs, pair)
+	}
+	u.RawQuery = strings.Join(pairs, "&")
+}
+
+// equalStrings returns a boolean value indicating whether both slices hold
+// the same strings in the same order.
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// containsString returns a boolean value indicating whether the slice holds
+// the string.
+func containsString(a []string, s string) bool {
+	for _, v := range a {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestDefaultQuery(t *testing.T) {
+	var query url.Values
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+		w.Write([]byte("<html></html>"))
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	bow.SetDefaultQuery("key", "secret", "127.0.0.1")
+	bow.SetDefaultQuery("lang", "en")
+	bow.SetDefaultQuery("lang", "fr")
+	bow.SetDefaultQuery("other", "1", "example.com")
+
+	tests := []struct {
+		u        string
+		opts     []RequestOption
+		expected url.Values
+	}{
+		{ts.URL, nil, url.Values{"key": {"secret"}, "lang": {"fr"}}},
+		{ts.URL + "?lang=de", nil, url.Values{"key": {"secret"}, "lang": {"de"}}},
+		{ts.URL, []RequestOption{WithQuery("key", "mine")}, url.Values{"key": {"mine"}, "lang": {"fr"}}},
+		{ts.URL, []RequestOption{WithoutDefaultQuery("key")}, url.Values{"lang": {"fr"}}},
+		{ts.URL, []RequestOption{WithoutDefaultQuery()}, url.Values{}},
+	}
+	for _, tt := range tests {
+		if err := bow.GET(tt.u, tt.opts...); err != nil {
+			t.Fatal(err)
+		}
+		if query.Encode() != tt.expected.Encode() {
+			t.Errorf("Expected query %q for %s, got %q", tt.expected.Encode(), tt.u, query.Encode())
+		}
+	}
+
+	// The query string of the URL is kept as is.
+	var raw string
+	ts2 := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw = r.URL.RawQuery
+		w.Write([]byte("<html></html>"))
+	}))
+	defer ts2.Close()
+	if err := bow.GET(ts2.URL + "?z=1&a=%7e&sig=x"); err != nil {
+		t.Fatal(err)
+	}
+	if raw != "z=1&a=%7e&sig=x&key=secret&lang=fr" {
+		t.Errorf("Expected the default values after the query string, got %q", raw)
+	}
+
+	bow.DelDefaultQuery("lang")
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if query.Encode() != "key=secret" {
+		t.Errorf("Expected query key=secret, got %q", query.Encode())
+	}
+}
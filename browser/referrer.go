@@ -0,0 +1,181 @@
+package browser
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ReferrerPolicy controls the Referer header sent with requests, as defined
+// by https://www.w3.org/TR/referrer-policy/.
+type ReferrerPolicy string
+
+const (
+	// NoReferrer never sends the Referer header.
+	NoReferrer ReferrerPolicy = "no-referrer"
+
+	// NoReferrerWhenDowngrade sends the full URL, except from https to http.
+	NoReferrerWhenDowngrade ReferrerPolicy = "no-referrer-when-downgrade"
+
+	// SameOrigin sends the full URL to the same origin only.
+	SameOrigin ReferrerPolicy = "same-origin"
+
+	// OriginOnly sends the origin of the page, eg "https://example.com/".
+	OriginOnly ReferrerPolicy = "origin"
+
+	// StrictOrigin sends the origin of the page, except from https to http.
+	StrictOrigin ReferrerPolicy = "strict-origin"
+
+	// OriginWhenCrossOrigin sends the full URL to the same origin, and the
+	// origin to other origins.
+	OriginWhenCrossOrigin ReferrerPolicy = "origin-when-cross-origin"
+
+	// StrictOriginWhenCrossOrigin sends the full URL to the same origin, and
+	// the origin to other origins, except from https to http.
+	StrictOriginWhenCrossOrigin ReferrerPolicy = "strict-origin-when-cross-origin"
+
+	// UnsafeURL always sends the full URL.
+	UnsafeURL ReferrerPolicy = "unsafe-url"
+)
+
+// DefaultReferrerPolicy is the global value for the referrer policy, which
+// is the default policy of web browsers.
+var DefaultReferrerPolicy = StrictOriginWhenCrossOrigin
+
+// referrerCandidate matches the bodies which may contain a referrer meta tag,
+// so other pages don't need to be parsed before each request.
+var referrerCandidate = regexp.MustCompile(`(?i)referrer`)
+
+// referrerPolicies are the valid policy names. "origin-only" is accepted as
+// an alias of "origin".
+var referrerPolicies = map[string]ReferrerPolicy{
+	string(NoReferrer):                  NoReferrer,
+	string(NoReferrerWhenDowngrade):     NoReferrerWhenDowngrade,
+	string(SameOrigin):                  SameOrigin,
+	string(OriginOnly):                  OriginOnly,
+	"origin-only":                       OriginOnly,
+	string(StrictOrigin):                StrictOrigin,
+	string(OriginWhenCrossOrigin):       OriginWhenCrossOrigin,
+	string(StrictOriginWhenCrossOrigin): StrictOriginWhenCrossOrigin,
+	string(UnsafeURL):                   UnsafeURL,
+}
+
+// WithReferrerPolicy sets the referrer policy of this request only.
+func WithReferrerPolicy(p ReferrerPolicy) RequestOption {
+	return func(o *requestOptions) {
+		o.referrerPolicy = p
+	}
+}
+
+// SetReferrerPolicy sets the referrer policy of the browser. Pages may
+// override it with the Referrer-Policy header or a referrer meta tag, and
+// clicked links with their referrerpolicy attribute.
+//
+// No Referer header is sent when the SendReferer attribute is false.
+func (bow *Browser) SetReferrerPolicy(p ReferrerPolicy) {
+	bow.referrerPolicy = p
+}
+
+// ReferrerPolicy returns the referrer policy of the browser.
+func (bow *Browser) ReferrerPolicy() ReferrerPolicy {
+	return bow.referrerPolicy
+}
+
+// pageReferrerPolicy returns the policy of the current page, set with a
+// referrer meta tag or the Referrer-Policy header, or the browser policy.
+func (bow *Browser) pageReferrerPolicy() ReferrerPolicy {
+	if bow.state == nil || bow.state.Response == nil {
+		return bow.referrerPolicy
+	}
+	if referrerCandidate.Match(bow.state.Body) {
+		var policy ReferrerPolicy
+		bow.Find("meta[name='referrer' i]").Each(func(_ int, s *goquery.Selection) {
+			content, _ := s.Attr("content")
+			if p, ok := parseReferrerPolicy(content); ok {
+				policy = p
+			}
+		})
+		if policy != "" {
+			return policy
+		}
+	}
+	if p, ok := parseReferrerPolicy(bow.state.Response.Header.Get("Referrer-Policy")); ok {
+		return p
+	}
+	return bow.referrerPolicy
+}
+
+// elementReferrerPolicy returns the policy set on the element with the
+// referrerpolicy attribute, or a rel="noreferrer" attribute.
+func elementReferrerPolicy(sel *goquery.Selection) (ReferrerPolicy, bool) {
+	for _, rel := range strings.Fields(strings.ToLower(sel.AttrOr("rel", ""))) {
+		if rel == "noreferrer" {
+			return NoReferrer, true
+		}
+	}
+	return parseReferrerPolicy(sel.AttrOr("referrerpolicy", ""))
+}
+
+// parseReferrerPolicy returns the last valid policy of the comma separated
+// list, as the Referrer-Policy header allows fallbacks.
+func parseReferrerPolicy(value string) (ReferrerPolicy, bool) {
+	var policy ReferrerPolicy
+	for _, name := range strings.Split(value, ",") {
+		if p, ok := referrerPolicies[strings.ToLower(strings.TrimSpace(name))]; ok {
+			policy = p
+		}
+	}
+	return policy, policy != ""
+}
+
+// referrerFor returns the Referer header sent from the ref page to the
+// target URL with the given policy, or an empty string when none is sent.
+func referrerFor(ref, target *url.URL, policy ReferrerPolicy) string {
+	if ref == nil || (ref.Scheme != "http" && ref.Scheme != "https") {
+		return ""
+	}
+	full := *ref
+	full.User = nil
+	full.Fragment = ""
+	origin := url.URL{Scheme: ref.Scheme, Host: ref.Host, Path: "/"}
+	sameOrigin := ref.Scheme == target.Scheme && strings.EqualFold(ref.Host, target.Host)
+	downgrade := ref.Scheme == "https" && target.Scheme != "https"
+
+	switch policy {
+	case NoReferrer:
+		return ""
+	case NoReferrerWhenDowngrade:
+		if downgrade {
+			return ""
+		}
+		return full.String()
+	case SameOrigin:
+		if sameOrigin {
+			return full.String()
+		}
+		return ""
+	case OriginOnly:
+		return origin.String()
+	case StrictOrigin:
+		if downgrade {
+			return ""
+		}
+		return origin.String()
+	case OriginWhenCrossOrigin:
+		if sameOrigin {
+			return full.String()
+		}
+		return origin.String()
+	case UnsafeURL:
+		return full.String()
+	}
+	if sameOrigin {
+		return full.String()
+	}
+	if downgrade {
+		return ""
+	}
+	return origin.String()
+}
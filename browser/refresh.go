@@ -0,0 +1,230 @@
+package browser
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+// MaxAutoRefreshes is the number of refreshes followed in a row by
+// AutoFollowRefreshBelow(), so pages refreshing to each other don't loop.
+var MaxAutoRefreshes = 10
+
+// Refresh is a refresh requested by the Refresh header or the refresh meta
+// tag of a page.
+//
+// The refresh is never followed in the background: it's followed by the
+// goroutine calling Wait() or Browser.FollowMetaRefresh(), so it doesn't race
+// with the other uses of the browser.
+type Refresh struct {
+	// URL is the page loaded by the refresh, or nil when the page reloads itself.
+	URL *url.URL
+
+	// Delay is the time the page asked to wait before refreshing.
+	Delay time.Duration
+
+	bow     *Browser
+	ref     *url.URL
+	due     time.Time
+	mu      sync.Mutex
+	started bool
+	done    chan struct{}
+	err     error
+}
+
+// Cancel stops the refresh. Returns false when the refresh has already been
+// followed or cancelled.
+func (r *Refresh) Cancel() bool {
+	if !r.start() {
+		return false
+	}
+	r.finish(errors.New("The refresh was cancelled."))
+	return true
+}
+
+// Wait blocks until the delay of the refresh has elapsed, follows it, and
+// returns the error of the refresh request, if any. When the refresh is
+// cancelled or followed by another goroutine, Wait returns once it's done.
+func (r *Refresh) Wait() error {
+	t := time.NewTimer(time.Until(r.due))
+	defer t.Stop()
+	select {
+	case <-r.done:
+		return r.err
+	case <-t.C:
+	}
+	return r.follow()
+}
+
+// Done returns a channel which is closed once the refresh has been followed
+// or cancelled.
+func (r *Refresh) Done() <-chan struct{} {
+	return r.done
+}
+
+// follow loads the page of the refresh right away.
+func (r *Refresh) follow() error {
+	if !r.start() {
+		<-r.done
+		return r.err
+	}
+	if r.bow.refresh == r {
+		r.bow.refresh = nil
+	}
+	if r.URL == nil {
+		r.finish(r.bow.Reload())
+	} else {
+		r.finish(r.bow.httpGET(r.URL, r.ref))
+	}
+	return r.err
+}
+
+// start marks the refresh as followed or cancelled. Returns false when it
+// already was.
+func (r *Refresh) start() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.started {
+		return false
+	}
+	r.started = true
+	return true
+}
+
+// finish records the result of the refresh and releases the waiters.
+func (r *Refresh) finish(err error) {
+	r.err = err
+	close(r.done)
+}
+
+// PendingRefresh returns the refresh requested by the current page, or nil
+// when there is none, or it has been followed or cancelled.
+func (bow *Browser) PendingRefresh() *Refresh {
+	if bow.refresh == nil {
+		return nil
+	}
+	select {
+	case <-bow.refresh.done:
+		return nil
+	default:
+		return bow.refresh
+	}
+}
+
+// CancelRefresh discards the refresh requested by the current page.
+func (bow *Browser) CancelRefresh() {
+	if bow.refresh != nil {
+		bow.refresh.Cancel()
+		bow.refresh = nil
+	}
+}
+
+// FollowMetaRefresh follows the refresh requested by the current page right
+// away, without waiting for its delay. Use PendingRefresh().Wait() to honor
+// the delay.
+//
+// Returns a PageNotLoaded error when the page did not request a refresh.
+func (bow *Browser) FollowMetaRefresh() error {
+	r := bow.PendingRefresh()
+	if r == nil {
+		return errors.NewPageNotLoaded("Cannot follow the refresh, the page did not request one.")
+	}
+	return r.follow()
+}
+
+// AutoFollowRefreshBelow sets the browser to follow the refreshes requested
+// with a delay lower than d as soon as the page is loaded, the way browsers
+// treat short refreshes as redirects. Longer refreshes, and the refreshes
+// reloading the page itself, eg on status pages, are left pending. Defaults to DefaultAutoFollowRefreshBelow, and 0 disables it.
+func (bow *Browser) AutoFollowRefreshBelow(d time.Duration) {
+	bow.autoRefreshBelow = d
+}
+
+// recordRefresh stores the refresh requested by the current page, when the
+// MetaRefreshHandling attribute is set, and follows it when its delay is
+// below the auto follow delay. The Refresh header takes precedence over the
+// refresh meta tag.
+func (bow *Browser) recordRefresh() error {
+	bow.CancelRefresh()
+	if !bow.attributes[MetaRefreshHandling] || bow.state.Response == nil {
+		return nil
+	}
+	value := bow.state.Response.Header.Get("Refresh")
+	if value == "" && isContentTypeHtml(bow.state.Response) && metaRefreshCandidate.Match(bow.body) {
+		value, _ = bow.Find("meta[http-equiv='refresh' i]").First().Attr("content")
+	}
+	if value == "" {
+		return nil
+	}
+	delay, target, ok := parseRefresh(value)
+	if !ok {
+		return nil
+	}
+	r := &Refresh{
+		Delay: delay,
+		bow:   bow,
+		ref:   bow.URL(),
+		due:   time.Now().Add(delay),
+		done:  make(chan struct{}),
+	}
+	if target != "" {
+		u, err := url.Parse(target)
+		if err != nil {
+			return nil
+		}
+		r.URL = bow.ResolveURL(u)
+	}
+	bow.refresh = r
+
+	if delay >= bow.autoRefreshBelow || bow.refreshDepth >= MaxAutoRefreshes || r.reloads() {
+		return nil
+	}
+	bow.refreshDepth++
+	defer func() { bow.refreshDepth-- }()
+	return bow.FollowMetaRefresh()
+}
+
+// reloads returns whether the refresh loads the page which requested it
+// again, no matter the fragment.
+func (r *Refresh) reloads() bool {
+	if r.URL == nil || r.ref == nil {
+		return true
+	}
+	u, ref := *r.URL, *r.ref
+	u.Fragment, u.RawFragment = "", ""
+	ref.Fragment, ref.RawFragment = "", ""
+	return u.String() == ref.String()
+}
+
+// parseRefresh parses the value of a Refresh header or meta tag, eg
+// "5; url=/next", and returns the delay and the URL, which is empty when the
+// page reloads itself.
+func parseRefresh(value string) (time.Duration, string, bool) {
+	value = strings.TrimSpace(value)
+	end := strings.IndexAny(value, ";,")
+	num := value
+	if end >= 0 {
+		num = value[:end]
+	}
+	secs, err := strconv.ParseFloat(strings.TrimSpace(num), 64)
+	if err != nil || secs < 0 {
+		return 0, "", false
+	}
+	delay := time.Duration(secs * float64(time.Second))
+	if end < 0 {
+		return delay, "", true
+	}
+
+	target := strings.TrimSpace(value[end+1:])
+	if len(target) >= 4 && strings.EqualFold(target[:3], "url") {
+		if rest := strings.TrimSpace(target[3:]); strings.HasPrefix(rest, "=") {
+			target = strings.TrimSpace(rest[1:])
+		}
+	}
+	target = strings.Trim(target, `"'`)
+	return delay, target, true
+}
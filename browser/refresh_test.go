@@ -0,0 +1,187 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFollowMetaRefresh(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Refresh", "0; url=/next")
+			w.Write([]byte(`<html><body>Redirecting</body></html>`))
+		case "/meta":
+			w.Write([]byte(`<html><head><meta http-equiv="refresh" content="0;URL='/next'"></head></html>`))
+		case "/later":
+			w.Header().Set("Refresh", "60")
+			w.Write([]byte(`<html><body>Later</body></html>`))
+		default:
+			w.Write([]byte(`<html><body>Next</body></html>`))
+		}
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	if err := bow.FollowMetaRefresh(); err == nil {
+		t.Error("Expected an error when no refresh is pending")
+	}
+	for _, path := range []string{"/", "/meta"} {
+		if err := bow.GET(ts.URL + path); err != nil {
+			t.Fatal(err)
+		}
+		r := bow.PendingRefresh()
+		if r == nil {
+			t.Fatalf("Expected a pending refresh for %s", path)
+		}
+		if r.URL.String() != ts.URL+"/next" {
+			t.Errorf("Expected refresh URL %s/next, got %s", ts.URL, r.URL)
+		}
+		if err := bow.FollowMetaRefresh(); err != nil {
+			t.Fatal(err)
+		}
+		if bow.URL().Path != "/next" {
+			t.Errorf("Expected to follow the refresh of %s, got %s", path, bow.URL())
+		}
+		if bow.PendingRefresh() != nil {
+			t.Error("Expected no pending refresh after following it")
+		}
+	}
+
+	if err := bow.GET(ts.URL + "/later"); err != nil {
+		t.Fatal(err)
+	}
+	r := bow.PendingRefresh()
+	if r == nil || r.URL != nil || r.Delay != time.Minute {
+		t.Fatalf("Expected a reload in a minute, got %+v", r)
+	}
+	bow.CancelRefresh()
+	if bow.PendingRefresh() != nil {
+		t.Error("Expected no pending refresh after cancelling it")
+	}
+	if r.Cancel() {
+		t.Error("Expected Cancel to report the refresh was already cancelled")
+	}
+	if err := r.Wait(); err == nil {
+		t.Error("Expected an error from a cancelled refresh")
+	}
+}
+
+func TestRefreshWait(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			w.Header().Set("Refresh", "0.05; url=/next")
+		}
+		w.Write([]byte(`<html><body>Page</body></html>`))
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	bow.AutoFollowRefreshBelow(0)
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	r := bow.PendingRefresh()
+	if r == nil {
+		t.Fatal("Expected a pending refresh")
+	}
+	start := time.Now()
+	if err := r.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) < 40*time.Millisecond {
+		t.Error("Expected Wait to honor the delay of the refresh")
+	}
+	if bow.URL().Path != "/next" {
+		t.Errorf("Expected Wait to follow the refresh, got %s", bow.URL())
+	}
+	select {
+	case <-r.Done():
+	default:
+		t.Error("Expected Done to be closed once the refresh was followed")
+	}
+}
+
+func TestAutoFollowRefreshBelow(t *testing.T) {
+	var hits int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		switch r.URL.Path {
+		case "/":
+			w.Header().Set("Refresh", "1; url=/next")
+		case "/status":
+			w.Write([]byte(`<html><head><meta http-equiv="refresh" content="2"></head></html>`))
+			return
+		case "/self":
+			w.Header().Set("Refresh", "0; url=/self#top")
+		case "/ping":
+			w.Header().Set("Refresh", "0; url=/pong")
+		case "/pong":
+			w.Header().Set("Refresh", "0; url=/ping")
+		case "/next":
+			w.Header().Set("Refresh", "30; url=/")
+		}
+		w.Write([]byte(`<html><body>Page</body></html>`))
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	bow.AutoFollowRefreshBelow(5 * time.Second)
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if bow.URL().Path != "/next" {
+		t.Errorf("Expected the short refresh to be followed, got %s", bow.URL())
+	}
+	if r := bow.PendingRefresh(); r == nil || r.Delay != 30*time.Second {
+		t.Errorf("Expected the long refresh to be pending, got %+v", r)
+	}
+
+	for _, path := range []string{"/status", "/self"} {
+		atomic.StoreInt32(&hits, 0)
+		if err := bow.GET(ts.URL + path); err != nil {
+			t.Fatal(err)
+		}
+		if n := atomic.LoadInt32(&hits); n != 1 {
+			t.Errorf("Expected the page reloading itself at %s not to be followed, got %d requests", path, n)
+		}
+		if bow.PendingRefresh() == nil {
+			t.Errorf("Expected the reload of %s to be pending", path)
+		}
+	}
+
+	atomic.StoreInt32(&hits, 0)
+	if err := bow.GET(ts.URL + "/ping"); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadInt32(&hits); n != int32(MaxAutoRefreshes+1) {
+		t.Errorf("Expected %d requests, got %d", MaxAutoRefreshes+1, n)
+	}
+	if bow.PendingRefresh() == nil {
+		t.Error("Expected the refresh to be pending after the limit")
+	}
+}
+
+func TestParseRefresh(t *testing.T) {
+	tests := []struct {
+		value string
+		delay time.Duration
+		url   string
+		ok    bool
+	}{
+		{"5", 5 * time.Second, "", true},
+		{"0; url=/next", 0, "/next", true},
+		{"1.5;URL='/x'", 1500 * time.Millisecond, "/x", true},
+		{"3, http://example.com/", 3 * time.Second, "http://example.com/", true},
+		{"soon", 0, "", false},
+	}
+	for _, tt := range tests {
+		delay, u, ok := parseRefresh(tt.value)
+		if delay != tt.delay || u != tt.url || ok != tt.ok {
+			t.Errorf("parseRefresh(%q) = %v, %q, %v", tt.value, delay, u, ok)
+		}
+	}
+}
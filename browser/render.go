@@ -0,0 +1,56 @@
+package browser
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/lostinblue/surf/errors"
+	"github.com/lostinblue/surf/render"
+)
+
+// SetRenderer sets the external renderer used by Screenshot() and Render(),
+// eg render.NewWkhtml() or render.NewCDP("http://127.0.0.1:9222").
+func (bow *Browser) SetRenderer(r render.Renderer) {
+	bow.renderer = r
+}
+
+// Renderer returns the external renderer used by Screenshot() and Render().
+func (bow *Browser) Renderer() render.Renderer {
+	return bow.renderer
+}
+
+// Screenshot writes a PNG image of the current page to w.
+func (bow *Browser) Screenshot(w io.Writer) error {
+	return bow.Render(w, render.PNG)
+}
+
+// Render hands the current page off to the renderer, which fetches it again
+// with the cookies and headers of the browser, and writes the result in the
+// given format to w.
+//
+// Returns an error when no page has been loaded or no renderer has been set.
+func (bow *Browser) Render(w io.Writer, format render.Format) error {
+	if bow.renderer == nil {
+		return errors.New("Cannot render the page, SetRenderer() has not been called.")
+	}
+	u := bow.URL()
+	if u == nil {
+		return errors.NewPageNotLoaded("Cannot render the page, no page has been loaded.")
+	}
+
+	header := make(http.Header, len(bow.headers)+1)
+	for name, values := range bow.headers {
+		header[name] = append([]string(nil), values...)
+	}
+	if bow.userAgent != "" {
+		header.Set("User-Agent", bow.userAgent)
+	}
+	p := &render.Page{
+		URL:    u.String(),
+		Header: header,
+	}
+	if bow.client != nil && bow.client.Jar != nil {
+		p.Cookies = bow.client.Jar.Cookies(u)
+	}
+	return bow.renderer.Render(p, format, w)
+}
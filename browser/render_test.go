@@ -0,0 +1,67 @@
+package browser
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lostinblue/surf/render"
+)
+
+// fakeRenderer records the page it's asked to render.
+type fakeRenderer struct {
+	page   *render.Page
+	format render.Format
+}
+
+func (r *fakeRenderer) Render(p *render.Page, format render.Format, w io.Writer) error {
+	r.page, r.format = p, format
+	_, err := w.Write([]byte(format.String()))
+	return err
+}
+
+func TestScreenshot(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc"})
+		w.Write([]byte("<html></html>"))
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	buff := &bytes.Buffer{}
+	if err := bow.Screenshot(buff); err == nil {
+		t.Error("Expected an error when no renderer is set")
+	}
+	r := &fakeRenderer{}
+	bow.SetRenderer(r)
+	if err := bow.Screenshot(buff); err == nil {
+		t.Error("Expected an error when no page has been loaded")
+	}
+
+	bow.AddRequestHeader("Accept-Language", "en")
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if err := bow.Screenshot(buff); err != nil {
+		t.Fatal(err)
+	}
+	if buff.String() != "png" || r.format != render.PNG {
+		t.Errorf("Expected a PNG render, got '%s'", buff.String())
+	}
+	if r.page.URL != ts.URL {
+		t.Errorf("Expected the URL %s, got %s", ts.URL, r.page.URL)
+	}
+	if len(r.page.Cookies) != 1 || r.page.Cookies[0].Value != "abc" {
+		t.Errorf("Expected the session cookie, got %v", r.page.Cookies)
+	}
+	if r.page.Header.Get("Accept-Language") != "en" || r.page.Header.Get("User-Agent") != bow.UserAgent() {
+		t.Errorf("Expected the browser headers, got %v", r.page.Header)
+	}
+
+	buff.Reset()
+	if err := bow.Render(buff, render.PDF); err != nil || buff.String() != "pdf" {
+		t.Errorf("Expected a PDF render, got '%s' %v", buff.String(), err)
+	}
+}
@@ -0,0 +1,166 @@
+package browser
+
+import (
+	"mime"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/lostinblue/surf/profiles"
+)
+
+// Report summarizes a navigation, for logging. It marshals to JSON, with
+// the durations formatted like "1.5s".
+type Report struct {
+	// URL is the final URL of the page, after the redirects.
+	URL string `json:"url"`
+
+	// Method is the method of the request.
+	Method string `json:"method"`
+
+	// Redirects are the responses redirecting to the page, in order,
+	// starting with the response to the requested URL.
+	Redirects []ReportRedirect `json:"redirects,omitempty"`
+
+	// StatusCode is the status code of the page.
+	StatusCode int `json:"status_code"`
+
+	// ContentType is the media type of the page, without parameters.
+	ContentType string `json:"content_type,omitempty"`
+
+	// Charset is the declared charset of the page, see Charset().
+	Charset string `json:"charset,omitempty"`
+
+	// Sent is the time the navigation started, and TimeToHeaders and
+	// LoadTime the times taken to receive the headers and the whole body.
+	// They are zero for the pages which were not requested, eg offline.
+	Sent          time.Time         `json:"sent"`
+	TimeToHeaders profiles.Duration `json:"time_to_headers"`
+	LoadTime      profiles.Duration `json:"load_time"`
+
+	// BodySize is the size of the body of the page, once decoded.
+	BodySize int64 `json:"body_size"`
+
+	// ContentEncoding and EncodedSize are the encoding the body was received
+	// with, and its size before it was decoded, see EncodedSize().
+	ContentEncoding string `json:"content_encoding,omitempty"`
+	EncodedSize     int64  `json:"encoded_size"`
+
+	// Links, Forms, Images, Stylesheets and Scripts are the numbers of
+	// elements of each kind in the page.
+	Links       int `json:"links"`
+	Forms       int `json:"forms"`
+	Images      int `json:"images"`
+	Stylesheets int `json:"stylesheets"`
+	Scripts     int `json:"scripts"`
+
+	// Warnings describe the problems found with the page, eg a body which
+	// is not valid in its charset, or which could not be parsed.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// ReportRedirect is a redirection of a navigation.
+type ReportRedirect struct {
+	// URL is the redirected URL.
+	URL string `json:"url"`
+
+	// StatusCode is the status code of the redirection, eg 301.
+	StatusCode int `json:"status_code"`
+}
+
+// Report returns a summary of the last navigation, or nil when no page has
+// been loaded. The page is parsed to count its elements, unless the
+// ParseDOM attribute is false.
+func (bow *Browser) Report() *Report {
+	resp := bow.state.Response
+	if resp == nil {
+		return nil
+	}
+	r := &Report{
+		StatusCode:      resp.StatusCode,
+		Charset:         bow.Charset(),
+		Sent:            bow.state.Sent,
+		TimeToHeaders:   profiles.Duration(bow.state.TimeToHeaders),
+		LoadTime:        profiles.Duration(bow.state.LoadTime),
+		BodySize:        int64(len(bow.state.Body)),
+		ContentEncoding: bow.state.ContentEncoding,
+		EncodedSize:     bow.state.EncodedSize,
+	}
+	if u := bow.URL(); u != nil {
+		r.URL = u.String()
+	}
+	if req := bow.state.Request; req != nil {
+		r.Method = req.Method
+	}
+	for req := resp.Request; req != nil && req.Response != nil; req = req.Response.Request {
+		redirect := ReportRedirect{StatusCode: req.Response.StatusCode}
+		if req.Response.Request != nil {
+			redirect.URL = req.Response.Request.URL.String()
+		}
+		r.Redirects = append([]ReportRedirect{redirect}, r.Redirects...)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		r.Warnings = append(r.Warnings, "The response has no Content-Type header.")
+	} else if mt, _, err := mime.ParseMediaType(contentType); err == nil {
+		r.ContentType = mt
+	} else {
+		r.Warnings = append(r.Warnings, "The Content-Type header is invalid: "+err.Error()+".")
+	}
+
+	if bow.parsesDOM() && (r.ContentType == "" || r.ContentType == "text/html" || r.ContentType == "application/xhtml+xml") {
+		if err := bow.DOMError(); err != nil {
+			r.Warnings = append(r.Warnings, "The page could not be parsed: "+err.Error())
+		}
+		r.Warnings = append(r.Warnings, bow.charsetWarnings()...)
+		r.Links = len(bow.Links())
+		r.Forms = bow.Find("form").Length()
+		r.Images = len(bow.Images())
+		r.Stylesheets = len(bow.Stylesheets())
+		r.Scripts = len(bow.Scripts())
+	}
+	return r
+}
+
+// charsetWarnings returns the problems with the charset of the page: a
+// missing declaration for a body which is not ASCII, conflicting
+// declarations, or a body which is not valid UTF-8 when it's declared so.
+func (bow *Browser) charsetWarnings() []string {
+	var warnings []string
+	body := bow.state.Body
+	header := ""
+	if _, params, err := mime.ParseMediaType(bow.state.Response.Header.Get("Content-Type")); err == nil {
+		header = params["charset"]
+	}
+	meta := metaCharset(bow.dom())
+	charset := header
+	if charset == "" {
+		charset = meta
+	}
+	switch {
+	case charset == "" && !isASCII(body):
+		warnings = append(warnings, "The charset of the page is not declared.")
+	case header != "" && meta != "" && normalizeCharset(header) != normalizeCharset(meta):
+		warnings = append(warnings, "The charset of the Content-Type header, "+header+", differs from the charset of the meta tags, "+meta+".")
+	}
+	if (charset == "" || normalizeCharset(charset) == "utf8") && !utf8.Valid(body) {
+		warnings = append(warnings, "The body of the page is not valid UTF-8.")
+	}
+	return warnings
+}
+
+// normalizeCharset returns the charset name in lower case without dashes
+// and underscores, eg "utf8" for "UTF-8".
+func normalizeCharset(cs string) string {
+	return strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(strings.TrimSpace(cs)))
+}
+
+// isASCII returns whether the bytes are ASCII characters.
+func isASCII(b []byte) bool {
+	for _, c := range b {
+		if c >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,71 @@
+package browser
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReport(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/old":
+			http.Redirect(w, r, "/moved", http.StatusMovedPermanently)
+		case "/moved":
+			http.Redirect(w, r, "/page", http.StatusFound)
+		case "/page":
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write([]byte(`<html><head><meta charset="iso-8859-1"><link rel="stylesheet" href="/s.css">
+				<script src="/a.js"></script></head><body><a href="/a">A</a> <a href="/b">B</a>
+				<img src="/i.png"><form><input name="q"></form>Caf` + "\xe9" + `</body></html>`))
+		case "/plain":
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("text"))
+		}
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	if r := bow.Report(); r != nil {
+		t.Errorf("Expected no report before the first navigation, got %+v.", r)
+	}
+	if err := bow.GET(ts.URL + "/old"); err != nil {
+		t.Fatal(err)
+	}
+	r := bow.Report()
+	if r.URL != ts.URL+"/page" || r.Method != "GET" || r.StatusCode != 200 || r.ContentType != "text/html" || r.Charset != "utf-8" {
+		t.Errorf("Unexpected report %+v.", r)
+	}
+	redirects := []ReportRedirect{{ts.URL + "/old", 301}, {ts.URL + "/moved", 302}}
+	if len(r.Redirects) != 2 || r.Redirects[0] != redirects[0] || r.Redirects[1] != redirects[1] {
+		t.Errorf("Expected the redirects %v, got %v.", redirects, r.Redirects)
+	}
+	if r.Sent.IsZero() || r.TimeToHeaders <= 0 || r.LoadTime < r.TimeToHeaders {
+		t.Errorf("Expected the timings of the navigation, got %v, %v, %v.", r.Sent, r.TimeToHeaders, r.LoadTime)
+	}
+	if r.BodySize != int64(len(bow.state.Body)) || r.Links != 2 || r.Forms != 1 || r.Images != 1 || r.Stylesheets != 1 || r.Scripts != 1 {
+		t.Errorf("Unexpected sizes and counts %+v.", r)
+	}
+	if len(r.Warnings) != 2 || !strings.Contains(r.Warnings[0], "iso-8859-1") || !strings.Contains(r.Warnings[1], "UTF-8") {
+		t.Errorf("Expected the charset warnings, got %q.", r.Warnings)
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{`"url":"` + ts.URL + `/page"`, `"status_code":301`, `"links":2`, `"time_to_headers":"`} {
+		if !strings.Contains(string(b), expected) {
+			t.Errorf("Expected the JSON report to contain %s, got %s.", expected, b)
+		}
+	}
+
+	if err := bow.GET(ts.URL + "/plain"); err != nil {
+		t.Fatal(err)
+	}
+	r = bow.Report()
+	if r.ContentType != "text/plain" || len(r.Redirects) != 0 || r.Links != 0 || len(r.Warnings) != 0 {
+		t.Errorf("Unexpected report of a text page %+v.", r)
+	}
+}
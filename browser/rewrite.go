@@ -0,0 +1,109 @@
+package browser
+
+import (
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+// Matcher reports whether a rewrite rule applies to the given request URL.
+type Matcher func(u *url.URL) bool
+
+// RewriteAction changes the given request URL before the request is sent.
+// Returning an error blocks the request, and the error is returned to the caller.
+type RewriteAction func(u *url.URL) error
+
+// rewriteRule pairs a Matcher with the action applied to matching URLs.
+type rewriteRule struct {
+	match  Matcher
+	action RewriteAction
+}
+
+// AddRewriteRule adds a rule which is applied to every request the browser
+// sends, including redirects. Rules are applied in the order they were added,
+// and each rule sees the URL as changed by the previous rules.
+func (bow *Browser) AddRewriteRule(m Matcher, a RewriteAction) {
+	bow.rewrites = append(bow.rewrites, rewriteRule{match: m, action: a})
+}
+
+// ClearRewriteRules removes every rule added with AddRewriteRule.
+func (bow *Browser) ClearRewriteRules() {
+	bow.rewrites = nil
+}
+
+// rewriteRequest applies the rewrite rules to the URL of the given request.
+func (bow *Browser) rewriteRequest(req *http.Request) error {
+	host := req.URL.Host
+	for _, r := range bow.rewrites {
+		if !r.match(req.URL) {
+			continue
+		}
+		if err := r.action(req.URL); err != nil {
+			return err
+		}
+	}
+	if req.URL.Host != host && req.Host == host {
+		req.Host = req.URL.Host
+	}
+	return nil
+}
+
+// MatchAll returns a Matcher which matches every URL.
+func MatchAll() Matcher {
+	return func(_ *url.URL) bool {
+		return true
+	}
+}
+
+// MatchHost returns a Matcher which matches URLs whose host name matches one
+// of the given patterns. Patterns use path.Match syntax, eg "*.example.com".
+func MatchHost(patterns ...string) Matcher {
+	return func(u *url.URL) bool {
+		host := strings.ToLower(u.Hostname())
+		for _, p := range patterns {
+			if ok, _ := path.Match(strings.ToLower(p), host); ok {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// MatchRegexp returns a Matcher which matches URLs whose string form matches
+// the given regular expression.
+func MatchRegexp(re *regexp.Regexp) Matcher {
+	return func(u *url.URL) bool {
+		return re.MatchString(u.String())
+	}
+}
+
+// Block returns a RewriteAction which prevents the request from being sent.
+func Block() RewriteAction {
+	return func(u *url.URL) error {
+		return errors.NewBlocked("Request to '%s' blocked by rewrite rule.", u.String())
+	}
+}
+
+// RewriteHost returns a RewriteAction which sends the request to the given
+// host instead, eg a mirror or CDN. The host may include a port.
+func RewriteHost(host string) RewriteAction {
+	return func(u *url.URL) error {
+		u.Host = host
+		return nil
+	}
+}
+
+// ForceHTTPS returns a RewriteAction which upgrades http URLs to https.
+func ForceHTTPS() RewriteAction {
+	return func(u *url.URL) error {
+		if u.Scheme == "http" {
+			u.Scheme = "https"
+			u.Host = strings.TrimSuffix(u.Host, ":80")
+		}
+		return nil
+	}
+}
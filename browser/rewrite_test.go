@@ -0,0 +1,63 @@
+package browser
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+func TestRewriteRules(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.URL.Path)
+	}))
+	defer ts.Close()
+	tsURL, _ := url.Parse(ts.URL)
+
+	bow := newDefaultTestBrowser()
+	bow.AddRewriteRule(MatchHost("mirror.example.com"), RewriteHost(tsURL.Host))
+	bow.AddRewriteRule(MatchRegexp(regexp.MustCompile(`/ads/`)), Block())
+
+	if err := bow.GET("http://mirror.example.com/page"); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(bow.body); got != "/page" {
+		t.Errorf("Expected request to be sent to the mirror, got '%s'", got)
+	}
+
+	err := bow.GET(ts.URL + "/ads/banner")
+	if _, ok := err.(errors.Blocked); !ok {
+		t.Errorf("Expected a Blocked error, got %v", err)
+	}
+
+	bow.ClearRewriteRules()
+	if err := bow.GET(ts.URL + "/ads/banner"); err != nil {
+		t.Errorf("Expected request to be sent after clearing rules, got %s", err)
+	}
+}
+
+func TestForceHTTPS(t *testing.T) {
+	bow := newDefaultTestBrowser()
+	bow.AddRewriteRule(MatchHost("*.example.com"), ForceHTTPS())
+
+	req, _ := http.NewRequest("GET", "http://www.example.com:80/login", nil)
+	if err := bow.rewriteRequest(req); err != nil {
+		t.Fatal(err)
+	}
+	if req.URL.String() != "https://www.example.com/login" {
+		t.Errorf("Expected https URL, got '%s'", req.URL)
+	}
+	if req.Host != "www.example.com" {
+		t.Errorf("Expected request host to follow the URL, got '%s'", req.Host)
+	}
+
+	req, _ = http.NewRequest("GET", "http://example.com/login", nil)
+	bow.rewriteRequest(req)
+	if req.URL.Scheme != "http" {
+		t.Errorf("Rule applied to a host which does not match the pattern")
+	}
+}
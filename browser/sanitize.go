@@ -0,0 +1,118 @@
+package browser
+
+import (
+	"bytes"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// Sanitizer removes the unsafe parts of an HTML fragment. It's implemented
+// by *bluemonday.Policy, and by SanitizePolicy.
+type Sanitizer interface {
+	Sanitize(html string) string
+}
+
+// DefaultSanitizePolicy removes the scripts, frames, plugins, event handlers
+// and javascript: URLs.
+var DefaultSanitizePolicy = &SanitizePolicy{
+	Elements:            []string{"script", "noscript", "iframe", "frame", "frameset", "object", "embed", "applet", "base", "meta", "link"},
+	StripEventHandlers:  true,
+	StripJavaScriptURLs: true,
+}
+
+// SanitizePolicy is a Sanitizer removing the given elements and attributes.
+type SanitizePolicy struct {
+	// Elements are the names of the elements removed with their content.
+	Elements []string
+
+	// Attributes are the names of the attributes removed from every element.
+	Attributes []string
+
+	// StripEventHandlers removes the on* attributes, eg onclick.
+	StripEventHandlers bool
+
+	// StripJavaScriptURLs removes the attributes whose value is a
+	// javascript: URL.
+	StripJavaScriptURLs bool
+}
+
+// SanitizedBody returns the page body sanitized with the given policy, or
+// with DefaultSanitizePolicy when it's nil.
+func (bow *Browser) SanitizedBody(policy Sanitizer) string {
+	if policy == nil {
+		policy = DefaultSanitizePolicy
+	}
+	return policy.Sanitize(bow.Body())
+}
+
+// Sanitize returns the HTML fragment without the elements and attributes
+// removed by the policy.
+func (p *SanitizePolicy) Sanitize(s string) string {
+	ctx := &html.Node{Type: html.ElementNode, Data: "body", DataAtom: atom.Body}
+	nodes, err := html.ParseFragment(strings.NewReader(s), ctx)
+	if err != nil {
+		return ""
+	}
+	var buf bytes.Buffer
+	for _, n := range nodes {
+		if p.removesElement(n) {
+			continue
+		}
+		p.sanitize(n)
+		html.Render(&buf, n)
+	}
+	return buf.String()
+}
+
+// sanitize removes the unsafe children and attributes of the node.
+func (p *SanitizePolicy) sanitize(n *html.Node) {
+	attrs := n.Attr[:0]
+	for _, a := range n.Attr {
+		if !p.removesAttr(a) {
+			attrs = append(attrs, a)
+		}
+	}
+	n.Attr = attrs
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if p.removesElement(c) {
+			n.RemoveChild(c)
+		} else {
+			p.sanitize(c)
+		}
+		c = next
+	}
+}
+
+func (p *SanitizePolicy) removesElement(n *html.Node) bool {
+	if n.Type != html.ElementNode {
+		return false
+	}
+	for _, name := range p.Elements {
+		if strings.EqualFold(name, n.Data) {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *SanitizePolicy) removesAttr(a html.Attribute) bool {
+	key := strings.ToLower(a.Key)
+	if p.StripEventHandlers && strings.HasPrefix(key, "on") {
+		return true
+	}
+	if p.StripJavaScriptURLs {
+		v := strings.ToLower(strings.Join(strings.Fields(a.Val), ""))
+		if strings.HasPrefix(v, "javascript:") || strings.HasPrefix(v, "vbscript:") {
+			return true
+		}
+	}
+	for _, name := range p.Attributes {
+		if strings.EqualFold(name, key) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,34 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSanitizedBody(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><body>` +
+			`<p onclick="steal()">Hello <a href=" JavaScript:steal()">link</a> <a href="/ok">ok</a></p>` +
+			`<script>steal()</script><iframe src="/ad"></iframe>` +
+			`<div class="x" style="color: red">Bye</div>` +
+			`</body></html>`))
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `<p>Hello <a>link</a> <a href="/ok">ok</a></p><div class="x" style="color: red">Bye</div>`
+	if body := bow.SanitizedBody(nil); body != expected {
+		t.Errorf("Expected %q, got %q", expected, body)
+	}
+
+	policy := &SanitizePolicy{Elements: []string{"a"}, Attributes: []string{"style"}}
+	expected = `<p onclick="steal()">Hello  </p><script>steal()</script><iframe src="/ad"></iframe><div class="x">Bye</div>`
+	if body := bow.SanitizedBody(policy); body != expected {
+		t.Errorf("Expected %q, got %q", expected, body)
+	}
+}
@@ -0,0 +1,212 @@
+package browser
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+	"time"
+)
+
+// ScheduledJob is a fetch scheduled with Schedule(). Its methods may be
+// called while RunSchedule() runs, including from the callbacks.
+type ScheduledJob struct {
+	s        *fetchSchedule
+	url      string
+	opts     []RequestOption
+	next     time.Time
+	interval time.Duration
+	fn       func(b *Browser, err error)
+	runs     int
+	canceled bool
+	seq      int
+}
+
+// Every repeats the fetch every d after the first one, until the job is
+// canceled. Runs which are late because of a slow fetch are not caught up.
+func (j *ScheduledJob) Every(d time.Duration) *ScheduledJob {
+	j.s.mu.Lock()
+	defer j.s.mu.Unlock()
+	j.interval = d
+	return j
+}
+
+// Do sets the function called after each fetch, with the browser showing
+// the fetched page and the error of the request, if any.
+func (j *ScheduledJob) Do(fn func(b *Browser, err error)) *ScheduledJob {
+	j.s.mu.Lock()
+	defer j.s.mu.Unlock()
+	j.fn = fn
+	return j
+}
+
+// Cancel removes the job from the schedule. A fetch in progress is completed.
+func (j *ScheduledJob) Cancel() {
+	j.s.mu.Lock()
+	defer j.s.mu.Unlock()
+	j.canceled = true
+	j.s.signal()
+}
+
+// URL returns the URL fetched by the job.
+func (j *ScheduledJob) URL() string {
+	return j.url
+}
+
+// Next returns the time of the next fetch.
+func (j *ScheduledJob) Next() time.Time {
+	j.s.mu.Lock()
+	defer j.s.mu.Unlock()
+	return j.next
+}
+
+// Runs returns the number of times the URL has been fetched.
+func (j *ScheduledJob) Runs() int {
+	j.s.mu.Lock()
+	defer j.s.mu.Unlock()
+	return j.runs
+}
+
+// Schedule adds a fetch of the given URL at the given time to the schedule
+// of the browser, which is run by RunSchedule(). Use the Every() and Do()
+// methods of the returned job to repeat the fetch and handle the pages, eg
+// to monitor a page:
+//
+//	bow.Schedule(u, time.Now()).Every(time.Minute).Do(func(b *browser.Browser, err error) {
+//		// check the page
+//	})
+func (bow *Browser) Schedule(u string, at time.Time, opts ...RequestOption) *ScheduledJob {
+	s := bow.fetchSchedule()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seq++
+	j := &ScheduledJob{s: s, url: u, opts: opts, next: at, seq: s.seq}
+	heap.Push(&s.queue, j)
+	s.signal()
+	return j
+}
+
+// RunSchedule fetches the scheduled URLs at their time, one at a time with
+// this browser, and calls the function of each job after its fetch. Jobs
+// may be added and canceled while it runs.
+//
+// Returns nil once no job is left, or the error of the context when it's
+// done first.
+func (bow *Browser) RunSchedule(ctx context.Context) error {
+	s := bow.fetchSchedule()
+	for {
+		j, wait, idle := s.nextJob()
+		if idle {
+			return nil
+		}
+		if j == nil {
+			timer := time.NewTimer(wait)
+			select {
+			case <-s.wake:
+				timer.Stop()
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			}
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			s.reschedule(j, false)
+			return err
+		}
+
+		err := bow.GET(j.url, j.opts...)
+		s.mu.Lock()
+		j.runs++
+		fn := j.fn
+		s.mu.Unlock()
+		if fn != nil {
+			fn(bow, err)
+		}
+		s.reschedule(j, true)
+	}
+}
+
+// fetchSchedule returns the schedule of the browser, creating it on the first call.
+func (bow *Browser) fetchSchedule() *fetchSchedule {
+	bow.scheduleOnce.Do(func() {
+		bow.schedule = &fetchSchedule{wake: make(chan struct{}, 1)}
+	})
+	return bow.schedule
+}
+
+// fetchSchedule is the queue of the jobs added with Schedule().
+type fetchSchedule struct {
+	mu    sync.Mutex
+	queue scheduledQueue
+	seq   int
+	wake  chan struct{}
+}
+
+// nextJob removes the first job from the queue when its time has come, or
+// returns the time until it does. Returns true when the queue is empty.
+func (s *fetchSchedule) nextJob() (*ScheduledJob, time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for s.queue.Len() > 0 && s.queue[0].canceled {
+		heap.Pop(&s.queue)
+	}
+	if s.queue.Len() == 0 {
+		return nil, 0, true
+	}
+	if wait := time.Until(s.queue[0].next); wait > 0 {
+		return nil, wait, false
+	}
+	return heap.Pop(&s.queue).(*ScheduledJob), 0, false
+}
+
+// reschedule adds the job back to the queue. Once it has run, repeated jobs
+// are moved to their next time and the others are dropped.
+func (s *fetchSchedule) reschedule(j *ScheduledJob, ran bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if j.canceled {
+		return
+	}
+	if ran {
+		if j.interval <= 0 {
+			return
+		}
+		j.next = j.next.Add(j.interval)
+		if now := time.Now(); j.next.Before(now) {
+			j.next = now.Add(j.interval)
+		}
+	}
+	heap.Push(&s.queue, j)
+}
+
+// signal wakes RunSchedule when it's waiting for the next job.
+func (s *fetchSchedule) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// scheduledQueue is a heap of jobs ordered by time.
+type scheduledQueue []*ScheduledJob
+
+func (q scheduledQueue) Len() int { return len(q) }
+
+func (q scheduledQueue) Less(i, j int) bool {
+	if !q[i].next.Equal(q[j].next) {
+		return q[i].next.Before(q[j].next)
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q scheduledQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *scheduledQueue) Push(x interface{}) { *q = append(*q, x.(*ScheduledJob)) }
+
+func (q *scheduledQueue) Pop() interface{} {
+	old := *q
+	j := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return j
+}
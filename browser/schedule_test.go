@@ -0,0 +1,68 @@
+package browser
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSchedule(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html><head><title>" + r.URL.Path + "</title></head></html>"))
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	start := time.Now()
+	var fetched []string
+	record := func(b *Browser, err error) {
+		if err != nil {
+			t.Error(err)
+		}
+		fetched = append(fetched, b.Title())
+	}
+
+	bow.Schedule(ts.URL+"/later", start.Add(100*time.Millisecond)).Do(record)
+	bow.Schedule(ts.URL+"/first", start).Do(func(b *Browser, err error) {
+		record(b, err)
+		// Jobs may be added by the callbacks.
+		b.Schedule(ts.URL+"/added", time.Now()).Do(record)
+	})
+	monitor := bow.Schedule(ts.URL+"/monitor", start.Add(25*time.Millisecond)).Every(50 * time.Millisecond)
+	monitor.Do(func(b *Browser, err error) {
+		record(b, err)
+		if monitor.Runs() == 3 {
+			monitor.Cancel()
+		}
+	})
+	canceled := bow.Schedule(ts.URL+"/canceled", start.Add(50*time.Millisecond))
+	canceled.Cancel()
+
+	if err := bow.RunSchedule(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"/first", "/added", "/monitor", "/monitor", "/later", "/monitor"}
+	if !reflect.DeepEqual(fetched, expected) {
+		t.Errorf("Expected the fetches %v, got %v.", expected, fetched)
+	}
+	if monitor.Runs() != 3 || canceled.Runs() != 0 {
+		t.Errorf("Expected 3 and 0 runs, got %d and %d.", monitor.Runs(), canceled.Runs())
+	}
+	if d := time.Since(start); d < 125*time.Millisecond {
+		t.Errorf("Expected the fetches to wait for their time, took %s.", d)
+	}
+
+	at := time.Now().Add(time.Hour)
+	job := bow.Schedule(ts.URL+"/tomorrow", at)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := bow.RunSchedule(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected the context error, got %v.", err)
+	}
+	if !job.Next().Equal(at) || job.Runs() != 0 {
+		t.Errorf("Expected the job to stay scheduled, got %s after %d runs.", job.Next(), job.Runs())
+	}
+}
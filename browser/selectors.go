@@ -0,0 +1,58 @@
+package browser
+
+import (
+	"strings"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+// FindText returns the trimmed text of the first element matching the given
+// expression. Use Text() for the visible text of the whole page.
+//
+// Returns a PageNotLoaded error when no page has been loaded, and an
+// ElementNotFound error when no element matches.
+func (bow *Browser) FindText(expr string) (string, error) {
+	if bow.dom() == nil {
+		return "", errors.NewPageNotLoaded("Cannot find the text of '%s', no page has been loaded.", expr)
+	}
+	sel := bow.Find(expr).First()
+	if sel.Length() == 0 {
+		return "", errors.NewElementNotFound("Element not found matching expr '%s'.", expr)
+	}
+	return strings.TrimSpace(sel.Text()), nil
+}
+
+// Attr returns the value of the named attribute of the first element
+// matching the given expression.
+//
+// Returns a PageNotLoaded error when no page has been loaded, an
+// ElementNotFound error when no element matches, and an AttributeNotFound
+// error when the element does not have the attribute.
+func (bow *Browser) Attr(expr, name string) (string, error) {
+	if bow.dom() == nil {
+		return "", errors.NewPageNotLoaded("Cannot find the attribute '%s' of '%s', no page has been loaded.", name, expr)
+	}
+	sel := bow.Find(expr).First()
+	if sel.Length() == 0 {
+		return "", errors.NewElementNotFound("Element not found matching expr '%s'.", expr)
+	}
+	v, ok := sel.Attr(name)
+	if !ok {
+		return "", errors.NewAttributeNotFound("Attribute '%s' not found on element matching expr '%s'.", name, expr)
+	}
+	return v, nil
+}
+
+// Exists returns a boolean value indicating whether an element matches the
+// given expression.
+func (bow *Browser) Exists(expr string) bool {
+	return bow.Count(expr) > 0
+}
+
+// Count returns the number of elements matching the given expression.
+func (bow *Browser) Count(expr string) int {
+	if bow.dom() == nil {
+		return 0
+	}
+	return bow.Find(expr).Length()
+}
@@ -0,0 +1,71 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+func TestSelectorHelpers(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><body>
+			<h1>  Title </h1>
+			<a class="nav" href="/a">A</a>
+			<a class="nav">B</a>
+		</body></html>`))
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	if bow.Exists("h1") || bow.Count("a") != 0 {
+		t.Error("Expected no matches before a page is loaded")
+	}
+	if _, err := bow.FindText("h1"); !isPageNotLoaded(err) {
+		t.Errorf("Expected a PageNotLoaded error, got %v", err)
+	}
+	if _, err := bow.Attr("a", "href"); !isPageNotLoaded(err) {
+		t.Errorf("Expected a PageNotLoaded error, got %v", err)
+	}
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	if text, err := bow.FindText("h1"); err != nil || text != "Title" {
+		t.Errorf("Expected 'Title', got '%s' %v", text, err)
+	}
+	if _, err := bow.FindText("h2"); err == nil {
+		t.Error("Expected an error for a missing element")
+	}
+	if href, err := bow.Attr("a.nav", "href"); err != nil || href != "/a" {
+		t.Errorf("Expected '/a', got '%s' %v", href, err)
+	}
+	if _, err := bow.Attr("a.nav:nth-of-type(2)", "href"); !isAttributeNotFound(err) {
+		t.Error("Expected an AttributeNotFound error")
+	}
+	if _, err := bow.Attr("a.missing", "href"); !isElementNotFound(err) {
+		t.Error("Expected an ElementNotFound error")
+	}
+	if !bow.Exists("a.nav") || bow.Exists("form") {
+		t.Error("Unexpected result from Exists")
+	}
+	if bow.Count("a.nav") != 2 {
+		t.Errorf("Expected 2 links, got %d", bow.Count("a.nav"))
+	}
+}
+
+func isAttributeNotFound(err error) bool {
+	_, ok := err.(errors.AttributeNotFound)
+	return ok
+}
+
+func isElementNotFound(err error) bool {
+	_, ok := err.(errors.ElementNotFound)
+	return ok
+}
+
+func isPageNotLoaded(err error) bool {
+	_, ok := err.(errors.PageNotLoaded)
+	return ok
+}
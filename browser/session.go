@@ -0,0 +1,110 @@
+package browser
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/lostinblue/surf/errors"
+	"github.com/lostinblue/surf/notify"
+)
+
+// SessionGuard recognizes the pages returned once a login session expired,
+// eg a redirect to the login page, logs in again and retries the request
+// transparently. Set it with Browser.SetSessionGuard().
+type SessionGuard struct {
+	// LoginPaths are the path prefixes of the pages expired sessions are
+	// redirected to, eg "/login".
+	LoginPaths []string
+
+	// Selector matches an element only shown when the session expired, eg
+	// "form#login".
+	Selector string
+
+	// StatusCodes are the status codes returned for expired sessions, eg
+	// 401 or 440.
+	StatusCodes []int
+
+	// Expired reports whether the page shows an expired session, in addition
+	// to the checks above.
+	Expired func(bow *Browser) bool
+
+	// Relogin logs in again, eg with Browser.Login(). The requests it sends
+	// are not guarded.
+	Relogin func(bow *Browser) error
+}
+
+// SetSessionGuard sets the guard which logs in again when a page shows an
+// expired session. A nil guard disables the checks.
+func (bow *Browser) SetSessionGuard(g *SessionGuard) {
+	bow.sessionGuard = g
+}
+
+// SessionGuard returns the guard set with SetSessionGuard(), or nil.
+func (bow *Browser) SessionGuard() *SessionGuard {
+	return bow.sessionGuard
+}
+
+// expired returns a boolean value indicating whether the current page of the
+// browser shows an expired session.
+func (g *SessionGuard) expired(bow *Browser) bool {
+	if u := bow.URL(); u != nil {
+		for _, p := range g.LoginPaths {
+			if strings.HasPrefix(u.Path, p) {
+				return true
+			}
+		}
+	}
+	status := bow.StatusCode()
+	for _, code := range g.StatusCodes {
+		if code == status {
+			return true
+		}
+	}
+	if g.Selector != "" && bow.Find(g.Selector).Length() > 0 {
+		return true
+	}
+	return g.Expired != nil && g.Expired(bow)
+}
+
+// guardSession logs in again and retries the request when the page returned
+// for it shows an expired session, returning true when it did. Requests sent
+// by the guard itself are not checked, so an expired session is retried once.
+func (bow *Browser) guardSession(req *http.Request) (bool, error) {
+	g := bow.sessionGuard
+	if g == nil || g.Relogin == nil || bow.guarding || !g.expired(bow) {
+		return false, nil
+	}
+	if isLoginRequest(req, g) {
+		// The login page itself was requested.
+		return false, nil
+	}
+	bow.notify(notify.SessionExpired, req.URL.String(), "The page showed an expired session.", nil)
+	retry, err := resendRequest(req)
+	if err != nil {
+		return false, err
+	}
+
+	bow.guarding = true
+	defer func() { bow.guarding = false }()
+	if err := g.Relogin(bow); err != nil {
+		return true, errors.NewSessionExpired("Cannot log in again after '%s' showed an expired session: %s", req.URL, err)
+	}
+	if err := bow.httpRequest(retry); err != nil {
+		return true, err
+	}
+	if g.expired(bow) {
+		return true, errors.NewSessionExpired("The page '%s' still shows an expired session after logging in again.", req.URL)
+	}
+	return true, nil
+}
+
+// isLoginRequest returns a boolean value indicating whether the request was
+// sent to one of the login paths of the guard.
+func isLoginRequest(req *http.Request, g *SessionGuard) bool {
+	for _, p := range g.LoginPaths {
+		if strings.HasPrefix(req.URL.Path, p) {
+			return true
+		}
+	}
+	return false
+}
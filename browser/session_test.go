@@ -0,0 +1,76 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lostinblue/surf/errors"
+	"github.com/lostinblue/surf/jar"
+	"github.com/lostinblue/surf/notify"
+)
+
+func TestSessionGuard(t *testing.T) {
+	loggedIn := false
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			if r.Method == "POST" {
+				loggedIn = true
+				http.Redirect(w, r, "/", http.StatusFound)
+				return
+			}
+			w.Write([]byte(`<html><body><form method="post" id="login"></form></body></html>`))
+		default:
+			if !loggedIn {
+				http.Redirect(w, r, "/login", http.StatusFound)
+				return
+			}
+			w.Write([]byte(`<html><body><p class="secret">` + r.URL.Path + `</p></body></html>`))
+		}
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	bow.SetCookieJar(jar.NewCookiesJar())
+	var expired []string
+	bow.SetNotifier(notify.Only(notify.Func(func(e *notify.Event) error {
+		expired = append(expired, e.URL)
+		return nil
+	}), notify.SessionExpired))
+	relogins := 0
+	bow.SetSessionGuard(&SessionGuard{
+		LoginPaths: []string{"/login"},
+		Relogin: func(b *Browser) error {
+			relogins++
+			return b.POSTForm(ts.URL+"/login", nil)
+		},
+	})
+
+	if err := bow.GET(ts.URL + "/account"); err != nil {
+		t.Fatal(err)
+	}
+	if relogins != 1 {
+		t.Errorf("Expected 1 relogin, got %d", relogins)
+	}
+	if text := bow.Find(".secret").Text(); text != "/account" {
+		t.Errorf("Expected the retried page /account, got %q", text)
+	}
+	if len(expired) != 1 || expired[0] != ts.URL+"/account" {
+		t.Errorf("Expected a session expired event for /account, got %v", expired)
+	}
+
+	// A login which does not restore the session is reported.
+	loggedIn = false
+	bow.SessionGuard().Relogin = func(b *Browser) error {
+		relogins++
+		return nil
+	}
+	err := bow.GET(ts.URL + "/account")
+	if _, ok := err.(errors.SessionExpired); !ok {
+		t.Errorf("Expected a SessionExpired error, got %v", err)
+	}
+	if relogins != 2 {
+		t.Errorf("Expected 2 relogins, got %d", relogins)
+	}
+}
@@ -0,0 +1,315 @@
+package browser
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lostinblue/surf/errors"
+	"github.com/lostinblue/surf/jar"
+	"github.com/lostinblue/surf/notify"
+	"golang.org/x/net/html"
+)
+
+// DiffOp is the kind of change of a DiffLine.
+type DiffOp int
+
+const (
+	// DiffRemoved marks a line found in the snapshot but not in the page.
+	DiffRemoved DiffOp = iota
+
+	// DiffAdded marks a line found in the page but not in the snapshot.
+	DiffAdded
+)
+
+// String returns "-" for removed lines and "+" for added lines.
+func (op DiffOp) String() string {
+	if op == DiffAdded {
+		return "+"
+	}
+	return "-"
+}
+
+// DiffLine is a line which changed between a snapshot and the current page.
+type DiffLine struct {
+	Op   DiffOp
+	Line string
+}
+
+// SnapshotDiff holds the changes between a snapshot and the current page.
+type SnapshotDiff struct {
+	// Name is the name of the snapshot.
+	Name string
+
+	// Previous is the snapshot the page was compared with.
+	Previous *jar.Snapshot
+
+	// Text contains the changes to the visible text of the page.
+	Text []DiffLine
+
+	// DOM contains the changes to the markup of the page.
+	DOM []DiffLine
+}
+
+// Changed returns a boolean value indicating whether the text or markup of
+// the page changed since the snapshot was saved.
+func (d *SnapshotDiff) Changed() bool {
+	return len(d.Text) > 0 || len(d.DOM) > 0
+}
+
+// String returns the text changes, one per line, prefixed by "+" or "-".
+func (d *SnapshotDiff) String() string {
+	buff := &bytes.Buffer{}
+	for _, l := range d.Text {
+		buff.WriteString(l.Op.String() + " " + l.Line + "\n")
+	}
+	return buff.String()
+}
+
+// SetSnapshotsJar sets the jar used to store page snapshots.
+func (bow *Browser) SetSnapshotsJar(sj jar.SnapshotsJar) {
+	bow.snapshots = sj
+}
+
+// SnapshotsJar returns the jar used to store page snapshots.
+func (bow *Browser) SnapshotsJar() jar.SnapshotsJar {
+	return bow.snapshots
+}
+
+// Snapshot saves the normalized text and markup of the current page with the
+// given name, replacing any snapshot with the same name. Scripts, styles and
+// comments are left out, attributes are sorted and whitespace is collapsed,
+// so only changes a visitor could see are reported by DiffSnapshot().
+//
+// Use a FileSnapshots jar to compare pages between runs of a program.
+func (bow *Browser) Snapshot(name string) error {
+	s, err := bow.snapshot()
+	if err != nil {
+		return err
+	}
+	return bow.snapshots.Save(name, s)
+}
+
+// DiffSnapshot compares the current page with the snapshot saved with the
+// given name. The snapshot is not updated, call Snapshot() afterwards to make
+// the current page the baseline of the next comparison.
+//
+// Returns an error when no page has been loaded or the snapshot does not exist.
+func (bow *Browser) DiffSnapshot(name string) (*SnapshotDiff, error) {
+	prev, err := bow.snapshots.Read(name)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := bow.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	diff := &SnapshotDiff{
+		Name:     name,
+		Previous: prev,
+		Text:     diffLines(prev.Text, cur.Text),
+		DOM:      diffLines(prev.DOM, cur.DOM),
+	}
+	if diff.Changed() {
+		bow.notify(notify.PageChanged, "", fmt.Sprintf("The page changed since the snapshot '%s'.", name),
+			map[string]string{"snapshot": name, "diff": diff.String()})
+	}
+	return diff, nil
+}
+
+// snapshot returns the normalized content of the current page.
+func (bow *Browser) snapshot() (*jar.Snapshot, error) {
+	if bow.dom() == nil || bow.URL() == nil {
+		return nil, errors.NewPageNotLoaded("Cannot take a snapshot, no page has been loaded.")
+	}
+	s := &jar.Snapshot{
+		URL:   bow.URL().String(),
+		Taken: time.Now(),
+	}
+	var walk func(n *html.Node, depth int)
+	walk = func(n *html.Node, depth int) {
+		indent := strings.Repeat("  ", depth)
+		switch n.Type {
+		case html.TextNode:
+			if t := strings.Join(strings.Fields(n.Data), " "); t != "" {
+				s.DOM = append(s.DOM, indent+t)
+			}
+			return
+		case html.ElementNode:
+			if hiddenElements[n.Data] {
+				return
+			}
+			s.DOM = append(s.DOM, indent+snapshotTag(n))
+			depth++
+		case html.DocumentNode:
+		default:
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c, depth)
+		}
+	}
+	for _, n := range bow.dom().Nodes {
+		walk(n, 0)
+	}
+	s.Text = visibleText(bow.dom().Nodes)
+
+	return s, nil
+}
+
+// snapshotTag returns the opening tag of the element with sorted attributes.
+func snapshotTag(n *html.Node) string {
+	attrs := make([]string, 0, len(n.Attr))
+	for _, a := range n.Attr {
+		attrs = append(attrs, a.Key+`="`+html.EscapeString(a.Val)+`"`)
+	}
+	sort.Strings(attrs)
+	if len(attrs) == 0 {
+		return "<" + n.Data + ">"
+	}
+	return "<" + n.Data + " " + strings.Join(attrs, " ") + ">"
+}
+
+// diffLines returns the lines removed from a and added to b, in the order
+// they appear.
+func diffLines(a, b []string) []DiffLine {
+	var diff []DiffLine
+	for _, d := range diffIndexes(a, b) {
+		if d.op == DiffAdded {
+			diff = append(diff, DiffLine{Op: DiffAdded, Line: b[d.index]})
+		} else {
+			diff = append(diff, DiffLine{Op: DiffRemoved, Line: a[d.index]})
+		}
+	}
+	return diff
+}
+
+// diffIndex is a line removed from a, or added to b, by diffIndexes.
+type diffIndex struct {
+	op    DiffOp
+	index int
+}
+
+// diffSame marks the lines common to a and b in differ.changes.
+const diffSame DiffOp = -1
+
+// diffIndexes returns the indexes of the lines removed from a and added to
+// b, in the order they appear, using the algorithm of Myers in linear space,
+// so large pages don't need a table of every pair of lines. The lines
+// removed from a hunk are returned before the lines added to it.
+func diffIndexes(a, b []string) []diffIndex {
+	max := (len(a)+len(b)+1)/2 + 1
+	d := &differ{a: a, b: b, vf: make([]int, 2*max+2), vb: make([]int, 2*max+2), offset: max}
+	d.compare(0, len(a), 0, len(b))
+
+	// Order the changes of each hunk, removed lines first.
+	var diff, added []diffIndex
+	for _, c := range d.changes {
+		switch c.op {
+		case DiffAdded:
+			added = append(added, c)
+		case DiffRemoved:
+			diff = append(diff, c)
+		case diffSame:
+			diff = append(diff, added...)
+			added = added[:0]
+		}
+	}
+	return append(diff, added...)
+}
+
+// differ holds the state of diffIndexes. Its changes include the lines
+// common to a and b, which end the hunks.
+type differ struct {
+	a, b    []string
+	vf, vb  []int
+	offset  int
+	changes []diffIndex
+}
+
+// compare adds the changes between a[aLo:aHi] and b[bLo:bHi].
+func (d *differ) compare(aLo, aHi, bLo, bHi int) {
+	for aLo < aHi && bLo < bHi && d.a[aLo] == d.b[bLo] {
+		d.changes = append(d.changes, diffIndex{op: diffSame, index: aLo})
+		aLo++
+		bLo++
+	}
+	end := 0
+	for aLo < aHi && bLo < bHi && d.a[aHi-1] == d.b[bHi-1] {
+		aHi--
+		bHi--
+		end++
+	}
+	switch {
+	case aLo == aHi:
+		for j := bLo; j < bHi; j++ {
+			d.changes = append(d.changes, diffIndex{op: DiffAdded, index: j})
+		}
+	case bLo == bHi:
+		for i := aLo; i < aHi; i++ {
+			d.changes = append(d.changes, diffIndex{op: DiffRemoved, index: i})
+		}
+	default:
+		x1, y1, x2, y2 := d.middleSnake(aLo, aHi, bLo, bHi)
+		d.compare(aLo, x1, bLo, y1)
+		for i := x1; i < x2; i++ {
+			d.changes = append(d.changes, diffIndex{op: diffSame, index: i})
+		}
+		d.compare(x2, aHi, y2, bHi)
+	}
+	for i := 0; i < end; i++ {
+		d.changes = append(d.changes, diffIndex{op: diffSame, index: aHi + i})
+	}
+}
+
+// middleSnake returns the start and end of the middle snake of the shortest
+// edit script between a[aLo:aHi] and b[bLo:bHi], whose first and last lines
+// differ. The forward and backward paths are searched at once, until they
+// overlap.
+func (d *differ) middleSnake(aLo, aHi, bLo, bHi int) (x1, y1, x2, y2 int) {
+	n, m := aHi-aLo, bHi-bLo
+	delta := n - m
+	odd := delta&1 != 0
+	vf, vb, off := d.vf, d.vb, d.offset
+	vf[off+1], vb[off+1] = 0, 0
+	for k := 0; ; k++ {
+		for diag := -k; diag <= k; diag += 2 {
+			var x int
+			if diag == -k || (diag != k && vf[off+diag-1] < vf[off+diag+1]) {
+				x = vf[off+diag+1]
+			} else {
+				x = vf[off+diag-1] + 1
+			}
+			y := x - diag
+			sx, sy := x, y
+			for x < n && y < m && d.a[aLo+x] == d.b[bLo+y] {
+				x++
+				y++
+			}
+			vf[off+diag] = x
+			if back := delta - diag; odd && back >= -(k-1) && back <= k-1 && x+vb[off+back] >= n {
+				return aLo + sx, bLo + sy, aLo + x, bLo + y
+			}
+		}
+		for diag := -k; diag <= k; diag += 2 {
+			var x int
+			if diag == -k || (diag != k && vb[off+diag-1] < vb[off+diag+1]) {
+				x = vb[off+diag+1]
+			} else {
+				x = vb[off+diag-1] + 1
+			}
+			y := x - diag
+			sx, sy := x, y
+			for x < n && y < m && d.a[aHi-1-x] == d.b[bHi-1-y] {
+				x++
+				y++
+			}
+			vb[off+diag] = x
+			if fwd := delta - diag; !odd && fwd >= -k && fwd <= k && x+vf[off+fwd] >= n {
+				return aHi - x, bHi - y, aHi - sx, bHi - sy
+			}
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package browser
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/lostinblue/surf/jar"
+)
+
+func TestDiffSnapshot(t *testing.T) {
+	price := "10"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `<html><head><title>Shop</title>
+			<script>var nonce = "%s";</script></head>
+			<body><h1>Widget</h1><p>Price: <b>%s</b> USD</p></body></html>`, r.URL.Query().Get("n"), price)
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	bow.SetSnapshotsJar(jar.NewMemorySnapshots())
+	if _, err := bow.DiffSnapshot("shop"); err == nil {
+		t.Error("Expected an error for a missing snapshot")
+	}
+	if err := bow.Snapshot("shop"); err == nil {
+		t.Error("Expected an error when no page has been loaded")
+	}
+
+	if err := bow.GET(ts.URL + "?n=1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := bow.Snapshot("shop"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := bow.GET(ts.URL + "?n=2"); err != nil {
+		t.Fatal(err)
+	}
+	diff, err := bow.DiffSnapshot("shop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff.Changed() {
+		t.Errorf("Expected script changes to be ignored, got %v %v", diff.Text, diff.DOM)
+	}
+
+	price = "12"
+	if err := bow.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	diff, err = bow.DiffSnapshot("shop")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !diff.Changed() {
+		t.Fatal("Expected the price change to be reported")
+	}
+	if s := diff.String(); s != "- Price: 10 USD\n+ Price: 12 USD\n" {
+		t.Errorf("Unexpected text diff %q", s)
+	}
+	if len(diff.DOM) != 2 || strings.TrimSpace(diff.DOM[0].Line) != "10" || diff.DOM[1].Op != DiffAdded {
+		t.Errorf("Unexpected DOM diff %v", diff.DOM)
+	}
+}
+
+func TestDiffSnapshotTitle(t *testing.T) {
+	bow := newDefaultTestBrowser()
+	bow.SetSnapshotsJar(jar.NewMemorySnapshots())
+	if err := bow.SetContent(`<html><head><title>Old</title></head><body><p>Same</p></body></html>`, "http://example.com/"); err != nil {
+		t.Fatal(err)
+	}
+	if err := bow.Snapshot("page"); err != nil {
+		t.Fatal(err)
+	}
+	if err := bow.SetContent(`<html><head><title>New</title></head><body><p>Same</p></body></html>`, "http://example.com/"); err != nil {
+		t.Fatal(err)
+	}
+	diff, err := bow.DiffSnapshot("page")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(diff.Text) != 0 {
+		t.Errorf("Expected the title to be left out of the text, got %v", diff.Text)
+	}
+	if len(diff.DOM) != 2 || strings.TrimSpace(diff.DOM[0].Line) != "Old" || strings.TrimSpace(diff.DOM[1].Line) != "New" {
+		t.Errorf("Expected the title change in the DOM diff, got %v", diff.DOM)
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	diff := diffLines([]string{"a", "b", "c", "d"}, []string{"a", "c", "e", "d"})
+	expected := []DiffLine{{DiffRemoved, "b"}, {DiffAdded, "e"}}
+	if fmt.Sprint(diff) != fmt.Sprint(expected) {
+		t.Errorf("Expected %v, got %v", expected, diff)
+	}
+}
+
+func TestDiffLinesLarge(t *testing.T) {
+	// The table of the longest common subsequence would need 40 billion
+	// entries.
+	a := make([]string, 200000)
+	for i := range a {
+		a[i] = strconv.Itoa(i)
+	}
+	b := append([]string(nil), a...)
+	for i := 0; i < 100; i++ {
+		b[i*1999] = "changed"
+	}
+	diff := diffLines(a, b)
+	if len(diff) != 200 {
+		t.Fatalf("Expected 200 changed lines, got %d", len(diff))
+	}
+	if diff[0] != (DiffLine{DiffRemoved, "0"}) || diff[1] != (DiffLine{DiffAdded, "changed"}) {
+		t.Errorf("Expected the removed line first, got %v", diff[:2])
+	}
+}
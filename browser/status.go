@@ -0,0 +1,48 @@
+package browser
+
+// StatusHandler handles the pages returned with a status code, eg by
+// waiting and reloading a throttled page, or by returning an error for a
+// missing one. Register it with Browser.On().
+type StatusHandler func(bow *Browser) error
+
+// On registers the handler called after a navigation returns a page with
+// the given status code, eg 404 or 429, replacing the handler registered
+// before for the code. A nil handler removes it.
+//
+// The error returned by the handler is returned by the navigation. The
+// requests sent by the handler itself, eg with Reload(), are not handled,
+// so a handler isn't called again for the pages it loads.
+func (bow *Browser) On(code int, h StatusHandler) {
+	if h == nil {
+		delete(bow.statusHandlers, code)
+		return
+	}
+	if bow.statusHandlers == nil {
+		bow.statusHandlers = make(map[int]StatusHandler)
+	}
+	bow.statusHandlers[code] = h
+}
+
+// handleStatus calls the handler registered for the status code of the
+// current page.
+func (bow *Browser) handleStatus() error {
+	h := bow.statusHandlers[bow.StatusCode()]
+	if h == nil || bow.handlingStatus {
+		return nil
+	}
+	bow.handlingStatus = true
+	defer func() { bow.handlingStatus = false }()
+	return h(bow)
+}
+
+// copyStatusHandlers returns a copy of the handlers, for a new tab.
+func copyStatusHandlers(handlers map[int]StatusHandler) map[int]StatusHandler {
+	if handlers == nil {
+		return nil
+	}
+	c := make(map[int]StatusHandler, len(handlers))
+	for code, h := range handlers {
+		c[code] = h
+	}
+	return c
+}
@@ -0,0 +1,65 @@
+package browser
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOn(t *testing.T) {
+	throttled := 1
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/busy":
+			if throttled > 0 {
+				throttled--
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.Write([]byte(`<html><body>Ready</body></html>`))
+		case "/always-busy":
+			w.WriteHeader(http.StatusTooManyRequests)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	calls := 0
+	bow.On(http.StatusTooManyRequests, func(b *Browser) error {
+		calls++
+		if err := b.Reload(); err != nil {
+			return err
+		}
+		if b.StatusCode() == http.StatusTooManyRequests {
+			return fmt.Errorf("still throttled")
+		}
+		return nil
+	})
+	bow.On(http.StatusNotFound, func(b *Browser) error {
+		return fmt.Errorf("not found: %s", b.URL().Path)
+	})
+
+	if err := bow.GET(ts.URL + "/busy"); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 1 || bow.StatusCode() != 200 || bow.Find("body").Text() != "Ready" {
+		t.Errorf("Expected the throttled page to be reloaded once, got %d calls and the status %d.", calls, bow.StatusCode())
+	}
+	if err := bow.GET(ts.URL + "/always-busy"); err == nil || err.Error() != "still throttled" {
+		t.Errorf("Expected the error of the handler, got %v.", err)
+	}
+	if calls != 2 {
+		t.Errorf("Expected the handler not to be called for its own reload, got %d calls.", calls)
+	}
+	if err := bow.GET(ts.URL + "/missing"); err == nil || err.Error() != "not found: /missing" {
+		t.Errorf("Expected the error of the 404 handler, got %v.", err)
+	}
+
+	bow.On(http.StatusNotFound, nil)
+	if err := bow.GET(ts.URL + "/missing"); err != nil {
+		t.Errorf("Expected no error once the handler is removed, got %v.", err)
+	}
+}
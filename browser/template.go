@@ -0,0 +1,76 @@
+package browser
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/lostinblue/surf/errors"
+	"github.com/lostinblue/surf/util"
+)
+
+// SetBaseURL sets the URL the templates opened with OpenTemplate() are
+// resolved against, eg "https://api.example.com/v1/". The base is treated as
+// a directory, so "users/{id}" opens "https://api.example.com/v1/users/1"
+// with or without a trailing slash. An empty URL resolves the templates
+// against the current page.
+func (bow *Browser) SetBaseURL(u string) error {
+	if u == "" {
+		bow.baseURL = nil
+		return nil
+	}
+	base, err := url.Parse(u)
+	if err != nil {
+		return err
+	}
+	if !base.IsAbs() {
+		return errors.New("The base URL '%s' is not absolute.", u)
+	}
+	if !strings.HasSuffix(base.Path, "/") {
+		base.Path += "/"
+		if base.RawPath != "" {
+			base.RawPath += "/"
+		}
+	}
+	bow.baseURL = base
+	return nil
+}
+
+// BaseURL returns the URL set with SetBaseURL(), or nil.
+func (bow *Browser) BaseURL() *url.URL {
+	return bow.baseURL
+}
+
+// OpenTemplate calls GET() with the URL template expanded with params, eg
+// "users/{id}/posts", and resolved against the base URL, or the current page
+// when no base URL is set. Values are path escaped in the path of the
+// template and query escaped in its query string, see util.ExpandTemplate().
+func (bow *Browser) OpenTemplate(tmpl string, params map[string]string, opts ...RequestOption) error {
+	u, err := bow.templateURL(tmpl, params)
+	if err != nil {
+		return err
+	}
+	return bow.GET(u.String(), opts...)
+}
+
+// templateURL returns the absolute URL of the expanded template.
+func (bow *Browser) templateURL(tmpl string, params map[string]string) (*url.URL, error) {
+	expanded, err := util.ExpandTemplate(tmpl, params)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(expanded)
+	if err != nil {
+		return nil, err
+	}
+	base := bow.baseURL
+	if base == nil {
+		base = bow.URL()
+	}
+	if base == nil {
+		if !u.IsAbs() {
+			return nil, errors.NewPageNotLoaded("Cannot resolve the template '%s' without a base URL or a page.", tmpl)
+		}
+		return u, nil
+	}
+	return base.ResolveReference(u), nil
+}
@@ -0,0 +1,50 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOpenTemplate(t *testing.T) {
+	var requested string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = r.URL.RequestURI()
+		w.Write([]byte("<html></html>"))
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	if err := bow.OpenTemplate("users/{id}", map[string]string{"id": "1"}); err == nil {
+		t.Error("Expected an error resolving a relative template without a base URL")
+	}
+	if err := bow.SetBaseURL(ts.URL + "/api/v1"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		tmpl     string
+		params   map[string]string
+		expected string
+	}{
+		{"users/{id}/posts", map[string]string{"id": "42"}, "/api/v1/users/42/posts"},
+		{"users/{id}", map[string]string{"id": "a/../b"}, "/api/v1/users/a%2F..%2Fb"},
+		{"search?q={q}", map[string]string{"q": "a&b"}, "/api/v1/search?q=a%26b"},
+		{"/root/{id}", map[string]string{"id": "1"}, "/root/1"},
+	}
+	for _, tt := range tests {
+		if err := bow.OpenTemplate(tt.tmpl, tt.params); err != nil {
+			t.Fatal(err)
+		}
+		if requested != tt.expected {
+			t.Errorf("Expected %s to request %s, got %s", tt.tmpl, tt.expected, requested)
+		}
+	}
+
+	if err := bow.OpenTemplate("users/{id}", nil); err == nil {
+		t.Error("Expected an error for a missing parameter")
+	}
+	if err := bow.SetBaseURL("api/v1"); err == nil {
+		t.Error("Expected an error for a relative base URL")
+	}
+}
@@ -0,0 +1,33 @@
+package browser
+
+import (
+	"github.com/lostinblue/surf/errors"
+	"github.com/lostinblue/surf/tor"
+)
+
+// UseTor sends every request through the Tor daemon described by the given
+// config, and remembers the config so RenewTorIdentity() can reach the control port.
+func (bow *Browser) UseTor(cfg tor.Config) error {
+	if err := bow.SetProxy(cfg.ProxyURL()); err != nil {
+		return err
+	}
+	bow.tor = &cfg
+	return nil
+}
+
+// RenewTorIdentity asks Tor for new circuits and drops the open connections,
+// so the following requests leave Tor through a new exit node.
+//
+// Returns an error when UseTor() has not been called.
+func (bow *Browser) RenewTorIdentity() error {
+	if bow.tor == nil {
+		return errors.New("Cannot renew the Tor identity, UseTor() has not been called.")
+	}
+	if err := bow.tor.NewIdentity(); err != nil {
+		return err
+	}
+	if bow.client != nil {
+		bow.client.CloseIdleConnections()
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package browser
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+// TOTP generates the time-based one-time passwords of RFC 6238, as shown by
+// authenticator apps, to solve two-factor login challenges.
+type TOTP struct {
+	// Secret is the shared key, base32 encoded as in the otpauth:// URLs.
+	Secret string
+
+	// Digits is the length of the codes, at most 10 since the codes are
+	// 31-bit numbers. Defaults to 6.
+	Digits int
+
+	// Period is the time each code is valid for, at least a second.
+	// Defaults to 30 seconds.
+	Period time.Duration
+
+	// Now returns the current time. Defaults to time.Now.
+	Now func() time.Time
+}
+
+// NewTOTP creates and returns a new *TOTP type with the given base32 secret.
+func NewTOTP(secret string) *TOTP {
+	return &TOTP{Secret: secret}
+}
+
+// Code returns the code for the given time. Returns an error when the
+// secret, the digits or the period are invalid.
+func (t *TOTP) Code(at time.Time) (string, error) {
+	secret := strings.ToUpper(strings.Replace(t.Secret, " ", "", -1))
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.TrimRight(secret, "="))
+	if err != nil {
+		return "", errors.New("Invalid TOTP secret: %s", err)
+	}
+	digits, period := t.Digits, t.Period
+	if digits <= 0 {
+		digits = 6
+	}
+	if period <= 0 {
+		period = 30 * time.Second
+	}
+	if digits > 10 {
+		return "", errors.New("Invalid TOTP digits %d, the codes have at most 10 digits.", digits)
+	}
+	if period < time.Second {
+		return "", errors.New("Invalid TOTP period %s, the period is at least a second.", period)
+	}
+
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(at.Unix()/int64(period/time.Second)))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := uint64(binary.BigEndian.Uint32(sum[offset:]) & 0x7fffffff)
+
+	mod := uint64(1)
+	for i := 0; i < digits; i++ {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%mod), nil
+}
+
+// Solve returns the current code.
+func (t *TOTP) Solve(_ *LoginChallenge) (string, error) {
+	now := time.Now
+	if t.Now != nil {
+		now = t.Now
+	}
+	return t.Code(now())
+}
+
+// PromptSolver returns a solver which writes a prompt to out and reads the
+// code from a line of in, eg to ask for the code of an SMS on the terminal:
+//
+//	spec.Solver = browser.PromptSolver(os.Stdin, os.Stderr)
+func PromptSolver(in io.Reader, out io.Writer) ChallengeSolver {
+	r := bufio.NewReader(in)
+	return ChallengeFunc(func(c *LoginChallenge) (string, error) {
+		fmt.Fprintf(out, "Enter the code for %s: ", c.Browser.URL())
+		line, err := r.ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return "", err
+		}
+		return strings.TrimSpace(line), nil
+	})
+}
@@ -0,0 +1,61 @@
+package browser
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTOTP(t *testing.T) {
+	// Test vectors of RFC 6238, with the SHA1 secret "12345678901234567890".
+	totp := &TOTP{Secret: "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", Digits: 8}
+	tests := map[int64]string{
+		59:         "94287082",
+		1111111109: "07081804",
+		1234567890: "89005924",
+		2000000000: "69279037",
+	}
+	for unix, want := range tests {
+		code, err := totp.Code(time.Unix(unix, 0))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != want {
+			t.Errorf("Expected the code %s at %d, got %s", want, unix, code)
+		}
+	}
+
+	totp = NewTOTP("gezd gnbv gy3t qojq gezd gnbv gy3t qojq")
+	totp.Now = func() time.Time { return time.Unix(59, 0) }
+	if code, err := totp.Solve(nil); err != nil || code != "287082" {
+		t.Errorf("Expected the 6 digits code 287082, got %q, %v", code, err)
+	}
+	if _, err := NewTOTP("not base32!").Code(time.Now()); err == nil {
+		t.Error("Expected an error for an invalid secret")
+	}
+	if _, err := (&TOTP{Secret: totp.Secret, Digits: 20}).Code(time.Now()); err == nil {
+		t.Error("Expected an error for too many digits")
+	}
+	if _, err := (&TOTP{Secret: totp.Secret, Period: time.Millisecond}).Code(time.Now()); err == nil {
+		t.Error("Expected an error for a period shorter than a second")
+	}
+}
+
+func TestPromptSolver(t *testing.T) {
+	bow := newDefaultTestBrowser()
+	var out bytes.Buffer
+	solver := PromptSolver(strings.NewReader(" 123456 \n654321"), &out)
+	for _, want := range []string{"123456", "654321"} {
+		code, err := solver.Solve(&LoginChallenge{Browser: bow})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if code != want {
+			t.Errorf("Expected the code %s, got %s", want, code)
+		}
+	}
+	if !strings.Contains(out.String(), "Enter the code") {
+		t.Errorf("Expected a prompt, got %q", out.String())
+	}
+}
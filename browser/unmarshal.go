@@ -0,0 +1,274 @@
+package browser
+
+import (
+	"encoding"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/lostinblue/surf/errors"
+)
+
+// numberPattern matches the first number of a text, eg "1,299.99" in "$1,299.99".
+var numberPattern = regexp.MustCompile(`-?\d[\d,]*(\.\d+)?|-?\.\d+`)
+
+// textUnmarshalerType is the reflect type of encoding.TextUnmarshaler.
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// Unmarshal decodes the current page into the struct pointed to by v, using
+// the surf tags of its fields. A tag holds a CSS selector and, after a comma,
+// how the value is read from the first matching element:
+//
+//	type Product struct {
+//		Name   string   `surf:"h1"`
+//		Price  float64  `surf:"div.price,text"`
+//		Image  *url.URL `surf:"img.main,attr=src"`
+//		Body   string   `surf:"div.description,html"`
+//		Tags   []string `surf:"ul.tags li"`
+//		Offers []struct {
+//			Seller string  `surf:".seller"`
+//			Price  float64 `surf:".price"`
+//		} `surf:"div.offer"`
+//	}
+//
+// The value is read with "text" (the default), "html" or "attr=name". An
+// empty selector reads the element matched by the parent struct.
+//
+// Slices are filled with every matching element, and nested structs are
+// decoded from the matching element. Numbers are parsed from the first number
+// found in the text, ignoring thousands separators, time.Time values are
+// parsed with the layout tag or time.RFC3339, *url.URL values are resolved
+// against the base URL of the page, and types implementing encoding.TextUnmarshaler are
+// decoded with UnmarshalText. Fields without a match are left unchanged.
+func (bow *Browser) Unmarshal(v interface{}) error {
+	if bow.dom() == nil {
+		return errors.NewPageNotLoaded("Cannot unmarshal the page, no page has been loaded.")
+	}
+	return UnmarshalSelection(bow.dom().Selection, bow.documentBase(), v)
+}
+
+// UnmarshalSelection decodes the given selection into the struct pointed to
+// by v. Relative URLs are resolved against base, which may be nil. See
+// Browser.Unmarshal() for the tag syntax.
+func UnmarshalSelection(sel *goquery.Selection, base *url.URL, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return errors.New("Cannot unmarshal into %T, a pointer to a struct is required.", v)
+	}
+	u := &unmarshaler{base: base}
+	return u.unmarshalStruct(sel, rv.Elem())
+}
+
+// ReadSelection returns the value of the first element of sel matching the
+// spec, which uses the syntax of the surf tags, eg "a.title,attr=href". An
+// empty selector reads the first element of sel. Returns false when no
+// element matches.
+func ReadSelection(sel *goquery.Selection, spec string) (string, bool, error) {
+	t := parseSurfTag(spec)
+	if t.selector != "" {
+		sel = sel.Find(t.selector)
+	}
+	if sel.Length() == 0 {
+		return "", false, nil
+	}
+	v, err := readSelection(sel.First(), t)
+	return v, err == nil, err
+}
+
+// unmarshaler decodes selections into values.
+type unmarshaler struct {
+	base *url.URL
+}
+
+// surfTag is a parsed surf struct tag.
+type surfTag struct {
+	selector string
+	read     string
+	attr     string
+}
+
+// parseSurfTag splits the tag into the selector and the way the value is read.
+// Selectors may contain commas, so only a known suffix is removed.
+func parseSurfTag(tag string) surfTag {
+	t := surfTag{selector: tag, read: "text"}
+	i := strings.LastIndex(tag, ",")
+	if i < 0 {
+		return t
+	}
+	opt := strings.TrimSpace(tag[i+1:])
+	switch {
+	case opt == "text" || opt == "html":
+		t.read = opt
+	case strings.HasPrefix(opt, "attr="):
+		t.read, t.attr = "attr", strings.TrimPrefix(opt, "attr=")
+	default:
+		return t
+	}
+	t.selector = strings.TrimSpace(tag[:i])
+	return t
+}
+
+// unmarshalStruct decodes the tagged fields of the struct from the selection.
+func (u *unmarshaler) unmarshalStruct(sel *goquery.Selection, rv reflect.Value) error {
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		tag, ok := sf.Tag.Lookup("surf")
+		if !ok || tag == "-" || sf.PkgPath != "" {
+			continue
+		}
+		t := parseSurfTag(tag)
+		matches := sel
+		if t.selector != "" {
+			matches = sel.Find(t.selector)
+		}
+		if err := u.unmarshalField(matches, rv.Field(i), t, sf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unmarshalField decodes the matching elements into the field.
+func (u *unmarshaler) unmarshalField(matches *goquery.Selection, fv reflect.Value, t surfTag, sf reflect.StructField) error {
+	if matches.Length() == 0 {
+		return nil
+	}
+	if fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() != reflect.Uint8 {
+		slice := reflect.MakeSlice(fv.Type(), 0, matches.Length())
+		var err error
+		matches.EachWithBreak(func(_ int, s *goquery.Selection) bool {
+			ev := reflect.New(fv.Type().Elem()).Elem()
+			if err = u.unmarshalValue(s, ev, t, sf); err != nil {
+				return false
+			}
+			slice = reflect.Append(slice, ev)
+			return true
+		})
+		if err != nil {
+			return err
+		}
+		fv.Set(slice)
+		return nil
+	}
+	return u.unmarshalValue(matches.First(), fv, t, sf)
+}
+
+// unmarshalValue decodes a single element into the value.
+func (u *unmarshaler) unmarshalValue(s *goquery.Selection, fv reflect.Value, t surfTag, sf reflect.StructField) error {
+	if fv.Kind() == reflect.Ptr {
+		if fv.Type() == reflect.TypeOf(&url.URL{}) {
+			return u.unmarshalURL(s, fv, t, sf)
+		}
+		ev := reflect.New(fv.Type().Elem())
+		if err := u.unmarshalValue(s, ev.Elem(), t, sf); err != nil {
+			return err
+		}
+		fv.Set(ev)
+		return nil
+	}
+	if fv.Kind() == reflect.Struct && fv.Type() != reflect.TypeOf(time.Time{}) && !reflect.PtrTo(fv.Type()).Implements(textUnmarshalerType) {
+		return u.unmarshalStruct(s, fv)
+	}
+
+	text, err := readSelection(s, t)
+	if err != nil {
+		return err
+	}
+	if fv.CanAddr() && fv.Addr().Type().Implements(textUnmarshalerType) && fv.Type() != reflect.TypeOf(time.Time{}) {
+		return fv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(text))
+	}
+
+	switch fv.Kind() {
+	case reflect.String:
+		fv.SetString(text)
+		return nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return unmarshalError(text, sf, err)
+		}
+		fv.SetBool(b)
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(findNumber(text), 10, fv.Type().Bits())
+		if err != nil {
+			return unmarshalError(text, sf, err)
+		}
+		fv.SetInt(n)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(findNumber(text), 10, fv.Type().Bits())
+		if err != nil {
+			return unmarshalError(text, sf, err)
+		}
+		fv.SetUint(n)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(findNumber(text), fv.Type().Bits())
+		if err != nil {
+			return unmarshalError(text, sf, err)
+		}
+		fv.SetFloat(n)
+		return nil
+	case reflect.Slice:
+		fv.SetBytes([]byte(text))
+		return nil
+	case reflect.Struct:
+		layout := sf.Tag.Get("layout")
+		if layout == "" {
+			layout = time.RFC3339
+		}
+		tm, err := time.Parse(layout, text)
+		if err != nil {
+			return unmarshalError(text, sf, err)
+		}
+		fv.Set(reflect.ValueOf(tm))
+		return nil
+	}
+	return errors.New("Cannot unmarshal into the field %s of type %s.", sf.Name, fv.Type())
+}
+
+// unmarshalURL decodes the element into a *url.URL resolved against the base URL.
+func (u *unmarshaler) unmarshalURL(s *goquery.Selection, fv reflect.Value, t surfTag, sf reflect.StructField) error {
+	text, err := readSelection(s, t)
+	if err != nil {
+		return err
+	}
+	parsed, err := url.Parse(text)
+	if err != nil {
+		return unmarshalError(text, sf, err)
+	}
+	if u.base != nil {
+		parsed = u.base.ResolveReference(parsed)
+	}
+	fv.Set(reflect.ValueOf(parsed))
+	return nil
+}
+
+// readSelection returns the trimmed text, html or attribute of the element.
+func readSelection(s *goquery.Selection, t surfTag) (string, error) {
+	switch t.read {
+	case "html":
+		h, err := s.Html()
+		return strings.TrimSpace(h), err
+	case "attr":
+		v, _ := s.Attr(t.attr)
+		return strings.TrimSpace(v), nil
+	}
+	return strings.TrimSpace(s.Text()), nil
+}
+
+// findNumber returns the first number in the text without thousands separators.
+func findNumber(text string) string {
+	return strings.Replace(numberPattern.FindString(text), ",", "", -1)
+}
+
+// unmarshalError returns the error for a text which could not be decoded.
+func unmarshalError(text string, sf reflect.StructField, err error) error {
+	return errors.New("Cannot unmarshal %q into the field %s: %s", text, sf.Name, err)
+}
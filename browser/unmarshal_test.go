@@ -0,0 +1,94 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+type testOffer struct {
+	Seller string  `surf:".seller"`
+	Price  float64 `surf:".price"`
+	Link   string  `surf:",attr=data-link"`
+}
+
+type testProduct struct {
+	Name      string      `surf:"h1"`
+	Price     float64     `surf:"div.price,text"`
+	Stock     int         `surf:"span.stock"`
+	Featured  bool        `surf:"div.product,attr=data-featured"`
+	Image     *url.URL    `surf:"img.main,attr=src"`
+	Body      string      `surf:"div.description,html"`
+	Tags      []string    `surf:"ul.tags li"`
+	Released  time.Time   `surf:"time,attr=datetime" layout:"2006-01-02"`
+	Offers    []testOffer `surf:"div.offer"`
+	Headings  []string    `surf:"h1, h2"`
+	Missing   *string     `surf:"div.missing"`
+	Untouched string      `surf:"div.missing"`
+	ignored   string      `surf:"h1"`
+}
+
+func TestUnmarshal(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><body><div class="product" data-featured="true">
+			<h1> Widget </h1>
+			<h2>Details</h2>
+			<div class="price">$1,299.99</div>
+			<span class="stock">12 left</span>
+			<img class="main" src="/img/widget.png">
+			<div class="description"><p>Very <b>nice</b></p></div>
+			<ul class="tags"><li>new</li><li>sale</li></ul>
+			<time datetime="2020-05-17">May 17</time>
+			<div class="offer" data-link="/a"><span class="seller">Acme</span><span class="price">10</span></div>
+			<div class="offer" data-link="/b"><span class="seller">Bolt</span><span class="price">9.5</span></div>
+		</div></body></html>`))
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	p := &testProduct{}
+	if err := bow.Unmarshal(p); err == nil {
+		t.Error("Expected an error when no page has been loaded")
+	}
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	p.Untouched = "default"
+	if err := bow.Unmarshal(p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "Widget" || p.Price != 1299.99 || p.Stock != 12 || !p.Featured {
+		t.Errorf("Unexpected values %+v", p)
+	}
+	if p.Image == nil || p.Image.String() != ts.URL+"/img/widget.png" {
+		t.Errorf("Expected the image URL to be resolved, got %v", p.Image)
+	}
+	if p.Body != "<p>Very <b>nice</b></p>" {
+		t.Errorf("Unexpected body %q", p.Body)
+	}
+	if len(p.Tags) != 2 || p.Tags[1] != "sale" || len(p.Headings) != 2 {
+		t.Errorf("Unexpected tags %v and headings %v", p.Tags, p.Headings)
+	}
+	if !p.Released.Equal(time.Date(2020, 5, 17, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected release date %v", p.Released)
+	}
+	if len(p.Offers) != 2 || p.Offers[1] != (testOffer{Seller: "Bolt", Price: 9.5, Link: "/b"}) {
+		t.Errorf("Unexpected offers %+v", p.Offers)
+	}
+	if p.Missing != nil || p.Untouched != "default" || p.ignored != "" {
+		t.Error("Expected fields without a match to be left unchanged")
+	}
+
+	bad := &struct {
+		Name int `surf:"h1"`
+	}{}
+	if err := bow.Unmarshal(bad); err == nil {
+		t.Error("Expected an error for text which is not a number")
+	}
+	if err := bow.Unmarshal(testProduct{}); err == nil {
+		t.Error("Expected an error for a value which is not a pointer")
+	}
+}
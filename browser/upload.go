@@ -0,0 +1,110 @@
+package browser
+
+import (
+	"io"
+	"net/http"
+	"time"
+)
+
+// DefaultExpectContinueAbove is the global value for the body size above
+// which requests are sent with the Expect: 100-continue header.
+var DefaultExpectContinueAbove int64 = 1 << 20
+
+// DefaultExpectContinueTimeout is the time the transports of the browser
+// wait for a 100 Continue response before sending the body of a request with
+// the Expect: 100-continue header anyway.
+var DefaultExpectContinueTimeout = time.Second
+
+// WithStreamingBody sends the body of this request as it's read, instead of
+// reading it in memory first, eg to upload a large file. The size is the
+// length of the body, or -1 when unknown, in which case the body is sent
+// with the chunked transfer encoding.
+//
+// Streamed requests can't be sent again, so Reload returns an error on
+// the page they load. The multipart bodies of POSTMultipart are streamed
+// too, but they are built again when their files can be read again.
+func WithStreamingBody(size int64) RequestOption {
+	return func(o *requestOptions) {
+		o.streaming = true
+		o.size = size
+	}
+}
+
+// withReplayableBody streams the body of this request like
+// WithStreamingBody, and builds it again with getBody when the request is
+// sent again, eg after a redirect or an authentication challenge.
+func withReplayableBody(size int64, getBody func() (io.ReadCloser, error)) RequestOption {
+	return func(o *requestOptions) {
+		o.streaming = true
+		o.size = size
+		o.getBody = getBody
+	}
+}
+
+// WithUploadProgress calls fn as the body of this request is sent, with
+// the number of bytes sent and the size of the body, or -1 when unknown.
+func WithUploadProgress(fn func(sent, total int64)) RequestOption {
+	return func(o *requestOptions) {
+		o.progress = fn
+	}
+}
+
+// SetExpectContinueAbove sets the body size above which requests are sent
+// with the Expect: 100-continue header, so the server may refuse the
+// request before the body is sent. Bodies of unknown size are sent with the
+// header too. Use 0 to never send the header.
+func (bow *Browser) SetExpectContinueAbove(n int64) {
+	bow.expectContinueAbove = n
+}
+
+// ExpectContinueAbove returns the size set with SetExpectContinueAbove.
+func (bow *Browser) ExpectContinueAbove() int64 {
+	return bow.expectContinueAbove
+}
+
+// prepareUpload sets the length, Expect header and progress reporting of
+// the request body.
+func (bow *Browser) prepareUpload(req *http.Request, o *requestOptions) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return
+	}
+	if o.streaming {
+		req.ContentLength = o.size
+	}
+	if n := bow.expectContinueAbove; n > 0 && (req.ContentLength > n || req.ContentLength <= 0) {
+		req.Header.Set("Expect", "100-continue")
+	}
+	if o.progress != nil {
+		total := req.ContentLength
+		if total == 0 {
+			total = -1
+		}
+		req.Body = &progressReader{ReadCloser: req.Body, total: total, fn: o.progress}
+		if getBody := req.GetBody; getBody != nil {
+			req.GetBody = func() (io.ReadCloser, error) {
+				body, err := getBody()
+				if err != nil {
+					return nil, err
+				}
+				return &progressReader{ReadCloser: body, total: total, fn: o.progress}, nil
+			}
+		}
+	}
+}
+
+// progressReader reports the bytes read from a request body.
+type progressReader struct {
+	io.ReadCloser
+	sent  int64
+	total int64
+	fn    func(sent, total int64)
+}
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	if n > 0 {
+		r.sent += int64(n)
+		r.fn(r.sent, r.total)
+	}
+	return n, err
+}
@@ -0,0 +1,139 @@
+package browser
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestStreamingUpload(t *testing.T) {
+	var expect, encoding string
+	var length int64
+	var received int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		expect = r.Header.Get("Expect")
+		encoding = strings.Join(r.TransferEncoding, ",")
+		length = r.ContentLength
+		b, _ := io.ReadAll(r.Body)
+		received = len(b)
+		w.Write([]byte("<html><body>ok</body></html>"))
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	bow.SetExpectContinueAbove(1024)
+	data := strings.Repeat("x", 4096)
+
+	var sent, total int64
+	progress := WithUploadProgress(func(s, t int64) {
+		sent, total = s, t
+	})
+	body := io.MultiReader(strings.NewReader(data))
+	if err := bow.POST(ts.URL, "application/octet-stream", body, WithStreamingBody(-1), progress); err != nil {
+		t.Fatal(err)
+	}
+	if received != len(data) || encoding != "chunked" || length != -1 {
+		t.Errorf("Expected a chunked body of %d bytes, got %d bytes %q %d", len(data), received, encoding, length)
+	}
+	if expect != "100-continue" {
+		t.Errorf("Expected the Expect header, got %q", expect)
+	}
+	if sent != int64(len(data)) || total != -1 {
+		t.Errorf("Expected progress %d/-1, got %d/%d", len(data), sent, total)
+	}
+	if err := bow.Reload(); err == nil {
+		t.Error("Expected an error reloading a streamed request")
+	}
+
+	body = io.MultiReader(strings.NewReader(data))
+	if err := bow.POST(ts.URL, "application/octet-stream", body, WithStreamingBody(int64(len(data))), progress); err != nil {
+		t.Fatal(err)
+	}
+	if encoding != "" || length != int64(len(data)) || total != int64(len(data)) {
+		t.Errorf("Expected a body of length %d, got %q %d %d", len(data), encoding, length, total)
+	}
+
+	if err := bow.POST(ts.URL, "text/plain", strings.NewReader("small"), progress); err != nil {
+		t.Fatal(err)
+	}
+	if expect != "" || sent != 5 || total != 5 {
+		t.Errorf("Expected no Expect header and progress 5/5, got %q %d/%d", expect, sent, total)
+	}
+	if err := bow.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if received != 5 || sent != 5 {
+		t.Errorf("Expected the body to be sent again, got %d bytes and progress %d", received, sent)
+	}
+}
+
+func TestMultipartStreaming(t *testing.T) {
+	var length int64
+	var files []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/", http.StatusTemporaryRedirect)
+			return
+		}
+		length = r.ContentLength
+		files = nil
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		for _, fh := range r.MultipartForm.File["upload"] {
+			files = append(files, fh.Filename)
+		}
+		w.Write([]byte("<html><body>" + r.FormValue("name") + "</body></html>"))
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	fields := url.Values{"name": {"surf"}}
+
+	// The size of the body is known from the size of the files.
+	known := FileSet{"upload": {NewFile("a.txt", strings.NewReader(strings.Repeat("a", 1000)))}}
+	if err := bow.POSTMultipart(ts.URL, fields, known); err != nil {
+		t.Fatal(err)
+	}
+	if length <= 1000 || bow.Body() != "surf" || len(files) != 1 {
+		t.Errorf("Expected a sized body, got length %d, body %q and files %v", length, bow.Body(), files)
+	}
+
+	// The body is built again for redirects and reloads.
+	length, files = 0, nil
+	resent := FileSet{"upload": {NewFile("a.txt", strings.NewReader(strings.Repeat("a", 1000)))}}
+	if err := bow.POSTMultipart(ts.URL+"/redirect", fields, resent); err != nil {
+		t.Fatal(err)
+	}
+	if length <= 1000 || len(files) != 1 {
+		t.Errorf("Expected the body to be sent again after a redirect, got length %d and files %v", length, files)
+	}
+	files = nil
+	if err := bow.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 {
+		t.Errorf("Expected the body to be sent again by Reload, got files %v", files)
+	}
+
+	// Otherwise the body is sent chunked.
+	unknown := FileSet{"upload": {
+		NewFile("a.txt", io.MultiReader(strings.NewReader("a"))),
+		NewFile("b.txt", io.MultiReader(strings.NewReader("b"))),
+	}}
+	if err := bow.POSTMultipart(ts.URL, fields, unknown); err != nil {
+		t.Fatal(err)
+	}
+	if length != -1 || len(files) != 2 {
+		t.Errorf("Expected a chunked body with 2 files, got length %d and files %v", length, files)
+	}
+
+	missing := FileSet{"upload": {&File{fileName: "missing.txt", path: "/nonexistent/missing.txt"}}}
+	if err := bow.POSTMultipart(ts.URL, fields, missing); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
@@ -0,0 +1,117 @@
+package browser
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/lostinblue/surf/errors"
+)
+
+// namedFields selects the fields of a form which are submitted by name.
+const namedFields = "input[name]:not([type=hidden]):not([type=submit]):not([type=button]), select[name], textarea[name]"
+
+// SubmitAndValidate submits the form, and returns a ValidationError when the
+// returned page has elements matching errorSelector, eg ".error" or
+// ".invalid-feedback". The messages are the texts of the elements, and the
+// field of a message is found from:
+//
+//   - the data-field, data-for or for attribute of the element, which is the
+//     name or the id of the field;
+//   - the field whose aria-describedby or aria-errormessage attribute has
+//     the id of the element;
+//   - the closest container of the element with a single named field.
+//
+// The messages whose field is not found are ValidationError.Messages.
+// Elements matching the selector which are fields themselves, eg
+// "input.is-invalid", give a message from their title attribute.
+func (f *Form) SubmitAndValidate(errorSelector string, opts ...RequestOption) error {
+	if err := f.Submit(opts...); err != nil {
+		return err
+	}
+	doc := f.bow.DOM()
+	if doc == nil {
+		return nil
+	}
+	return validationErrors(doc.Selection, errorSelector)
+}
+
+// validationErrors returns a ValidationError with the messages of the error
+// elements of the page, or nil when there are none.
+func validationErrors(page *goquery.Selection, errorSelector string) error {
+	fields := make(map[string][]string)
+	var messages []string
+	page.Find(errorSelector).Each(func(_ int, el *goquery.Selection) {
+		msg := strings.Join(strings.Fields(el.Text()), " ")
+		name := ""
+		if el.Is(namedFields) {
+			name, _ = el.Attr("name")
+			if title, ok := el.Attr("title"); ok && msg == "" {
+				msg = strings.TrimSpace(title)
+			}
+			if msg == "" {
+				msg = "Invalid value."
+			}
+		} else {
+			name = errorField(page, el)
+		}
+		if msg == "" {
+			return
+		}
+		if name == "" {
+			messages = append(messages, msg)
+			return
+		}
+		fields[name] = append(fields[name], msg)
+	})
+	if len(fields) == 0 && len(messages) == 0 {
+		return nil
+	}
+	return errors.NewValidationError(fields, messages)
+}
+
+// errorField returns the name of the field of an error element, or an empty
+// string when it's not found.
+func errorField(page *goquery.Selection, el *goquery.Selection) string {
+	for _, attr := range []string{"data-field", "data-for", "for"} {
+		if v, ok := el.Attr(attr); ok && v != "" {
+			if field := findByID(page, v); field.Is(namedFields) {
+				name, _ := field.Attr("name")
+				return name
+			}
+			return v
+		}
+	}
+	if id, ok := el.Attr("id"); ok && id != "" {
+		name := ""
+		page.Find("[aria-describedby], [aria-errormessage]").EachWithBreak(func(_ int, field *goquery.Selection) bool {
+			refs := field.AttrOr("aria-describedby", "") + " " + field.AttrOr("aria-errormessage", "")
+			for _, ref := range strings.Fields(refs) {
+				if ref == id {
+					name = field.AttrOr("name", "")
+					return false
+				}
+			}
+			return true
+		})
+		if name != "" {
+			return name
+		}
+	}
+	for p := el.Parent(); p.Length() > 0 && !p.Is("body"); p = p.Parent() {
+		named := p.Find(namedFields)
+		if named.Length() == 1 {
+			return named.AttrOr("name", "")
+		}
+		if named.Length() > 1 || p.Is("form") {
+			break
+		}
+	}
+	return ""
+}
+
+// findByID returns the element of the page with the id.
+func findByID(page *goquery.Selection, id string) *goquery.Selection {
+	return page.Find("[id]").FilterFunction(func(_ int, s *goquery.Selection) bool {
+		return s.AttrOr("id", "") == id
+	}).First()
+}
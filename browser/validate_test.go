@@ -0,0 +1,81 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+const signupPage = `<html><head><title>Signup</title></head><body>
+<div class="alert error">Please fix the errors below.</div>
+<form method="post" action="/signup">
+	<div class="group">
+		<label>Email <input type="email" name="email"></label>
+		<span class="error">Invalid email address.</span>
+	</div>
+	<input type="password" id="pwd" name="password" aria-describedby="pwd-help pwd-error">
+	<p id="pwd-error" class="error">Too short.</p>
+	<label class="error" for="pwd">Must contain a digit.</label>
+	<input type="text" name="username" class="error" title="Already taken.">
+	<span class="error" data-field="terms">You must accept the terms.</span>
+	<input type="hidden" name="token" value="x">
+	<input type="submit" name="go" value="Sign up">
+</form>
+</body></html>`
+
+func TestSubmitAndValidate(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" && r.FormValue("email") == "ok@example.com" {
+			w.Write([]byte("<html><head><title>Welcome</title></head></html>"))
+			return
+		}
+		w.Write([]byte(signupPage))
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	form, err := bow.Form("form")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = form.SubmitAndValidate(".error")
+	verr, ok := err.(errors.ValidationError)
+	if !ok {
+		t.Fatalf("Expected a ValidationError, got %v.", err)
+	}
+	expected := map[string][]string{
+		"email":    {"Invalid email address."},
+		"password": {"Too short.", "Must contain a digit."},
+		"username": {"Already taken."},
+		"terms":    {"You must accept the terms."},
+	}
+	for name, msgs := range expected {
+		if got := verr.Fields[name]; len(got) != len(msgs) || got[0] != msgs[0] || got[len(got)-1] != msgs[len(msgs)-1] {
+			t.Errorf("Expected the messages %q for '%s', got %q.", msgs, name, got)
+		}
+	}
+	if len(verr.Fields) != len(expected) {
+		t.Errorf("Expected %d fields with errors, got %v.", len(expected), verr.Fields)
+	}
+	if len(verr.Messages) != 1 || verr.Messages[0] != "Please fix the errors below." {
+		t.Errorf("Expected the general message, got %q.", verr.Messages)
+	}
+	if want := "Validation failed: email: Invalid email address.; password: Too short., Must contain a digit.; " +
+		"terms: You must accept the terms.; username: Already taken.; Please fix the errors below."; verr.Error() != want {
+		t.Errorf("Unexpected error message '%s'.", verr.Error())
+	}
+
+	form, _ = bow.Form("form")
+	form.Input("email", "ok@example.com")
+	if err := form.SubmitAndValidate(".error"); err != nil {
+		t.Errorf("Expected no validation error, got %v.", err)
+	}
+	if bow.Title() != "Welcome" {
+		t.Errorf("Expected the page of the submission, got '%s'.", bow.Title())
+	}
+}
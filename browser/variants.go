@@ -0,0 +1,321 @@
+package browser
+
+import (
+	"bytes"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/lostinblue/surf/errors"
+)
+
+// Variant is one of the URLs of a responsive image: a candidate of a srcset
+// attribute, or the src of the image.
+type Variant struct {
+	DownloadableAsset
+
+	// Width is the width of the candidate, eg 640 for "640w", or 0 when the
+	// candidate has a density descriptor.
+	Width int
+
+	// Density is the pixel density of the candidate, eg 2 for "2x". It's 1
+	// when the candidate has no descriptor, and 0 when it has a width.
+	Density float64
+
+	// Media is the media attribute of the <source> element of the
+	// candidate, eg "(min-width: 800px)", or empty.
+	Media string
+
+	// MimeType is the type attribute of the <source> element of the
+	// candidate, eg "image/webp", or empty.
+	MimeType string
+}
+
+// ResponsiveImage is an image with its variants, from its srcset attribute
+// and the <source> elements of its <picture> parent.
+type ResponsiveImage struct {
+	*Image
+
+	// Sizes is the sizes attribute of the image, eg "(max-width: 600px) 100vw, 50vw".
+	Sizes string
+
+	// Variants are the variants of the image, in the order browsers consider
+	// them: the <source> elements first, then the srcset and src of the image.
+	Variants []*Variant
+}
+
+// VariantSelector chooses the variant of a responsive image, or returns nil
+// when none is suitable.
+type VariantSelector func(variants []*Variant) *Variant
+
+// ResponsiveImages returns the images of the page with their variants.
+func (bow *Browser) ResponsiveImages() []*ResponsiveImage {
+	images := make([]*ResponsiveImage, 0, InitialAssetsSliceSize)
+	bow.Find("img").Each(func(_ int, s *goquery.Selection) {
+		img := &ResponsiveImage{
+			Image: NewImageAsset(nil, s.AttrOr("id", ""), s.AttrOr("alt", ""), s.AttrOr("title", "")),
+			Sizes: s.AttrOr("sizes", ""),
+		}
+		img.bow = bow
+		if s.Parent().Is("picture") {
+			// PrevAll returns the siblings in reverse order.
+			sources := s.PrevAllFiltered("source")
+			for i := sources.Length() - 1; i >= 0; i-- {
+				src := sources.Eq(i)
+				img.Variants = append(img.Variants, bow.srcsetVariants(src.AttrOr("srcset", ""), src)...)
+			}
+		}
+		img.Variants = append(img.Variants, bow.srcsetVariants(s.AttrOr("srcset", ""), nil)...)
+		if src, err := bow.attrToResolvedURL("src", s); err == nil && s.AttrOr("src", "") != "" {
+			img.URL = src
+			if !hasDensity(img.Variants, src, 1) {
+				img.Variants = append(img.Variants, newVariant(src, 0, 1))
+			}
+		}
+		if len(img.Variants) == 0 {
+			return
+		}
+		if img.URL == nil {
+			img.URL = img.Variants[len(img.Variants)-1].URL
+		}
+		images = append(images, img)
+	})
+	return images
+}
+
+// srcsetVariants returns the candidates of a srcset attribute, with the
+// media and type of the <source> element.
+func (bow *Browser) srcsetVariants(srcset string, source *goquery.Selection) []*Variant {
+	var variants []*Variant
+	for _, c := range parseSrcset(srcset) {
+		ref, err := url.Parse(c.url)
+		if err != nil {
+			continue
+		}
+		u := ref
+		if base := bow.URL(); base != nil {
+			u = bow.ResolveURL(ref)
+		}
+		v := newVariant(u, c.width, c.density)
+		if source != nil {
+			v.Media = strings.TrimSpace(source.AttrOr("media", ""))
+			v.MimeType = strings.TrimSpace(source.AttrOr("type", ""))
+		}
+		variants = append(variants, v)
+	}
+	return variants
+}
+
+// newVariant creates and returns a new *Variant type.
+func newVariant(u *url.URL, width int, density float64) *Variant {
+	return &Variant{
+		DownloadableAsset: DownloadableAsset{
+			Asset: Asset{URL: u, Type: ImageAsset},
+		},
+		Width:   width,
+		Density: density,
+	}
+}
+
+// hasDensity returns whether a variant of the image, outside <source>
+// elements, has the URL and density.
+func hasDensity(variants []*Variant, u *url.URL, density float64) bool {
+	for _, v := range variants {
+		if v.Media == "" && v.MimeType == "" && v.Density == density && v.URL.String() == u.String() {
+			return true
+		}
+	}
+	return false
+}
+
+// srcsetCandidate is a candidate of a srcset attribute.
+type srcsetCandidate struct {
+	url     string
+	width   int
+	density float64
+}
+
+// parseSrcset returns the candidates of a srcset attribute, following the
+// parsing rules of the HTML specification. Candidates with an invalid
+// descriptor are skipped.
+func parseSrcset(srcset string) []srcsetCandidate {
+	var candidates []srcsetCandidate
+	s := srcset
+	for {
+		s = strings.TrimLeft(s, " \t\n\r\f,")
+		if s == "" {
+			return candidates
+		}
+		end := strings.IndexAny(s, " \t\n\r\f")
+		if end < 0 {
+			end = len(s)
+		}
+		u := s[:end]
+		s = s[end:]
+		var descriptors string
+		if strings.HasSuffix(u, ",") {
+			u = strings.TrimRight(u, ",")
+		} else {
+			depth, i := 0, 0
+			for ; i < len(s); i++ {
+				if s[i] == '(' {
+					depth++
+				} else if s[i] == ')' && depth > 0 {
+					depth--
+				} else if s[i] == ',' && depth == 0 {
+					break
+				}
+			}
+			descriptors, s = s[:i], s[i:]
+		}
+		if c, ok := parseDescriptors(u, strings.Fields(descriptors)); ok {
+			candidates = append(candidates, c)
+		}
+	}
+}
+
+// parseDescriptors returns the candidate of the URL with the descriptors,
+// and whether they are valid.
+func parseDescriptors(u string, descriptors []string) (srcsetCandidate, bool) {
+	c := srcsetCandidate{url: u}
+	for _, d := range descriptors {
+		if len(d) < 2 {
+			return c, false
+		}
+		value := d[:len(d)-1]
+		switch d[len(d)-1] {
+		case 'w':
+			w, err := strconv.Atoi(value)
+			if err != nil || w <= 0 || c.width != 0 || c.density != 0 {
+				return c, false
+			}
+			c.width = w
+		case 'x':
+			x, err := strconv.ParseFloat(value, 64)
+			if err != nil || x <= 0 || c.width != 0 || c.density != 0 {
+				return c, false
+			}
+			c.density = x
+		case 'h':
+			// The height descriptor only qualifies a width descriptor.
+		default:
+			return c, false
+		}
+	}
+	if c.width == 0 && c.density == 0 {
+		c.density = 1
+	}
+	return c, c.url != ""
+}
+
+// Select returns the variant chosen by the selector, or nil.
+func (img *ResponsiveImage) Select(s VariantSelector) *Variant {
+	return s(img.Variants)
+}
+
+// DownloadVariant writes the variant chosen by the selector to out, and
+// returns it with the number of bytes written. The variant is downloaded
+// with the session of the browser which found the image.
+func (img *ResponsiveImage) DownloadVariant(s VariantSelector, out io.Writer) (*Variant, int64, error) {
+	v := img.Select(s)
+	if v == nil {
+		return nil, 0, errors.NewElementNotFound("No variant of the image '%s' was selected.", img.URL)
+	}
+	if img.bow == nil {
+		n, err := v.Download(out)
+		return v, n, err
+	}
+	data, _, err := img.bow.fetchAsset(v.URL.String())
+	if err != nil {
+		return v, 0, err
+	}
+	n, err := io.Copy(out, bytes.NewReader(data))
+	return v, n, err
+}
+
+// Largest selects the largest variant. The variants with a width are
+// compared by width, the others by density, and are considered smaller than
+// the variants with a width, as the src of an image usually is. The first
+// of the variants of the same size is selected.
+func Largest() VariantSelector {
+	return func(variants []*Variant) *Variant {
+		var best *Variant
+		for _, v := range variants {
+			if best == nil || smallerVariant(best, v) {
+				best = v
+			}
+		}
+		return best
+	}
+}
+
+// Smallest selects the smallest variant, see Largest().
+func Smallest() VariantSelector {
+	return func(variants []*Variant) *Variant {
+		var best *Variant
+		for _, v := range variants {
+			if best == nil || smallerVariant(v, best) {
+				best = v
+			}
+		}
+		return best
+	}
+}
+
+// smallerVariant returns whether a is smaller than b, see Largest().
+func smallerVariant(a, b *Variant) bool {
+	switch {
+	case a.Width > 0 && b.Width > 0:
+		return a.Width < b.Width
+	case a.Width > 0 || b.Width > 0:
+		return b.Width > 0
+	}
+	return a.Density < b.Density
+}
+
+// ForMedia selects the variant a browser with the viewport and the pixel
+// density would load: the candidates of the first <source> whose media
+// query matches, or of the image when none does, then the smallest
+// candidate large enough for the viewport width, or density, or the largest
+// one when none is. The images are assumed to fill the width of the
+// viewport, since the sizes attribute is not evaluated.
+func ForMedia(width, height int, density float64) VariantSelector {
+	if density <= 0 {
+		density = 1
+	}
+	return func(variants []*Variant) *Variant {
+		var set []*Variant
+		for i, v := range variants {
+			if v.Media != "" && !MatchMedia(v.Media, width, height, density) {
+				continue
+			}
+			// The candidates of a <source> share its media and type.
+			for _, c := range variants[i:] {
+				if c.Media != v.Media || c.MimeType != v.MimeType {
+					break
+				}
+				set = append(set, c)
+			}
+			break
+		}
+		var best, largest *Variant
+		for _, v := range set {
+			need, have := density, v.Density
+			if v.Width > 0 {
+				need, have = float64(width)*density, float64(v.Width)
+			}
+			if have >= need && (best == nil || smallerVariant(v, best)) {
+				best = v
+			}
+			if largest == nil || smallerVariant(largest, v) {
+				largest = v
+			}
+		}
+		if best != nil {
+			return best
+		}
+		return largest
+	}
+}
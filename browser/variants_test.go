@@ -0,0 +1,157 @@
+package browser
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseSrcset(t *testing.T) {
+	tests := []struct {
+		srcset string
+		want   []srcsetCandidate
+	}{
+		{"a.png", []srcsetCandidate{{"a.png", 0, 1}}},
+		{"a.png 1x, b.png 2x", []srcsetCandidate{{"a.png", 0, 1}, {"b.png", 0, 2}}},
+		{" a.png 320w,\n b.png 640w ", []srcsetCandidate{{"a.png", 320, 0}, {"b.png", 640, 0}}},
+		{"a.png, b.png 2x,", []srcsetCandidate{{"a.png", 0, 1}, {"b.png", 0, 2}}},
+		{"/img/a,b.png 1.5x", []srcsetCandidate{{"/img/a,b.png", 0, 1.5}}},
+		{"a.png 320w 240h", []srcsetCandidate{{"a.png", 320, 0}}},
+		{"a.png 2y, b.png 1x 2x, c.png -1w, d.png 2x", []srcsetCandidate{{"d.png", 0, 2}}},
+		{"", nil},
+	}
+	for _, test := range tests {
+		got := parseSrcset(test.srcset)
+		if len(got) != len(test.want) {
+			t.Errorf("parseSrcset(%q): expected %v, got %v.", test.srcset, test.want, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("parseSrcset(%q): expected %v, got %v.", test.srcset, test.want, got)
+				break
+			}
+		}
+	}
+}
+
+func TestMatchMedia(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"(min-width: 800px)", true},
+		{"(min-width: 1200px)", false},
+		{"(max-width: 50em)", false},
+		{"screen and (min-width: 600px) and (max-width: 1200px)", true},
+		{"print and (min-width: 600px)", false},
+		{"only screen and (orientation: landscape)", true},
+		{"not screen and (orientation: landscape)", false},
+		{"(orientation: portrait), (min-resolution: 2dppx)", true},
+		{"(min-resolution: 192dpi)", true},
+		{"(-webkit-min-device-pixel-ratio: 3)", false},
+		{"(width >= 1024px)", true},
+		{"(height < 768px)", false},
+		{"(hover: hover)", false},
+		{"(min-width: wide)", false},
+		{"all", true},
+		{"", false},
+	}
+	for _, test := range tests {
+		if got := MatchMedia(test.query, 1024, 768, 2); got != test.want {
+			t.Errorf("MatchMedia(%q): expected %v, got %v.", test.query, test.want, got)
+		}
+	}
+}
+
+func TestResponsiveImages(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "1"})
+			w.Write([]byte(`<html><body>
+				<picture>
+					<source media="(min-width: 1000px)" srcset="/wide.webp 1600w, /wide-small.webp 1000w" type="image/webp">
+					<source media="(min-width: 600px)" srcset="/medium.jpg">
+					<img id="photo" src="/small.jpg" srcset="/small.jpg 1x, /small@2x.jpg 2x" alt="A photo">
+				</picture>
+				<img src="/plain.png">
+				<img alt="No source">
+			</body></html>`))
+		case "/small@2x.jpg":
+			if c, err := r.Cookie("session"); err != nil || c.Value != "1" {
+				http.Error(w, "forbidden", http.StatusForbidden)
+				return
+			}
+			w.Write([]byte("2x"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	images := bow.ResponsiveImages()
+	if len(images) != 2 {
+		t.Fatalf("Expected 2 images, got %d.", len(images))
+	}
+	img := images[0]
+	if img.ID != "photo" || img.Alt != "A photo" || img.URL.Path != "/small.jpg" {
+		t.Errorf("Unexpected image %+v.", img.Image)
+	}
+	var paths []string
+	for _, v := range img.Variants {
+		paths = append(paths, v.URL.Path)
+	}
+	want := []string{"/wide.webp", "/wide-small.webp", "/medium.jpg", "/small.jpg", "/small@2x.jpg"}
+	if len(paths) != len(want) {
+		t.Fatalf("Expected the variants %v, got %v.", want, paths)
+	}
+	for i := range want {
+		if paths[i] != want[i] {
+			t.Fatalf("Expected the variants %v, got %v.", want, paths)
+		}
+	}
+	if v := img.Variants[0]; v.Width != 1600 || v.Media != "(min-width: 1000px)" || v.MimeType != "image/webp" {
+		t.Errorf("Unexpected variant %+v.", v)
+	}
+	if len(images[1].Variants) != 1 || images[1].Variants[0].Density != 1 {
+		t.Errorf("Expected the src as the only variant, got %v.", images[1].Variants)
+	}
+
+	selections := []struct {
+		name     string
+		selector VariantSelector
+		want     string
+	}{
+		{"Largest", Largest(), "/wide.webp"},
+		{"Smallest", Smallest(), "/medium.jpg"},
+		{"ForMedia desktop", ForMedia(1200, 800, 1), "/wide.webp"},
+		{"ForMedia laptop", ForMedia(1000, 700, 1), "/wide-small.webp"},
+		{"ForMedia tablet", ForMedia(800, 1000, 2), "/medium.jpg"},
+		{"ForMedia phone", ForMedia(400, 700, 2), "/small@2x.jpg"},
+		{"ForMedia phone 3x", ForMedia(400, 700, 3), "/small@2x.jpg"},
+	}
+	for _, s := range selections {
+		if v := img.Select(s.selector); v == nil || v.URL.Path != s.want {
+			t.Errorf("%s: expected %s, got %v.", s.name, s.want, v)
+		}
+	}
+
+	var out bytes.Buffer
+	v, n, err := img.DownloadVariant(ForMedia(400, 700, 2), &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.URL.Path != "/small@2x.jpg" || n != 2 || out.String() != "2x" {
+		t.Errorf("Expected the 2x variant, got %s with %q.", v.URL, out.String())
+	}
+	none := func([]*Variant) *Variant { return nil }
+	if _, _, err := img.DownloadVariant(none, &out); err == nil {
+		t.Error("Expected an error when no variant is selected.")
+	}
+}
@@ -0,0 +1,58 @@
+package browser
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// WriteTo writes the page bytes, as received, to w. It implements
+// io.WriterTo.
+func (bow *Browser) WriteTo(w io.Writer) (int64, error) {
+	return io.Copy(w, bytes.NewReader(bow.body))
+}
+
+// WriteDOM renders the DOM of the page, including the changes made with
+// MutateDom, and writes it to w.
+//
+// The page is not transcoded, so the output declares the charset of the
+// response with a meta tag, replacing the declarations of the page, for the
+// saved page to be read with the encoding it was received with.
+func (bow *Browser) WriteDOM(w io.Writer) (int64, error) {
+	doc := bow.dom()
+	if doc == nil {
+		return 0, nil
+	}
+	clone := goquery.NewDocumentFromNode(doc.Selection.Clone().Get(0))
+	if cs := bow.Charset(); cs != "" {
+		declareCharset(clone, cs)
+	}
+	var buf bytes.Buffer
+	for _, n := range clone.Nodes {
+		if err := html.Render(&buf, n); err != nil {
+			return 0, err
+		}
+	}
+	return buf.WriteTo(w)
+}
+
+// declareCharset replaces the charset declarations of the document with a
+// meta tag declaring the given charset.
+func declareCharset(doc *goquery.Document, cs string) {
+	doc.Find("meta[charset], meta[http-equiv='content-type' i]").Remove()
+	head := doc.Find("head")
+	if head.Length() == 0 {
+		return
+	}
+	meta := &html.Node{
+		Type:     html.ElementNode,
+		Data:     "meta",
+		DataAtom: atom.Meta,
+		Attr:     []html.Attribute{{Key: "charset", Val: cs}},
+	}
+	h := head.Get(0)
+	h.InsertBefore(meta, h.FirstChild)
+}
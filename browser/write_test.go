@@ -0,0 +1,52 @@
+package browser
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Browser implements io.WriterTo.
+var _ io.WriterTo = (*Browser)(nil)
+
+func TestWriteTo(t *testing.T) {
+	page := "<html><head><meta http-equiv=\"Content-Type\" content=\"text/html; charset=utf-8\"><title>Caf\xe9</title></head>" +
+		"<body><p>Caf\xe9</p></body></html>"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=ISO-8859-1")
+		w.Write([]byte(page))
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	n, err := bow.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(len(page)) || buf.String() != page {
+		t.Errorf("Expected the page as received, got %d bytes %q", n, buf.String())
+	}
+
+	buf.Reset()
+	if _, err := bow.WriteDOM(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.HasPrefix(out, `<html><head><meta charset="ISO-8859-1"/><title>Caf`+"\xe9</title>") {
+		t.Errorf("Expected the response charset to be declared, got %q", out)
+	}
+	if strings.Contains(out, "utf-8") {
+		t.Errorf("Expected the page declaration to be removed, got %q", out)
+	}
+	if bow.Find("meta[http-equiv]").Length() != 1 {
+		t.Error("Expected the page DOM not to be modified")
+	}
+}
@@ -0,0 +1,43 @@
+package browser
+
+import (
+	"github.com/beevik/etree"
+	"github.com/lostinblue/surf/errors"
+	"golang.org/x/net/html/charset"
+)
+
+// XML parses the current page as XML, eg a sitemap, an RSS feed or an XML
+// API response, and returns the document. Query it with XPath-like paths:
+//
+//	doc, err := bow.XML()
+//	if err != nil {
+//		return err
+//	}
+//	for _, loc := range doc.FindElements("//url/loc") {
+//		fmt.Println(loc.Text())
+//	}
+//
+// The page is parsed strictly, unlike the HTML pages, so malformed documents
+// return an error rather than a repaired tree. The charset declared by the
+// XML declaration is decoded to UTF-8, and the parser limits apply.
+//
+// The body is parsed on each call, and the HTML DOM of the page is not
+// built, so XML endpoints can be scraped with the ParseDOM attribute set to
+// false.
+func (bow *Browser) XML() (*etree.Document, error) {
+	if bow.state.Body == nil {
+		return nil, errors.NewPageNotLoaded("Cannot parse the page as XML, no page has been loaded.")
+	}
+	body := bow.state.Body
+	if l := bow.parserLimits; l.MaxNodes > 0 || l.MaxDepth > 0 {
+		if err := checkParserLimits(body, l); err != nil {
+			return nil, err
+		}
+	}
+	doc := etree.NewDocument()
+	doc.ReadSettings.CharsetReader = charset.NewReaderLabel
+	if err := doc.ReadFromBytes(body); err != nil {
+		return nil, errors.New("The page '%s' is not well-formed XML: %s", bow.URL(), err)
+	}
+	return doc, nil
+}
@@ -0,0 +1,68 @@
+package browser
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+func TestXML(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/xml")
+		switch r.URL.Path {
+		case "/sitemap.xml":
+			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+				<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+					<url><loc>http://example.com/a</loc></url>
+					<url><loc>http://example.com/b</loc></url>
+				</urlset>`))
+		case "/feed.xml":
+			w.Write([]byte(`<?xml version="1.0" encoding="ISO-8859-1"?><rss><channel><title>Caf` + "\xe9" + `</title></channel></rss>`))
+		case "/broken.xml":
+			w.Write([]byte(`<rss><channel><title>Open</channel></rss>`))
+		}
+	}))
+	defer ts.Close()
+
+	bow := newDefaultTestBrowser()
+	if _, err := bow.XML(); err == nil {
+		t.Error("Expected an error before the first page.")
+	} else if _, ok := err.(errors.PageNotLoaded); !ok {
+		t.Errorf("Expected a PageNotLoaded error before the first page, got %v.", err)
+	}
+	bow.SetAttribute(ParseDOM, false)
+	if err := bow.GET(ts.URL + "/sitemap.xml"); err != nil {
+		t.Fatal(err)
+	}
+	doc, err := bow.XML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	locs := doc.FindElements("//url/loc")
+	if len(locs) != 2 || locs[0].Text() != "http://example.com/a" || locs[1].Text() != "http://example.com/b" {
+		t.Errorf("Expected the 2 locations of the sitemap, got %d.", len(locs))
+	}
+	if bow.state.Dom != nil {
+		t.Error("Expected the page not to be parsed as HTML.")
+	}
+
+	if err := bow.GET(ts.URL + "/feed.xml"); err != nil {
+		t.Fatal(err)
+	}
+	doc, err = bow.XML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if title := doc.FindElement("//channel/title"); title == nil || title.Text() != "Café" {
+		t.Errorf("Expected the title decoded from ISO-8859-1, got %v.", title)
+	}
+
+	if err := bow.GET(ts.URL + "/broken.xml"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bow.XML(); err == nil {
+		t.Error("Expected an error for a malformed document.")
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/lostinblue/surf/browser"
+)
+
+// asset is the JSON output describing a downloaded asset.
+type asset struct {
+	URL   string `json:"url"`
+	File  string `json:"file,omitempty"`
+	Bytes int64  `json:"bytes"`
+	Error string `json:"error,omitempty"`
+}
+
+// cmdDownloadAssets downloads the images, stylesheets and scripts of a page
+// to a directory, and writes the list of downloaded files.
+func cmdDownloadAssets(args []string, stdout io.Writer) error {
+	fs, o := newFlagSet("download-assets")
+	dir := fs.String("dir", ".", "the `directory` the assets are saved to")
+	types := fs.String("types", "images,stylesheets,scripts", "the comma separated `types` of assets to download")
+	u, err := parseURL(fs, args)
+	if err != nil {
+		return err
+	}
+	bow, done, err := o.newBrowser()
+	if err != nil {
+		return err
+	}
+	if err := bow.GET(u); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(*dir, 0755); err != nil {
+		return err
+	}
+
+	var downloads []browser.DownloadableAsset
+	for _, t := range strings.Split(*types, ",") {
+		switch strings.TrimSpace(t) {
+		case "images":
+			for _, a := range bow.Images() {
+				downloads = append(downloads, a.DownloadableAsset)
+			}
+		case "stylesheets":
+			for _, a := range bow.Stylesheets() {
+				downloads = append(downloads, a.DownloadableAsset)
+			}
+		case "scripts":
+			for _, a := range bow.Scripts() {
+				downloads = append(downloads, a.DownloadableAsset)
+			}
+		default:
+			return fmt.Errorf("download-assets: unknown asset type %q", t)
+		}
+	}
+
+	used := make(map[string]bool)
+	results := []asset{}
+	for i, a := range downloads {
+		r := asset{URL: a.URL.String(), File: filepath.Join(*dir, assetName(a, i, used))}
+		r.Bytes, err = downloadTo(bow, a, r.File)
+		if err != nil {
+			r.Error = err.Error()
+		}
+		results = append(results, r)
+	}
+	if err := writeJSON(stdout, results); err != nil {
+		return err
+	}
+	return done()
+}
+
+// assetName returns a file name for the asset which is not used yet.
+func assetName(a browser.DownloadableAsset, i int, used map[string]bool) string {
+	name := path.Base(a.URL.Path)
+	if name == "/" || name == "." {
+		name = fmt.Sprintf("asset-%d", i)
+	}
+	if used[name] {
+		name = fmt.Sprintf("%d-%s", i, name)
+	}
+	used[name] = true
+	return name
+}
+
+// downloadTo downloads the asset to the file with the session of the
+// browser, so its cookies, headers and credentials are sent.
+func downloadTo(bow *browser.Browser, a browser.DownloadableAsset, file string) (int64, error) {
+	f, err := os.Create(file)
+	if err != nil {
+		return 0, err
+	}
+	ch := make(browser.AsyncDownloadChannel, 1)
+	bow.DownloadAssetAsync(context.Background(), a, f, ch)
+	res := <-ch
+	err = res.Error
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return res.Size, err
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/lostinblue/surf/browser"
+	"github.com/lostinblue/surf/jar"
+)
+
+// cookieFile is a cookie jar saved to a JSON file between runs, as a map of
+// the cookies of each host.
+type cookieFile struct {
+	*jar.MemoryCookies
+	path  string
+	mu    sync.Mutex
+	hosts map[string]bool
+}
+
+// loadCookies reads the cookies saved in the file, which may not exist yet,
+// and sets the jar on the browser.
+func loadCookies(path string, bow *browser.Browser) (*cookieFile, error) {
+	c := &cookieFile{MemoryCookies: jar.NewCookiesJar(), path: path, hosts: make(map[string]bool)}
+	b, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if len(b) > 0 {
+		saved := make(map[string][]*http.Cookie)
+		if err := json.Unmarshal(b, &saved); err != nil {
+			return nil, err
+		}
+		for host, cookies := range saved {
+			for _, cookie := range cookies {
+				// Cookies scoped to the host itself were host-only cookies.
+				if cookie.Domain == host {
+					cookie.Domain = ""
+				}
+			}
+			c.SetCookies(&url.URL{Scheme: "https", Host: host, Path: "/"}, cookies)
+		}
+	}
+	bow.SetCookieJar(c)
+	return c, nil
+}
+
+// SetCookies stores the cookies, and records the host they were set by.
+func (c *cookieFile) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	c.mu.Lock()
+	c.hosts[u.Hostname()] = true
+	c.mu.Unlock()
+	c.MemoryCookies.SetCookies(u, cookies)
+}
+
+// save writes the cookies of every host which set cookies to the file.
+func (c *cookieFile) save(bow *browser.Browser) error {
+	c.mu.Lock()
+	hosts := make([]string, 0, len(c.hosts))
+	for host := range c.hosts {
+		hosts = append(hosts, host)
+	}
+	c.mu.Unlock()
+	sort.Strings(hosts)
+
+	saved := make(map[string][]*http.Cookie)
+	for _, host := range hosts {
+		if cookies := c.HostCookies(host); len(cookies) > 0 {
+			saved[host] = cookies
+		}
+	}
+	b, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(c.path, b, 0600)
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync"
+
+	"github.com/lostinblue/surf/scheduler"
+	"github.com/lostinblue/surf/urlnorm"
+)
+
+// cmdCrawl crawls the pages linked from a page, and writes one JSON object
+// per fetched page.
+func cmdCrawl(args []string, stdout io.Writer) error {
+	fs, o := newFlagSet("crawl")
+	depth := fs.Int("depth", 1, "the number of links followed from the first page")
+	workers := fs.Int("workers", scheduler.DefaultWorkers, "the number of pages fetched concurrently")
+	sameHost := fs.Bool("same-host", true, "only follow the links to the host of the first page")
+	u, err := parseURL(fs, args)
+	if err != nil {
+		return err
+	}
+	start, err := urlnorm.NormalizeString(u)
+	if err != nil {
+		return err
+	}
+	bow, done, err := o.newBrowser()
+	if err != nil {
+		return err
+	}
+
+	var mu sync.Mutex
+	depths := map[string]int{start: 0}
+	s := scheduler.New(bow)
+	s.Workers = *workers
+	s.Follow = func(r *scheduler.Result) []*scheduler.Job {
+		mu.Lock()
+		defer mu.Unlock()
+		d := depths[r.Job.URL]
+		if d >= *depth {
+			return nil
+		}
+		var jobs []*scheduler.Job
+		for _, l := range r.Browser.Links() {
+			if (l.URL.Scheme != "http" && l.URL.Scheme != "https") || (*sameHost && l.URL.Host != r.Browser.URL().Host) {
+				continue
+			}
+			n := urlnorm.Normalize(l.URL).String()
+			if _, ok := depths[n]; ok {
+				continue
+			}
+			depths[n] = d + 1
+			// Shallower pages are fetched first.
+			jobs = append(jobs, &scheduler.Job{URL: n, Priority: -(d + 1)})
+		}
+		return jobs
+	}
+	if err := s.Add(start, 0); err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(stdout)
+	for r := range s.Run(context.Background()) {
+		p := &page{URL: r.Job.URL}
+		if r.Err != nil {
+			p.Error = r.Err.Error()
+		} else {
+			p = newPage(r.Browser)
+		}
+		mu.Lock()
+		p.Depth = depths[r.Job.URL]
+		mu.Unlock()
+		if err := enc.Encode(p); err != nil {
+			return err
+		}
+	}
+	return done()
+}
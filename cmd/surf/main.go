@@ -0,0 +1,267 @@
+// Command surf exposes the Surf browser on the command line, so it can be
+// used from shell pipelines. Results are written to stdout as JSON, and the
+// crawl command writes one JSON object per line.
+//
+// Usage:
+//
+//	surf get [flags] URL
+//	surf links [flags] URL
+//	surf form-submit [flags] -field name=value ... URL
+//	surf download-assets [flags] -dir DIR URL
+//	surf crawl [flags] -depth 2 URL
+//	surf pipeline [flags] FILE
+//	surf mirror [flags] -dir DIR URL
+//
+// Every command accepts -cookies FILE, which loads the cookies saved in the
+// file before the requests and saves them back afterwards, -user-agent, and
+// -netrc, which answers the hosts asking for a password with the
+// credentials of ~/.netrc.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/lostinblue/surf"
+	"github.com/lostinblue/surf/browser"
+	"github.com/lostinblue/surf/credentials"
+)
+
+// commands are the subcommands, by name.
+var commands = map[string]func(args []string, stdout io.Writer) error{
+	"get":             cmdGet,
+	"links":           cmdLinks,
+	"form-submit":     cmdFormSubmit,
+	"download-assets": cmdDownloadAssets,
+	"crawl":           cmdCrawl,
+	"pipeline":        cmdPipeline,
+	"mirror":          cmdMirror,
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "surf:", err)
+		os.Exit(1)
+	}
+}
+
+// run executes the subcommand named by the first argument.
+func run(args []string, stdout io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing command, expected one of: %s", commandNames())
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown command %q, expected one of: %s", args[0], commandNames())
+	}
+	return cmd(args[1:], stdout)
+}
+
+// commandNames returns the names of the subcommands.
+func commandNames() string {
+	return "get, links, form-submit, download-assets, crawl, pipeline, mirror"
+}
+
+// options are the flags shared by every command.
+type options struct {
+	cookies   string
+	userAgent string
+	netrc     bool
+}
+
+// newFlagSet returns the flag set of the named command with the shared flags.
+func newFlagSet(name string) (*flag.FlagSet, *options) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	o := &options{}
+	fs.StringVar(&o.cookies, "cookies", "", "load and save the cookies in `file`")
+	fs.StringVar(&o.userAgent, "user-agent", "", "the user agent sent with the requests")
+	fs.BoolVar(&o.netrc, "netrc", false, "send the credentials of ~/.netrc to the hosts asking for them")
+	return fs, o
+}
+
+// parseURL parses the flags, and returns the URL given as the only argument.
+func parseURL(fs *flag.FlagSet, args []string) (string, error) {
+	if err := fs.Parse(args); err != nil {
+		return "", err
+	}
+	if fs.NArg() != 1 {
+		return "", fmt.Errorf("%s: expected a single URL argument", fs.Name())
+	}
+	return fs.Arg(0), nil
+}
+
+// newBrowser returns a browser with the shared options, and a function
+// saving its cookies which must be called once the command is done.
+func (o *options) newBrowser() (*browser.Browser, func() error, error) {
+	bow := surf.NewBrowser()
+	if o.userAgent != "" {
+		bow.SetUserAgent(o.userAgent)
+	}
+	if o.netrc {
+		n, err := credentials.DefaultNetrc()
+		if err != nil {
+			return nil, nil, err
+		}
+		bow.SetCredentialStore(n)
+	}
+	if o.cookies == "" {
+		return bow, func() error { return nil }, nil
+	}
+	cookies, err := loadCookies(o.cookies, bow)
+	if err != nil {
+		return nil, nil, err
+	}
+	return bow, func() error { return cookies.save(bow) }, nil
+}
+
+// page is the JSON output describing a page.
+type page struct {
+	URL         string `json:"url"`
+	Status      int    `json:"status"`
+	Title       string `json:"title"`
+	ContentType string `json:"contentType,omitempty"`
+	Body        string `json:"body,omitempty"`
+	Text        string `json:"text,omitempty"`
+	Depth       int    `json:"depth,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// newPage returns the description of the current page of the browser.
+func newPage(bow *browser.Browser) *page {
+	p := &page{Status: bow.StatusCode(), Title: bow.Title()}
+	if u := bow.URL(); u != nil {
+		p.URL = u.String()
+	}
+	if h := bow.ResponseHeaders(); h != nil {
+		p.ContentType = h.Get("Content-Type")
+	}
+	return p
+}
+
+// writeJSON writes the value as indented JSON.
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// cmdGet requests a page and writes its description.
+func cmdGet(args []string, stdout io.Writer) error {
+	fs, o := newFlagSet("get")
+	body := fs.Bool("body", false, "include the page body")
+	text := fs.Bool("text", false, "include the visible text of the page")
+	u, err := parseURL(fs, args)
+	if err != nil {
+		return err
+	}
+	bow, done, err := o.newBrowser()
+	if err != nil {
+		return err
+	}
+	if err := bow.GET(u); err != nil {
+		return err
+	}
+	p := newPage(bow)
+	if *body {
+		p.Body = bow.Body()
+	}
+	if *text {
+		p.Text = bow.Text()
+	}
+	if err := writeJSON(stdout, p); err != nil {
+		return err
+	}
+	return done()
+}
+
+// link is the JSON output describing a link.
+type link struct {
+	URL  string `json:"url"`
+	Text string `json:"text"`
+	ID   string `json:"id,omitempty"`
+}
+
+// cmdLinks writes the links of a page.
+func cmdLinks(args []string, stdout io.Writer) error {
+	fs, o := newFlagSet("links")
+	u, err := parseURL(fs, args)
+	if err != nil {
+		return err
+	}
+	bow, done, err := o.newBrowser()
+	if err != nil {
+		return err
+	}
+	if err := bow.GET(u); err != nil {
+		return err
+	}
+	links := []link{}
+	for _, l := range bow.Links() {
+		links = append(links, link{URL: l.URL.String(), Text: strings.TrimSpace(l.Text), ID: l.ID})
+	}
+	if err := writeJSON(stdout, links); err != nil {
+		return err
+	}
+	return done()
+}
+
+// fieldFlags collects the repeated -field name=value flags.
+type fieldFlags [][2]string
+
+func (f *fieldFlags) String() string {
+	return fmt.Sprint(*f)
+}
+
+func (f *fieldFlags) Set(v string) error {
+	i := strings.Index(v, "=")
+	if i < 1 {
+		return fmt.Errorf("expected name=value, got %q", v)
+	}
+	*f = append(*f, [2]string{v[:i], v[i+1:]})
+	return nil
+}
+
+// cmdFormSubmit fills and submits a form, and writes the resulting page.
+func cmdFormSubmit(args []string, stdout io.Writer) error {
+	fs, o := newFlagSet("form-submit")
+	form := fs.String("form", "form", "the `selector` of the form")
+	button := fs.String("button", "", "the `name` of the button to click")
+	var fields fieldFlags
+	fs.Var(&fields, "field", "set a field, as `name=value`; may be repeated")
+	u, err := parseURL(fs, args)
+	if err != nil {
+		return err
+	}
+	bow, done, err := o.newBrowser()
+	if err != nil {
+		return err
+	}
+	if err := bow.GET(u); err != nil {
+		return err
+	}
+	f, err := bow.Form(*form)
+	if err != nil {
+		return err
+	}
+	for _, field := range fields {
+		if err := f.Set(field[0], field[1]); err != nil {
+			return err
+		}
+	}
+	if *button != "" {
+		err = f.Click(*button)
+	} else {
+		err = f.Submit()
+	}
+	if err != nil {
+		return err
+	}
+	if err := writeJSON(stdout, newPage(bow)); err != nil {
+		return err
+	}
+	return done()
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lostinblue/surf/mirror"
+)
+
+func newTestServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			http.SetCookie(w, &http.Cookie{Name: "visited", Value: "yes", Path: "/"})
+			fmt.Fprint(w, `<html><head><title>Home</title><link rel="stylesheet" href="/style.css"></head>
+				<body><a id="a" href="/a">Page A</a> <a href="/b">Page B</a> <a href="https://example.com/">Out</a>
+				<img src="/logo.png"></body></html>`)
+		case "/a":
+			fmt.Fprint(w, `<html><head><title>A</title></head><body><a href="/c">Page C</a></body></html>`)
+		case "/c":
+			fmt.Fprint(w, `<html><head><title>C</title></head><body><a href="/d">Page D</a></body></html>`)
+		case "/form":
+			fmt.Fprint(w, `<html><body><form method="post" action="/submit"><input name="q"></form></body></html>`)
+		case "/submit":
+			r.ParseForm()
+			c, _ := r.Cookie("visited")
+			fmt.Fprintf(w, `<html><head><title>%s %v</title></head></html>`, r.PostForm.Get("q"), c != nil)
+		case "/style.css":
+			fmt.Fprint(w, "body {}")
+		case "/logo.png":
+			if _, err := r.Cookie("visited"); err != nil {
+				http.Error(w, "anonymous", http.StatusForbidden)
+				return
+			}
+			fmt.Fprint(w, "PNG")
+		case "/private":
+			if user, _, ok := r.BasicAuth(); !ok {
+				w.WriteHeader(http.StatusUnauthorized)
+			} else {
+				fmt.Fprintf(w, `<html><head><title>Hello %s</title></head></html>`, user)
+			}
+		default:
+			fmt.Fprintf(w, `<html><head><title>%s</title></head></html>`, r.URL.Path)
+		}
+	}))
+}
+
+func TestRun(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+	dir, err := ioutil.TempDir("", "surf-cli")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	cookies := filepath.Join(dir, "cookies.json")
+
+	var out bytes.Buffer
+	if err := run([]string{"get", "-cookies", cookies, ts.URL}, &out); err != nil {
+		t.Fatal(err)
+	}
+	var p page
+	if err := json.Unmarshal(out.Bytes(), &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Title != "Home" || p.Status != 200 {
+		t.Errorf("Expected the home page, got %+v", p)
+	}
+
+	out.Reset()
+	netrc := filepath.Join(dir, "netrc")
+	ioutil.WriteFile(netrc, []byte("machine 127.0.0.1 login user password pass\n"), 0600)
+	defer os.Setenv("NETRC", os.Getenv("NETRC"))
+	os.Setenv("NETRC", netrc)
+	if err := run([]string{"get", "-netrc", ts.URL + "/private"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	p = page{}
+	if err := json.Unmarshal(out.Bytes(), &p); err != nil || p.Title != "Hello user" {
+		t.Errorf("Expected the credentials of the netrc file to be sent, got %+v (%v)", p, err)
+	}
+
+	out.Reset()
+	if err := run([]string{"links", ts.URL}, &out); err != nil {
+		t.Fatal(err)
+	}
+	var links []link
+	if err := json.Unmarshal(out.Bytes(), &links); err != nil {
+		t.Fatal(err)
+	}
+	if len(links) != 3 || links[0].URL != ts.URL+"/a" || links[0].Text != "Page A" || links[0].ID != "a" {
+		t.Errorf("Expected the links of the page, got %+v", links)
+	}
+
+	out.Reset()
+	if err := run([]string{"form-submit", "-cookies", cookies, "-field", "q=surf", ts.URL + "/form"}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(out.Bytes(), &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Title != "surf true" {
+		t.Errorf("Expected the form to be submitted with the saved cookie, got %q", p.Title)
+	}
+
+	out.Reset()
+	assets := filepath.Join(dir, "assets")
+	if err := run([]string{"download-assets", "-dir", assets, ts.URL}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := ioutil.ReadFile(filepath.Join(assets, "logo.png")); err != nil || string(b) != "PNG" {
+		t.Errorf("Expected the image to be downloaded, got %q, %v", b, err)
+	}
+	if b, err := ioutil.ReadFile(filepath.Join(assets, "style.css")); err != nil || string(b) != "body {}" {
+		t.Errorf("Expected the stylesheet to be downloaded, got %q, %v", b, err)
+	}
+
+	out.Reset()
+	if err := run([]string{"crawl", "-depth", "2", "-workers", "1", ts.URL}, &out); err != nil {
+		t.Fatal(err)
+	}
+	titles := make(map[string]int)
+	scanner := bufio.NewScanner(&out)
+	for scanner.Scan() {
+		var p page
+		if err := json.Unmarshal(scanner.Bytes(), &p); err != nil {
+			t.Fatal(err)
+		}
+		titles[p.Title] = p.Depth
+	}
+	want := map[string]int{"Home": 0, "A": 1, "/b": 1, "C": 2}
+	if len(titles) != len(want) {
+		t.Errorf("Expected %d pages, got %v", len(want), titles)
+	}
+	for title, depth := range want {
+		if d, ok := titles[title]; !ok || d != depth {
+			t.Errorf("Expected the page %q at depth %d, got %v", title, depth, titles)
+		}
+	}
+
+	config := filepath.Join(dir, "pipeline.json")
+	ioutil.WriteFile(config, []byte(`{"steps": [
+		{"open": "`+ts.URL+`"},
+		{"extract": {"selector": "a", "fields": {"url": ",attr=href", "text": ""}}},
+		{"transform": {"field": "url", "op": "match", "pattern": "^http://127"}},
+		{"output": {"format": "csv", "columns": ["text", "url"]}}
+	]}`), 0644)
+	out.Reset()
+	if err := run([]string{"pipeline", config}, &out); err != nil {
+		t.Fatal(err)
+	}
+	expected := "text,url\nPage A," + ts.URL + "/a\nPage B," + ts.URL + "/b\n"
+	if out.String() != expected {
+		t.Errorf("Expected the pipeline output %q, got %q", expected, out.String())
+	}
+
+	out.Reset()
+	site := filepath.Join(dir, "site")
+	if err := run([]string{"mirror", "-dir", site, "-workers", "1", ts.URL}, &out); err != nil {
+		t.Fatal(err)
+	}
+	var stats mirror.Stats
+	if err := json.Unmarshal(out.Bytes(), &stats); err != nil || stats.Pages != 5 || stats.Assets != 2 {
+		t.Errorf("Expected 5 pages and 2 assets to be mirrored, got %s (%v)", out.String(), err)
+	}
+	if b, err := ioutil.ReadFile(filepath.Join(site, "index.html")); err != nil || !strings.Contains(string(b), `href="a.html"`) {
+		t.Errorf("Expected the links of the saved page to be rewritten, got %q, %v", b, err)
+	}
+
+	if err := run([]string{"unknown"}, &out); err == nil {
+		t.Error("Expected an error for an unknown command")
+	}
+	if err := run([]string{"get"}, &out); err == nil {
+		t.Error("Expected an error without a URL")
+	}
+}
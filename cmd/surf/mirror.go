@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"io"
+
+	"github.com/lostinblue/surf/mirror"
+	"github.com/lostinblue/surf/scheduler"
+)
+
+// cmdMirror saves a site to a directory, and writes the counts of the
+// fetched URLs. Running it again updates the changed files only.
+func cmdMirror(args []string, stdout io.Writer) error {
+	fs, o := newFlagSet("mirror")
+	dir := fs.String("dir", ".", "the `directory` the site is saved to")
+	workers := fs.Int("workers", scheduler.DefaultWorkers, "the number of URLs fetched concurrently")
+	delay := fs.Duration("delay", 0, "the minimum time between two requests")
+	maxPages := fs.Int("max-pages", 0, "the maximum number of pages saved, 0 for no limit")
+	assets := fs.Bool("assets", true, "save the images, stylesheets and scripts of the pages")
+	sitemap := fs.String("sitemap", "", "the `URL` of the sitemap listing the pages, /sitemap.xml by default")
+	followLinks := fs.Bool("follow-links", false, "follow the links of the pages listed in a sitemap")
+	store := fs.String("asset-store", "", "save the assets by checksum to `directory`, saving identical assets once")
+	u, err := parseURL(fs, args)
+	if err != nil {
+		return err
+	}
+	bow, done, err := o.newBrowser()
+	if err != nil {
+		return err
+	}
+
+	m := mirror.New(bow, *dir)
+	m.Workers = *workers
+	m.Delay = *delay
+	m.MaxPages = *maxPages
+	m.Assets = *assets
+	m.Sitemap = *sitemap
+	m.FollowLinks = *followLinks
+	if *store != "" {
+		if m.Store, err = mirror.NewContentStore(*store); err != nil {
+			return err
+		}
+	}
+	stats, err := m.Run(context.Background(), u)
+	if err != nil {
+		return err
+	}
+	if err := writeJSON(stdout, stats); err != nil {
+		return err
+	}
+	return done()
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/lostinblue/surf/pipeline"
+)
+
+// cmdPipeline runs the pipeline described by a config file, and writes the
+// outputs without a file to stdout.
+func cmdPipeline(args []string, stdout io.Writer) error {
+	fs, o := newFlagSet("pipeline")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("%s: expected a single config file argument", fs.Name())
+	}
+	c, err := pipeline.LoadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	p, err := c.Build(stdout)
+	if err != nil {
+		return err
+	}
+	bow, done, err := o.newBrowser()
+	if err != nil {
+		return err
+	}
+	if _, err := p.Run(bow); err != nil {
+		return err
+	}
+	return done()
+}
@@ -0,0 +1,140 @@
+package surf
+
+import (
+	"time"
+
+	"github.com/lostinblue/surf/browser"
+	"github.com/lostinblue/surf/errors"
+	"github.com/lostinblue/surf/jar"
+	"github.com/lostinblue/surf/profiles"
+	"github.com/lostinblue/surf/util"
+)
+
+// Decoders are the functions used by LoadConfig to decode config files, by
+// file extension. It's the registry shared with the profiles and the
+// pipelines, util.Decoders, so JSON, YAML and TOML are supported out of the
+// box, and a decoder registered once applies to them all.
+var Decoders = util.Decoders
+
+// attributeNames are the names of the attributes in config files.
+var attributeNames = map[string]browser.Attribute{
+	"send_referer":          browser.SendReferer,
+	"meta_refresh_handling": browser.MetaRefreshHandling,
+	"follow_redirects":      browser.FollowRedirects,
+	"challenge_detection":   browser.ChallengeDetection,
+	"head_first":            browser.HeadFirst,
+	"parse_dom":             browser.ParseDOM,
+	"form_events":           browser.FormEvents,
+	"compression":           browser.Compression,
+}
+
+// Config is the configuration of a browser, loaded from a file with
+// LoadConfig(). The zero values keep the defaults of the browser.
+type Config struct {
+	// UserAgent is the user agent of the browser.
+	UserAgent string `json:"user_agent,omitempty" yaml:"user_agent,omitempty" toml:"user_agent,omitempty"`
+
+	// Timeout is the timeout of the requests, eg "30s".
+	Timeout profiles.Duration `json:"timeout,omitempty" yaml:"timeout,omitempty" toml:"timeout,omitempty"`
+
+	// Proxy is the URL of the proxy. See Browser.SetProxy().
+	Proxy string `json:"proxy,omitempty" yaml:"proxy,omitempty" toml:"proxy,omitempty"`
+
+	// Attributes are the attributes of the browser by name, eg
+	// "follow_redirects" or "form_events".
+	Attributes map[string]bool `json:"attributes,omitempty" yaml:"attributes,omitempty" toml:"attributes,omitempty"`
+
+	// Headers are the headers sent with each request.
+	Headers map[string]string `json:"headers,omitempty" yaml:"headers,omitempty" toml:"headers,omitempty"`
+
+	// MaxHistoryLength is the maximum number of pages of the history.
+	MaxHistoryLength int `json:"max_history_length,omitempty" yaml:"max_history_length,omitempty" toml:"max_history_length,omitempty"`
+
+	// Jars are the files of the jars saved to disk.
+	Jars JarFiles `json:"jars,omitempty" yaml:"jars,omitempty" toml:"jars,omitempty"`
+}
+
+// JarFiles are the files of the jars of a Config. The jars whose file is
+// empty are kept in memory.
+type JarFiles struct {
+	Bookmarks    string `json:"bookmarks,omitempty" yaml:"bookmarks,omitempty" toml:"bookmarks,omitempty"`
+	Snapshots    string `json:"snapshots,omitempty" yaml:"snapshots,omitempty" toml:"snapshots,omitempty"`
+	LocalStorage string `json:"local_storage,omitempty" yaml:"local_storage,omitempty" toml:"local_storage,omitempty"`
+}
+
+// LoadConfig decodes the browser configuration saved in the given file, with
+// the decoder registered for its extension in Decoders. Use the options of
+// the configuration to create a browser:
+//
+//	c, err := surf.LoadConfig("surf.yaml")
+//	if err != nil { panic(err) }
+//	bow, err := surf.New(surf.WithConfig(c))
+func LoadConfig(file string) (*Config, error) {
+	b, dec, err := util.ReadConfigFile(file, "config")
+	if err != nil {
+		return nil, err
+	}
+	c := &Config{}
+	if err := dec(b, c); err != nil {
+		return nil, err
+	}
+	for name := range c.Attributes {
+		if _, ok := attributeNames[name]; !ok {
+			return nil, errors.New("Unknown attribute '%s' in config file '%s'.", name, file)
+		}
+	}
+	return c, nil
+}
+
+// WithConfig configures the browser with the configuration. The jar files
+// are opened, and created when they are saved.
+func WithConfig(c *Config) Option {
+	return func(bow *browser.Browser) error {
+		if c.UserAgent != "" {
+			bow.SetUserAgent(c.UserAgent)
+		}
+		if c.Timeout > 0 {
+			bow.SetTimeout(time.Duration(c.Timeout))
+		}
+		if c.Proxy != "" {
+			if err := bow.SetProxy(c.Proxy); err != nil {
+				return err
+			}
+		}
+		for name, v := range c.Attributes {
+			a, ok := attributeNames[name]
+			if !ok {
+				return errors.New("Unknown attribute '%s'.", name)
+			}
+			bow.SetAttribute(a, v)
+		}
+		for name, v := range c.Headers {
+			bow.AddRequestHeader(name, v)
+		}
+		if c.MaxHistoryLength > 0 {
+			bow.HistoryJar().SetMax(c.MaxHistoryLength)
+		}
+		if c.Jars.Bookmarks != "" {
+			bj, err := jar.NewFileBookmarks(c.Jars.Bookmarks)
+			if err != nil {
+				return err
+			}
+			bow.SetBookmarksJar(bj)
+		}
+		if c.Jars.Snapshots != "" {
+			sj, err := jar.NewFileSnapshots(c.Jars.Snapshots)
+			if err != nil {
+				return err
+			}
+			bow.SetSnapshotsJar(sj)
+		}
+		if c.Jars.LocalStorage != "" {
+			s, err := jar.NewFileStorage(c.Jars.LocalStorage)
+			if err != nil {
+				return err
+			}
+			bow.SetLocalStorageJar(s)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,82 @@
+package surf
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lostinblue/surf/browser"
+	"github.com/lostinblue/surf/jar"
+)
+
+func TestLoadConfig(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.UserAgent() + " " + r.Header.Get("X-Team")))
+	}))
+	defer ts.Close()
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "surf.json")
+	config := `{
+		"user_agent": "Configured/1.0",
+		"timeout": "7s",
+		"attributes": {"follow_redirects": false, "form_events": true},
+		"headers": {"X-Team": "crawlers"},
+		"max_history_length": 2,
+		"jars": {"bookmarks": "` + filepath.Join(dir, "bookmarks.json") + `"}
+	}`
+	if err := ioutil.WriteFile(file, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c, err := LoadConfig(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bow, err := New(WithConfig(c))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bow.UserAgent() != "Configured/1.0" || bow.Timeout() != 7*time.Second {
+		t.Errorf("Expected the user agent and timeout of the config, got %q %v.", bow.UserAgent(), bow.Timeout())
+	}
+	if bow.Attribute(browser.FollowRedirects) || !bow.Attribute(browser.FormEvents) || !bow.Attribute(browser.SendReferer) {
+		t.Error("Expected the attributes of the config, and the others to keep their default.")
+	}
+	if _, ok := bow.BookmarksJar().(*jar.FileBookmarks); !ok {
+		t.Errorf("Expected a file bookmarks jar, got %T.", bow.BookmarksJar())
+	}
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if bow.Body() != "Configured/1.0 crawlers" {
+		t.Errorf("Expected the user agent and headers of the config, got '%s'.", bow.Body())
+	}
+
+	toml := filepath.Join(dir, "surf.toml")
+	ioutil.WriteFile(toml, []byte(`user_agent = "Toml/1.0"
+timeout = "2s"
+
+[attributes]
+follow_redirects = false
+
+[headers]
+X-Team = "toml"
+`), 0600)
+	if c, err = LoadConfig(toml); err != nil {
+		t.Fatal(err)
+	}
+	if c.UserAgent != "Toml/1.0" || time.Duration(c.Timeout) != 2*time.Second || c.Attributes["follow_redirects"] || c.Headers["X-Team"] != "toml" {
+		t.Errorf("Expected the TOML config, got %+v.", c)
+	}
+
+	if _, err := LoadConfig(filepath.Join(dir, "surf.ini")); err == nil {
+		t.Error("Expected an error for an extension without a decoder.")
+	}
+	ioutil.WriteFile(file, []byte(`{"attributes": {"javascript": true}}`), 0600)
+	if _, err := LoadConfig(file); err == nil {
+		t.Error("Expected an error for an unknown attribute.")
+	}
+}
@@ -0,0 +1,138 @@
+// Package credentials looks up the user names and passwords of hosts, eg in
+// a .netrc file, the keychain of the operating system or with a credential
+// helper command, so they don't need to be written in the programs using
+// them. Set the store of a browser with Browser.SetCredentialStore(), and it
+// answers the 401 responses of the hosts it knows with Basic authentication,
+// and provides the credentials of logins and proxies.
+package credentials
+
+import (
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+// Credentials are the user name and password of a host.
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// Store looks up credentials.
+type Store interface {
+	// Lookup returns the credentials of the host, or nil when it has none.
+	// Hosts are lowercase and have no port.
+	Lookup(host string) (*Credentials, error)
+}
+
+// URLStore is a Store whose credentials depend on the URL they are sent to,
+// eg to send some over https only, or to give the protocol to a credential
+// helper.
+type URLStore interface {
+	Store
+
+	// LookupURL returns the credentials of the host of the URL, or nil when
+	// it has none.
+	LookupURL(u *url.URL) (*Credentials, error)
+}
+
+// LookupURL returns the credentials of the host of the URL in the store,
+// using its LookupURL method when it is a URLStore.
+func LookupURL(s Store, u *url.URL) (*Credentials, error) {
+	if us, ok := s.(URLStore); ok {
+		return us.LookupURL(u)
+	}
+	return s.Lookup(strings.ToLower(u.Hostname()))
+}
+
+// Func is a function used as a Store.
+type Func func(host string) (*Credentials, error)
+
+// Lookup calls the function.
+func (f Func) Lookup(host string) (*Credentials, error) {
+	return f(host)
+}
+
+// Map is a Store holding the credentials of hosts, by host name. Names may
+// be patterns matching several hosts, eg "*.example.com", using the syntax
+// of path.Match. Use a List to choose which of several matching patterns
+// is used.
+type Map map[string]Credentials
+
+// Lookup returns the credentials of the host, or of the longest pattern
+// matching it.
+func (m Map) Lookup(host string) (*Credentials, error) {
+	if c, ok := m[host]; ok {
+		return &c, nil
+	}
+	patterns := make([]string, 0, len(m))
+	for pattern := range m {
+		patterns = append(patterns, pattern)
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		if len(patterns[i]) != len(patterns[j]) {
+			return len(patterns[i]) > len(patterns[j])
+		}
+		return patterns[i] < patterns[j]
+	})
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.ToLower(pattern), host); ok {
+			c := m[pattern]
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+// Entry holds the credentials of the hosts matching a name, see List.
+type Entry struct {
+	// Host is a host name, or a pattern using the syntax of path.Match, eg
+	// "*.example.com".
+	Host string
+
+	Credentials
+}
+
+// List is a Store holding the credentials of hosts, by host name or pattern,
+// in order. The first entry matching a host is used, so the specific
+// entries go before the broad patterns.
+type List []Entry
+
+// Lookup returns the credentials of the first entry matching the host.
+func (l List) Lookup(host string) (*Credentials, error) {
+	for _, e := range l {
+		if ok, _ := path.Match(strings.ToLower(e.Host), host); ok {
+			c := e.Credentials
+			return &c, nil
+		}
+	}
+	return nil, nil
+}
+
+// Chain is a Store asking each store in turn, until one has the credentials
+// of the host.
+type Chain []Store
+
+// Lookup returns the first credentials found, or the first error.
+func (c Chain) Lookup(host string) (*Credentials, error) {
+	for _, s := range c {
+		creds, err := s.Lookup(host)
+		if err != nil || creds != nil {
+			return creds, err
+		}
+	}
+	return nil, nil
+}
+
+// LookupURL returns the first credentials found for the URL, or the first
+// error.
+func (c Chain) LookupURL(u *url.URL) (*Credentials, error) {
+	for _, s := range c {
+		creds, err := LookupURL(s, u)
+		if err != nil || creds != nil {
+			return creds, err
+		}
+	}
+	return nil, nil
+}
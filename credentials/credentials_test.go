@@ -0,0 +1,219 @@
+package credentials
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMap(t *testing.T) {
+	m := Map{
+		"example.com":   {Username: "user", Password: "pass"},
+		"*.example.org": {Username: "sub", Password: "secret"},
+	}
+	if c, _ := m.Lookup("example.com"); c == nil || c.Username != "user" {
+		t.Errorf("Expected the credentials of example.com, got %v.", c)
+	}
+	if c, _ := m.Lookup("www.example.org"); c == nil || c.Username != "sub" {
+		t.Errorf("Expected the credentials of the pattern, got %v.", c)
+	}
+	if c, _ := m.Lookup("example.net"); c != nil {
+		t.Errorf("Expected no credentials, got %v.", c)
+	}
+
+	chain := Chain{Map{}, Func(func(host string) (*Credentials, error) {
+		return &Credentials{Username: host}, nil
+	}), m}
+	if c, _ := chain.Lookup("example.com"); c == nil || c.Username != "example.com" {
+		t.Errorf("Expected the credentials of the first store which has them, got %v.", c)
+	}
+
+	// The longest pattern matching the host is used.
+	m["*.example.org"], m["*.api.example.org"], m["*"] = Credentials{Username: "sub"}, Credentials{Username: "api"}, Credentials{Username: "any"}
+	for i := 0; i < 10; i++ {
+		if c, _ := m.Lookup("v1.api.example.org"); c == nil || c.Username != "api" {
+			t.Fatalf("Expected the credentials of the longest pattern, got %v.", c)
+		}
+	}
+
+	l := List{
+		{Host: "www.example.org", Credentials: Credentials{Username: "www"}},
+		{Host: "*.example.org", Credentials: Credentials{Username: "sub"}},
+		{Host: "*", Credentials: Credentials{Username: "any"}},
+	}
+	for host, user := range map[string]string{"www.example.org": "www", "a.example.org": "sub", "example.net": "any"} {
+		if c, _ := l.Lookup(host); c == nil || c.Username != user {
+			t.Errorf("Expected the credentials of %s for %s, got %v.", user, host, c)
+		}
+	}
+}
+
+const netrc = `# comment
+machine example.com login user password pass
+machine API.example.com
+	login api
+	account ignored
+	password "quoted"
+
+macdef init
+cd /pub
+bin
+
+default login anonymous password guest
+`
+
+func TestNetrc(t *testing.T) {
+	n, err := ParseNetrc(strings.NewReader(netrc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]Credentials{
+		"example.com":     {Username: "user", Password: "pass"},
+		"api.example.com": {Username: "api", Password: `"quoted"`},
+	}
+	for host, e := range expected {
+		if c, _ := n.Lookup(host); c == nil || *c != e {
+			t.Errorf("Expected the credentials %v for %s, got %v.", e, host, c)
+		}
+	}
+
+	// The default entry is used for https URLs once enabled.
+	https, _ := url.Parse("https://other.com/")
+	plain, _ := url.Parse("http://other.com/")
+	if c, _ := LookupURL(n, https); c != nil {
+		t.Errorf("Expected the default entry to be disabled, got %v.", c)
+	}
+	n.UseDefault = true
+	if c, _ := LookupURL(n, https); c == nil || *c != (Credentials{Username: "anonymous", Password: "guest"}) {
+		t.Errorf("Expected the default entry for https, got %v.", c)
+	}
+	if c, _ := LookupURL(n, plain); c != nil {
+		t.Errorf("Expected no default entry for http, got %v.", c)
+	}
+	if c, _ := n.Lookup("other.com"); c != nil {
+		t.Errorf("Expected no default entry without a URL, got %v.", c)
+	}
+
+	n, _ = ParseNetrc(strings.NewReader("machine example.com login user password pass"))
+	if c, _ := n.Lookup("other.com"); c != nil {
+		t.Errorf("Expected no credentials without a default entry, got %v.", c)
+	}
+	for _, invalid := range []string{"machine", "machine a login", "machine a user b"} {
+		if _, err := ParseNetrc(strings.NewReader(invalid)); err == nil {
+			t.Errorf("Expected an error for %q.", invalid)
+		}
+	}
+}
+
+func TestDefaultNetrc(t *testing.T) {
+	dir, err := ioutil.TempDir("", "surf-netrc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Setenv("NETRC", os.Getenv("NETRC"))
+
+	file := filepath.Join(dir, "netrc")
+	os.Setenv("NETRC", file)
+	if DefaultNetrcFile() != file {
+		t.Errorf("Expected the file of NETRC, got %s.", DefaultNetrcFile())
+	}
+	n, err := DefaultNetrc()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := n.Lookup("example.com"); c != nil {
+		t.Errorf("Expected no credentials without a file, got %v.", c)
+	}
+	ioutil.WriteFile(file, []byte(netrc), 0600)
+	if n, err = DefaultNetrc(); err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := n.Lookup("example.com"); c == nil || c.Password != "pass" {
+		t.Errorf("Expected the credentials of the file, got %v.", c)
+	}
+}
+
+func TestHelper(t *testing.T) {
+	h := NewHelper("sh", "-c", `read p; read h; [ "$p" = "protocol=https" ] && [ "$h" = "host=example.com" ] && printf 'protocol=https\nusername=user\npassword=p=ss\n'; true`)
+	c, err := h.Lookup("example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c == nil || *c != (Credentials{Username: "user", Password: "p=ss"}) {
+		t.Errorf("Expected the credentials written by the helper, got %v.", c)
+	}
+	if c, err := h.Lookup("example.org"); c != nil || err != nil {
+		t.Errorf("Expected no credentials, got %v (%v).", c, err)
+	}
+	u, _ := url.Parse("http://example.com/")
+	if c, err := LookupURL(h, u); c != nil || err != nil {
+		t.Errorf("Expected no credentials for http, got %v (%v).", c, err)
+	}
+	if _, err := NewHelper("sh", "-c", "echo denied >&2; exit 1").Lookup("example.com"); err == nil || !strings.Contains(err.Error(), "denied") {
+		t.Errorf("Expected the error of the helper, got %v.", err)
+	}
+}
+
+var errNotFound = errors.New("secret not found in keyring")
+
+// memoryKeyring is a keychain in memory, with the functions of go-keyring.
+type memoryKeyring map[string]string
+
+func (m memoryKeyring) get(service, user string) (string, error) {
+	if s, ok := m[service+"/"+user]; ok {
+		return s, nil
+	}
+	return "", errNotFound
+}
+
+func (m memoryKeyring) set(service, user, secret string) error {
+	m[service+"/"+user] = secret
+	return nil
+}
+
+func (m memoryKeyring) delete(service, user string) error {
+	if _, ok := m[service+"/"+user]; !ok {
+		return errNotFound
+	}
+	delete(m, service+"/"+user)
+	return nil
+}
+
+func TestKeyring(t *testing.T) {
+	m := memoryKeyring{}
+	k := &Keyring{Service: "surf", Get: m.get, Set: m.set, Delete: m.delete, ErrNotFound: errNotFound}
+	if c, err := k.Lookup("example.com"); c != nil || err != nil {
+		t.Errorf("Expected no credentials, got %v (%v).", c, err)
+	}
+	if err := k.Save("Example.com", Credentials{Username: "user", Password: "p:ss"}); err != nil {
+		t.Fatal(err)
+	}
+	if m["surf/example.com"] != "user:p:ss" {
+		t.Errorf("Expected the secret to be saved for the host, got %v.", m)
+	}
+	if c, err := k.Lookup("example.com"); err != nil || c == nil || *c != (Credentials{Username: "user", Password: "p:ss"}) {
+		t.Errorf("Expected the saved credentials, got %v (%v).", c, err)
+	}
+	if err := k.Save("example.com", Credentials{Username: "a:b"}); err == nil {
+		t.Error("Expected an error for a user name with a colon.")
+	}
+	if err := k.Remove("example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.Remove("example.com"); err != nil {
+		t.Errorf("Expected no error when removing a missing entry, got %v.", err)
+	}
+
+	k.Get = func(service, user string) (string, error) { return "", errors.New("locked") }
+	if _, err := k.Lookup("example.com"); err == nil {
+		t.Error("Expected the error of the keychain.")
+	}
+	if err := (&Keyring{}).Save("example.com", Credentials{}); err == nil {
+		t.Error("Expected an error without a Set function.")
+	}
+}
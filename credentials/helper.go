@@ -0,0 +1,75 @@
+package credentials
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net/url"
+	"os/exec"
+	"strings"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+// Helper is a Store running a credential helper command, using the protocol
+// of the git credential helpers: the command is run with a "get" argument,
+// reads the "protocol" and "host" attributes on its standard input, and
+// writes the "username" and "password" attributes on its standard output,
+// one "key=value" per line. Helpers writing nothing have no credentials for
+// the host.
+//
+// The protocol is the scheme of the URL with LookupURL(), and https with
+// Lookup().
+type Helper struct {
+	// Command is the program run, and Args its arguments before "get".
+	Command string
+	Args    []string
+}
+
+// NewHelper creates and returns a *Helper running the given command.
+func NewHelper(command string, args ...string) *Helper {
+	return &Helper{Command: command, Args: args}
+}
+
+// Lookup runs the helper for the host with the https protocol, and returns
+// the credentials it wrote.
+func (h *Helper) Lookup(host string) (*Credentials, error) {
+	return h.get("https", host)
+}
+
+// LookupURL runs the helper for the protocol and host of the URL, and returns
+// the credentials it wrote.
+func (h *Helper) LookupURL(u *url.URL) (*Credentials, error) {
+	return h.get(u.Scheme, strings.ToLower(u.Host))
+}
+
+// get runs the helper, and returns the credentials it wrote.
+func (h *Helper) get(protocol, host string) (*Credentials, error) {
+	cmd := exec.Command(h.Command, append(append([]string(nil), h.Args...), "get")...)
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("protocol=%s\nhost=%s\n\n", protocol, host))
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, errors.New("The credential helper '%s' failed: %s %s", h.Command, err, strings.TrimSpace(stderr.String()))
+	}
+
+	c := &Credentials{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		kv := strings.SplitN(scanner.Text(), "=", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		switch kv[0] {
+		case "username":
+			c.Username = kv[1]
+		case "password":
+			c.Password = kv[1]
+		}
+	}
+	if c.Username == "" && c.Password == "" {
+		return nil, nil
+	}
+	return c, nil
+}
@@ -0,0 +1,81 @@
+package credentials
+
+import (
+	"strings"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+// Keyring is a Store keeping the credentials of hosts in the keychain of the
+// operating system, so secrets are not written in config files. Programs
+// built with the keyring tag get one with NewSystemKeyring(). Otherwise, the
+// keychain is accessed with functions which have the signatures of the
+// go-keyring package, eg:
+//
+//	kr := &credentials.Keyring{
+//		Service:     "surf",
+//		Get:         keyring.Get,
+//		Set:         keyring.Set,
+//		Delete:      keyring.Delete,
+//		ErrNotFound: keyring.ErrNotFound,
+//	}
+//
+// The credentials of a host are saved as "username:password", under the
+// service and with the host as the user name of the keychain entry.
+type Keyring struct {
+	// Service is the name the entries are saved under, eg the name of the program.
+	Service string
+
+	// Get, Set and Delete read, write and remove the secret of a keychain
+	// entry. Set and Delete are only needed by Save and Remove.
+	Get    func(service, user string) (string, error)
+	Set    func(service, user, secret string) error
+	Delete func(service, user string) error
+
+	// ErrNotFound is the error returned by Get for a missing entry.
+	ErrNotFound error
+}
+
+// Lookup returns the credentials saved for the host, or nil when the
+// keychain has no entry for it.
+func (k *Keyring) Lookup(host string) (*Credentials, error) {
+	if k.Get == nil {
+		return nil, errors.New("The keyring has no Get function.")
+	}
+	secret, err := k.Get(k.Service, host)
+	if err != nil {
+		if k.ErrNotFound != nil && err == k.ErrNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	user, pass := secret, ""
+	if i := strings.Index(secret, ":"); i >= 0 {
+		user, pass = secret[:i], secret[i+1:]
+	}
+	return &Credentials{Username: user, Password: pass}, nil
+}
+
+// Save saves the credentials of the host in the keychain. User names can't
+// contain a colon.
+func (k *Keyring) Save(host string, c Credentials) error {
+	if k.Set == nil {
+		return errors.New("The keyring has no Set function.")
+	}
+	if strings.Contains(c.Username, ":") {
+		return errors.New("Cannot save the user name '%s', it contains a colon.", c.Username)
+	}
+	return k.Set(k.Service, strings.ToLower(host), c.Username+":"+c.Password)
+}
+
+// Remove removes the credentials of the host from the keychain.
+func (k *Keyring) Remove(host string) error {
+	if k.Delete == nil {
+		return errors.New("The keyring has no Delete function.")
+	}
+	err := k.Delete(k.Service, strings.ToLower(host))
+	if k.ErrNotFound != nil && err == k.ErrNotFound {
+		return nil
+	}
+	return err
+}
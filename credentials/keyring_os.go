@@ -0,0 +1,27 @@
+//go:build keyring
+// +build keyring
+
+package credentials
+
+import (
+	"github.com/zalando/go-keyring"
+)
+
+// NewSystemKeyring creates and returns a *Keyring keeping the credentials in
+// the keychain of the operating system with go-keyring: the macOS Keychain,
+// the Secret Service on Linux, or the Windows Credential Manager. The
+// entries are saved under the given service name.
+//
+// It is only built with the keyring build tag, so the programs which don't
+// use it don't depend on go-keyring:
+//
+//	go build -tags keyring
+func NewSystemKeyring(service string) *Keyring {
+	return &Keyring{
+		Service:     service,
+		Get:         keyring.Get,
+		Set:         keyring.Set,
+		Delete:      keyring.Delete,
+		ErrNotFound: keyring.ErrNotFound,
+	}
+}
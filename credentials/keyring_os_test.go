@@ -0,0 +1,30 @@
+//go:build keyring
+// +build keyring
+
+package credentials
+
+import (
+	"testing"
+
+	"github.com/zalando/go-keyring"
+)
+
+func TestSystemKeyring(t *testing.T) {
+	keyring.MockInit()
+	k := NewSystemKeyring("surf")
+	if c, err := k.Lookup("example.com"); c != nil || err != nil {
+		t.Errorf("Expected no credentials, got %v (%v).", c, err)
+	}
+	if err := k.Save("example.com", Credentials{Username: "user", Password: "p:ss"}); err != nil {
+		t.Fatal(err)
+	}
+	if c, err := k.Lookup("example.com"); err != nil || c == nil || *c != (Credentials{Username: "user", Password: "p:ss"}) {
+		t.Errorf("Expected the saved credentials, got %v (%v).", c, err)
+	}
+	if err := k.Remove("example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.Remove("example.com"); err != nil {
+		t.Errorf("Expected no error removing a missing entry, got %v.", err)
+	}
+}
@@ -0,0 +1,158 @@
+package credentials
+
+import (
+	"bufio"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+// Netrc is a Store reading the credentials of a .netrc file, as used by
+// curl and ftp.
+//
+// The "default" entry would send its credentials to any host, so it is only
+// used when UseDefault is set, and only for https URLs, see LookupURL().
+type Netrc struct {
+	// UseDefault returns the credentials of the "default" entry for the
+	// https URLs whose host has no "machine" entry.
+	UseDefault bool
+
+	machines map[string]Credentials
+	def      *Credentials
+}
+
+// DefaultNetrcFile returns the path of the .netrc file of the user, which is
+// the file set by the NETRC environment variable, or .netrc in the home
+// directory (_netrc on Windows).
+func DefaultNetrcFile() string {
+	if file := os.Getenv("NETRC"); file != "" {
+		return file
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	if runtime.GOOS == "windows" {
+		return filepath.Join(home, "_netrc")
+	}
+	return filepath.Join(home, ".netrc")
+}
+
+// DefaultNetrc loads the .netrc file of the user. See DefaultNetrcFile().
+// An empty Netrc is returned when the file does not exist.
+func DefaultNetrc() (*Netrc, error) {
+	n, err := LoadNetrc(DefaultNetrcFile())
+	if os.IsNotExist(err) {
+		return &Netrc{machines: make(map[string]Credentials)}, nil
+	}
+	return n, err
+}
+
+// LoadNetrc reads the named .netrc file.
+func LoadNetrc(file string) (*Netrc, error) {
+	fin, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer fin.Close()
+	return ParseNetrc(fin)
+}
+
+// ParseNetrc reads a .netrc file. Macros and accounts are skipped.
+func ParseNetrc(r io.Reader) (*Netrc, error) {
+	n := &Netrc{machines: make(map[string]Credentials)}
+	var cur *Credentials
+	var machine string
+	end := func() {
+		if cur == nil {
+			return
+		}
+		if machine == "" {
+			n.def = cur
+		} else if _, ok := n.machines[machine]; !ok {
+			n.machines[machine] = *cur
+		}
+		cur = nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	inMacro := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if inMacro {
+			// Macros end with a blank line.
+			inMacro = strings.TrimSpace(line) != ""
+			continue
+		}
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		for i := 0; i < len(fields); i++ {
+			tok := fields[i]
+			switch tok {
+			case "default":
+				end()
+				cur, machine = &Credentials{}, ""
+				continue
+			case "macdef":
+				inMacro = true
+				i = len(fields)
+				continue
+			}
+			if i+1 >= len(fields) {
+				return nil, errors.New("Invalid netrc file, '%s' has no value.", tok)
+			}
+			i++
+			value := fields[i]
+			switch tok {
+			case "machine":
+				end()
+				cur, machine = &Credentials{}, strings.ToLower(value)
+			case "login":
+				if cur != nil {
+					cur.Username = value
+				}
+			case "password":
+				if cur != nil {
+					cur.Password = value
+				}
+			case "account":
+			default:
+				return nil, errors.New("Invalid netrc file, unknown token '%s'.", tok)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	end()
+	return n, nil
+}
+
+// Lookup returns the credentials of the machine entry of the host. The
+// default entry is not used, since the protocol is unknown.
+func (n *Netrc) Lookup(host string) (*Credentials, error) {
+	if c, ok := n.machines[host]; ok {
+		return &c, nil
+	}
+	return nil, nil
+}
+
+// LookupURL returns the credentials of the machine entry of the host of the
+// URL, or of the default entry when UseDefault is set and the URL is https.
+func (n *Netrc) LookupURL(u *url.URL) (*Credentials, error) {
+	if c, err := n.Lookup(strings.ToLower(u.Hostname())); c != nil || err != nil {
+		return c, err
+	}
+	if n.UseDefault && n.def != nil && u.Scheme == "https" {
+		c := *n.def
+		return &c, nil
+	}
+	return nil, nil
+}
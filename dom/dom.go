@@ -0,0 +1,148 @@
+// Package dom defines document and selection interfaces, so code written
+// against them works with any DOM backend: goquery, which the browser uses,
+// a tree built directly with golang.org/x/net/html, or the live DOM of a
+// headless browser.
+//
+// The browser exposes its pages with them through Browser.Document() only;
+// Find() and the other methods of the browser still return goquery
+// selections, which Goquery() converts from a Selection.
+package dom
+
+import (
+	"net/url"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// Selection is a set of elements of a document.
+type Selection interface {
+	// Find returns the descendants of the elements matching the CSS selector.
+	Find(selector string) Selection
+
+	// Filter returns the elements matching the CSS selector.
+	Filter(selector string) Selection
+
+	// Is returns whether one of the elements matches the CSS selector.
+	Is(selector string) bool
+
+	// Length returns the number of elements.
+	Length() int
+
+	// Eq returns the element at the index, or an empty selection when the
+	// index is out of range. A negative index counts from the end.
+	Eq(i int) Selection
+
+	// First returns the first element.
+	First() Selection
+
+	// Each calls fn for each element, with its index.
+	Each(fn func(i int, s Selection))
+
+	// Parent returns the parents of the elements.
+	Parent() Selection
+
+	// Children returns the child elements of the elements.
+	Children() Selection
+
+	// Text returns the combined text of the elements and their descendants.
+	Text() string
+
+	// Attr returns the value of the attribute of the first element, and
+	// whether it has it.
+	Attr(name string) (string, bool)
+
+	// HTML returns the inner HTML of the first element.
+	HTML() (string, error)
+}
+
+// Document is a parsed page.
+type Document interface {
+	Selection
+
+	// URL returns the URL of the page, or nil when it's unknown.
+	URL() *url.URL
+}
+
+// NewDocument returns the Document of a goquery document, for the page at
+// the URL u, which may be nil.
+func NewDocument(doc *goquery.Document, u *url.URL) Document {
+	return &document{selection: selection{doc.Selection}, url: u}
+}
+
+// NewDocumentFromNode returns the Document of a tree built with
+// golang.org/x/net/html.
+func NewDocumentFromNode(root *html.Node, u *url.URL) Document {
+	return NewDocument(goquery.NewDocumentFromNode(root), u)
+}
+
+// Goquery returns the goquery selection of a Selection backed by goquery,
+// eg to use the goquery methods missing from Selection, or nil for the
+// other backends.
+func Goquery(s Selection) *goquery.Selection {
+	switch s := s.(type) {
+	case selection:
+		return s.Selection
+	case *document:
+		return s.Selection
+	}
+	return nil
+}
+
+// document is the goquery implementation of Document.
+type document struct {
+	selection
+	url *url.URL
+}
+
+// URL returns the URL of the page.
+func (d *document) URL() *url.URL {
+	return d.url
+}
+
+// selection is the goquery implementation of Selection.
+type selection struct {
+	*goquery.Selection
+}
+
+// Find returns the descendants of the elements matching the CSS selector.
+func (s selection) Find(selector string) Selection {
+	return selection{s.Selection.Find(selector)}
+}
+
+// Filter returns the elements matching the CSS selector.
+func (s selection) Filter(selector string) Selection {
+	return selection{s.Selection.Filter(selector)}
+}
+
+// Eq returns the element at the index.
+func (s selection) Eq(i int) Selection {
+	return selection{s.Selection.Eq(i)}
+}
+
+// First returns the first element.
+func (s selection) First() Selection {
+	return selection{s.Selection.First()}
+}
+
+// Each calls fn for each element, with its index.
+func (s selection) Each(fn func(i int, sel Selection)) {
+	s.Selection.Each(func(i int, sel *goquery.Selection) {
+		fn(i, selection{sel})
+	})
+}
+
+// Parent returns the parents of the elements.
+func (s selection) Parent() Selection {
+	return selection{s.Selection.Parent()}
+}
+
+// Children returns the child elements of the elements.
+func (s selection) Children() Selection {
+	return selection{s.Selection.Children()}
+}
+
+// HTML returns the inner HTML of the first element.
+func (s selection) HTML() (string, error) {
+	return s.Selection.Html()
+}
@@ -0,0 +1,60 @@
+package dom
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+const page = `<html><body><ul id="list"><li class="a"><a href="/one">One</a></li><li><a href="/two">Two</a></li></ul></body></html>`
+
+func TestDocument(t *testing.T) {
+	gq, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse("http://example.com/")
+	doc := NewDocument(gq, u)
+	if doc.URL() != u {
+		t.Errorf("Expected the URL %s, got %s.", u, doc.URL())
+	}
+	links := doc.Find("#list a")
+	if links.Length() != 2 || links.First().Text() != "One" || links.Eq(-1).Text() != "Two" {
+		t.Errorf("Unexpected links %q.", links.Text())
+	}
+	var hrefs []string
+	links.Each(func(_ int, s Selection) {
+		href, _ := s.Attr("href")
+		hrefs = append(hrefs, href)
+	})
+	if strings.Join(hrefs, " ") != "/one /two" {
+		t.Errorf("Expected the hrefs /one /two, got %v.", hrefs)
+	}
+	if _, ok := links.Attr("title"); ok {
+		t.Error("Expected no title attribute.")
+	}
+	items := doc.Find("ul").Children()
+	if items.Length() != 2 || items.Filter(".a").Length() != 1 || !items.First().Is(".a") {
+		t.Errorf("Unexpected items %d.", items.Length())
+	}
+	if inner, err := links.First().Parent().HTML(); err != nil || inner != `<a href="/one">One</a>` {
+		t.Errorf("Unexpected inner HTML %q, %v.", inner, err)
+	}
+	if Goquery(links) == nil || Goquery(doc) != gq.Selection {
+		t.Error("Expected the goquery selections.")
+	}
+}
+
+func TestNewDocumentFromNode(t *testing.T) {
+	root, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := NewDocumentFromNode(root, nil)
+	if doc.URL() != nil || doc.Find("li").Length() != 2 {
+		t.Errorf("Unexpected document of the node %v.", doc.URL())
+	}
+}
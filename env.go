@@ -0,0 +1,61 @@
+package surf
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/lostinblue/surf/browser"
+	"github.com/lostinblue/surf/errors"
+	"golang.org/x/net/http/httpproxy"
+)
+
+// NewBrowserFromEnv creates and returns a *browser.Browser type configured
+// with the environment variables, then with the options:
+//
+//   - HTTP_PROXY, HTTPS_PROXY and NO_PROXY (or their lowercase versions)
+//     choose the proxy of each request, as curl and http.DefaultTransport do;
+//   - SURF_USER_AGENT sets the user agent;
+//   - SURF_TIMEOUT sets the timeout of the requests, eg "30s".
+//
+// Unlike http.DefaultTransport, the proxy variables are read when the
+// browser is created, rather than once per process. They only set the proxy
+// of the transport of the browser, which keeps its other settings.
+func NewBrowserFromEnv(opts ...Option) (*browser.Browser, error) {
+	env, err := envOptions()
+	if err != nil {
+		return nil, err
+	}
+	return New(append(env, opts...)...)
+}
+
+// envOptions returns the options of the environment variables.
+func envOptions() ([]Option, error) {
+	proxy := envProxy()
+	opts := []Option{func(bow *browser.Browser) error {
+		return bow.SetProxyFunc(proxy)
+	}}
+
+	if ua := os.Getenv("SURF_USER_AGENT"); ua != "" {
+		opts = append(opts, WithUserAgent(ua))
+	}
+	if v := os.Getenv("SURF_TIMEOUT"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			return nil, errors.New("Invalid SURF_TIMEOUT '%s': %s.", v, err)
+		}
+		opts = append(opts, WithTimeout(d))
+	}
+	return opts, nil
+}
+
+// envProxy returns the proxy function of the HTTP_PROXY, HTTPS_PROXY and
+// NO_PROXY variables, as matched by golang.org/x/net/http/httpproxy.
+// Requests to localhost are never proxied.
+func envProxy() func(*http.Request) (*url.URL, error) {
+	proxy := httpproxy.FromEnvironment().ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return proxy(req.URL)
+	}
+}
@@ -0,0 +1,82 @@
+package surf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNewBrowserFromEnv(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("proxied " + r.URL.String() + " " + r.UserAgent()))
+	}))
+	defer proxy.Close()
+
+	t.Setenv("HTTP_PROXY", proxy.URL)
+	t.Setenv("NO_PROXY", "direct.example")
+	t.Setenv("SURF_USER_AGENT", "EnvAgent/1.0")
+	t.Setenv("SURF_TIMEOUT", "3s")
+	bow, err := NewBrowserFromEnv(WithTimeout(4 * time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bow.UserAgent() != "EnvAgent/1.0" || bow.Timeout() != 4*time.Second {
+		t.Errorf("Expected the environment, then the options, got %q %v.", bow.UserAgent(), bow.Timeout())
+	}
+	if err := bow.GET("http://surf.example/page"); err != nil {
+		t.Fatal(err)
+	}
+	if bow.Body() != "proxied http://surf.example/page EnvAgent/1.0" {
+		t.Errorf("Expected the request to go through the proxy, got '%s'.", bow.Body())
+	}
+	if err := bow.GET("http://direct.example/"); err == nil {
+		t.Error("Expected the hosts of NO_PROXY not to go through the proxy.")
+	}
+
+	t.Setenv("SURF_TIMEOUT", "soon")
+	if _, err := NewBrowserFromEnv(); err == nil {
+		t.Error("Expected an error for an invalid SURF_TIMEOUT.")
+	}
+}
+
+func TestEnvProxy(t *testing.T) {
+	t.Setenv("HTTP_PROXY", "proxy.example:3128")
+	t.Setenv("HTTPS_PROXY", "http://proxy.example:3128")
+	t.Setenv("NO_PROXY", "internal.example, .corp.example,api.example:8443,secure.example:443,10.0.0.0/8,192.168.1.5,")
+	tests := map[string]bool{
+		"http://surf.example/":         true,
+		"http://internal.example/":     false,
+		"http://www.internal.example/": false,
+		"http://notinternal.example/":  true,
+		"http://a.corp.example/":       false,
+		"https://api.example:8443/":    false,
+		"https://api.example/":         true,
+		"https://secure.example/":      false,
+		"http://secure.example/":       true,
+		"http://10.1.2.3/":             false,
+		"http://192.168.1.5:8080/":     false,
+		"http://192.168.1.6/":          true,
+		"http://localhost:8080/":       false,
+		"http://127.0.0.1/":            false,
+	}
+	proxy := envProxy()
+	for raw, want := range tests {
+		req, _ := http.NewRequest("GET", raw, nil)
+		u, err := proxy(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := u != nil; got != want {
+			t.Errorf("Expected proxy %v for %s, got %v.", want, raw, u)
+		} else if got && u.String() != "http://proxy.example:3128" {
+			t.Errorf("Expected the proxy of the environment for %s, got %v.", raw, u)
+		}
+	}
+
+	t.Setenv("NO_PROXY", "*")
+	req, _ := http.NewRequest("GET", "http://surf.example/", nil)
+	if u, _ := envProxy()(req); u != nil {
+		t.Error("Expected no proxy for '*'.")
+	}
+}
@@ -4,6 +4,8 @@ package errors
 import (
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 )
 
 // Error represents any generic error.
@@ -110,3 +112,237 @@ func NewInvalidFormValue(msg string, a ...interface{}) InvalidFormValue {
 		error: errors.New(msg),
 	}
 }
+
+// Blocked represents a request that was blocked before being sent.
+type Blocked struct {
+	error
+}
+
+// NewBlocked creates and returns a Blocked type.
+func NewBlocked(msg string, a ...interface{}) Blocked {
+	msg = fmt.Sprintf("Blocked: "+msg, a...)
+	return Blocked{
+		error: errors.New(msg),
+	}
+}
+
+// ParseLimit represents a document which exceeds the parser limits.
+type ParseLimit struct {
+	error
+}
+
+// NewParseLimit creates and returns a ParseLimit type.
+func NewParseLimit(msg string, a ...interface{}) ParseLimit {
+	msg = fmt.Sprintf("Parse limit: "+msg, a...)
+	return ParseLimit{
+		error: errors.New(msg),
+	}
+}
+
+// HostNotAllowed represents a request to a host outside of the allowed hosts.
+type HostNotAllowed struct {
+	error
+}
+
+// NewHostNotAllowed creates and returns a HostNotAllowed type.
+func NewHostNotAllowed(msg string, a ...interface{}) HostNotAllowed {
+	msg = fmt.Sprintf("Host not allowed: "+msg, a...)
+	return HostNotAllowed{
+		error: errors.New(msg),
+	}
+}
+
+// Timeout represents an operation which did not complete in time.
+type Timeout struct {
+	error
+}
+
+// NewTimeout creates and returns a Timeout type.
+func NewTimeout(msg string, a ...interface{}) Timeout {
+	msg = fmt.Sprintf("Timeout: "+msg, a...)
+	return Timeout{
+		error: errors.New(msg),
+	}
+}
+
+// LoginFailed represents a failed login, along with the step which failed.
+type LoginFailed struct {
+	error
+
+	// Step is the login step which failed, eg "fetch", "form", "fill",
+	// "submit" or "verify".
+	Step string
+}
+
+// NewLoginFailed creates and returns a LoginFailed type.
+func NewLoginFailed(step, msg string, a ...interface{}) LoginFailed {
+	msg = fmt.Sprintf("Login failed at "+step+": "+msg, a...)
+	return LoginFailed{
+		error: errors.New(msg),
+		Step:  step,
+	}
+}
+
+// ChallengeError represents an anti-bot challenge page, eg a Cloudflare
+// "Just a moment..." page, returned instead of the requested page.
+type ChallengeError struct {
+	error
+
+	// Kind is the kind of challenge, eg "cloudflare" or "recaptcha".
+	Kind string
+
+	// StatusCode is the status code of the challenge page.
+	StatusCode int
+}
+
+// NewChallengeError creates and returns a ChallengeError type.
+func NewChallengeError(kind string, status int, msg string, a ...interface{}) ChallengeError {
+	msg = fmt.Sprintf("Challenge: "+msg, a...)
+	return ChallengeError{
+		error:      errors.New(msg),
+		Kind:       kind,
+		StatusCode: status,
+	}
+}
+
+// Skipped represents a page which was not downloaded because the response
+// to the HEAD request sent first did not pass the browser filter.
+type Skipped struct {
+	error
+
+	// ContentType is the Content-Type of the page.
+	ContentType string
+
+	// ContentLength is the size of the page, or -1 when unknown.
+	ContentLength int64
+}
+
+// NewSkipped creates and returns a Skipped type.
+func NewSkipped(contentType string, length int64, msg string, a ...interface{}) Skipped {
+	msg = fmt.Sprintf("Skipped: "+msg, a...)
+	return Skipped{
+		error:         errors.New(msg),
+		ContentType:   contentType,
+		ContentLength: length,
+	}
+}
+
+// ExpectationFailed represents a page which did not pass a check of
+// Browser.Expect(), along with the expected and actual values.
+type ExpectationFailed struct {
+	error
+
+	// Check is the name of the failed check, eg "Status" or "SelectorExists".
+	Check string
+
+	// Expected and Actual are the expected and actual values.
+	Expected string
+	Actual   string
+}
+
+// NewExpectationFailed creates and returns an ExpectationFailed type.
+func NewExpectationFailed(check, expected, actual string) ExpectationFailed {
+	msg := fmt.Sprintf("Expectation failed: %s: expected %s, got %s", check, expected, actual)
+	return ExpectationFailed{
+		error:    errors.New(msg),
+		Check:    check,
+		Expected: expected,
+		Actual:   actual,
+	}
+}
+
+// SessionExpired represents a page which still showed an expired session
+// after the SessionGuard of the browser logged in again.
+type SessionExpired struct {
+	error
+}
+
+// NewSessionExpired creates and returns a SessionExpired type.
+func NewSessionExpired(msg string, a ...interface{}) SessionExpired {
+	msg = fmt.Sprintf("Session expired: "+msg, a...)
+	return SessionExpired{
+		error: errors.New(msg),
+	}
+}
+
+// DecompressionLimit represents a response body which exceeds the
+// decompression limits once decoded.
+type DecompressionLimit struct {
+	error
+}
+
+// NewDecompressionLimit creates and returns a DecompressionLimit type.
+func NewDecompressionLimit(msg string, a ...interface{}) DecompressionLimit {
+	msg = fmt.Sprintf("Decompression limit: "+msg, a...)
+	return DecompressionLimit{
+		error: errors.New(msg),
+	}
+}
+
+// Closed represents a request refused or aborted because the browser was
+// closed.
+type Closed struct {
+	error
+}
+
+// NewClosed creates and returns a Closed type.
+func NewClosed(msg string, a ...interface{}) Closed {
+	msg = fmt.Sprintf("Browser closed: "+msg, a...)
+	return Closed{
+		error: errors.New(msg),
+	}
+}
+
+// BudgetExceeded represents a request refused or aborted because the budget
+// of the browser, or of the host, is spent.
+type BudgetExceeded struct {
+	error
+
+	// Limit is the spent limit: "requests", "bytes" or "duration".
+	Limit string
+
+	// Host is the host whose budget is spent, or empty for the budget of
+	// the browser.
+	Host string
+}
+
+// NewBudgetExceeded creates and returns a BudgetExceeded type.
+func NewBudgetExceeded(limit, host, msg string, a ...interface{}) BudgetExceeded {
+	msg = fmt.Sprintf("Budget exceeded: "+msg, a...)
+	return BudgetExceeded{
+		error: errors.New(msg),
+		Limit: limit,
+		Host:  host,
+	}
+}
+
+// ValidationError represents a form submission which returned the form again
+// with error messages, along with the messages of each field.
+type ValidationError struct {
+	error
+
+	// Fields are the error messages by field name.
+	Fields map[string][]string
+
+	// Messages are the error messages which are not about a field.
+	Messages []string
+}
+
+// NewValidationError creates and returns a ValidationError type.
+func NewValidationError(fields map[string][]string, messages []string) ValidationError {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var parts []string
+	for _, name := range names {
+		parts = append(parts, name+": "+strings.Join(fields[name], ", "))
+	}
+	parts = append(parts, messages...)
+	return ValidationError{
+		error:    errors.New("Validation failed: " + strings.Join(parts, "; ")),
+		Fields:   fields,
+		Messages: messages,
+	}
+}
@@ -0,0 +1,363 @@
+// Package export streams extracted records to CSV, JSON or NDJSON files and
+// writers, for pipelines and crawls:
+//
+//	e, err := export.Create("products.csv", export.Options{
+//		Columns: []string{"name", "price", "url"},
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	defer e.Close()
+//	e.Export(map[string]string{"name": "Widget", "price": "1.50"})
+//
+// Exporters are safe for concurrent use, so the workers of a crawl may share one.
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+// DefaultInfer is the number of records buffered to infer the columns of the
+// JSON and NDJSON exporters created without columns. CSV exporters buffer
+// every record by default, see Options.Infer.
+var DefaultInfer = 1
+
+// Format is the format records are written in.
+type Format string
+
+const (
+	// CSV writes a header line with the column names, and a line per record.
+	CSV Format = "csv"
+
+	// JSON writes an array of records.
+	JSON Format = "json"
+
+	// NDJSON writes a JSON object per line.
+	NDJSON Format = "ndjson"
+)
+
+// Exporter receives the records of a scrape.
+type Exporter interface {
+	// Export writes the record.
+	Export(rec map[string]string) error
+
+	// Close writes the buffered records and the end of the output.
+	Close() error
+}
+
+// Options configure the columns of a Writer.
+type Options struct {
+	// Columns are the fields written, in order. CSV lines and JSON objects
+	// hold these fields only.
+	//
+	// When empty, the columns are inferred from the first records: they are
+	// the names of their fields, sorted. Later fields missing from the
+	// inferred columns are left out of CSV lines, and written after the
+	// columns, sorted, in JSON objects.
+	Columns []string
+
+	// Infer is the number of records buffered to infer the columns. When 0,
+	// CSV writers buffer every record until Close(), so the header holds
+	// every field, and JSON writers buffer DefaultInfer records. Setting it
+	// streams CSV records sooner, at the cost of the fields first seen
+	// afterwards.
+	Infer int
+
+	// Indent indents the JSON array and its objects with the given string,
+	// eg two spaces. The records are written on a line each when empty.
+	Indent string
+}
+
+// Writer is an Exporter writing records to an io.Writer.
+type Writer struct {
+	mu       sync.Mutex
+	w        io.Writer
+	closer   io.Closer
+	format   Format
+	columns  []string
+	explicit bool
+	infer    int
+	indent   string
+	pending  []map[string]string
+	started  bool
+	closed   bool
+	count    int
+	csv      *csv.Writer
+}
+
+// New creates and returns a *Writer writing the records to w in the given format.
+func New(w io.Writer, f Format, opts Options) (*Writer, error) {
+	switch f {
+	case CSV, JSON, NDJSON:
+	default:
+		return nil, errors.New("Unknown export format '%s', expected csv, json or ndjson.", f)
+	}
+	infer := opts.Infer
+	if infer <= 0 && f != CSV {
+		infer = DefaultInfer
+	}
+	return &Writer{
+		w:        w,
+		format:   f,
+		columns:  opts.Columns,
+		explicit: len(opts.Columns) > 0,
+		infer:    infer,
+		indent:   opts.Indent,
+	}, nil
+}
+
+// NewCSV creates and returns a *Writer writing CSV to w, with the given
+// columns or inferred ones when none are given.
+func NewCSV(w io.Writer, columns ...string) *Writer {
+	e, _ := New(w, CSV, Options{Columns: columns})
+	return e
+}
+
+// NewJSON creates and returns a *Writer writing a JSON array to w, with the
+// given columns or inferred ones when none are given.
+func NewJSON(w io.Writer, columns ...string) *Writer {
+	e, _ := New(w, JSON, Options{Columns: columns})
+	return e
+}
+
+// NewNDJSON creates and returns a *Writer writing NDJSON to w, with the given
+// columns or inferred ones when none are given.
+func NewNDJSON(w io.Writer, columns ...string) *Writer {
+	e, _ := New(w, NDJSON, Options{Columns: columns})
+	return e
+}
+
+// Create creates or truncates the named file, and returns a *Writer writing
+// to it in the format given by its extension: .csv, .json, or .ndjson and
+// .jsonl. Close() closes the file.
+func Create(file string, opts Options) (*Writer, error) {
+	f, err := FormatOf(file)
+	if err != nil {
+		return nil, err
+	}
+	fout, err := os.Create(file)
+	if err != nil {
+		return nil, err
+	}
+	e, _ := New(fout, f, opts)
+	e.closer = fout
+	return e, nil
+}
+
+// FormatOf returns the format of the named file, by extension.
+func FormatOf(file string) (Format, error) {
+	switch ext := strings.ToLower(filepath.Ext(file)); ext {
+	case ".csv":
+		return CSV, nil
+	case ".json":
+		return JSON, nil
+	case ".ndjson", ".jsonl":
+		return NDJSON, nil
+	default:
+		return "", errors.New("Unknown export file extension '%s', expected .csv, .json, .ndjson or .jsonl.", ext)
+	}
+}
+
+// Columns returns the columns of the writer, or nil while they are being inferred.
+func (e *Writer) Columns() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if !e.explicit && !e.started {
+		return nil
+	}
+	return append([]string(nil), e.columns...)
+}
+
+// Export writes the record, or buffers it while the columns are inferred.
+func (e *Writer) Export(rec map[string]string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		return errors.New("Cannot export a record, the exporter is closed.")
+	}
+	if !e.started && !e.explicit {
+		e.pending = append(e.pending, rec)
+		if e.infer <= 0 || len(e.pending) < e.infer {
+			return nil
+		}
+		return e.flush()
+	}
+	return e.write(rec)
+}
+
+// Close writes the buffered records and the end of the output, and closes
+// the file of the writers returned by Create().
+func (e *Writer) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	err := e.flush()
+	if err == nil {
+		err = e.start()
+	}
+	if err == nil && e.format == JSON {
+		if e.count == 0 {
+			_, err = io.WriteString(e.w, "[]\n")
+		} else {
+			_, err = io.WriteString(e.w, "\n]\n")
+		}
+	}
+	if e.closer != nil {
+		if cerr := e.closer.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+// flush infers the columns from the buffered records, and writes them.
+func (e *Writer) flush() error {
+	if !e.explicit && !e.started {
+		e.columns = fieldNames(e.pending)
+	}
+	pending := e.pending
+	e.pending = nil
+	for _, rec := range pending {
+		if err := e.write(rec); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// start writes the beginning of the output the first time it's called.
+func (e *Writer) start() error {
+	if e.started {
+		return nil
+	}
+	e.started = true
+	if e.format == CSV {
+		e.csv = csv.NewWriter(e.w)
+		if len(e.columns) == 0 {
+			return nil
+		}
+		e.csv.Write(e.columns)
+		e.csv.Flush()
+		return e.csv.Error()
+	}
+	return nil
+}
+
+// write writes a record in the format of the writer.
+func (e *Writer) write(rec map[string]string) error {
+	if err := e.start(); err != nil {
+		return err
+	}
+	e.count++
+	if e.format == CSV {
+		row := make([]string, len(e.columns))
+		for i, c := range e.columns {
+			row[i] = rec[c]
+		}
+		e.csv.Write(row)
+		e.csv.Flush()
+		return e.csv.Error()
+	}
+
+	b, err := e.marshal(rec)
+	if err != nil {
+		return err
+	}
+	if e.format == JSON {
+		if e.indent != "" {
+			indented := &bytes.Buffer{}
+			if err := json.Indent(indented, b, e.indent, e.indent); err != nil {
+				return err
+			}
+			b = append([]byte(e.indent), indented.Bytes()...)
+		}
+		sep := ",\n"
+		if e.count == 1 {
+			sep = "[\n"
+		}
+		_, err = io.WriteString(e.w, sep+string(b))
+		return err
+	}
+	_, err = e.w.Write(append(b, '\n'))
+	return err
+}
+
+// marshal encodes the record as a JSON object, with its fields in the order
+// of the columns.
+func (e *Writer) marshal(rec map[string]string) ([]byte, error) {
+	keys := make([]string, 0, len(rec))
+	for _, c := range e.columns {
+		if _, ok := rec[c]; ok {
+			keys = append(keys, c)
+		}
+	}
+	if !e.explicit && len(keys) < len(rec) {
+		start := len(keys)
+		for name := range rec {
+			if !contains(e.columns, name) {
+				keys = append(keys, name)
+			}
+		}
+		sort.Strings(keys[start:])
+	}
+
+	buf := &bytes.Buffer{}
+	buf.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		kb, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+		vb, err := json.Marshal(rec[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(kb)
+		buf.WriteByte(':')
+		buf.Write(vb)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// fieldNames returns the names of the fields of the records, sorted.
+func fieldNames(records []map[string]string) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, rec := range records {
+		for name := range rec {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// contains returns a boolean value indicating whether the names contain name.
+func contains(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,152 @@
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+var records = []map[string]string{
+	{"name": "Widget", "price": "1.50"},
+	{"name": "Gadget, large", "url": "/g", "price": "10"},
+}
+
+func export(t *testing.T, e *Writer, records []map[string]string) {
+	for _, rec := range records {
+		if err := e.Export(rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCSV(t *testing.T) {
+	var out bytes.Buffer
+	e := NewCSV(&out)
+	export(t, e, records)
+	expected := "name,price,url\nWidget,1.50,\n\"Gadget, large\",10,/g\n"
+	if out.String() != expected {
+		t.Errorf("Expected the columns of every record %q, got %q.", expected, out.String())
+	}
+	if cols := e.Columns(); !reflect.DeepEqual(cols, []string{"name", "price", "url"}) {
+		t.Errorf("Expected the inferred columns, got %v.", cols)
+	}
+
+	out.Reset()
+	e, _ = New(&out, CSV, Options{Infer: 1})
+	export(t, e, records)
+	expected = "name,price\nWidget,1.50\n\"Gadget, large\",10\n"
+	if out.String() != expected {
+		t.Errorf("Expected the columns of the first record %q, got %q.", expected, out.String())
+	}
+
+	out.Reset()
+	export(t, NewCSV(&out, "url", "name"), records)
+	expected = "url,name\n,Widget\n/g,\"Gadget, large\"\n"
+	if out.String() != expected {
+		t.Errorf("Expected the given columns %q, got %q.", expected, out.String())
+	}
+
+	out.Reset()
+	export(t, NewCSV(&out), nil)
+	if out.Len() != 0 {
+		t.Errorf("Expected no output without records, got %q.", out.String())
+	}
+}
+
+func TestJSON(t *testing.T) {
+	var out bytes.Buffer
+	e := NewJSON(&out)
+	export(t, e, records)
+	expected := "[\n{\"name\":\"Widget\",\"price\":\"1.50\"},\n{\"name\":\"Gadget, large\",\"price\":\"10\",\"url\":\"/g\"}\n]\n"
+	if out.String() != expected {
+		t.Errorf("Expected the output %q, got %q.", expected, out.String())
+	}
+	var decoded []map[string]string
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil || !reflect.DeepEqual(decoded, records) {
+		t.Errorf("Expected the records decoded, got %v (%v).", decoded, err)
+	}
+	if err := e.Export(records[0]); err == nil {
+		t.Error("Expected an error when exporting to a closed exporter.")
+	}
+
+	out.Reset()
+	export(t, NewJSON(&out, "price", "name"), records)
+	expected = "[\n{\"price\":\"1.50\",\"name\":\"Widget\"},\n{\"price\":\"10\",\"name\":\"Gadget, large\"}\n]\n"
+	if out.String() != expected {
+		t.Errorf("Expected the fields in column order %q, got %q.", expected, out.String())
+	}
+
+	out.Reset()
+	export(t, NewJSON(&out), nil)
+	if out.String() != "[]\n" {
+		t.Errorf("Expected an empty array, got %q.", out.String())
+	}
+
+	out.Reset()
+	e, _ = New(&out, JSON, Options{Indent: "  "})
+	export(t, e, records[:1])
+	expected = "[\n  {\n    \"name\": \"Widget\",\n    \"price\": \"1.50\"\n  }\n]\n"
+	if out.String() != expected {
+		t.Errorf("Expected the indented output %q, got %q.", expected, out.String())
+	}
+}
+
+func TestNDJSON(t *testing.T) {
+	var out bytes.Buffer
+	e := NewNDJSON(&out, "url", "name")
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			e.Export(records[1])
+		}()
+	}
+	wg.Wait()
+	e.Close()
+	line := "{\"url\":\"/g\",\"name\":\"Gadget, large\"}\n"
+	if out.String() != string(bytes.Repeat([]byte(line), 10)) {
+		t.Errorf("Expected 10 lines %q, got %q.", line, out.String())
+	}
+}
+
+func TestCreate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "surf-export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"out.csv":    "name,price,url\nWidget,1.50,\n\"Gadget, large\",10,/g\n",
+		"out.jsonl":  "{\"name\":\"Widget\",\"price\":\"1.50\"}\n{\"name\":\"Gadget, large\",\"price\":\"10\",\"url\":\"/g\"}\n",
+		"out.NDJSON": "{\"name\":\"Widget\",\"price\":\"1.50\"}\n{\"name\":\"Gadget, large\",\"price\":\"10\",\"url\":\"/g\"}\n",
+	}
+	for name, expected := range files {
+		file := filepath.Join(dir, name)
+		e, err := Create(file, Options{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		export(t, e, records)
+		b, _ := ioutil.ReadFile(file)
+		if string(b) != expected {
+			t.Errorf("Expected %s to hold %q, got %q.", name, expected, b)
+		}
+	}
+
+	if _, err := Create(filepath.Join(dir, "out.xml"), Options{}); err == nil {
+		t.Error("Expected an error for an unknown extension.")
+	}
+	if _, err := New(ioutil.Discard, Format("xml"), Options{}); err == nil {
+		t.Error("Expected an error for an unknown format.")
+	}
+}
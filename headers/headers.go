@@ -0,0 +1,121 @@
+// Package headers has presets of the request headers sent by common
+// clients, eg browsers or API clients, applied to a browser with
+// Browser.ApplyHeaderProfile().
+package headers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Header is a request header of a Profile.
+type Header struct {
+	Name  string
+	Value string
+}
+
+// Profile is a named set of request headers, listed in the order they are
+// sent.
+type Profile struct {
+	// Name identifies the profile.
+	Name string
+
+	// Headers are the headers of the profile, in order. Headers with an
+	// empty value only set the position of headers set elsewhere, such as
+	// User-Agent or Cookie.
+	Headers []Header
+}
+
+// New creates and returns a new *Profile type with the given name and
+// headers, given as name and value pairs.
+func New(name string, pairs ...string) *Profile {
+	p := &Profile{Name: name}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		p.Set(pairs[i], pairs[i+1])
+	}
+	return p
+}
+
+// Set sets the value of the header, keeping its position when the profile
+// already has it, and appending it otherwise.
+func (p *Profile) Set(name, value string) *Profile {
+	name = http.CanonicalHeaderKey(name)
+	for i, h := range p.Headers {
+		if h.Name == name {
+			p.Headers[i].Value = value
+			return p
+		}
+	}
+	p.Headers = append(p.Headers, Header{Name: name, Value: value})
+	return p
+}
+
+// Header returns the headers of the profile which have a value.
+func (p *Profile) Header() http.Header {
+	h := make(http.Header, len(p.Headers))
+	for _, ph := range p.Headers {
+		if ph.Value != "" {
+			h.Set(ph.Name, ph.Value)
+		}
+	}
+	return h
+}
+
+// Order returns the names of the headers of the profile, in order.
+func (p *Profile) Order() []string {
+	order := make([]string, len(p.Headers))
+	for i, h := range p.Headers {
+		order[i] = h.Name
+	}
+	return order
+}
+
+// JSONAPI returns the headers of a client of a JSON API.
+func JSONAPI() *Profile {
+	return New("json-api",
+		"Accept", "application/json",
+		"User-Agent", "",
+		"Cache-Control", "no-cache",
+	)
+}
+
+// BrowserLike returns the headers a desktop browser sends when navigating
+// to a page, accepting content in the given language, eg "fr-FR". An empty
+// language defaults to "en-US".
+func BrowserLike(lang string) *Profile {
+	return New("browser",
+		"Host", "",
+		"Connection", "",
+		"Upgrade-Insecure-Requests", "1",
+		"User-Agent", "",
+		"Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,*/*;q=0.8",
+		"Sec-Fetch-Site", "none",
+		"Sec-Fetch-Mode", "navigate",
+		"Sec-Fetch-User", "?1",
+		"Sec-Fetch-Dest", "document",
+		"Referer", "",
+		"Accept-Encoding", "",
+		"Accept-Language", acceptLanguage(lang),
+		"Cookie", "",
+	)
+}
+
+// acceptLanguage returns the Accept-Language header of a browser using the
+// given language, falling back to its base language and English.
+func acceptLanguage(lang string) string {
+	if lang == "" {
+		lang = "en-US"
+	}
+	langs := []string{lang}
+	if i := strings.IndexAny(lang, "-_"); i > 0 {
+		langs = append(langs, lang[:i])
+	}
+	if base := langs[len(langs)-1]; base != "en" {
+		langs = append(langs, "en")
+	}
+	for i := 1; i < len(langs); i++ {
+		langs[i] = fmt.Sprintf("%s;q=0.%d", langs[i], 10-i)
+	}
+	return strings.Join(langs, ",")
+}
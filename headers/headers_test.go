@@ -0,0 +1,36 @@
+package headers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProfile(t *testing.T) {
+	p := New("test", "accept", "text/html", "User-Agent", "")
+	p.Set("X-Requested-With", "XMLHttpRequest").Set("Accept", "application/json")
+
+	expected := []string{"Accept", "User-Agent", "X-Requested-With"}
+	if order := p.Order(); !reflect.DeepEqual(order, expected) {
+		t.Errorf("Expected order %v, got %v", expected, order)
+	}
+	h := p.Header()
+	if len(h) != 2 || h.Get("Accept") != "application/json" {
+		t.Errorf("Expected the headers with a value, got %v", h)
+	}
+}
+
+func TestBrowserLike(t *testing.T) {
+	tests := map[string]string{
+		"":      "en-US,en;q=0.9",
+		"fr-FR": "fr-FR,fr;q=0.9,en;q=0.8",
+		"de":    "de,en;q=0.9",
+	}
+	for lang, expected := range tests {
+		if v := BrowserLike(lang).Header().Get("Accept-Language"); v != expected {
+			t.Errorf("Expected Accept-Language %q for %q, got %q", expected, lang, v)
+		}
+	}
+	if v := JSONAPI().Header().Get("Accept"); v != "application/json" {
+		t.Errorf("Expected Accept application/json, got %q", v)
+	}
+}
@@ -175,6 +175,11 @@ func (b *FileBookmarks) All() BookmarksMap {
 	return b.bookmarks
 }
 
+// Flush writes the bookmarks to the file.
+func (b *FileBookmarks) Flush() error {
+	return b.writeToFile()
+}
+
 // writeToFile writes the bookmarks to the file.
 func (b *FileBookmarks) writeToFile() (err error) {
 	j, err := json.Marshal(b.bookmarks)
@@ -195,3 +200,17 @@ func (b *FileBookmarks) writeToFile() (err error) {
 
 	return err
 }
+
+// BookmarkParams returns the names of the parameters in a templated bookmark
+// URL, eg "query" for "https://example.com/search?q={query}".
+func BookmarkParams(tmpl string) []string {
+	return util.TemplateParams(tmpl)
+}
+
+// ExpandBookmark substitutes the parameters of a templated bookmark URL with
+// the given values, see util.ExpandTemplate().
+//
+// Returns an error when a parameter has no value.
+func ExpandBookmark(tmpl string, params map[string]string) (string, error) {
+	return util.ExpandTemplate(tmpl, params)
+}
@@ -51,3 +51,25 @@ func assertBookmarks(b BookmarksJar) {
 	r = b.Has("test4")
 	ut.AssertFalse(r)
 }
+
+func TestExpandBookmark(t *testing.T) {
+	ut.Run(t)
+
+	tmpl := "https://example.com/{section}/search?q={query}&page={page}"
+	ut.AssertEquals([]string{"section", "query", "page"}, BookmarkParams(tmpl))
+
+	u, err := ExpandBookmark(tmpl, map[string]string{
+		"section": "go lang",
+		"query":   "a&b c",
+		"page":    "2",
+	})
+	ut.AssertNil(err)
+	ut.AssertEquals("https://example.com/go%20lang/search?q=a%26b+c&page=2", u)
+
+	_, err = ExpandBookmark(tmpl, map[string]string{"query": "surf"})
+	ut.AssertNotNil(err)
+
+	u, err = ExpandBookmark("http://localhost", nil)
+	ut.AssertNil(err)
+	ut.AssertEquals("http://localhost", u)
+}
@@ -1,11 +1,245 @@
 package jar
 
-import "net/http/cookiejar"
+import (
+	"net"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
 
-// New returns a new cookie jar.
+	"golang.org/x/net/publicsuffix"
+)
+
+// CookiesJar is an http.CookieJar which can also list and remove cookies.
+type CookiesJar interface {
+	http.CookieJar
+
+	// HostCookies returns every cookie which would be sent to the given host,
+	// no matter the path.
+	HostCookies(host string) []*http.Cookie
+
+	// Remove deletes the cookies with the given name which would be sent to
+	// the given host.
+	Remove(host, name string) bool
+
+	// Clear deletes every cookie.
+	Clear()
+}
+
+// cookieEntry is a cookie stored by MemoryCookies along with its scope.
+type cookieEntry struct {
+	cookie   *http.Cookie
+	domain   string
+	path     string
+	hostOnly bool
+	expires  time.Time
+}
+
+// MemoryCookies is an in-memory implementation of CookiesJar.
+//
+// Cookies are stored in a cookiejar.Jar, which decides which cookies are
+// sent with each request, and are also recorded so they may be listed and
+// removed.
+type MemoryCookies struct {
+	mu      sync.Mutex
+	jar     *cookiejar.Jar
+	options *cookiejar.Options
+	entries map[string]*cookieEntry
+}
+
+// NewMemoryCookies creates and returns a new *cookiejar.Jar type.
+//
+// The jar uses the public suffix list, so cookies set by one site for a
+// public suffix, such as a.github.io setting a cookie for github.io, are not
+// sent to other sites under the same suffix. Use NewCookiesJar() for a jar
+// which can also list and remove its cookies.
 func NewMemoryCookies() *cookiejar.Jar {
 	// cookiejar.New returns an error, but it's always nil. Maybe it's there
 	// for future use or to conform to an interface?
-	jar, _ := cookiejar.New(nil)
+	jar, _ := cookiejar.New(publicSuffixOptions())
 	return jar
 }
+
+// NewCookiesJar creates and returns a new *MemoryCookies type, which uses the
+// public suffix list as NewMemoryCookies() does.
+func NewCookiesJar() *MemoryCookies {
+	return NewCookiesJarWithOptions(publicSuffixOptions())
+}
+
+// NewCookiesJarWithOptions creates and returns a new *MemoryCookies type
+// using the given options. A nil PublicSuffixList allows cookies to be set
+// for any parent domain, which is the behavior of cookiejar.New(nil).
+func NewCookiesJarWithOptions(o *cookiejar.Options) *MemoryCookies {
+	jar, _ := cookiejar.New(o)
+	return &MemoryCookies{
+		jar:     jar,
+		options: o,
+		entries: make(map[string]*cookieEntry),
+	}
+}
+
+// publicSuffixOptions returns the options of the jars using the public
+// suffix list.
+func publicSuffixOptions() *cookiejar.Options {
+	return &cookiejar.Options{PublicSuffixList: publicsuffix.List}
+}
+
+// SetCookies handles the receipt of the cookies in a reply for the given URL.
+func (c *MemoryCookies) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.jar.SetCookies(u, cookies)
+	host := canonicalHost(u.Host)
+	now := time.Now()
+	for _, cookie := range cookies {
+		e := &cookieEntry{
+			cookie:   cookie,
+			domain:   strings.TrimPrefix(strings.ToLower(cookie.Domain), "."),
+			path:     cookie.Path,
+			hostOnly: cookie.Domain == "",
+		}
+		if e.domain == host && c.isPublicSuffix(host) {
+			// A site which is itself a public suffix may only set host cookies.
+			e.hostOnly = true
+		}
+		if e.hostOnly {
+			e.domain = host
+		} else if !domainMatch(host, e.domain) || c.isPublicSuffix(e.domain) {
+			continue
+		}
+		if e.path == "" || e.path[0] != '/' {
+			e.path = defaultPath(u.Path)
+		}
+		key := e.domain + ";" + e.path + ";" + cookie.Name
+
+		switch {
+		case cookie.MaxAge < 0:
+			delete(c.entries, key)
+			continue
+		case cookie.MaxAge > 0:
+			e.expires = now.Add(time.Duration(cookie.MaxAge) * time.Second)
+		case !cookie.Expires.IsZero():
+			if !cookie.Expires.After(now) {
+				delete(c.entries, key)
+				continue
+			}
+			e.expires = cookie.Expires
+		}
+		c.entries[key] = e
+	}
+}
+
+// Cookies returns the cookies to send in a request for the given URL.
+func (c *MemoryCookies) Cookies(u *url.URL) []*http.Cookie {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.jar.Cookies(u)
+}
+
+// HostCookies returns every cookie which would be sent to the given host,
+// no matter the path.
+func (c *MemoryCookies) HostCookies(host string) []*http.Cookie {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var cookies []*http.Cookie
+	for _, e := range c.matching(canonicalHost(host), "") {
+		cookie := *e.cookie
+		cookie.Domain = e.domain
+		cookie.Path = e.path
+		if !e.expires.IsZero() {
+			cookie.Expires = e.expires
+		}
+		cookies = append(cookies, &cookie)
+	}
+	return cookies
+}
+
+// Remove deletes the cookies with the given name which would be sent to
+// the given host.
+//
+// Returns a boolean value indicating whether any cookie was removed.
+func (c *MemoryCookies) Remove(host, name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	removed := false
+	for key, e := range c.matching(canonicalHost(host), name) {
+		expired := &http.Cookie{Name: name, Path: e.path, MaxAge: -1}
+		if !e.hostOnly {
+			expired.Domain = e.domain
+		}
+		c.jar.SetCookies(&url.URL{Scheme: "https", Host: e.domain, Path: e.path}, []*http.Cookie{expired})
+		delete(c.entries, key)
+		removed = true
+	}
+	return removed
+}
+
+// Clear deletes every cookie.
+func (c *MemoryCookies) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.jar, _ = cookiejar.New(c.options)
+	c.entries = make(map[string]*cookieEntry)
+}
+
+// isPublicSuffix returns a boolean value indicating whether cookies may not
+// be set for the given domain, because it's a public suffix.
+func (c *MemoryCookies) isPublicSuffix(domain string) bool {
+	if c.options == nil || c.options.PublicSuffixList == nil {
+		return false
+	}
+	return c.options.PublicSuffixList.PublicSuffix(domain) == domain
+}
+
+// matching returns the unexpired entries which would be sent to the given
+// host, keyed by their id. When name is not empty only cookies with that
+// name are returned.
+func (c *MemoryCookies) matching(host, name string) map[string]*cookieEntry {
+	now := time.Now()
+	matches := make(map[string]*cookieEntry)
+	for key, e := range c.entries {
+		if !e.expires.IsZero() && !e.expires.After(now) {
+			delete(c.entries, key)
+			continue
+		}
+		if name != "" && e.cookie.Name != name {
+			continue
+		}
+		if host == e.domain || (!e.hostOnly && domainMatch(host, e.domain)) {
+			matches[key] = e
+		}
+	}
+	return matches
+}
+
+// canonicalHost strips the port from the host and lowercases it.
+func canonicalHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(host)
+}
+
+// domainMatch returns a boolean value indicating whether the host is the
+// domain or one of its sub-domains.
+func domainMatch(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// defaultPath returns the cookie path used when a cookie does not have one,
+// as defined by RFC 6265 section 5.1.4.
+func defaultPath(path string) string {
+	if path == "" || path[0] != '/' {
+		return "/"
+	}
+	i := strings.LastIndex(path, "/")
+	if i == 0 {
+		return "/"
+	}
+	return path[:i]
+}
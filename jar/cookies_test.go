@@ -0,0 +1,63 @@
+package jar
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/lostinblue/ut"
+)
+
+func TestMemoryCookies(t *testing.T) {
+	ut.Run(t)
+
+	c := NewCookiesJar()
+	u, _ := url.Parse("http://www.example.com/account/login")
+	c.SetCookies(u, []*http.Cookie{
+		{Name: "session", Value: "1"},
+		{Name: "lang", Value: "en", Path: "/", Domain: ".example.com"},
+		{Name: "old", Value: "x", MaxAge: -1},
+		{Name: "evil", Value: "x", Domain: "other.com"},
+	})
+
+	cookies := c.HostCookies("www.example.com")
+	ut.AssertEquals(2, len(cookies))
+	cookies = c.HostCookies("shop.example.com:8080")
+	ut.AssertEquals(1, len(cookies))
+	ut.AssertEquals("lang", cookies[0].Name)
+	ut.AssertEquals(0, len(c.HostCookies("other.com")))
+
+	sent, _ := url.Parse("http://www.example.com/account/profile")
+	ut.AssertEquals(2, len(c.Cookies(sent)))
+
+	ut.AssertTrue(c.Remove("www.example.com", "session"))
+	ut.AssertFalse(c.Remove("www.example.com", "session"))
+	ut.AssertEquals(1, len(c.Cookies(sent)))
+	ut.AssertEquals(1, len(c.HostCookies("www.example.com")))
+
+	c.Clear()
+	ut.AssertEquals(0, len(c.Cookies(sent)))
+	ut.AssertEquals(0, len(c.HostCookies("www.example.com")))
+}
+
+func TestMemoryCookiesPublicSuffix(t *testing.T) {
+	ut.Run(t)
+
+	a, _ := url.Parse("https://a.github.io/")
+	b, _ := url.Parse("https://b.github.io/")
+	cookie := []*http.Cookie{{Name: "shared", Value: "1", Domain: "github.io"}}
+
+	j := NewMemoryCookies()
+	j.SetCookies(a, cookie)
+	ut.AssertEquals(0, len(j.Cookies(b)))
+
+	c := NewCookiesJar()
+	c.SetCookies(a, cookie)
+	ut.AssertEquals(0, len(c.Cookies(b)))
+	ut.AssertEquals(0, len(c.HostCookies("b.github.io")))
+
+	c = NewCookiesJarWithOptions(nil)
+	c.SetCookies(a, cookie)
+	ut.AssertEquals(1, len(c.Cookies(b)))
+	ut.AssertEquals(1, len(c.HostCookies("b.github.io")))
+}
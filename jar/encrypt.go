@@ -0,0 +1,192 @@
+package jar
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+// sealer encrypts values with AES-GCM, for the encrypting jars.
+type sealer struct {
+	aead cipher.AEAD
+}
+
+// newSealer creates and returns a new *sealer type using the given key, which
+// must be 16, 24 or 32 bytes long to use AES-128, AES-192 or AES-256.
+func newSealer(key []byte) (*sealer, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &sealer{aead: aead}, nil
+}
+
+// seal encrypts the value, bound to the given associated data, and returns
+// it base64 encoded with its nonce. The result is a valid cookie value.
+func (s *sealer) seal(value, data string) (string, error) {
+	nonce := make([]byte, s.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	b := s.aead.Seal(nonce, nonce, []byte(value), []byte(data))
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// open decrypts a value returned by seal with the same associated data.
+func (s *sealer) open(sealed, data string) (string, error) {
+	b, err := base64.RawURLEncoding.DecodeString(sealed)
+	if err != nil {
+		return "", err
+	}
+	n := s.aead.NonceSize()
+	if len(b) < n {
+		return "", errors.New("The sealed value is too short.")
+	}
+	value, err := s.aead.Open(nil, b[:n], b[n:], []byte(data))
+	if err != nil {
+		return "", err
+	}
+	return string(value), nil
+}
+
+// EncryptedCookies is a CookiesJar which encrypts the cookie values before
+// storing them in another jar, eg one saved to a shared disk or database.
+type EncryptedCookies struct {
+	inner  CookiesJar
+	sealer *sealer
+}
+
+// Encrypt returns a jar storing the cookies in inner with their values
+// encrypted with AES-GCM. The key must be 16, 24 or 32 bytes long.
+//
+// Cookie names and scopes are stored in clear, as the inner jar needs them to
+// match the cookies with requests. Values are bound to the cookie name, and
+// values which cannot be decrypted, eg ones stored with another key, are
+// dropped.
+func Encrypt(inner CookiesJar, key []byte) (*EncryptedCookies, error) {
+	s, err := newSealer(key)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptedCookies{inner: inner, sealer: s}, nil
+}
+
+// SetCookies encrypts the values of the cookies and stores them in the inner
+// jar.
+func (c *EncryptedCookies) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	sealed := make([]*http.Cookie, 0, len(cookies))
+	for _, cookie := range cookies {
+		value, err := c.sealer.seal(cookie.Value, cookie.Name)
+		if err != nil {
+			continue
+		}
+		cp := *cookie
+		cp.Value = value
+		sealed = append(sealed, &cp)
+	}
+	c.inner.SetCookies(u, sealed)
+}
+
+// Cookies returns the cookies to send in a request for the given URL.
+func (c *EncryptedCookies) Cookies(u *url.URL) []*http.Cookie {
+	return c.open(c.inner.Cookies(u))
+}
+
+// HostCookies returns every cookie which would be sent to the given host,
+// no matter the path.
+func (c *EncryptedCookies) HostCookies(host string) []*http.Cookie {
+	return c.open(c.inner.HostCookies(host))
+}
+
+// Remove deletes the cookies with the given name which would be sent to
+// the given host.
+func (c *EncryptedCookies) Remove(host, name string) bool {
+	return c.inner.Remove(host, name)
+}
+
+// Clear deletes every cookie.
+func (c *EncryptedCookies) Clear() {
+	c.inner.Clear()
+}
+
+// open returns copies of the cookies with their values decrypted, dropping
+// the cookies which cannot be decrypted.
+func (c *EncryptedCookies) open(cookies []*http.Cookie) []*http.Cookie {
+	opened := make([]*http.Cookie, 0, len(cookies))
+	for _, cookie := range cookies {
+		value, err := c.sealer.open(cookie.Value, cookie.Name)
+		if err != nil {
+			continue
+		}
+		cp := *cookie
+		cp.Value = value
+		opened = append(opened, &cp)
+	}
+	return opened
+}
+
+// EncryptedStorage is a Storage which encrypts the item values before storing
+// them in another Storage, eg a FileStorage on a shared disk.
+type EncryptedStorage struct {
+	inner  Storage
+	sealer *sealer
+}
+
+// EncryptStorage returns a Storage saving the items in inner with their
+// values encrypted with AES-GCM. The key must be 16, 24 or 32 bytes long.
+//
+// Origins and keys are stored in clear. Values are bound to their origin and
+// key, and values which cannot be decrypted are reported as missing.
+func EncryptStorage(inner Storage, key []byte) (*EncryptedStorage, error) {
+	s, err := newSealer(key)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptedStorage{inner: inner, sealer: s}, nil
+}
+
+// GetItem returns the value of the item with the given key.
+func (s *EncryptedStorage) GetItem(origin, key string) (string, bool) {
+	sealed, ok := s.inner.GetItem(origin, key)
+	if !ok {
+		return "", false
+	}
+	value, err := s.sealer.open(sealed, origin+"\x00"+key)
+	if err != nil {
+		return "", false
+	}
+	return value, true
+}
+
+// SetItem saves an item with the given key and value.
+func (s *EncryptedStorage) SetItem(origin, key, value string) error {
+	sealed, err := s.sealer.seal(value, origin+"\x00"+key)
+	if err != nil {
+		return err
+	}
+	return s.inner.SetItem(origin, key, sealed)
+}
+
+// RemoveItem deletes the item with the given key.
+func (s *EncryptedStorage) RemoveItem(origin, key string) error {
+	return s.inner.RemoveItem(origin, key)
+}
+
+// Clear deletes every item of the origin.
+func (s *EncryptedStorage) Clear(origin string) error {
+	return s.inner.Clear(origin)
+}
+
+// Keys returns the sorted keys of every item of the origin.
+func (s *EncryptedStorage) Keys(origin string) []string {
+	return s.inner.Keys(origin)
+}
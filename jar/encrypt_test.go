@@ -0,0 +1,60 @@
+package jar
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/lostinblue/ut"
+)
+
+func TestEncrypt(t *testing.T) {
+	ut.Run(t)
+
+	key := []byte("0123456789abcdef0123456789abcdef")
+	inner := NewCookiesJar()
+	c, err := Encrypt(inner, key)
+	ut.AssertNil(err)
+
+	u, _ := url.Parse("https://example.com/")
+	c.SetCookies(u, []*http.Cookie{{Name: "session", Value: "secret token"}})
+
+	cookies := c.Cookies(u)
+	ut.AssertEquals(1, len(cookies))
+	ut.AssertEquals("secret token", cookies[0].Value)
+	stored := inner.HostCookies("example.com")
+	ut.AssertEquals(1, len(stored))
+	ut.AssertFalse(strings.Contains(stored[0].Value, "secret"))
+	ut.AssertEquals("secret token", c.HostCookies("example.com")[0].Value)
+
+	// Values sealed with another key are dropped.
+	other, err := Encrypt(inner, []byte("fedcba9876543210"))
+	ut.AssertNil(err)
+	ut.AssertEquals(0, len(other.Cookies(u)))
+
+	ut.AssertTrue(c.Remove("example.com", "session"))
+	ut.AssertEquals(0, len(c.Cookies(u)))
+
+	_, err = Encrypt(inner, []byte("short"))
+	ut.AssertNotNil(err)
+}
+
+func TestEncryptStorage(t *testing.T) {
+	ut.Run(t)
+
+	inner := NewMemoryStorage()
+	s, err := EncryptStorage(inner, []byte("0123456789abcdef"))
+	ut.AssertNil(err)
+	assertStorage(s)
+
+	ut.AssertNil(s.SetItem("https://example.com", "token", "abc"))
+	sealed, ok := inner.GetItem("https://example.com", "token")
+	ut.AssertTrue(ok)
+	ut.AssertNotEquals("abc", sealed)
+
+	// Values are bound to their origin and key.
+	ut.AssertNil(inner.SetItem("https://other.com", "token", sealed))
+	_, ok = s.GetItem("https://other.com", "token")
+	ut.AssertFalse(ok)
+}
@@ -0,0 +1,7 @@
+package jar
+
+// Flusher is implemented by the jars saved to disk. Flush writes the jar to
+// its file, eg before the program exits.
+type Flusher interface {
+	Flush() error
+}
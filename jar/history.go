@@ -2,9 +2,15 @@ package jar
 
 import (
 	"container/list"
+	"encoding/json"
+	"io"
 	"net/http"
+	"net/url"
+	"regexp"
+	"time"
 
 	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
 )
 
 // State represents a point in time.
@@ -12,15 +18,104 @@ type State struct {
 	Request  *http.Request
 	Response *http.Response
 	Dom      *goquery.Document
+
+	// Body is the response body. Browsers may leave Dom nil until the
+	// document is needed, and parse it from Body then.
+	Body []byte
+
+	// Stripped is true when the body and DOM of the state were dropped to
+	// save memory, see MemoryHistory.SetMaxFull.
+	Stripped bool
+
+	// Base is the URL the relative URLs of the page are resolved against,
+	// from its <base> element, or nil when it's the URL of the page.
+	Base *url.URL
+
+	// ContentEncoding is the encoding the body was received with, eg
+	// "gzip", or empty when it was not compressed.
+	ContentEncoding string
+
+	// EncodedSize is the size of the body as received, before it was
+	// decoded, or -1 when it's unknown.
+	EncodedSize int64
+
+	// Sent is the time the navigation to the page started. TimeToHeaders
+	// and LoadTime are the times taken from then to receive the response
+	// headers, and the whole body.
+	Sent          time.Time
+	TimeToHeaders time.Duration
+	LoadTime      time.Duration
 }
 
-// NewHistoryState creates and returns a new *State type.
+// NewHistoryState creates and returns a new *State type. Its EncodedSize is
+// unknown until it's set.
 func NewHistoryState(req *http.Request, resp *http.Response, dom *goquery.Document) *State {
 	return &State{
-		Request:  req,
-		Response: resp,
-		Dom:      dom,
+		Request:     req,
+		Response:    resp,
+		Dom:         dom,
+		EncodedSize: -1,
+	}
+}
+
+// Strip drops the body and DOM of the state, keeping its request, status
+// and headers, eg the URL and status of the pages visited by a crawler.
+func (s *State) Strip() {
+	if s.Stripped {
+		return
+	}
+	s.Dom = nil
+	s.Body = nil
+	if s.Response != nil {
+		resp := *s.Response
+		resp.Body = http.NoBody
+		s.Response = &resp
+	}
+	s.Stripped = true
+}
+
+// Size returns an estimate of the memory used by the state, in bytes,
+// counting its body, headers and parsed DOM.
+func (s *State) Size() int64 {
+	size := int64(len(s.Body))
+	if s.Request != nil {
+		size += headerSize(s.Request.Header)
+	}
+	if s.Response != nil {
+		size += headerSize(s.Response.Header)
+	}
+	if s.Dom != nil {
+		for _, n := range s.Dom.Nodes {
+			size += nodeSize(n)
+		}
+	}
+	return size
+}
+
+// htmlNodeSize is the estimated size of an html.Node without its data and
+// attributes.
+const htmlNodeSize = 120
+
+func nodeSize(n *html.Node) int64 {
+	size := int64(htmlNodeSize + len(n.Data))
+	for _, a := range n.Attr {
+		size += int64(len(a.Namespace) + len(a.Key) + len(a.Val) + 48)
 	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		size += nodeSize(c)
+	}
+	return size
+}
+
+func headerSize(h http.Header) int64 {
+	var size int64
+	for k, vs := range h {
+		size += int64(len(k))
+		for _, v := range vs {
+			size += int64(len(v))
+		}
+	}
+	return size
 }
 
 // History is a type that records browser state.
@@ -31,6 +126,14 @@ type History interface {
 	Push(p *State) int
 	Pop() *State
 	Top() *State
+
+	// At returns the State at index i, 0 being the front of the history,
+	// or nil when i is out of range.
+	At(i int) *State
+
+	// Find returns the indexes of the states whose request URL matches the
+	// regular expression urlPattern, front first.
+	Find(urlPattern string) ([]int, error)
 }
 
 // Node holds stack values and points to the next element.
@@ -43,6 +146,8 @@ type Node struct {
 type MemoryHistory struct {
 	list    *list.List
 	maxHist int
+	maxFull int
+	onEvict func(p *State)
 }
 
 // NewMemoryHistory creates and returns a new *StateHistory type.
@@ -61,6 +166,57 @@ func (his *MemoryHistory) SetMax(max int) {
 	his.maxHist = max
 }
 
+// SetMaxFull sets the number of most recent states which keep their body
+// and DOM. Older states are stripped, keeping only their request, status
+// and headers, to bound the memory used by long crawls. Setting values to 0
+// keeps every state whole.
+func (his *MemoryHistory) SetMaxFull(max int) {
+	his.maxFull = max
+	his.strip()
+}
+
+// OnEvict sets a function called with the states removed from the history
+// because of its max length, or by Truncate.
+func (his *MemoryHistory) OnEvict(fn func(p *State)) {
+	his.onEvict = fn
+}
+
+// Truncate removes the oldest states, keeping the n most recent ones.
+func (his *MemoryHistory) Truncate(n int) {
+	if n < 0 {
+		n = 0
+	}
+	for his.list.Len() > n {
+		p := his.list.Remove(his.list.Back()).(*State)
+		if his.onEvict != nil {
+			his.onEvict(p)
+		}
+	}
+}
+
+// Size returns an estimate of the memory used by the states, in bytes.
+func (his *MemoryHistory) Size() int64 {
+	var size int64
+	for e := his.list.Front(); e != nil; e = e.Next() {
+		size += e.Value.(*State).Size()
+	}
+	return size
+}
+
+// strip strips the states older than the max number of full states.
+func (his *MemoryHistory) strip() {
+	if his.maxFull <= 0 {
+		return
+	}
+	i := 0
+	for e := his.list.Front(); e != nil; e = e.Next() {
+		if i >= his.maxFull {
+			e.Value.(*State).Strip()
+		}
+		i++
+	}
+}
+
 // Clear removes all history.
 func (his *MemoryHistory) Clear() {
 	his.list.Init()
@@ -72,12 +228,9 @@ func (his *MemoryHistory) Push(p *State) int {
 
 	// Trim history if maxHist is set
 	if his.maxHist > 0 {
-		if l := his.list.Len(); l > his.maxHist {
-			for i := 0; i < l-his.maxHist; i++ {
-				his.list.Remove(his.list.Back())
-			}
-		}
+		his.Truncate(his.maxHist)
 	}
+	his.strip()
 	return his.list.Len()
 }
 
@@ -96,3 +249,77 @@ func (his *MemoryHistory) Top() *State {
 	}
 	return his.list.Front().Value.(*State)
 }
+
+// At returns the State at index i, 0 being the front of the history, or nil
+// when i is out of range.
+func (his *MemoryHistory) At(i int) *State {
+	if i < 0 || i >= his.list.Len() {
+		return nil
+	}
+	e := his.list.Front()
+	for ; i > 0; i-- {
+		e = e.Next()
+	}
+	return e.Value.(*State)
+}
+
+// Find returns the indexes of the states whose request URL matches the
+// regular expression urlPattern, front first.
+func (his *MemoryHistory) Find(urlPattern string) ([]int, error) {
+	re, err := regexp.Compile(urlPattern)
+	if err != nil {
+		return nil, err
+	}
+	var found []int
+	i := 0
+	for e := his.list.Front(); e != nil; e = e.Next() {
+		p := e.Value.(*State)
+		if p.Request != nil && p.Request.URL != nil && re.MatchString(p.Request.URL.String()) {
+			found = append(found, i)
+		}
+		i++
+	}
+	return found, nil
+}
+
+// HistoryEntry is the exported form of a State, used for audit trails.
+type HistoryEntry struct {
+	Index       int    `json:"index"`
+	Method      string `json:"method,omitempty"`
+	URL         string `json:"url,omitempty"`
+	Status      int    `json:"status,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Date        string `json:"date,omitempty"`
+	Stripped    bool   `json:"stripped,omitempty"`
+}
+
+// Entries returns the exported form of the states in the history, front
+// first.
+func Entries(his History) []HistoryEntry {
+	entries := make([]HistoryEntry, 0, his.Len())
+	for i := 0; i < his.Len(); i++ {
+		p := his.At(i)
+		if p == nil {
+			break
+		}
+		entry := HistoryEntry{Index: i, Stripped: p.Stripped}
+		if p.Request != nil {
+			entry.Method = p.Request.Method
+			if p.Request.URL != nil {
+				entry.URL = p.Request.URL.String()
+			}
+		}
+		if p.Response != nil {
+			entry.Status = p.Response.StatusCode
+			entry.ContentType = p.Response.Header.Get("Content-Type")
+			entry.Date = p.Response.Header.Get("Date")
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// ExportHistory writes the history to w as a JSON array of HistoryEntry.
+func ExportHistory(his History, w io.Writer) error {
+	return json.NewEncoder(w).Encode(Entries(his))
+}
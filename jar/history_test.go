@@ -1,8 +1,13 @@
 package jar
 
 import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
 	"testing"
 
+	"github.com/PuerkitoBio/goquery"
 	"github.com/lostinblue/ut"
 )
 
@@ -30,6 +35,14 @@ func TestMemoryHistory(t *testing.T) {
 	ut.AssertEquals(0, stack.Len())
 }
 
+func TestNewHistoryState(t *testing.T) {
+	ut.Run(t)
+	req, _ := http.NewRequest("GET", "http://example.com", nil)
+	s := NewHistoryState(req, &http.Response{StatusCode: 200}, nil)
+	ut.AssertEquals(req, s.Request)
+	ut.AssertEquals(int64(-1), s.EncodedSize)
+}
+
 func TestMemoryHistoryWithMax(t *testing.T) {
 	ut.Run(t)
 	stack := NewMemoryHistory()
@@ -77,3 +90,90 @@ func TestMemoryHistoryClear(t *testing.T) {
 	stack.Clear()
 	ut.AssertEquals(0, stack.Len())
 }
+
+func TestMemoryHistoryEvictAndTruncate(t *testing.T) {
+	ut.Run(t)
+	stack := NewMemoryHistory()
+	stack.SetMax(2)
+
+	var evicted []*State
+	stack.OnEvict(func(p *State) {
+		evicted = append(evicted, p)
+	})
+
+	page1, page2, page3 := &State{}, &State{}, &State{}
+	stack.Push(page1)
+	stack.Push(page2)
+	stack.Push(page3)
+	ut.AssertEquals(1, len(evicted))
+	ut.AssertEquals(page1, evicted[0])
+
+	stack.Truncate(1)
+	ut.AssertEquals(1, stack.Len())
+	ut.AssertEquals(page3, stack.Top())
+	ut.AssertEquals(2, len(evicted))
+	ut.AssertEquals(page2, evicted[1])
+}
+
+func TestMemoryHistoryMaxFull(t *testing.T) {
+	ut.Run(t)
+	stack := NewMemoryHistory()
+	stack.SetMaxFull(1)
+
+	newState := func(body string) *State {
+		dom, _ := goquery.NewDocumentFromReader(strings.NewReader(body))
+		return &State{
+			Request:  &http.Request{Header: http.Header{}},
+			Response: &http.Response{StatusCode: 200, Header: http.Header{}},
+			Dom:      dom,
+			Body:     []byte(body),
+		}
+	}
+	page1 := newState("<html><body><p>one</p></body></html>")
+	page2 := newState("<html><body><p>two</p></body></html>")
+
+	stack.Push(page1)
+	full := stack.Size()
+	ut.AssertTrue(full > int64(len(page1.Body)))
+
+	stack.Push(page2)
+	ut.AssertTrue(page1.Stripped)
+	ut.AssertFalse(page2.Stripped)
+	ut.AssertEquals(0, len(page1.Body))
+	ut.AssertEquals(200, page1.Response.StatusCode)
+	ut.AssertTrue(page1.Dom == nil)
+	ut.AssertEquals(page2.Size(), stack.Size())
+}
+
+func TestMemoryHistoryFindAndExport(t *testing.T) {
+	ut.Run(t)
+	stack := NewMemoryHistory()
+
+	for _, u := range []string{"http://example.com/a", "http://example.com/b", "http://example.com/a?p=2"} {
+		req, _ := http.NewRequest("GET", u, nil)
+		resp := &http.Response{StatusCode: 200, Header: http.Header{"Content-Type": {"text/html"}}}
+		stack.Push(NewHistoryState(req, resp, nil))
+	}
+
+	found, err := stack.Find(`/a`)
+	ut.AssertNil(err)
+	ut.AssertEquals([]int{0, 2}, found)
+	ut.AssertEquals("http://example.com/b", stack.At(1).Request.URL.String())
+	ut.AssertNil(stack.At(3))
+
+	_, err = stack.Find(`(`)
+	ut.AssertNotNil(err)
+
+	var buf bytes.Buffer
+	ut.AssertNil(ExportHistory(stack, &buf))
+	var entries []HistoryEntry
+	ut.AssertNil(json.Unmarshal(buf.Bytes(), &entries))
+	ut.AssertEquals(3, len(entries))
+	ut.AssertEquals(HistoryEntry{
+		Index:       1,
+		Method:      "GET",
+		URL:         "http://example.com/b",
+		Status:      200,
+		ContentType: "text/html",
+	}, entries[1])
+}
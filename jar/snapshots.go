@@ -0,0 +1,193 @@
+package jar
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/lostinblue/surf/errors"
+	"github.com/lostinblue/surf/util"
+)
+
+// Snapshot is the normalized content of a page saved at a point in time.
+type Snapshot struct {
+	// URL is the address of the page.
+	URL string
+
+	// Taken is the time the snapshot was saved.
+	Taken time.Time
+
+	// Text contains the visible text of the page, one block per line.
+	Text []string
+
+	// DOM contains the normalized markup of the page, one element per line.
+	DOM []string
+}
+
+// SnapshotsMap stores snapshots by name.
+type SnapshotsMap map[string]*Snapshot
+
+// SnapshotsJar is a container for storage and retrieval of page snapshots.
+type SnapshotsJar interface {
+	// Save saves the snapshot with the given name, replacing any existing
+	// snapshot with the same name.
+	Save(name string, s *Snapshot) error
+
+	// Read returns the snapshot with the given name.
+	Read(name string) (*Snapshot, error)
+
+	// Remove deletes the snapshot with the given name.
+	Remove(name string) bool
+
+	// Has returns a boolean value indicating whether a snapshot exists with the given name.
+	Has(name string) bool
+}
+
+// MemorySnapshots is an in-memory implementation of SnapshotsJar.
+type MemorySnapshots struct {
+	mu        sync.RWMutex
+	snapshots SnapshotsMap
+}
+
+// NewMemorySnapshots creates and returns a new *MemorySnapshots type.
+func NewMemorySnapshots() *MemorySnapshots {
+	return &MemorySnapshots{
+		snapshots: make(SnapshotsMap),
+	}
+}
+
+// Save saves the snapshot with the given name, replacing any existing
+// snapshot with the same name.
+func (j *MemorySnapshots) Save(name string, s *Snapshot) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.snapshots[name] = s
+	return nil
+}
+
+// Read returns the snapshot with the given name.
+//
+// Returns an error when a snapshot does not exist with the given name.
+func (j *MemorySnapshots) Read(name string) (*Snapshot, error) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return readSnapshot(j.snapshots, name)
+}
+
+// Remove deletes the snapshot with the given name.
+//
+// Returns a boolean value indicating whether a snapshot existed with the
+// given name and was removed.
+func (j *MemorySnapshots) Remove(name string) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	_, ok := j.snapshots[name]
+	delete(j.snapshots, name)
+	return ok
+}
+
+// Has returns a boolean value indicating whether a snapshot exists with the given name.
+func (j *MemorySnapshots) Has(name string) bool {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	_, ok := j.snapshots[name]
+	return ok
+}
+
+// FileSnapshots is an implementation of SnapshotsJar that saves to a file,
+// so pages may be compared between runs of a program.
+//
+// The snapshots are saved as a JSON string.
+type FileSnapshots struct {
+	mu        sync.RWMutex
+	snapshots SnapshotsMap
+	file      string
+}
+
+// NewFileSnapshots creates and returns a new *FileSnapshots type.
+func NewFileSnapshots(file string) (*FileSnapshots, error) {
+	snapshots := make(SnapshotsMap)
+	if util.FileExists(file) {
+		fin, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal(fin, &snapshots)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &FileSnapshots{
+		snapshots: snapshots,
+		file:      file,
+	}, nil
+}
+
+// Save saves the snapshot with the given name, replacing any existing
+// snapshot with the same name.
+func (j *FileSnapshots) Save(name string, s *Snapshot) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.snapshots[name] = s
+	return j.writeToFile()
+}
+
+// Read returns the snapshot with the given name.
+//
+// Returns an error when a snapshot does not exist with the given name.
+func (j *FileSnapshots) Read(name string) (*Snapshot, error) {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return readSnapshot(j.snapshots, name)
+}
+
+// Remove deletes the snapshot with the given name.
+//
+// Returns a boolean value indicating whether a snapshot existed with the
+// given name and was removed.
+func (j *FileSnapshots) Remove(name string) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if _, ok := j.snapshots[name]; !ok {
+		return false
+	}
+	delete(j.snapshots, name)
+	return j.writeToFile() == nil
+}
+
+// Has returns a boolean value indicating whether a snapshot exists with the given name.
+func (j *FileSnapshots) Has(name string) bool {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	_, ok := j.snapshots[name]
+	return ok
+}
+
+// Flush writes the snapshots to the file.
+func (j *FileSnapshots) Flush() error {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.writeToFile()
+}
+
+// writeToFile writes the snapshots to the file.
+func (j *FileSnapshots) writeToFile() error {
+	b, err := json.Marshal(j.snapshots)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(j.file, b, os.FileMode(0600))
+}
+
+// readSnapshot returns the snapshot with the given name from the map.
+func readSnapshot(snapshots SnapshotsMap, name string) (*Snapshot, error) {
+	s, ok := snapshots[name]
+	if !ok {
+		return nil, errors.New(
+			"A snapshot does not exist with the name '%s'.", name)
+	}
+	return s, nil
+}
@@ -0,0 +1,50 @@
+package jar
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/lostinblue/ut"
+)
+
+func TestMemorySnapshots(t *testing.T) {
+	ut.Run(t)
+
+	assertSnapshots(NewMemorySnapshots())
+}
+
+func TestFileSnapshots(t *testing.T) {
+	ut.Run(t)
+
+	j, err := NewFileSnapshots("./snapshots.json")
+	ut.AssertNil(err)
+	defer os.Remove("./snapshots.json")
+	assertSnapshots(j)
+
+	ut.AssertNil(j.Save("home", &Snapshot{URL: "https://example.com", Text: []string{"Hello"}}))
+	j, err = NewFileSnapshots("./snapshots.json")
+	ut.AssertNil(err)
+	s, err := j.Read("home")
+	ut.AssertNil(err)
+	ut.AssertEquals("https://example.com", s.URL)
+	ut.AssertEquals([]string{"Hello"}, s.Text)
+}
+
+// assertSnapshots tests the given snapshots jar.
+func assertSnapshots(j SnapshotsJar) {
+	_, err := j.Read("home")
+	ut.AssertNotNil(err)
+	ut.AssertFalse(j.Has("home"))
+
+	ut.AssertNil(j.Save("home", &Snapshot{URL: "https://example.com", Taken: time.Now()}))
+	ut.AssertNil(j.Save("home", &Snapshot{URL: "https://example.com/new"}))
+	ut.AssertTrue(j.Has("home"))
+	s, err := j.Read("home")
+	ut.AssertNil(err)
+	ut.AssertEquals("https://example.com/new", s.URL)
+
+	ut.AssertTrue(j.Remove("home"))
+	ut.AssertFalse(j.Remove("home"))
+	ut.AssertFalse(j.Has("home"))
+}
@@ -0,0 +1,118 @@
+package sqljar
+
+import (
+	"database/sql"
+
+	"github.com/lostinblue/surf/errors"
+	"github.com/lostinblue/surf/jar"
+)
+
+// Bookmarks is an implementation of jar.BookmarksJar which stores the
+// bookmarks in a database.
+type Bookmarks struct {
+	*store
+}
+
+var _ jar.BookmarksJar = (*Bookmarks)(nil)
+
+// NewBookmarks creates and returns a new *Bookmarks type storing the
+// bookmarks of the session in the surf_bookmarks table.
+func NewBookmarks(db *sql.DB, d Dialect, session string) (*Bookmarks, error) {
+	b := &Bookmarks{&store{db: db, dialect: d, session: session}}
+	_, err := b.exec(`CREATE TABLE IF NOT EXISTS surf_bookmarks (
+		session TEXT NOT NULL,
+		name TEXT NOT NULL,
+		url TEXT NOT NULL,
+		PRIMARY KEY (session, name)
+	)`)
+	if err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Save saves a bookmark with the given name.
+//
+// Returns an error when a bookmark with the given name already exists. Use the
+// Has() or Remove() methods first to avoid errors.
+func (b *Bookmarks) Save(name, url string) error {
+	res, err := b.exec(`INSERT INTO surf_bookmarks (session, name, url) VALUES (?, ?, ?)
+		ON CONFLICT (session, name) DO NOTHING`, b.session, name, url)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return errors.New(
+			"Bookmark with the name '%s' already exists.", name)
+	}
+	return nil
+}
+
+// Read returns the URL for the bookmark with the given name.
+//
+// Returns an error when a bookmark does not exist with the given name. Use the
+// Has() method first to avoid errors.
+func (b *Bookmarks) Read(name string) (string, error) {
+	var url string
+	err := b.queryRow(`SELECT url FROM surf_bookmarks WHERE session = ? AND name = ?`,
+		b.session, name).Scan(&url)
+	if err == sql.ErrNoRows {
+		return "", errors.New(
+			"A bookmark does not exist with the name '%s'.", name)
+	}
+	return url, err
+}
+
+// Remove deletes the bookmark with the given name.
+//
+// Returns a boolean value indicating whether a bookmark existed with the given
+// name and was removed. Database errors are returned by Err().
+func (b *Bookmarks) Remove(name string) bool {
+	res, err := b.exec(`DELETE FROM surf_bookmarks WHERE session = ? AND name = ?`,
+		b.session, name)
+	if err != nil {
+		b.setErr(err)
+		return false
+	}
+	n, err := res.RowsAffected()
+	b.setErr(err)
+	return n > 0
+}
+
+// Has returns a boolean value indicating whether a bookmark exists with the given name.
+//
+// Database errors are returned by Err().
+func (b *Bookmarks) Has(name string) bool {
+	var one int
+	err := b.queryRow(`SELECT 1 FROM surf_bookmarks WHERE session = ? AND name = ?`,
+		b.session, name).Scan(&one)
+	if err != sql.ErrNoRows {
+		b.setErr(err)
+	}
+	return err == nil
+}
+
+// All returns all of the bookmarks as a BookmarksMap.
+//
+// Database errors are returned by Err().
+func (b *Bookmarks) All() jar.BookmarksMap {
+	bookmarks := make(jar.BookmarksMap)
+	rows, err := b.query(`SELECT name, url FROM surf_bookmarks WHERE session = ?`, b.session)
+	if err != nil {
+		b.setErr(err)
+		return bookmarks
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var name, url string
+		if err := rows.Scan(&name, &url); err != nil {
+			b.setErr(err)
+			return bookmarks
+		}
+		bookmarks[name] = url
+	}
+	b.setErr(rows.Err())
+	return bookmarks
+}
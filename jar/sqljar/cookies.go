@@ -0,0 +1,343 @@
+package sqljar
+
+import (
+	"database/sql"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lostinblue/surf/jar"
+	"golang.org/x/net/publicsuffix"
+)
+
+// Cookies is an implementation of jar.CookiesJar which stores the cookies in
+// a database.
+//
+// Cookies are scoped with the rules of RFC 6265, and the public suffix list
+// is used as with jar.NewCookiesJar().
+type Cookies struct {
+	*store
+}
+
+var _ jar.CookiesJar = (*Cookies)(nil)
+
+// NewCookies creates and returns a new *Cookies type storing the cookies of
+// the session in the surf_cookies table.
+func NewCookies(db *sql.DB, d Dialect, session string) (*Cookies, error) {
+	c := &Cookies{&store{db: db, dialect: d, session: session}}
+	_, err := c.exec(`CREATE TABLE IF NOT EXISTS surf_cookies (
+		session TEXT NOT NULL,
+		domain TEXT NOT NULL,
+		path TEXT NOT NULL,
+		name TEXT NOT NULL,
+		value TEXT NOT NULL,
+		host_only INTEGER NOT NULL,
+		secure INTEGER NOT NULL,
+		http_only INTEGER NOT NULL,
+		same_site INTEGER NOT NULL,
+		expires BIGINT NOT NULL,
+		PRIMARY KEY (session, domain, path, name)
+	)`)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// cookieRow is a cookie stored in the surf_cookies table along with its scope.
+type cookieRow struct {
+	domain   string
+	path     string
+	name     string
+	value    string
+	hostOnly bool
+	secure   bool
+	httpOnly bool
+	sameSite http.SameSite
+	expires  int64
+}
+
+// SetCookies handles the receipt of the cookies in a reply for the given URL.
+//
+// Database errors are returned by Err().
+func (c *Cookies) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	host := canonicalHost(u.Host)
+	now := time.Now()
+	for _, cookie := range cookies {
+		row, ok := scopeCookie(host, u.Path, cookie)
+		if !ok {
+			continue
+		}
+		switch {
+		case cookie.MaxAge < 0:
+			row.expires = -1
+		case cookie.MaxAge > 0:
+			row.expires = now.Add(time.Duration(cookie.MaxAge) * time.Second).Unix()
+		case !cookie.Expires.IsZero():
+			row.expires = cookie.Expires.Unix()
+			if !cookie.Expires.After(now) {
+				row.expires = -1
+			}
+		}
+
+		var err error
+		if row.expires < 0 {
+			_, err = c.exec(`DELETE FROM surf_cookies
+				WHERE session = ? AND domain = ? AND path = ? AND name = ?`,
+				c.session, row.domain, row.path, row.name)
+		} else {
+			_, err = c.exec(`INSERT INTO surf_cookies
+				(session, domain, path, name, value, host_only, secure, http_only, same_site, expires)
+				VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+				ON CONFLICT (session, domain, path, name) DO UPDATE SET
+				value = excluded.value, host_only = excluded.host_only,
+				secure = excluded.secure, http_only = excluded.http_only,
+				same_site = excluded.same_site, expires = excluded.expires`,
+				c.session, row.domain, row.path, row.name, row.value, boolInt(row.hostOnly),
+				boolInt(row.secure), boolInt(row.httpOnly), int(row.sameSite), row.expires)
+		}
+		c.setErr(err)
+	}
+}
+
+// Cookies returns the cookies to send in a request for the given URL.
+//
+// Database errors are returned by Err().
+func (c *Cookies) Cookies(u *url.URL) []*http.Cookie {
+	host := canonicalHost(u.Host)
+	rows, err := c.matching(host, "")
+	if err != nil {
+		c.setErr(err)
+		return nil
+	}
+	secure := u.Scheme == "https" || u.Scheme == "wss"
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+
+	var matches []cookieRow
+	for _, row := range rows {
+		if row.secure && !secure || !pathMatch(path, row.path) {
+			continue
+		}
+		matches = append(matches, row)
+	}
+	// Cookies with longer paths are listed first.
+	sort.SliceStable(matches, func(i, j int) bool {
+		return len(matches[i].path) > len(matches[j].path)
+	})
+
+	cookies := make([]*http.Cookie, 0, len(matches))
+	for _, row := range matches {
+		cookies = append(cookies, &http.Cookie{Name: row.name, Value: row.value})
+	}
+	return cookies
+}
+
+// HostCookies returns every cookie which would be sent to the given host,
+// no matter the path.
+//
+// Database errors are returned by Err().
+func (c *Cookies) HostCookies(host string) []*http.Cookie {
+	rows, err := c.matching(canonicalHost(host), "")
+	if err != nil {
+		c.setErr(err)
+		return nil
+	}
+	var cookies []*http.Cookie
+	for _, row := range rows {
+		cookies = append(cookies, row.cookie())
+	}
+	return cookies
+}
+
+// Remove deletes the cookies with the given name which would be sent to
+// the given host.
+//
+// Returns a boolean value indicating whether any cookie was removed.
+// Database errors are returned by Err().
+func (c *Cookies) Remove(host, name string) bool {
+	host = canonicalHost(host)
+	domains := parentDomains(host)
+	args := []interface{}{c.session, name, host}
+	for _, d := range domains {
+		args = append(args, d)
+	}
+	res, err := c.exec(`DELETE FROM surf_cookies
+		WHERE session = ? AND name = ? AND (domain = ? OR (host_only = 0 AND domain IN (`+
+		placeholders(len(domains))+`)))`, args...)
+	if err != nil {
+		c.setErr(err)
+		return false
+	}
+	n, err := res.RowsAffected()
+	c.setErr(err)
+	return n > 0
+}
+
+// Clear deletes every cookie of the session.
+//
+// Database errors are returned by Err().
+func (c *Cookies) Clear() {
+	_, err := c.exec(`DELETE FROM surf_cookies WHERE session = ?`, c.session)
+	c.setErr(err)
+}
+
+// matching returns the unexpired cookies which would be sent to the given
+// host. When name is not empty only cookies with that name are returned.
+func (c *Cookies) matching(host, name string) ([]cookieRow, error) {
+	domains := parentDomains(host)
+	args := []interface{}{c.session}
+	for _, d := range domains {
+		args = append(args, d)
+	}
+	query := `SELECT domain, path, name, value, host_only, secure, http_only, same_site, expires
+		FROM surf_cookies WHERE session = ? AND domain IN (` + placeholders(len(domains)) + `)`
+	if name != "" {
+		query += ` AND name = ?`
+		args = append(args, name)
+	}
+	rows, err := c.query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	now := time.Now().Unix()
+	var matches []cookieRow
+	for rows.Next() {
+		var row cookieRow
+		var hostOnly, secure, httpOnly, sameSite int64
+		err := rows.Scan(&row.domain, &row.path, &row.name, &row.value,
+			&hostOnly, &secure, &httpOnly, &sameSite, &row.expires)
+		if err != nil {
+			return nil, err
+		}
+		row.hostOnly, row.secure, row.httpOnly = hostOnly != 0, secure != 0, httpOnly != 0
+		row.sameSite = http.SameSite(sameSite)
+		if row.expires > 0 && row.expires <= now {
+			continue
+		}
+		if row.hostOnly && row.domain != host {
+			continue
+		}
+		matches = append(matches, row)
+	}
+	return matches, rows.Err()
+}
+
+// cookie returns the row as an *http.Cookie, with its scope.
+func (row cookieRow) cookie() *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     row.name,
+		Value:    row.value,
+		Domain:   row.domain,
+		Path:     row.path,
+		Secure:   row.secure,
+		HttpOnly: row.httpOnly,
+		SameSite: row.sameSite,
+	}
+	if row.expires > 0 {
+		cookie.Expires = time.Unix(row.expires, 0)
+	}
+	return cookie
+}
+
+// scopeCookie returns the row storing a cookie received from the given host
+// and path, or false when the host may not set the cookie.
+func scopeCookie(host, path string, cookie *http.Cookie) (cookieRow, bool) {
+	row := cookieRow{
+		domain:   strings.TrimPrefix(strings.ToLower(cookie.Domain), "."),
+		path:     cookie.Path,
+		name:     cookie.Name,
+		value:    cookie.Value,
+		hostOnly: cookie.Domain == "",
+		secure:   cookie.Secure,
+		httpOnly: cookie.HttpOnly,
+		sameSite: cookie.SameSite,
+	}
+	if row.domain == host && isPublicSuffix(host) {
+		// A site which is itself a public suffix may only set host cookies.
+		row.hostOnly = true
+	}
+	switch {
+	case row.hostOnly:
+		row.domain = host
+	case net.ParseIP(host) != nil && row.domain != host:
+		// IP addresses have no sub-domains.
+		return row, false
+	case !domainMatch(host, row.domain) || isPublicSuffix(row.domain):
+		return row, false
+	}
+	if row.path == "" || row.path[0] != '/' {
+		row.path = defaultPath(path)
+	}
+	return row, true
+}
+
+// isPublicSuffix returns a boolean value indicating whether cookies may not
+// be set for the given domain, because it's a public suffix.
+func isPublicSuffix(domain string) bool {
+	return publicsuffix.List.PublicSuffix(domain) == domain
+}
+
+// parentDomains returns the host and the domains it's a sub-domain of, eg
+// "www.example.com" and "example.com" for "www.example.com".
+func parentDomains(host string) []string {
+	domains := []string{host}
+	if net.ParseIP(host) != nil {
+		return domains
+	}
+	for i := strings.Index(host, "."); i >= 0; i = strings.Index(host, ".") {
+		host = host[i+1:]
+		if host == "" {
+			break
+		}
+		domains = append(domains, host)
+	}
+	return domains
+}
+
+// canonicalHost strips the port from the host and lowercases it.
+func canonicalHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(host)
+}
+
+// domainMatch returns a boolean value indicating whether the host is the
+// domain or one of its sub-domains.
+func domainMatch(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// pathMatch returns a boolean value indicating whether a cookie with the
+// given path is sent to the request path, as defined by RFC 6265 section
+// 5.1.4.
+func pathMatch(reqPath, path string) bool {
+	if reqPath == path {
+		return true
+	}
+	if strings.HasPrefix(reqPath, path) {
+		return path[len(path)-1] == '/' || reqPath[len(path)] == '/'
+	}
+	return false
+}
+
+// defaultPath returns the cookie path used when a cookie does not have one,
+// as defined by RFC 6265 section 5.1.4.
+func defaultPath(path string) string {
+	if path == "" || path[0] != '/' {
+		return "/"
+	}
+	i := strings.LastIndex(path, "/")
+	if i == 0 {
+		return "/"
+	}
+	return path[:i]
+}
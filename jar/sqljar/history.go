@@ -0,0 +1,288 @@
+package sqljar
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+
+	"github.com/lostinblue/surf/jar"
+)
+
+// History is an implementation of jar.History which stores the states in a
+// database.
+//
+// States are stored with their request, status, headers and body. The DOM of
+// the states read back is nil, and is parsed from the body when needed.
+type History struct {
+	*store
+	maxHist int
+}
+
+var _ jar.History = (*History)(nil)
+
+// NewHistory creates and returns a new *History type storing the states of
+// the session in the surf_history table.
+func NewHistory(db *sql.DB, d Dialect, session string) (*History, error) {
+	h := &History{store: &store{db: db, dialect: d, session: session}}
+	_, err := h.exec(`CREATE TABLE IF NOT EXISTS surf_history (
+		seq ` + d.Serial + `,
+		session TEXT NOT NULL,
+		method TEXT NOT NULL,
+		url TEXT NOT NULL,
+		status INTEGER NOT NULL,
+		request_header TEXT NOT NULL,
+		response_header TEXT NOT NULL,
+		body ` + d.Blob + `,
+		stripped INTEGER NOT NULL
+	)`)
+	if err != nil {
+		return nil, err
+	}
+	_, err = h.exec(`CREATE INDEX IF NOT EXISTS surf_history_session ON surf_history (session, seq)`)
+	if err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+// stateColumns are the columns of surf_history read into a stateRow.
+const stateColumns = `method, url, status, request_header, response_header, body, stripped`
+
+// stateRow is a state stored in the surf_history table.
+type stateRow struct {
+	method         string
+	url            string
+	status         int
+	requestHeader  string
+	responseHeader string
+	body           []byte
+	stripped       bool
+}
+
+// SetMax sets the max history length. Setting values to 0 will disable
+// history trimming, keeping a infinite list.
+func (h *History) SetMax(max int) {
+	h.maxHist = max
+}
+
+// Len returns the number of states in the history.
+//
+// Database errors are returned by Err().
+func (h *History) Len() int {
+	var n int
+	err := h.queryRow(`SELECT COUNT(*) FROM surf_history WHERE session = ?`, h.session).Scan(&n)
+	h.setErr(err)
+	return n
+}
+
+// Clear removes all history.
+//
+// Database errors are returned by Err().
+func (h *History) Clear() {
+	_, err := h.exec(`DELETE FROM surf_history WHERE session = ?`, h.session)
+	h.setErr(err)
+}
+
+// Push adds a new State at the front of the history.
+//
+// Database errors are returned by Err().
+func (h *History) Push(p *jar.State) int {
+	h.setErr(h.push(p))
+	return h.Len()
+}
+
+func (h *History) push(p *jar.State) error {
+	row, err := encodeState(p)
+	if err != nil {
+		return err
+	}
+	// The sequence is assigned by the database, so browsers pushing to the
+	// same session concurrently don't compete for the next one.
+	tx, err := h.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(h.dialect.rebind(`INSERT INTO surf_history
+		(session, `+stateColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`),
+		h.session, row.method, row.url, row.status, row.requestHeader,
+		row.responseHeader, row.body, boolInt(row.stripped))
+	if err != nil {
+		return err
+	}
+	if h.maxHist > 0 {
+		_, err = tx.Exec(h.dialect.rebind(`DELETE FROM surf_history WHERE session = ? AND seq NOT IN
+			(SELECT seq FROM surf_history WHERE session = ? ORDER BY seq DESC LIMIT ?)`),
+			h.session, h.session, h.maxHist)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Pop removes and returns the State at the front of the history.
+//
+// Database errors are returned by Err().
+func (h *History) Pop() *jar.State {
+	p, err := h.pop()
+	h.setErr(err)
+	return p
+}
+
+func (h *History) pop() (*jar.State, error) {
+	// The front state is deleted only when it is still there, and the next
+	// one is tried otherwise, so concurrent pops don't return the same one.
+	for {
+		var seq int64
+		var row stateRow
+		var stripped int64
+		err := h.queryRow(`SELECT seq, `+stateColumns+` FROM surf_history
+			WHERE session = ? ORDER BY seq DESC LIMIT 1`, h.session).Scan(
+			&seq, &row.method, &row.url, &row.status, &row.requestHeader,
+			&row.responseHeader, &row.body, &stripped)
+		if err == sql.ErrNoRows {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+		row.stripped = stripped != 0
+		res, err := h.exec(`DELETE FROM surf_history WHERE session = ? AND seq = ?`, h.session, seq)
+		if err != nil {
+			return nil, err
+		}
+		if n, err := res.RowsAffected(); err != nil {
+			return nil, err
+		} else if n == 0 {
+			continue
+		}
+		return decodeState(row)
+	}
+}
+
+// Top returns the State at the front of the history without removing it.
+//
+// Database errors are returned by Err().
+func (h *History) Top() *jar.State {
+	return h.At(0)
+}
+
+// At returns the State at index i, 0 being the front of the history, or nil
+// when i is out of range.
+//
+// Database errors are returned by Err().
+func (h *History) At(i int) *jar.State {
+	if i < 0 {
+		return nil
+	}
+	var row stateRow
+	var stripped int64
+	err := h.queryRow(`SELECT `+stateColumns+` FROM surf_history
+		WHERE session = ? ORDER BY seq DESC LIMIT 1 OFFSET ?`, h.session, i).Scan(
+		&row.method, &row.url, &row.status, &row.requestHeader,
+		&row.responseHeader, &row.body, &stripped)
+	if err == sql.ErrNoRows {
+		return nil
+	} else if err != nil {
+		h.setErr(err)
+		return nil
+	}
+	row.stripped = stripped != 0
+	p, err := decodeState(row)
+	h.setErr(err)
+	return p
+}
+
+// Find returns the indexes of the states whose request URL matches the
+// regular expression urlPattern, front first.
+func (h *History) Find(urlPattern string) ([]int, error) {
+	re, err := regexp.Compile(urlPattern)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := h.query(`SELECT url FROM surf_history WHERE session = ? ORDER BY seq DESC`,
+		h.session)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var found []int
+	for i := 0; rows.Next(); i++ {
+		var u string
+		if err := rows.Scan(&u); err != nil {
+			return nil, err
+		}
+		if re.MatchString(u) {
+			found = append(found, i)
+		}
+	}
+	return found, rows.Err()
+}
+
+// encodeState returns the row storing the state.
+func encodeState(p *jar.State) (stateRow, error) {
+	row := stateRow{body: p.Body, stripped: p.Stripped}
+	reqHeader, respHeader := http.Header{}, http.Header{}
+	if p.Request != nil {
+		row.method = p.Request.Method
+		if p.Request.URL != nil {
+			row.url = p.Request.URL.String()
+		}
+		reqHeader = p.Request.Header
+	}
+	if p.Response != nil {
+		row.status = p.Response.StatusCode
+		respHeader = p.Response.Header
+	}
+
+	b, err := json.Marshal(reqHeader)
+	if err != nil {
+		return row, err
+	}
+	row.requestHeader = string(b)
+	b, err = json.Marshal(respHeader)
+	if err != nil {
+		return row, err
+	}
+	row.responseHeader = string(b)
+	return row, nil
+}
+
+// decodeState returns the state stored in the row. States without a URL,
+// such as the blank state of new browsers, have no request nor response.
+// The encoded size of the body is not stored, so it's unknown.
+func decodeState(row stateRow) (*jar.State, error) {
+	p := &jar.State{Body: row.body, Stripped: row.stripped, EncodedSize: -1}
+	if row.url == "" {
+		return p, nil
+	}
+
+	req, err := http.NewRequest(row.method, row.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(row.requestHeader), &req.Header); err != nil {
+		return nil, err
+	}
+	resp := &http.Response{
+		Status:     fmt.Sprintf("%d %s", row.status, http.StatusText(row.status)),
+		StatusCode: row.status,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Request:    req,
+		Body:       ioutil.NopCloser(bytes.NewReader(row.body)),
+	}
+	if err := json.Unmarshal([]byte(row.responseHeader), &resp.Header); err != nil {
+		return nil, err
+	}
+	p.Request = req
+	p.Response = resp
+	return p, nil
+}
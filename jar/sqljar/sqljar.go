@@ -0,0 +1,131 @@
+// Package sqljar has implementations of the jar containers which store their
+// data with database/sql, so many browsers, eg the workers of a crawler, may
+// share one session.
+//
+// The package does not import a driver. Open the database with a SQLite or
+// Postgres driver, and pass the matching Dialect:
+//
+//	db, err := sql.Open("sqlite3", "session.db")
+//	cookies, err := sqljar.NewCookies(db, sqljar.SQLite, "crawler")
+//	bow.SetCookieJar(cookies)
+//
+// Every container is scoped to a session name, so one database may hold the
+// data of several sessions. The tables are created when they do not exist.
+package sqljar
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Dialect describes the SQL differences between databases.
+type Dialect struct {
+	// Name is the name of the dialect.
+	Name string
+
+	// Placeholder returns the placeholder of the nth argument of a query,
+	// starting at 1.
+	Placeholder func(n int) string
+
+	// Blob is the column type of binary data.
+	Blob string
+
+	// Serial is the column type of auto-incremented primary keys.
+	Serial string
+}
+
+// SQLite is the dialect of SQLite databases.
+var SQLite = Dialect{
+	Name:        "sqlite",
+	Placeholder: func(int) string { return "?" },
+	Blob:        "BLOB",
+	Serial:      "INTEGER PRIMARY KEY AUTOINCREMENT",
+}
+
+// Postgres is the dialect of PostgreSQL databases.
+var Postgres = Dialect{
+	Name:        "postgres",
+	Placeholder: func(n int) string { return "$" + strconv.Itoa(n) },
+	Blob:        "BYTEA",
+	Serial:      "BIGSERIAL PRIMARY KEY",
+}
+
+// rebind replaces the "?" placeholders of the query with the placeholders of
+// the dialect.
+func (d Dialect) rebind(query string) string {
+	if d.Placeholder == nil {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString(d.Placeholder(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// placeholders returns n comma separated "?" placeholders.
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+// store holds the database of a container along with the last error of the
+// methods which cannot return one.
+type store struct {
+	db      *sql.DB
+	dialect Dialect
+	session string
+
+	mu  sync.Mutex
+	err error
+}
+
+// exec runs a statement written with "?" placeholders.
+func (s *store) exec(query string, args ...interface{}) (sql.Result, error) {
+	return s.db.Exec(s.dialect.rebind(query), args...)
+}
+
+// query runs a query written with "?" placeholders.
+func (s *store) query(query string, args ...interface{}) (*sql.Rows, error) {
+	return s.db.Query(s.dialect.rebind(query), args...)
+}
+
+// queryRow runs a query written with "?" placeholders, returning one row.
+func (s *store) queryRow(query string, args ...interface{}) *sql.Row {
+	return s.db.QueryRow(s.dialect.rebind(query), args...)
+}
+
+// setErr records the error, when not nil, for Err().
+func (s *store) setErr(err error) {
+	if err == nil {
+		return
+	}
+	s.mu.Lock()
+	s.err = err
+	s.mu.Unlock()
+}
+
+// Err returns the last error of the methods which cannot return one, such as
+// SetCookies() or Push(), and resets it.
+func (s *store) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	err := s.err
+	s.err = nil
+	return err
+}
+
+// boolInt converts b to the integer stored in boolean columns.
+func boolInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,140 @@
+package sqljar
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/lostinblue/surf/jar"
+	"github.com/lostinblue/ut"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestRebind(t *testing.T) {
+	ut.Run(t)
+
+	q := `SELECT url FROM surf_bookmarks WHERE session = ? AND name IN (` + placeholders(2) + `)`
+	ut.AssertEquals(`SELECT url FROM surf_bookmarks WHERE session = ? AND name IN (?, ?)`, SQLite.rebind(q))
+	ut.AssertEquals(`SELECT url FROM surf_bookmarks WHERE session = $1 AND name IN ($2, $3)`, Postgres.rebind(q))
+}
+
+func TestScopeCookie(t *testing.T) {
+	ut.Run(t)
+
+	row, ok := scopeCookie("www.example.com", "/a/b", &http.Cookie{Name: "id", Value: "1"})
+	ut.AssertTrue(ok)
+	ut.AssertTrue(row.hostOnly)
+	ut.AssertEquals("www.example.com", row.domain)
+	ut.AssertEquals("/a", row.path)
+
+	row, ok = scopeCookie("www.example.com", "/", &http.Cookie{Name: "id", Domain: ".Example.com", Path: "/"})
+	ut.AssertTrue(ok)
+	ut.AssertFalse(row.hostOnly)
+	ut.AssertEquals("example.com", row.domain)
+
+	_, ok = scopeCookie("www.example.com", "/", &http.Cookie{Name: "id", Domain: "other.com"})
+	ut.AssertFalse(ok)
+	_, ok = scopeCookie("a.github.io", "/", &http.Cookie{Name: "id", Domain: "github.io"})
+	ut.AssertFalse(ok)
+	_, ok = scopeCookie("10.0.0.1", "/", &http.Cookie{Name: "id", Domain: "0.0.1"})
+	ut.AssertFalse(ok)
+
+	ut.AssertEquals([]string{"www.example.com", "example.com", "com"}, parentDomains("www.example.com"))
+	ut.AssertEquals([]string{"10.0.0.1"}, parentDomains("10.0.0.1"))
+
+	ut.AssertTrue(pathMatch("/a/b", "/a"))
+	ut.AssertTrue(pathMatch("/a/b", "/a/"))
+	ut.AssertFalse(pathMatch("/ab", "/a"))
+}
+
+func TestEncodeState(t *testing.T) {
+	ut.Run(t)
+
+	req, _ := http.NewRequest("POST", "http://example.com/search?q=surf", nil)
+	req.Header.Set("Accept", "text/html")
+	resp := &http.Response{StatusCode: 404, Header: http.Header{"Content-Type": {"text/html"}}}
+	state := jar.NewHistoryState(req, resp, nil)
+	state.Body = []byte("<html></html>")
+
+	row, err := encodeState(state)
+	ut.AssertNil(err)
+	p, err := decodeState(row)
+	ut.AssertNil(err)
+	ut.AssertEquals("POST", p.Request.Method)
+	ut.AssertEquals("http://example.com/search?q=surf", p.Request.URL.String())
+	ut.AssertEquals("text/html", p.Request.Header.Get("Accept"))
+	ut.AssertEquals(404, p.Response.StatusCode)
+	ut.AssertEquals("404 Not Found", p.Response.Status)
+	ut.AssertEquals("text/html", p.Response.Header.Get("Content-Type"))
+	ut.AssertEquals("<html></html>", string(p.Body))
+	ut.AssertEquals(int64(-1), p.EncodedSize)
+
+	row, err = encodeState(&jar.State{})
+	ut.AssertNil(err)
+	p, err = decodeState(row)
+	ut.AssertNil(err)
+	ut.AssertNil(p.Request)
+}
+
+func TestHistory(t *testing.T) {
+	ut.Run(t)
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "history.db")+"?_busy_timeout=5000")
+	ut.AssertNil(err)
+	defer db.Close()
+
+	h, err := NewHistory(db, SQLite, "crawler")
+	ut.AssertNil(err)
+	other, err := NewHistory(db, SQLite, "other")
+	ut.AssertNil(err)
+	other.Push(&jar.State{})
+
+	// Concurrent workers push to the same session.
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < 10; i++ {
+				req, _ := http.NewRequest("GET", fmt.Sprintf("http://localhost/%d/%d", w, i), nil)
+				h.Push(jar.NewHistoryState(req, &http.Response{StatusCode: 200}, nil))
+			}
+		}(w)
+	}
+	wg.Wait()
+	ut.AssertNil(h.Err())
+	ut.AssertEquals(40, h.Len())
+	ut.AssertEquals(1, other.Len())
+
+	// Concurrent pops return each state once.
+	seen := make(map[string]bool)
+	var mu sync.Mutex
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for p := h.Pop(); p != nil; p = h.Pop() {
+				mu.Lock()
+				seen[p.Request.URL.String()] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	ut.AssertNil(h.Err())
+	ut.AssertEquals(40, len(seen))
+	ut.AssertEquals(0, h.Len())
+
+	h.SetMax(2)
+	for _, u := range []string{"http://localhost/a", "http://localhost/b", "http://localhost/c"} {
+		req, _ := http.NewRequest("GET", u, nil)
+		h.Push(jar.NewHistoryState(req, &http.Response{StatusCode: 200}, nil))
+	}
+	ut.AssertEquals(2, h.Len())
+	ut.AssertEquals("http://localhost/c", h.Top().Request.URL.String())
+	ut.AssertEquals("http://localhost/b", h.At(1).Request.URL.String())
+	ut.AssertEquals(1, other.Len())
+}
@@ -0,0 +1,188 @@
+package jar
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+
+	"github.com/lostinblue/surf/util"
+)
+
+// StorageMap stores the Web Storage items of each origin.
+type StorageMap map[string]map[string]string
+
+// Storage is a container for the items pages save with the Web Storage API,
+// eg localStorage.setItem(). Items are scoped to an origin, such as
+// "https://example.com".
+type Storage interface {
+	// GetItem returns the value of the item with the given key.
+	GetItem(origin, key string) (string, bool)
+
+	// SetItem saves an item with the given key and value.
+	SetItem(origin, key, value string) error
+
+	// RemoveItem deletes the item with the given key.
+	RemoveItem(origin, key string) error
+
+	// Clear deletes every item of the origin.
+	Clear(origin string) error
+
+	// Keys returns the sorted keys of every item of the origin.
+	Keys(origin string) []string
+}
+
+// MemoryStorage is an in-memory implementation of Storage.
+type MemoryStorage struct {
+	mu    sync.RWMutex
+	items StorageMap
+}
+
+// NewMemoryStorage creates and returns a new *MemoryStorage type.
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		items: make(StorageMap),
+	}
+}
+
+// GetItem returns the value of the item with the given key.
+func (s *MemoryStorage) GetItem(origin, key string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.items[origin][key]
+	return v, ok
+}
+
+// SetItem saves an item with the given key and value.
+func (s *MemoryStorage) SetItem(origin, key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	setStorageItem(s.items, origin, key, value)
+	return nil
+}
+
+// RemoveItem deletes the item with the given key.
+func (s *MemoryStorage) RemoveItem(origin, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.items[origin], key)
+	return nil
+}
+
+// Clear deletes every item of the origin.
+func (s *MemoryStorage) Clear(origin string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.items, origin)
+	return nil
+}
+
+// Keys returns the sorted keys of every item of the origin.
+func (s *MemoryStorage) Keys(origin string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return storageKeys(s.items, origin)
+}
+
+// FileStorage is an implementation of Storage that saves to a file.
+//
+// The items are saved as a JSON string.
+type FileStorage struct {
+	mu    sync.RWMutex
+	items StorageMap
+	file  string
+}
+
+// NewFileStorage creates and returns a new *FileStorage type.
+func NewFileStorage(file string) (*FileStorage, error) {
+	items := make(StorageMap)
+	if util.FileExists(file) {
+		fin, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		err = json.Unmarshal(fin, &items)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &FileStorage{
+		items: items,
+		file:  file,
+	}, nil
+}
+
+// GetItem returns the value of the item with the given key.
+func (s *FileStorage) GetItem(origin, key string) (string, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.items[origin][key]
+	return v, ok
+}
+
+// SetItem saves an item with the given key and value.
+func (s *FileStorage) SetItem(origin, key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	setStorageItem(s.items, origin, key, value)
+	return s.writeToFile()
+}
+
+// RemoveItem deletes the item with the given key.
+func (s *FileStorage) RemoveItem(origin, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.items[origin], key)
+	return s.writeToFile()
+}
+
+// Clear deletes every item of the origin.
+func (s *FileStorage) Clear(origin string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.items, origin)
+	return s.writeToFile()
+}
+
+// Keys returns the sorted keys of every item of the origin.
+func (s *FileStorage) Keys(origin string) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return storageKeys(s.items, origin)
+}
+
+// Flush writes the items to the file.
+func (s *FileStorage) Flush() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.writeToFile()
+}
+
+// writeToFile writes the items to the file.
+func (s *FileStorage) writeToFile() error {
+	j, err := json.Marshal(s.items)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(s.file, j, os.FileMode(0600))
+}
+
+// setStorageItem sets an item in the given map, creating the origin map as needed.
+func setStorageItem(items StorageMap, origin, key, value string) {
+	if items[origin] == nil {
+		items[origin] = make(map[string]string)
+	}
+	items[origin][key] = value
+}
+
+// storageKeys returns the sorted keys of the items of the origin.
+func storageKeys(items StorageMap, origin string) []string {
+	keys := make([]string, 0, len(items[origin]))
+	for k := range items[origin] {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,59 @@
+package jar
+
+import (
+	"os"
+	"testing"
+
+	"github.com/lostinblue/ut"
+)
+
+func TestMemoryStorage(t *testing.T) {
+	ut.Run(t)
+
+	assertStorage(NewMemoryStorage())
+}
+
+func TestFileStorage(t *testing.T) {
+	ut.Run(t)
+
+	s, err := NewFileStorage("./storage.json")
+	ut.AssertNil(err)
+	defer os.Remove("./storage.json")
+	assertStorage(s)
+
+	s.SetItem("https://example.com", "token", "abc")
+	s, err = NewFileStorage("./storage.json")
+	ut.AssertNil(err)
+	v, ok := s.GetItem("https://example.com", "token")
+	ut.AssertTrue(ok)
+	ut.AssertEquals("abc", v)
+
+	os.Remove("./storage.json")
+	ut.AssertNil(s.Flush())
+	_, err = os.Stat("./storage.json")
+	ut.AssertNil(err)
+}
+
+// assertStorage tests the given storage jar.
+func assertStorage(s Storage) {
+	origin := "https://example.com"
+	_, ok := s.GetItem(origin, "token")
+	ut.AssertFalse(ok)
+
+	ut.AssertNil(s.SetItem(origin, "token", "abc"))
+	ut.AssertNil(s.SetItem(origin, "user", "joe"))
+	ut.AssertNil(s.SetItem("https://other.com", "token", "xyz"))
+
+	v, ok := s.GetItem(origin, "token")
+	ut.AssertTrue(ok)
+	ut.AssertEquals("abc", v)
+	ut.AssertEquals([]string{"token", "user"}, s.Keys(origin))
+
+	ut.AssertNil(s.RemoveItem(origin, "user"))
+	ut.AssertEquals([]string{"token"}, s.Keys(origin))
+
+	ut.AssertNil(s.Clear(origin))
+	ut.AssertEquals([]string{}, s.Keys(origin))
+	v, _ = s.GetItem("https://other.com", "token")
+	ut.AssertEquals("xyz", v)
+}
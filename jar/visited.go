@@ -0,0 +1,201 @@
+package jar
+
+import (
+	"database/sql"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"regexp"
+	"sync"
+)
+
+// Visited is a container which records the URLs visited by a browser.
+//
+// Implementations must be safe for concurrent use, because browser tabs share
+// the same Visited jar.
+type Visited interface {
+	// Add records the given URL as visited.
+	Add(u string) error
+
+	// Has returns a boolean value indicating whether the given URL was visited.
+	Has(u string) bool
+}
+
+// MemoryVisited is an in-memory implementation of Visited which stores every URL.
+type MemoryVisited struct {
+	mu   sync.RWMutex
+	urls map[string]struct{}
+}
+
+// NewMemoryVisited creates and returns a new *MemoryVisited type.
+func NewMemoryVisited() *MemoryVisited {
+	return &MemoryVisited{
+		urls: make(map[string]struct{}),
+	}
+}
+
+// Add records the given URL as visited.
+func (v *MemoryVisited) Add(u string) error {
+	v.mu.Lock()
+	v.urls[u] = struct{}{}
+	v.mu.Unlock()
+	return nil
+}
+
+// Has returns a boolean value indicating whether the given URL was visited.
+func (v *MemoryVisited) Has(u string) bool {
+	v.mu.RLock()
+	_, ok := v.urls[u]
+	v.mu.RUnlock()
+	return ok
+}
+
+// Len returns the number of visited URLs.
+func (v *MemoryVisited) Len() int {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return len(v.urls)
+}
+
+// BloomVisited is an implementation of Visited backed by a bloom filter.
+//
+// The memory used is fixed no matter how many URLs are added, at the cost of
+// Has() occasionally reporting a URL as visited when it was not.
+type BloomVisited struct {
+	mu     sync.RWMutex
+	bits   []uint64
+	m      uint64
+	hashes uint64
+}
+
+// NewBloomVisited creates and returns a new *BloomVisited type sized to hold
+// n URLs with the given false positive rate, eg 0.001.
+func NewBloomVisited(n uint, rate float64) *BloomVisited {
+	if n == 0 {
+		n = 1
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(rate) / (math.Ln2 * math.Ln2)))
+	if m < 64 {
+		m = 64
+	}
+	k := uint64(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return &BloomVisited{
+		bits:   make([]uint64, (m+63)/64),
+		m:      m,
+		hashes: k,
+	}
+}
+
+// Add records the given URL as visited.
+func (v *BloomVisited) Add(u string) error {
+	h1, h2 := bloomHashes(u)
+	v.mu.Lock()
+	for i := uint64(0); i < v.hashes; i++ {
+		b := (h1 + i*h2) % v.m
+		v.bits[b/64] |= 1 << (b % 64)
+	}
+	v.mu.Unlock()
+	return nil
+}
+
+// Has returns a boolean value indicating whether the given URL was visited.
+func (v *BloomVisited) Has(u string) bool {
+	h1, h2 := bloomHashes(u)
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	for i := uint64(0); i < v.hashes; i++ {
+		b := (h1 + i*h2) % v.m
+		if v.bits[b/64]&(1<<(b%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// bloomHashes returns the two hashes used to derive the bloom filter bit positions.
+func bloomHashes(u string) (uint64, uint64) {
+	h := fnv.New64a()
+	h.Write([]byte(u))
+	h1 := h.Sum64()
+	h.Write([]byte{0})
+	h2 := h.Sum64() | 1
+	return h1, h2
+}
+
+// sqlTableName matches the table names accepted by NewSQLVisited, which are
+// written into the queries.
+var sqlTableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// SQLVisited is an implementation of Visited which stores the URLs in a
+// database table, eg an SQLite file shared between crawler runs.
+//
+// The queries use "?" placeholders, which are understood by SQLite and MySQL drivers.
+type SQLVisited struct {
+	db    *sql.DB
+	table string
+
+	mu  sync.Mutex
+	err error
+}
+
+// NewSQLVisited creates the table used to store visited URLs when it does
+// not exist, and returns a new *SQLVisited type. The table name may only
+// contain letters, digits and underscores.
+func NewSQLVisited(db *sql.DB, table string) (*SQLVisited, error) {
+	if !sqlTableName.MatchString(table) {
+		return nil, fmt.Errorf("invalid table name %q", table)
+	}
+	_, err := db.Exec("CREATE TABLE IF NOT EXISTS " + table + " (url VARCHAR(2048) PRIMARY KEY)")
+	if err != nil {
+		return nil, err
+	}
+	return &SQLVisited{
+		db:    db,
+		table: table,
+	}, nil
+}
+
+// Add records the given URL as visited. The primary key of the table keeps
+// the URLs unique, so concurrent browsers may add the same URL.
+func (v *SQLVisited) Add(u string) error {
+	_, err := v.db.Exec("INSERT INTO "+v.table+" (url) VALUES (?)", u)
+	if err == nil {
+		return nil
+	}
+	// The insert fails when the URL was already added.
+	if ok, herr := v.has(u); herr == nil && ok {
+		return nil
+	}
+	return err
+}
+
+// Has returns a boolean value indicating whether the given URL was visited.
+//
+// Database errors are returned by Err().
+func (v *SQLVisited) Has(u string) bool {
+	ok, err := v.has(u)
+	if err != nil {
+		v.mu.Lock()
+		v.err = err
+		v.mu.Unlock()
+	}
+	return ok
+}
+
+func (v *SQLVisited) has(u string) (bool, error) {
+	var n int
+	err := v.db.QueryRow("SELECT COUNT(*) FROM "+v.table+" WHERE url = ?", u).Scan(&n)
+	return n > 0, err
+}
+
+// Err returns the last error of Has(), which cannot return one, and resets it.
+func (v *SQLVisited) Err() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	err := v.err
+	v.err = nil
+	return err
+}
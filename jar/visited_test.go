@@ -0,0 +1,96 @@
+package jar
+
+import (
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/lostinblue/ut"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestMemoryVisited(t *testing.T) {
+	ut.Run(t)
+
+	v := NewMemoryVisited()
+	assertVisited(v)
+	ut.AssertEquals(2, v.Len())
+}
+
+func TestBloomVisited(t *testing.T) {
+	ut.Run(t)
+
+	v := NewBloomVisited(1000, 0.001)
+	assertVisited(v)
+
+	for i := 0; i < 1000; i++ {
+		v.Add(fmt.Sprintf("http://localhost/page/%d", i))
+	}
+	for i := 0; i < 1000; i++ {
+		ut.AssertTrue(v.Has(fmt.Sprintf("http://localhost/page/%d", i)))
+	}
+	misses := 0
+	for i := 1000; i < 2000; i++ {
+		if v.Has(fmt.Sprintf("http://localhost/page/%d", i)) {
+			misses++
+		}
+	}
+	ut.AssertLessThan(10, misses)
+}
+
+func TestSQLVisited(t *testing.T) {
+	ut.Run(t)
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "visited.db")+"?_busy_timeout=5000")
+	ut.AssertNil(err)
+	defer db.Close()
+
+	_, err = NewSQLVisited(db, "visited; DROP TABLE x")
+	ut.AssertNotNil(err)
+
+	v, err := NewSQLVisited(db, "surf_visited")
+	ut.AssertNil(err)
+	assertVisited(v)
+	ut.AssertNil(v.Err())
+
+	// Concurrent workers add the same URLs.
+	var wg sync.WaitGroup
+	errs := make(chan error, 100)
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 25; i++ {
+				if err := v.Add(fmt.Sprintf("http://localhost/page/%d", i)); err != nil {
+					errs <- err
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	var n int
+	ut.AssertNil(db.QueryRow("SELECT COUNT(*) FROM surf_visited").Scan(&n))
+	ut.AssertEquals(27, n)
+
+	_, err = db.Exec("DROP TABLE surf_visited")
+	ut.AssertNil(err)
+	ut.AssertFalse(v.Has("http://localhost/"))
+	ut.AssertNotNil(v.Err())
+}
+
+// assertVisited tests the given visited jar.
+func assertVisited(v Visited) {
+	ut.AssertFalse(v.Has("http://localhost/"))
+	ut.AssertNil(v.Add("http://localhost/"))
+	ut.AssertNil(v.Add("http://127.0.0.1/"))
+	ut.AssertNil(v.Add("http://localhost/"))
+	ut.AssertTrue(v.Has("http://localhost/"))
+	ut.AssertTrue(v.Has("http://127.0.0.1/"))
+	ut.AssertFalse(v.Has("http://localhost/other"))
+}
@@ -0,0 +1,736 @@
+// Package mirror saves a copy of a web site to a directory, with the links
+// of its pages rewritten to the saved files so it can be browsed offline:
+//
+//	m := mirror.New(surf.NewBrowser(), "site")
+//	m.Assets = true
+//	stats, err := m.Run(context.Background(), "https://example.com/")
+//
+// The pages are found in the sitemap of the site, or by following the links
+// of the pages when it has none. Running a mirror again in the same directory
+// sends conditional requests for the saved URLs, so only the changed ones are
+// downloaded again.
+package mirror
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/lostinblue/surf/browser"
+	"github.com/lostinblue/surf/errors"
+	"github.com/lostinblue/surf/scheduler"
+	"github.com/lostinblue/surf/urlnorm"
+	"golang.org/x/net/html"
+)
+
+// ManifestFile is the name of the file, in the mirror directory, recording
+// the mirrored URLs and their validators.
+const ManifestFile = ".surf-mirror.json"
+
+// linkAttrs are the attributes rewritten to the saved files, and whether
+// they link to pages or assets.
+var linkAttrs = []struct {
+	selector string
+	attr     string
+	page     bool
+}{
+	{"a[href]", "href", true},
+	{"area[href]", "href", true},
+	{"frame[src]", "src", true},
+	{"iframe[src]", "src", true},
+	{"img[src]", "src", false},
+	{"script[src]", "src", false},
+	{"link[rel~=stylesheet][href]", "href", false},
+	{"link[rel~=icon][href]", "href", false},
+	{"source[src]", "src", false},
+	{"audio[src]", "src", false},
+	{"video[src]", "src", false},
+}
+
+// serverExts are the extensions of the pages generated by servers, which
+// are saved with a .html extension to be opened by browsers.
+var serverExts = map[string]bool{
+	".php": true, ".asp": true, ".aspx": true, ".jsp": true, ".cgi": true,
+}
+
+// Entry is a mirrored URL, recorded in the manifest.
+type Entry struct {
+	// File is the path of the saved file, relative to the mirror directory.
+	File string `json:"file"`
+
+	// SHA256 is the hex SHA-256 checksum of a saved asset.
+	SHA256 string `json:"sha256,omitempty"`
+
+	// ETag and LastModified are the validators sent with the conditional
+	// requests of the next runs.
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+
+	// Links and Assets are the mirrored URLs linked from a page, which are
+	// queued again when the page is not modified.
+	Links  []string `json:"links,omitempty"`
+	Assets []string `json:"assets,omitempty"`
+}
+
+// Stats counts the URLs fetched by a run.
+type Stats struct {
+	// Pages and Assets are the numbers of pages and assets saved.
+	Pages  int `json:"pages"`
+	Assets int `json:"assets"`
+
+	// NotModified is the number of URLs which did not change since the
+	// previous run.
+	NotModified int `json:"not_modified"`
+
+	// Errors is the number of URLs which could not be fetched or saved.
+	Errors int `json:"errors"`
+}
+
+// Mirror saves the pages of a site, and their assets, to a directory.
+type Mirror struct {
+	// Dir is the directory the site is saved to. Its files are named after
+	// the URL paths, eg "docs/index.html" for "/docs/".
+	Dir string
+
+	// Workers is the number of URLs fetched concurrently.
+	Workers int
+
+	// Delay is the minimum time between two requests.
+	Delay time.Duration
+
+	// MaxPages is the maximum number of pages saved, or 0 for no limit.
+	MaxPages int
+
+	// Assets enables saving the images, stylesheets and scripts of the
+	// pages, and the assets referenced by the stylesheets and styles, with
+	// url() and @import. Only the assets of the site host are saved.
+	Assets bool
+
+	// Sitemap is the URL of the sitemap listing the pages. When empty, the
+	// /sitemap.xml of the site is used when there is one.
+	Sitemap string
+
+	// FollowLinks enables following the links of the pages even when the
+	// pages are listed in a sitemap.
+	FollowLinks bool
+
+	// Store saves the assets instead of the mirror directory when it's set,
+	// eg a ContentStore saving identical assets once. The links of the pages
+	// saved by a run are rewritten to the stored files when it stops. The
+	// stylesheets linking to mirrored assets are saved to the mirror
+	// directory, since their links depend on its layout.
+	Store AssetStore
+
+	bow     *browser.Browser
+	mu      sync.Mutex
+	host    string
+	follow  bool
+	entries map[string]*Entry
+	files   map[string]string
+	queued  map[string]bool
+	saved   []string
+	pages   int
+	stats   Stats
+}
+
+// New creates and returns a *Mirror saving the pages fetched with tabs of
+// the given browser to dir.
+func New(bow *browser.Browser, dir string) *Mirror {
+	return &Mirror{
+		Dir:     dir,
+		Workers: scheduler.DefaultWorkers,
+		bow:     bow,
+	}
+}
+
+// Run mirrors the site of the start URL, and returns the counts of the
+// fetched URLs. Only the pages of the start URL host are saved, and links
+// to other pages are made absolute.
+//
+// The manifest is saved when the run stops, including when the context is
+// done, so an interrupted run is completed by the next one.
+func (m *Mirror) Run(ctx context.Context, start string) (*Stats, error) {
+	u, err := url.Parse(start)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, errors.New("Cannot mirror '%s', expected an http or https URL.", start)
+	}
+	u = urlnorm.Normalize(u)
+	if err := os.MkdirAll(m.Dir, 0755); err != nil {
+		return nil, err
+	}
+	if err := m.loadManifest(); err != nil {
+		return nil, err
+	}
+	pages, err := m.sitemap(u)
+	if err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	m.host = u.Host
+	m.follow = m.FollowLinks || len(pages) == 0
+	m.queued = make(map[string]bool)
+	m.saved = nil
+	m.pages = 0
+	m.stats = Stats{}
+	s := scheduler.New(m.bow)
+	s.Workers = m.Workers
+	s.Delay = m.Delay
+	s.Options = m.conditional
+	s.Follow = m.save
+	for _, p := range append([]string{u.String()}, pages...) {
+		if m.queue(p, false) {
+			s.Add(p, 0)
+		}
+	}
+	m.mu.Unlock()
+
+	for r := range s.Run(ctx) {
+		if r.Err != nil {
+			m.mu.Lock()
+			m.stats.Errors++
+			m.mu.Unlock()
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := m.stats
+	if m.Store != nil {
+		if err := m.Store.Flush(); err != nil {
+			return &stats, err
+		}
+		if err := m.relink(); err != nil {
+			return &stats, err
+		}
+	}
+	if err := m.saveManifest(); err != nil {
+		return &stats, err
+	}
+	return &stats, ctx.Err()
+}
+
+// sitemap returns the pages of the start URL host listed in the sitemap of
+// the site, following sitemap indexes. A missing /sitemap.xml is not an error, but a missing
+// Sitemap is.
+func (m *Mirror) sitemap(start *url.URL) ([]string, error) {
+	first := m.Sitemap
+	if first == "" {
+		first = start.ResolveReference(&url.URL{Path: "/sitemap.xml"}).String()
+	}
+	var pages []string
+	seen := make(map[string]bool)
+	queue := []string{first}
+	for len(queue) > 0 {
+		su := queue[0]
+		queue = queue[1:]
+		if seen[su] {
+			continue
+		}
+		seen[su] = true
+		urls, indexes, err := fetchSitemap(m.bow.NewTab(), su)
+		if err != nil {
+			if m.Sitemap != "" {
+				return nil, err
+			}
+			continue
+		}
+		for _, p := range urls {
+			pu, err := url.Parse(p)
+			if err != nil {
+				continue
+			}
+			if n := urlnorm.Normalize(pu); n.Host == start.Host {
+				pages = append(pages, n.String())
+			}
+		}
+		queue = append(queue, indexes...)
+	}
+	return pages, nil
+}
+
+// queue marks the URL to be fetched, and returns whether it is, which is
+// false once MaxPages pages are queued, and for assets unless Assets is
+// set. The lock must be held.
+func (m *Mirror) queue(u string, asset bool) bool {
+	if _, ok := m.queued[u]; ok {
+		return true
+	}
+	if asset && !m.Assets {
+		return false
+	}
+	if !asset {
+		if m.MaxPages > 0 && m.pages >= m.MaxPages {
+			return false
+		}
+		m.pages++
+	}
+	m.queued[u] = asset
+	return true
+}
+
+// conditional returns the headers validating the saved copy of the job URL.
+func (m *Mirror) conditional(job *scheduler.Job) []browser.RequestOption {
+	m.mu.Lock()
+	e := m.entries[job.URL]
+	m.mu.Unlock()
+	if e == nil {
+		return nil
+	}
+	if _, err := os.Stat(filepath.Join(m.Dir, e.File)); err != nil {
+		return nil
+	}
+	var opts []browser.RequestOption
+	if e.ETag != "" {
+		opts = append(opts, browser.WithHeader("If-None-Match", e.ETag))
+	}
+	if e.LastModified != "" {
+		opts = append(opts, browser.WithHeader("If-Modified-Since", e.LastModified))
+	}
+	return opts
+}
+
+// save saves the fetched URL, and returns the jobs of the URLs it links to.
+func (m *Mirror) save(r *scheduler.Result) []*scheduler.Job {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	bow := r.Browser
+	asset := m.queued[r.Job.URL]
+	switch bow.StatusCode() {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		if e := m.entries[r.Job.URL]; e != nil {
+			m.stats.NotModified++
+			return m.jobs(e.Links, e.Assets)
+		}
+		fallthrough
+	default:
+		m.stats.Errors++
+		return nil
+	}
+
+	u, err := url.Parse(r.Job.URL)
+	if err != nil {
+		m.stats.Errors++
+		return nil
+	}
+	file := m.localFile(u, !asset)
+	e := &Entry{
+		File:         filepath.ToSlash(file),
+		ETag:         bow.ResponseHeaders().Get("ETag"),
+		LastModified: bow.ResponseHeaders().Get("Last-Modified"),
+	}
+	if asset && isCSS(bow) {
+		err = m.saveStylesheet(bow, r.Job.URL, file, e)
+	} else if asset {
+		err = m.saveAsset(bow, r.Job.URL, e)
+	} else {
+		write := bow.WriteTo
+		if isHTML(bow) {
+			e.Links, e.Assets = m.rewrite(bow, file)
+			write = bow.WriteDOM
+		}
+		err = writeFile(filepath.Join(m.Dir, file), write)
+	}
+	if err != nil {
+		m.stats.Errors++
+		return nil
+	}
+	m.entries[r.Job.URL] = e
+	if !asset || len(e.Assets) > 0 {
+		m.saved = append(m.saved, r.Job.URL)
+	}
+	if asset {
+		m.stats.Assets++
+	} else {
+		m.stats.Pages++
+	}
+	return m.jobs(e.Links, e.Assets)
+}
+
+// saveAsset saves the asset fetched from the URL to its file, or streams it
+// to the Store when it's set, and records its file and checksum in the
+// entry.
+func (m *Mirror) saveAsset(bow *browser.Browser, u string, e *Entry) error {
+	if m.Store == nil {
+		h := sha256.New()
+		err := writeFile(filepath.Join(m.Dir, filepath.FromSlash(e.File)), func(w io.Writer) (int64, error) {
+			return bow.WriteTo(io.MultiWriter(w, h))
+		})
+		e.SHA256 = hex.EncodeToString(h.Sum(nil))
+		return err
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		_, err := bow.WriteTo(pw)
+		pw.CloseWithError(err)
+	}()
+	err := m.storeAsset(u, pr, e)
+	// Unblocks the writer when the store stopped reading early.
+	pr.Close()
+	return err
+}
+
+// storeAsset saves the asset to the Store, and records its file and
+// checksum in the entry.
+func (m *Mirror) storeAsset(u string, r io.Reader, e *Entry) error {
+	a, err := m.Store.Put(u, r)
+	if err != nil {
+		return err
+	}
+	file, err := relativeFile(m.Dir, a.File)
+	if err != nil {
+		return err
+	}
+	e.File = filepath.ToSlash(file)
+	e.SHA256 = a.SHA256
+	return nil
+}
+
+// saveStylesheet saves the stylesheet fetched from the URL to its file,
+// with its references rewritten like the links of the pages, and records
+// its assets and checksum in the entry. It's saved to the Store when it
+// links to no mirrored asset.
+func (m *Mirror) saveStylesheet(bow *browser.Browser, u, file string, e *Entry) error {
+	var buf bytes.Buffer
+	if _, err := bow.WriteTo(&buf); err != nil {
+		return err
+	}
+	var css []byte
+	css, e.Assets = m.rewriteCSS(bow.URL(), file, buf.Bytes())
+	if m.Store != nil && len(e.Assets) == 0 {
+		return m.storeAsset(u, bytes.NewReader(css), e)
+	}
+	sum := sha256.Sum256(css)
+	e.SHA256 = hex.EncodeToString(sum[:])
+	return writeFile(filepath.Join(m.Dir, file), bytes.NewReader(css).WriteTo)
+}
+
+// relink rewrites the links of the pages and stylesheets saved by the run
+// to the assets saved in the Store, which are linked to their path in the
+// mirror directory when they are saved. The pages are parsed, so only their
+// links and styles are rewritten. The lock must be held.
+func (m *Mirror) relink() error {
+	for _, p := range m.saved {
+		e := m.entries[p]
+		moved := make(map[string]string)
+		for _, a := range e.Assets {
+			ae := m.entries[a]
+			au, err := url.Parse(a)
+			if ae == nil || err != nil {
+				continue
+			}
+			if local := filepath.ToSlash(m.localFile(au, false)); ae.File != local {
+				moved[local] = ae.File
+			}
+		}
+		if len(moved) == 0 {
+			continue
+		}
+		file := filepath.Join(m.Dir, filepath.FromSlash(e.File))
+		b, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if m.queued[p] {
+			b = relinkCSS(e.File, b, moved)
+		} else if b, err = relinkHTML(e.File, b, moved); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(file, b, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// relinkHTML rewrites the links of the page saved to file to the moved
+// files, by their path in the mirror directory.
+func relinkHTML(file string, b []byte, moved map[string]string) ([]byte, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+	for _, la := range linkAttrs {
+		if la.page {
+			continue
+		}
+		doc.Find(la.selector).Each(func(_ int, s *goquery.Selection) {
+			if l := relinked(file, s.AttrOr(la.attr, ""), moved); l != "" {
+				s.SetAttr(la.attr, l)
+			}
+		})
+	}
+	doc.Find("style").Each(func(_ int, s *goquery.Selection) {
+		if css := relinkCSS(file, []byte(s.Text()), moved); string(css) != s.Text() {
+			s.SetText(string(css))
+		}
+	})
+	doc.Find("[style]").Each(func(_ int, s *goquery.Selection) {
+		s.SetAttr("style", string(relinkCSS(file, []byte(s.AttrOr("style", "")), moved)))
+	})
+	var buf bytes.Buffer
+	for _, n := range doc.Nodes {
+		if err := html.Render(&buf, n); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// relinkCSS rewrites the references of the stylesheet, or styles, of the
+// file to the moved files.
+func relinkCSS(file string, css []byte, moved map[string]string) []byte {
+	return browser.RewriteCSS(css, func(ref browser.CSSReference) string {
+		return relinked(file, ref.URL, moved)
+	})
+}
+
+// relinked returns the link from the file to the moved file the link v
+// points to, or an empty string when it points to no moved file.
+func relinked(file, v string, moved map[string]string) string {
+	ref, err := url.Parse(strings.TrimSpace(v))
+	if err != nil || ref.Scheme != "" || ref.Host != "" || ref.Path == "" {
+		return ""
+	}
+	to, ok := moved[path.Join(path.Dir(file), ref.Path)]
+	if !ok {
+		return ""
+	}
+	return relativeLink(filepath.FromSlash(file), filepath.FromSlash(to), ref.Fragment)
+}
+
+// relativeFile returns the path of file relative to dir.
+func relativeFile(dir, file string) (string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	absFile, err := filepath.Abs(file)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Rel(absDir, absFile)
+}
+
+// jobs returns the jobs fetching the links and assets of a page.
+func (m *Mirror) jobs(links, assets []string) []*scheduler.Job {
+	var jobs []*scheduler.Job
+	for _, u := range links {
+		if m.queue(u, false) {
+			jobs = append(jobs, &scheduler.Job{URL: u})
+		}
+	}
+	for _, u := range assets {
+		if m.queue(u, true) {
+			// Assets are fetched first, so pages are complete early.
+			jobs = append(jobs, &scheduler.Job{URL: u, Priority: 1})
+		}
+	}
+	return jobs
+}
+
+// rewrite rewrites the links of the page saved to file: the links to the
+// mirrored URLs point to their saved files, and the others are made
+// absolute. Returns the mirrored URLs of the pages and assets.
+func (m *Mirror) rewrite(bow *browser.Browser, file string) (links, assets []string) {
+	doc := bow.DOM()
+	// The base element would apply to the rewritten links, and srcset
+	// candidates would be loaded from the site instead of src.
+	doc.Find("base").Remove()
+	doc.Find("img[srcset], source[srcset]").RemoveAttr("srcset")
+
+	for _, la := range linkAttrs {
+		doc.Find(la.selector).Each(func(_ int, s *goquery.Selection) {
+			v, _ := s.Attr(la.attr)
+			v = strings.TrimSpace(v)
+			if v == "" || strings.HasPrefix(v, "#") {
+				return
+			}
+			ref, err := url.Parse(v)
+			if err != nil {
+				return
+			}
+			abs := bow.URL().ResolveReference(ref)
+			if abs.Scheme != "http" && abs.Scheme != "https" {
+				return
+			}
+			n := urlnorm.Normalize(abs)
+			_, queued := m.queued[n.String()]
+			if n.Host != m.host || (la.page && !m.follow && !queued) || !m.queue(n.String(), !la.page) {
+				s.SetAttr(la.attr, abs.String())
+				return
+			}
+			if la.page {
+				links = append(links, n.String())
+			} else {
+				assets = append(assets, n.String())
+			}
+			s.SetAttr(la.attr, relativeLink(file, m.localFile(n, la.page), abs.Fragment))
+		})
+	}
+
+	doc.Find("style").Each(func(_ int, s *goquery.Selection) {
+		css, found := m.rewriteCSS(bow.URL(), file, []byte(s.Text()))
+		if len(found) > 0 || string(css) != s.Text() {
+			s.SetText(string(css))
+		}
+		assets = append(assets, found...)
+	})
+	doc.Find("[style]").Each(func(_ int, s *goquery.Selection) {
+		css, found := m.rewriteCSS(bow.URL(), file, []byte(s.AttrOr("style", "")))
+		s.SetAttr("style", string(css))
+		assets = append(assets, found...)
+	})
+	return links, assets
+}
+
+// rewriteCSS rewrites the references of the stylesheet, or styles, of the
+// file like the links of the pages, resolved against base. Returns the
+// mirrored URLs of the assets. The lock must be held.
+func (m *Mirror) rewriteCSS(base *url.URL, file string, css []byte) ([]byte, []string) {
+	var assets []string
+	out := browser.RewriteCSS(css, func(ref browser.CSSReference) string {
+		ru, err := url.Parse(strings.TrimSpace(ref.URL))
+		if err != nil {
+			return ""
+		}
+		abs := base.ResolveReference(ru)
+		if abs.Scheme != "http" && abs.Scheme != "https" {
+			return ""
+		}
+		n := urlnorm.Normalize(abs)
+		if n.Host != m.host || !m.queue(n.String(), true) {
+			return abs.String()
+		}
+		assets = append(assets, n.String())
+		return relativeLink(file, m.localFile(n, false), abs.Fragment)
+	})
+	return out, assets
+}
+
+// loadManifest reads the manifest of the previous run, if any.
+func (m *Mirror) loadManifest() error {
+	m.entries = make(map[string]*Entry)
+	m.files = make(map[string]string)
+	b, err := ioutil.ReadFile(filepath.Join(m.Dir, ManifestFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(b, &m.entries); err != nil {
+		return errors.New("Cannot read the mirror manifest: %s", err)
+	}
+	for u, e := range m.entries {
+		m.files[e.File] = u
+	}
+	return nil
+}
+
+// saveManifest writes the manifest. The lock must be held.
+func (m *Mirror) saveManifest() error {
+	b, err := json.MarshalIndent(m.entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(m.Dir, ManifestFile), b, 0644)
+}
+
+// localPath returns the path of the file a URL is saved to, relative to the
+// mirror directory. Directories are saved to index.html, pages get a .html
+// extension unless they have another one, and the query is hashed into the
+// file name.
+func localPath(u *url.URL, page bool) string {
+	p := u.Path
+	if p == "" || strings.HasSuffix(p, "/") {
+		p += "index.html"
+	} else if ext := strings.ToLower(path.Ext(p)); page && (ext == "" || serverExts[ext]) {
+		p += ".html"
+	}
+	p = path.Clean("/" + p)
+	if u.RawQuery != "" {
+		h := fnv.New32a()
+		h.Write([]byte(u.RawQuery))
+		ext := path.Ext(p)
+		p = fmt.Sprintf("%s-%08x%s", strings.TrimSuffix(p, ext), h.Sum32(), ext)
+	}
+	return filepath.FromSlash(p[1:])
+}
+
+// localFile returns the path of the file the URL is saved to, relative to
+// the mirror directory, see localPath(). A path already used by another URL,
+// eg "a.html" for both "/a" and "/a.html", gets the hash of the URL in its
+// name, as queries do. The lock must be held.
+func (m *Mirror) localFile(u *url.URL, page bool) string {
+	key := *u
+	key.Fragment = ""
+	file := filepath.ToSlash(localPath(u, page))
+	if owner, ok := m.files[file]; ok && owner != key.String() {
+		h := fnv.New32a()
+		h.Write([]byte(key.String()))
+		ext := path.Ext(file)
+		file = fmt.Sprintf("%s-%08x%s", strings.TrimSuffix(file, ext), h.Sum32(), ext)
+	}
+	m.files[file] = key.String()
+	return filepath.FromSlash(file)
+}
+
+// relativeLink returns the link from the file to the target file, both
+// relative to the mirror directory.
+func relativeLink(file, target, fragment string) string {
+	rel, err := filepath.Rel(filepath.Dir(file), target)
+	if err != nil {
+		rel = target
+	}
+	l := &url.URL{Path: filepath.ToSlash(rel), Fragment: fragment}
+	return l.String()
+}
+
+// isHTML returns a boolean value indicating whether the page is an HTML document.
+func isHTML(bow *browser.Browser) bool {
+	mt, _, _ := mime.ParseMediaType(bow.ResponseHeaders().Get("Content-Type"))
+	return mt == "text/html" || mt == "application/xhtml+xml" || mt == ""
+}
+
+// isCSS returns a boolean value indicating whether the page is a stylesheet.
+func isCSS(bow *browser.Browser) bool {
+	mt, _, _ := mime.ParseMediaType(bow.ResponseHeaders().Get("Content-Type"))
+	return mt == "text/css"
+}
+
+// writeFile creates the file and its directory, and writes it with write.
+func writeFile(file string, write func(w io.Writer) (int64, error)) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+	fout, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if _, err := write(fout); err != nil {
+		fout.Close()
+		return err
+	}
+	return fout.Close()
+}
@@ -0,0 +1,352 @@
+package mirror
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/lostinblue/surf/browser"
+	"github.com/lostinblue/surf/jar"
+)
+
+func newTestBrowser() *browser.Browser {
+	bow := &browser.Browser{}
+	bow.SetUserAgent("Surf")
+	bow.SetState(&jar.State{})
+	bow.SetCookieJar(jar.NewCookiesJar())
+	bow.SetBookmarksJar(jar.NewMemoryBookmarks())
+	bow.SetHistoryJar(jar.NewMemoryHistory())
+	bow.SetHeadersJar(jar.NewMemoryHeaders())
+	bow.SetAttributes(browser.AttributeMap{browser.FollowRedirects: true})
+	return bow
+}
+
+// site is a test site whose pages are validated with ETags.
+type site struct {
+	mu       sync.Mutex
+	versions map[string]int
+	sitemap  bool
+}
+
+func (s *site) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	etag := fmt.Sprintf(`"v%d"`, s.versions[r.URL.Path])
+	s.mu.Unlock()
+	if strings.HasSuffix(r.URL.Path, ".xml") {
+		if !s.sitemap || (r.URL.Path != "/sitemap.xml" && r.URL.Path != "/pages.xml") {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/xml")
+		if r.URL.Path == "/sitemap.xml" {
+			fmt.Fprintf(w, `<sitemapindex><sitemap><loc>http://%s/pages.xml</loc></sitemap></sitemapindex>`, r.Host)
+		} else {
+			fmt.Fprintf(w, `<urlset><url><loc> http://%[1]s/about </loc></url><url><loc>http://%[1]s/docs/</loc></url>
+				<url><loc>https://example.com/other</loc></url></urlset>`, r.Host)
+		}
+		return
+	}
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("ETag", etag)
+	switch r.URL.Path {
+	case "/":
+		fmt.Fprint(w, `<html><head><base href="/docs/"><link rel="stylesheet" href="/style.css"></head><body>
+			<a href="/docs/">Docs</a> <a href="/about">About</a> <a href="/search?q=go">Search</a>
+			<a href="https://example.com/x">Out</a> <a href="#top">Top</a> <a href="mailto:me@example.com">Mail</a>
+			<img src="/img/logo.png" srcset="/img/logo@2x.png 2x"></body></html>`)
+	case "/docs/":
+		fmt.Fprint(w, `<html><body><a href="../about#team">Team</a> <a href="page.php">Page</a></body></html>`)
+	case "/style.css":
+		w.Header().Set("Content-Type", "text/css")
+		fmt.Fprint(w, "body {}")
+	case "/img/logo.png":
+		w.Header().Set("Content-Type", "image/png")
+		fmt.Fprint(w, "PNG")
+	default:
+		fmt.Fprintf(w, `<html><head><title>%s %s</title></head><body><a href="/">Home</a></body></html>`, r.URL.Path, etag)
+	}
+}
+
+func readFile(t *testing.T, file string) string {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestMirror(t *testing.T) {
+	s := &site{versions: make(map[string]int)}
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+	dir, err := ioutil.TempDir("", "surf-mirror")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	m := New(newTestBrowser(), dir)
+	m.Assets = true
+	stats, err := m.Run(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *stats != (Stats{Pages: 5, Assets: 2}) {
+		t.Errorf("Expected 5 pages and 2 assets to be saved, got %+v.", stats)
+	}
+
+	search := localPath(&url.URL{Path: "/search", RawQuery: "q=go"}, true)
+	index := readFile(t, filepath.Join(dir, "index.html"))
+	for _, expected := range []string{
+		`href="docs/index.html"`, `href="about.html"`, `href="` + search + `"`,
+		`href="https://example.com/x"`, `href="#top"`, `href="mailto:me@example.com"`,
+		`href="style.css"`, `<img src="img/logo.png"/>`,
+	} {
+		if !strings.Contains(index, expected) {
+			t.Errorf("Expected the saved page to contain %s, got %s.", expected, index)
+		}
+	}
+	if strings.Contains(index, "<base") {
+		t.Errorf("Expected the base element to be removed, got %s.", index)
+	}
+	docs := readFile(t, filepath.Join(dir, "docs", "index.html"))
+	if !strings.Contains(docs, `href="../about.html#team"`) || !strings.Contains(docs, `href="page.php.html"`) {
+		t.Errorf("Expected the links relative to the docs page, got %s.", docs)
+	}
+	if css := readFile(t, filepath.Join(dir, "style.css")); css != "body {}" {
+		t.Errorf("Expected the stylesheet to be saved as received, got %q.", css)
+	}
+	readFile(t, filepath.Join(dir, "img", "logo.png"))
+	readFile(t, filepath.Join(dir, "docs", "page.php.html"))
+	readFile(t, filepath.Join(dir, ManifestFile))
+
+	// The next runs only download the changed URLs.
+	stats, err = New(newTestBrowser(), dir).Run(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *stats != (Stats{NotModified: 5}) {
+		t.Errorf("Expected the 5 pages to be unmodified, got %+v.", stats)
+	}
+	s.mu.Lock()
+	s.versions["/about"]++
+	s.mu.Unlock()
+	m = New(newTestBrowser(), dir)
+	m.Assets = true
+	stats, err = m.Run(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *stats != (Stats{Pages: 1, NotModified: 6}) {
+		t.Errorf("Expected the changed page to be saved, got %+v.", stats)
+	}
+	if about := readFile(t, filepath.Join(dir, "about.html")); !strings.Contains(about, "v1") || !strings.Contains(about, `href="index.html"`) {
+		t.Errorf("Expected the new version of the page, got %s.", about)
+	}
+}
+
+func TestMirrorSitemap(t *testing.T) {
+	ts := httptest.NewServer(&site{versions: make(map[string]int), sitemap: true})
+	defer ts.Close()
+	dir, err := ioutil.TempDir("", "surf-mirror")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	stats, err := New(newTestBrowser(), dir).Run(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *stats != (Stats{Pages: 3}) {
+		t.Errorf("Expected the pages of the sitemap to be saved, got %+v.", stats)
+	}
+	docs := readFile(t, filepath.Join(dir, "docs", "index.html"))
+	if !strings.Contains(docs, `href="../about.html#team"`) || !strings.Contains(docs, `href="`+ts.URL+`/docs/page.php"`) {
+		t.Errorf("Expected only the links to the sitemap pages to be rewritten, got %s.", docs)
+	}
+
+	m := New(newTestBrowser(), dir)
+	m.Sitemap = ts.URL + "/missing.xml"
+	if _, err := m.Run(context.Background(), ts.URL); err == nil {
+		t.Error("Expected an error for a missing sitemap.")
+	}
+	if _, err := New(newTestBrowser(), dir).Run(context.Background(), "ftp://example.com/"); err == nil {
+		t.Error("Expected an error for a non HTTP URL.")
+	}
+}
+
+func TestMirrorMaxPages(t *testing.T) {
+	ts := httptest.NewServer(&site{versions: make(map[string]int)})
+	defer ts.Close()
+	dir, err := ioutil.TempDir("", "surf-mirror")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	m := New(newTestBrowser(), dir)
+	m.MaxPages = 2
+	m.Workers = 1
+	stats, err := m.Run(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *stats != (Stats{Pages: 2}) {
+		t.Errorf("Expected 2 pages to be saved, got %+v.", stats)
+	}
+	index := readFile(t, filepath.Join(dir, "index.html"))
+	if !strings.Contains(index, `href="docs/index.html"`) || !strings.Contains(index, `href="`+ts.URL+`/about"`) {
+		t.Errorf("Expected the links past the limit to be absolute, got %s.", index)
+	}
+	if !strings.Contains(index, `src="`+ts.URL+`/img/logo.png"`) {
+		t.Errorf("Expected the assets to be absolute, got %s.", index)
+	}
+}
+
+func TestLocalPath(t *testing.T) {
+	tests := []struct {
+		path  string
+		page  bool
+		query string
+		file  string
+	}{
+		{"", true, "", "index.html"},
+		{"/docs/", true, "", "docs/index.html"},
+		{"/about", true, "", "about.html"},
+		{"/index.php", true, "", "index.php.html"},
+		{"/report.pdf", true, "", "report.pdf"},
+		{"/img/logo", false, "", "img/logo"},
+		{"/../../etc/passwd", false, "", "etc/passwd"},
+		{"/list.html", true, "page=2", "list-"},
+	}
+	for _, tt := range tests {
+		file := filepath.ToSlash(localPath(&url.URL{Path: tt.path, RawQuery: tt.query}, tt.page))
+		if tt.query != "" {
+			if !strings.HasPrefix(file, tt.file) || !strings.HasSuffix(file, ".html") {
+				t.Errorf("Expected the query to be hashed into the name of %s, got %s.", tt.path, file)
+			}
+			continue
+		}
+		if file != tt.file {
+			t.Errorf("Expected %s to be saved to %s, got %s.", tt.path, tt.file, file)
+		}
+	}
+}
+
+func TestMirrorPathCollision(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `<html><body><a href="/a">A</a> <a href="/a.html">A.html</a></body></html>`)
+		default:
+			fmt.Fprintf(w, `<html><head><title>%s</title></head></html>`, r.URL.Path)
+		}
+	}))
+	defer ts.Close()
+	dir := t.TempDir()
+
+	m := New(newTestBrowser(), dir)
+	m.Workers = 1
+	if _, err := m.Run(context.Background(), ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	index := readFile(t, filepath.Join(dir, "index.html"))
+	titles := make(map[string]bool)
+	for _, href := range []string{"/a", "/a.html"} {
+		file := m.entries[ts.URL+href].File
+		if !strings.Contains(index, `href="`+file+`"`) {
+			t.Errorf("Expected the page to link %s to %s, got %s.", href, file, index)
+		}
+		if page := readFile(t, filepath.Join(dir, filepath.FromSlash(file))); strings.Contains(page, "<title>"+href+"</title>") {
+			titles[href] = true
+		}
+	}
+	if !titles["/a"] || !titles["/a.html"] {
+		t.Errorf("Expected /a and /a.html to be saved to their own files, got %v.", titles)
+	}
+}
+
+func TestMirrorStylesheets(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `<html><head><link rel="stylesheet" href="/css/site.css">
+				<style>body { background: url(/img/bg.png) }</style></head>
+				<body><div style="background: url('img/hero.jpg')"></div></body></html>`)
+		case "/css/site.css":
+			w.Header().Set("Content-Type", "text/css")
+			fmt.Fprint(w, `@import "theme.css"; h1 { background: url(../img/bg.png) } h2 { background: url(https://example.com/x.png) }`)
+		case "/css/theme.css":
+			w.Header().Set("Content-Type", "text/css")
+			fmt.Fprint(w, `@font-face { src: url("/fonts/f.woff2") }`)
+		case "/img/bg.png", "/img/hero.jpg", "/fonts/f.woff2":
+			fmt.Fprint(w, "BIN")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+	dir, err := ioutil.TempDir("", "surf-mirror")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	m := New(newTestBrowser(), dir)
+	m.Assets = true
+	stats, err := m.Run(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *stats != (Stats{Pages: 1, Assets: 5}) {
+		t.Errorf("Expected the page and 5 assets to be saved, got %+v.", stats)
+	}
+	index := readFile(t, filepath.Join(dir, "index.html"))
+	for _, expected := range []string{`url(img/bg.png)`, `url(img/hero.jpg)`} {
+		if !strings.Contains(index, expected) {
+			t.Errorf("Expected the saved page to contain %s, got %s.", expected, index)
+		}
+	}
+	expected := `@import url(theme.css); h1 { background: url(../img/bg.png) } h2 { background: url(https://example.com/x.png) }`
+	if css := readFile(t, filepath.Join(dir, "css", "site.css")); css != expected {
+		t.Errorf("Expected the stylesheet %s, got %s.", expected, css)
+	}
+	if css := readFile(t, filepath.Join(dir, "css", "theme.css")); css != `@font-face { src: url(../fonts/f.woff2) }` {
+		t.Errorf("Expected the links of the imported stylesheet to be rewritten, got %s.", css)
+	}
+	readFile(t, filepath.Join(dir, "fonts", "f.woff2"))
+
+	// The assets of the stylesheets are linked to the store.
+	storeDir := filepath.Join(dir, "store")
+	m = New(newTestBrowser(), filepath.Join(dir, "stored"))
+	m.Assets = true
+	if m.Store, err = NewContentStore(storeDir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Run(context.Background(), ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	font := m.Store.(*ContentStore).Lookup(ts.URL + "/fonts/f.woff2")
+	if font == nil {
+		t.Fatal("Expected the font to be stored.")
+	}
+	link, err := filepath.Rel(filepath.Join(dir, "stored", "css"), font.File)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if css := readFile(t, filepath.Join(dir, "stored", "css", "theme.css")); css != `@font-face { src: url(`+filepath.ToSlash(link)+`) }` {
+		t.Errorf("Expected the font to be linked to the store, got %s.", css)
+	}
+}
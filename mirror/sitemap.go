@@ -0,0 +1,55 @@
+package mirror
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/lostinblue/surf/browser"
+	"github.com/lostinblue/surf/errors"
+)
+
+// sitemapDoc is a sitemap, or a sitemap index listing other sitemaps.
+type sitemapDoc struct {
+	URLs     []string `xml:"url>loc"`
+	Sitemaps []string `xml:"sitemap>loc"`
+}
+
+// fetchSitemap fetches the sitemap with the given browser, and returns the
+// URLs of the pages and of the sitemaps it lists. Gzipped sitemaps are
+// decompressed.
+func fetchSitemap(bow *browser.Browser, u string) (urls, sitemaps []string, err error) {
+	if err := bow.GET(u); err != nil {
+		return nil, nil, err
+	}
+	if bow.StatusCode() != http.StatusOK {
+		return nil, nil, errors.NewPageNotFound("Cannot fetch the sitemap '%s', the server returned %d.", u, bow.StatusCode())
+	}
+	var buf bytes.Buffer
+	bow.WriteTo(&buf)
+	body := buf.Bytes()
+	if bytes.HasPrefix(body, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, nil, err
+		}
+		if body, err = ioutil.ReadAll(gz); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	doc := &sitemapDoc{}
+	if err := xml.Unmarshal(body, doc); err != nil {
+		return nil, nil, errors.New("Cannot parse the sitemap '%s': %s", u, err)
+	}
+	for _, l := range doc.URLs {
+		urls = append(urls, strings.TrimSpace(l))
+	}
+	for _, l := range doc.Sitemaps {
+		sitemaps = append(sitemaps, strings.TrimSpace(l))
+	}
+	return urls, sitemaps, nil
+}
@@ -0,0 +1,162 @@
+package mirror
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+// StoreIndexFile is the name of the index of a ContentStore, in its directory.
+const StoreIndexFile = "index.json"
+
+// AssetStore saves the assets of a mirror, see Mirror.Store.
+type AssetStore interface {
+	// Put saves the content of the asset downloaded from the URL, and
+	// returns the stored asset.
+	Put(u string, r io.Reader) (*StoredAsset, error)
+
+	// Flush writes the index of the store.
+	Flush() error
+}
+
+// StoredAsset is an asset saved by an AssetStore.
+type StoredAsset struct {
+	// File is the path of the saved file. It's relative to the directory of
+	// the store in the index, and includes it otherwise.
+	File string `json:"file"`
+
+	// SHA256 is the hex SHA-256 checksum of the content.
+	SHA256 string `json:"sha256"`
+
+	// Size is the length of the content.
+	Size int64 `json:"size"`
+}
+
+// ContentStore is an AssetStore saving the assets to files named after the
+// SHA-256 checksum of their content, eg "3f/3fa9...c2.png", so identical
+// assets downloaded from several URLs are saved once. The index file maps
+// the URLs to the files.
+type ContentStore struct {
+	dir   string
+	mu    sync.Mutex
+	index map[string]*StoredAsset
+	files map[string]string
+}
+
+// NewContentStore creates and returns a *ContentStore saving the assets to
+// dir, with the index of a previous run when there is one.
+func NewContentStore(dir string) (*ContentStore, error) {
+	s := &ContentStore{
+		dir:   dir,
+		index: make(map[string]*StoredAsset),
+		files: make(map[string]string),
+	}
+	b, err := ioutil.ReadFile(filepath.Join(dir, StoreIndexFile))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if err == nil {
+		if err := json.Unmarshal(b, &s.index); err != nil {
+			return nil, errors.New("Cannot read the asset store index: %s", err)
+		}
+	}
+	for _, a := range s.index {
+		s.files[a.SHA256] = a.File
+	}
+	return s, nil
+}
+
+// Put saves the content of the asset downloaded from the URL, unless an
+// asset with the same content is already saved.
+func (s *ContentStore) Put(u string, r io.Reader) (*StoredAsset, error) {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return nil, err
+	}
+	tmp, err := ioutil.TempFile(s.dir, ".put-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, h), r)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, err
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	file, ok := s.files[sum]
+	if _, err := os.Stat(filepath.Join(s.dir, filepath.FromSlash(file))); !ok || err != nil {
+		file = path.Join(sum[:2], sum+assetExt(u))
+		full := filepath.Join(s.dir, filepath.FromSlash(file))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			return nil, err
+		}
+		if err := os.Rename(tmp.Name(), full); err != nil {
+			return nil, err
+		}
+		s.files[sum] = file
+	}
+	a := &StoredAsset{File: file, SHA256: sum, Size: size}
+	s.index[u] = a
+	return s.resolve(a), nil
+}
+
+// Lookup returns the stored asset downloaded from the URL, or nil.
+func (s *ContentStore) Lookup(u string) *StoredAsset {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if a, ok := s.index[u]; ok {
+		return s.resolve(a)
+	}
+	return nil
+}
+
+// Flush writes the index of the store.
+func (s *ContentStore) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(s.index, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(s.dir, StoreIndexFile), b, 0644)
+}
+
+// resolve returns a copy of the indexed asset with the path of its file.
+func (s *ContentStore) resolve(a *StoredAsset) *StoredAsset {
+	c := *a
+	c.File = filepath.Join(s.dir, filepath.FromSlash(a.File))
+	return &c
+}
+
+// assetExt returns the extension of the file of the URL, eg ".png", so the
+// stored files are opened with the right type.
+func assetExt(u string) string {
+	pu, err := url.Parse(u)
+	if err != nil {
+		return ""
+	}
+	ext := strings.ToLower(path.Ext(pu.Path))
+	if len(ext) > 6 {
+		return ""
+	}
+	return ext
+}
@@ -0,0 +1,160 @@
+package mirror
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+// countFiles returns the number of files of the asset store, without its index.
+func countFiles(t *testing.T, dir string) int {
+	n := 0
+	err := filepath.Walk(dir, func(p string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() && info.Name() != StoreIndexFile {
+			n++
+		}
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestMirrorContentStore(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			fmt.Fprint(w, `<html><head><link rel="stylesheet" href="/style.css"></head><body>
+				<img src="/img/a.png"> <img src="/img/b.png"> <a href="/other">Other</a>
+				<span data-file="img/a.png">url(img/a.png)</span></body></html>`)
+		case "/other":
+			fmt.Fprint(w, `<html><body><img src="/img/b.png"> <img src="/img/c.gif"></body></html>`)
+		case "/style.css":
+			w.Header().Set("Content-Type", "text/css")
+			fmt.Fprint(w, "body {}")
+		default:
+			w.Header().Set("Content-Type", "image/png")
+			fmt.Fprint(w, "PNG")
+		}
+	}))
+	defer ts.Close()
+	dir := t.TempDir()
+	assets := filepath.Join(dir, "assets")
+
+	store, err := NewContentStore(assets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := New(newTestBrowser(), dir)
+	m.Assets = true
+	m.Store = store
+	stats, err := m.Run(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *stats != (Stats{Pages: 2, Assets: 4}) {
+		t.Errorf("Expected 2 pages and 4 assets to be saved, got %+v.", stats)
+	}
+	if n := countFiles(t, assets); n != 2 {
+		t.Errorf("Expected the identical assets to be stored once, got %d files.", n)
+	}
+
+	sum := sha256.Sum256([]byte("PNG"))
+	png := hex.EncodeToString(sum[:])
+	link := "assets/" + png[:2] + "/" + png + ".png"
+	index := readFile(t, filepath.Join(dir, "index.html"))
+	if strings.Count(index, `src="`+link+`"`) != 2 || strings.Contains(index, `src="img/`) {
+		t.Errorf("Expected the images to link to the stored file, got %s.", index)
+	}
+	if !strings.Contains(index, `<span data-file="img/a.png">url(img/a.png)</span>`) {
+		t.Errorf("Expected only the links of the page to be rewritten, got %s.", index)
+	}
+	if other := readFile(t, filepath.Join(dir, "other.html")); strings.Count(other, `src="`+link+`"`) != 2 {
+		t.Errorf("Expected the images of the other page to link to the stored file, got %s.", other)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "img")); !os.IsNotExist(err) {
+		t.Error("Expected the assets not to be saved to the mirror directory.")
+	}
+
+	var entries map[string]*Entry
+	if err := json.Unmarshal([]byte(readFile(t, filepath.Join(dir, ManifestFile))), &entries); err != nil {
+		t.Fatal(err)
+	}
+	if e := entries[ts.URL+"/img/c.gif"]; e == nil || e.File != link || e.SHA256 != png {
+		t.Errorf("Expected the stored file and checksum of the asset, got %+v.", e)
+	}
+
+	reopened, err := NewContentStore(assets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := reopened.Lookup(ts.URL + "/img/b.png"); a == nil || a.SHA256 != png || a.Size != 3 {
+		t.Errorf("Expected the index to be saved, got %+v.", a)
+	}
+	m = New(newTestBrowser(), dir)
+	m.Assets = true
+	m.Store = reopened
+	if _, err := m.Run(context.Background(), ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if n := countFiles(t, assets); n != 2 {
+		t.Errorf("Expected the next runs to reuse the stored files, got %d files.", n)
+	}
+}
+
+func TestContentStoreIndex(t *testing.T) {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, StoreIndexFile), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewContentStore(dir); err == nil {
+		t.Error("Expected an error for an invalid index.")
+	}
+}
+
+// failingStore is an AssetStore failing without reading the assets.
+type failingStore struct{}
+
+func (failingStore) Put(u string, r io.Reader) (*StoredAsset, error) {
+	return nil, errors.New("The store is full.")
+}
+
+func (failingStore) Flush() error {
+	return nil
+}
+
+func TestMirrorStoreError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" {
+			fmt.Fprint(w, `<html><body><img src="/big.png"></body></html>`)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(make([]byte, 1<<20))
+	}))
+	defer ts.Close()
+
+	m := New(newTestBrowser(), t.TempDir())
+	m.Assets = true
+	m.Store = failingStore{}
+	stats, err := m.Run(context.Background(), ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *stats != (Stats{Pages: 1, Errors: 1}) {
+		t.Errorf("Expected the asset the store rejected to be an error, got %+v.", stats)
+	}
+}
@@ -0,0 +1,157 @@
+// Package notify sends the events of browsers and crawls, eg a page which
+// changed or an expired session, to callbacks, channels or webhooks, so
+// monitoring programs don't need glue code. Set the notifier of a browser
+// with Browser.SetNotifier().
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+// DefaultWebhookTimeout is the timeout of the requests sent by the webhooks
+// created with NewWebhook.
+var DefaultWebhookTimeout = 10 * time.Second
+
+// Kind is the kind of an Event.
+type Kind string
+
+const (
+	// PageChanged is sent when a reloaded page or a snapshot comparison
+	// shows a change.
+	PageChanged Kind = "page_changed"
+
+	// SessionExpired is sent when a page shows an expired session.
+	SessionExpired Kind = "session_expired"
+
+	// CrawlFinished is sent when a crawl has fetched every queued page.
+	CrawlFinished Kind = "crawl_finished"
+)
+
+// Event is something which happened to a browser or a crawl.
+type Event struct {
+	// Kind is the kind of event.
+	Kind Kind `json:"kind"`
+
+	// Time is when the event happened.
+	Time time.Time `json:"time"`
+
+	// URL is the page the event is about, if any.
+	URL string `json:"url,omitempty"`
+
+	// Message describes the event.
+	Message string `json:"message,omitempty"`
+
+	// Data holds details which depend on the kind of event.
+	Data map[string]string `json:"data,omitempty"`
+}
+
+// Notifier receives events.
+type Notifier interface {
+	// Notify handles the event, and returns an error when it could not.
+	Notify(e *Event) error
+}
+
+// Func is a function used as a Notifier.
+type Func func(e *Event) error
+
+// Notify calls the function.
+func (f Func) Notify(e *Event) error {
+	return f(e)
+}
+
+// Channel returns a Notifier which sends the events to ch. Events are not
+// waited for, and an error is returned when the channel is full.
+func Channel(ch chan<- *Event) Notifier {
+	return Func(func(e *Event) error {
+		select {
+		case ch <- e:
+			return nil
+		default:
+			return errors.New("Cannot send the '%s' event, the channel is full.", e.Kind)
+		}
+	})
+}
+
+// Multi returns a Notifier which sends the events to each notifier, and
+// returns the first error.
+func Multi(ns ...Notifier) Notifier {
+	return Func(func(e *Event) error {
+		var first error
+		for _, n := range ns {
+			if err := n.Notify(e); err != nil && first == nil {
+				first = err
+			}
+		}
+		return first
+	})
+}
+
+// Only returns a Notifier which sends the events of the given kinds to n,
+// and drops the others.
+func Only(n Notifier, kinds ...Kind) Notifier {
+	return Func(func(e *Event) error {
+		for _, k := range kinds {
+			if e.Kind == k {
+				return n.Notify(e)
+			}
+		}
+		return nil
+	})
+}
+
+// Webhook is a Notifier which posts the events as JSON to a URL.
+type Webhook struct {
+	// URL is the address the events are posted to.
+	URL string
+
+	// Header holds the headers sent with each event, eg an Authorization header.
+	Header http.Header
+
+	// Client sends the requests.
+	Client *http.Client
+}
+
+// NewWebhook creates and returns a *Webhook posting the events to the given
+// URL, with a timeout of DefaultWebhookTimeout.
+func NewWebhook(u string) *Webhook {
+	return &Webhook{
+		URL:    u,
+		Header: make(http.Header),
+		Client: &http.Client{Timeout: DefaultWebhookTimeout},
+	}
+}
+
+// Notify posts the event, and returns an error when the server does not
+// answer with a 2xx status.
+func (w *Webhook) Notify(e *Event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for name, values := range w.Header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.New("Cannot send the '%s' event, the webhook '%s' returned %d.", e.Kind, w.URL, resp.StatusCode)
+	}
+	return nil
+}
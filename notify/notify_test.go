@@ -0,0 +1,72 @@
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNotifiers(t *testing.T) {
+	var got []Kind
+	record := Func(func(e *Event) error {
+		got = append(got, e.Kind)
+		return nil
+	})
+	ch := make(chan *Event, 1)
+	n := Multi(Only(record, PageChanged, CrawlFinished), Channel(ch))
+
+	if err := n.Notify(&Event{Kind: PageChanged}); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Notify(&Event{Kind: SessionExpired}); err == nil {
+		t.Error("Expected an error when the channel is full.")
+	}
+	if len(got) != 1 || got[0] != PageChanged {
+		t.Errorf("Expected only the page changed event, got %v.", got)
+	}
+	if e := <-ch; e.Kind != PageChanged {
+		t.Errorf("Expected the page changed event on the channel, got %s.", e.Kind)
+	}
+}
+
+func TestWebhook(t *testing.T) {
+	var received Event
+	var auth string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			http.Error(w, "nope", http.StatusInternalServerError)
+			return
+		}
+		auth = r.Header.Get("Authorization")
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Expected a JSON body, got '%s'.", r.Header.Get("Content-Type"))
+		}
+		json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer ts.Close()
+
+	w := NewWebhook(ts.URL)
+	w.Header.Set("Authorization", "Bearer secret")
+	e := &Event{
+		Kind: PageChanged,
+		Time: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		URL:  "http://example.com/",
+		Data: map[string]string{"snapshot": "home"},
+	}
+	if err := w.Notify(e); err != nil {
+		t.Fatal(err)
+	}
+	if received.Kind != PageChanged || received.URL != e.URL || !received.Time.Equal(e.Time) || received.Data["snapshot"] != "home" {
+		t.Errorf("Expected the event to be posted, got %+v.", received)
+	}
+	if auth != "Bearer secret" {
+		t.Errorf("Expected the webhook headers, got '%s'.", auth)
+	}
+
+	w.URL = ts.URL + "/fail"
+	if err := w.Notify(e); err == nil {
+		t.Error("Expected an error for a failed webhook.")
+	}
+}
@@ -0,0 +1,81 @@
+package surf
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/lostinblue/surf/browser"
+)
+
+// Option configures a browser created by NewBrowser() or New(). Options
+// apply to the new browser only, unlike the browser.Default* variables,
+// which are read by every browser and are not safe to change concurrently.
+type Option func(bow *browser.Browser) error
+
+// WithUserAgent sets the user agent of the browser.
+func WithUserAgent(ua string) Option {
+	return func(bow *browser.Browser) error {
+		bow.SetUserAgent(ua)
+		return nil
+	}
+}
+
+// WithTimeout sets the timeout of the requests of the browser.
+func WithTimeout(d time.Duration) Option {
+	return func(bow *browser.Browser) error {
+		bow.SetTimeout(d)
+		return nil
+	}
+}
+
+// WithProxy sets the proxy of the browser. See Browser.SetProxy().
+func WithProxy(u string) Option {
+	return func(bow *browser.Browser) error {
+		return bow.SetProxy(u)
+	}
+}
+
+// WithCookieJar sets the cookie jar of the browser.
+func WithCookieJar(cj http.CookieJar) Option {
+	return func(bow *browser.Browser) error {
+		bow.SetCookieJar(cj)
+		return nil
+	}
+}
+
+// WithTransport sets the transport of the browser.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(bow *browser.Browser) error {
+		bow.SetTransport(rt)
+		return nil
+	}
+}
+
+// WithAttribute sets an attribute of the browser, eg
+// WithAttribute(browser.FollowRedirects, false).
+func WithAttribute(a browser.Attribute, v bool) Option {
+	return func(bow *browser.Browser) error {
+		bow.SetAttribute(a, v)
+		return nil
+	}
+}
+
+// WithAttributes sets attributes of the browser. The attributes which are
+// not in the map keep their default value.
+func WithAttributes(attrs browser.AttributeMap) Option {
+	return func(bow *browser.Browser) error {
+		for a, v := range attrs {
+			bow.SetAttribute(a, v)
+		}
+		return nil
+	}
+}
+
+// WithMaxHistoryLength sets the maximum number of pages of the history of
+// the browser, or 0 for no limit.
+func WithMaxHistoryLength(n int) Option {
+	return func(bow *browser.Browser) error {
+		bow.HistoryJar().SetMax(n)
+		return nil
+	}
+}
@@ -0,0 +1,63 @@
+package surf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/lostinblue/surf/browser"
+	"github.com/lostinblue/surf/jar"
+)
+
+func TestNewBrowserOptions(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/", http.StatusFound)
+			return
+		}
+		w.Write([]byte(r.UserAgent()))
+	}))
+	defer ts.Close()
+
+	cookies := jar.NewCookiesJar()
+	bow := NewBrowser(
+		WithUserAgent("Testing/2.0"),
+		WithTimeout(5*time.Second),
+		WithCookieJar(cookies),
+		WithAttribute(browser.FollowRedirects, false),
+		WithAttributes(browser.AttributeMap{browser.FormEvents: true}),
+		WithMaxHistoryLength(1),
+	)
+	if bow.UserAgent() != "Testing/2.0" || bow.Timeout() != 5*time.Second || bow.CookieJar() != cookies {
+		t.Errorf("Expected the options to be applied, got %q %v.", bow.UserAgent(), bow.Timeout())
+	}
+	if bow.Attribute(browser.FollowRedirects) || !bow.Attribute(browser.FormEvents) || !bow.Attribute(browser.SendReferer) {
+		t.Error("Expected the attributes to be set, and the others to keep their default.")
+	}
+	if err := bow.GET(ts.URL); err != nil {
+		t.Fatal(err)
+	}
+	if bow.Body() != "Testing/2.0" {
+		t.Errorf("Expected the user agent to be sent, got '%s'.", bow.Body())
+	}
+
+	other := NewBrowser()
+	if other.UserAgent() != browser.DefaultUserAgent || !other.Attribute(browser.FollowRedirects) {
+		t.Error("Expected the options not to change the defaults of other browsers.")
+	}
+
+	if _, err := New(WithProxy("ftp://localhost:21")); err == nil {
+		t.Error("Expected the error of the proxy option.")
+	}
+	bow, err := New(WithProxy("http://localhost:3128"))
+	if err != nil || bow.Proxy() != "http://localhost:3128" {
+		t.Errorf("Expected the proxy to be set, got '%s' (%v).", bow.Proxy(), err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected NewBrowser to panic when an option fails.")
+		}
+	}()
+	NewBrowser(WithProxy("ftp://localhost:21"))
+}
@@ -0,0 +1,206 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"regexp"
+
+	"github.com/lostinblue/surf/errors"
+	"github.com/lostinblue/surf/util"
+)
+
+// Decoders are the functions used by LoadFile to decode config files, by
+// file extension. It's the registry shared with the browser and profile
+// configs, util.Decoders, so JSON, YAML and TOML are supported out of the box, and
+// a decoder registered once applies to them all.
+var Decoders = util.Decoders
+
+// Config describes a pipeline in a config file. Each step sets one of its
+// fields, eg in YAML:
+//
+//	steps:
+//	  - open: https://example.com/products
+//	  - paginate:
+//	      next: a.next
+//	      max: 10
+//	      steps:
+//	        - extract:
+//	            selector: div.product
+//	            fields:
+//	              name: h2
+//	              price: .price
+//	              url: a,attr=href
+//	  - transform:
+//	      field: price
+//	      op: replace
+//	      pattern: '[^0-9.]'
+//	  - output:
+//	      format: csv
+//	      columns: [name, price, url]
+type Config struct {
+	Steps []*StepConfig `json:"steps"`
+}
+
+// StepConfig describes a step of a Config.
+type StepConfig struct {
+	// Open is the URL loaded by an Open step.
+	Open string `json:"open,omitempty"`
+
+	// Follow is the selector of the link clicked by a Follow step.
+	Follow string `json:"follow,omitempty"`
+
+	Paginate  *PaginateConfig  `json:"paginate,omitempty"`
+	Extract   *ExtractConfig   `json:"extract,omitempty"`
+	Transform *TransformConfig `json:"transform,omitempty"`
+	Output    *OutputConfig    `json:"output,omitempty"`
+}
+
+// PaginateConfig describes a Paginate step.
+type PaginateConfig struct {
+	Next  string        `json:"next,omitempty"`
+	Max   int           `json:"max,omitempty"`
+	Steps []*StepConfig `json:"steps"`
+}
+
+// ExtractConfig describes an Extract step.
+type ExtractConfig struct {
+	Selector string            `json:"selector"`
+	Fields   map[string]string `json:"fields"`
+}
+
+// TransformConfig describes a transform of a field, where op is one of
+// "replace", which replaces the matches of the pattern with the replace
+// value, "match", which keeps the records whose field matches the pattern,
+// "lower" or "upper".
+type TransformConfig struct {
+	Field   string `json:"field"`
+	Op      string `json:"op"`
+	Pattern string `json:"pattern,omitempty"`
+	Replace string `json:"replace,omitempty"`
+}
+
+// OutputConfig describes a Write step, or a WriteFile step when a file is
+// given. The format defaults to JSON.
+type OutputConfig struct {
+	Format  Format   `json:"format,omitempty"`
+	File    string   `json:"file,omitempty"`
+	Columns []string `json:"columns,omitempty"`
+}
+
+// Load decodes a JSON pipeline config.
+func Load(r io.Reader) (*Config, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return decode(b, json.Unmarshal)
+}
+
+// LoadFile decodes the pipeline config saved in the given file, with the
+// decoder registered for its extension in Decoders.
+func LoadFile(file string) (*Config, error) {
+	b, dec, err := util.ReadConfigFile(file, "pipeline")
+	if err != nil {
+		return nil, err
+	}
+	return decode(b, dec)
+}
+
+// decode unmarshals the config.
+func decode(b []byte, dec func([]byte, interface{}) error) (*Config, error) {
+	c := &Config{}
+	if err := dec(b, c); err != nil {
+		return nil, err
+	}
+	if len(c.Steps) == 0 {
+		return nil, errors.New("The pipeline has no steps.")
+	}
+	return c, nil
+}
+
+// Build returns the pipeline described by the config. Outputs without a
+// file are written to w.
+func (c *Config) Build(w io.Writer) (*Pipeline, error) {
+	steps, err := buildSteps(c.Steps, w)
+	if err != nil {
+		return nil, err
+	}
+	return New(steps...), nil
+}
+
+// buildSteps returns the steps described by the configs.
+func buildSteps(configs []*StepConfig, w io.Writer) ([]Step, error) {
+	steps := make([]Step, 0, len(configs))
+	for i, sc := range configs {
+		s, err := buildStep(sc, w)
+		if err != nil {
+			return nil, errors.New("Invalid step %d: %s", i+1, err)
+		}
+		steps = append(steps, s)
+	}
+	return steps, nil
+}
+
+// buildStep returns the step described by the config.
+func buildStep(sc *StepConfig, w io.Writer) (Step, error) {
+	switch {
+	case sc == nil:
+		return nil, errors.New("The step is empty.")
+	case sc.Open != "":
+		return Open(sc.Open), nil
+	case sc.Follow != "":
+		return Follow(sc.Follow), nil
+	case sc.Paginate != nil:
+		steps, err := buildSteps(sc.Paginate.Steps, w)
+		if err != nil {
+			return nil, err
+		}
+		return Paginate(sc.Paginate.Next, sc.Paginate.Max, steps...), nil
+	case sc.Extract != nil:
+		if sc.Extract.Selector == "" || len(sc.Extract.Fields) == 0 {
+			return nil, errors.New("The extract step needs a selector and fields.")
+		}
+		return Extract(sc.Extract.Selector, sc.Extract.Fields), nil
+	case sc.Transform != nil:
+		return buildTransform(sc.Transform)
+	case sc.Output != nil:
+		o := sc.Output
+		if o.Format == "" {
+			o.Format = JSON
+		}
+		switch o.Format {
+		case CSV, JSON, NDJSON:
+		default:
+			return nil, errors.New("Unknown output format '%s', expected csv, json or ndjson.", o.Format)
+		}
+		if o.File != "" {
+			return WriteFile(o.File, o.Format, o.Columns...), nil
+		}
+		return Write(w, o.Format, o.Columns...), nil
+	}
+	return nil, errors.New("The step is empty.")
+}
+
+// buildTransform returns the transform step described by the config.
+func buildTransform(tc *TransformConfig) (Step, error) {
+	if tc.Field == "" {
+		return nil, errors.New("The transform step needs a field.")
+	}
+	switch tc.Op {
+	case "lower":
+		return Lower(tc.Field), nil
+	case "upper":
+		return Upper(tc.Field), nil
+	case "replace", "match":
+		re, err := regexp.Compile(tc.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		if tc.Op == "match" {
+			return Match(tc.Field, re), nil
+		}
+		return Replace(tc.Field, re, tc.Replace), nil
+	}
+	return nil, errors.New("Unknown transform '%s', expected replace, match, lower or upper.", tc.Op)
+}
@@ -0,0 +1,81 @@
+package pipeline
+
+import (
+	"io"
+	"os"
+
+	"github.com/lostinblue/surf/export"
+)
+
+// Format is the format records are written in.
+type Format string
+
+const (
+	// CSV writes a header line with the column names, and a line per record.
+	CSV Format = "csv"
+
+	// JSON writes an array of records.
+	JSON Format = "json"
+
+	// NDJSON writes a JSON object per line.
+	NDJSON Format = "ndjson"
+)
+
+// Write writes the records of the run to w in the given format. The CSV
+// columns are the given ones, or the names of every field sorted when none
+// are given, while the JSON objects hold every field, sorted. The records
+// are kept, so they may be written more than once.
+func Write(w io.Writer, f Format, columns ...string) Step {
+	return StepFunc(func(r *Run) error {
+		return writeRecords(w, r.Records, f, columns)
+	})
+}
+
+// WriteFile writes the records of the run to the named file, which is
+// created or truncated. See Write().
+func WriteFile(file string, f Format, columns ...string) Step {
+	return StepFunc(func(r *Run) error {
+		fout, err := os.Create(file)
+		if err != nil {
+			return err
+		}
+		if err = writeRecords(fout, r.Records, f, columns); err != nil {
+			fout.Close()
+			return err
+		}
+		return fout.Close()
+	})
+}
+
+// Export exports the records of the run with e, which is not closed, eg to
+// stream the records of several pipelines to the same file.
+func Export(e export.Exporter) Step {
+	return StepFunc(func(r *Run) error {
+		for _, rec := range r.Records {
+			if err := e.Export(rec); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// writeRecords writes the records to w in the given format, with indented
+// JSON arrays.
+func writeRecords(w io.Writer, records []Record, f Format, columns []string) error {
+	opts := export.Options{Infer: len(records)}
+	switch f {
+	case CSV:
+		opts.Columns = columns
+	case JSON:
+		opts.Indent = "  "
+	}
+	e, err := export.New(w, export.Format(f), opts)
+	if err != nil {
+		return err
+	}
+	if err = Export(e).Apply(&Run{Records: records}); err != nil {
+		return err
+	}
+	return e.Close()
+}
@@ -0,0 +1,239 @@
+// Package pipeline describes scrapes as a list of steps, which navigate to
+// pages, extract records from them, transform the records and write them
+// as CSV, JSON or NDJSON:
+//
+//	p := pipeline.New(
+//		pipeline.Open("https://example.com/products"),
+//		pipeline.Paginate("a.next", 10,
+//			pipeline.Extract("div.product", map[string]string{
+//				"name":  "h2",
+//				"price": ".price",
+//				"url":   "a,attr=href",
+//			}),
+//		),
+//		pipeline.Write(os.Stdout, pipeline.CSV, "name", "price", "url"),
+//	)
+//	records, err := p.Run(surf.NewBrowser())
+//
+// Pipelines may also be loaded from config files with LoadFile, so simple
+// scrapes need no Go code.
+package pipeline
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/lostinblue/surf/browser"
+	"github.com/lostinblue/surf/errors"
+)
+
+// Record is an item extracted from a page, eg a product, holding its fields by name.
+type Record map[string]string
+
+// Run is the state of a pipeline run, passed from step to step.
+type Run struct {
+	// Browser loads the pages.
+	Browser *browser.Browser
+
+	// Records holds the records extracted by the previous steps.
+	Records []Record
+}
+
+// Step is a step of a pipeline.
+type Step interface {
+	// Apply runs the step.
+	Apply(r *Run) error
+}
+
+// StepFunc is a function used as a Step.
+type StepFunc func(r *Run) error
+
+// Apply calls the function.
+func (f StepFunc) Apply(r *Run) error {
+	return f(r)
+}
+
+// Pipeline is a list of steps run in order.
+type Pipeline struct {
+	// Steps are the steps of the pipeline.
+	Steps []Step
+}
+
+// New creates and returns a *Pipeline with the given steps.
+func New(steps ...Step) *Pipeline {
+	return &Pipeline{Steps: steps}
+}
+
+// Then adds the steps to the end of the pipeline.
+func (p *Pipeline) Then(steps ...Step) *Pipeline {
+	p.Steps = append(p.Steps, steps...)
+	return p
+}
+
+// Run runs the steps with the given browser, and returns the records.
+// The pipeline stops at the first step which fails.
+func (p *Pipeline) Run(bow *browser.Browser) ([]Record, error) {
+	r := &Run{Browser: bow}
+	if err := runSteps(r, p.Steps); err != nil {
+		return r.Records, err
+	}
+	return r.Records, nil
+}
+
+// runSteps applies the steps in order, and adds the step number to errors.
+func runSteps(r *Run, steps []Step) error {
+	for i, s := range steps {
+		if err := s.Apply(r); err != nil {
+			return errors.New("Step %d failed: %s", i+1, err)
+		}
+	}
+	return nil
+}
+
+// Open loads the given URL.
+func Open(u string) Step {
+	return StepFunc(func(r *Run) error {
+		return r.Browser.GET(u)
+	})
+}
+
+// Follow clicks the first link matching the selector.
+func Follow(selector string) Step {
+	return StepFunc(func(r *Run) error {
+		return r.Browser.Click(selector)
+	})
+}
+
+// Paginate applies the steps to the current page and to each following
+// page, up to max pages, or every page when max is 0. The next pages are
+// found with Browser.NextPage(), so next is a selector or rel value, and
+// may be empty to find the next link automatically.
+func Paginate(next string, max int, steps ...Step) Step {
+	return StepFunc(func(r *Run) error {
+		for page := 1; ; page++ {
+			if err := runSteps(r, steps); err != nil {
+				return err
+			}
+			if max > 0 && page >= max {
+				return nil
+			}
+			if err := r.Browser.NextPage(next); err != nil {
+				if _, ok := err.(errors.LinkNotFound); ok {
+					return nil
+				}
+				return err
+			}
+		}
+	})
+}
+
+// Extract adds a record for each element matching the selector to the run.
+// The fields are read from the element with specs using the syntax of the
+// surf tags, eg "h2" or "a,attr=href" (see Browser.Unmarshal()), and href
+// and src attributes are resolved against the page URL. Fields without a
+// match are left out of the record.
+//
+// Returns a PageNotLoaded error when no page has been opened.
+func Extract(selector string, fields map[string]string) Step {
+	return StepFunc(func(r *Run) error {
+		if r.Browser.Document() == nil {
+			return errors.NewPageNotLoaded("Cannot extract '%s', no page has been opened.", selector)
+		}
+		var err error
+		r.Browser.Find(selector).EachWithBreak(func(_ int, s *goquery.Selection) bool {
+			rec := make(Record, len(fields))
+			for name, spec := range fields {
+				var v string
+				var ok bool
+				v, ok, err = browser.ReadSelection(s, spec)
+				if err != nil {
+					return false
+				}
+				if !ok {
+					continue
+				}
+				if isURLSpec(spec) {
+					if v, err = r.Browser.ResolveStringURL(v); err != nil {
+						return false
+					}
+				}
+				rec[name] = v
+			}
+			r.Records = append(r.Records, rec)
+			return true
+		})
+		return err
+	})
+}
+
+// isURLSpec returns a boolean value indicating whether the spec reads an
+// href or src attribute.
+func isURLSpec(spec string) bool {
+	i := strings.LastIndex(spec, ",")
+	if i < 0 {
+		return false
+	}
+	opt := strings.Replace(spec[i+1:], " ", "", -1)
+	return opt == "attr=href" || opt == "attr=src"
+}
+
+// Transform replaces each record by the one returned by fn, and drops the
+// records for which it returns nil.
+func Transform(fn func(rec Record) (Record, error)) Step {
+	return StepFunc(func(r *Run) error {
+		records := r.Records[:0]
+		for _, rec := range r.Records {
+			out, err := fn(rec)
+			if err != nil {
+				return err
+			}
+			if out != nil {
+				records = append(records, out)
+			}
+		}
+		r.Records = records
+		return nil
+	})
+}
+
+// Replace replaces the matches of re in the field with repl, which may
+// refer to the groups of re, eg "$1".
+func Replace(field string, re *regexp.Regexp, repl string) Step {
+	return Transform(func(rec Record) (Record, error) {
+		if v, ok := rec[field]; ok {
+			rec[field] = re.ReplaceAllString(v, repl)
+		}
+		return rec, nil
+	})
+}
+
+// Match keeps the records whose field matches re.
+func Match(field string, re *regexp.Regexp) Step {
+	return Transform(func(rec Record) (Record, error) {
+		if !re.MatchString(rec[field]) {
+			return nil, nil
+		}
+		return rec, nil
+	})
+}
+
+// Lower converts the field to lower case.
+func Lower(field string) Step {
+	return Transform(func(rec Record) (Record, error) {
+		if v, ok := rec[field]; ok {
+			rec[field] = strings.ToLower(v)
+		}
+		return rec, nil
+	})
+}
+
+// Upper converts the field to upper case.
+func Upper(field string) Step {
+	return Transform(func(rec Record) (Record, error) {
+		if v, ok := rec[field]; ok {
+			rec[field] = strings.ToUpper(v)
+		}
+		return rec, nil
+	})
+}
@@ -0,0 +1,212 @@
+package pipeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/lostinblue/surf/browser"
+	"github.com/lostinblue/surf/jar"
+)
+
+func newTestServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		if page == "" {
+			page = "1"
+		}
+		fmt.Fprintf(w, `<html><body>
+			<div class="product"><h2>Widget %[1]s</h2><span class="price">$%[1]s.50</span><a href="/p/w%[1]s">more</a></div>
+			<div class="product"><h2>Gadget %[1]s</h2><span class="price">$1%[1]s.00</span><a href="/p/g%[1]s">more</a></div>`, page)
+		if page != "3" {
+			fmt.Fprintf(w, `<a class="next" href="/?page=%d">Next</a>`, page[0]-'0'+1)
+		}
+		fmt.Fprint(w, `</body></html>`)
+	}))
+}
+
+func newTestBrowser() *browser.Browser {
+	bow := &browser.Browser{}
+	bow.SetState(&jar.State{})
+	bow.SetCookieJar(jar.NewCookiesJar())
+	bow.SetHistoryJar(jar.NewMemoryHistory())
+	bow.SetHeadersJar(jar.NewMemoryHeaders())
+	bow.SetAttributes(browser.AttributeMap{browser.FollowRedirects: true})
+	return bow
+}
+
+func TestPipeline(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+
+	var csvOut, ndjsonOut bytes.Buffer
+	p := New(
+		Open(ts.URL),
+		Paginate("a.next", 2, Extract("div.product", map[string]string{
+			"name":  "h2",
+			"price": ".price",
+			"url":   "a, attr=href",
+			"none":  ".missing",
+		})),
+		Replace("price", regexp.MustCompile(`[^0-9.]`), ""),
+		Match("name", regexp.MustCompile(`^Widget`)),
+		Upper("name"),
+	).Then(
+		Write(&csvOut, CSV, "name", "price", "url"),
+		Write(&ndjsonOut, NDJSON),
+	)
+	records, err := p.Run(newTestBrowser())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []Record{
+		{"name": "WIDGET 1", "price": "1.50", "url": ts.URL + "/p/w1"},
+		{"name": "WIDGET 2", "price": "2.50", "url": ts.URL + "/p/w2"},
+	}
+	if !reflect.DeepEqual(records, expected) {
+		t.Errorf("Expected the records %v, got %v.", expected, records)
+	}
+	csv := "name,price,url\nWIDGET 1,1.50," + ts.URL + "/p/w1\nWIDGET 2,2.50," + ts.URL + "/p/w2\n"
+	if csvOut.String() != csv {
+		t.Errorf("Expected the CSV output %q, got %q.", csv, csvOut.String())
+	}
+	lines := strings.Split(strings.TrimSpace(ndjsonOut.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Expected 2 NDJSON lines, got %q.", ndjsonOut.String())
+	}
+	var rec Record
+	if err := json.Unmarshal([]byte(lines[1]), &rec); err != nil || !reflect.DeepEqual(rec, expected[1]) {
+		t.Errorf("Expected the second record, got %s (%v).", lines[1], err)
+	}
+
+	// The JSON output is indented, and the CSV header is written without
+	// records.
+	var jsonOut, emptyOut bytes.Buffer
+	if err := Write(&jsonOut, JSON, "url").Apply(&Run{Records: []Record{{"name": "A", "price": "1"}}}); err != nil {
+		t.Fatal(err)
+	}
+	if s := "[\n  {\n    \"name\": \"A\",\n    \"price\": \"1\"\n  }\n]\n"; jsonOut.String() != s {
+		t.Errorf("Expected the JSON output %q, got %q.", s, jsonOut.String())
+	}
+	if err := Write(&emptyOut, CSV, "name", "price").Apply(&Run{}); err != nil {
+		t.Fatal(err)
+	}
+	if emptyOut.String() != "name,price\n" {
+		t.Errorf("Expected the CSV header, got %q.", emptyOut.String())
+	}
+
+	// Paginate stops at the last page without a max.
+	records, err = New(Open(ts.URL), Paginate("a.next", 0, Extract("h2", map[string]string{"name": ""}))).Run(newTestBrowser())
+	if err != nil || len(records) != 6 {
+		t.Errorf("Expected 6 records from 3 pages, got %d (%v).", len(records), err)
+	}
+
+	_, err = New(Extract("h2", map[string]string{"name": ""})).Run(newTestBrowser())
+	if err == nil || !strings.Contains(err.Error(), "Page Not Loaded") {
+		t.Errorf("Expected a PageNotLoaded error without a page, got %v.", err)
+	}
+
+	_, err = New(Open(ts.URL), Follow("a.missing")).Run(newTestBrowser())
+	if err == nil || !strings.Contains(err.Error(), "Step 2 failed") {
+		t.Errorf("Expected the failed step in the error, got %v.", err)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+	dir, err := ioutil.TempDir("", "surf-pipeline")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "scrape.json")
+	output := filepath.Join(dir, "products.json")
+	ioutil.WriteFile(file, []byte(`{"steps": [
+		{"open": "`+ts.URL+`"},
+		{"paginate": {"next": "a.next", "steps": [
+			{"extract": {"selector": "div.product", "fields": {"name": "h2"}}}
+		]}},
+		{"transform": {"field": "name", "op": "lower"}},
+		{"transform": {"field": "name", "op": "match", "pattern": "gadget"}},
+		{"output": {"file": "`+filepath.ToSlash(output)+`"}},
+		{"output": {"format": "ndjson"}}
+	]}`), 0644)
+
+	c, err := LoadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	p, err := c.Build(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = p.Run(newTestBrowser()); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(out.String(), "\n") != 3 || !strings.Contains(out.String(), `{"name":"gadget 3"}`) {
+		t.Errorf("Expected 3 NDJSON records, got %q.", out.String())
+	}
+	var saved []Record
+	b, _ := ioutil.ReadFile(output)
+	if err = json.Unmarshal(b, &saved); err != nil || len(saved) != 3 || saved[0]["name"] != "gadget 1" {
+		t.Errorf("Expected the records saved as JSON, got %s (%v).", b, err)
+	}
+
+	yml := filepath.Join(dir, "scrape.yaml")
+	ioutil.WriteFile(yml, []byte(`
+steps:
+  - open: `+ts.URL+`
+  - extract:
+      selector: div.product
+      fields:
+        name: h2
+  - output:
+      format: ndjson
+`), 0644)
+	if c, err = LoadFile(yml); err != nil {
+		t.Fatal(err)
+	}
+	out.Reset()
+	if p, err = c.Build(&out); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = p.Run(newTestBrowser()); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(out.String(), "\n") != 2 {
+		t.Errorf("Expected the records of the YAML pipeline, got %q.", out.String())
+	}
+	if _, err = LoadFile(filepath.Join(dir, "scrape.ini")); err == nil {
+		t.Error("Expected an error without an INI decoder.")
+	}
+	invalid := []string{
+		`{"steps": []}`,
+		`{"steps": [{}]}`,
+		`{"steps": [{"extract": {"selector": "div"}}]}`,
+		`{"steps": [{"transform": {"field": "name", "op": "reverse"}}]}`,
+		`{"steps": [{"transform": {"field": "name", "op": "match", "pattern": "("}}]}`,
+		`{"steps": [{"output": {"format": "xml"}}]}`,
+		`{"steps": [{"paginate": {"steps": [{}]}}]}`,
+	}
+	for _, config := range invalid {
+		c, err := Load(strings.NewReader(config))
+		if err == nil {
+			_, err = c.Build(&out)
+		}
+		if err == nil {
+			t.Errorf("Expected an error for the config %s.", config)
+		}
+	}
+}
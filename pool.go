@@ -0,0 +1,132 @@
+package surf
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/lostinblue/surf/browser"
+	"github.com/lostinblue/surf/errors"
+	"github.com/lostinblue/surf/jar"
+)
+
+// BrowserPool bounds the number of browsers used concurrently, so
+// applications can fan out work safely.
+//
+// Each browser has its own state, history and cookies, unless a cookie jar
+// is shared with ShareCookieJar(). Browsers are created on demand, up to the
+// size of the pool, and reused once released.
+type BrowserPool struct {
+	configure func(*browser.Browser)
+	slots     chan struct{}
+
+	mu     sync.Mutex
+	idle   []*browser.Browser
+	inUse  map[*browser.Browser]bool
+	jar    http.CookieJar
+	closed bool
+}
+
+// NewBrowserPool creates and returns a new *BrowserPool type holding up to n
+// browsers. The configure function, which may be nil, is called with each
+// new browser, eg to set its user agent.
+func NewBrowserPool(n int, configure func(*browser.Browser)) *BrowserPool {
+	if n < 1 {
+		n = 1
+	}
+	return &BrowserPool{
+		configure: configure,
+		slots:     make(chan struct{}, n),
+		inUse:     make(map[*browser.Browser]bool),
+	}
+}
+
+// ShareCookieJar sets the cookie jar used by every browser of the pool, eg
+// to share a logged in session, or nil to give each new browser its own jar.
+// The jar must be safe for concurrent use.
+func (p *BrowserPool) ShareCookieJar(cj http.CookieJar) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.jar = cj
+	for _, bow := range p.idle {
+		if cj != nil {
+			bow.SetCookieJar(cj)
+		} else {
+			bow.SetCookieJar(jar.NewCookiesJar())
+		}
+	}
+}
+
+// Acquire returns a browser of the pool, waiting for one to be released
+// when they are all in use. Returns the context error when the context is
+// done first. The browser must be given back with Release().
+func (p *BrowserPool) Acquire(ctx context.Context) (*browser.Browser, error) {
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		<-p.slots
+		return nil, errors.New("The browser pool is closed.")
+	}
+	var bow *browser.Browser
+	if n := len(p.idle); n > 0 {
+		bow = p.idle[n-1]
+		p.idle = p.idle[:n-1]
+	} else {
+		bow = NewBrowser()
+		if p.jar != nil {
+			bow.SetCookieJar(p.jar)
+		}
+		if p.configure != nil {
+			p.configure(bow)
+		}
+	}
+	p.inUse[bow] = true
+	return bow, nil
+}
+
+// Release gives the browser back to the pool. Its page, history, session
+// storage and pending refresh are cleared with ResetPage(), so the next user
+// starts from a blank page, but its cookies are kept. Releasing a browser
+// which isn't in use, eg twice, has no effect.
+func (p *BrowserPool) Release(bow *browser.Browser) {
+	p.mu.Lock()
+	if !p.inUse[bow] {
+		p.mu.Unlock()
+		return
+	}
+	delete(p.inUse, bow)
+	p.mu.Unlock()
+
+	bow.ResetPage()
+	p.mu.Lock()
+	if !p.closed {
+		p.idle = append(p.idle, bow)
+	}
+	p.mu.Unlock()
+	<-p.slots
+}
+
+// Size returns the maximum number of browsers of the pool.
+func (p *BrowserPool) Size() int {
+	return cap(p.slots)
+}
+
+// InUse returns the number of browsers acquired and not released yet.
+func (p *BrowserPool) InUse() int {
+	return len(p.slots)
+}
+
+// Close discards the idle browsers, and makes Acquire() return an error.
+// Browsers in use may still be released.
+func (p *BrowserPool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	p.idle = nil
+}
@@ -0,0 +1,118 @@
+package surf
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/lostinblue/surf/browser"
+	"github.com/lostinblue/surf/jar"
+)
+
+func TestBrowserPool(t *testing.T) {
+	var active, peak int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&active, 1)
+		defer atomic.AddInt32(&active, -1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		if r.URL.Path == "/login" {
+			http.SetCookie(w, &http.Cookie{Name: "session", Value: "1", Path: "/"})
+		}
+		time.Sleep(5 * time.Millisecond)
+		fmt.Fprintf(w, `<html><head><title>%s</title></head><body>%s</body></html>`, r.Header.Get("User-Agent"), r.URL.Path)
+	}))
+	defer ts.Close()
+
+	pool := NewBrowserPool(2, func(bow *browser.Browser) {
+		bow.SetUserAgent("pool")
+	})
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			bow, err := pool.Acquire(context.Background())
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			defer pool.Release(bow)
+			if err := bow.GET(fmt.Sprintf("%s/page/%d", ts.URL, i)); err != nil {
+				t.Error(err)
+			}
+			if bow.Title() != "pool" {
+				t.Errorf("Expected the configured user agent, got %q", bow.Title())
+			}
+		}(i)
+	}
+	wg.Wait()
+	if peak > 2 {
+		t.Errorf("Expected at most 2 concurrent requests, got %d", peak)
+	}
+	if pool.InUse() != 0 || pool.Size() != 2 {
+		t.Errorf("Expected all the browsers to be released, got %d of %d in use", pool.InUse(), pool.Size())
+	}
+
+	a, _ := pool.Acquire(context.Background())
+	b, _ := pool.Acquire(context.Background())
+	if a.URL() != nil {
+		t.Error("Expected released browsers to be cleared")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := pool.Acquire(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Expected the acquire to time out, got %v", err)
+	}
+	if err := a.GET(ts.URL + "/login"); err != nil {
+		t.Fatal(err)
+	}
+	u, _ := url.Parse(ts.URL)
+	if len(b.CookieJar().Cookies(u)) != 0 {
+		t.Error("Expected the browsers not to share cookies")
+	}
+	pool.Release(a)
+	pool.Release(b)
+
+	shared := jar.NewCookiesJar()
+	pool.ShareCookieJar(shared)
+	a, _ = pool.Acquire(context.Background())
+	b, _ = pool.Acquire(context.Background())
+	if err := a.GET(ts.URL + "/login"); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.GET(ts.URL + "/"); err != nil {
+		t.Fatal(err)
+	}
+	if len(b.SiteCookies()) != 1 {
+		t.Error("Expected the browsers to share cookies")
+	}
+	pool.Release(a)
+	pool.Release(b)
+	pool.Release(a)
+	if pool.InUse() != 0 {
+		t.Errorf("Expected a second release to be ignored, got %d in use", pool.InUse())
+	}
+	a, _ = pool.Acquire(context.Background())
+	b, _ = pool.Acquire(context.Background())
+	if a == b {
+		t.Error("Expected a browser released twice to be handed out once")
+	}
+	pool.Release(a)
+	pool.Release(b)
+
+	pool.Close()
+	if _, err := pool.Acquire(context.Background()); err == nil {
+		t.Error("Expected an error acquiring from a closed pool")
+	}
+}
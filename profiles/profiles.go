@@ -0,0 +1,190 @@
+// Package profiles holds per-site browser configuration, such as headers,
+// cookies, credentials, rate limits and proxies, declared once and applied
+// by the browser to every request to the matching hosts.
+package profiles
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lostinblue/surf/errors"
+	"github.com/lostinblue/surf/util"
+)
+
+// Decoders are the functions used by LoadFile to decode config files, by
+// file extension. It's the registry shared with the browser and pipeline
+// configs, util.Decoders, so a decoder registered once applies to them all.
+var Decoders = util.Decoders
+
+// Duration is a time.Duration read from config files as a string, eg "1.5s".
+type Duration time.Duration
+
+// UnmarshalText parses the duration with time.ParseDuration.
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// MarshalText formats the duration like time.Duration.String().
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+// Cookie is a cookie set on the hosts of a profile.
+type Cookie struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+
+	// Path defaults to "/".
+	Path string `json:"path,omitempty"`
+}
+
+// Profile is the configuration of a site.
+type Profile struct {
+	// Name identifies the profile in errors.
+	Name string `json:"name"`
+
+	// Hosts are the host patterns the profile applies to, using path.Match
+	// syntax, eg "*.example.com", which does not match "example.com" itself.
+	Hosts []string `json:"hosts"`
+
+	// Headers are set on each request, replacing the browser headers with
+	// the same name. Headers set with request options are kept.
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// Cookies are added to the cookie jar when it has no cookie with the
+	// same name for the host.
+	Cookies []Cookie `json:"cookies,omitempty"`
+
+	// Username and Password are sent with basic authentication, unless the
+	// request already has an Authorization header.
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+
+	// Delay is the minimum time between two requests to the hosts.
+	Delay Duration `json:"delay,omitempty"`
+
+	// Proxy is the URL of the proxy the requests are sent through, unless
+	// the request sets its own proxy.
+	Proxy string `json:"proxy,omitempty"`
+
+	mu   sync.Mutex
+	next time.Time
+}
+
+// Match returns a boolean value indicating whether the profile applies to
+// the given host name.
+func (p *Profile) Match(host string) bool {
+	host = strings.ToLower(host)
+	for _, pattern := range p.Hosts {
+		if ok, _ := path.Match(strings.ToLower(pattern), host); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Wait blocks until the delay since the previous request to the profile
+// hosts has passed, and reserves the next slot. Returns the context error
+// when the context is done first.
+func (p *Profile) Wait(ctx context.Context) error {
+	if p.Delay <= 0 {
+		return nil
+	}
+	p.mu.Lock()
+	now := time.Now()
+	at := p.next
+	if at.Before(now) {
+		at = now
+	}
+	p.next = at.Add(time.Duration(p.Delay))
+	p.mu.Unlock()
+
+	if wait := at.Sub(now); wait > 0 {
+		t := time.NewTimer(wait)
+		defer t.Stop()
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Set is a list of profiles.
+type Set struct {
+	// Profiles are matched in order, so the first profile matching a host
+	// applies to it.
+	Profiles []*Profile `json:"profiles"`
+}
+
+// Match returns the first profile matching the given host name, or nil.
+func (s *Set) Match(host string) *Profile {
+	if s == nil {
+		return nil
+	}
+	for _, p := range s.Profiles {
+		if p.Match(host) {
+			return p
+		}
+	}
+	return nil
+}
+
+// Load decodes a JSON set of profiles, eg:
+//
+//	{"profiles": [{
+//		"name": "example",
+//		"hosts": ["example.com", "*.example.com"],
+//		"headers": {"Accept-Language": "en"},
+//		"username": "user",
+//		"password": "secret",
+//		"delay": "2s"
+//	}]}
+func Load(r io.Reader) (*Set, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return decode(b, json.Unmarshal)
+}
+
+// LoadFile decodes the set of profiles saved in the given file, with the
+// decoder registered for its extension in Decoders.
+func LoadFile(file string) (*Set, error) {
+	b, dec, err := util.ReadConfigFile(file, "profile")
+	if err != nil {
+		return nil, err
+	}
+	return decode(b, dec)
+}
+
+// decode unmarshals the profiles and checks they are valid.
+func decode(b []byte, dec func([]byte, interface{}) error) (*Set, error) {
+	s := &Set{}
+	if err := dec(b, s); err != nil {
+		return nil, err
+	}
+	for i, p := range s.Profiles {
+		if p == nil || len(p.Hosts) == 0 {
+			return nil, errors.New("The profile %d has no hosts.", i)
+		}
+		for _, pattern := range p.Hosts {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, errors.New("Invalid host pattern '%s' in the profile '%s'.", pattern, p.Name)
+			}
+		}
+	}
+	return s, nil
+}
@@ -0,0 +1,120 @@
+package profiles
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const config = `{"profiles": [
+	{"name": "api", "hosts": ["api.example.com"], "delay": "20ms", "proxy": "socks5://127.0.0.1:1080"},
+	{"name": "example", "hosts": ["example.com", "*.example.com"],
+	 "headers": {"Accept-Language": "fr"}, "cookies": [{"name": "lang", "value": "fr"}],
+	 "username": "user", "password": "secret"}
+]}`
+
+func TestLoad(t *testing.T) {
+	s, err := Load(strings.NewReader(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Profiles) != 2 {
+		t.Fatalf("Expected 2 profiles, got %d", len(s.Profiles))
+	}
+	if d := time.Duration(s.Profiles[0].Delay); d != 20*time.Millisecond {
+		t.Errorf("Expected a 20ms delay, got %s", d)
+	}
+
+	tests := map[string]string{
+		"api.example.com": "api",
+		"WWW.Example.com": "example",
+		"example.com":     "example",
+		"example.org":     "",
+	}
+	for host, name := range tests {
+		p := s.Match(host)
+		if (p == nil && name != "") || (p != nil && p.Name != name) {
+			t.Errorf("Expected %s to match the profile %q, got %+v", host, name, p)
+		}
+	}
+	var nilSet *Set
+	if nilSet.Match("example.com") != nil {
+		t.Error("Expected a nil set to match nothing")
+	}
+
+	if _, err := Load(strings.NewReader(`{"profiles": [{"name": "empty"}]}`)); err == nil {
+		t.Error("Expected an error for a profile without hosts")
+	}
+	if _, err := Load(strings.NewReader(`{"profiles": [{"hosts": ["["]}]}`)); err == nil {
+		t.Error("Expected an error for an invalid host pattern")
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "surf-profiles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "sites.json")
+	if err := ioutil.WriteFile(file, []byte(config), 0600); err != nil {
+		t.Fatal(err)
+	}
+	s, err := LoadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Match("example.com") == nil {
+		t.Error("Expected the loaded profiles to match example.com")
+	}
+	if _, err := LoadFile(filepath.Join(dir, "sites.ini")); err == nil {
+		t.Error("Expected an error without an INI decoder")
+	}
+
+	file = filepath.Join(dir, "sites.yaml")
+	yml := `
+profiles:
+  - hosts: ["*.example.org"]
+    headers:
+      X-Token: secret
+    delay: 1.5s
+`
+	if err := ioutil.WriteFile(file, []byte(yml), 0600); err != nil {
+		t.Fatal(err)
+	}
+	s, err = LoadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := s.Match("www.example.org")
+	if p == nil {
+		t.Fatal("Expected the YAML profiles to match www.example.org")
+	}
+	if p.Headers["X-Token"] != "secret" || time.Duration(p.Delay) != 1500*time.Millisecond {
+		t.Errorf("Unexpected YAML profile %+v", p)
+	}
+}
+
+func TestWait(t *testing.T) {
+	p := &Profile{Delay: Duration(30 * time.Millisecond)}
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if err := p.Wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if d := time.Since(start); d < 60*time.Millisecond {
+		t.Errorf("Expected the requests to be spaced by the delay, took %s", d)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := p.Wait(ctx); err != context.Canceled {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
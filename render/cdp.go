@@ -0,0 +1,220 @@
+package render
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lostinblue/surf/errors"
+	"golang.org/x/net/websocket"
+)
+
+// DefaultCDPEndpoint is the address of a Chrome started with --remote-debugging-port=9222.
+var DefaultCDPEndpoint = "http://127.0.0.1:9222"
+
+// CDP renders pages with a headless Chrome, or any browser speaking the
+// Chrome DevTools Protocol, eg one started with:
+//
+//	chrome --headless --remote-debugging-port=9222
+//
+// Each page is rendered in a new tab, which is closed afterwards.
+type CDP struct {
+	// Endpoint is the HTTP address of the debugging port. Defaults to DefaultCDPEndpoint.
+	Endpoint string
+
+	// Timeout is the time limit for loading and rendering a page, or 0 for no limit.
+	Timeout time.Duration
+}
+
+// NewCDP creates and returns a new *CDP type for the given endpoint.
+func NewCDP(endpoint string) *CDP {
+	return &CDP{
+		Endpoint: endpoint,
+		Timeout:  30 * time.Second,
+	}
+}
+
+// cdpTarget is a tab returned by the /json/new endpoint.
+type cdpTarget struct {
+	ID                   string `json:"id"`
+	WebSocketDebuggerURL string `json:"webSocketDebuggerUrl"`
+}
+
+// cdpMessage is a command, response or event sent over the debugger connection.
+type cdpMessage struct {
+	ID     int                    `json:"id,omitempty"`
+	Method string                 `json:"method,omitempty"`
+	Params map[string]interface{} `json:"params,omitempty"`
+	Result json.RawMessage        `json:"result,omitempty"`
+	Error  *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+// Render fetches and renders the given page in the given format, and writes
+// the result to w.
+func (r *CDP) Render(p *Page, format Format, w io.Writer) error {
+	endpoint := strings.TrimRight(r.Endpoint, "/")
+	if endpoint == "" {
+		endpoint = DefaultCDPEndpoint
+	}
+	target, err := r.newTarget(endpoint)
+	if err != nil {
+		return err
+	}
+	defer r.closeTarget(endpoint, target.ID)
+
+	ws, err := websocket.Dial(target.WebSocketDebuggerURL, "", endpoint)
+	if err != nil {
+		return err
+	}
+	defer ws.Close()
+	if r.Timeout > 0 {
+		ws.SetDeadline(time.Now().Add(r.Timeout))
+	}
+	c := &cdpConn{ws: ws}
+
+	if _, err = c.call("Page.enable", nil); err != nil {
+		return err
+	}
+	if len(p.Cookies) > 0 {
+		cookies := make([]map[string]interface{}, 0, len(p.Cookies))
+		for _, ck := range p.Cookies {
+			cookies = append(cookies, map[string]interface{}{
+				"name":  ck.Name,
+				"value": ck.Value,
+				"url":   p.URL,
+			})
+		}
+		if _, err = c.call("Network.setCookies", map[string]interface{}{"cookies": cookies}); err != nil {
+			return err
+		}
+	}
+	if len(p.Header) > 0 {
+		headers := make(map[string]interface{}, len(p.Header))
+		for name := range p.Header {
+			headers[name] = strings.Join(p.Header[name], ", ")
+		}
+		if ua := p.Header.Get("User-Agent"); ua != "" {
+			if _, err = c.call("Network.setUserAgentOverride", map[string]interface{}{"userAgent": ua}); err != nil {
+				return err
+			}
+		}
+		if _, err = c.call("Network.setExtraHTTPHeaders", map[string]interface{}{"headers": headers}); err != nil {
+			return err
+		}
+	}
+	if _, err = c.call("Page.navigate", map[string]interface{}{"url": p.URL}); err != nil {
+		return err
+	}
+	if err = c.wait("Page.loadEventFired"); err != nil {
+		return err
+	}
+
+	method, params := "Page.captureScreenshot", map[string]interface{}{
+		"format":                "png",
+		"captureBeyondViewport": true,
+	}
+	if format == PDF {
+		method, params = "Page.printToPDF", map[string]interface{}{"printBackground": true}
+	}
+	res, err := c.call(method, params)
+	if err != nil {
+		return err
+	}
+	var out struct {
+		Data string `json:"data"`
+	}
+	if err = json.Unmarshal(res, &out); err != nil {
+		return err
+	}
+	data, err := base64.StdEncoding.DecodeString(out.Data)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// newTarget opens a new blank tab.
+func (r *CDP) newTarget(endpoint string) (*cdpTarget, error) {
+	req, err := http.NewRequest("PUT", endpoint+"/json/new?about:blank", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := (&http.Client{Timeout: r.Timeout}).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New("Failed to open a renderer tab: %s", resp.Status)
+	}
+	target := &cdpTarget{}
+	if err = json.NewDecoder(resp.Body).Decode(target); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+// closeTarget closes the tab with the given id.
+func (r *CDP) closeTarget(endpoint, id string) {
+	resp, err := (&http.Client{Timeout: r.Timeout}).Get(endpoint + "/json/close/" + id)
+	if err == nil {
+		resp.Body.Close()
+	}
+}
+
+// cdpConn sends commands over a debugger connection.
+type cdpConn struct {
+	ws     *websocket.Conn
+	nextID int
+	events []string
+}
+
+// call sends the command and returns its result, recording the events
+// received while waiting for it.
+func (c *cdpConn) call(method string, params map[string]interface{}) (json.RawMessage, error) {
+	c.nextID++
+	if err := websocket.JSON.Send(c.ws, &cdpMessage{ID: c.nextID, Method: method, Params: params}); err != nil {
+		return nil, err
+	}
+	for {
+		msg := &cdpMessage{}
+		if err := websocket.JSON.Receive(c.ws, msg); err != nil {
+			return nil, err
+		}
+		if msg.ID == 0 {
+			c.events = append(c.events, msg.Method)
+			continue
+		}
+		if msg.ID != c.nextID {
+			continue
+		}
+		if msg.Error != nil {
+			return nil, errors.New("%s failed: %s", method, msg.Error.Message)
+		}
+		return msg.Result, nil
+	}
+}
+
+// wait blocks until the event with the given name is received.
+func (c *cdpConn) wait(event string) error {
+	for _, e := range c.events {
+		if e == event {
+			return nil
+		}
+	}
+	for {
+		msg := &cdpMessage{}
+		if err := websocket.JSON.Receive(c.ws, msg); err != nil {
+			return err
+		}
+		if msg.Method == event {
+			return nil
+		}
+	}
+}
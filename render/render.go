@@ -0,0 +1,49 @@
+// Package render hands the current page of a browser off to an external
+// renderer, such as wkhtmltoimage or a headless Chrome, to produce images and
+// PDF documents of it.
+package render
+
+import (
+	"io"
+	"net/http"
+)
+
+// Format is the output format of a renderer.
+type Format int
+
+const (
+	// PNG renders the page as a PNG image.
+	PNG Format = iota
+
+	// PDF renders the page as a PDF document.
+	PDF
+)
+
+// String returns the name of the format, eg "png".
+func (f Format) String() string {
+	if f == PDF {
+		return "pdf"
+	}
+	return "png"
+}
+
+// Page describes the page to render, along with the state needed to fetch it
+// the way the browser did.
+type Page struct {
+	// URL is the address of the page.
+	URL string
+
+	// Cookies are the cookies the browser sends to the page.
+	Cookies []*http.Cookie
+
+	// Header contains the headers the browser sends with each request,
+	// including the User-Agent.
+	Header http.Header
+}
+
+// Renderer renders pages to images or PDF documents.
+type Renderer interface {
+	// Render fetches and renders the given page in the given format, and
+	// writes the result to w.
+	Render(p *Page, format Format, w io.Writer) error
+}
@@ -0,0 +1,100 @@
+package render
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/lostinblue/ut"
+	"golang.org/x/net/websocket"
+)
+
+func TestWkhtmlArgs(t *testing.T) {
+	ut.Run(t)
+
+	r := NewWkhtml()
+	r.Args = []string{"--width", "1280"}
+	p := &Page{
+		URL:     "https://example.com",
+		Cookies: []*http.Cookie{{Name: "session", Value: "abc"}},
+		Header:  http.Header{"User-Agent": {"Surf"}},
+	}
+	ut.AssertEquals("wkhtmltoimage", r.path(PNG))
+	ut.AssertEquals("wkhtmltopdf", r.path(PDF))
+	ut.AssertEquals([]string{
+		"--quiet", "--format", "png",
+		"--cookie-jar", "/tmp/jar",
+		"--custom-header", "User-Agent", "Surf", "--custom-header-propagation",
+		"--width", "1280",
+		"https://example.com", "-",
+	}, r.args(p, PNG, "/tmp/jar"))
+
+	jar, err := writeCookieJar(p)
+	ut.AssertNil(err)
+	defer os.Remove(jar)
+	b, err := ioutil.ReadFile(jar)
+	ut.AssertNil(err)
+	ut.AssertEquals("session=abc; Path=/; Domain=example.com\n", string(b))
+
+	jar, err = writeCookieJar(&Page{URL: "https://example.com"})
+	ut.AssertNil(err)
+	ut.AssertEquals("", jar)
+}
+
+func TestCDP(t *testing.T) {
+	ut.Run(t)
+
+	var methods []string
+	var server *httptest.Server
+	mux := http.NewServeMux()
+	mux.HandleFunc("/json/new", func(w http.ResponseWriter, r *http.Request) {
+		ws := "ws" + strings.TrimPrefix(server.URL, "http") + "/devtools/page/1"
+		json.NewEncoder(w).Encode(map[string]string{"id": "1", "webSocketDebuggerUrl": ws})
+	})
+	mux.HandleFunc("/json/close/1", func(w http.ResponseWriter, r *http.Request) {
+		methods = append(methods, "close")
+	})
+	mux.Handle("/devtools/page/1", websocket.Handler(func(ws *websocket.Conn) {
+		for {
+			msg := &cdpMessage{}
+			if err := websocket.JSON.Receive(ws, msg); err != nil {
+				return
+			}
+			methods = append(methods, msg.Method)
+			result := json.RawMessage(`{}`)
+			switch msg.Method {
+			case "Page.navigate":
+				websocket.JSON.Send(ws, &cdpMessage{Method: "Page.loadEventFired"})
+			case "Page.captureScreenshot":
+				result = json.RawMessage(`{"data":"` + base64.StdEncoding.EncodeToString([]byte("PNG")) + `"}`)
+			}
+			websocket.JSON.Send(ws, &cdpMessage{ID: msg.ID, Result: result})
+		}
+	}))
+	server = httptest.NewServer(mux)
+	defer server.Close()
+
+	buff := &bytes.Buffer{}
+	err := NewCDP(server.URL).Render(&Page{
+		URL:     "https://example.com",
+		Cookies: []*http.Cookie{{Name: "session", Value: "abc"}},
+		Header:  http.Header{"User-Agent": {"Surf"}},
+	}, PNG, buff)
+	ut.AssertNil(err)
+	ut.AssertEquals("PNG", buff.String())
+	ut.AssertEquals([]string{
+		"Page.enable",
+		"Network.setCookies",
+		"Network.setUserAgentOverride",
+		"Network.setExtraHTTPHeaders",
+		"Page.navigate",
+		"Page.captureScreenshot",
+		"close",
+	}, methods)
+}
@@ -0,0 +1,125 @@
+package render
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+// Wkhtml renders pages with the wkhtmltoimage and wkhtmltopdf commands.
+// See https://wkhtmltopdf.org.
+type Wkhtml struct {
+	// ImagePath is the path of the wkhtmltoimage command.
+	ImagePath string
+
+	// PDFPath is the path of the wkhtmltopdf command.
+	PDFPath string
+
+	// Args are additional arguments passed to the command, eg "--width", "1280".
+	Args []string
+}
+
+// NewWkhtml creates and returns a new *Wkhtml type which finds the commands
+// in the PATH.
+func NewWkhtml() *Wkhtml {
+	return &Wkhtml{
+		ImagePath: "wkhtmltoimage",
+		PDFPath:   "wkhtmltopdf",
+	}
+}
+
+// Render fetches and renders the given page in the given format, and writes
+// the result to w.
+//
+// The cookies are passed to the command in a temporary cookie jar file,
+// rather than as arguments, which other users of the system could read.
+func (r *Wkhtml) Render(p *Page, format Format, w io.Writer) error {
+	jar, err := writeCookieJar(p)
+	if err != nil {
+		return err
+	}
+	if jar != "" {
+		defer os.Remove(jar)
+	}
+	cmd := exec.Command(r.path(format), r.args(p, format, jar)...)
+	stderr := &bytes.Buffer{}
+	cmd.Stdout = w
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return errors.New("Failed to render %s: %s %s", p.URL, err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
+
+// writeCookieJar writes the cookies of the page to a temporary file, one
+// Set-Cookie line per cookie as read by the --cookie-jar option, and returns
+// its path. Returns an empty path when the page has no cookies.
+func writeCookieJar(p *Page) (string, error) {
+	if len(p.Cookies) == 0 {
+		return "", nil
+	}
+	host := ""
+	if u, err := url.Parse(p.URL); err == nil {
+		host = u.Hostname()
+	}
+	f, err := ioutil.TempFile("", "surf-cookies-")
+	if err != nil {
+		return "", err
+	}
+	for _, c := range p.Cookies {
+		jc := *c
+		if jc.Domain == "" {
+			jc.Domain = host
+		}
+		if jc.Path == "" {
+			jc.Path = "/"
+		}
+		if _, err = io.WriteString(f, jc.String()+"\n"); err != nil {
+			break
+		}
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// path returns the command used to render the given format.
+func (r *Wkhtml) path(format Format) string {
+	if format == PDF {
+		return r.PDFPath
+	}
+	return r.ImagePath
+}
+
+// args returns the command arguments used to render the page, which write
+// the result to stdout. The cookies are read from the jar file, if any.
+func (r *Wkhtml) args(p *Page, format Format, jar string) []string {
+	args := []string{"--quiet"}
+	if format == PNG {
+		args = append(args, "--format", "png")
+	}
+	if jar != "" {
+		args = append(args, "--cookie-jar", jar)
+	}
+	for name, values := range p.Header {
+		for _, v := range values {
+			args = append(args, "--custom-header", name, v)
+		}
+	}
+	if len(p.Header) > 0 {
+		args = append(args, "--custom-header-propagation")
+	}
+	args = append(args, r.Args...)
+	return append(args, p.URL, "-")
+}
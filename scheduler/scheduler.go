@@ -0,0 +1,397 @@
+// Package scheduler crawls queues of URLs with a pool of browser tabs.
+package scheduler
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lostinblue/surf/browser"
+	"github.com/lostinblue/surf/errors"
+	"github.com/lostinblue/surf/notify"
+	"github.com/lostinblue/surf/urlnorm"
+)
+
+// DefaultWorkers is the number of pages fetched concurrently by a new Scheduler.
+var DefaultWorkers = 4
+
+// Job is a URL waiting to be fetched.
+type Job struct {
+	// URL is the address of the page.
+	URL string
+
+	// Priority orders the queue. Jobs with a higher priority are fetched
+	// first, and jobs with the same priority in the order they were added.
+	Priority int
+}
+
+// Result is a fetched page.
+type Result struct {
+	// Job is the job which was fetched.
+	Job *Job
+
+	// Browser is the tab which fetched the page. Each job is fetched by a
+	// new tab of the scheduler browser, so it may be used freely.
+	Browser *browser.Browser
+
+	// Err is the error returned by the request, if any.
+	Err error
+}
+
+// Scheduler fetches queued URLs by priority with tabs of a browser, waiting
+// between requests to the same host.
+//
+// URLs are normalized before being queued, and each URL is fetched once.
+type Scheduler struct {
+	// Workers is the number of pages fetched concurrently.
+	Workers int
+
+	// Delay is the minimum time between two requests to the same host. Use
+	// SetCrawlDelay() to set the delay of a single host.
+	Delay time.Duration
+
+	// Follow is called with each fetched page before the result is sent,
+	// and returns the jobs to add to the queue, eg built from the page links.
+	// Jobs may also be added with Add() while the scheduler runs, but Run()
+	// stops as soon as the queue is empty.
+	Follow func(r *Result) []*Job
+
+	// Options is called before each fetch, and returns the options of the
+	// request, eg conditional headers for the pages fetched before.
+	Options func(job *Job) []browser.RequestOption
+
+	bow      *browser.Browser
+	mu       sync.Mutex
+	queue    jobQueue
+	seq      int
+	seen     map[string]bool
+	inflight map[string]*Job
+	done     []string
+	delays   map[string]time.Duration
+	next     map[string]time.Time
+	store    Store
+	wake     chan struct{}
+	spent    bool
+}
+
+// New creates and returns a new *Scheduler type which fetches pages with
+// tabs of the given browser.
+func New(bow *browser.Browser) *Scheduler {
+	return &Scheduler{
+		Workers:  DefaultWorkers,
+		bow:      bow,
+		seen:     make(map[string]bool),
+		inflight: make(map[string]*Job),
+		delays:   make(map[string]time.Duration),
+		next:     make(map[string]time.Time),
+		wake:     make(chan struct{}, 1),
+	}
+}
+
+// SetStore sets the store the queue is saved to after each change, and adds
+// the jobs saved by a previous run, so an interrupted crawl resumes where it
+// stopped. Pages which were being fetched when the crawl stopped are fetched again.
+func (s *Scheduler) SetStore(st Store) error {
+	state, err := st.Load()
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.store = st
+	for _, u := range state.Done {
+		s.seen[u] = true
+		s.done = append(s.done, u)
+	}
+	for _, job := range state.Pending {
+		s.push(job)
+	}
+	return s.save()
+}
+
+// SetCrawlDelay sets the minimum time between two requests to the given
+// host, eg the Crawl-delay of its robots.txt.
+func (s *Scheduler) SetCrawlDelay(host string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delays[strings.ToLower(host)] = d
+}
+
+// Add queues the given URL with the given priority. URLs which were already
+// queued or fetched are ignored.
+func (s *Scheduler) Add(u string, priority int) error {
+	n, err := urlnorm.NormalizeString(u)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.seen[n] {
+		return nil
+	}
+	job := &Job{URL: n, Priority: priority}
+	s.push(job)
+	s.signal()
+	return s.record([]*Job{job}, nil)
+}
+
+// Pending returns the number of jobs queued or being fetched.
+func (s *Scheduler) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.queue.Len() + len(s.inflight)
+}
+
+// Run fetches the queued jobs and sends the results on the returned channel,
+// which is closed once the queue is empty and every job has been fetched,
+// or the context is done.
+//
+// The next results are not fetched until the results are read, so the
+// channel must be drained.
+//
+// Run also stops once a fetch fails because the budget of the browser is
+// spent (see Browser.SetBudget()). The jobs left are kept in the queue, and
+// Run may be called again once a new budget is set to fetch them.
+func (s *Scheduler) Run(ctx context.Context) <-chan *Result {
+	s.mu.Lock()
+	s.spent = false
+	s.mu.Unlock()
+
+	workers := s.Workers
+	if workers < 1 {
+		workers = 1
+	}
+	results := make(chan *Result, workers)
+	tasks := make(chan *Result)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for r := range tasks {
+				s.fetch(ctx, r, results)
+			}
+		}()
+	}
+
+	go func() {
+		defer func() {
+			close(tasks)
+			wg.Wait()
+			close(results)
+		}()
+		for {
+			job, wait, idle := s.nextJob()
+			if idle {
+				s.finished()
+				return
+			}
+			if job != nil {
+				// Tabs are created here rather than by the workers, because
+				// creating a tab reads the state of the parent browser.
+				r := &Result{Job: job, Browser: s.bow.NewTab()}
+				select {
+				case tasks <- r:
+				case <-ctx.Done():
+					return
+				}
+				continue
+			}
+
+			// An empty queue leaves wait at 0, so only wake can end the select.
+			timer := time.NewTimer(wait)
+			if wait == 0 {
+				timer.Stop()
+			}
+			select {
+			case <-s.wake:
+				timer.Stop()
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			}
+		}
+	}()
+
+	return results
+}
+
+// finished notifies the browser notifier that every queued page was fetched,
+// unless the crawl stopped because the browser budget is spent.
+func (s *Scheduler) finished() {
+	s.mu.Lock()
+	pages, spent := len(s.done), s.spent
+	s.mu.Unlock()
+	if spent {
+		return
+	}
+	s.bow.Notify(&notify.Event{
+		Kind:    notify.CrawlFinished,
+		Message: fmt.Sprintf("The crawl fetched %d pages.", pages),
+		Data:    map[string]string{"pages": strconv.Itoa(pages)},
+	})
+}
+
+// fetch requests the page of the result, queues the jobs returned by
+// Follow, and sends the result.
+func (s *Scheduler) fetch(ctx context.Context, r *Result, results chan<- *Result) {
+	var opts []browser.RequestOption
+	if s.Options != nil {
+		opts = s.Options(r.Job)
+	}
+	r.Err = r.Browser.GET(r.Job.URL, opts...)
+	if be, ok := r.Err.(errors.BudgetExceeded); ok && be.Host == "" {
+		s.mu.Lock()
+		s.spent = true
+		s.mu.Unlock()
+	}
+	if r.Err == nil && s.Follow != nil {
+		for _, job := range s.Follow(r) {
+			s.Add(job.URL, job.Priority)
+		}
+	}
+	select {
+	case results <- r:
+	case <-ctx.Done():
+		return
+	}
+
+	s.mu.Lock()
+	delete(s.inflight, r.Job.URL)
+	s.done = append(s.done, r.Job.URL)
+	s.record(nil, []string{r.Job.URL})
+	s.mu.Unlock()
+	s.signal()
+}
+
+// nextJob removes the job with the highest priority whose host may be
+// requested now from the queue. When no job is ready, the time until one is
+// ready is returned instead, or 0 when the queue is empty. Returns true when
+// the queue is empty and no job is being fetched.
+func (s *Scheduler) nextJob() (*Job, time.Duration, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.queue.Len() == 0 || s.spent {
+		return nil, 0, len(s.inflight) == 0
+	}
+
+	now := time.Now()
+	var job *Job
+	var skipped []*queueItem
+	var wait time.Duration
+	for s.queue.Len() > 0 {
+		item := heap.Pop(&s.queue).(*queueItem)
+		host := jobHost(item.job)
+		if next := s.next[host]; next.After(now) {
+			if d := next.Sub(now); wait == 0 || d < wait {
+				wait = d
+			}
+			skipped = append(skipped, item)
+			continue
+		}
+		job = item.job
+		s.next[host] = now.Add(s.delay(host))
+		s.inflight[job.URL] = job
+		break
+	}
+	for _, item := range skipped {
+		heap.Push(&s.queue, item)
+	}
+	return job, wait, false
+}
+
+// delay returns the minimum time between two requests to the host.
+func (s *Scheduler) delay(host string) time.Duration {
+	if d, ok := s.delays[host]; ok {
+		return d
+	}
+	return s.Delay
+}
+
+// push adds the job to the queue and marks its URL as seen.
+func (s *Scheduler) push(job *Job) {
+	s.seen[job.URL] = true
+	s.seq++
+	heap.Push(&s.queue, &queueItem{job: job, seq: s.seq})
+}
+
+// signal wakes the dispatcher when it's waiting for jobs.
+func (s *Scheduler) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// record saves a change of the queue to the store, when one has been set.
+// The change is appended to a Journal, and the whole queue is saved to the
+// other stores.
+func (s *Scheduler) record(added []*Job, done []string) error {
+	if j, ok := s.store.(Journal); ok {
+		return j.Append(added, done)
+	}
+	return s.save()
+}
+
+// save writes the queue to the store, when one has been set.
+func (s *Scheduler) save() error {
+	if s.store == nil {
+		return nil
+	}
+	state := &State{
+		Pending: make([]*Job, 0, s.queue.Len()+len(s.inflight)),
+		Done:    s.done,
+	}
+	for _, job := range s.inflight {
+		state.Pending = append(state.Pending, job)
+	}
+	for _, item := range s.queue {
+		state.Pending = append(state.Pending, item.job)
+	}
+	return s.store.Save(state)
+}
+
+// jobHost returns the lowercase host name of the job URL.
+func jobHost(job *Job) string {
+	u, err := url.Parse(job.URL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// queueItem is a job in the queue, along with the order it was added in.
+type queueItem struct {
+	job *Job
+	seq int
+}
+
+// jobQueue is a heap of jobs ordered by priority.
+type jobQueue []*queueItem
+
+func (q jobQueue) Len() int { return len(q) }
+
+func (q jobQueue) Less(i, j int) bool {
+	if q[i].job.Priority != q[j].job.Priority {
+		return q[i].job.Priority > q[j].job.Priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q jobQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *jobQueue) Push(x interface{}) { *q = append(*q, x.(*queueItem)) }
+
+func (q *jobQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
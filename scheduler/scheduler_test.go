@@ -0,0 +1,231 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lostinblue/surf/browser"
+	"github.com/lostinblue/surf/jar"
+	"github.com/lostinblue/surf/notify"
+	"github.com/lostinblue/ut"
+)
+
+func newTestBrowser() *browser.Browser {
+	bow := &browser.Browser{}
+	bow.SetUserAgent("Surf")
+	bow.SetState(&jar.State{})
+	bow.SetCookieJar(jar.NewCookiesJar())
+	bow.SetBookmarksJar(jar.NewMemoryBookmarks())
+	bow.SetHistoryJar(jar.NewMemoryHistory())
+	bow.SetHeadersJar(jar.NewMemoryHeaders())
+	bow.SetAttributes(browser.AttributeMap{browser.FollowRedirects: true})
+	return bow
+}
+
+// newSiteServer serves pages linking to the next two pages, up to page 10.
+func newSiteServer(hits *sync.Map) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := 0
+		fmt.Sscanf(r.URL.Path, "/%d", &n)
+		count, _ := hits.LoadOrStore(r.URL.Path, new(int))
+		*count.(*int)++
+		fmt.Fprintf(w, `<html><body>`)
+		for i := n + 1; i <= n+2 && i <= 10; i++ {
+			fmt.Fprintf(w, `<a href="/%d">page %d</a>`, i, i)
+		}
+		fmt.Fprintf(w, `<a href="/%d#top">again</a></body></html>`, n)
+	}))
+}
+
+// followLinks returns a job for each link of the page.
+func followLinks(r *Result) []*Job {
+	var jobs []*Job
+	for _, l := range r.Browser.Links() {
+		jobs = append(jobs, &Job{URL: l.URL.String()})
+	}
+	return jobs
+}
+
+func TestSchedulerCrawl(t *testing.T) {
+	ut.Run(t)
+
+	hits := &sync.Map{}
+	ts := newSiteServer(hits)
+	defer ts.Close()
+
+	bow := newTestBrowser()
+	events := make(chan *notify.Event, 1)
+	bow.SetNotifier(notify.Channel(events))
+	s := New(bow)
+	s.Workers = 3
+	s.Follow = followLinks
+	ut.AssertNil(s.Add(ts.URL+"/0", 0))
+
+	count := 0
+	for r := range s.Run(context.Background()) {
+		ut.AssertNil(r.Err)
+		count++
+	}
+	ut.AssertEquals(11, count)
+	ut.AssertEquals(0, s.Pending())
+	e := <-events
+	ut.AssertEquals(notify.CrawlFinished, e.Kind)
+	ut.AssertEquals("11", e.Data["pages"])
+	hits.Range(func(_, v interface{}) bool {
+		ut.AssertEquals(1, *v.(*int))
+		return true
+	})
+}
+
+func TestSchedulerPriority(t *testing.T) {
+	ut.Run(t)
+
+	ts := newSiteServer(&sync.Map{})
+	defer ts.Close()
+
+	s := New(newTestBrowser())
+	s.Workers = 1
+	s.Add(ts.URL+"/1", 1)
+	s.Add(ts.URL+"/2", 5)
+	s.Add(ts.URL+"/3", 3)
+	s.Add(ts.URL+"/4", 3)
+
+	var order []string
+	for r := range s.Run(context.Background()) {
+		order = append(order, strings.TrimPrefix(r.Job.URL, ts.URL))
+	}
+	ut.AssertEquals([]string{"/2", "/3", "/4", "/1"}, order)
+}
+
+func TestSchedulerCrawlDelay(t *testing.T) {
+	ut.Run(t)
+
+	ts := newSiteServer(&sync.Map{})
+	defer ts.Close()
+
+	s := New(newTestBrowser())
+	s.SetCrawlDelay("127.0.0.1", 50*time.Millisecond)
+	for i := 1; i <= 3; i++ {
+		s.Add(fmt.Sprintf("%s/%d", ts.URL, i), 0)
+	}
+	start := time.Now()
+	for range s.Run(context.Background()) {
+	}
+	ut.AssertGreaterThan(99, int(time.Since(start)/time.Millisecond))
+}
+
+func TestSchedulerResume(t *testing.T) {
+	ut.Run(t)
+
+	ts := newSiteServer(&sync.Map{})
+	defer ts.Close()
+	defer os.Remove("./crawl.json")
+
+	s := New(newTestBrowser())
+	s.Workers = 1
+	s.Follow = followLinks
+	ut.AssertNil(s.SetStore(NewFileStore("./crawl.json")))
+	s.Add(ts.URL+"/0", 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	fetched := make(map[string]bool)
+	for r := range s.Run(ctx) {
+		fetched[r.Job.URL] = true
+		if len(fetched) == 3 {
+			cancel()
+			break
+		}
+	}
+	cancel()
+
+	s = New(newTestBrowser())
+	s.Follow = followLinks
+	ut.AssertNil(s.SetStore(NewFileStore("./crawl.json")))
+	ut.AssertGreaterThan(0, s.Pending())
+	for r := range s.Run(context.Background()) {
+		fetched[r.Job.URL] = true
+	}
+	ut.AssertEquals(11, len(fetched))
+}
+
+func TestFileStoreJournal(t *testing.T) {
+	ut.Run(t)
+	defer os.Remove("./journal.json")
+
+	st := NewFileStore("./journal.json")
+	ut.AssertNil(st.Save(&State{Pending: []*Job{{URL: "http://a/0"}}}))
+	ut.AssertNil(st.Append([]*Job{{URL: "http://a/1"}, {URL: "http://a/2"}}, nil))
+	ut.AssertNil(st.Append(nil, []string{"http://a/0"}))
+	ut.AssertNil(st.Append(nil, []string{"http://a/2"}))
+
+	state, err := st.Load()
+	ut.AssertNil(err)
+	ut.AssertEquals(1, len(state.Pending))
+	ut.AssertEquals("http://a/1", state.Pending[0].URL)
+	ut.AssertEquals(2, len(state.Done))
+}
+
+func TestSchedulerOptions(t *testing.T) {
+	ut.Run(t)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, r.Header.Get("X-Job"))
+	}))
+	defer ts.Close()
+
+	s := New(newTestBrowser())
+	s.Options = func(job *Job) []browser.RequestOption {
+		return []browser.RequestOption{browser.WithHeader("X-Job", strings.TrimPrefix(job.URL, ts.URL))}
+	}
+	s.Add(ts.URL+"/a", 0)
+	s.Add(ts.URL+"/b", 0)
+	for r := range s.Run(context.Background()) {
+		ut.AssertNil(r.Err)
+		var body strings.Builder
+		r.Browser.WriteTo(&body)
+		ut.AssertEquals(strings.TrimPrefix(r.Job.URL, ts.URL), body.String())
+	}
+}
+
+func TestSchedulerBudget(t *testing.T) {
+	ut.Run(t)
+
+	ts := newSiteServer(&sync.Map{})
+	defer ts.Close()
+
+	bow := newTestBrowser()
+	bow.SetBudget(browser.Budget{MaxRequests: 3})
+	events := make(chan *notify.Event, 1)
+	bow.SetNotifier(notify.Channel(events))
+	s := New(bow)
+	s.Workers = 1
+	s.Follow = followLinks
+	s.Add(ts.URL+"/0", 0)
+
+	fetched := 0
+	for r := range s.Run(context.Background()) {
+		if r.Err == nil {
+			fetched++
+		}
+	}
+	ut.AssertEquals(3, fetched)
+	ut.AssertGreaterThan(0, s.Pending())
+	ut.AssertEquals(0, len(events))
+
+	// A new budget resumes the crawl.
+	bow.SetBudget(browser.Budget{MaxRequests: 2})
+	fetched = 0
+	for r := range s.Run(context.Background()) {
+		if r.Err == nil {
+			fetched++
+		}
+	}
+	ut.AssertEquals(2, fetched)
+}
@@ -0,0 +1,136 @@
+package scheduler
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/lostinblue/surf/util"
+)
+
+// State is the saved queue of a scheduler.
+type State struct {
+	// Pending contains the jobs queued or being fetched.
+	Pending []*Job
+
+	// Done contains the URLs which have been fetched.
+	Done []string
+}
+
+// Store saves the queue of a scheduler so interrupted crawls may resume.
+type Store interface {
+	// Load returns the saved state, or an empty state when none was saved.
+	Load() (*State, error)
+
+	// Save replaces the saved state.
+	Save(state *State) error
+}
+
+// Journal is a Store which records the changes of the queue as they happen,
+// rather than saving the whole queue after each change, so a crawl of n pages
+// is saved in O(n) rather than O(n²). Save is still called to compact the
+// saved state, eg when the store is set.
+type Journal interface {
+	Store
+
+	// Append records a change of the queue: the jobs added to it, and the
+	// URLs which have been fetched.
+	Append(added []*Job, done []string) error
+}
+
+// FileStore is an implementation of Journal that saves to a file.
+//
+// The state is saved as a JSON string, followed by one JSON line per change.
+type FileStore struct {
+	file string
+}
+
+// NewFileStore creates and returns a new *FileStore type.
+func NewFileStore(file string) *FileStore {
+	return &FileStore{file: file}
+}
+
+// Load returns the saved state, or an empty state when the file does not exist.
+func (s *FileStore) Load() (*State, error) {
+	state := &State{}
+	if !util.FileExists(s.file) {
+		return state, nil
+	}
+	fin, err := os.Open(s.file)
+	if err != nil {
+		return nil, err
+	}
+	defer fin.Close()
+	dec := json.NewDecoder(fin)
+	if err = dec.Decode(state); err != nil {
+		return nil, err
+	}
+
+	fetched := make(map[string]bool, len(state.Done))
+	for _, u := range state.Done {
+		fetched[u] = true
+	}
+	for {
+		var c change
+		err = dec.Decode(&c)
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			// The last change may be truncated when the program was
+			// interrupted while appending it.
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		state.Pending = append(state.Pending, c.Added...)
+		for _, u := range c.Done {
+			fetched[u] = true
+			state.Done = append(state.Done, u)
+		}
+	}
+	pending := state.Pending[:0]
+	for _, job := range state.Pending {
+		if !fetched[job.URL] {
+			pending = append(pending, job)
+		}
+	}
+	state.Pending = pending
+	return state, nil
+}
+
+// Save replaces the saved state. The file is replaced atomically, so it's
+// not left truncated when the program is interrupted.
+func (s *FileStore) Save(state *State) error {
+	j, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	tmp := s.file + ".tmp"
+	if err = ioutil.WriteFile(tmp, j, os.FileMode(0600)); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.file)
+}
+
+// Append records a change of the queue at the end of the file.
+func (s *FileStore) Append(added []*Job, done []string) error {
+	j, err := json.Marshal(&change{Added: added, Done: done})
+	if err != nil {
+		return err
+	}
+	fout, err := os.OpenFile(s.file, os.O_WRONLY|os.O_APPEND|os.O_CREATE, os.FileMode(0600))
+	if err != nil {
+		return err
+	}
+	if _, err = fout.Write(append(j, '\n')); err != nil {
+		fout.Close()
+		return err
+	}
+	return fout.Close()
+}
+
+// change is a change of the queue appended to the file of a FileStore.
+type change struct {
+	Added []*Job   `json:",omitempty"`
+	Done  []string `json:",omitempty"`
+}
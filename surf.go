@@ -5,10 +5,35 @@ import (
 	"github.com/lostinblue/surf/browser"
 )
 
-// NewBrowser creates and returns a *browser.Browser type.
-func NewBrowser() *browser.Browser {
+// New creates and returns a *browser.Browser type configured with the
+// options, eg:
+//
+//	bow, err := surf.New(
+//		surf.WithUserAgent("mybot/1.0"),
+//		surf.WithTimeout(10*time.Second),
+//		surf.WithProxy("socks5://localhost:1080"),
+//	)
+//
+// Returns the error of the first option which fails.
+func New(opts ...Option) (*browser.Browser, error) {
 	bow := &browser.Browser{}
 	//# TODO: All this initializing feels like it should be inside Browser init() function
 	bow.Initialize()
+	for _, opt := range opts {
+		if err := opt(bow); err != nil {
+			return nil, err
+		}
+	}
+	return bow, nil
+}
+
+// NewBrowser creates and returns a *browser.Browser type configured with
+// the options. It panics when an option fails, eg with an invalid proxy
+// URL; use New() to handle the error.
+func NewBrowser(opts ...Option) *browser.Browser {
+	bow, err := New(opts...)
+	if err != nil {
+		panic(err)
+	}
 	return bow
 }
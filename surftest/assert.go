@@ -0,0 +1,80 @@
+package surftest
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/lostinblue/surf/browser"
+)
+
+// AssertStatus fails the test when the status code of the page is not want.
+func AssertStatus(t testing.TB, bow *browser.Browser, want int) {
+	t.Helper()
+	if got := bow.StatusCode(); got != want {
+		t.Errorf("Expected the status %d, got %d for %s", want, got, bow.URL())
+	}
+}
+
+// AssertTitle fails the test when the title of the page is not want.
+func AssertTitle(t testing.TB, bow *browser.Browser, want string) {
+	t.Helper()
+	if got := bow.Title(); got != want {
+		t.Errorf("Expected the title %q, got %q for %s", want, got, bow.URL())
+	}
+}
+
+// AssertURL fails the test when the URL of the page is not want, eg after
+// following redirects.
+func AssertURL(t testing.TB, bow *browser.Browser, want string) {
+	t.Helper()
+	got := ""
+	if u := bow.URL(); u != nil {
+		got = u.String()
+	}
+	if got != want {
+		t.Errorf("Expected the URL %s, got %s", want, got)
+	}
+}
+
+// AssertContains fails the test when the page body does not contain text.
+func AssertContains(t testing.TB, bow *browser.Browser, text string) {
+	t.Helper()
+	if !strings.Contains(bow.Body(), text) {
+		t.Errorf("Expected the page %s to contain %q", bow.URL(), text)
+	}
+}
+
+// AssertExists fails the test when no element matches the selector.
+func AssertExists(t testing.TB, bow *browser.Browser, selector string) {
+	t.Helper()
+	if bow.Find(selector).Length() == 0 {
+		t.Errorf("Expected an element matching %q on %s", selector, bow.URL())
+	}
+}
+
+// AssertNotExists fails the test when an element matches the selector.
+func AssertNotExists(t testing.TB, bow *browser.Browser, selector string) {
+	t.Helper()
+	if n := bow.Find(selector).Length(); n > 0 {
+		t.Errorf("Expected no element matching %q on %s, got %d", selector, bow.URL(), n)
+	}
+}
+
+// AssertCookie fails the test when the browser has no cookie with the given
+// name and value for the site of the page.
+func AssertCookie(t testing.TB, bow *browser.Browser, name, value string) {
+	t.Helper()
+	if bow.URL() == nil {
+		t.Errorf("Expected the cookie %s, but no page has been loaded", name)
+		return
+	}
+	for _, c := range bow.SiteCookies() {
+		if c.Name == name {
+			if c.Value != value {
+				t.Errorf("Expected the cookie %s to be %q, got %q", name, value, c.Value)
+			}
+			return
+		}
+	}
+	t.Errorf("Expected the cookie %s for %s", name, bow.URL().Host)
+}
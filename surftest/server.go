@@ -0,0 +1,228 @@
+// Package surftest provides a fixture HTTP server and browser assertions,
+// so scrapers built with Surf can be tested without network access.
+//
+//	srv := surftest.NewServer()
+//	defer srv.Close()
+//	srv.Page("/", "<html><head><title>Home</title></head></html>")
+//	srv.Redirect("/old", "/", http.StatusMovedPermanently)
+//
+//	bow := surf.NewBrowser()
+//	bow.GET(srv.URL("/old"))
+//	surftest.AssertTitle(t, bow, "Home")
+package surftest
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"html"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Route is the response of the server for a path.
+type Route struct {
+	// Status is the status code. Defaults to 200.
+	Status int
+
+	// Body is the response body.
+	Body string
+
+	// ContentType defaults to "text/html; charset=utf-8".
+	ContentType string
+
+	// Headers are added to the response.
+	Headers map[string]string
+
+	// Cookies are set by the response.
+	Cookies []*http.Cookie
+
+	// Delay is the time waited before responding, eg to test timeouts.
+	Delay time.Duration
+
+	// Gzip compresses the body, with the Content-Encoding header.
+	Gzip bool
+
+	// Handler handles the requests instead, when it's not nil.
+	Handler http.HandlerFunc
+}
+
+// Request is a request received by the server.
+type Request struct {
+	Method string
+	Path   string
+	Query  url.Values
+	Header http.Header
+
+	// Form holds the submitted form values, of the body and the query.
+	Form url.Values
+
+	// Cookies are the cookies sent with the request.
+	Cookies []*http.Cookie
+}
+
+// Server is an httptest.Server serving the configured routes, and recording
+// the requests it receives. Paths without a route return 404.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	routes   map[string]*Route
+	requests []*Request
+}
+
+// NewServer starts and returns a new *Server type.
+func NewServer() *Server {
+	s := &Server{routes: make(map[string]*Route)}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	return s
+}
+
+// URL returns the absolute URL of the given path.
+func (s *Server) URL(path string) string {
+	return s.Server.URL + path
+}
+
+// Handle sets the route of the path, replacing any previous route.
+func (s *Server) Handle(path string, r Route) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.routes[path] = &r
+}
+
+// Page serves the HTML page at the path.
+func (s *Server) Page(path, body string) {
+	s.Handle(path, Route{Body: body})
+}
+
+// Redirect redirects the path to the given URL, which may be relative, with
+// the given status code, eg http.StatusFound.
+func (s *Server) Redirect(path, to string, code int) {
+	s.Handle(path, Route{Handler: func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, to, code)
+	}})
+}
+
+// Form serves a page at the path with a form posting the named text fields
+// to the action path. The action responds with a page listing the submitted
+// values in a dl element, eg <dt>name</dt><dd>value</dd>, sorted by name.
+func (s *Server) Form(path, action string, fields ...string) {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<html><head><title>Form</title></head><body><form method="post" action="%s">`, html.EscapeString(action))
+	for _, f := range fields {
+		fmt.Fprintf(&b, `<input type="text" name="%s">`, html.EscapeString(f))
+	}
+	b.WriteString(`<input type="submit" name="submit" value="Submit"></form></body></html>`)
+	s.Page(path, b.String())
+
+	s.Handle(action, Route{Handler: func(w http.ResponseWriter, r *http.Request) {
+		r.ParseMultipartForm(32 << 20)
+		names := make([]string, 0, len(r.Form))
+		for name := range r.Form {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		fmt.Fprint(w, `<html><head><title>Submitted</title></head><body><dl>`)
+		for _, name := range names {
+			for _, v := range r.Form[name] {
+				fmt.Fprintf(w, "<dt>%s</dt><dd>%s</dd>", html.EscapeString(name), html.EscapeString(v))
+			}
+		}
+		fmt.Fprint(w, `</dl></body></html>`)
+	}})
+}
+
+// Requests returns the requests received by the server, in order.
+func (s *Server) Requests() []*Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*Request(nil), s.requests...)
+}
+
+// LastRequest returns the last request received by the server, or nil.
+func (s *Server) LastRequest() *Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.requests) == 0 {
+		return nil
+	}
+	return s.requests[len(s.requests)-1]
+}
+
+// Reset removes the routes and the recorded requests.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.routes = make(map[string]*Route)
+	s.requests = nil
+}
+
+// serve records the request and writes the response of its route.
+func (s *Server) serve(w http.ResponseWriter, r *http.Request) {
+	// The body is read once for the record, and restored for the handler.
+	body, _ := ioutil.ReadAll(r.Body)
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	rec := &Request{
+		Method:  r.Method,
+		Path:    r.URL.Path,
+		Query:   r.URL.Query(),
+		Header:  r.Header.Clone(),
+		Cookies: r.Cookies(),
+	}
+	parsed := r.Clone(r.Context())
+	parsed.Body = ioutil.NopCloser(bytes.NewReader(body))
+	parsed.ParseMultipartForm(32 << 20)
+	rec.Form = parsed.Form
+
+	s.mu.Lock()
+	s.requests = append(s.requests, rec)
+	route, ok := s.routes[r.URL.Path]
+	s.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if route.Delay > 0 {
+		select {
+		case <-time.After(route.Delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if route.Handler != nil {
+		route.Handler(w, r)
+		return
+	}
+
+	for name, value := range route.Headers {
+		w.Header().Set(name, value)
+	}
+	for _, c := range route.Cookies {
+		http.SetCookie(w, c)
+	}
+	ct := route.ContentType
+	if ct == "" {
+		ct = "text/html; charset=utf-8"
+	}
+	w.Header().Set("Content-Type", ct)
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if route.Gzip {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.WriteHeader(status)
+		gz := gzip.NewWriter(w)
+		gz.Write([]byte(route.Body))
+		gz.Close()
+		return
+	}
+	w.WriteHeader(status)
+	w.Write([]byte(route.Body))
+}
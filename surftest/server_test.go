@@ -0,0 +1,107 @@
+package surftest
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/lostinblue/surf"
+	"github.com/lostinblue/surf/browser"
+)
+
+// recorder is a testing.TB recording the failures of the assertions.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestServer(t *testing.T) {
+	srv := NewServer()
+	defer srv.Close()
+	srv.Page("/", `<html><head><title>Home</title></head><body><p class="intro">Hello</p></body></html>`)
+	srv.Redirect("/old", "/", http.StatusMovedPermanently)
+	srv.Handle("/login", Route{
+		Body:    `<html><head><title>Logged in</title></head></html>`,
+		Cookies: []*http.Cookie{{Name: "session", Value: "abc", Path: "/"}},
+		Gzip:    true,
+	})
+	srv.Handle("/slow", Route{Body: "slow", Delay: 200 * time.Millisecond})
+	srv.Handle("/error", Route{Status: http.StatusInternalServerError, Body: "oops", ContentType: "text/plain"})
+	srv.Form("/form", "/submit", "name", "email")
+
+	bow := surf.NewBrowser()
+	if err := bow.GET(srv.URL("/old")); err != nil {
+		t.Fatal(err)
+	}
+	AssertURL(t, bow, srv.URL("/"))
+	AssertStatus(t, bow, http.StatusOK)
+	AssertTitle(t, bow, "Home")
+	AssertContains(t, bow, "Hello")
+	AssertExists(t, bow, "p.intro")
+	AssertNotExists(t, bow, "form")
+
+	if err := bow.GET(srv.URL("/login")); err != nil {
+		t.Fatal(err)
+	}
+	AssertTitle(t, bow, "Logged in")
+	AssertCookie(t, bow, "session", "abc")
+
+	if err := bow.GET(srv.URL("/error")); err != nil {
+		t.Fatal(err)
+	}
+	AssertStatus(t, bow, http.StatusInternalServerError)
+	if err := bow.GET(srv.URL("/slow"), browser.WithTimeout(20*time.Millisecond)); err == nil {
+		t.Error("Expected the slow route to time out")
+	}
+
+	if err := bow.GET(srv.URL("/form")); err != nil {
+		t.Fatal(err)
+	}
+	f, err := bow.Form("form")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Input("name", "Surf")
+	f.Input("email", "surf@example.com")
+	if err := f.Submit(); err != nil {
+		t.Fatal(err)
+	}
+	AssertTitle(t, bow, "Submitted")
+	AssertContains(t, bow, "<dt>email</dt><dd>surf@example.com</dd><dt>name</dt><dd>Surf</dd>")
+
+	last := srv.LastRequest()
+	if last.Method != "POST" || last.Path != "/submit" || last.Form.Get("name") != "Surf" {
+		t.Errorf("Expected the form submission to be recorded, got %+v", last)
+	}
+	if len(last.Cookies) != 1 || last.Cookies[0].Value != "abc" {
+		t.Errorf("Expected the session cookie to be sent, got %v", last.Cookies)
+	}
+	if n := len(srv.Requests()); n != 7 {
+		t.Errorf("Expected 7 requests, got %d", n)
+	}
+
+	rec := &recorder{TB: t}
+	AssertTitle(rec, bow, "Other")
+	AssertStatus(rec, bow, http.StatusNotFound)
+	AssertExists(rec, bow, "table")
+	AssertCookie(rec, bow, "missing", "")
+	if len(rec.errors) != 4 {
+		t.Errorf("Expected 4 failed assertions, got %v", rec.errors)
+	}
+
+	srv.Reset()
+	if err := bow.GET(srv.URL("/")); err != nil {
+		t.Fatal(err)
+	}
+	AssertStatus(t, bow, http.StatusNotFound)
+	if n := len(srv.Requests()); n != 1 {
+		t.Errorf("Expected the requests to be reset, got %d", n)
+	}
+}
@@ -0,0 +1,114 @@
+// Package tor contains helpers for browsing through a local Tor daemon.
+package tor
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+var (
+	// DefaultSocksAddr is the address of the Tor SOCKS port.
+	DefaultSocksAddr = "127.0.0.1:9050"
+
+	// DefaultControlAddr is the address of the Tor control port.
+	DefaultControlAddr = "127.0.0.1:9051"
+
+	// DialTimeout is the time limit for connecting to the control port.
+	DialTimeout = 10 * time.Second
+)
+
+// Config describes how to reach a local Tor daemon.
+type Config struct {
+	// SocksAddr is the address of the SOCKS port. Defaults to DefaultSocksAddr.
+	SocksAddr string
+
+	// ControlAddr is the address of the control port. Defaults to DefaultControlAddr.
+	ControlAddr string
+
+	// ControlPassword is the password set with HashedControlPassword in the torrc.
+	ControlPassword string
+
+	// CookieFile is the path of the control auth cookie, used instead of the
+	// password when set. See the CookieAuthentication torrc option.
+	CookieFile string
+}
+
+// ProxyURL returns the URL used to send requests through the SOCKS port.
+//
+// The socks5h scheme is used so host names are resolved by Tor instead of
+// leaking DNS queries outside the tunnel.
+func (c Config) ProxyURL() string {
+	addr := c.SocksAddr
+	if addr == "" {
+		addr = DefaultSocksAddr
+	}
+	return "socks5h://" + addr
+}
+
+// NewIdentity asks Tor to use new circuits for new connections, which
+// usually gives the browser a new exit IP address.
+//
+// Tor rate limits the signal, so calling it again within about 10 seconds
+// may not change the circuits.
+func (c Config) NewIdentity() error {
+	addr := c.ControlAddr
+	if addr == "" {
+		addr = DefaultControlAddr
+	}
+	conn, err := net.DialTimeout("tcp", addr, DialTimeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	auth, err := c.authentication()
+	if err != nil {
+		return err
+	}
+	if err = command(conn, r, "AUTHENTICATE "+auth); err != nil {
+		return err
+	}
+	if err = command(conn, r, "SIGNAL NEWNYM"); err != nil {
+		return err
+	}
+	return command(conn, r, "QUIT")
+}
+
+// authentication returns the argument of the AUTHENTICATE command.
+func (c Config) authentication() (string, error) {
+	if c.CookieFile != "" {
+		cookie, err := ioutil.ReadFile(c.CookieFile)
+		if err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(cookie), nil
+	}
+	return `"` + strings.Replace(c.ControlPassword, `"`, `\"`, -1) + `"`, nil
+}
+
+// command sends a line to the control port and reads the reply, which must
+// have the 250 status code.
+func command(conn net.Conn, r *bufio.Reader, line string) error {
+	conn.SetDeadline(time.Now().Add(DialTimeout))
+	if _, err := fmt.Fprintf(conn, "%s\r\n", line); err != nil {
+		return err
+	}
+	reply, err := r.ReadString('\n')
+	if err != nil {
+		return err
+	}
+	reply = strings.TrimSpace(reply)
+	if !strings.HasPrefix(reply, "250") {
+		name := strings.SplitN(line, " ", 2)[0]
+		return errors.New("Tor control command %s failed: %s", name, reply)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package tor
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/lostinblue/ut"
+)
+
+// serveControl runs a fake control port which accepts the given password.
+func serveControl(t *testing.T, password string) (string, chan []string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	ut.AssertNil(err)
+	lines := make(chan []string, 1)
+	go func() {
+		defer l.Close()
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		var received []string
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				break
+			}
+			line = strings.TrimSpace(line)
+			received = append(received, line)
+			if strings.HasPrefix(line, "AUTHENTICATE") && line != `AUTHENTICATE "`+password+`"` {
+				conn.Write([]byte("515 Authentication failed\r\n"))
+				break
+			}
+			conn.Write([]byte("250 OK\r\n"))
+			if line == "QUIT" {
+				break
+			}
+		}
+		lines <- received
+	}()
+	return l.Addr().String(), lines
+}
+
+func TestNewIdentity(t *testing.T) {
+	ut.Run(t)
+
+	addr, lines := serveControl(t, "secret")
+	err := Config{ControlAddr: addr, ControlPassword: "secret"}.NewIdentity()
+	ut.AssertNil(err)
+	ut.AssertEquals([]string{`AUTHENTICATE "secret"`, "SIGNAL NEWNYM", "QUIT"}, <-lines)
+
+	addr, lines = serveControl(t, "secret")
+	err = Config{ControlAddr: addr, ControlPassword: "wrong"}.NewIdentity()
+	ut.AssertNotNil(err)
+	<-lines
+}
+
+func TestProxyURL(t *testing.T) {
+	ut.Run(t)
+
+	ut.AssertEquals("socks5h://127.0.0.1:9050", Config{}.ProxyURL())
+	ut.AssertEquals("socks5h://127.0.0.1:9150", Config{SocksAddr: "127.0.0.1:9150"}.ProxyURL())
+}
@@ -0,0 +1,85 @@
+// Package urlnorm normalizes URLs so equivalent URLs compare equal.
+package urlnorm
+
+import (
+	"net/url"
+	"strings"
+)
+
+// TrackingParams are the query parameters removed by Normalize. A name ending
+// with "*" matches every parameter starting with the prefix.
+var TrackingParams = []string{
+	"utm_*",
+	"fbclid",
+	"gclid",
+	"dclid",
+	"msclkid",
+	"mc_cid",
+	"mc_eid",
+	"_ga",
+	"yclid",
+}
+
+// defaultPorts maps schemes to the port used when none is given.
+var defaultPorts = map[string]string{
+	"http":  "80",
+	"https": "443",
+	"ftp":   "21",
+}
+
+// Normalize returns a normalized copy of the given URL.
+//
+// The scheme and host are lowercased, default ports, fragments and tracking
+// query parameters are removed, query parameters are sorted, and an empty
+// path is replaced with "/".
+func Normalize(u *url.URL) *url.URL {
+	n := *u
+	n.Scheme = strings.ToLower(n.Scheme)
+	n.Host = strings.ToLower(n.Host)
+	if port, ok := defaultPorts[n.Scheme]; ok {
+		n.Host = strings.TrimSuffix(n.Host, ":"+port)
+	}
+	if n.Path == "" && n.Opaque == "" {
+		n.Path = "/"
+	}
+	n.Fragment = ""
+	n.RawFragment = ""
+
+	if n.RawQuery != "" {
+		q := n.Query()
+		for name := range q {
+			if IsTrackingParam(name) {
+				q.Del(name)
+			}
+		}
+		n.RawQuery = q.Encode()
+	}
+	n.ForceQuery = false
+
+	return &n
+}
+
+// NormalizeString works just like Normalize, but the argument and return value are strings.
+func NormalizeString(s string) (string, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return "", err
+	}
+	return Normalize(u).String(), nil
+}
+
+// IsTrackingParam returns a boolean value indicating whether the query
+// parameter with the given name matches one of the TrackingParams.
+func IsTrackingParam(name string) bool {
+	name = strings.ToLower(name)
+	for _, p := range TrackingParams {
+		if strings.HasSuffix(p, "*") {
+			if strings.HasPrefix(name, strings.TrimSuffix(p, "*")) {
+				return true
+			}
+		} else if name == p {
+			return true
+		}
+	}
+	return false
+}
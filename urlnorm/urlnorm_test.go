@@ -0,0 +1,37 @@
+package urlnorm
+
+import (
+	"testing"
+
+	"github.com/lostinblue/ut"
+)
+
+func TestNormalizeString(t *testing.T) {
+	ut.Run(t)
+
+	tests := map[string]string{
+		"HTTP://Example.COM":                              "http://example.com/",
+		"http://example.com:80/a":                         "http://example.com/a",
+		"https://example.com:443/a":                       "https://example.com/a",
+		"https://example.com:8443/a":                      "https://example.com:8443/a",
+		"http://example.com/a?b=2&a=1":                    "http://example.com/a?a=1&b=2",
+		"http://example.com/a?utm_source=x&id=5&fbclid=y": "http://example.com/a?id=5",
+		"http://example.com/a?utm_campaign=x":             "http://example.com/a",
+		"http://example.com/a#section":                    "http://example.com/a",
+	}
+	for in, expected := range tests {
+		out, err := NormalizeString(in)
+		ut.AssertNil(err)
+		ut.AssertEquals(expected, out)
+	}
+}
+
+func TestIsTrackingParam(t *testing.T) {
+	ut.Run(t)
+
+	ut.AssertTrue(IsTrackingParam("utm_medium"))
+	ut.AssertTrue(IsTrackingParam("UTM_Source"))
+	ut.AssertTrue(IsTrackingParam("gclid"))
+	ut.AssertFalse(IsTrackingParam("id"))
+	ut.AssertFalse(IsTrackingParam("gclids"))
+}
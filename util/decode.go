@@ -0,0 +1,59 @@
+package util
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/lostinblue/surf/errors"
+	"sigs.k8s.io/yaml"
+)
+
+// Decoders are the functions used to decode the config files of the
+// browser, the profiles and the pipelines, by file extension. JSON, YAML and
+// TOML are supported out of the box, and other formats are loaded by
+// registering their decoders. The YAML and TOML keys are the JSON keys of the
+// configs.
+var Decoders = map[string]func([]byte, interface{}) error{
+	".json": json.Unmarshal,
+	".yaml": unmarshalYAML,
+	".yml":  unmarshalYAML,
+	".toml": unmarshalTOML,
+}
+
+// ReadConfigFile reads the given file, and returns its content and the
+// decoder registered for its extension in Decoders. The kind names the
+// config in the error returned for an unknown extension, eg "profile".
+func ReadConfigFile(file, kind string) ([]byte, func([]byte, interface{}) error, error) {
+	ext := strings.ToLower(filepath.Ext(file))
+	dec, ok := Decoders[ext]
+	if !ok {
+		return nil, nil, errors.New("No decoder registered for '%s' %s files.", ext, kind)
+	}
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	return b, dec, nil
+}
+
+// unmarshalYAML decodes YAML by converting it to JSON, so the JSON tags and
+// the UnmarshalText methods apply.
+func unmarshalYAML(b []byte, v interface{}) error {
+	return yaml.Unmarshal(b, v)
+}
+
+// unmarshalTOML decodes TOML by converting it to JSON, like YAML.
+func unmarshalTOML(b []byte, v interface{}) error {
+	var m map[string]interface{}
+	if err := toml.Unmarshal(b, &m); err != nil {
+		return err
+	}
+	j, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(j, v)
+}
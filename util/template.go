@@ -0,0 +1,55 @@
+package util
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/lostinblue/surf/errors"
+)
+
+// templateParam matches the parameters of URL templates, eg "{id}".
+var templateParam = regexp.MustCompile(`\{(\w+)\}`)
+
+// TemplateParams returns the names of the parameters in a URL template, eg
+// "id" for "users/{id}/posts".
+func TemplateParams(tmpl string) []string {
+	var names []string
+	for _, m := range templateParam.FindAllStringSubmatch(tmpl, -1) {
+		names = append(names, m[1])
+	}
+	return names
+}
+
+// ExpandTemplate substitutes the parameters of a URL template with the given
+// values. Values are query escaped after the "?" of the template, and path
+// escaped before it, so a value may not add path segments.
+//
+// Returns an error when a parameter has no value.
+func ExpandTemplate(tmpl string, params map[string]string) (string, error) {
+	query := strings.Index(tmpl, "?")
+	var b strings.Builder
+	var missing []string
+	last := 0
+	for _, loc := range templateParam.FindAllStringSubmatchIndex(tmpl, -1) {
+		name := tmpl[loc[2]:loc[3]]
+		v, ok := params[name]
+		if !ok {
+			missing = append(missing, name)
+		}
+		if query >= 0 && loc[0] > query {
+			v = url.QueryEscape(v)
+		} else {
+			v = url.PathEscape(v)
+		}
+		b.WriteString(tmpl[last:loc[0]])
+		b.WriteString(v)
+		last = loc[1]
+	}
+	if len(missing) > 0 {
+		return "", errors.New(
+			"Missing values for the template parameters %s.", strings.Join(missing, ", "))
+	}
+	b.WriteString(tmpl[last:])
+	return b.String(), nil
+}
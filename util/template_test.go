@@ -0,0 +1,20 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/lostinblue/ut"
+)
+
+func TestExpandTemplate(t *testing.T) {
+	ut.Run(t)
+
+	ut.AssertEquals([]string{"id", "q"}, TemplateParams("users/{id}/posts?q={q}"))
+
+	u, err := ExpandTemplate("users/{id}/posts?q={q}", map[string]string{"id": "a/b", "q": "x y"})
+	ut.AssertNil(err)
+	ut.AssertEquals("users/a%2Fb/posts?q=x+y", u)
+
+	_, err = ExpandTemplate("users/{id}", nil)
+	ut.AssertNotNil(err)
+}